@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/joho/godotenv"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"the-driver-location-service/config"
+	"the-driver-location-service/internal/adapter/db"
+	"the-driver-location-service/internal/adapter/logging"
+	"the-driver-location-service/internal/application"
+)
+
+// backfill runs a backfill job against the configured database and exits,
+// e.g. go run ./cmd/backfill -job driver_defaults -batch-size 1000 -rate 2
+func main() {
+	if err := godotenv.Load(); err != nil {
+		log.Println(".env file not found or could not be loaded, environment variables will be read from the shell")
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	job := flag.String("job", application.DriverDefaultsBackfill.Name, "backfill job to run")
+	batchSize := flag.Int("batch-size", cfg.Backfill.BatchSize, "documents per batch")
+	rate := flag.Float64("rate", cfg.Backfill.Rate, "batches per second, 0 disables rate limiting")
+	flag.Parse()
+
+	driverRepo, err := db.NewMongoDriverRepository(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize MongoDB repository: %v", err)
+	}
+	defer driverRepo.Close()
+
+	// batch progress is logged by the service as it goes
+	logger, err := logging.NewZapLogger(cfg.Log)
+	if err != nil {
+		log.Fatalf("Failed to create logger: %v", err)
+	}
+	defer logger.Sync()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	service := application.NewBackfillApplicationService(driverRepo, application.BackfillOptions{
+		BatchSize: *batchSize,
+		Rate:      *rate,
+	}, application.DriverDefaultsBackfill, application.ShardKeyBackfill(cfg.Database.DefaultTenant))
+	service.SetLogger(logger)
+
+	progress, err := service.Run(ctx, *job)
+	if err != nil {
+		log.Fatalf("Backfill %s failed after %d documents (last id %q): %v", *job, progress.Scanned, progress.LastID, err)
+	}
+
+	log.Printf("Backfill %s completed. Scanned: %d, Updated: %d", *job, progress.Scanned, progress.Updated)
+
+	if *job == "shard_key" {
+		logShardingSteps(cfg)
+	}
+}
+
+// logShardingSteps prints what is left to shard the drivers collection once the
+// shard key fields are populated, the index was created when the repository started
+func logShardingSteps(cfg *config.Config) {
+	shardKey, err := db.ParseShardKey(cfg.Database.ShardKey)
+	if err != nil || shardKey == db.ShardKeyNone {
+		log.Printf("MONGO_SHARD_KEY is not set, pick hashed_id, geohash or tenant before sharding the drivers collection")
+		return
+	}
+
+	command, err := bson.MarshalExtJSON(shardKey.ShardCollectionCommand(cfg.Database.Database, "drivers"), false, false)
+	if err != nil {
+		log.Fatalf("Failed to encode shardCollection command: %v", err)
+	}
+	log.Printf("Shard key fields populated, shard the collection with the %s strategy from mongosh connected to mongos:", shardKey)
+	log.Printf("  sh.enableSharding(%q)", cfg.Database.Database)
+	log.Printf("  db.adminCommand(%s)", command)
+}
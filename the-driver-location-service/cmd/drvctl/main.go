@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+)
+
+// drvctl is the operator CLI of the driver location platform, e.g.
+// drvctl smoke -driver-url https://drivers.example.com -matching-url https://matching.example.com -junit smoke.xml
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	switch os.Args[1] {
+	case "smoke":
+		os.Exit(runSmoke(os.Args[2:]))
+	case "help", "-h", "--help":
+		usage()
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: drvctl <command> [flags]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "commands:")
+	fmt.Fprintln(os.Stderr, "  smoke   run the end-to-end smoke test against a deployed environment")
+}
+
+func runSmoke(args []string) int {
+	fs := flag.NewFlagSet("smoke", flag.ExitOnError)
+	cfg := SmokeConfig{}
+	fs.StringVar(&cfg.DriverURL, "driver-url", getenvOrDefault("DRIVER_LOCATION_SERVICE_URL", "http://localhost:8087"), "driver location service base URL")
+	fs.StringVar(&cfg.MatchingURL, "matching-url", getenvOrDefault("MATCHING_SERVICE_URL", "http://localhost:8088"), "matching service base URL")
+	fs.StringVar(&cfg.APIKey, "api-key", getenvOrDefault("MATCHING_API_KEY", ""), "API key of the driver location service")
+	fs.StringVar(&cfg.Token, "token", getenvOrDefault("SMOKE_JWT", ""), "rider JWT accepted by the matching service")
+	fs.Float64Var(&cfg.Longitude, "lon", 0.0001, "longitude of the smoke test driver, pick a place without real drivers")
+	fs.Float64Var(&cfg.Latitude, "lat", 0.0001, "latitude of the smoke test driver")
+	fs.Float64Var(&cfg.Radius, "radius", 100, "search and match radius in meters")
+	fs.DurationVar(&cfg.Timeout, "timeout", 10*time.Second, "timeout of every request")
+	junit := fs.String("junit", "", "write a JUnit XML report to this file")
+	fs.Parse(args)
+
+	report := NewSmokeRunner(cfg).Run()
+	report.Print(os.Stdout)
+
+	if *junit != "" {
+		if err := report.WriteJUnit(*junit); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write JUnit report: %v\n", err)
+			return 1
+		}
+	}
+
+	if report.Failures() > 0 {
+		return 1
+	}
+	return 0
+}
+
+func getenvOrDefault(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
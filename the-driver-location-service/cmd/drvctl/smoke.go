@@ -0,0 +1,277 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"the-driver-location-service/internal/domain"
+)
+
+type SmokeConfig struct {
+	DriverURL   string
+	MatchingURL string
+	APIKey      string
+	Token       string
+	Longitude   float64
+	Latitude    float64
+	Radius      float64
+	Timeout     time.Duration
+}
+
+// SmokeStep is the outcome of one step of the smoke test
+type SmokeStep struct {
+	Name     string
+	Duration time.Duration
+	Err      error
+	Skipped  string // reason the step did not run
+}
+
+type SmokeReport struct {
+	Steps    []SmokeStep
+	Duration time.Duration
+}
+
+// SmokeRunner drives the end-to-end flow through the public APIs: create a driver,
+// find it with a nearby search, match a rider next to it through the matching
+// service and delete it again. The cleanup always runs once the driver exists.
+type SmokeRunner struct {
+	config   SmokeConfig
+	client   *http.Client
+	driverID string
+}
+
+func NewSmokeRunner(config SmokeConfig) *SmokeRunner {
+	return &SmokeRunner{
+		config: config,
+		client: &http.Client{Timeout: config.Timeout},
+	}
+}
+
+func (r *SmokeRunner) Run() *SmokeReport {
+	started := time.Now()
+	report := &SmokeReport{}
+	r.driverID = fmt.Sprintf("smoke-%d", started.UnixNano())
+
+	created := report.run("create driver", r.createDriver)
+	if created {
+		report.run("search nearby drivers", r.searchDriver)
+		if r.config.Token == "" {
+			report.skip("match rider", "no rider token, pass -token or SMOKE_JWT")
+		} else {
+			report.run("match rider", r.matchRider)
+		}
+	} else {
+		report.skip("search nearby drivers", "driver was not created")
+		report.skip("match rider", "driver was not created")
+	}
+	// the matching service only reads driver locations, there is no reservation API yet
+	report.skip("reserve driver", "the matching service has no reservation endpoint")
+	if created {
+		report.run("cleanup", r.deleteDriver)
+	} else {
+		report.skip("cleanup", "driver was not created")
+	}
+
+	report.Duration = time.Since(started)
+	return report
+}
+
+func (r *SmokeRunner) createDriver() error {
+	req := domain.CreateDriverRequest{ID: r.driverID, Location: domain.NewPoint(r.config.Longitude, r.config.Latitude)}
+	return r.do(http.MethodPost, r.config.DriverURL+"/api/v1/drivers", req, r.driverHeaders(), http.StatusCreated, nil)
+}
+
+func (r *SmokeRunner) searchDriver() error {
+	req := domain.SearchRequest{Location: domain.NewPoint(r.config.Longitude, r.config.Latitude), Radius: r.config.Radius, Limit: 100}
+	var resp struct {
+		Data struct {
+			Drivers []struct {
+				Driver struct {
+					ID string `json:"id"`
+				} `json:"driver"`
+			} `json:"drivers"`
+		} `json:"data"`
+	}
+	if err := r.do(http.MethodPost, r.config.DriverURL+"/api/v1/drivers/search", req, r.driverHeaders(), http.StatusOK, &resp); err != nil {
+		return err
+	}
+
+	for _, nearby := range resp.Data.Drivers {
+		if nearby.Driver.ID == r.driverID {
+			return nil
+		}
+	}
+	return fmt.Errorf("driver %s not found among %d nearby drivers", r.driverID, len(resp.Data.Drivers))
+}
+
+func (r *SmokeRunner) matchRider() error {
+	req := map[string]interface{}{
+		"location": map[string]interface{}{
+			"type":        "Point",
+			"coordinates": []float64{r.config.Longitude, r.config.Latitude},
+		},
+		"radius": r.config.Radius,
+	}
+	headers := map[string]string{"Authorization": "Bearer " + r.config.Token}
+	var resp struct {
+		Data struct {
+			Driver string `json:"driver"`
+		} `json:"data"`
+	}
+	if err := r.do(http.MethodPost, r.config.MatchingURL+"/api/v1/match", req, headers, http.StatusOK, &resp); err != nil {
+		return err
+	}
+
+	if resp.Data.Driver != r.driverID {
+		return fmt.Errorf("matched driver %q instead of %s, is the smoke location free of real drivers?", resp.Data.Driver, r.driverID)
+	}
+	return nil
+}
+
+func (r *SmokeRunner) deleteDriver() error {
+	return r.do(http.MethodDelete, r.config.DriverURL+"/api/v1/drivers/"+r.driverID, nil, r.driverHeaders(), http.StatusOK, nil)
+}
+
+func (r *SmokeRunner) driverHeaders() map[string]string {
+	return map[string]string{"X-API-KEY": r.config.APIKey}
+}
+
+func (r *SmokeRunner) do(method, url string, body interface{}, headers map[string]string, expected int, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s failed: %w", method, url, err)
+	}
+	defer resp.Body.Close()
+
+	payload, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != expected {
+		return fmt.Errorf("%s %s returned %d, expected %d: %s", method, url, resp.StatusCode, expected, strings.TrimSpace(string(payload)))
+	}
+
+	if out != nil {
+		if err := json.Unmarshal(payload, out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
+
+func (r *SmokeReport) run(name string, step func() error) bool {
+	started := time.Now()
+	err := step()
+	r.Steps = append(r.Steps, SmokeStep{Name: name, Duration: time.Since(started), Err: err})
+	return err == nil
+}
+
+func (r *SmokeReport) skip(name, reason string) {
+	r.Steps = append(r.Steps, SmokeStep{Name: name, Skipped: reason})
+}
+
+func (r *SmokeReport) Failures() int {
+	failures := 0
+	for _, step := range r.Steps {
+		if step.Err != nil {
+			failures++
+		}
+	}
+	return failures
+}
+
+func (r *SmokeReport) Print(w io.Writer) {
+	for _, step := range r.Steps {
+		switch {
+		case step.Skipped != "":
+			fmt.Fprintf(w, "SKIP %s: %s\n", step.Name, step.Skipped)
+		case step.Err != nil:
+			fmt.Fprintf(w, "FAIL %s (%s): %v\n", step.Name, step.Duration.Round(time.Millisecond), step.Err)
+		default:
+			fmt.Fprintf(w, "PASS %s (%s)\n", step.Name, step.Duration.Round(time.Millisecond))
+		}
+	}
+	fmt.Fprintf(w, "%d steps, %d failed (%s)\n", len(r.Steps), r.Failures(), r.Duration.Round(time.Millisecond))
+}
+
+type junitTestSuite struct {
+	XMLName  xml.Name        `xml:"testsuite"`
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name    string        `xml:"name,attr"`
+	Class   string        `xml:"classname,attr"`
+	Time    string        `xml:"time,attr"`
+	Failure *junitMessage `xml:"failure,omitempty"`
+	Skipped *junitMessage `xml:"skipped,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+}
+
+// JUnit renders the report in the JUnit XML format CI systems understand
+func (r *SmokeReport) JUnit() ([]byte, error) {
+	suite := junitTestSuite{
+		Name:     "smoke",
+		Tests:    len(r.Steps),
+		Failures: r.Failures(),
+		Time:     fmt.Sprintf("%.3f", r.Duration.Seconds()),
+	}
+	for _, step := range r.Steps {
+		tc := junitTestCase{Name: step.Name, Class: "drvctl.smoke", Time: fmt.Sprintf("%.3f", step.Duration.Seconds())}
+		if step.Err != nil {
+			tc.Failure = &junitMessage{Message: step.Err.Error()}
+		}
+		if step.Skipped != "" {
+			suite.Skipped++
+			tc.Skipped = &junitMessage{Message: step.Skipped}
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+
+	out, err := xml.MarshalIndent(suite, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+func (r *SmokeReport) WriteJUnit(path string) error {
+	out, err := r.JUnit()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, out, 0o644)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeEnvironment serves the driver location and matching endpoints the smoke test uses,
+// the match returns matchedDriver or the last created driver when it is empty
+func fakeEnvironment(t *testing.T, matchedDriver string) (*httptest.Server, *[]string) {
+	var calls []string
+	created := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v1/drivers":
+			assert.Equal(t, "secret", r.Header.Get("X-API-KEY"))
+			var body struct {
+				ID string `json:"id"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			created = body.ID
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"success":true}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v1/drivers/search":
+			w.Write([]byte(`{"success":true,"data":{"drivers":[{"driver":{"id":"` + created + `"},"distance":1}],"count":1}}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v1/match":
+			assert.Equal(t, "Bearer rider-token", r.Header.Get("Authorization"))
+			driver := matchedDriver
+			if driver == "" {
+				driver = created
+			}
+			w.Write([]byte(`{"success":true,"data":{"driver":"` + driver + `","rider":"r1","distance":1}}`))
+		case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/api/v1/drivers/"):
+			w.Write([]byte(`{"success":true}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func smokeConfig(url string) SmokeConfig {
+	return SmokeConfig{DriverURL: url, MatchingURL: url, APIKey: "secret", Token: "rider-token", Radius: 100, Timeout: time.Second}
+}
+
+// TestSmokeRunner_Run tests the smoke flow against healthy services
+// Expected: Should pass every step, skip the reservation and write a JUnit report without failures
+func TestSmokeRunner_Run(t *testing.T) {
+	server, calls := fakeEnvironment(t, "")
+
+	report := NewSmokeRunner(smokeConfig(server.URL)).Run()
+	assert.Equal(t, 0, report.Failures())
+	require.Len(t, report.Steps, 5)
+	assert.Equal(t, "reserve driver", report.Steps[3].Name)
+	assert.NotEmpty(t, report.Steps[3].Skipped)
+	assert.Equal(t, "DELETE", strings.Fields((*calls)[len(*calls)-1])[0])
+
+	out, err := report.JUnit()
+	require.NoError(t, err)
+	assert.Contains(t, string(out), `<testsuite name="smoke" tests="5" failures="0" skipped="1"`)
+}
+
+// TestSmokeRunner_Run_WrongMatch tests a match that returns another driver
+// Expected: Should fail the match step, still clean up the driver and report the failure in JUnit
+func TestSmokeRunner_Run_WrongMatch(t *testing.T) {
+	server, calls := fakeEnvironment(t, "real-driver")
+
+	report := NewSmokeRunner(smokeConfig(server.URL)).Run()
+	assert.Equal(t, 1, report.Failures())
+	assert.Contains(t, report.Steps[2].Err.Error(), "real-driver")
+	assert.Contains(t, (*calls)[len(*calls)-1], "DELETE /api/v1/drivers/smoke-")
+
+	out, err := report.JUnit()
+	require.NoError(t, err)
+	assert.Contains(t, string(out), `<failure message=`)
+}
+
+// TestSmokeRunner_Run_CreateFails tests an unreachable driver location service
+// Expected: Should fail the create step and skip every step that needs the driver
+func TestSmokeRunner_Run_CreateFails(t *testing.T) {
+	report := NewSmokeRunner(smokeConfig("http://127.0.0.1:1")).Run()
+	assert.Equal(t, 1, report.Failures())
+	for _, step := range report.Steps[1:] {
+		assert.NotEmpty(t, step.Skipped, step.Name)
+	}
+}
@@ -2,26 +2,36 @@ package main
 
 import (
 	"context"
-	"fmt"
 	"log"
 	"net/http"
 	"os"
-	"os/exec"
 	"os/signal"
 	"syscall"
 	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/redis/go-redis/v9"
 
 	"the-driver-location-service/config"
-	_ "the-driver-location-service/docs"
+	"the-driver-location-service/docs"
 	"the-driver-location-service/internal/adapter/cache"
 	"the-driver-location-service/internal/adapter/db"
+	"the-driver-location-service/internal/adapter/event"
+	"the-driver-location-service/internal/adapter/featureflag"
 	httpAdapter "the-driver-location-service/internal/adapter/http"
+	"the-driver-location-service/internal/adapter/logging"
+	"the-driver-location-service/internal/adapter/mapmatching"
 	"the-driver-location-service/internal/adapter/middleware"
+	"the-driver-location-service/internal/adapter/ratelimit"
+	"the-driver-location-service/internal/adapter/replication"
+	"the-driver-location-service/internal/adapter/webhook"
 	"the-driver-location-service/internal/application"
+	"the-driver-location-service/internal/buildinfo"
+	"the-driver-location-service/internal/domain"
+	"the-driver-location-service/internal/importer"
 	"the-driver-location-service/internal/ports/primary"
 	"the-driver-location-service/internal/ports/secondary"
+	"the-driver-location-service/internal/startup"
 )
 
 // @title           Driver Location Service API
@@ -32,93 +42,419 @@ import (
 // @name X-API-KEY
 // @description Type X-API-KEY followed by a space and API key.
 func main() {
-	if err := godotenv.Load(); err != nil {
-		log.Println(".env file not found or could not be loaded, environment variables will be read from the shell")
-	}
+	envErr := godotenv.Load()
 
+	// the logger is configured too, failures before it exists go to the standard logger
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
+	logger, err := logging.NewZapLogger(cfg.Log)
+	if err != nil {
+		log.Fatalf("Failed to create logger: %v", err)
+	}
+	defer logger.Sync()
+
+	ctx := context.Background()
+	if envErr != nil {
+		logger.Info(ctx, ".env file not found or could not be loaded, environment variables will be read from the shell")
+	}
 
-	driverRepo, err := db.NewMongoDriverRepository(cfg)
+	// a shutdown while dependencies are still coming up stops waiting for them
+	startupCtx, stopStartup := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	startupOptions := startup.Options{
+		MaxAttempts:    cfg.Startup.MaxAttempts,
+		InitialBackoff: cfg.Startup.InitialBackoff,
+		MaxBackoff:     cfg.Startup.MaxBackoff,
+		Logger:         logger,
+	}
+
+	driverRepo, err := startup.Wait(startupCtx, "MongoDB", startupOptions, func() (*db.MongoDriverRepository, error) {
+		return db.NewMongoDriverRepository(cfg)
+	})
 	if err != nil {
-		log.Fatalf("Failed to initialize MongoDB repository: %v", err)
+		logger.Fatal(ctx, "failed to initialize MongoDB repository", "error", err)
 	}
 
 	var driverCache secondary.DriverCache
+	// pendingCache is set while the instance runs without the driver cache,
+	// Redis being down at startup only costs the cache until it comes back
+	var pendingCache *cache.OptionalDriverCache
 
-	redisClient, err := cache.NewRedisClient(cfg.Redis)
+	redisClient, err := startup.Wait(startupCtx, "Redis", startupOptions, func() (*redis.Client, error) {
+		return cache.NewRedisClient(cfg.Redis)
+	})
 	if err != nil {
-		log.Fatalf("Warning: Failed to connect to Redis: %v", err)
+		if cfg.Search.Backend == "redis" {
+			logger.Fatal(ctx, "failed to connect to Redis, the redis search backend needs it", "error", err)
+		}
+		logger.Warn(ctx, "Redis is not available, running without the driver cache until it is", "error", err)
+		redisClient = cache.OpenRedisClient(cfg.Redis)
+		pendingCache = cache.NewOptionalDriverCache()
 	} else {
-		log.Println("Connected to Redis successfully")
-		driverCache = cache.NewRedisDriverCache(redisClient)
+		logger.Info(ctx, "connected to Redis")
+	}
+	redisCache := cache.NewRedisDriverCache(redisClient)
+	redisCache.SetTTLJitter(cfg.Redis.TTLJitter)
+	driverCache = redisCache
+	if pendingCache != nil {
+		driverCache = pendingCache
+	}
+	if !cfg.Redis.DriverCacheEnabled {
+		logger.Info(ctx, "driver cache is disabled, driver lookups read MongoDB")
+		driverCache, pendingCache = nil, nil
+	}
+	defer func() {
+		if err := redisClient.Close(); err != nil {
+			logger.Error(ctx, "failed to close Redis connection", "error", err)
+		}
+	}()
+	stopStartup()
+
+	flagService := application.NewFeatureFlagApplicationService(newFeatureFlagProvider(ctx, cfg, redisClient, logger), cfg.Environment)
+	flagService.SetLogger(logger)
+	if err := flagService.Refresh(ctx); err != nil {
+		logger.Warn(ctx, "failed to load feature flags", "error", err)
+	}
+	flagCtx, stopFlagRefresh := context.WithCancel(context.Background())
+	defer stopFlagRefresh()
+	flagService.Start(flagCtx, cfg.FeatureFlags.RefreshInterval)
+
+	readOnlyService := application.NewReadOnlyApplicationService(flagService)
+	readOnlyService.SetLogger(logger)
+	if cfg.Server.ReadOnly {
+		readOnlyService.SetReadOnly(true, "READ_ONLY is set")
+	}
+
+	// writes and searches go through the redis geo index when it is the search
+	// backend, everything else reads mongo directly
+	var searchRepo secondary.DriverRepository = driverRepo
+	var inactivityStore secondary.DriverInactivityStore = driverRepo
+	var replicaStore secondary.DriverReplicaStore = driverRepo
+	geoCtx, stopGeoRebuild := context.WithCancel(context.Background())
+	defer stopGeoRebuild()
+	if cfg.Search.Backend == "redis" {
+		geoRepo := cache.NewRedisGeoDriverRepository(redisClient, driverRepo)
+		geoRepo.SetMaxLocationAge(cfg.Search.MaxLocationAge)
+		geoRepo.SetHeartbeatTimeout(cfg.Search.HeartbeatTimeout)
+		geoRepo.SetLogger(logger)
+		searchRepo, inactivityStore, replicaStore = geoRepo, geoRepo, geoRepo
+		go func() {
+			if err := geoRepo.Rebuild(geoCtx); err != nil {
+				logger.Warn(ctx, "failed to rebuild the redis geo index, nearby searches stay on MongoDB", "error", err)
+			}
+		}()
+	}
+
+	appService := application.NewDriverApplicationService(searchRepo, driverCache)
+	appService.SetFeatureFlags(flagService)
+	appService.SetLogger(logger)
+	appService.SetCellCountLevel(cfg.Cells.CountLevel)
+	appService.SetMaxSearchRadius(cfg.Search.MaxRadius)
+	appService.SetDefaultSearchRadius(cfg.Search.DefaultRadius)
+	appService.SetSearchLimits(cfg.Search.DefaultLimit, cfg.Search.MaxLimit)
+	appService.SetCacheTTL(cfg.Redis.DriverCacheTTL)
+	appService.SetNotFoundCacheTTL(cfg.Redis.NotFoundTTL)
+	if cfg.Search.MaxLocationAge > 0 {
+		logger.Info(ctx, "leaving drivers without location updates out of searches", "max_location_age", cfg.Search.MaxLocationAge)
+	}
+
+	matcher, err := mapmatching.NewFromConfig(cfg.MapMatching)
+	if err != nil {
+		logger.Fatal(ctx, "failed to configure map matching", "error", err)
+	}
+	if matcher != nil {
+		logger.Info(ctx, "snapping location updates to roads", "provider", cfg.MapMatching.Provider)
+		appService.SetMapMatcher(matcher)
+	}
+
+	var eventPublisher secondary.DriverEventPublisher
+	if len(cfg.Events.KafkaBrokers) > 0 {
+		publisher := event.NewKafkaDriverEventPublisher(cfg.Events.KafkaBrokers, cfg.Events.Topic)
+		publisher.SetLogger(logger)
+		defer func() {
+			if err := publisher.Close(); err != nil {
+				logger.Error(ctx, "failed to flush driver events", "error", err)
+			}
+		}()
+		logger.Info(ctx, "publishing driver events to Kafka", "topic", cfg.Events.Topic)
+		appService.SetEventPublisher(publisher)
+		eventPublisher = publisher
+	}
+
+	replicationCtx, stopReplication := context.WithCancel(context.Background())
+	defer stopReplication()
+	if cfg.Replication.TargetURL != "" {
+		replicator := replication.NewHTTPReplicator(replication.Config{
+			TargetURL:     cfg.Replication.TargetURL,
+			APIKey:        cfg.Replication.APIKey,
+			BatchSize:     cfg.Replication.BatchSize,
+			QueueSize:     cfg.Replication.QueueSize,
+			FlushInterval: cfg.Replication.FlushInterval,
+			MaxRetries:    cfg.Replication.MaxRetries,
+			RetryBackoff:  cfg.Replication.RetryBackoff,
+			Timeout:       cfg.Replication.Timeout,
+			Logger:        logger,
+		})
 		defer func() {
-			if err := redisClient.Close(); err != nil {
-				log.Printf("Error closing Redis connection: %v", err)
+			stopReplication()
+			if err := replicator.Close(); err != nil {
+				logger.Error(ctx, "failed to flush replicated driver changes", "error", err)
 			}
 		}()
+		appService.SetReplicator(replicator)
+
+		if cfg.Replication.ReconcileInterval > 0 {
+			reconcileService := application.NewReplicationReconcileService(driverRepo, replicator, application.ReplicationReconcileOptions{
+				Interval:  cfg.Replication.ReconcileInterval,
+				BatchSize: cfg.Replication.ReconcileBatchSize,
+			})
+			reconcileService.SetLogger(logger)
+			reconcileService.Start(replicationCtx)
+		}
+		logger.Info(ctx, "replicating driver changes to the standby region", "target", cfg.Replication.TargetURL, "reconcile_interval", cfg.Replication.ReconcileInterval)
 	}
+	var driverService primary.DriverService = appService
 
-	var driverService primary.DriverService = application.NewDriverApplicationService(driverRepo, driverCache)
+	inactivityCtx, stopInactivity := context.WithCancel(context.Background())
+	defer stopInactivity()
+	if cfg.Inactivity.Enabled {
+		var notifier secondary.InactivityNotifier
+		if len(cfg.Inactivity.WebhookURLs) > 0 {
+			dispatcher := webhook.NewInactivityDispatcher(webhook.Config{
+				URLs:          cfg.Inactivity.WebhookURLs,
+				BatchSize:     cfg.Inactivity.WebhookBatchSize,
+				FlushInterval: cfg.Inactivity.WebhookFlushInterval,
+				MaxRetries:    cfg.Inactivity.WebhookMaxRetries,
+				RetryBackoff:  cfg.Inactivity.WebhookRetryBackoff,
+				Timeout:       cfg.Inactivity.WebhookTimeout,
+				Logger:        logger,
+			})
+			defer func() {
+				stopInactivity()
+				if err := dispatcher.Close(); err != nil {
+					logger.Error(ctx, "failed to flush inactivity webhooks", "error", err)
+				}
+			}()
+			notifier = dispatcher
+		}
 
-	go func() {
-		if err := runDataImport(); err != nil {
-			log.Printf("Warning: Data import failed: %v", err)
-			log.Println("Continuing without imported data...")
+		inactivityService := application.NewInactivityApplicationService(inactivityStore, driverCache, notifier, application.InactivityOptions{
+			Threshold: cfg.Inactivity.Threshold,
+			Interval:  cfg.Inactivity.CheckInterval,
+			BatchSize: cfg.Inactivity.BatchSize,
+		})
+		inactivityService.SetLogger(logger)
+		inactivityService.SetReadOnlyMode(readOnlyService)
+		if eventPublisher != nil {
+			inactivityService.SetEventPublisher(eventPublisher)
 		}
-	}()
+		logger.Info(ctx, "taking drivers offline without location updates", "threshold", cfg.Inactivity.Threshold)
+		inactivityService.Start(inactivityCtx)
+	}
+
+	importCtx, stopImport := context.WithCancel(context.Background())
+	defer stopImport()
+	if cfg.Import.OnStartup {
+		go runDataImport(importCtx, searchRepo, driverRepo, cfg.Import, logger)
+	}
 
 	authConfig := middleware.AuthConfig{
-		MatchingAPIKey: cfg.Auth.MatchingAPIKey,
+		MatchingAPIKey:     cfg.Auth.MatchingAPIKey,
+		MatchingAPIKeyHash: cfg.Auth.MatchingAPIKeyHash,
+		Tenants:            cfg.Auth.Tenants,
+		SigningSecret:      cfg.Auth.SigningSecret,
+		RequireSignature:   cfg.Auth.RequireSignature,
+		SignatureTolerance: cfg.Auth.SignatureTolerance,
+		MaxSignedBodyBytes: cfg.Auth.MaxSignedBodyBytes,
+		Logger:             logger,
+	}
+
+	deprecatedRoutes, err := middleware.ParseDeprecatedRoutes(cfg.Deprecation.Routes, cfg.Deprecation.Link)
+	if err != nil {
+		logger.Fatal(ctx, "failed to configure deprecated routes", "error", err)
+	}
+
+	requestValidator, err := httpAdapter.NewRequestValidator(docs.SwaggerInfo.ReadDoc(), authConfig)
+	if err != nil {
+		logger.Fatal(ctx, "failed to load the request validation of the API documentation", "error", err)
 	}
 
 	router := httpAdapter.NewRouter(driverService, authConfig)
+	if cfg.RateLimit.Rate > 0 {
+		var limiter secondary.RateLimiter = ratelimit.NewMemoryLimiter()
+		if cfg.RateLimit.Backend == "redis" {
+			limiter = ratelimit.NewRedisLimiter(redisClient)
+		}
+		router.SetupRateLimit(middleware.RateLimitConfig{
+			Limiter: limiter,
+			Limit:   domain.RateLimit{Rate: cfg.RateLimit.Rate, Burst: cfg.RateLimit.Burst},
+			Logger:  logger,
+		})
+		logger.Info(ctx, "rate limiting API keys", "rate", cfg.RateLimit.Rate, "burst", cfg.RateLimit.Burst, "backend", cfg.RateLimit.Backend)
+	}
+	if cfg.LoadShedding.P99Threshold > 0 {
+		router.SetupLoadShedding(middleware.LoadSheddingConfig{
+			Threshold:  cfg.LoadShedding.P99Threshold,
+			Window:     cfg.LoadShedding.Window,
+			MinSamples: cfg.LoadShedding.MinSamples,
+			Logger:     logger,
+		})
+		logger.Info(ctx, "shedding low priority requests while the handler latency is high", "p99_threshold", cfg.LoadShedding.P99Threshold, "window", cfg.LoadShedding.Window)
+	}
+	router.SetupRequestValidation(requestValidator)
+	router.SetupDeprecations(deprecatedRoutes)
+	router.SetResponseStreamThreshold(cfg.Server.ResponseStreamThreshold)
+	backfillService := application.NewBackfillApplicationService(driverRepo, application.BackfillOptions{
+		BatchSize: cfg.Backfill.BatchSize,
+		Rate:      cfg.Backfill.Rate,
+	}, application.DriverDefaultsBackfill, application.ShardKeyBackfill(cfg.Database.DefaultTenant))
+	backfillService.SetLogger(logger)
+	backfillCtx, stopBackfill := context.WithCancel(context.Background())
+	defer stopBackfill()
+	backfillService.SetContext(backfillCtx)
+	router.SetupAdminRoutes(httpAdapter.NewAdminHandler(flagService, backfillService))
+	router.SetupReadOnlyMode(httpAdapter.NewReadOnlyHandler(readOnlyService))
+	replicationService := application.NewReplicationApplicationService(replicaStore, driverCache)
+	replicationService.SetLogger(logger)
+	router.SetupReplicationRoute(httpAdapter.NewReplicationHandler(replicationService))
+	router.SetupReconcileRoute(httpAdapter.NewReconcileHandler(application.NewReconcileApplicationService(driverRepo)))
+	router.SetupDuplicateRoute(httpAdapter.NewDuplicateHandler(application.NewDuplicateApplicationService(driverRepo, driverService)))
+	outcomeService := application.NewOutcomeApplicationService(driverRepo, driverCache)
+	outcomeService.SetLogger(logger)
+	router.SetupOutcomeRoute(httpAdapter.NewOutcomeHandler(outcomeService))
+	streamHandler := httpAdapter.NewLocationStreamHandler(driverService, httpAdapter.LocationStreamConfig{
+		MinInterval: cfg.Stream.MinInterval,
+		IdleTimeout: cfg.Stream.IdleTimeout,
+	})
+	streamHandler.SetLogger(logger)
+	router.SetupLocationStreamRoute(streamHandler)
 
-	server := &http.Server{
-		Addr:         cfg.GetAddress(),
-		Handler:      router.GetEcho(),
-		ReadTimeout:  cfg.Server.ReadTimeout,
-		WriteTimeout: cfg.Server.WriteTimeout,
-		IdleTimeout:  cfg.Server.IdleTimeout,
+	warmupCache := driverCache
+	if !cfg.Warmup.Enabled {
+		warmupCache = nil
 	}
+	warmupService := application.NewWarmupApplicationService(driverRepo, warmupCache, application.WarmupOptions{
+		Window:    cfg.Warmup.Window,
+		BatchSize: cfg.Warmup.BatchSize,
+		CacheTTL:  cfg.Warmup.CacheTTL,
+	})
+	warmupService.SetLogger(logger)
+	warmupCtx, stopWarmup := context.WithCancel(context.Background())
+	defer stopWarmup()
+	if pendingCache == nil {
+		warmupService.Start(warmupCtx)
+	} else {
+		// the cache comes on once Redis answers, the warmup then fills it
+		go func() {
+			_, err := startup.Reconnect(warmupCtx, "Redis", startupOptions, func() (*redis.Client, error) {
+				pingCtx, cancel := context.WithTimeout(warmupCtx, cfg.Redis.Timeout)
+				defer cancel()
+				return redisClient, redisClient.Ping(pingCtx).Err()
+			})
+			if err != nil {
+				return
+			}
+			pendingCache.Enable(redisCache)
+			logger.Info(ctx, "reconnected to Redis, the driver cache is on")
+			warmupService.Start(warmupCtx)
+		}()
+	}
+	readinessHandler := httpAdapter.NewReadinessHandler(warmupService)
+	// without the redis geo index a Redis outage only costs the cache
+	readinessHandler.SetDependencies(cfg.Server.HealthCheckTimeout,
+		httpAdapter.DependencyCheck{Name: "mongodb", Required: true, Ping: driverRepo.Ping},
+		httpAdapter.DependencyCheck{Name: "redis", Required: cfg.Search.Backend == "redis", Ping: func(ctx context.Context) error {
+			return redisClient.Ping(ctx).Err()
+		}},
+	)
+	router.SetupReadinessRoute(readinessHandler)
+
+	server := newHTTPServer(cfg, router.GetEcho())
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 
 	go func() {
-		log.Printf("Starting server on %s", cfg.GetAddress())
+		logger.Info(ctx, "starting server", "address", cfg.GetAddress(), "version", buildinfo.Version)
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Server failed to start: %v", err)
+			logger.Fatal(ctx, "server failed to start", "error", err)
 		}
 	}()
 
 	<-quit
-	log.Println("Shutting down server...")
+	logger.Info(ctx, "shutting down server")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	shutdownCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	if err := server.Shutdown(ctx); err != nil {
-		log.Printf("Server forced to shutdown: %v", err)
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logger.Error(ctx, "server forced to shutdown", "error", err)
 	}
 
-	log.Println("Server exited gracefully")
+	logger.Info(ctx, "server exited gracefully")
 }
 
-func runDataImport() error {
-	log.Println("Starting data import...")
+func newFeatureFlagProvider(ctx context.Context, cfg *config.Config, redisClient *redis.Client, logger secondary.Logger) secondary.FeatureFlagProvider {
+	switch cfg.FeatureFlags.Source {
+	case "file":
+		return featureflag.NewFileProvider(cfg.FeatureFlags.FilePath)
+	case "redis":
+		if redisClient != nil {
+			return featureflag.NewRedisProvider(redisClient, cfg.FeatureFlags.RedisKey)
+		}
+		logger.Warn(ctx, "redis feature flags requested without redis, falling back to environment flags")
+	}
+	return featureflag.NewEnvProvider(cfg.FeatureFlags.Flags)
+}
+
+func newHTTPServer(cfg *config.Config, handler http.Handler) *http.Server {
+	server := &http.Server{
+		Addr:         cfg.GetAddress(),
+		Handler:      handler,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
+	}
 
-	cmd := exec.Command("./importer")
-	cmd.Dir = "/app" // Set working directory to app directory (containerized)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	if cfg.Server.HTTP2Enabled {
+		protocols.SetUnencryptedHTTP2(true)
+		server.HTTP2 = &http.HTTP2Config{
+			MaxConcurrentStreams: cfg.Server.MaxConcurrentStreams,
+		}
+	}
+	server.Protocols = protocols
+	server.SetKeepAlivesEnabled(cfg.Server.KeepAlivesEnabled)
+
+	return server
+}
+
+func runDataImport(ctx context.Context, repo secondary.DriverRepository, stager secondary.DriverImportStager, cfg config.ImportConfig, logger secondary.Logger) {
+	logger.Info(ctx, "starting data import", "file", cfg.FilePath, "staged", cfg.Staged)
 
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to run importer: %v", err)
+	options := importer.Options{
+		FilePath:  cfg.FilePath,
+		BatchSize: cfg.BatchSize,
+		Workers:   cfg.Workers,
+		Logger:    logger,
+	}
+	dataImporter := importer.New(repo, options)
+	if cfg.Staged {
+		stage, err := stager.StageImport(ctx)
+		if err != nil {
+			logger.Warn(ctx, "data import failed", "error", err)
+			return
+		}
+		dataImporter = importer.NewStaged(stage, options)
+	}
+
+	result, err := dataImporter.Run(ctx)
+	if err != nil {
+		logger.Warn(ctx, "data import failed, continuing without imported data", "error", err)
+		return
 	}
 
-	log.Println("Data import completed successfully.")
-	return nil
+	logger.Info(ctx, "data import completed", "requested", result.RequestedCount, "created", result.CreatedCount, "errors", result.ErrorCount)
 }
@@ -1,25 +1,119 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
 type Config struct {
-	Server   ServerConfig   `json:"server"`
-	Database DatabaseConfig `json:"database"`
-	Redis    RedisConfig    `json:"redis"`
-	Auth     AuthConfig     `json:"auth"`
+	Environment  string             `json:"environment"`
+	Server       ServerConfig       `json:"server"`
+	Database     DatabaseConfig     `json:"database"`
+	Redis        RedisConfig        `json:"redis"`
+	Auth         AuthConfig         `json:"auth"`
+	FeatureFlags FeatureFlagsConfig `json:"feature_flags"`
+	Backfill     BackfillConfig     `json:"backfill"`
+	MapMatching  MapMatchingConfig  `json:"map_matching"`
+	Warmup       WarmupConfig       `json:"warmup"`
+	Stream       StreamConfig       `json:"stream"`
+	Import       ImportConfig       `json:"import"`
+	Events       EventsConfig       `json:"events"`
+	Inactivity   InactivityConfig   `json:"inactivity"`
+	Replication  ReplicationConfig  `json:"replication"`
+	Startup      StartupConfig      `json:"startup"`
+	Cells        CellsConfig        `json:"cells"`
+	Search       SearchConfig       `json:"search"`
+	Deprecation  DeprecationConfig  `json:"deprecation"`
+	RateLimit    RateLimitConfig    `json:"rate_limit"`
+	LoadShedding LoadSheddingConfig `json:"load_shedding"`
+	Log          LogConfig          `json:"log"`
+}
+
+// LogConfig sets the level of the entries written and their format, json lines
+// for log collectors or console for reading them in a terminal
+type LogConfig struct {
+	Level  string `json:"level"`  // debug, info, warn or error
+	Format string `json:"format"` // json or console
+}
+
+// DeprecationConfig lists the routes being retired as "METHOD PATH|DEPRECATED_AT[|SUNSET]",
+// their responses carry Deprecation and Sunset headers and their calls are counted per API key
+type DeprecationConfig struct {
+	Routes []string `json:"routes"`
+	Link   string   `json:"link"` // migration guide sent with every deprecated route
+}
+
+// RateLimitConfig lets every API key make Rate requests per second with bursts
+// of Burst, 0 turns the limit off. The buckets are kept per instance in memory
+// or shared by the instances in redis.
+type RateLimitConfig struct {
+	Rate    float64 `json:"rate"`
+	Burst   int     `json:"burst"`
+	Backend string  `json:"backend"` // memory or redis
+}
+
+// LoadSheddingConfig refuses exports, map views and bulk scans with 503 while
+// the p99 handler latency over Window is above P99Threshold, 0 turns it off
+type LoadSheddingConfig struct {
+	P99Threshold time.Duration `json:"p99_threshold"`
+	Window       time.Duration `json:"window"`
+	MinSamples   int           `json:"min_samples"` // requests needed in the window before the p99 counts
+}
+
+// SearchConfig selects where nearby searches are answered, mongo or a redis GEO
+// index of the available drivers kept next to mongo
+type SearchConfig struct {
+	Backend   string  `json:"backend"`    // mongo or redis
+	MaxRadius float64 `json:"max_radius"` // meters, nearby searches with a larger radius are rejected
+
+	// DefaultRadius is the radius in meters of nearby searches without one, 0
+	// keeps the radius required. Searches without a limit get DefaultLimit
+	// drivers, larger limits than MaxLimit are rejected.
+	DefaultRadius float64 `json:"default_radius"`
+	DefaultLimit  int     `json:"default_limit"`
+	MaxLimit      int     `json:"max_limit"`
+
+	// MaxLocationAge leaves drivers without a location update for longer out of
+	// every search, 0 keeps them until the inactivity check takes them offline
+	MaxLocationAge time.Duration `json:"max_location_age"`
+
+	// HeartbeatTimeout leaves drivers that send heartbeats out of every search
+	// once their last heartbeat or update is older, 0 keeps them
+	HeartbeatTimeout time.Duration `json:"heartbeat_timeout"`
+}
+
+// CellsConfig controls the S2 cell queries, drivers store their leaf cell and
+// cell searches counting drivers group them by cells of CountLevel unless the
+// request asks for another level
+type CellsConfig struct {
+	CountLevel int `json:"count_level"`
+}
+
+// StartupConfig controls waiting for MongoDB and Redis on startup, a failed
+// connection is retried MaxAttempts times with a backoff doubling up to MaxBackoff
+type StartupConfig struct {
+	MaxAttempts    int           `json:"max_attempts"`
+	InitialBackoff time.Duration `json:"initial_backoff"`
+	MaxBackoff     time.Duration `json:"max_backoff"`
 }
 
 type ServerConfig struct {
-	Port         string        `json:"port"`
-	Host         string        `json:"host"`
-	ReadTimeout  time.Duration `json:"read_timeout"`
-	WriteTimeout time.Duration `json:"write_timeout"`
-	IdleTimeout  time.Duration `json:"idle_timeout"`
+	Port                    string        `json:"port"`
+	Host                    string        `json:"host"`
+	ReadTimeout             time.Duration `json:"read_timeout"`
+	WriteTimeout            time.Duration `json:"write_timeout"`
+	IdleTimeout             time.Duration `json:"idle_timeout"`
+	KeepAlivesEnabled       bool          `json:"keep_alives_enabled"`
+	HTTP2Enabled            bool          `json:"http2_enabled"`
+	MaxConcurrentStreams    int           `json:"max_concurrent_streams"`
+	ResponseStreamThreshold int           `json:"response_stream_threshold"` // driver lists longer than this are streamed
+	HealthCheckTimeout      time.Duration `json:"health_check_timeout"`      // bounds each dependency ping of /health/ready
+	ReadOnly                bool          `json:"read_only"`                 // start refusing writes, e.g. to restart during a maintenance
 }
 
 type DatabaseConfig struct {
@@ -28,10 +122,21 @@ type DatabaseConfig struct {
 	ConnectTimeout time.Duration `json:"connect_timeout"`
 	MaxPoolSize    uint64        `json:"max_pool_size"`
 	MinPoolSize    uint64        `json:"min_pool_size"`
+	ShardKey       string        `json:"shard_key"`      // none, hashed_id, geohash or tenant, see db.ShardKey
+	DefaultTenant  string        `json:"default_tenant"` // tenant_id given to drivers without one by the shard_key backfill
 }
 
 type AuthConfig struct {
-	MatchingAPIKey string `json:"matching_api_key"`
+	MatchingAPIKey     string   `json:"matching_api_key"`
+	MatchingAPIKeyHash string   `json:"matching_api_key_hash"` // hex SHA-256 of the key, used instead of the key when set
+	Tenants            []string `json:"tenants"`
+
+	// callers sharing the secret sign their requests with HMAC-SHA256 instead of
+	// sending the API key, RequireSignature refuses unsigned requests
+	SigningSecret      string        `json:"-"`
+	RequireSignature   bool          `json:"require_signature"`
+	SignatureTolerance time.Duration `json:"signature_tolerance"`
+	MaxSignedBodyBytes int64         `json:"max_signed_body_bytes"`
 }
 
 type RedisConfig struct {
@@ -42,16 +147,119 @@ type RedisConfig struct {
 	PoolSize   int           `json:"pool_size"`
 	Timeout    time.Duration `json:"timeout"`
 	Enabled    bool          `json:"enabled"`
+	TTLJitter  float64       `json:"ttl_jitter"` // fraction of a cache TTL taken off at random
+	// NotFoundTTL caches lookups of IDs no driver has for that long, 0 does not cache them
+	NotFoundTTL time.Duration `json:"not_found_ttl"`
+	// DriverCacheEnabled caches drivers by ID in Redis, Redis still serves the
+	// search backend, the rate limiter and the feature flags without it
+	DriverCacheEnabled bool `json:"driver_cache_enabled"`
+	// DriverCacheTTL is how long a driver read or written stays cached
+	DriverCacheTTL time.Duration `json:"driver_cache_ttl"`
+}
+
+type BackfillConfig struct {
+	BatchSize int     `json:"batch_size"`
+	Rate      float64 `json:"rate"` // batches per second
+}
+
+// EventsConfig enables publishing driver events to Kafka, publishing is off without brokers
+type EventsConfig struct {
+	KafkaBrokers []string `json:"kafka_brokers"`
+	Topic        string   `json:"topic"`
+}
+
+// InactivityConfig controls taking drivers offline after Threshold without a location
+// update, the taken offline drivers are posted to WebhookURLs in batches
+type InactivityConfig struct {
+	Enabled              bool          `json:"enabled"`
+	Threshold            time.Duration `json:"threshold"`
+	CheckInterval        time.Duration `json:"check_interval"`
+	BatchSize            int           `json:"batch_size"`
+	WebhookURLs          []string      `json:"webhook_urls"`
+	WebhookBatchSize     int           `json:"webhook_batch_size"`
+	WebhookFlushInterval time.Duration `json:"webhook_flush_interval"`
+	WebhookMaxRetries    int           `json:"webhook_max_retries"`
+	WebhookRetryBackoff  time.Duration `json:"webhook_retry_backoff"`
+	WebhookTimeout       time.Duration `json:"webhook_timeout"`
+}
+
+// ReplicationConfig replicates the driver writes to the driver location service
+// of the standby region at TargetURL, empty turns it off. The changes are posted
+// in batches with APIKey, every ReconcileInterval all drivers are sent to catch
+// the standby up with the changes that were dropped.
+type ReplicationConfig struct {
+	TargetURL          string        `json:"target_url"`
+	APIKey             string        `json:"-"`
+	BatchSize          int           `json:"batch_size"`
+	QueueSize          int           `json:"queue_size"` // changes waiting to be sent, more are dropped
+	FlushInterval      time.Duration `json:"flush_interval"`
+	MaxRetries         int           `json:"max_retries"`
+	RetryBackoff       time.Duration `json:"retry_backoff"`
+	Timeout            time.Duration `json:"timeout"`
+	ReconcileInterval  time.Duration `json:"reconcile_interval"` // 0 turns the reconciliation off
+	ReconcileBatchSize int           `json:"reconcile_batch_size"`
+}
+
+// ImportConfig controls the CSV import, the server runs it on startup when OnStartup is set.
+// A Staged import writes to a staging collection merged into the drivers once the
+// whole file was imported.
+type ImportConfig struct {
+	OnStartup bool   `json:"on_startup"`
+	FilePath  string `json:"file_path"`
+	BatchSize int    `json:"batch_size"`
+	Workers   int    `json:"workers"`
+	Staged    bool   `json:"staged"`
+}
+
+// StreamConfig controls the WebSocket location stream, updates closer together than
+// MinInterval are dropped and connections without updates for IdleTimeout are closed
+type StreamConfig struct {
+	MinInterval time.Duration `json:"min_interval"`
+	IdleTimeout time.Duration `json:"idle_timeout"`
+}
+
+// WarmupConfig controls loading the drivers updated within Window into the cache on startup
+type WarmupConfig struct {
+	Enabled   bool          `json:"enabled"`
+	Window    time.Duration `json:"window"`
+	BatchSize int           `json:"batch_size"`
+	CacheTTL  time.Duration `json:"cache_ttl"`
+}
+
+// MapMatchingConfig enables snapping location updates to roads, the profile is the
+// OSRM profile (driving) or the Valhalla costing (auto)
+type MapMatchingConfig struct {
+	Provider string        `json:"provider"` // none, osrm or valhalla
+	URL      string        `json:"url"`
+	Profile  string        `json:"profile"`
+	Timeout  time.Duration `json:"timeout"`
+}
+
+type FeatureFlagsConfig struct {
+	Source          string        `json:"source"` // env, file or redis
+	Flags           string        `json:"flags"`  // used by the env source
+	FilePath        string        `json:"file_path"`
+	RedisKey        string        `json:"redis_key"`
+	RefreshInterval time.Duration `json:"refresh_interval"`
 }
 
 func LoadConfig() (*Config, error) {
 	config := &Config{
+		Environment: getEnv("ENVIRONMENT", "development"),
 		Server: ServerConfig{
 			Port:         getEnv("PORT", "8080"),
 			Host:         getEnv("HOST", "0.0.0.0"),
 			ReadTimeout:  getDurationEnv("READ_TIMEOUT", 30*time.Second),
 			WriteTimeout: getDurationEnv("WRITE_TIMEOUT", 30*time.Second),
 			IdleTimeout:  getDurationEnv("IDLE_TIMEOUT", 120*time.Second),
+			// the matching service keeps long lived connections open, so we
+			// allow h2c (HTTP/2 without TLS) to multiplex its requests
+			KeepAlivesEnabled:       getBoolEnv("KEEP_ALIVES_ENABLED", true),
+			HTTP2Enabled:            getBoolEnv("HTTP2_ENABLED", true),
+			MaxConcurrentStreams:    getIntEnv("HTTP2_MAX_CONCURRENT_STREAMS", 250),
+			ResponseStreamThreshold: getIntEnv("RESPONSE_STREAM_THRESHOLD", 1000),
+			HealthCheckTimeout:      getDurationEnv("HEALTH_CHECK_TIMEOUT", 2*time.Second),
+			ReadOnly:                getBoolEnv("READ_ONLY", false),
 		},
 		Database: DatabaseConfig{
 			URI:            getEnv("MONGO_URI", "mongodb://localhost:27017"),
@@ -59,18 +267,127 @@ func LoadConfig() (*Config, error) {
 			ConnectTimeout: getDurationEnv("MONGO_CONNECT_TIMEOUT", 10*time.Second),
 			MaxPoolSize:    getUint64Env("MONGO_MAX_POOL_SIZE", 100),
 			MinPoolSize:    getUint64Env("MONGO_MIN_POOL_SIZE", 10),
+			ShardKey:       strings.ToLower(getEnv("MONGO_SHARD_KEY", "none")),
+			DefaultTenant:  getEnv("MONGO_DEFAULT_TENANT", "default"),
 		},
 		Redis: RedisConfig{
-			Address:    getEnv("REDIS_ADDRESS", "localhost:6379"),
-			Password:   getEnv("REDIS_PASSWORD", ""),
-			DB:         getIntEnv("REDIS_DB", 0),
-			MaxRetries: getIntEnv("REDIS_MAX_RETRIES", 3),
-			PoolSize:   getIntEnv("REDIS_POOL_SIZE", 10),
-			Timeout:    getDurationEnv("REDIS_TIMEOUT", 5*time.Second),
-			Enabled:    getBoolEnv("REDIS_ENABLED", true),
+			Address:            getEnv("REDIS_ADDRESS", "localhost:6379"),
+			Password:           getEnv("REDIS_PASSWORD", ""),
+			DB:                 getIntEnv("REDIS_DB", 0),
+			MaxRetries:         getIntEnv("REDIS_MAX_RETRIES", 3),
+			PoolSize:           getIntEnv("REDIS_POOL_SIZE", 10),
+			Timeout:            getDurationEnv("REDIS_TIMEOUT", 5*time.Second),
+			Enabled:            getBoolEnv("REDIS_ENABLED", true),
+			TTLJitter:          getFloatEnv("CACHE_TTL_JITTER", 0.1),
+			NotFoundTTL:        getDurationEnv("CACHE_NOT_FOUND_TTL", 0),
+			DriverCacheEnabled: getBoolEnv("CACHE_DRIVER_ENABLED", true),
+			DriverCacheTTL:     getDurationEnv("CACHE_DRIVER_TTL", time.Minute),
 		},
 		Auth: AuthConfig{
-			MatchingAPIKey: getEnv("MATCHING_API_KEY", "default-matching-api-key"),
+			MatchingAPIKey:     getEnv("MATCHING_API_KEY", "default-matching-api-key"),
+			MatchingAPIKeyHash: strings.ToLower(getEnv("MATCHING_API_KEY_HASH", "")),
+			Tenants:            getSliceEnv("TENANTS", nil),
+			SigningSecret:      getEnv("HMAC_SIGNING_SECRET", ""),
+			RequireSignature:   getBoolEnv("HMAC_REQUIRED", false),
+			SignatureTolerance: getDurationEnv("HMAC_MAX_SKEW", 5*time.Minute),
+			MaxSignedBodyBytes: int64(getIntEnv("HMAC_MAX_BODY_BYTES", 8<<20)),
+		},
+		FeatureFlags: FeatureFlagsConfig{
+			Source:          getEnv("FEATURE_FLAGS_SOURCE", "env"),
+			Flags:           getEnv("FEATURE_FLAGS", ""),
+			FilePath:        getEnv("FEATURE_FLAGS_FILE", "feature_flags.json"),
+			RedisKey:        getEnv("FEATURE_FLAGS_REDIS_KEY", "feature_flags"),
+			RefreshInterval: getDurationEnv("FEATURE_FLAGS_REFRESH_INTERVAL", 30*time.Second),
+		},
+		MapMatching: MapMatchingConfig{
+			Provider: getEnv("MAP_MATCHING_PROVIDER", "none"),
+			URL:      getEnv("MAP_MATCHING_URL", ""),
+			Profile:  getEnv("MAP_MATCHING_PROFILE", ""),
+			Timeout:  getDurationEnv("MAP_MATCHING_TIMEOUT", 2*time.Second),
+		},
+		Events: EventsConfig{
+			KafkaBrokers: getSliceEnv("KAFKA_BROKERS", nil),
+			Topic:        getEnv("DRIVER_EVENTS_TOPIC", "driver-events"),
+		},
+		Inactivity: InactivityConfig{
+			Enabled:              getBoolEnv("INACTIVITY_ENABLED", false),
+			Threshold:            getDurationEnv("INACTIVITY_THRESHOLD", 5*time.Minute),
+			CheckInterval:        getDurationEnv("INACTIVITY_CHECK_INTERVAL", time.Minute),
+			BatchSize:            getIntEnv("INACTIVITY_BATCH_SIZE", 500),
+			WebhookURLs:          getSliceEnv("INACTIVITY_WEBHOOK_URLS", nil),
+			WebhookBatchSize:     getIntEnv("INACTIVITY_WEBHOOK_BATCH_SIZE", 100),
+			WebhookFlushInterval: getDurationEnv("INACTIVITY_WEBHOOK_FLUSH_INTERVAL", 5*time.Second),
+			WebhookMaxRetries:    getIntEnv("INACTIVITY_WEBHOOK_MAX_RETRIES", 3),
+			WebhookRetryBackoff:  getDurationEnv("INACTIVITY_WEBHOOK_RETRY_BACKOFF", time.Second),
+			WebhookTimeout:       getDurationEnv("INACTIVITY_WEBHOOK_TIMEOUT", 5*time.Second),
+		},
+		Replication: ReplicationConfig{
+			TargetURL:          getEnv("REPLICATION_TARGET_URL", ""),
+			APIKey:             getEnv("REPLICATION_API_KEY", ""),
+			BatchSize:          getIntEnv("REPLICATION_BATCH_SIZE", 100),
+			QueueSize:          getIntEnv("REPLICATION_QUEUE_SIZE", 10000),
+			FlushInterval:      getDurationEnv("REPLICATION_FLUSH_INTERVAL", time.Second),
+			MaxRetries:         getIntEnv("REPLICATION_MAX_RETRIES", 3),
+			RetryBackoff:       getDurationEnv("REPLICATION_RETRY_BACKOFF", time.Second),
+			Timeout:            getDurationEnv("REPLICATION_TIMEOUT", 5*time.Second),
+			ReconcileInterval:  getDurationEnv("REPLICATION_RECONCILE_INTERVAL", 15*time.Minute),
+			ReconcileBatchSize: getIntEnv("REPLICATION_RECONCILE_BATCH_SIZE", 500),
+		},
+		Import: ImportConfig{
+			OnStartup: getBoolEnv("IMPORT_ON_STARTUP", true),
+			FilePath:  getEnv("IMPORT_FILE_PATH", "Coordinates.csv"),
+			BatchSize: getIntEnv("IMPORT_BATCH_SIZE", 100),
+			Workers:   getIntEnv("IMPORT_WORKERS", 4),
+			Staged:    getBoolEnv("IMPORT_STAGED", false),
+		},
+		Stream: StreamConfig{
+			MinInterval: getDurationEnv("LOCATION_STREAM_MIN_INTERVAL", time.Second),
+			IdleTimeout: getDurationEnv("LOCATION_STREAM_IDLE_TIMEOUT", time.Minute),
+		},
+		Warmup: WarmupConfig{
+			Enabled:   getBoolEnv("WARMUP_ENABLED", true),
+			Window:    getDurationEnv("WARMUP_WINDOW", 15*time.Minute),
+			BatchSize: getIntEnv("WARMUP_BATCH_SIZE", 500),
+			CacheTTL:  getDurationEnv("WARMUP_CACHE_TTL", 5*time.Minute),
+		},
+		Startup: StartupConfig{
+			MaxAttempts:    getIntEnv("STARTUP_MAX_ATTEMPTS", 10),
+			InitialBackoff: getDurationEnv("STARTUP_INITIAL_BACKOFF", time.Second),
+			MaxBackoff:     getDurationEnv("STARTUP_MAX_BACKOFF", 30*time.Second),
+		},
+		Search: SearchConfig{
+			Backend:          strings.ToLower(getEnv("SEARCH_BACKEND", "mongo")),
+			MaxRadius:        getFloatEnv("SEARCH_MAX_RADIUS", 50000),
+			DefaultRadius:    getFloatEnv("SEARCH_DEFAULT_RADIUS", 0),
+			DefaultLimit:     getIntEnv("SEARCH_DEFAULT_LIMIT", 10),
+			MaxLimit:         getIntEnv("SEARCH_MAX_LIMIT", 1000),
+			MaxLocationAge:   getDurationEnv("SEARCH_MAX_LOCATION_AGE", 0),
+			HeartbeatTimeout: getDurationEnv("HEARTBEAT_TIMEOUT", 90*time.Second),
+		},
+		Deprecation: DeprecationConfig{
+			Routes: getSliceEnv("DEPRECATED_ROUTES", nil),
+			Link:   getEnv("DEPRECATION_LINK", ""),
+		},
+		RateLimit: RateLimitConfig{
+			Rate:    getFloatEnv("RATE_LIMIT_RPS", 0),
+			Burst:   getIntEnv("RATE_LIMIT_BURST", 200),
+			Backend: strings.ToLower(getEnv("RATE_LIMIT_BACKEND", "memory")),
+		},
+		LoadShedding: LoadSheddingConfig{
+			P99Threshold: getDurationEnv("LOAD_SHED_P99_THRESHOLD", 0),
+			Window:       getDurationEnv("LOAD_SHED_WINDOW", 10*time.Second),
+			MinSamples:   getIntEnv("LOAD_SHED_MIN_SAMPLES", 50),
+		},
+		Cells: CellsConfig{
+			CountLevel: getIntEnv("S2_CELL_COUNT_LEVEL", 13),
+		},
+		Backfill: BackfillConfig{
+			BatchSize: getIntEnv("BACKFILL_BATCH_SIZE", 500),
+			Rate:      getFloatEnv("BACKFILL_RATE", 5),
+		},
+		Log: LogConfig{
+			Level:  strings.ToLower(getEnv("LOG_LEVEL", "info")),
+			Format: strings.ToLower(getEnv("LOG_FORMAT", "json")),
 		},
 	}
 
@@ -94,9 +411,136 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("redis address is required when redis is enabled")
 	}
 
-	if c.Auth.MatchingAPIKey == "" {
+	if c.Redis.TTLJitter < 0 || c.Redis.TTLJitter >= 1 {
+		return fmt.Errorf("cache TTL jitter must be at least 0 and below 1")
+	}
+
+	if c.Redis.NotFoundTTL < 0 {
+		return fmt.Errorf("cache not found TTL must not be negative")
+	}
+	if c.Redis.DriverCacheEnabled && c.Redis.DriverCacheTTL <= 0 {
+		return fmt.Errorf("driver cache TTL must be positive")
+	}
+
+	if c.Auth.RequireSignature && c.Auth.SigningSecret == "" {
+		return fmt.Errorf("HMAC signing secret is required when signatures are required")
+	}
+	if c.Auth.SigningSecret != "" && c.Auth.SignatureTolerance <= 0 {
+		return fmt.Errorf("HMAC max skew must be positive")
+	}
+	if !c.Auth.RequireSignature && c.Auth.MatchingAPIKey == "" && c.Auth.MatchingAPIKeyHash == "" {
 		return fmt.Errorf("matching API key is required")
 	}
+	if c.Auth.MatchingAPIKeyHash != "" {
+		if hash, err := hex.DecodeString(c.Auth.MatchingAPIKeyHash); err != nil || len(hash) != sha256.Size {
+			return fmt.Errorf("matching API key hash must be a hex SHA-256")
+		}
+	}
+
+	switch c.Database.ShardKey {
+	case "", "none", "hashed_id", "geohash", "tenant":
+	default:
+		return fmt.Errorf("unknown shard key strategy: %s", c.Database.ShardKey)
+	}
+
+	switch c.Search.Backend {
+	case "", "mongo":
+	case "redis":
+		if !c.Redis.Enabled {
+			return fmt.Errorf("redis search backend requires redis to be enabled")
+		}
+	default:
+		return fmt.Errorf("unknown search backend: %s", c.Search.Backend)
+	}
+	if c.Search.MaxRadius < 0 {
+		return fmt.Errorf("search max radius must not be negative")
+	}
+	if c.Search.DefaultRadius < 0 || (c.Search.MaxRadius > 0 && c.Search.DefaultRadius > c.Search.MaxRadius) {
+		return fmt.Errorf("search default radius must be between 0 and the max radius")
+	}
+	if c.Search.DefaultLimit < 0 || c.Search.MaxLimit < 0 {
+		return fmt.Errorf("search limits must not be negative")
+	}
+	if c.Search.MaxLimit > 0 && c.Search.DefaultLimit > c.Search.MaxLimit {
+		return fmt.Errorf("search default limit must not exceed the max limit")
+	}
+	if c.Search.MaxLocationAge < 0 {
+		return fmt.Errorf("search max location age must not be negative")
+	}
+	if c.Search.HeartbeatTimeout < 0 {
+		return fmt.Errorf("heartbeat timeout must not be negative")
+	}
+
+	if c.RateLimit.Rate < 0 {
+		return fmt.Errorf("rate limit must not be negative")
+	}
+	if c.RateLimit.Rate > 0 && c.RateLimit.Burst < 1 {
+		return fmt.Errorf("rate limit burst must be at least 1")
+	}
+	switch c.RateLimit.Backend {
+	case "", "memory":
+	case "redis":
+		if !c.Redis.Enabled {
+			return fmt.Errorf("redis rate limit backend requires redis to be enabled")
+		}
+	default:
+		return fmt.Errorf("unknown rate limit backend: %s", c.RateLimit.Backend)
+	}
+
+	if c.LoadShedding.P99Threshold < 0 {
+		return fmt.Errorf("load shedding p99 threshold must not be negative")
+	}
+	if c.LoadShedding.P99Threshold > 0 && c.LoadShedding.Window <= 0 {
+		return fmt.Errorf("load shedding window must be positive")
+	}
+
+	switch c.Log.Level {
+	case "", "debug", "info", "warn", "error":
+	default:
+		return fmt.Errorf("unknown log level: %s", c.Log.Level)
+	}
+	switch c.Log.Format {
+	case "", "json", "console":
+	default:
+		return fmt.Errorf("unknown log format: %s", c.Log.Format)
+	}
+
+	switch c.FeatureFlags.Source {
+	case "", "env", "file", "redis":
+	default:
+		return fmt.Errorf("unknown feature flags source: %s", c.FeatureFlags.Source)
+	}
+
+	switch c.MapMatching.Provider {
+	case "", "none":
+	case "osrm", "valhalla":
+		if c.MapMatching.URL == "" {
+			return fmt.Errorf("map matching URL is required for provider %s", c.MapMatching.Provider)
+		}
+	default:
+		return fmt.Errorf("unknown map matching provider: %s", c.MapMatching.Provider)
+	}
+
+	if c.Cells.CountLevel < 0 || c.Cells.CountLevel > 20 {
+		return fmt.Errorf("S2 cell count level must be between 1 and 20")
+	}
+
+	if c.Server.HTTP2Enabled && c.Server.MaxConcurrentStreams < 0 {
+		return fmt.Errorf("max concurrent streams must not be negative")
+	}
+
+	if c.Replication.TargetURL != "" {
+		if !strings.HasPrefix(c.Replication.TargetURL, "http://") && !strings.HasPrefix(c.Replication.TargetURL, "https://") {
+			return fmt.Errorf("replication target URL must be an http or https URL")
+		}
+		if c.Replication.APIKey == "" {
+			return fmt.Errorf("replication API key is required with a replication target URL")
+		}
+		// the standby takes at most 1000 changes per batch
+		if c.Replication.BatchSize < 1 || c.Replication.BatchSize > 1000 || c.Replication.ReconcileBatchSize < 1 || c.Replication.ReconcileBatchSize > 1000 {
+			return fmt.Errorf("replication batch sizes must be between 1 and 1000")
+		}
+	}
 
 	return nil
 }
@@ -120,6 +564,15 @@ func getIntEnv(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getFloatEnv(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
 func getUint64Env(key string, defaultValue uint64) uint64 {
 	if value := os.Getenv(key); value != "" {
 		if uintValue, err := strconv.ParseUint(value, 10, 64); err == nil {
@@ -138,6 +591,19 @@ func getBoolEnv(key string, defaultValue bool) bool {
 	return defaultValue
 }
 
+func getSliceEnv(key string, defaultValue []string) []string {
+	if value := os.Getenv(key); value != "" {
+		var values []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				values = append(values, item)
+			}
+		}
+		return values
+	}
+	return defaultValue
+}
+
 func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
 	if value := os.Getenv(key); value != "" {
 		if duration, err := time.ParseDuration(value); err == nil {
@@ -24,6 +24,18 @@ func TestLoadConfig_DefaultValues(t *testing.T) {
 	assert.Equal(t, 30*time.Second, config.Server.ReadTimeout)
 	assert.Equal(t, 30*time.Second, config.Server.WriteTimeout)
 	assert.Equal(t, 120*time.Second, config.Server.IdleTimeout)
+	assert.True(t, config.Server.KeepAlivesEnabled)
+	assert.True(t, config.Server.HTTP2Enabled)
+	assert.Equal(t, 250, config.Server.MaxConcurrentStreams)
+	assert.Equal(t, 1000, config.Server.ResponseStreamThreshold)
+	assert.Equal(t, 2*time.Second, config.Server.HealthCheckTimeout)
+	assert.False(t, config.Server.ReadOnly)
+	assert.Zero(t, config.RateLimit.Rate)
+	assert.Equal(t, 200, config.RateLimit.Burst)
+	assert.Equal(t, "memory", config.RateLimit.Backend)
+	assert.Zero(t, config.LoadShedding.P99Threshold)
+	assert.Equal(t, 10*time.Second, config.LoadShedding.Window)
+	assert.Equal(t, 50, config.LoadShedding.MinSamples)
 
 	// Test database defaults
 	assert.Equal(t, "mongodb://localhost:27017", config.Database.URI)
@@ -31,6 +43,16 @@ func TestLoadConfig_DefaultValues(t *testing.T) {
 	assert.Equal(t, 10*time.Second, config.Database.ConnectTimeout)
 	assert.Equal(t, uint64(100), config.Database.MaxPoolSize)
 	assert.Equal(t, uint64(10), config.Database.MinPoolSize)
+	assert.Equal(t, "none", config.Database.ShardKey)
+	assert.Equal(t, "default", config.Database.DefaultTenant)
+	assert.Equal(t, 50000.0, config.Search.MaxRadius)
+	assert.Zero(t, config.Search.DefaultRadius)
+	assert.Equal(t, 10, config.Search.DefaultLimit)
+	assert.Equal(t, 1000, config.Search.MaxLimit)
+	assert.Zero(t, config.Search.MaxLocationAge)
+	assert.Equal(t, 90*time.Second, config.Search.HeartbeatTimeout)
+	assert.Equal(t, "info", config.Log.Level)
+	assert.Equal(t, "json", config.Log.Format)
 
 	// Test redis defaults
 	assert.Equal(t, "localhost:6379", config.Redis.Address)
@@ -40,9 +62,53 @@ func TestLoadConfig_DefaultValues(t *testing.T) {
 	assert.Equal(t, 10, config.Redis.PoolSize)
 	assert.Equal(t, 5*time.Second, config.Redis.Timeout)
 	assert.True(t, config.Redis.Enabled)
+	assert.Equal(t, 0.1, config.Redis.TTLJitter)
+	assert.Equal(t, time.Duration(0), config.Redis.NotFoundTTL)
+	assert.True(t, config.Redis.DriverCacheEnabled)
+	assert.Equal(t, time.Minute, config.Redis.DriverCacheTTL)
 
 	// Test auth defaults
 	assert.Equal(t, "default-matching-api-key", config.Auth.MatchingAPIKey)
+
+	// Test feature flag defaults
+	assert.Equal(t, "development", config.Environment)
+	assert.Equal(t, "env", config.FeatureFlags.Source)
+	assert.Equal(t, "", config.FeatureFlags.Flags)
+	assert.Equal(t, 30*time.Second, config.FeatureFlags.RefreshInterval)
+
+	// Test backfill defaults
+	assert.Equal(t, 500, config.Backfill.BatchSize)
+	assert.Equal(t, 5.0, config.Backfill.Rate)
+
+	// Test map matching defaults
+	assert.Equal(t, "none", config.MapMatching.Provider)
+	assert.Equal(t, 2*time.Second, config.MapMatching.Timeout)
+
+	// Test warmup defaults
+	assert.True(t, config.Warmup.Enabled)
+	assert.Equal(t, 15*time.Minute, config.Warmup.Window)
+	assert.Equal(t, 500, config.Warmup.BatchSize)
+	assert.Equal(t, 5*time.Minute, config.Warmup.CacheTTL)
+
+	// Test location stream defaults
+	assert.Equal(t, time.Second, config.Stream.MinInterval)
+	assert.Equal(t, time.Minute, config.Stream.IdleTimeout)
+
+	// Test startup wait defaults
+	assert.Equal(t, 10, config.Startup.MaxAttempts)
+	assert.Equal(t, time.Second, config.Startup.InitialBackoff)
+	assert.Equal(t, 30*time.Second, config.Startup.MaxBackoff)
+
+	// Test import defaults
+	assert.True(t, config.Import.OnStartup)
+	assert.Equal(t, "Coordinates.csv", config.Import.FilePath)
+	assert.Equal(t, 100, config.Import.BatchSize)
+	assert.Equal(t, 4, config.Import.Workers)
+	assert.False(t, config.Import.Staged)
+
+	// Test event publishing defaults
+	assert.Empty(t, config.Events.KafkaBrokers)
+	assert.Equal(t, "driver-events", config.Events.Topic)
 }
 
 // TestLoadConfig_CustomValues tests config loading with custom environment variables
@@ -50,25 +116,29 @@ func TestLoadConfig_DefaultValues(t *testing.T) {
 func TestLoadConfig_CustomValues(t *testing.T) {
 	// Set custom environment variables
 	setConfigEnvVars(map[string]string{
-		"PORT":                  "9090",
-		"HOST":                  "127.0.0.1",
-		"READ_TIMEOUT":          "60s",
-		"WRITE_TIMEOUT":         "60s",
-		"IDLE_TIMEOUT":          "300s",
-		"MONGO_URI":             "mongodb://custom:27017",
-		"MONGO_DATABASE":        "custom_db",
-		"MONGO_CONNECT_TIMEOUT": "20s",
-		"MONGO_MAX_POOL_SIZE":   "200",
-		"MONGO_MIN_POOL_SIZE":   "20",
-		"REDIS_ADDRESS":         "custom-redis:6380",
-		"REDIS_PASSWORD":        "secret123",
-		"REDIS_DB":              "1",
-		"REDIS_MAX_RETRIES":     "5",
-		"REDIS_POOL_SIZE":       "20",
-		"REDIS_TIMEOUT":         "10s",
-		"REDIS_ENABLED":         "true",
-		"MATCHING_API_KEY":      "custom-api-key",
-		"ENVIRONMENT":           "development",
+		"PORT":                         "9090",
+		"HOST":                         "127.0.0.1",
+		"READ_TIMEOUT":                 "60s",
+		"WRITE_TIMEOUT":                "60s",
+		"IDLE_TIMEOUT":                 "300s",
+		"KEEP_ALIVES_ENABLED":          "false",
+		"HTTP2_ENABLED":                "false",
+		"HTTP2_MAX_CONCURRENT_STREAMS": "500",
+		"MONGO_URI":                    "mongodb://custom:27017",
+		"MONGO_DATABASE":               "custom_db",
+		"MONGO_CONNECT_TIMEOUT":        "20s",
+		"MONGO_MAX_POOL_SIZE":          "200",
+		"MONGO_MIN_POOL_SIZE":          "20",
+		"REDIS_ADDRESS":                "custom-redis:6380",
+		"REDIS_PASSWORD":               "secret123",
+		"REDIS_DB":                     "1",
+		"REDIS_MAX_RETRIES":            "5",
+		"REDIS_POOL_SIZE":              "20",
+		"REDIS_TIMEOUT":                "10s",
+		"REDIS_ENABLED":                "true",
+		"MATCHING_API_KEY":             "custom-api-key",
+		"TENANTS":                      "acme, globex",
+		"ENVIRONMENT":                  "development",
 	})
 
 	defer clearConfigEnvVars()
@@ -83,6 +153,9 @@ func TestLoadConfig_CustomValues(t *testing.T) {
 	assert.Equal(t, 60*time.Second, config.Server.ReadTimeout)
 	assert.Equal(t, 60*time.Second, config.Server.WriteTimeout)
 	assert.Equal(t, 300*time.Second, config.Server.IdleTimeout)
+	assert.False(t, config.Server.KeepAlivesEnabled)
+	assert.False(t, config.Server.HTTP2Enabled)
+	assert.Equal(t, 500, config.Server.MaxConcurrentStreams)
 
 	// Test custom database values
 	assert.Equal(t, "mongodb://custom:27017", config.Database.URI)
@@ -102,7 +175,7 @@ func TestLoadConfig_CustomValues(t *testing.T) {
 
 	// Test custom auth values
 	assert.Equal(t, "custom-api-key", config.Auth.MatchingAPIKey)
-
+	assert.Equal(t, []string{"acme", "globex"}, config.Auth.Tenants)
 }
 
 // TestLoadConfig_InvalidDurationValues tests config loading with invalid duration values
@@ -270,6 +343,28 @@ func TestConfig_Validate_RedisDisabled(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+// TestConfig_Validate_NegativeMaxConcurrentStreams tests config validation with a negative HTTP/2 stream limit
+// Expected: Should return error when HTTP/2 is enabled and max concurrent streams is negative
+func TestConfig_Validate_NegativeMaxConcurrentStreams(t *testing.T) {
+	config := &Config{
+		Server: ServerConfig{
+			HTTP2Enabled:         true,
+			MaxConcurrentStreams: -1,
+		},
+		Database: DatabaseConfig{
+			URI:      "mongodb://localhost:27017",
+			Database: "test_db",
+		},
+		Auth: AuthConfig{
+			MatchingAPIKey: "test-api-key",
+		},
+	}
+
+	err := config.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "max concurrent streams must not be negative")
+}
+
 // TestConfig_Validate_EmptyAPIKey tests config validation with empty API key
 // Expected: Should return error when matching API key is empty
 func TestConfig_Validate_EmptyAPIKey(t *testing.T) {
@@ -288,6 +383,310 @@ func TestConfig_Validate_EmptyAPIKey(t *testing.T) {
 	assert.Contains(t, err.Error(), "matching API key is required")
 }
 
+// TestConfig_Validate_RequestSigning tests config validation of HMAC request signing
+// Expected: Should require the secret when signatures are required and then accept a config without an API key
+func TestConfig_Validate_RequestSigning(t *testing.T) {
+	config := &Config{
+		Database: DatabaseConfig{
+			URI:      "mongodb://localhost:27017",
+			Database: "test_db",
+		},
+		Auth: AuthConfig{
+			RequireSignature:   true,
+			SignatureTolerance: 5 * time.Minute,
+		},
+	}
+	err := config.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "HMAC signing secret is required")
+
+	config.Auth.SigningSecret = "shared-secret"
+	assert.NoError(t, config.Validate())
+
+	config.Auth.SignatureTolerance = 0
+	err = config.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "HMAC max skew")
+}
+
+// TestConfig_Validate_APIKeyHash tests config validation with the hash of the API key instead of the key
+// Expected: Should accept a hex SHA-256 without the key and reject anything else
+func TestConfig_Validate_APIKeyHash(t *testing.T) {
+	config := &Config{
+		Database: DatabaseConfig{
+			URI:      "mongodb://localhost:27017",
+			Database: "test_db",
+		},
+		Auth: AuthConfig{
+			MatchingAPIKeyHash: "2bb80d537b1da3e38bd30361aa855686bde0eacd7162fef6a25fe97bf527a25b",
+		},
+	}
+	assert.NoError(t, config.Validate())
+
+	for _, hash := range []string{"secret", "2bb80d537b1da3e3"} {
+		config.Auth.MatchingAPIKeyHash = hash
+		err := config.Validate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "hex SHA-256")
+	}
+}
+
+// TestConfig_Validate_Replication tests config validation of the replication to the standby region
+// Expected: Should accept an http URL with an API key and reject a URL without scheme, a missing key or batches the standby refuses
+func TestConfig_Validate_Replication(t *testing.T) {
+	config := &Config{
+		Database: DatabaseConfig{
+			URI:      "mongodb://localhost:27017",
+			Database: "test_db",
+		},
+		Auth: AuthConfig{
+			MatchingAPIKey: "test-api-key",
+		},
+		Replication: ReplicationConfig{
+			TargetURL:          "https://drivers.eu-west-1.internal",
+			APIKey:             "standby-key",
+			BatchSize:          100,
+			ReconcileBatchSize: 500,
+		},
+	}
+	assert.NoError(t, config.Validate())
+
+	config.Replication.TargetURL = "drivers.eu-west-1.internal"
+	assert.ErrorContains(t, config.Validate(), "http or https")
+
+	config.Replication.TargetURL = "https://drivers.eu-west-1.internal"
+	config.Replication.APIKey = ""
+	assert.ErrorContains(t, config.Validate(), "API key is required")
+
+	config.Replication.APIKey = "standby-key"
+	config.Replication.ReconcileBatchSize = 5000
+	assert.ErrorContains(t, config.Validate(), "between 1 and 1000")
+}
+
+// TestConfig_Validate_UnknownFeatureFlagsSource tests config validation with an unsupported flag source
+// Expected: Should return error when the feature flags source is unknown
+func TestConfig_Validate_UnknownFeatureFlagsSource(t *testing.T) {
+	config := &Config{
+		Database: DatabaseConfig{
+			URI:      "mongodb://localhost:27017",
+			Database: "test_db",
+		},
+		Auth: AuthConfig{
+			MatchingAPIKey: "test-api-key",
+		},
+		FeatureFlags: FeatureFlagsConfig{
+			Source: "launchdarkly",
+		},
+	}
+
+	err := config.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown feature flags source")
+}
+
+// TestConfig_Validate_UnknownShardKey tests config validation with an unsupported shard key strategy
+// Expected: Should return error when the shard key strategy is unknown
+func TestConfig_Validate_UnknownShardKey(t *testing.T) {
+	config := &Config{
+		Database: DatabaseConfig{
+			URI:      "mongodb://localhost:27017",
+			Database: "test_db",
+			ShardKey: "zone",
+		},
+		Auth: AuthConfig{
+			MatchingAPIKey: "test-api-key",
+		},
+	}
+
+	err := config.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown shard key strategy")
+}
+
+// TestConfig_Validate_UnknownLogLevel tests config validation with an unsupported log level
+// Expected: Should return error when the log level is unknown
+func TestConfig_Validate_UnknownLogLevel(t *testing.T) {
+	config := &Config{
+		Database: DatabaseConfig{
+			URI:      "mongodb://localhost:27017",
+			Database: "test_db",
+		},
+		Auth: AuthConfig{
+			MatchingAPIKey: "test-api-key",
+		},
+		Log: LogConfig{Level: "verbose"},
+	}
+
+	err := config.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown log level")
+}
+
+// TestConfig_Validate_CellCountLevel tests config validation with an S2 level too fine to count by
+// Expected: Should return error for levels above 20
+func TestConfig_Validate_CellCountLevel(t *testing.T) {
+	config := &Config{
+		Database: DatabaseConfig{
+			URI:      "mongodb://localhost:27017",
+			Database: "test_db",
+		},
+		Auth: AuthConfig{
+			MatchingAPIKey: "test-api-key",
+		},
+		Cells: CellsConfig{CountLevel: 24},
+	}
+
+	err := config.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "S2 cell count level")
+}
+
+// TestConfig_Validate_SearchBackend tests config validation of the nearby search backend
+// Expected: Should return error for unknown backends and for redis without redis enabled
+func TestConfig_Validate_SearchBackend(t *testing.T) {
+	config := &Config{
+		Database: DatabaseConfig{
+			URI:      "mongodb://localhost:27017",
+			Database: "test_db",
+		},
+		Auth: AuthConfig{
+			MatchingAPIKey: "test-api-key",
+		},
+		Search: SearchConfig{Backend: "elastic"},
+	}
+
+	err := config.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown search backend")
+
+	config.Search.Backend = "redis"
+	err = config.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "requires redis")
+
+	config.Redis = RedisConfig{Enabled: true, Address: "localhost:6379"}
+	assert.NoError(t, config.Validate())
+}
+
+// TestConfig_Validate_SearchDefaults tests config validation of the default radius and limits of nearby searches
+// Expected: Should return error for a default radius above the max radius and a default limit above the max limit
+func TestConfig_Validate_SearchDefaults(t *testing.T) {
+	config := &Config{
+		Database: DatabaseConfig{
+			URI:      "mongodb://localhost:27017",
+			Database: "test_db",
+		},
+		Auth: AuthConfig{
+			MatchingAPIKey: "test-api-key",
+		},
+		Search: SearchConfig{MaxRadius: 5000, DefaultRadius: 6000, DefaultLimit: 10, MaxLimit: 100},
+	}
+
+	assert.ErrorContains(t, config.Validate(), "default radius")
+
+	config.Search.DefaultRadius = 3000
+	config.Search.DefaultLimit = 200
+	assert.ErrorContains(t, config.Validate(), "default limit")
+
+	config.Search.MaxLimit = -1
+	assert.ErrorContains(t, config.Validate(), "must not be negative")
+
+	config.Search.DefaultLimit, config.Search.MaxLimit = 10, 100
+	assert.NoError(t, config.Validate())
+}
+
+// TestConfig_Validate_RateLimit tests config validation of the rate limit
+// Expected: Should return error for a negative rate, an empty burst and a redis backend without redis
+func TestConfig_Validate_RateLimit(t *testing.T) {
+	config := &Config{
+		Database: DatabaseConfig{
+			URI:      "mongodb://localhost:27017",
+			Database: "test_db",
+		},
+		Auth: AuthConfig{
+			MatchingAPIKey: "test-api-key",
+		},
+		RateLimit: RateLimitConfig{Rate: -1},
+	}
+
+	err := config.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "must not be negative")
+
+	config.RateLimit = RateLimitConfig{Rate: 10}
+	err = config.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "burst")
+
+	config.RateLimit = RateLimitConfig{Rate: 10, Burst: 20, Backend: "redis"}
+	err = config.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "requires redis")
+
+	config.Redis = RedisConfig{Enabled: true, Address: "localhost:6379"}
+	assert.NoError(t, config.Validate())
+}
+
+// TestConfig_Validate_LoadShedding tests config validation of the load shedding
+// Expected: Should return error for a negative threshold and a threshold without a window
+func TestConfig_Validate_LoadShedding(t *testing.T) {
+	config := &Config{
+		Database: DatabaseConfig{
+			URI:      "mongodb://localhost:27017",
+			Database: "test_db",
+		},
+		Auth: AuthConfig{
+			MatchingAPIKey: "test-api-key",
+		},
+		LoadShedding: LoadSheddingConfig{P99Threshold: -time.Second},
+	}
+
+	err := config.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "threshold")
+
+	config.LoadShedding = LoadSheddingConfig{P99Threshold: 500 * time.Millisecond}
+	err = config.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "window")
+
+	config.LoadShedding.Window = 10 * time.Second
+	assert.NoError(t, config.Validate())
+}
+
+// TestConfig_Validate_TTLJitter tests config validation of the cache TTL jitter
+// Expected: Should return error for negative fractions and fractions of 1 or more
+func TestConfig_Validate_TTLJitter(t *testing.T) {
+	config := &Config{
+		Database: DatabaseConfig{
+			URI:      "mongodb://localhost:27017",
+			Database: "test_db",
+		},
+		Auth: AuthConfig{
+			MatchingAPIKey: "test-api-key",
+		},
+	}
+
+	for _, jitter := range []float64{-0.1, 1, 2} {
+		config.Redis.TTLJitter = jitter
+		err := config.Validate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "cache TTL jitter")
+	}
+
+	config.Redis.TTLJitter = 0.25
+	assert.NoError(t, config.Validate())
+
+	config.Redis.NotFoundTTL = -time.Second
+	assert.ErrorContains(t, config.Validate(), "cache not found TTL")
+	config.Redis.NotFoundTTL = 0
+
+	config.Redis.DriverCacheEnabled = true
+	assert.ErrorContains(t, config.Validate(), "driver cache TTL")
+	config.Redis.DriverCacheTTL = 30 * time.Second
+	assert.NoError(t, config.Validate())
+}
+
 // TestConfig_GetAddress tests server address construction
 // Expected: Should return properly formatted host:port address
 func TestConfig_GetAddress(t *testing.T) {
@@ -333,9 +732,21 @@ func TestConfig_GetAddress_EmptyPort(t *testing.T) {
 func clearConfigEnvVars() {
 	envVars := []string{
 		"PORT", "HOST", "READ_TIMEOUT", "WRITE_TIMEOUT", "IDLE_TIMEOUT",
-		"MONGO_URI", "MONGO_DATABASE", "MONGO_CONNECT_TIMEOUT", "MONGO_MAX_POOL_SIZE", "MONGO_MIN_POOL_SIZE",
-		"REDIS_ADDRESS", "REDIS_PASSWORD", "REDIS_DB", "REDIS_MAX_RETRIES", "REDIS_POOL_SIZE", "REDIS_TIMEOUT", "REDIS_ENABLED",
-		"MATCHING_API_KEY",
+		"KEEP_ALIVES_ENABLED", "HTTP2_ENABLED", "HTTP2_MAX_CONCURRENT_STREAMS", "RESPONSE_STREAM_THRESHOLD", "HEALTH_CHECK_TIMEOUT", "READ_ONLY",
+		"MONGO_URI", "MONGO_DATABASE", "MONGO_CONNECT_TIMEOUT", "MONGO_MAX_POOL_SIZE", "MONGO_MIN_POOL_SIZE", "MONGO_SHARD_KEY", "MONGO_DEFAULT_TENANT",
+		"REDIS_ADDRESS", "REDIS_PASSWORD", "REDIS_DB", "REDIS_MAX_RETRIES", "REDIS_POOL_SIZE", "REDIS_TIMEOUT", "REDIS_ENABLED", "CACHE_TTL_JITTER", "CACHE_NOT_FOUND_TTL", "CACHE_DRIVER_ENABLED", "CACHE_DRIVER_TTL",
+		"MATCHING_API_KEY", "MATCHING_API_KEY_HASH", "TENANTS", "ENVIRONMENT",
+		"FEATURE_FLAGS_SOURCE", "FEATURE_FLAGS", "FEATURE_FLAGS_FILE", "FEATURE_FLAGS_REDIS_KEY", "FEATURE_FLAGS_REFRESH_INTERVAL",
+		"BACKFILL_BATCH_SIZE", "BACKFILL_RATE",
+		"KAFKA_BROKERS", "DRIVER_EVENTS_TOPIC",
+		"IMPORT_ON_STARTUP", "IMPORT_FILE_PATH", "IMPORT_BATCH_SIZE", "IMPORT_WORKERS", "IMPORT_STAGED",
+		"LOCATION_STREAM_MIN_INTERVAL", "LOCATION_STREAM_IDLE_TIMEOUT",
+		"WARMUP_ENABLED", "WARMUP_WINDOW", "WARMUP_BATCH_SIZE", "WARMUP_CACHE_TTL",
+		"MAP_MATCHING_PROVIDER", "MAP_MATCHING_URL", "MAP_MATCHING_PROFILE", "MAP_MATCHING_TIMEOUT",
+		"STARTUP_MAX_ATTEMPTS", "STARTUP_INITIAL_BACKOFF", "STARTUP_MAX_BACKOFF",
+		"S2_CELL_COUNT_LEVEL", "SEARCH_BACKEND", "SEARCH_MAX_RADIUS", "SEARCH_DEFAULT_RADIUS", "SEARCH_DEFAULT_LIMIT", "SEARCH_MAX_LIMIT", "SEARCH_MAX_LOCATION_AGE",
+		"HEARTBEAT_TIMEOUT", "RATE_LIMIT_RPS", "RATE_LIMIT_BURST", "RATE_LIMIT_BACKEND",
+		"LOAD_SHED_P99_THRESHOLD", "LOAD_SHED_WINDOW", "LOAD_SHED_MIN_SAMPLES",
 	}
 
 	for _, envVar := range envVars {
@@ -15,53 +15,108 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/v1/drivers": {
-            "post": {
+        "/admin/backfills": {
+            "get": {
                 "security": [
                     {
                         "X-API-KEY": []
                     }
                 ],
-                "description": "Create one or multiple drivers in a single request. Supports both single driver and batch operations.",
-                "consumes": [
+                "description": "Get the progress of every registered backfill job",
+                "produces": [
                     "application/json"
                 ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List backfill jobs",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/http.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/backfills/{job}": {
+            "get": {
+                "security": [
+                    {
+                        "X-API-KEY": []
+                    }
+                ],
+                "description": "Get the progress of a backfill job",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "drivers"
+                    "admin"
                 ],
-                "summary": "Create driver(s)",
+                "summary": "Get backfill progress",
                 "parameters": [
                     {
-                        "description": "Driver(s) info - send array with single element for one driver, multiple elements for batch",
-                        "name": "drivers",
-                        "in": "body",
-                        "required": true,
+                        "type": "string",
+                        "description": "Backfill job name",
+                        "name": "job",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/domain.CreateDriverRequest"
-                            }
+                            "$ref": "#/definitions/http.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/http.APIResponse"
                         }
                     }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "X-API-KEY": []
+                    }
+                ],
+                "description": "Start a backfill job in the background, a failed job continues from its last checkpoint",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Start a backfill",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Backfill job name",
+                        "name": "job",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "202": {
+                        "description": "Accepted",
                         "schema": {
                             "$ref": "#/definitions/http.APIResponse"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/http.APIResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
                             "$ref": "#/definitions/http.APIResponse"
                         }
@@ -69,14 +124,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/drivers/search": {
+        "/admin/duplicates": {
             "post": {
                 "security": [
                     {
                         "X-API-KEY": []
                     }
                 ],
-                "description": "Find drivers near a given location",
+                "description": "Report drivers with identical coordinates created within a window of each other, merge or delete the clones when asked to",
                 "consumes": [
                     "application/json"
                 ],
@@ -84,17 +139,16 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "drivers"
+                    "admin"
                 ],
-                "summary": "Search nearby drivers",
+                "summary": "Find duplicate drivers",
                 "parameters": [
                     {
-                        "description": "Search params",
-                        "name": "search",
+                        "description": "Window, action and number of coordinates to scan",
+                        "name": "request",
                         "in": "body",
-                        "required": true,
                         "schema": {
-                            "$ref": "#/definitions/domain.SearchRequest"
+                            "$ref": "#/definitions/domain.DuplicateRequest"
                         }
                     }
                 ],
@@ -102,7 +156,19 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/http.APIResponse"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/http.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/domain.DuplicateReport"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
@@ -120,35 +186,112 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/drivers/{id}": {
+        "/admin/flags": {
             "get": {
                 "security": [
                     {
                         "X-API-KEY": []
                     }
                 ],
-                "description": "Get a driver by its ID",
+                "description": "Get the current state of every feature flag for this environment",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "drivers"
+                    "admin"
                 ],
-                "summary": "Get driver by ID",
+                "summary": "List feature flags",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/http.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/read-only": {
+            "get": {
+                "security": [
+                    {
+                        "X-API-KEY": []
+                    }
+                ],
+                "description": "Whether the instance refuses writes, turned on through this endpoint (source admin) or by the read_only feature flag (source flag)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get the read-only mode",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/http.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/domain.ReadOnlyMode"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "X-API-KEY": []
+                    }
+                ],
+                "description": "Searches and reads keep working in read-only mode, writes are answered with 503 and the maintenance error. The mode applies to this instance. The read_only feature flag turns it on for every instance, or with a percentage for that share of the instances picked by hostname, and keeps it on until the flag is disabled.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Turn the read-only mode on or off",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Driver ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Read-only mode",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.SetReadOnlyRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/http.APIResponse"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/http.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/domain.ReadOnlyMode"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
@@ -156,22 +299,18 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/http.APIResponse"
                         }
-                    },
-                    "404": {
-                        "description": "Not Found",
-                        "schema": {
-                            "$ref": "#/definitions/http.APIResponse"
-                        }
                     }
                 }
-            },
-            "put": {
+            }
+        },
+        "/admin/replication/drivers": {
+            "post": {
                 "security": [
                     {
                         "X-API-KEY": []
                     }
                 ],
-                "description": "Update a driver's information by ID",
+                "description": "Called by the driver location service of the primary region. Every change carries the updated_at of the write on the primary, a change older than the stored driver is skipped so retried and reordered batches are safe. A batch with a mirror range also deletes the stored drivers of the range it does not list unless they were updated after the snapshot.",
                 "consumes": [
                     "application/json"
                 ],
@@ -179,24 +318,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "drivers"
+                    "admin"
                 ],
-                "summary": "Update driver by ID",
+                "summary": "Apply replicated driver changes",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Driver ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Driver info",
-                        "name": "driver",
+                        "description": "Driver changes of the primary region",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/domain.Driver"
+                            "$ref": "#/definitions/domain.ReplicationBatch"
                         }
                     }
                 ],
@@ -204,7 +336,19 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/http.APIResponse"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/http.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/domain.ReplicationResult"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
@@ -220,35 +364,73 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/api/v1/drivers": {
+            "get": {
                 "security": [
                     {
                         "X-API-KEY": []
                     }
                 ],
-                "description": "Delete a driver by its ID",
+                "description": "Page through every driver ordered by ID, whatever its status, pass the next_cursor of a page to get the next one",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "application/geo+json"
                 ],
                 "tags": [
                     "drivers"
                 ],
-                "summary": "Delete driver by ID",
+                "summary": "List drivers",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Driver ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Drivers per page (100 by default, at most 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only drivers with this status: available, busy or offline",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only drivers updated at or after this RFC3339 time",
+                        "name": "updated_since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "strong to read from the primary",
+                        "name": "X-Consistency",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/http.APIResponse"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/http.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/domain.DriverPage"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
@@ -264,16 +446,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/api/v1/drivers/{id}/location": {
-            "patch": {
+            },
+            "post": {
                 "security": [
                     {
                         "X-API-KEY": []
                     }
                 ],
-                "description": "Update a driver's location by ID",
+                "description": "Create one or multiple drivers in a single request. Supports both single driver and batch operations.\nA batch with a taken ID creates none of its drivers and answers 409 listing the taken IDs in data.duplicate_ids,\nwith upsert=true the drivers whose IDs are taken are updated instead and the request answers 200.\nLarge imports can pass response=ids to get the count and the IDs of the drivers instead of the drivers,\nbatches above RESPONSE_STREAM_THRESHOLD drivers (1000 by default) are streamed.\nWith ordered=false the drivers that cannot be created (taken IDs) are skipped instead of failing the batch,\nthe others are created in any order and the request answers 207 listing the skipped ones in data.failed.",
                 "consumes": [
                     "application/json"
                 ],
@@ -283,23 +463,41 @@ const docTemplate = `{
                 "tags": [
                     "drivers"
                 ],
-                "summary": "Update driver location",
+                "summary": "Create driver(s)",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Driver ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "New location",
-                        "name": "location",
+                        "description": "Driver(s) info - send array with single element for one driver, multiple elements for batch",
+                        "name": "drivers",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/domain.Point"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/domain.CreateDriverRequest"
+                            }
                         }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Update the drivers whose IDs are taken instead of failing",
+                        "name": "upsert",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "full",
+                            "ids"
+                        ],
+                        "type": "string",
+                        "description": "ids to answer with the count and the IDs of the drivers only",
+                        "name": "response",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "false to create every driver that can be created instead of stopping at the first that cannot, true by default",
+                        "name": "ordered",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -309,12 +507,42 @@ const docTemplate = `{
                             "$ref": "#/definitions/http.APIResponse"
                         }
                     },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/http.APIResponse"
+                        }
+                    },
+                    "207": {
+                        "description": "Multi-Status",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/http.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/http.CreateDriversPartialData"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/http.APIResponse"
                         }
                     },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/http.APIResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -324,65 +552,1427 @@ const docTemplate = `{
                 }
             }
         },
-        "/health": {
+        "/api/v1/drivers/cells/{token}": {
             "get": {
-                "description": "Check if the service is healthy",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "X-API-KEY": []
+                    }
                 ],
+                "description": "Find the available drivers inside the S2 cell of a token, or their counts per descendant cell with count",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "application/geo+json"
                 ],
                 "tags": [
-                    "health"
+                    "drivers"
                 ],
-                "summary": "Health check endpoint",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/http.APIResponse"
-                        }
-                    }
-                }
-            }
-        }
-    },
+                "summary": "Search drivers in an S2 cell",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "S2 cell token, e.g. 89c25a3",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum drivers or cells (500 by default, at most 2000)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Count the drivers per descendant cell instead of returning them",
+                        "name": "count",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Level of the counted cells (up to 20), S2_CELL_COUNT_LEVEL by default",
+                        "name": "level",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "strong to bypass the cache and read from the primary",
+                        "name": "X-Consistency",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/http.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/domain.CellSearchResult"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/drivers/reconcile": {
+            "post": {
+                "security": [
+                    {
+                        "X-API-KEY": []
+                    }
+                ],
+                "description": "Compare a partner's list of drivers with the stored drivers and report the missing, extra and divergent ones",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "drivers"
+                ],
+                "summary": "Reconcile drivers",
+                "parameters": [
+                    {
+                        "description": "Drivers as the partner expects them",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.ReconcileRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/http.APIResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/drivers/search": {
+            "post": {
+                "security": [
+                    {
+                        "X-API-KEY": []
+                    }
+                ],
+                "description": "Find drivers near a given location, the radius is capped at SEARCH_MAX_RADIUS meters (50000 by default) and the limit at SEARCH_MAX_LIMIT drivers (1000 by default, SEARCH_DEFAULT_LIMIT or 10 when omitted)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/geo+json"
+                ],
+                "tags": [
+                    "drivers"
+                ],
+                "summary": "Search nearby drivers",
+                "parameters": [
+                    {
+                        "description": "Search params",
+                        "name": "search",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.SearchRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "strong to bypass the cache and read from the primary",
+                        "name": "X-Consistency",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/http.APIResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/drivers/search/box": {
+            "get": {
+                "security": [
+                    {
+                        "X-API-KEY": []
+                    }
+                ],
+                "description": "Find the available drivers inside a longitude/latitude box for map views, or their counts per geohash cell with cluster",
+                "produces": [
+                    "application/json",
+                    "application/geo+json"
+                ],
+                "tags": [
+                    "drivers"
+                ],
+                "summary": "Search drivers in a bounding box",
+                "parameters": [
+                    {
+                        "type": "number",
+                        "description": "West edge of the box",
+                        "name": "min_lon",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "South edge of the box",
+                        "name": "min_lat",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "East edge of the box",
+                        "name": "max_lon",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "North edge of the box",
+                        "name": "max_lat",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum drivers or clusters (500 by default, at most 2000)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Geohash precision (1-7) to group the drivers by, 0 returns the drivers",
+                        "name": "cluster",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "strong to bypass the cache and read from the primary",
+                        "name": "X-Consistency",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/http.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/domain.BoxSearchResult"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/drivers/search/within": {
+            "post": {
+                "security": [
+                    {
+                        "X-API-KEY": []
+                    }
+                ],
+                "description": "Find the available drivers inside a GeoJSON Polygon or MultiPolygon",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/geo+json"
+                ],
+                "tags": [
+                    "drivers"
+                ],
+                "summary": "Search drivers within an area",
+                "parameters": [
+                    {
+                        "description": "Area and optional limit (100 by default, at most 1000)",
+                        "name": "search",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.AreaSearchRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "strong to bypass the cache and read from the primary",
+                        "name": "X-Consistency",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/http.APIResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/drivers/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "X-API-KEY": []
+                    }
+                ],
+                "description": "Get a driver by its ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "drivers"
+                ],
+                "summary": "Get driver by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Driver ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "strong to bypass the cache and read from the primary",
+                        "name": "X-Consistency",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/http.APIResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/http.APIResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "X-API-KEY": []
+                    }
+                ],
+                "description": "Update a driver's information by ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "drivers"
+                ],
+                "summary": "Update driver by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Driver ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Driver info",
+                        "name": "driver",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.Driver"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/http.APIResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/http.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.APIResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "X-API-KEY": []
+                    }
+                ],
+                "description": "Delete a driver by its ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "drivers"
+                ],
+                "summary": "Delete driver by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Driver ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/http.APIResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/http.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/drivers/{id}/heartbeat": {
+            "post": {
+                "security": [
+                    {
+                        "X-API-KEY": []
+                    }
+                ],
+                "description": "Keep a driver searchable without a location update, drivers that sent a heartbeat are left out of nearby searches once none arrived within HEARTBEAT_TIMEOUT",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "drivers"
+                ],
+                "summary": "Record driver heartbeat",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Driver ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/http.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/domain.Heartbeat"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/http.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/drivers/{id}/location": {
+            "patch": {
+                "security": [
+                    {
+                        "X-API-KEY": []
+                    }
+                ],
+                "description": "Update a driver's location by ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "drivers"
+                ],
+                "summary": "Update driver location",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Driver ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New location with optional speed (m/s) and heading (degrees)",
+                        "name": "location",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.LocationUpdate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/http.APIResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/http.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/drivers/{id}/location/stream": {
+            "get": {
+                "security": [
+                    {
+                        "X-API-KEY": []
+                    }
+                ],
+                "description": "Upgrade to a WebSocket and send one location update JSON message per position, every message is answered with an ack whose status is ok, throttled or error",
+                "tags": [
+                    "drivers"
+                ],
+                "summary": "Stream driver location updates",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Driver ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols",
+                        "schema": {
+                            "$ref": "#/definitions/http.LocationStreamAck"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/drivers/{id}/outcomes": {
+            "post": {
+                "security": [
+                    {
+                        "X-API-KEY": []
+                    }
+                ],
+                "description": "Count an accepted, completed or driver cancelled match of the driver, the counts and the quality score derived from them are returned and show up on the driver",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "drivers"
+                ],
+                "summary": "Record a match outcome",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Driver ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Match and its outcome",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.OutcomeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/http.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/domain.DriverOutcomes"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/http.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/drivers/{id}/status": {
+            "patch": {
+                "security": [
+                    {
+                        "X-API-KEY": []
+                    }
+                ],
+                "description": "Set a driver's availability, only available drivers are returned by nearby searches",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "drivers"
+                ],
+                "summary": "Update driver status",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Driver ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New status: available, busy or offline",
+                        "name": "status",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.UpdateStatusRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/http.APIResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/http.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/health": {
+            "get": {
+                "description": "Check if the service is alive. The liveness probe does not check MongoDB or Redis, see /health/ready",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Health check endpoint",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/http.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/health/live": {
+            "get": {
+                "description": "Check if the service is alive. The liveness probe does not check MongoDB or Redis, see /health/ready",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Health check endpoint",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/http.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/health/ready": {
+            "get": {
+                "description": "Check if the service is ready, with the progress of the startup cache warmup and the state of MongoDB and Redis. Answers 503 while a required dependency is down (MongoDB, and Redis when it is the search backend), a cache outage only degrades the status.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Readiness check endpoint",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/http.APIResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/http.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/ready": {
+            "get": {
+                "description": "Check if the service is ready, with the progress of the startup cache warmup and the state of MongoDB and Redis. Answers 503 while a required dependency is down (MongoDB, and Redis when it is the search backend), a cache outage only degrades the status.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Readiness check endpoint",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/http.APIResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/http.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/version": {
+            "get": {
+                "description": "Version, git commit and build time of the running binary, set at build time with -ldflags",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Build information",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/http.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/buildinfo.Info"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        }
+    },
     "definitions": {
+        "buildinfo.Info": {
+            "type": "object",
+            "properties": {
+                "build_time": {
+                    "type": "string"
+                },
+                "git_sha": {
+                    "type": "string"
+                },
+                "go_version": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.Area": {
+            "type": "object",
+            "required": [
+                "coordinates",
+                "type"
+            ],
+            "properties": {
+                "coordinates": {
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "Polygon",
+                        "MultiPolygon"
+                    ],
+                    "example": "Polygon"
+                }
+            }
+        },
+        "domain.AreaSearchRequest": {
+            "type": "object",
+            "required": [
+                "area"
+            ],
+            "properties": {
+                "area": {
+                    "$ref": "#/definitions/domain.Area"
+                },
+                "limit": {
+                    "type": "integer",
+                    "maximum": 1000,
+                    "minimum": 0
+                }
+            }
+        },
+        "domain.BatchCreateFailure": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "index": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "description": "duplicate_id, or the error of the database",
+                    "type": "string"
+                }
+            }
+        },
+        "domain.BoxSearchResult": {
+            "type": "object",
+            "properties": {
+                "clusters": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.DriverCluster"
+                    }
+                },
+                "count": {
+                    "type": "integer"
+                },
+                "drivers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.Driver"
+                    }
+                }
+            }
+        },
+        "domain.CellCount": {
+            "type": "object",
+            "properties": {
+                "cell": {
+                    "type": "string"
+                },
+                "center": {
+                    "$ref": "#/definitions/domain.Point"
+                },
+                "count": {
+                    "type": "integer"
+                },
+                "level": {
+                    "type": "integer"
+                }
+            }
+        },
+        "domain.CellSearchResult": {
+            "type": "object",
+            "properties": {
+                "cell": {
+                    "type": "string"
+                },
+                "count": {
+                    "type": "integer"
+                },
+                "counts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.CellCount"
+                    }
+                },
+                "drivers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.Driver"
+                    }
+                },
+                "level": {
+                    "type": "integer"
+                }
+            }
+        },
         "domain.CreateDriverRequest": {
             "type": "object",
             "required": [
                 "location"
             ],
             "properties": {
+                "attributes": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "capacity": {
+                    "type": "integer",
+                    "maximum": 100,
+                    "minimum": 0,
+                    "example": 4
+                },
+                "id": {
+                    "type": "string"
+                },
+                "location": {
+                    "$ref": "#/definitions/domain.Point"
+                },
+                "tenant_id": {
+                    "type": "string"
+                },
+                "vehicle_type": {
+                    "type": "string",
+                    "maxLength": 32,
+                    "example": "sedan"
+                }
+            }
+        },
+        "domain.Driver": {
+            "type": "object",
+            "required": [
+                "location"
+            ],
+            "properties": {
+                "attributes": {
+                    "description": "free-form, e.g. pet_friendly or wheelchair",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "capacity": {
+                    "description": "passenger seats, 0 when unknown",
+                    "type": "integer",
+                    "maximum": 100,
+                    "minimum": 0
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "geohash_cell": {
+                    "type": "string"
+                },
+                "heading": {
+                    "description": "degrees clockwise from north, nil when unknown",
+                    "type": "number"
+                },
                 "id": {
                     "type": "string"
                 },
+                "last_seen_at": {
+                    "description": "last heartbeat or update, nil for drivers that never sent a heartbeat",
+                    "type": "string"
+                },
                 "location": {
                     "$ref": "#/definitions/domain.Point"
+                },
+                "outcomes": {
+                    "description": "match outcomes reported by the matching service, nil before the first",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/domain.DriverOutcomes"
+                        }
+                    ]
+                },
+                "raw_location": {
+                    "description": "reported GPS position when Location was snapped to a road",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/domain.Point"
+                        }
+                    ]
+                },
+                "speed": {
+                    "description": "meters per second, nil when unknown",
+                    "type": "number"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "available",
+                        "busy",
+                        "offline"
+                    ]
+                },
+                "tenant_id": {
+                    "description": "fleet partner owning the driver, a shard key candidate",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "vehicle_type": {
+                    "description": "stored lower case, e.g. sedan, van or motorcycle",
+                    "type": "string",
+                    "maxLength": 32
+                },
+                "version": {
+                    "type": "integer"
                 }
             }
         },
-        "domain.Driver": {
+        "domain.DriverChange": {
             "type": "object",
             "required": [
-                "location"
+                "driver_id",
+                "op",
+                "updated_at"
             ],
             "properties": {
-                "created_at": {
+                "driver": {
+                    "$ref": "#/definitions/domain.Driver"
+                },
+                "driver_id": {
+                    "type": "string",
+                    "example": "driver-123"
+                },
+                "op": {
+                    "enum": [
+                        "upsert",
+                        "delete"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/domain.ReplicationOp"
+                        }
+                    ],
+                    "example": "upsert"
+                },
+                "updated_at": {
                     "type": "string"
+                }
+            }
+        },
+        "domain.DriverCluster": {
+            "type": "object",
+            "properties": {
+                "center": {
+                    "$ref": "#/definitions/domain.Point"
                 },
-                "id": {
+                "count": {
+                    "type": "integer"
+                },
+                "geohash": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.DriverOutcomes": {
+            "type": "object",
+            "properties": {
+                "accepted": {
+                    "type": "integer"
+                },
+                "cancelled": {
+                    "type": "integer"
+                },
+                "completed": {
+                    "type": "integer"
+                }
+            }
+        },
+        "domain.DriverPage": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "drivers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.Driver"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.DuplicateGroup": {
+            "type": "object",
+            "properties": {
+                "duplicate_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "keep_id": {
                     "type": "string"
                 },
                 "location": {
                     "$ref": "#/definitions/domain.Point"
+                }
+            }
+        },
+        "domain.DuplicateReport": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
                 },
-                "updated_at": {
+                "duplicates": {
+                    "type": "integer"
+                },
+                "groups": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.DuplicateGroup"
+                    }
+                },
+                "removed": {
+                    "type": "integer"
+                },
+                "truncated": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "domain.DuplicateRequest": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "enum": [
+                        "report",
+                        "merge",
+                        "delete"
+                    ],
+                    "example": "report"
+                },
+                "limit": {
+                    "type": "integer",
+                    "maximum": 10000,
+                    "minimum": 0,
+                    "example": 1000
+                },
+                "window_seconds": {
+                    "type": "integer",
+                    "maximum": 2592000,
+                    "minimum": 0,
+                    "example": 60
+                }
+            }
+        },
+        "domain.Heartbeat": {
+            "type": "object",
+            "properties": {
+                "driver_id": {
+                    "type": "string"
+                },
+                "last_seen_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.LocationUpdate": {
+            "type": "object",
+            "required": [
+                "coordinates",
+                "type"
+            ],
+            "properties": {
+                "coordinates": {
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "heading": {
+                    "type": "number",
+                    "minimum": 0
+                },
+                "speed": {
+                    "type": "number",
+                    "minimum": 0
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.MirrorRange": {
+            "type": "object",
+            "required": [
+                "snapshot_at"
+            ],
+            "properties": {
+                "after_id": {
+                    "type": "string"
+                },
+                "snapshot_at": {
+                    "type": "string"
+                },
+                "through_id": {
                     "type": "string"
                 }
             }
         },
+        "domain.OutcomeRequest": {
+            "type": "object",
+            "required": [
+                "match_id",
+                "outcome"
+            ],
+            "properties": {
+                "match_id": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "9b2d4c6e8f0a1b3c5d7e9f1a2b3c4d5e"
+                },
+                "outcome": {
+                    "type": "string",
+                    "enum": [
+                        "accepted",
+                        "completed",
+                        "cancelled"
+                    ],
+                    "example": "completed"
+                }
+            }
+        },
         "domain.Point": {
             "type": "object",
             "required": [
@@ -401,6 +1991,114 @@ const docTemplate = `{
                 }
             }
         },
+        "domain.ReadOnlyMode": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "since": {
+                    "type": "string"
+                },
+                "source": {
+                    "description": "admin or flag",
+                    "type": "string"
+                }
+            }
+        },
+        "domain.ReconcileDriver": {
+            "type": "object",
+            "required": [
+                "id"
+            ],
+            "properties": {
+                "id": {
+                    "type": "string",
+                    "example": "driver-123"
+                },
+                "location": {
+                    "$ref": "#/definitions/domain.Point"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "available",
+                        "busy",
+                        "offline"
+                    ],
+                    "example": "available"
+                }
+            }
+        },
+        "domain.ReconcileRequest": {
+            "type": "object",
+            "required": [
+                "drivers"
+            ],
+            "properties": {
+                "drivers": {
+                    "type": "array",
+                    "maxItems": 5000,
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/domain.ReconcileDriver"
+                    }
+                },
+                "id_prefix": {
+                    "type": "string",
+                    "minLength": 3,
+                    "example": "fleet-a-"
+                },
+                "location_tolerance_meters": {
+                    "type": "number",
+                    "example": 50
+                }
+            }
+        },
+        "domain.ReplicationBatch": {
+            "type": "object",
+            "properties": {
+                "changes": {
+                    "type": "array",
+                    "maxItems": 1000,
+                    "items": {
+                        "$ref": "#/definitions/domain.DriverChange"
+                    }
+                },
+                "mirror": {
+                    "$ref": "#/definitions/domain.MirrorRange"
+                }
+            }
+        },
+        "domain.ReplicationOp": {
+            "type": "string",
+            "enum": [
+                "upsert",
+                "delete"
+            ],
+            "x-enum-varnames": [
+                "ReplicationUpsert",
+                "ReplicationDelete"
+            ]
+        },
+        "domain.ReplicationResult": {
+            "type": "object",
+            "properties": {
+                "applied": {
+                    "type": "integer"
+                },
+                "deleted": {
+                    "description": "drivers of the mirror range missing on the primary",
+                    "type": "integer"
+                },
+                "skipped": {
+                    "type": "integer"
+                }
+            }
+        },
         "domain.SearchRequest": {
             "type": "object",
             "required": [
@@ -415,12 +2113,79 @@ const docTemplate = `{
                 "location": {
                     "$ref": "#/definitions/domain.Point"
                 },
+                "min_capacity": {
+                    "description": "only drivers with at least this many seats",
+                    "type": "integer",
+                    "maximum": 100,
+                    "minimum": 0,
+                    "example": 4
+                },
+                "min_radius": {
+                    "description": "inner radius in meters, turns the search into an annulus",
+                    "type": "number",
+                    "minimum": 0
+                },
                 "radius": {
-                    "description": "radius in meters",
+                    "description": "radius in meters, at most SEARCH_MAX_RADIUS, SEARCH_DEFAULT_RADIUS when omitted and set",
+                    "type": "number",
+                    "maximum": 50000,
+                    "example": 500
+                },
+                "vehicle_type": {
+                    "description": "only drivers of this vehicle type",
+                    "type": "string",
+                    "maxLength": 32,
+                    "example": "sedan"
+                }
+            }
+        },
+        "domain.SetReadOnlyRequest": {
+            "type": "object",
+            "required": [
+                "enabled"
+            ],
+            "properties": {
+                "enabled": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "MongoDB primary failover"
+                }
+            }
+        },
+        "domain.TimingBreakdown": {
+            "type": "object",
+            "properties": {
+                "cache_ms": {
+                    "type": "number"
+                },
+                "db_ms": {
+                    "type": "number"
+                },
+                "total_ms": {
                     "type": "number"
                 }
             }
         },
+        "domain.UpdateStatusRequest": {
+            "type": "object",
+            "required": [
+                "status"
+            ],
+            "properties": {
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "available",
+                        "busy",
+                        "offline"
+                    ],
+                    "example": "busy"
+                }
+            }
+        },
         "http.APIResponse": {
             "type": "object",
             "properties": {
@@ -431,10 +2196,59 @@ const docTemplate = `{
                 "message": {
                     "type": "string"
                 },
+                "meta": {
+                    "$ref": "#/definitions/http.ResponseMeta"
+                },
                 "success": {
                     "type": "boolean"
                 }
             }
+        },
+        "http.CreateDriversPartialData": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "drivers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.Driver"
+                    }
+                },
+                "failed": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.BatchCreateFailure"
+                    }
+                },
+                "ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "http.LocationStreamAck": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "ok, throttled or error",
+                    "type": "string"
+                }
+            }
+        },
+        "http.ResponseMeta": {
+            "type": "object",
+            "properties": {
+                "timing": {
+                    "$ref": "#/definitions/domain.TimingBreakdown"
+                }
+            }
         }
     },
     "securityDefinitions": {
@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"the-driver-location-service/internal/domain"
+	"the-driver-location-service/internal/ports/secondary"
+)
+
+// OptionalDriverCache is the driver cache of an instance that started while
+// Redis was down. Until Enable is called it is an empty cache that keeps
+// nothing, so every read goes to MongoDB; afterwards every call goes to the
+// enabled cache. The services are handed it once at startup and never learn
+// whether the cache is on.
+type OptionalDriverCache struct {
+	cache atomic.Pointer[secondary.DriverCache]
+}
+
+var _ secondary.DriverCache = (*OptionalDriverCache)(nil)
+
+func NewOptionalDriverCache() *OptionalDriverCache {
+	return &OptionalDriverCache{}
+}
+
+// Enable turns the cache on, it is safe to call while requests are served
+func (c *OptionalDriverCache) Enable(cache secondary.DriverCache) {
+	c.cache.Store(&cache)
+}
+
+// Enabled reports whether Enable was called
+func (c *OptionalDriverCache) Enabled() bool {
+	return c.cache.Load() != nil
+}
+
+func (c *OptionalDriverCache) Get(ctx context.Context, driverID string) (*domain.Driver, error) {
+	if cache := c.cache.Load(); cache != nil {
+		return (*cache).Get(ctx, driverID)
+	}
+	return nil, nil
+}
+
+func (c *OptionalDriverCache) Set(ctx context.Context, driverID string, driver *domain.Driver, ttl time.Duration) error {
+	if cache := c.cache.Load(); cache != nil {
+		return (*cache).Set(ctx, driverID, driver, ttl)
+	}
+	return nil
+}
+
+func (c *OptionalDriverCache) SetNotFound(ctx context.Context, driverID string, ttl time.Duration) error {
+	if cache := c.cache.Load(); cache != nil {
+		return (*cache).SetNotFound(ctx, driverID, ttl)
+	}
+	return nil
+}
+
+func (c *OptionalDriverCache) Delete(ctx context.Context, driverID string) error {
+	if cache := c.cache.Load(); cache != nil {
+		return (*cache).Delete(ctx, driverID)
+	}
+	return nil
+}
+
+func (c *OptionalDriverCache) IsHealthy(ctx context.Context) bool {
+	if cache := c.cache.Load(); cache != nil {
+		return (*cache).IsHealthy(ctx)
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"the-driver-location-service/internal/domain"
+)
+
+type memoryDriverCache struct {
+	drivers map[string]*domain.Driver
+}
+
+func (c *memoryDriverCache) Get(ctx context.Context, driverID string) (*domain.Driver, error) {
+	return c.drivers[driverID], nil
+}
+
+func (c *memoryDriverCache) Set(ctx context.Context, driverID string, driver *domain.Driver, ttl time.Duration) error {
+	c.drivers[driverID] = driver
+	return nil
+}
+
+func (c *memoryDriverCache) SetNotFound(ctx context.Context, driverID string, ttl time.Duration) error {
+	return nil
+}
+
+func (c *memoryDriverCache) Delete(ctx context.Context, driverID string) error {
+	delete(c.drivers, driverID)
+	return nil
+}
+
+func (c *memoryDriverCache) IsHealthy(ctx context.Context) bool {
+	return true
+}
+
+// TestOptionalDriverCache tests the cache of an instance started without Redis
+// Expected: Should miss and keep nothing until enabled, then hand every call to the enabled cache
+func TestOptionalDriverCache(t *testing.T) {
+	ctx := context.Background()
+	optional := NewOptionalDriverCache()
+	driver := &domain.Driver{ID: "driver-1"}
+
+	require.NoError(t, optional.Set(ctx, "driver-1", driver, time.Minute))
+	cached, err := optional.Get(ctx, "driver-1")
+	require.NoError(t, err)
+	assert.Nil(t, cached)
+	assert.NoError(t, optional.Delete(ctx, "driver-1"))
+	assert.False(t, optional.IsHealthy(ctx))
+	assert.False(t, optional.Enabled())
+
+	memory := &memoryDriverCache{drivers: map[string]*domain.Driver{}}
+	optional.Enable(memory)
+	assert.True(t, optional.Enabled())
+	assert.True(t, optional.IsHealthy(ctx))
+
+	require.NoError(t, optional.Set(ctx, "driver-1", driver, time.Minute))
+	cached, err = optional.Get(ctx, "driver-1")
+	require.NoError(t, err)
+	assert.Equal(t, driver, cached)
+	require.NoError(t, optional.Delete(ctx, "driver-1"))
+	assert.Empty(t, memory.drivers)
+}
@@ -4,8 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math/rand/v2"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/redis/go-redis/v9"
 
 	"the-driver-location-service/config"
@@ -13,8 +16,31 @@ import (
 	"the-driver-location-service/internal/ports/secondary"
 )
 
+var (
+	// driverCacheLookupsTotal counts the cache reads by result, a falling hit
+	// ratio next to the invalidations tells writes from TTLs emptying the cache
+	driverCacheLookupsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "driver_location_service",
+		Name:      "driver_cache_lookups_total",
+		Help:      "Number of driver cache reads by result: hit, miss, not_found or error.",
+	}, []string{"result"})
+
+	// driverCacheInvalidationsTotal counts the drivers writes drop from the
+	// cache, removed when the driver was cached and absent otherwise. Every
+	// write drops the key of its own driver only, the cache is never flushed.
+	driverCacheInvalidationsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "driver_location_service",
+		Name:      "driver_cache_invalidations_total",
+		Help:      "Number of drivers dropped from the cache by writes, by whether the driver was cached.",
+	}, []string{"result"})
+)
+
+// notFoundMarker is cached for IDs no driver has, a driver is a JSON object
+const notFoundMarker = "-"
+
 type RedisDriverCache struct {
 	client *redis.Client
+	jitter float64 // fraction of a TTL taken off at random, 0 keeps TTLs exact
 }
 
 var _ secondary.DriverCache = (*RedisDriverCache)(nil)
@@ -25,7 +51,33 @@ func NewRedisDriverCache(client *redis.Client) *RedisDriverCache {
 	}
 }
 
+// SetTTLJitter shortens every TTL by a random part of up to fraction of it, so
+// drivers cached together (by a batch import or the warmup) do not all expire
+// in the same second and hit MongoDB at once. Shortening instead of lengthening
+// keeps the TTL the upper bound of how stale a cached driver is.
+func (c *RedisDriverCache) SetTTLJitter(fraction float64) {
+	c.jitter = fraction
+}
+
+// NewRedisClient connects to Redis and fails when it does not answer a ping
 func NewRedisClient(cfg config.RedisConfig) (*redis.Client, error) {
+	client := OpenRedisClient(cfg)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+	defer cancel()
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+
+	return client, nil
+}
+
+// OpenRedisClient creates a client without connecting, it dials on its first
+// command and again after the connections broke. main uses it when Redis was
+// down at startup, the cache comes on once the client answers.
+func OpenRedisClient(cfg config.RedisConfig) *redis.Client {
 	client := redis.NewClient(&redis.Options{
 		Addr:         cfg.Address,
 		Password:     cfg.Password,
@@ -36,15 +88,35 @@ func NewRedisClient(cfg config.RedisConfig) (*redis.Client, error) {
 		ReadTimeout:  cfg.Timeout,
 		WriteTimeout: cfg.Timeout,
 	})
+	client.AddHook(timingHook{})
+	return client
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
-	defer cancel()
+// timingHook adds the duration of every command and pipeline to the timing of
+// the request it was run for, see domain.WithTiming. The client is shared, so
+// the rate limiter and the geo index count as well as the driver cache.
+type timingHook struct{}
 
-	if err := client.Ping(ctx).Err(); err != nil {
-		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+func (timingHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (timingHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmd)
+		domain.TimingFrom(ctx).AddCache(time.Since(start))
+		return err
 	}
+}
 
-	return client, nil
+func (timingHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmds)
+		domain.TimingFrom(ctx).AddCache(time.Since(start))
+		return err
+	}
 }
 
 func (c *RedisDriverCache) Get(ctx context.Context, driverID string) (*domain.Driver, error) {
@@ -53,16 +125,24 @@ func (c *RedisDriverCache) Get(ctx context.Context, driverID string) (*domain.Dr
 	data, err := c.client.Get(ctx, key).Result()
 	if err != nil {
 		if err == redis.Nil {
+			driverCacheLookupsTotal.WithLabelValues("miss").Inc()
 			return nil, nil
 		}
+		driverCacheLookupsTotal.WithLabelValues("error").Inc()
 		return nil, fmt.Errorf("failed to get driver from cache: %w", err)
 	}
+	if data == notFoundMarker {
+		driverCacheLookupsTotal.WithLabelValues("not_found").Inc()
+		return nil, fmt.Errorf("%w: %s", domain.ErrDriverNotFound, driverID)
+	}
 
 	var driver domain.Driver
 	if err := json.Unmarshal([]byte(data), &driver); err != nil {
+		driverCacheLookupsTotal.WithLabelValues("error").Inc()
 		return nil, fmt.Errorf("failed to unmarshal driver: %w", err)
 	}
 
+	driverCacheLookupsTotal.WithLabelValues("hit").Inc()
 	return &driver, nil
 }
 
@@ -74,7 +154,7 @@ func (c *RedisDriverCache) Set(ctx context.Context, driverID string, driver *dom
 		return fmt.Errorf("failed to marshal driver: %w", err)
 	}
 
-	err = c.client.Set(ctx, key, data, ttl).Err()
+	err = c.client.Set(ctx, key, data, jitterTTL(ttl, c.jitter, rand.Float64())).Err()
 	if err != nil {
 		return fmt.Errorf("failed to set driver in cache: %w", err)
 	}
@@ -82,14 +162,32 @@ func (c *RedisDriverCache) Set(ctx context.Context, driverID string, driver *dom
 	return nil
 }
 
+// SetNotFound stores the marker under the key of the driver, so creating the
+// driver overwrites it and deleting it removes it like a cached driver
+func (c *RedisDriverCache) SetNotFound(ctx context.Context, driverID string, ttl time.Duration) error {
+	key := c.generateDriverKey(driverID)
+
+	err := c.client.Set(ctx, key, notFoundMarker, jitterTTL(ttl, c.jitter, rand.Float64())).Err()
+	if err != nil {
+		return fmt.Errorf("failed to cache missing driver: %w", err)
+	}
+
+	return nil
+}
+
 func (c *RedisDriverCache) Delete(ctx context.Context, driverID string) error {
 	key := c.generateDriverKey(driverID)
 
-	err := c.client.Del(ctx, key).Err()
+	removed, err := c.client.Del(ctx, key).Result()
 	if err != nil {
 		return fmt.Errorf("failed to delete driver from cache: %w", err)
 	}
 
+	if removed > 0 {
+		driverCacheInvalidationsTotal.WithLabelValues("removed").Inc()
+	} else {
+		driverCacheInvalidationsTotal.WithLabelValues("absent").Inc()
+	}
 	return nil
 }
 
@@ -98,6 +196,14 @@ func (c *RedisDriverCache) IsHealthy(ctx context.Context) bool {
 	return err == nil
 }
 
+// jitterTTL takes off r (in [0, 1)) times fraction of ttl
+func jitterTTL(ttl time.Duration, fraction, r float64) time.Duration {
+	if fraction <= 0 || ttl <= 0 {
+		return ttl
+	}
+	return ttl - time.Duration(float64(ttl)*fraction*r)
+}
+
 func (c *RedisDriverCache) generateDriverKey(driverID string) string {
 	return fmt.Sprintf("driver:%s", driverID)
 }
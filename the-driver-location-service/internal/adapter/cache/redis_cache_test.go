@@ -6,6 +6,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -66,6 +68,24 @@ func TestRedisDriverCache_SetGetDelete(t *testing.T) {
 	assert.Nil(t, gone)
 }
 
+// TestRedisDriverCache_Set_TTLJitter tests caching drivers with a TTL jitter
+// Expected: Should store every driver with a TTL between (1 - jitter) * ttl and ttl
+func TestRedisDriverCache_Set_TTLJitter(t *testing.T) {
+	cache, cleanup := setupRedisTestCache(t)
+	defer cleanup()
+	cache.SetTTLJitter(0.5)
+	ctx := context.Background()
+
+	for i := 0; i < 10; i++ {
+		drv := &domain.Driver{ID: fmt.Sprintf("d%d", i), Location: domain.NewPoint(29, 41)}
+		require.NoError(t, cache.Set(ctx, drv.ID, drv, time.Minute))
+		ttl, err := cache.client.TTL(ctx, cache.generateDriverKey(drv.ID)).Result()
+		require.NoError(t, err)
+		assert.LessOrEqual(t, ttl, time.Minute)
+		assert.GreaterOrEqual(t, ttl, 29*time.Second)
+	}
+}
+
 // TestRedisDriverCache_Get_CacheMiss tests cache retrieval when key doesn't exist
 // Expected: Should return nil, nil when cache key is not found (cache miss)
 func TestRedisDriverCache_Get_CacheMiss(t *testing.T) {
@@ -78,6 +98,64 @@ func TestRedisDriverCache_Get_CacheMiss(t *testing.T) {
 	assert.Nil(t, got)
 }
 
+// TestRedisDriverCache_SetNotFound tests caching an ID no driver has
+// Expected: Should answer not found until the driver is cached or the entry deleted
+func TestRedisDriverCache_SetNotFound(t *testing.T) {
+	cache, cleanup := setupRedisTestCache(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	require.NoError(t, cache.SetNotFound(ctx, "missing", time.Minute))
+	got, err := cache.Get(ctx, "missing")
+	assert.ErrorIs(t, err, domain.ErrDriverNotFound)
+	assert.Nil(t, got)
+
+	drv := &domain.Driver{ID: "missing", Location: domain.NewPoint(29, 41)}
+	require.NoError(t, cache.Set(ctx, drv.ID, drv, time.Minute))
+	got, err = cache.Get(ctx, drv.ID)
+	require.NoError(t, err)
+	assert.Equal(t, drv.ID, got.ID)
+
+	require.NoError(t, cache.SetNotFound(ctx, "missing", time.Minute))
+	require.NoError(t, cache.Delete(ctx, "missing"))
+	got, err = cache.Get(ctx, "missing")
+	require.NoError(t, err)
+	assert.Nil(t, got)
+}
+
+func counterValue(t *testing.T, counter prometheus.Counter) float64 {
+	t.Helper()
+	var metric dto.Metric
+	require.NoError(t, counter.Write(&metric))
+	return metric.GetCounter().GetValue()
+}
+
+// TestRedisDriverCache_Metrics tests the lookup and invalidation counters of the cache
+// Expected: Should count hits and misses, and drivers dropped by a write apart from drivers that were not cached
+func TestRedisDriverCache_Metrics(t *testing.T) {
+	cache, cleanup := setupRedisTestCache(t)
+	defer cleanup()
+	ctx := context.Background()
+	hits := counterValue(t, driverCacheLookupsTotal.WithLabelValues("hit"))
+	misses := counterValue(t, driverCacheLookupsTotal.WithLabelValues("miss"))
+	removed := counterValue(t, driverCacheInvalidationsTotal.WithLabelValues("removed"))
+	absent := counterValue(t, driverCacheInvalidationsTotal.WithLabelValues("absent"))
+
+	drv := &domain.Driver{ID: "d1", Location: domain.NewPoint(29, 41)}
+	require.NoError(t, cache.Set(ctx, drv.ID, drv, time.Minute))
+	_, err := cache.Get(ctx, drv.ID)
+	require.NoError(t, err)
+	require.NoError(t, cache.Delete(ctx, drv.ID))
+	_, err = cache.Get(ctx, drv.ID)
+	require.NoError(t, err)
+	require.NoError(t, cache.Delete(ctx, drv.ID))
+
+	assert.Equal(t, hits+1, counterValue(t, driverCacheLookupsTotal.WithLabelValues("hit")))
+	assert.Equal(t, misses+1, counterValue(t, driverCacheLookupsTotal.WithLabelValues("miss")))
+	assert.Equal(t, removed+1, counterValue(t, driverCacheInvalidationsTotal.WithLabelValues("removed")))
+	assert.Equal(t, absent+1, counterValue(t, driverCacheInvalidationsTotal.WithLabelValues("absent")))
+}
+
 // TestRedisDriverCache_Get_CorruptData tests error handling when cached data is corrupted
 // Expected: Should return error when cached data is corrupted/invalid JSON
 func TestRedisDriverCache_Get_CorruptData(t *testing.T) {
@@ -0,0 +1,424 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"the-driver-location-service/internal/domain"
+	"the-driver-location-service/internal/ports/secondary"
+)
+
+const (
+	geoIndexKey   = "drivers:geo"         // GEO set of the available drivers
+	geoDataKey    = "drivers:geo:data"    // driver ID -> driver JSON of the indexed drivers
+	geoUpdatedKey = "drivers:geo:updated" // driver ID -> updated_at in ms of the last indexed write
+	geoSeenKey    = "drivers:geo:seen"    // driver ID -> time in ms of the last heartbeat
+
+	// redis only stores positions of the web mercator range
+	geoMaxLatitude = 85.05112878
+
+	geoRebuildBatchSize = 500
+)
+
+// geoIndexScript indexes a write unless a newer one was indexed already, writes
+// of the same driver racing between mongo and redis can land in any order
+var geoIndexScript = redis.NewScript(`
+local current = tonumber(redis.call('HGET', KEYS[3], ARGV[1]) or '0')
+if tonumber(ARGV[2]) < current then
+	return 0
+end
+redis.call('HSET', KEYS[3], ARGV[1], ARGV[2])
+if ARGV[3] == '1' then
+	redis.call('GEOADD', KEYS[1], ARGV[4], ARGV[5], ARGV[1])
+	redis.call('HSET', KEYS[2], ARGV[1], ARGV[6])
+else
+	redis.call('ZREM', KEYS[1], ARGV[1])
+	redis.call('HDEL', KEYS[2], ARGV[1])
+end
+return 1
+`)
+
+// geoOfflineScript removes a driver taken offline unless it was updated after
+// the inactivity check saw it
+var geoOfflineScript = redis.NewScript(`
+local current = tonumber(redis.call('HGET', KEYS[3], ARGV[1]) or '0')
+if current > tonumber(ARGV[2]) then
+	return 0
+end
+redis.call('ZREM', KEYS[1], ARGV[1])
+redis.call('HDEL', KEYS[2], ARGV[1])
+return 1
+`)
+
+// GeoDriverStore is the durable store behind the redis geo index, the index is
+// rebuilt from it and the inactivity check and replication go through to it
+type GeoDriverStore interface {
+	secondary.DriverRepository
+	secondary.DriverBackfillStore
+	secondary.DriverInactivityStore
+	secondary.DriverReplicaStore
+}
+
+// RedisGeoDriverRepository serves nearby searches from a redis GEO set of the
+// available drivers and keeps mongo as the durable store. Writes go to mongo
+// first and are indexed afterwards, every other query goes to mongo. Searches
+// fall back to mongo until Rebuild indexed the stored drivers, for annulus
+// searches and for strong consistency reads.
+type RedisGeoDriverRepository struct {
+	client    *redis.Client
+	store     GeoDriverStore
+	ready     atomic.Bool
+	maxAge    time.Duration
+	heartbeat time.Duration
+	logger    secondary.Logger
+}
+
+var _ secondary.DriverRepository = (*RedisGeoDriverRepository)(nil)
+var _ secondary.DriverInactivityStore = (*RedisGeoDriverRepository)(nil)
+var _ secondary.DriverReplicaStore = (*RedisGeoDriverRepository)(nil)
+
+func NewRedisGeoDriverRepository(client *redis.Client, store GeoDriverStore) *RedisGeoDriverRepository {
+	return &RedisGeoDriverRepository{
+		client: client,
+		store:  store,
+		logger: secondary.NopLogger{},
+	}
+}
+
+// SetMaxLocationAge leaves drivers without a location update for longer out of
+// the searches answered from redis, as the store does for its own searches
+func (r *RedisGeoDriverRepository) SetMaxLocationAge(maxAge time.Duration) {
+	r.maxAge = maxAge
+}
+
+// SetHeartbeatTimeout leaves drivers whose heartbeat lapsed for longer out of
+// the searches answered from redis, as the store does for its own searches
+func (r *RedisGeoDriverRepository) SetHeartbeatTimeout(timeout time.Duration) {
+	r.heartbeat = timeout
+}
+
+// SetLogger sets where writes that mongo took but the index missed are logged
+func (r *RedisGeoDriverRepository) SetLogger(logger secondary.Logger) {
+	r.logger = logger
+}
+
+// Rebuild indexes every stored driver and then serves the searches from redis,
+// drivers indexed meanwhile by newer writes are left as they are
+func (r *RedisGeoDriverRepository) Rebuild(ctx context.Context) error {
+	start := time.Now()
+	afterID, indexed := "", 0
+	for {
+		drivers, err := r.store.ScanAfter(ctx, afterID, geoRebuildBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to scan drivers: %w", err)
+		}
+		if len(drivers) == 0 {
+			break
+		}
+		if err := r.index(ctx, drivers...); err != nil {
+			return err
+		}
+		indexed += len(drivers)
+		afterID = drivers[len(drivers)-1].ID
+	}
+
+	r.ready.Store(true)
+	r.logger.Info(ctx, "redis geo index rebuilt", "drivers", indexed, "duration", time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+func (r *RedisGeoDriverRepository) Create(ctx context.Context, driver *domain.Driver) error {
+	if err := r.store.Create(ctx, driver); err != nil {
+		return err
+	}
+	r.indexStored(ctx, driver)
+	return nil
+}
+
+func (r *RedisGeoDriverRepository) BatchCreate(ctx context.Context, drivers []*domain.Driver) error {
+	if err := r.store.BatchCreate(ctx, drivers); err != nil {
+		return err
+	}
+	r.indexStored(ctx, drivers...)
+	return nil
+}
+
+func (r *RedisGeoDriverRepository) BatchCreateUnordered(ctx context.Context, drivers []*domain.Driver) ([]domain.BatchCreateFailure, error) {
+	failures, err := r.store.BatchCreateUnordered(ctx, drivers)
+	if err != nil {
+		return nil, err
+	}
+	r.indexStored(ctx, domain.CreatedDrivers(drivers, failures)...)
+	return failures, nil
+}
+
+func (r *RedisGeoDriverRepository) Update(ctx context.Context, driver *domain.Driver) error {
+	if err := r.store.Update(ctx, driver); err != nil {
+		return err
+	}
+	r.indexStored(ctx, driver)
+	return nil
+}
+
+func (r *RedisGeoDriverRepository) Delete(ctx context.Context, id string) error {
+	if err := r.store.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRem(ctx, geoIndexKey, id)
+		pipe.HDel(ctx, geoDataKey, id)
+		pipe.HDel(ctx, geoUpdatedKey, id)
+		pipe.HDel(ctx, geoSeenKey, id)
+		return nil
+	})
+	if err != nil {
+		r.logger.Warn(ctx, "failed to remove driver from the redis geo index", "driver_id", id, "error", err)
+	}
+	return nil
+}
+
+// Heartbeat records the heartbeat in mongo and next to the geo index, the
+// indexed driver is left as it is since its location did not change
+func (r *RedisGeoDriverRepository) Heartbeat(ctx context.Context, id string, at time.Time) error {
+	if err := r.store.Heartbeat(ctx, id, at); err != nil {
+		return err
+	}
+
+	if err := r.client.HSet(context.WithoutCancel(ctx), geoSeenKey, id, at.UnixMilli()).Err(); err != nil {
+		r.logger.Warn(ctx, "failed to record the heartbeat of driver in redis", "driver_id", id, "error", err)
+	}
+	return nil
+}
+
+// SearchNearby answers from the geo index, redis returns the distances it
+// sorted the drivers by
+func (r *RedisGeoDriverRepository) SearchNearby(ctx context.Context, location domain.Point, minRadiusMeters, radiusMeters float64, limit int, filter domain.DriverFilter) ([]*domain.DriverWithDistance, error) {
+	if !r.servesSearch(ctx, minRadiusMeters, filter) {
+		return r.store.SearchNearby(ctx, location, minRadiusMeters, radiusMeters, limit, filter)
+	}
+	return r.search(ctx, location, radiusMeters, limit)
+}
+
+// SearchGeoNear answers from the geo index too, the distances come from redis
+// either way
+func (r *RedisGeoDriverRepository) SearchGeoNear(ctx context.Context, location domain.Point, minRadiusMeters, radiusMeters float64, limit int, filter domain.DriverFilter) ([]*domain.DriverWithDistance, error) {
+	if !r.servesSearch(ctx, minRadiusMeters, filter) {
+		return r.store.SearchGeoNear(ctx, location, minRadiusMeters, radiusMeters, limit, filter)
+	}
+	return r.search(ctx, location, radiusMeters, limit)
+}
+
+func (r *RedisGeoDriverRepository) SearchWithin(ctx context.Context, area domain.Area, limit int) ([]*domain.Driver, error) {
+	return r.store.SearchWithin(ctx, area, limit)
+}
+
+func (r *RedisGeoDriverRepository) SearchInBox(ctx context.Context, req domain.BoxSearchRequest, limit int) ([]*domain.Driver, error) {
+	return r.store.SearchInBox(ctx, req, limit)
+}
+
+func (r *RedisGeoDriverRepository) ClusterInBox(ctx context.Context, req domain.BoxSearchRequest, limit int) ([]domain.DriverCluster, error) {
+	return r.store.ClusterInBox(ctx, req, limit)
+}
+
+func (r *RedisGeoDriverRepository) SearchInCell(ctx context.Context, cell domain.CellID, limit int) ([]*domain.Driver, error) {
+	return r.store.SearchInCell(ctx, cell, limit)
+}
+
+func (r *RedisGeoDriverRepository) CountInCell(ctx context.Context, cell domain.CellID, level, limit int) ([]domain.CellCount, error) {
+	return r.store.CountInCell(ctx, cell, level, limit)
+}
+
+func (r *RedisGeoDriverRepository) GetByID(ctx context.Context, id string) (*domain.Driver, error) {
+	return r.store.GetByID(ctx, id)
+}
+
+func (r *RedisGeoDriverRepository) List(ctx context.Context, filter domain.DriverListFilter, afterID string, limit int) ([]*domain.Driver, error) {
+	return r.store.List(ctx, filter, afterID, limit)
+}
+
+func (r *RedisGeoDriverRepository) StaleBefore(ctx context.Context, before time.Time, limit int) ([]*domain.Driver, error) {
+	return r.store.StaleBefore(ctx, before, limit)
+}
+
+// MarkOffline takes the driver out of the geo index once mongo took it offline
+func (r *RedisGeoDriverRepository) MarkOffline(ctx context.Context, id string, lastSeenAt time.Time) (bool, error) {
+	marked, err := r.store.MarkOffline(ctx, id, lastSeenAt)
+	if err != nil || !marked {
+		return marked, err
+	}
+
+	keys := []string{geoIndexKey, geoDataKey, geoUpdatedKey}
+	if err := geoOfflineScript.Run(ctx, r.client, keys, id, lastSeenAt.UnixMilli()).Err(); err != nil {
+		r.logger.Warn(ctx, "failed to remove offline driver from the redis geo index", "driver_id", id, "error", err)
+	}
+	return true, nil
+}
+
+func (r *RedisGeoDriverRepository) ScanAfter(ctx context.Context, afterID string, limit int) ([]*domain.Driver, error) {
+	return r.store.ScanAfter(ctx, afterID, limit)
+}
+
+// ApplyReplicatedUpsert indexes the replicated driver once mongo took it, the
+// index keeps the newest write as it does for local ones
+func (r *RedisGeoDriverRepository) ApplyReplicatedUpsert(ctx context.Context, driver *domain.Driver) (bool, error) {
+	applied, err := r.store.ApplyReplicatedUpsert(ctx, driver)
+	if err != nil || !applied {
+		return applied, err
+	}
+	r.indexStored(ctx, driver)
+	return true, nil
+}
+
+// ApplyReplicatedDelete takes the driver out of the geo index once mongo deleted it
+func (r *RedisGeoDriverRepository) ApplyReplicatedDelete(ctx context.Context, id string, deletedAt time.Time) (bool, error) {
+	deleted, err := r.store.ApplyReplicatedDelete(ctx, id, deletedAt)
+	if err != nil || !deleted {
+		return deleted, err
+	}
+
+	keys := []string{geoIndexKey, geoDataKey, geoUpdatedKey}
+	if err := geoOfflineScript.Run(ctx, r.client, keys, id, deletedAt.UnixMilli()).Err(); err != nil {
+		r.logger.Warn(ctx, "failed to remove replicated driver from the redis geo index", "driver_id", id, "error", err)
+	}
+	return true, nil
+}
+
+// servesSearch reports whether a search can be answered from the geo index,
+// GEOSEARCH has no minimum distance, the index trails mongo and it keeps no
+// vehicle data, so filtered searches go to the store
+func (r *RedisGeoDriverRepository) servesSearch(ctx context.Context, minRadiusMeters float64, filter domain.DriverFilter) bool {
+	return r.ready.Load() && minRadiusMeters <= 0 && filter.IsZero() && !domain.IsStrongConsistency(ctx)
+}
+
+func (r *RedisGeoDriverRepository) search(ctx context.Context, location domain.Point, radiusMeters float64, limit int) ([]*domain.DriverWithDistance, error) {
+	locations, err := r.client.GeoSearchLocation(ctx, geoIndexKey, &redis.GeoSearchLocationQuery{
+		GeoSearchQuery: redis.GeoSearchQuery{
+			Longitude:  location.Longitude(),
+			Latitude:   location.Latitude(),
+			Radius:     radiusMeters,
+			RadiusUnit: "m",
+			Sort:       "ASC",
+			Count:      limit,
+		},
+		WithDist: true,
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to search nearby drivers in redis: %w", err)
+	}
+	if len(locations) == 0 {
+		return []*domain.DriverWithDistance{}, nil
+	}
+
+	ids := make([]string, len(locations))
+	for i, loc := range locations {
+		ids[i] = loc.Name
+	}
+	var dataCmd, seenCmd *redis.SliceCmd
+	_, err = r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		dataCmd = pipe.HMGet(ctx, geoDataKey, ids...)
+		if r.heartbeat > 0 {
+			seenCmd = pipe.HMGet(ctx, geoSeenKey, ids...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load nearby drivers from redis: %w", err)
+	}
+	values := dataCmd.Val()
+
+	// stale drivers stay indexed until the inactivity check takes them offline,
+	// they are dropped here so a search may return fewer than limit drivers
+	var cutoff, heartbeatCutoff time.Time
+	if r.maxAge > 0 {
+		cutoff = time.Now().Add(-r.maxAge)
+	}
+	if r.heartbeat > 0 {
+		heartbeatCutoff = time.Now().Add(-r.heartbeat)
+	}
+
+	result := make([]*domain.DriverWithDistance, 0, len(locations))
+	for i, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			// removed between the two commands
+			continue
+		}
+		var driver domain.Driver
+		if err := json.Unmarshal([]byte(data), &driver); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal driver %s: %w", ids[i], err)
+		}
+		if driver.UpdatedAt.Before(cutoff) {
+			continue
+		}
+		if seenCmd != nil && heartbeatLapsed(&driver, seenCmd.Val()[i], heartbeatCutoff) {
+			continue
+		}
+		result = append(result, &domain.DriverWithDistance{Driver: driver, Distance: locations[i].Dist})
+	}
+	return result, nil
+}
+
+// heartbeatLapsed reports whether a driver that sends heartbeats was last seen
+// before the cutoff, the indexed driver carries the last update and seen the
+// last heartbeat recorded in redis
+func heartbeatLapsed(driver *domain.Driver, seen interface{}, cutoff time.Time) bool {
+	lastSeen := driver.LastSeenAt
+	if value, ok := seen.(string); ok {
+		if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+			if at := time.UnixMilli(ms); lastSeen == nil || at.After(*lastSeen) {
+				lastSeen = &at
+			}
+		}
+	}
+	return lastSeen != nil && lastSeen.Before(cutoff)
+}
+
+// indexStored indexes drivers that are already stored in mongo, a failure only
+// leaves the index behind until the next write of the driver so it is logged
+func (r *RedisGeoDriverRepository) indexStored(ctx context.Context, drivers ...*domain.Driver) {
+	if err := r.index(context.WithoutCancel(ctx), drivers...); err != nil {
+		r.logger.Warn(ctx, "failed to index stored drivers", "drivers", len(drivers), "error", err)
+	}
+}
+
+func (r *RedisGeoDriverRepository) index(ctx context.Context, drivers ...*domain.Driver) error {
+	keys := []string{geoIndexKey, geoDataKey, geoUpdatedKey}
+	_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, driver := range drivers {
+			data, err := json.Marshal(driver)
+			if err != nil {
+				return fmt.Errorf("failed to marshal driver %s: %w", driver.ID, err)
+			}
+			available := "0"
+			if isIndexable(driver) {
+				available = "1"
+			}
+			// EVALSHA cannot fall back to EVAL inside a pipeline
+			geoIndexScript.Eval(ctx, pipe, keys, driver.ID, driver.UpdatedAt.UnixMilli(), available,
+				driver.Location.Longitude(), driver.Location.Latitude(), data)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to index drivers in redis: %w", err)
+	}
+	return nil
+}
+
+// isIndexable reports whether nearby searches may return the driver, drivers
+// without a status were stored before it existed and count as available
+func isIndexable(driver *domain.Driver) bool {
+	if driver.Status != "" && driver.Status != domain.DriverStatusAvailable {
+		return false
+	}
+	return len(driver.Location.Coordinates) == 2 && math.Abs(driver.Location.Latitude()) <= geoMaxLatitude
+}
@@ -0,0 +1,252 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	"the-driver-location-service/internal/domain"
+	"the-driver-location-service/internal/ports/secondary"
+)
+
+// memoryGeoStore is the durable store of the tests, the methods the geo
+// repository only passes through are left to the embedded nil interfaces
+type memoryGeoStore struct {
+	secondary.DriverRepository
+	secondary.DriverBackfillStore
+	secondary.DriverInactivityStore
+	secondary.DriverReplicaStore
+	drivers       map[string]*domain.Driver
+	storeSearches int
+}
+
+func newMemoryGeoStore(drivers ...*domain.Driver) *memoryGeoStore {
+	store := &memoryGeoStore{drivers: make(map[string]*domain.Driver)}
+	for _, driver := range drivers {
+		driver.UpdatedAt = time.Now()
+		store.drivers[driver.ID] = driver
+	}
+	return store
+}
+
+func (s *memoryGeoStore) Create(_ context.Context, driver *domain.Driver) error {
+	driver.UpdatedAt = time.Now()
+	s.drivers[driver.ID] = driver
+	return nil
+}
+
+func (s *memoryGeoStore) Update(_ context.Context, driver *domain.Driver) error {
+	driver.UpdatedAt = time.Now()
+	s.drivers[driver.ID] = driver
+	return nil
+}
+
+func (s *memoryGeoStore) Delete(_ context.Context, id string) error {
+	delete(s.drivers, id)
+	return nil
+}
+
+func (s *memoryGeoStore) Heartbeat(_ context.Context, id string, at time.Time) error {
+	driver, ok := s.drivers[id]
+	if !ok {
+		return fmt.Errorf("%w: %s", domain.ErrDriverNotFound, id)
+	}
+	driver.LastSeenAt = &at
+	return nil
+}
+
+func (s *memoryGeoStore) SearchNearby(_ context.Context, location domain.Point, minRadiusMeters, radiusMeters float64, limit int, filter domain.DriverFilter) ([]*domain.DriverWithDistance, error) {
+	s.storeSearches++
+	return []*domain.DriverWithDistance{}, nil
+}
+
+func (s *memoryGeoStore) ScanAfter(_ context.Context, afterID string, limit int) ([]*domain.Driver, error) {
+	ids := make([]string, 0, len(s.drivers))
+	for id := range s.drivers {
+		if id > afterID {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+	drivers := make([]*domain.Driver, len(ids))
+	for i, id := range ids {
+		drivers[i] = s.drivers[id]
+	}
+	return drivers, nil
+}
+
+func (s *memoryGeoStore) MarkOffline(_ context.Context, id string, lastSeenAt time.Time) (bool, error) {
+	s.drivers[id].Status = domain.DriverStatusOffline
+	return true, nil
+}
+
+func setupRedisGeoRepository(t *testing.T, store *memoryGeoStore) (*RedisGeoDriverRepository, func()) {
+	t.Helper()
+	ctx := context.Background()
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        "redis:7",
+			ExposedPorts: []string{"6379/tcp"},
+			WaitingFor:   wait.ForListeningPort("6379/tcp").WithStartupTimeout(20 * time.Second),
+		},
+		Started: true,
+	})
+	require.NoError(t, err)
+
+	host, err := container.Host(ctx)
+	require.NoError(t, err)
+	port, err := container.MappedPort(ctx, "6379")
+	require.NoError(t, err)
+
+	client := redis.NewClient(&redis.Options{Addr: fmt.Sprintf("%s:%s", host, port.Port())})
+	require.NoError(t, client.Ping(ctx).Err())
+
+	cleanup := func() {
+		client.Close()
+		container.Terminate(ctx)
+	}
+	return NewRedisGeoDriverRepository(client, store), cleanup
+}
+
+// TestRedisGeoDriverRepository_SearchNearby tests nearby searches served from the geo index
+// Expected: Should return the available drivers nearest first with the distances of redis
+func TestRedisGeoDriverRepository_SearchNearby(t *testing.T) {
+	store := newMemoryGeoStore(
+		&domain.Driver{ID: "g1", Location: domain.NewPoint(29.0, 41.0)},
+		&domain.Driver{ID: "g2", Location: domain.NewPoint(29.002, 41.0), Status: domain.DriverStatusAvailable},
+		&domain.Driver{ID: "g3", Location: domain.NewPoint(29.001, 41.0), Status: domain.DriverStatusBusy},
+		&domain.Driver{ID: "g4", Location: domain.NewPoint(30.0, 41.0)},
+	)
+	repo, cleanup := setupRedisGeoRepository(t, store)
+	defer cleanup()
+	ctx := context.Background()
+	require.NoError(t, repo.Rebuild(ctx))
+
+	found, err := repo.SearchNearby(ctx, domain.NewPoint(29.0, 41.0), 0, 1000, 10, domain.DriverFilter{})
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	assert.Equal(t, "g1", found[0].Driver.ID)
+	assert.Equal(t, "g2", found[1].Driver.ID)
+	assert.InDelta(t, domain.NewPoint(29.0, 41.0).Distance(domain.NewPoint(29.002, 41.0)), found[1].Distance, 1)
+	assert.Zero(t, store.storeSearches)
+
+	// writes keep the index in step with the store
+	g2 := *store.drivers["g2"]
+	g2.Status = domain.DriverStatusBusy
+	require.NoError(t, repo.Update(ctx, &g2))
+	require.NoError(t, repo.Create(ctx, &domain.Driver{ID: "g5", Location: domain.NewPoint(29.0005, 41.0)}))
+	require.NoError(t, repo.Delete(ctx, "g1"))
+
+	found, err = repo.SearchNearby(ctx, domain.NewPoint(29.0, 41.0), 0, 1000, 10, domain.DriverFilter{})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, "g5", found[0].Driver.ID)
+
+	marked, err := repo.MarkOffline(ctx, "g5", store.drivers["g5"].UpdatedAt)
+	require.NoError(t, err)
+	assert.True(t, marked)
+	found, err = repo.SearchNearby(ctx, domain.NewPoint(29.0, 41.0), 0, 1000, 10, domain.DriverFilter{})
+	require.NoError(t, err)
+	assert.Empty(t, found)
+}
+
+// TestRedisGeoDriverRepository_StaleWrite tests indexing a write older than the indexed one
+// Expected: Should keep the newer position
+func TestRedisGeoDriverRepository_StaleWrite(t *testing.T) {
+	store := newMemoryGeoStore(&domain.Driver{ID: "s1", Location: domain.NewPoint(29.0, 41.0)})
+	repo, cleanup := setupRedisGeoRepository(t, store)
+	defer cleanup()
+	ctx := context.Background()
+	require.NoError(t, repo.Rebuild(ctx))
+
+	stale := &domain.Driver{ID: "s1", Location: domain.NewPoint(35.0, 39.0), UpdatedAt: time.Now().Add(-time.Minute)}
+	require.NoError(t, repo.index(ctx, stale))
+
+	found, err := repo.SearchNearby(ctx, domain.NewPoint(29.0, 41.0), 0, 100, 10, domain.DriverFilter{})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, 29.0, found[0].Driver.Location.Longitude())
+}
+
+// TestRedisGeoDriverRepository_FallsBackToStore tests searches the geo index cannot answer
+// Expected: Should search the store before the rebuild, for annulus searches, vehicle filters and strong reads
+func TestRedisGeoDriverRepository_FallsBackToStore(t *testing.T) {
+	store := newMemoryGeoStore()
+	// never reached, every search below goes to the store
+	repo := NewRedisGeoDriverRepository(redis.NewClient(&redis.Options{Addr: "localhost:0"}), store)
+	ctx := context.Background()
+	location := domain.NewPoint(29, 41)
+
+	_, err := repo.SearchNearby(ctx, location, 0, 1000, 10, domain.DriverFilter{})
+	require.NoError(t, err)
+
+	repo.ready.Store(true)
+	_, err = repo.SearchNearby(ctx, location, 500, 1000, 10, domain.DriverFilter{})
+	require.NoError(t, err)
+	_, err = repo.SearchNearby(domain.WithConsistency(ctx, domain.ConsistencyStrong), location, 0, 1000, 10, domain.DriverFilter{})
+	require.NoError(t, err)
+	_, err = repo.SearchNearby(ctx, location, 0, 1000, 10, domain.DriverFilter{VehicleType: "van"})
+	require.NoError(t, err)
+
+	assert.Equal(t, 4, store.storeSearches)
+}
+
+// TestRedisGeoDriverRepository_Heartbeat tests searches of the geo index with a heartbeat timeout
+// Expected: Should leave drivers whose heartbeat lapsed out and keep them once a heartbeat arrives
+func TestRedisGeoDriverRepository_Heartbeat(t *testing.T) {
+	lapsedAt := time.Now().Add(-time.Hour)
+	store := newMemoryGeoStore(
+		&domain.Driver{ID: "h1", Location: domain.NewPoint(29.0, 41.0)},
+		&domain.Driver{ID: "h2", Location: domain.NewPoint(29.001, 41.0), LastSeenAt: &lapsedAt},
+	)
+	repo, cleanup := setupRedisGeoRepository(t, store)
+	defer cleanup()
+	repo.SetHeartbeatTimeout(time.Minute)
+	ctx := context.Background()
+	require.NoError(t, repo.Rebuild(ctx))
+
+	found, err := repo.SearchNearby(ctx, domain.NewPoint(29.0, 41.0), 0, 1000, 10, domain.DriverFilter{})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, "h1", found[0].Driver.ID)
+
+	require.NoError(t, repo.Heartbeat(ctx, "h2", time.Now()))
+	found, err = repo.SearchNearby(ctx, domain.NewPoint(29.0, 41.0), 0, 1000, 10, domain.DriverFilter{})
+	require.NoError(t, err)
+	assert.Len(t, found, 2)
+}
+
+// TestHeartbeatLapsed tests which indexed drivers the heartbeat timeout leaves out
+// Expected: Should use the later of the indexed and the recorded heartbeat and keep drivers without one
+func TestHeartbeatLapsed(t *testing.T) {
+	cutoff := time.Now().Add(-time.Minute)
+	old := cutoff.Add(-time.Minute)
+	recent := strconv.FormatInt(time.Now().UnixMilli(), 10)
+
+	assert.False(t, heartbeatLapsed(&domain.Driver{}, nil, cutoff))
+	assert.True(t, heartbeatLapsed(&domain.Driver{LastSeenAt: &old}, nil, cutoff))
+	assert.False(t, heartbeatLapsed(&domain.Driver{LastSeenAt: &old}, recent, cutoff))
+	assert.False(t, heartbeatLapsed(&domain.Driver{}, recent, cutoff))
+	assert.True(t, heartbeatLapsed(&domain.Driver{}, strconv.FormatInt(old.UnixMilli(), 10), cutoff))
+}
+
+// TestIsIndexable tests which drivers nearby searches may return
+// Expected: Should index available drivers and drivers without a status inside the mercator range
+func TestIsIndexable(t *testing.T) {
+	assert.True(t, isIndexable(&domain.Driver{Location: domain.NewPoint(29, 41)}))
+	assert.True(t, isIndexable(&domain.Driver{Location: domain.NewPoint(29, 41), Status: domain.DriverStatusAvailable}))
+	assert.False(t, isIndexable(&domain.Driver{Location: domain.NewPoint(29, 41), Status: domain.DriverStatusOffline}))
+	assert.False(t, isIndexable(&domain.Driver{Location: domain.NewPoint(0, 89)}))
+}
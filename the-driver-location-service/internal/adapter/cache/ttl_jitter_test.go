@@ -0,0 +1,32 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestJitterTTL tests shortening a cache TTL by a random part of it
+// Expected: Should stay between (1 - fraction) * ttl and ttl and keep the TTL without jitter
+func TestJitterTTL(t *testing.T) {
+	assert.Equal(t, time.Minute, jitterTTL(time.Minute, 0.1, 0))
+	assert.Equal(t, 57*time.Second, jitterTTL(time.Minute, 0.1, 0.5))
+	assert.Equal(t, 54*time.Second, jitterTTL(time.Minute, 0.1, 1))
+
+	assert.Equal(t, time.Minute, jitterTTL(time.Minute, 0, 0.5))
+	assert.Equal(t, time.Duration(0), jitterTTL(0, 0.1, 0.5))
+}
+
+// TestJitterTTL_spread tests the TTLs of drivers cached together
+// Expected: Should spread them over the jitter window instead of giving all of them the same TTL
+func TestJitterTTL_spread(t *testing.T) {
+	seen := make(map[time.Duration]bool)
+	for i := 0; i < 100; i++ {
+		ttl := jitterTTL(5*time.Minute, 0.2, float64(i)/100)
+		assert.LessOrEqual(t, ttl, 5*time.Minute)
+		assert.Greater(t, ttl, 4*time.Minute)
+		seen[ttl.Truncate(time.Second)] = true
+	}
+	assert.Greater(t, len(seen), 50)
+}
@@ -0,0 +1,94 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"the-driver-location-service/internal/domain"
+	"the-driver-location-service/internal/ports/secondary"
+)
+
+// ImportStagingCollection holds the drivers of a staged import, there is one
+// staged import at a time
+const ImportStagingCollection = "drivers_import_staging"
+
+var _ secondary.DriverImportStager = (*MongoDriverRepository)(nil)
+
+// mongoImportStage writes the drivers of an import to the staging collection, the
+// live collection is only written by Commit
+type mongoImportStage struct {
+	staging *mongo.Collection
+	live    *mongo.Collection
+}
+
+// StageImport opens a staged import, drivers left in the staging collection by
+// an import that crashed before its commit or abort are discarded. The merge
+// matches drivers by _id alone, which mongo only allows for an unsharded
+// collection or one sharded by _id.
+func (r *MongoDriverRepository) StageImport(ctx context.Context) (secondary.DriverImportStage, error) {
+	if len(r.shardKey.Fields()) > 0 {
+		return nil, fmt.Errorf("staged imports are not supported with the %s shard key", r.shardKey)
+	}
+
+	staging := r.database.Collection(ImportStagingCollection)
+	if err := staging.Drop(ctx); err != nil {
+		return nil, fmt.Errorf("failed to clear import staging collection: %w", err)
+	}
+	return &mongoImportStage{staging: staging, live: r.collection}, nil
+}
+
+func (s *mongoImportStage) BatchCreate(ctx context.Context, drivers []*domain.Driver) error {
+	if len(drivers) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	_, err := s.staging.InsertMany(ctx, newDriverDocuments(drivers))
+	if mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("%w: a driver of the batch is already staged: %w", domain.ErrConflict, err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to stage drivers: %w", err)
+	}
+	return nil
+}
+
+// Commit merges the staged drivers into the live collection in one server side
+// $merge, a staged driver replaces a live driver of the same ID. The staging
+// collection is dropped once the merge succeeded.
+func (s *mongoImportStage) Commit(ctx context.Context) (int64, error) {
+	staged, err := s.staging.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count staged drivers: %w", err)
+	}
+
+	pipeline := mongo.Pipeline{{{Key: "$merge", Value: bson.M{
+		"into":           s.live.Name(),
+		"on":             "_id",
+		"whenMatched":    "replace",
+		"whenNotMatched": "insert",
+	}}}}
+	cursor, err := s.staging.Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, fmt.Errorf("failed to merge staged drivers: %w", err)
+	}
+	cursor.Close(ctx)
+
+	if err := s.staging.Drop(ctx); err != nil {
+		return staged, fmt.Errorf("failed to drop import staging collection: %w", err)
+	}
+	return staged, nil
+}
+
+func (s *mongoImportStage) Abort(ctx context.Context) error {
+	if err := s.staging.Drop(ctx); err != nil {
+		return fmt.Errorf("failed to drop import staging collection: %w", err)
+	}
+	return nil
+}
@@ -2,13 +2,17 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"regexp"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/event"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 
 	"the-driver-location-service/config"
 	"the-driver-location-service/internal/domain"
@@ -19,17 +23,42 @@ type MongoDriverRepository struct {
 	client     *mongo.Client
 	database   *mongo.Database
 	collection *mongo.Collection
+	primary    *mongo.Collection // reads of strong consistency requests
+	shardKey   ShardKey
+	maxAge     time.Duration // searches skip drivers without a location update for longer, 0 keeps them
+	heartbeat  time.Duration // searches skip drivers whose heartbeat lapsed for longer, 0 keeps them
 }
 
 var _ secondary.DriverRepository = (*MongoDriverRepository)(nil)
+var _ secondary.DriverBackfillStore = (*MongoDriverRepository)(nil)
+var _ secondary.DriverWarmupSource = (*MongoDriverRepository)(nil)
+var _ secondary.DriverInactivityStore = (*MongoDriverRepository)(nil)
+var _ secondary.DriverReconcileStore = (*MongoDriverRepository)(nil)
+var _ secondary.DriverDuplicateStore = (*MongoDriverRepository)(nil)
+var _ secondary.DriverOutcomeStore = (*MongoDriverRepository)(nil)
+var _ secondary.DriverReplicaStore = (*MongoDriverRepository)(nil)
+
+// badValueCode is the mongo error code of queries with invalid arguments
+const badValueCode = 2
+
+// shardFilterAttempts bounds the attempts of an update whose shard key was
+// changed by a concurrent update between reading and writing it, the first one
+// uses the shard key the driver was read with
+const shardFilterAttempts = 3
 
 func NewMongoDriverRepository(cfg *config.Config) (*MongoDriverRepository, error) {
+	shardKey, err := ParseShardKey(cfg.Database.ShardKey)
+	if err != nil {
+		return nil, err
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.Database.ConnectTimeout)
 	defer cancel()
 
 	clientOptions := options.Client().ApplyURI(cfg.Database.URI)
 	clientOptions.SetMaxPoolSize(cfg.Database.MaxPoolSize)
 	clientOptions.SetMinPoolSize(cfg.Database.MinPoolSize)
+	clientOptions.SetMonitor(timingMonitor())
 
 	client, err := mongo.Connect(ctx, clientOptions)
 	if err != nil {
@@ -37,6 +66,7 @@ func NewMongoDriverRepository(cfg *config.Config) (*MongoDriverRepository, error
 	}
 
 	if err := client.Ping(ctx, nil); err != nil {
+		client.Disconnect(context.Background())
 		return nil, fmt.Errorf("failed to ping MongoDB: %w", err)
 	}
 
@@ -55,26 +85,112 @@ func NewMongoDriverRepository(cfg *config.Config) (*MongoDriverRepository, error
 		return nil, fmt.Errorf("failed to create geospatial index: %w", err)
 	}
 
+	// the inactivity check and the cache warmup look drivers up by their last update
+	_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "updated_at", Value: 1}},
+		Options: options.Index().SetName("updated_at_1"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create updated_at index: %w", err)
+	}
+
+	// cell queries are range scans over the leaf cells of the drivers
+	_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "s2_cell", Value: 1}},
+		Options: options.Index().SetName("s2_cell_1"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create s2_cell index: %w", err)
+	}
+
+	// sharding needs an index on the shard key, it is created ahead of sharding
+	// the collection so the operator only has to run shardCollection
+	if index := shardKey.index(); index != nil {
+		if _, err := collection.Indexes().CreateOne(ctx, *index); err != nil {
+			return nil, fmt.Errorf("failed to create shard key index: %w", err)
+		}
+	}
+
 	return &MongoDriverRepository{
 		client:     client,
 		database:   database,
 		collection: collection,
+		primary:    database.Collection("drivers", options.Collection().SetReadPreference(readpref.Primary())),
+		shardKey:   shardKey,
+		maxAge:     cfg.Search.MaxLocationAge,
+		heartbeat:  cfg.Search.HeartbeatTimeout,
 	}, nil
 }
 
-func (r *MongoDriverRepository) Create(driver *domain.Driver) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+// timingMonitor adds the duration of every command to the timing of the
+// request it was run for, see domain.WithTiming
+func timingMonitor() *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Succeeded: func(ctx context.Context, e *event.CommandSucceededEvent) {
+			domain.TimingFrom(ctx).AddDB(e.Duration)
+		},
+		Failed: func(ctx context.Context, e *event.CommandFailedEvent) {
+			domain.TimingFrom(ctx).AddDB(e.Duration)
+		},
+	}
+}
+
+// available adds what every search asks of the drivers it returns to filter:
+// not busy or offline and, with a maximum location age, seen recently enough.
+// Drivers that send heartbeats must have sent one within the heartbeat timeout,
+// drivers that never sent one are left to the location age. The inactivity
+// check takes stale drivers offline too but only when enabled and once per
+// interval.
+func (r *MongoDriverRepository) available(filter bson.M) bson.M {
+	filter["status"] = bson.M{
+		"$nin": []string{domain.DriverStatusBusy, domain.DriverStatusOffline},
+	}
+	if r.maxAge > 0 {
+		filter["updated_at"] = bson.M{"$gte": time.Now().Add(-r.maxAge)}
+	}
+	if r.heartbeat > 0 {
+		filter["last_seen_at"] = bson.M{"$not": bson.M{"$lt": time.Now().Add(-r.heartbeat)}}
+	}
+	return filter
+}
+
+// matchingDrivers adds the vehicle a rider asked for to a search filter
+func matchingDrivers(filter bson.M, driverFilter domain.DriverFilter) bson.M {
+	if driverFilter.VehicleType != "" {
+		filter["vehicle_type"] = driverFilter.VehicleType
+	}
+	if driverFilter.MinCapacity > 0 {
+		filter["capacity"] = bson.M{"$gte": driverFilter.MinCapacity}
+	}
+	return filter
+}
+
+// reader returns the collection the reads of ctx go to, the primary for strong
+// consistency whatever read preference the connection string sets
+func (r *MongoDriverRepository) reader(ctx context.Context) *mongo.Collection {
+	if r.primary != nil && domain.IsStrongConsistency(ctx) {
+		return r.primary
+	}
+	return r.collection
+}
+
+func (r *MongoDriverRepository) Create(ctx context.Context, driver *domain.Driver) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	now := time.Now()
 	driver.CreatedAt = now
 	driver.UpdatedAt = now
+	driver.ApplyDefaults()
 
 	if driver.ID == "" {
 		driver.ID = primitive.NewObjectID().Hex()
 	}
 
 	_, err := r.collection.InsertOne(ctx, driver)
+	if mongo.IsDuplicateKeyError(err) {
+		return &domain.DuplicateDriverError{IDs: []string{driver.ID}}
+	}
 	if err != nil {
 		return fmt.Errorf("failed to insert driver: %w", err)
 	}
@@ -82,20 +198,136 @@ func (r *MongoDriverRepository) Create(driver *domain.Driver) error {
 	return nil
 }
 
-func (r *MongoDriverRepository) BatchCreate(drivers []*domain.Driver) error {
+func (r *MongoDriverRepository) BatchCreate(ctx context.Context, drivers []*domain.Driver) error {
 	if len(drivers) == 0 {
 		return nil
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// taken IDs are looked up first so a conflicting batch creates none of its
+	// drivers, only a driver created concurrently is left to the insert
+	taken, err := r.takenIDs(ctx, drivers)
+	if err != nil {
+		return err
+	}
+	if len(taken) > 0 {
+		return &domain.DuplicateDriverError{IDs: taken}
+	}
+
+	// the insert stops at the first driver that fails, the ones before it are kept
+	_, err = r.collection.InsertMany(ctx, newDriverDocuments(drivers), options.InsertMany().SetOrdered(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return &domain.DuplicateDriverError{IDs: duplicateIDs(err, drivers)}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to batch insert drivers: %w", err)
+	}
+
+	return nil
+}
+
+// BatchCreateUnordered inserts the drivers without stopping at the ones that
+// fail, MongoDB carries on with the rest of the batch and may insert them in
+// any order. Taken IDs are not looked up first, the insert reports them. The
+// drivers it rejected are returned, the error is a failure of the whole insert.
+func (r *MongoDriverRepository) BatchCreateUnordered(ctx context.Context, drivers []*domain.Driver) ([]domain.BatchCreateFailure, error) {
+	if len(drivers) == 0 {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
+	_, err := r.collection.InsertMany(ctx, newDriverDocuments(drivers), options.InsertMany().SetOrdered(false))
+	var bulkErr mongo.BulkWriteException
+	if errors.As(err, &bulkErr) && bulkErr.WriteConcernError == nil && len(bulkErr.WriteErrors) > 0 {
+		failures := make([]domain.BatchCreateFailure, 0, len(bulkErr.WriteErrors))
+		for _, writeErr := range bulkErr.WriteErrors {
+			if writeErr.Index >= len(drivers) {
+				continue
+			}
+			reason := writeErr.Message
+			if mongo.IsDuplicateKeyError(writeErr) {
+				reason = domain.BatchFailureDuplicateID
+			}
+			failures = append(failures, domain.BatchCreateFailure{Index: writeErr.Index, ID: drivers[writeErr.Index].ID, Reason: reason})
+		}
+		return failures, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to batch insert drivers: %w", err)
+	}
+
+	return nil, nil
+}
+
+// takenIDs returns the IDs of the drivers that are stored already, sorted.
+// They are read from the primary, a lagging secondary would miss new drivers.
+func (r *MongoDriverRepository) takenIDs(ctx context.Context, drivers []*domain.Driver) ([]string, error) {
+	ids := make([]string, 0, len(drivers))
+	for _, driver := range drivers {
+		if driver.ID != "" {
+			ids = append(ids, driver.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	ctx = domain.WithConsistency(ctx, domain.ConsistencyStrong)
+	opts := options.Find().SetProjection(bson.M{"_id": 1}).SetSort(bson.D{{Key: "_id", Value: 1}})
+	cursor, err := r.reader(ctx).Find(ctx, bson.M{"_id": bson.M{"$in": ids}}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up driver IDs: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var taken []struct {
+		ID string `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &taken); err != nil {
+		return nil, fmt.Errorf("failed to decode driver IDs: %w", err)
+	}
+
+	takenIDs := make([]string, len(taken))
+	for i, doc := range taken {
+		takenIDs[i] = doc.ID
+	}
+	return takenIDs, nil
+}
+
+// duplicateIDs returns the IDs of the drivers an insert rejected as duplicates
+func duplicateIDs(err error, drivers []*domain.Driver) []string {
+	var bulkErr mongo.BulkWriteException
+	if !errors.As(err, &bulkErr) {
+		return nil
+	}
+
+	var ids []string
+	for _, writeErr := range bulkErr.WriteErrors {
+		if mongo.IsDuplicateKeyError(writeErr) && writeErr.Index < len(drivers) {
+			ids = append(ids, drivers[writeErr.Index].ID)
+		}
+	}
+	return ids
+}
+
+// https://www.mongodb.com/docs/manual/reference/operator/query/near/
+// a positive minRadiusMeters adds $minDistance so only drivers in the ring between
+// the two radii are returned. Busy and offline drivers are skipped, drivers without
+// a status were stored before it existed and count as available.
+// newDriverDocuments prepares drivers for an insert, they get their timestamps,
+// defaults and an ID when they have none
+func newDriverDocuments(drivers []*domain.Driver) []interface{} {
 	documents := make([]interface{}, len(drivers))
 	now := time.Now()
 
 	for i, driver := range drivers {
 		driver.CreatedAt = now
 		driver.UpdatedAt = now
+		driver.ApplyDefaults()
 
 		if driver.ID == "" {
 			driver.ID = primitive.NewObjectID().Hex()
@@ -103,35 +335,33 @@ func (r *MongoDriverRepository) BatchCreate(drivers []*domain.Driver) error {
 
 		documents[i] = driver
 	}
-
-	_, err := r.collection.InsertMany(ctx, documents)
-	if err != nil {
-		return fmt.Errorf("failed to batch insert drivers: %w", err)
-	}
-
-	return nil
+	return documents
 }
 
-// https://www.mongodb.com/docs/manual/reference/operator/query/near/
-func (r *MongoDriverRepository) SearchNearby(location domain.Point, radiusMeters float64, limit int) ([]*domain.DriverWithDistance, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (r *MongoDriverRepository) SearchNearby(ctx context.Context, location domain.Point, minRadiusMeters, radiusMeters float64, limit int, driverFilter domain.DriverFilter) ([]*domain.DriverWithDistance, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	filter := bson.M{
-		"location": bson.M{
-			"$near": bson.M{
-				"$geometry": bson.M{
-					"type":        "Point",
-					"coordinates": []float64{location.Longitude(), location.Latitude()},
-				},
-				"$maxDistance": radiusMeters,
-			},
+	near := bson.M{
+		"$geometry": bson.M{
+			"type":        "Point",
+			"coordinates": []float64{location.Longitude(), location.Latitude()},
 		},
+		"$maxDistance": radiusMeters,
+	}
+	if minRadiusMeters > 0 {
+		near["$minDistance"] = minRadiusMeters
 	}
 
+	filter := r.available(matchingDrivers(bson.M{
+		"location": bson.M{
+			"$near": near,
+		},
+	}, driverFilter))
+
 	opts := options.Find().SetLimit(int64(limit))
 
-	cursor, err := r.collection.Find(ctx, filter, opts)
+	cursor, err := r.reader(ctx).Find(ctx, filter, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search nearby drivers: %w", err)
 	}
@@ -154,15 +384,264 @@ func (r *MongoDriverRepository) SearchNearby(location domain.Point, radiusMeters
 	return result, nil
 }
 
-func (r *MongoDriverRepository) GetByID(id string) (*domain.Driver, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+// https://www.mongodb.com/docs/manual/reference/operator/aggregation/geoNear/
+// SearchGeoNear is SearchNearby with the distances computed by mongo on the
+// sphere, the same distances the 2dsphere index uses to pick and order the
+// drivers, instead of recomputing them with Haversine afterwards.
+func (r *MongoDriverRepository) SearchGeoNear(ctx context.Context, location domain.Point, minRadiusMeters, radiusMeters float64, limit int, filter domain.DriverFilter) ([]*domain.DriverWithDistance, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	geoNear := bson.M{
+		"near": bson.M{
+			"type":        "Point",
+			"coordinates": []float64{location.Longitude(), location.Latitude()},
+		},
+		"key":           "location",
+		"distanceField": "distance",
+		"maxDistance":   radiusMeters,
+		"spherical":     true,
+		"query":         r.available(matchingDrivers(bson.M{}, filter)),
+	}
+	if minRadiusMeters > 0 {
+		geoNear["minDistance"] = minRadiusMeters
+	}
+
+	pipeline := mongo.Pipeline{{{Key: "$geoNear", Value: geoNear}}}
+	if limit > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$limit", Value: limit}})
+	}
+
+	cursor, err := r.reader(ctx).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search nearby drivers: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var result []*domain.DriverWithDistance
+	for cursor.Next(ctx) {
+		var doc struct {
+			domain.Driver `bson:",inline"`
+			Distance      float64 `bson:"distance"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("failed to decode drivers: %w", err)
+		}
+		result = append(result, &domain.DriverWithDistance{Driver: doc.Driver, Distance: doc.Distance})
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("failed to decode drivers: %w", err)
+	}
+
+	return result, nil
+}
+
+// SearchWithin returns the available drivers inside the area, in no particular order
+func (r *MongoDriverRepository) SearchWithin(ctx context.Context, area domain.Area, limit int) ([]*domain.Driver, error) {
+	polygons, err := area.Polygons()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", domain.ErrValidation, err)
+	}
+	geometry := bson.M{"type": area.Type, "coordinates": polygons}
+	if area.Type == "Polygon" {
+		geometry["coordinates"] = polygons[0]
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	filter := r.available(bson.M{
+		"location": bson.M{
+			"$geoWithin": bson.M{"$geometry": geometry},
+		},
+	})
+
+	cursor, err := r.reader(ctx).Find(ctx, filter, options.Find().SetLimit(int64(limit)))
+	if err != nil {
+		// self intersecting or otherwise invalid polygons are only detected by mongo
+		var cmdErr mongo.CommandError
+		if errors.As(err, &cmdErr) && cmdErr.Code == badValueCode {
+			return nil, fmt.Errorf("%w: invalid area: %s", domain.ErrValidation, cmdErr.Message)
+		}
+		return nil, fmt.Errorf("failed to search drivers within area: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	drivers := []*domain.Driver{}
+	if err := cursor.All(ctx, &drivers); err != nil {
+		return nil, fmt.Errorf("failed to decode drivers: %w", err)
+	}
+	return drivers, nil
+}
+
+// boxFilter matches the available drivers inside the box, $box uses flat
+// geometry which is what a map view shows
+func (r *MongoDriverRepository) boxFilter(req domain.BoxSearchRequest) bson.M {
+	return r.available(bson.M{
+		"location": bson.M{
+			"$geoWithin": bson.M{"$box": bson.A{
+				bson.A{req.MinLongitude, req.MinLatitude},
+				bson.A{req.MaxLongitude, req.MaxLatitude},
+			}},
+		},
+	})
+}
+
+// SearchInBox returns the available drivers inside a bounding box, in no particular order
+func (r *MongoDriverRepository) SearchInBox(ctx context.Context, req domain.BoxSearchRequest, limit int) ([]*domain.Driver, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	cursor, err := r.reader(ctx).Find(ctx, r.boxFilter(req), options.Find().SetLimit(int64(limit)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to search drivers in box: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	drivers := []*domain.Driver{}
+	if err := cursor.All(ctx, &drivers); err != nil {
+		return nil, fmt.Errorf("failed to decode drivers: %w", err)
+	}
+	return drivers, nil
+}
+
+// ClusterInBox groups the available drivers inside a bounding box by the prefix
+// of their geohash cell of length req.Cluster, the most crowded clusters first
+func (r *MongoDriverRepository) ClusterInBox(ctx context.Context, req domain.BoxSearchRequest, limit int) ([]domain.DriverCluster, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: r.boxFilter(req)}},
+		{{Key: "$group", Value: bson.M{
+			"_id":       bson.M{"$substrCP": bson.A{"$geohash_cell", 0, req.Cluster}},
+			"count":     bson.M{"$sum": 1},
+			"longitude": bson.M{"$avg": bson.M{"$arrayElemAt": bson.A{"$location.coordinates", 0}}},
+			"latitude":  bson.M{"$avg": bson.M{"$arrayElemAt": bson.A{"$location.coordinates", 1}}},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+
+	cursor, err := r.reader(ctx).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to cluster drivers in box: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var groups []struct {
+		Geohash   string  `bson:"_id"`
+		Count     int     `bson:"count"`
+		Longitude float64 `bson:"longitude"`
+		Latitude  float64 `bson:"latitude"`
+	}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return nil, fmt.Errorf("failed to decode driver clusters: %w", err)
+	}
+
+	clusters := make([]domain.DriverCluster, len(groups))
+	for i, group := range groups {
+		clusters[i] = domain.DriverCluster{
+			Geohash: group.Geohash,
+			Count:   group.Count,
+			Center:  domain.NewPoint(group.Longitude, group.Latitude),
+		}
+	}
+	return clusters, nil
+}
+
+// cellFilter selects the available drivers whose leaf cell is inside cell. The
+// cells of a face are a contiguous range of IDs that keeps its order once stored
+// as signed longs, so one range covers any cell.
+func (r *MongoDriverRepository) cellFilter(cell domain.CellID) bson.M {
+	return r.available(bson.M{
+		"s2_cell": bson.M{
+			"$gte": int64(cell.RangeMin()),
+			"$lte": int64(cell.RangeMax()),
+		},
+	})
+}
+
+// SearchInCell returns the available drivers inside an S2 cell, in cell order
+func (r *MongoDriverRepository) SearchInCell(ctx context.Context, cell domain.CellID, limit int) ([]*domain.Driver, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "s2_cell", Value: 1}}).SetLimit(int64(limit))
+	cursor, err := r.reader(ctx).Find(ctx, r.cellFilter(cell), opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search drivers in cell: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	drivers := []*domain.Driver{}
+	if err := cursor.All(ctx, &drivers); err != nil {
+		return nil, fmt.Errorf("failed to decode drivers: %w", err)
+	}
+	return drivers, nil
+}
+
+// CountInCell counts the available drivers of cell per descendant cell of the
+// given level, the most crowded cells first. The drivers are grouped by their
+// leaf cell minus its remainder modulo the size of a cell of that level, leaf
+// IDs are odd so the remainder is never zero and truncating the negative IDs of
+// faces 4 and 5 towards zero still groups them by cell.
+func (r *MongoDriverRepository) CountInCell(ctx context.Context, cell domain.CellID, level, limit int) ([]domain.CellCount, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// the IDs of a cell of the level span twice its lowest set bit
+	size := int64(1) << (2*(domain.MaxCellLevel-level) + 1)
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: r.cellFilter(cell)}},
+		{{Key: "$group", Value: bson.M{
+			"_id":       bson.M{"$subtract": bson.A{"$s2_cell", bson.M{"$mod": bson.A{"$s2_cell", size}}}},
+			"leaf":      bson.M{"$first": "$s2_cell"},
+			"count":     bson.M{"$sum": 1},
+			"longitude": bson.M{"$avg": bson.M{"$arrayElemAt": bson.A{"$location.coordinates", 0}}},
+			"latitude":  bson.M{"$avg": bson.M{"$arrayElemAt": bson.A{"$location.coordinates", 1}}},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+
+	cursor, err := r.reader(ctx).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count drivers in cell: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var groups []struct {
+		Leaf      int64   `bson:"leaf"`
+		Count     int     `bson:"count"`
+		Longitude float64 `bson:"longitude"`
+		Latitude  float64 `bson:"latitude"`
+	}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return nil, fmt.Errorf("failed to decode cell counts: %w", err)
+	}
+
+	counts := make([]domain.CellCount, len(groups))
+	for i, group := range groups {
+		counts[i] = domain.CellCount{
+			Cell:   domain.CellID(group.Leaf).Parent(level).Token(),
+			Level:  level,
+			Count:  group.Count,
+			Center: domain.NewPoint(group.Longitude, group.Latitude),
+		}
+	}
+	return counts, nil
+}
+
+func (r *MongoDriverRepository) GetByID(ctx context.Context, id string) (*domain.Driver, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	var driver domain.Driver
-	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&driver)
+	err := r.reader(ctx).FindOne(ctx, bson.M{"_id": id}).Decode(&driver)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, fmt.Errorf("driver not found: %s", id)
+			return nil, fmt.Errorf("%w: %s", domain.ErrDriverNotFound, id)
 		}
 		return nil, fmt.Errorf("failed to get driver: %w", err)
 	}
@@ -170,29 +649,98 @@ func (r *MongoDriverRepository) GetByID(id string) (*domain.Driver, error) {
 	return &driver, nil
 }
 
-func (r *MongoDriverRepository) Update(driver *domain.Driver) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+// List returns the next page of drivers ordered by ID, drivers without a status
+// were stored before it existed and count as available
+func (r *MongoDriverRepository) List(ctx context.Context, filter domain.DriverListFilter, afterID string, limit int) ([]*domain.Driver, error) {
+	query := bson.M{}
+	if afterID != "" {
+		query["_id"] = bson.M{"$gt": afterID}
+	}
+	switch filter.Status {
+	case "":
+	case domain.DriverStatusAvailable:
+		query["status"] = bson.M{"$nin": []string{domain.DriverStatusBusy, domain.DriverStatusOffline}}
+	default:
+		query["status"] = filter.Status
+	}
+	if !filter.UpdatedSince.IsZero() {
+		query["updated_at"] = bson.M{"$gte": filter.UpdatedSince}
+	}
 
-	driver.UpdatedAt = time.Now()
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(int64(limit))
+	cursor, err := r.reader(ctx).Find(ctx, query, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list drivers: %w", err)
+	}
+	defer cursor.Close(ctx)
 
-	filter := bson.M{"_id": driver.ID}
-	update := bson.M{"$set": driver}
+	drivers := make([]*domain.Driver, 0, limit)
+	if err := cursor.All(ctx, &drivers); err != nil {
+		return nil, fmt.Errorf("failed to decode drivers: %w", err)
+	}
 
-	result, err := r.collection.UpdateOne(ctx, filter, update)
+	return drivers, nil
+}
+
+func (r *MongoDriverRepository) Update(ctx context.Context, driver *domain.Driver) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// the cells of the driver are still the stored ones until ApplyDefaults
+	// recomputes them from the new location
+	filter, err := r.driverShardFilter(driver)
 	if err != nil {
-		return fmt.Errorf("failed to update driver: %w", err)
+		return err
 	}
 
-	if result.MatchedCount == 0 {
-		return fmt.Errorf("driver not found: %s", driver.ID)
+	driver.UpdatedAt = time.Now()
+	// an update is a sign of life too, drivers sending heartbeats stay searchable
+	if driver.LastSeenAt != nil {
+		driver.LastSeenAt = &driver.UpdatedAt
 	}
+	driver.ApplyDefaults()
+	update := driverUpdate(driver)
 
-	return nil
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 {
+			// the driver was read before another update moved it to another
+			// chunk, or came from a stale cache: read the stored shard key
+			if filter, err = r.shardFilter(ctx, driver.ID); err != nil {
+				return err
+			}
+		}
+
+		result, err := r.collection.UpdateOne(ctx, filter, update)
+		if err != nil {
+			return fmt.Errorf("failed to update driver: %w", err)
+		}
+		if result.MatchedCount > 0 {
+			return nil
+		}
+
+		// without shard key fields the filter is the ID alone, nothing to retry
+		if len(r.shardKey.Fields()) == 0 || attempt == shardFilterAttempts {
+			return fmt.Errorf("%w: %s", domain.ErrDriverNotFound, driver.ID)
+		}
+	}
 }
 
-func (r *MongoDriverRepository) Delete(id string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+// driverUpdate sets every field of the driver but the outcomes, they are only
+// counted by RecordOutcome and a driver read before an outcome was recorded must
+// not reset the counts. The raw location is omitted when empty, an update that
+// was not snapped to a road removes the one of the previous update.
+func driverUpdate(driver *domain.Driver) bson.M {
+	stored := *driver
+	stored.Outcomes = nil
+	update := bson.M{"$set": &stored}
+	if stored.RawLocation == nil {
+		update["$unset"] = bson.M{"raw_location": ""}
+	}
+	return update
+}
+
+func (r *MongoDriverRepository) Delete(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	filter := bson.M{"_id": id}
@@ -202,12 +750,344 @@ func (r *MongoDriverRepository) Delete(id string) error {
 	}
 
 	if result.DeletedCount == 0 {
-		return fmt.Errorf("driver not found: %s", id)
+		return fmt.Errorf("%w: %s", domain.ErrDriverNotFound, id)
 	}
 
 	return nil
 }
 
+// ApplyReplicatedUpsert replaces the driver with the one of the primary region
+// unless the stored driver was updated later. The filter only matches a driver
+// that is not newer, so the upsert of a newer one fails on the _id index and the
+// change is skipped. A driver of the same updated_at is replaced, writes that
+// keep updated_at like taking a driver offline reach the standby that way.
+func (r *MongoDriverRepository) ApplyReplicatedUpsert(ctx context.Context, driver *domain.Driver) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	driver.ApplyDefaults()
+	filter, err := r.driverShardFilter(driver)
+	if err != nil {
+		return false, err
+	}
+	filter["updated_at"] = bson.M{"$lte": driver.UpdatedAt}
+
+	result, err := r.collection.ReplaceOne(ctx, filter, driver, options.Replace().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to replicate driver: %w", err)
+	}
+	return result.MatchedCount > 0 || result.UpsertedCount > 0, nil
+}
+
+// ApplyReplicatedDelete deletes the driver unless it was updated after the
+// primary region deleted it
+func (r *MongoDriverRepository) ApplyReplicatedDelete(ctx context.Context, id string, deletedAt time.Time) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": id, "updated_at": bson.M{"$lte": deletedAt}}
+	result, err := r.collection.DeleteOne(ctx, filter)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete replicated driver: %w", err)
+	}
+	return result.DeletedCount > 0, nil
+}
+
+// driverShardFilter selects a driver by ID and the values of the shard key
+// fields it carries, the ones a replicated driver was written with or the ones
+// a driver was read with. mongos needs the full shard key of an upsert.
+func (r *MongoDriverRepository) driverShardFilter(driver *domain.Driver) (bson.M, error) {
+	filter := bson.M{"_id": driver.ID}
+	fields := r.shardKey.Fields()
+	if len(fields) == 0 {
+		return filter, nil
+	}
+
+	data, err := bson.Marshal(driver)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode driver %s: %w", driver.ID, err)
+	}
+	var document bson.M
+	if err := bson.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("failed to decode driver %s: %w", driver.ID, err)
+	}
+	for _, field := range fields {
+		filter[field] = document[field]
+	}
+	return filter, nil
+}
+
+// Heartbeat records that the driver is still online without touching the
+// location or updated_at, which stays the time of the last location update
+func (r *MongoDriverRepository) Heartbeat(ctx context.Context, id string, at time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	update := bson.M{"$set": bson.M{"last_seen_at": at}}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return fmt.Errorf("failed to record heartbeat: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrDriverNotFound, id)
+	}
+
+	return nil
+}
+
+// RecordOutcome counts one more match outcome of the driver and returns the
+// counts after the increment
+func (r *MongoDriverRepository) RecordOutcome(ctx context.Context, id, outcome string) (*domain.DriverOutcomes, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	update := bson.M{"$inc": bson.M{"outcomes." + outcome: 1}}
+	opts := options.FindOneAndUpdate().
+		SetProjection(bson.M{"outcomes": 1}).
+		SetReturnDocument(options.After)
+
+	var stored struct {
+		Outcomes domain.DriverOutcomes `bson:"outcomes"`
+	}
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": id}, update, opts).Decode(&stored)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("%w: %s", domain.ErrDriverNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to record outcome: %w", err)
+	}
+
+	return &stored.Outcomes, nil
+}
+
+// ScanAfter returns the next batch of drivers ordered by ID, the _id index makes
+// every batch a range scan no matter how far the backfill got
+func (r *MongoDriverRepository) ScanAfter(ctx context.Context, afterID string, limit int) ([]*domain.Driver, error) {
+	filter := bson.M{}
+	if afterID != "" {
+		filter["_id"] = bson.M{"$gt": afterID}
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(int64(limit))
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan drivers: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var drivers []*domain.Driver
+	if err := cursor.All(ctx, &drivers); err != nil {
+		return nil, fmt.Errorf("failed to decode drivers: %w", err)
+	}
+
+	return drivers, nil
+}
+
+// UpdatedSince returns the next batch of drivers updated since the given time, ordered by ID
+func (r *MongoDriverRepository) UpdatedSince(ctx context.Context, since time.Time, afterID string, limit int) ([]*domain.Driver, error) {
+	filter := bson.M{"updated_at": bson.M{"$gte": since}}
+	if afterID != "" {
+		filter["_id"] = bson.M{"$gt": afterID}
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(int64(limit))
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find recently updated drivers: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var drivers []*domain.Driver
+	if err := cursor.All(ctx, &drivers); err != nil {
+		return nil, fmt.Errorf("failed to decode drivers: %w", err)
+	}
+
+	return drivers, nil
+}
+
+// StaleBefore returns drivers that are not offline and were last updated before
+// the given time, a driver with a heartbeat since is still online
+func (r *MongoDriverRepository) StaleBefore(ctx context.Context, before time.Time, limit int) ([]*domain.Driver, error) {
+	filter := bson.M{
+		"updated_at":   bson.M{"$lt": before},
+		"last_seen_at": bson.M{"$not": bson.M{"$gte": before}},
+		"status":       bson.M{"$ne": domain.DriverStatusOffline},
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "updated_at", Value: 1}}).SetLimit(int64(limit))
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find stale drivers: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var drivers []*domain.Driver
+	if err := cursor.All(ctx, &drivers); err != nil {
+		return nil, fmt.Errorf("failed to decode drivers: %w", err)
+	}
+
+	return drivers, nil
+}
+
+// MarkOffline sets the status without touching updated_at, which stays the time the
+// driver was last seen. The updated_at match skips drivers that sent an update
+// after they were found stale.
+func (r *MongoDriverRepository) MarkOffline(ctx context.Context, id string, lastSeenAt time.Time) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"_id":        id,
+		"updated_at": lastSeenAt,
+		"status":     bson.M{"$ne": domain.DriverStatusOffline},
+	}
+	update := bson.M{"$set": bson.M{"status": domain.DriverStatusOffline}}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark driver offline: %w", err)
+	}
+
+	return result.ModifiedCount == 1, nil
+}
+
+// GetByIDs returns the stored drivers among the given IDs, unknown IDs are skipped
+func (r *MongoDriverRepository) GetByIDs(ctx context.Context, ids []string) ([]*domain.Driver, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	cursor, err := r.collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find drivers: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var drivers []*domain.Driver
+	if err := cursor.All(ctx, &drivers); err != nil {
+		return nil, fmt.Errorf("failed to decode drivers: %w", err)
+	}
+
+	return drivers, nil
+}
+
+// IDsWithPrefix returns the first limit IDs starting with prefix in ID order, an
+// anchored regex on _id is a range scan of the _id index
+func (r *MongoDriverRepository) IDsWithPrefix(ctx context.Context, prefix string, limit int) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": bson.M{"$regex": "^" + regexp.QuoteMeta(prefix)}}
+	opts := options.Find().
+		SetProjection(bson.M{"_id": 1}).
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetLimit(int64(limit))
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find driver IDs: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var docs []struct {
+		ID string `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("failed to decode driver IDs: %w", err)
+	}
+
+	ids := make([]string, len(docs))
+	for i, doc := range docs {
+		ids[i] = doc.ID
+	}
+	return ids, nil
+}
+
+// ColocatedDrivers groups the drivers by their exact coordinates in one pass over
+// the collection, the groups can be larger than the memory limit of a stage so
+// they may spill to disk
+func (r *MongoDriverRepository) ColocatedDrivers(ctx context.Context, limit int) ([][]*domain.Driver, error) {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{
+			"_id":     "$location.coordinates",
+			"drivers": bson.M{"$push": "$$ROOT"},
+			"count":   bson.M{"$sum": 1},
+		}}},
+		{{Key: "$match", Value: bson.M{"count": bson.M{"$gt": 1}}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+		{{Key: "$limit", Value: limit}},
+	}
+	cursor, err := r.collection.Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return nil, fmt.Errorf("failed to group colocated drivers: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var groups [][]*domain.Driver
+	for cursor.Next(ctx) {
+		var doc struct {
+			Drivers []*domain.Driver `bson:"drivers"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("failed to decode colocated drivers: %w", err)
+		}
+		groups = append(groups, doc.Drivers)
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("failed to group colocated drivers: %w", err)
+	}
+	return groups, nil
+}
+
+// ApplyUpdates only sets the given fields of the drivers still at the location
+// the fields were computed from, a driver that moved since it was scanned got
+// its cells from the location update. The version of an updated driver is
+// increased unless the update sets it.
+func (r *MongoDriverRepository) ApplyUpdates(ctx context.Context, updates []domain.DriverFieldUpdate) (int64, error) {
+	if len(updates) == 0 {
+		return 0, nil
+	}
+
+	models := make([]mongo.WriteModel, 0, len(updates))
+	for _, update := range updates {
+		filter := bson.M{"_id": update.ID}
+		if r.shardKey.changesShardKey(update.Fields) {
+			var err error
+			filter, err = r.shardFilter(ctx, update.ID)
+			if errors.Is(err, domain.ErrDriverNotFound) {
+				continue // deleted since it was scanned
+			}
+			if err != nil {
+				return 0, err
+			}
+		}
+		filter["location.coordinates"] = update.Location.Coordinates
+
+		set := bson.M{"$set": update.Fields}
+		if _, ok := update.Fields["version"]; !ok {
+			set["$inc"] = bson.M{"version": 1}
+		}
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(filter).
+			SetUpdate(set))
+	}
+	if len(models) == 0 {
+		return 0, nil
+	}
+
+	result, err := r.collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return 0, fmt.Errorf("failed to apply driver updates: %w", err)
+	}
+
+	return result.MatchedCount, nil
+}
+
 func (r *MongoDriverRepository) IsEmpty() (bool, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -220,6 +1100,11 @@ func (r *MongoDriverRepository) IsEmpty() (bool, error) {
 	return count == 0, nil
 }
 
+// Ping checks that the primary answers, it is the readiness check of MongoDB
+func (r *MongoDriverRepository) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx, readpref.Primary())
+}
+
 func (r *MongoDriverRepository) Close() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -2,7 +2,10 @@ package db
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"math"
+	"math/rand"
 	"testing"
 	"time"
 
@@ -10,6 +13,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/wait"
+	"go.mongodb.org/mongo-driver/bson"
 
 	"the-driver-location-service/config"
 	"the-driver-location-service/internal/domain"
@@ -66,10 +70,10 @@ func TestMongoDriverRepository_CreateAndGetByID(t *testing.T) {
 		ID:       "driver1",
 		Location: domain.NewPoint(29.0, 41.0),
 	}
-	err := repo.Create(drv)
+	err := repo.Create(context.Background(), drv)
 	require.NoError(t, err)
 
-	got, err := repo.GetByID(drv.ID)
+	got, err := repo.GetByID(context.Background(), drv.ID)
 	require.NoError(t, err)
 	assert.Equal(t, drv.ID, got.ID)
 	assert.Equal(t, drv.Location.Longitude(), got.Location.Longitude())
@@ -83,17 +87,55 @@ func TestMongoDriverRepository_Update(t *testing.T) {
 	defer cleanup()
 
 	drv := &domain.Driver{ID: "driver2", Location: domain.NewPoint(10, 10)}
-	require.NoError(t, repo.Create(drv))
+	require.NoError(t, repo.Create(context.Background(), drv))
 
 	drv.Location = domain.NewPoint(20, 20)
-	require.NoError(t, repo.Update(drv))
+	require.NoError(t, repo.Update(context.Background(), drv))
 
-	got, err := repo.GetByID(drv.ID)
+	got, err := repo.GetByID(context.Background(), drv.ID)
 	require.NoError(t, err)
 	assert.Equal(t, 20.0, got.Location.Longitude())
 	assert.Equal(t, 20.0, got.Location.Latitude())
 }
 
+// TestMongoDriverRepository_Update_RawLocation tests an update that is not snapped after one that was.
+// Expected: Should remove the raw location of the snapped update.
+func TestMongoDriverRepository_Update_RawLocation(t *testing.T) {
+	repo, cleanup := setupMongoTestRepo(t)
+	defer cleanup()
+
+	raw := domain.NewPoint(10.0001, 10.0001)
+	drv := &domain.Driver{ID: "driver-raw", Location: domain.NewPoint(10, 10), RawLocation: &raw}
+	require.NoError(t, repo.Create(context.Background(), drv))
+
+	got, err := repo.GetByID(context.Background(), drv.ID)
+	require.NoError(t, err)
+	require.NotNil(t, got.RawLocation)
+
+	got.Location, got.RawLocation = domain.NewPoint(20, 20), nil
+	require.NoError(t, repo.Update(context.Background(), got))
+
+	got, err = repo.GetByID(context.Background(), drv.ID)
+	require.NoError(t, err)
+	assert.Nil(t, got.RawLocation)
+	assert.Equal(t, 20.0, got.Location.Longitude())
+}
+
+// TestDriverUpdate tests the update document of a driver.
+// Expected: Should unset the raw location only when the driver has none and never set the outcomes.
+func TestDriverUpdate(t *testing.T) {
+	raw := domain.NewPoint(10.0001, 10.0001)
+	drv := &domain.Driver{ID: "d1", Location: domain.NewPoint(10, 10), RawLocation: &raw, Outcomes: &domain.DriverOutcomes{}}
+
+	update := driverUpdate(drv)
+	assert.NotContains(t, update, "$unset")
+	assert.Nil(t, update["$set"].(*domain.Driver).Outcomes)
+	assert.NotNil(t, drv.Outcomes)
+
+	drv.RawLocation = nil
+	assert.Equal(t, bson.M{"raw_location": ""}, driverUpdate(drv)["$unset"])
+}
+
 // TestMongoDriverRepository_Delete tests deleting a driver by ID.
 // Expected: Should delete the driver and not find it afterwards.
 func TestMongoDriverRepository_Delete(t *testing.T) {
@@ -101,9 +143,9 @@ func TestMongoDriverRepository_Delete(t *testing.T) {
 	defer cleanup()
 
 	drv := &domain.Driver{ID: "driver3", Location: domain.NewPoint(30, 30)}
-	require.NoError(t, repo.Create(drv))
-	require.NoError(t, repo.Delete(drv.ID))
-	_, err := repo.GetByID(drv.ID)
+	require.NoError(t, repo.Create(context.Background(), drv))
+	require.NoError(t, repo.Delete(context.Background(), drv.ID))
+	_, err := repo.GetByID(context.Background(), drv.ID)
 	assert.Error(t, err)
 }
 
@@ -117,14 +159,39 @@ func TestMongoDriverRepository_BatchCreate(t *testing.T) {
 		{ID: "b1", Location: domain.NewPoint(1, 1)},
 		{ID: "b2", Location: domain.NewPoint(2, 2)},
 	}
-	require.NoError(t, repo.BatchCreate(drivers))
+	require.NoError(t, repo.BatchCreate(context.Background(), drivers))
 	for _, d := range drivers {
-		got, err := repo.GetByID(d.ID)
+		got, err := repo.GetByID(context.Background(), d.ID)
 		require.NoError(t, err)
 		assert.Equal(t, d.Location.Longitude(), got.Location.Longitude())
 	}
 }
 
+// TestMongoDriverRepository_BatchCreateUnordered tests an unordered batch with a taken ID.
+// Expected: Should insert the drivers around the taken one and return it as a duplicate.
+func TestMongoDriverRepository_BatchCreateUnordered(t *testing.T) {
+	repo, cleanup := setupMongoTestRepo(t)
+	defer cleanup()
+
+	require.NoError(t, repo.Create(context.Background(), &domain.Driver{ID: "u2", Location: domain.NewPoint(2, 2)}))
+	drivers := []*domain.Driver{
+		{ID: "u1", Location: domain.NewPoint(1, 1)},
+		{ID: "u2", Location: domain.NewPoint(5, 5)},
+		{ID: "u3", Location: domain.NewPoint(3, 3)},
+	}
+	failures, err := repo.BatchCreateUnordered(context.Background(), drivers)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.BatchCreateFailure{{Index: 1, ID: "u2", Reason: domain.BatchFailureDuplicateID}}, failures)
+
+	for _, id := range []string{"u1", "u3"} {
+		_, err := repo.GetByID(context.Background(), id)
+		assert.NoError(t, err)
+	}
+	taken, err := repo.GetByID(context.Background(), "u2")
+	require.NoError(t, err)
+	assert.Equal(t, 2.0, taken.Location.Longitude())
+}
+
 // TestMongoDriverRepository_SearchNearby tests searching for nearby drivers.
 // Expected: Should find drivers within the given radius.
 func TestMongoDriverRepository_SearchNearby(t *testing.T) {
@@ -136,11 +203,11 @@ func TestMongoDriverRepository_SearchNearby(t *testing.T) {
 		{ID: "s2", Location: domain.NewPoint(10.001, 10.001)},
 		{ID: "s3", Location: domain.NewPoint(20, 20)},
 	}
-	require.NoError(t, repo.BatchCreate(drivers))
+	require.NoError(t, repo.BatchCreate(context.Background(), drivers))
 
 	center := domain.NewPoint(10, 10)
 	// 200m radius should find s1 and s2, but not s3
-	found, err := repo.SearchNearby(center, 200, 10)
+	found, err := repo.SearchNearby(context.Background(), center, 0, 200, 10, domain.DriverFilter{})
 	require.NoError(t, err)
 	ids := make([]string, 0, len(found))
 	for _, d := range found {
@@ -151,15 +218,101 @@ func TestMongoDriverRepository_SearchNearby(t *testing.T) {
 	assert.NotContains(t, ids, "s3")
 }
 
+// TestMongoDriverRepository_SearchWithin tests searching drivers inside polygons.
+// Expected: Should find the available drivers inside the area only.
+func TestMongoDriverRepository_SearchWithin(t *testing.T) {
+	repo, cleanup := setupMongoTestRepo(t)
+	defer cleanup()
+
+	drivers := []*domain.Driver{
+		{ID: "w1", Location: domain.NewPoint(10.05, 10.05)},
+		{ID: "w2", Location: domain.NewPoint(10.08, 10.02), Status: domain.DriverStatusBusy},
+		{ID: "w3", Location: domain.NewPoint(20.05, 20.05)},
+		{ID: "w4", Location: domain.NewPoint(10.5, 10.5)},
+	}
+	require.NoError(t, repo.BatchCreate(context.Background(), drivers))
+
+	square := `[[[10,10],[10.1,10],[10.1,10.1],[10,10.1],[10,10]]]`
+	found, err := repo.SearchWithin(context.Background(), domain.Area{Type: "Polygon", Coordinates: json.RawMessage(square)}, 10)
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, "w1", found[0].ID)
+
+	other := `[[[20,20],[20.1,20],[20.1,20.1],[20,20.1],[20,20]]]`
+	found, err = repo.SearchWithin(context.Background(), domain.Area{Type: "MultiPolygon", Coordinates: json.RawMessage("[" + square + "," + other + "]")}, 10)
+	require.NoError(t, err)
+	assert.Len(t, found, 2)
+
+	bowtie := `[[[10,10],[10.1,10.1],[10.1,10],[10,10.1],[10,10]]]`
+	_, err = repo.SearchWithin(context.Background(), domain.Area{Type: "Polygon", Coordinates: json.RawMessage(bowtie)}, 10)
+	assert.ErrorIs(t, err, domain.ErrValidation)
+}
+
+// TestMongoDriverRepository_Update_GeohashShardKey tests updates routed by a geohash shard key.
+// Expected: Should move a driver to another cell and report drivers that don't exist.
+func TestMongoDriverRepository_Update_GeohashShardKey(t *testing.T) {
+	repo, cleanup := setupMongoTestRepo(t)
+	defer cleanup()
+	repo.shardKey = ShardKeyGeohash
+
+	drv := &domain.Driver{ID: "g1", Location: domain.NewPoint(29, 41)}
+	require.NoError(t, repo.Create(context.Background(), drv))
+
+	drv.Location = domain.NewPoint(32.85, 39.93)
+	require.NoError(t, repo.Update(context.Background(), drv))
+
+	got, err := repo.GetByID(context.Background(), "g1")
+	require.NoError(t, err)
+	assert.Equal(t, domain.Geohash(39.93, 32.85, domain.GeohashPrecision), got.GeohashCell)
+
+	// a driver read before the last update still carries the previous cell
+	stale := &domain.Driver{ID: "g1", Location: domain.NewPoint(27.14, 38.42), GeohashCell: domain.Geohash(41, 29, domain.GeohashPrecision)}
+	require.NoError(t, repo.Update(context.Background(), stale))
+
+	got, err = repo.GetByID(context.Background(), "g1")
+	require.NoError(t, err)
+	assert.Equal(t, domain.Geohash(38.42, 27.14, domain.GeohashPrecision), got.GeohashCell)
+
+	err = repo.Update(context.Background(), &domain.Driver{ID: "missing", Location: domain.NewPoint(29, 41)})
+	assert.ErrorIs(t, err, domain.ErrDriverNotFound)
+}
+
+// TestMongoDriverRepository_SearchInBox tests bounding box searches and their clusters.
+// Expected: Should find the available drivers in the box and count them per geohash prefix.
+func TestMongoDriverRepository_SearchInBox(t *testing.T) {
+	repo, cleanup := setupMongoTestRepo(t)
+	defer cleanup()
+
+	drivers := []*domain.Driver{
+		{ID: "b1", Location: domain.NewPoint(10.01, 10.01)},
+		{ID: "b2", Location: domain.NewPoint(10.0101, 10.0101)},
+		{ID: "b3", Location: domain.NewPoint(10.09, 10.09)},
+		{ID: "b4", Location: domain.NewPoint(10.05, 10.05), Status: domain.DriverStatusOffline},
+		{ID: "b5", Location: domain.NewPoint(11, 11)},
+	}
+	require.NoError(t, repo.BatchCreate(context.Background(), drivers))
+
+	box := domain.BoxSearchRequest{MinLongitude: 10, MinLatitude: 10, MaxLongitude: 10.1, MaxLatitude: 10.1}
+	found, err := repo.SearchInBox(context.Background(), box, 10)
+	require.NoError(t, err)
+	assert.Len(t, found, 3)
+
+	box.Cluster = 6
+	clusters, err := repo.ClusterInBox(context.Background(), box, 10)
+	require.NoError(t, err)
+	require.Len(t, clusters, 2)
+	assert.Equal(t, 2, clusters[0].Count)
+	assert.Equal(t, domain.Geohash(10.01, 10.01, 6), clusters[0].Geohash)
+}
+
 // TestMongoDriverRepository_Delete_NotFound tests deletion of non-existent driver.
 // Expected: Should return error when trying to delete driver that doesn't exist.
 func TestMongoDriverRepository_Delete_NotFound(t *testing.T) {
 	repo, cleanup := setupMongoTestRepo(t)
 	defer cleanup()
 
-	err := repo.Delete("non-existent-driver")
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "driver not found")
+	err := repo.Delete(context.Background(), "non-existent-driver")
+	assert.ErrorIs(t, err, domain.ErrDriverNotFound)
 }
 
 // TestMongoDriverRepository_IsEmpty tests IsEmpty functionality.
@@ -173,13 +326,13 @@ func TestMongoDriverRepository_IsEmpty(t *testing.T) {
 	assert.True(t, isEmpty)
 
 	drv := &domain.Driver{ID: "test-driver", Location: domain.NewPoint(40, 40)}
-	require.NoError(t, repo.Create(drv))
+	require.NoError(t, repo.Create(context.Background(), drv))
 
 	isEmpty, err = repo.IsEmpty()
 	require.NoError(t, err)
 	assert.False(t, isEmpty)
 
-	require.NoError(t, repo.Delete(drv.ID))
+	require.NoError(t, repo.Delete(context.Background(), drv.ID))
 
 	isEmpty, err = repo.IsEmpty()
 	require.NoError(t, err)
@@ -192,9 +345,8 @@ func TestMongoDriverRepository_GetByID_NotFound(t *testing.T) {
 	repo, cleanup := setupMongoTestRepo(t)
 	defer cleanup()
 
-	_, err := repo.GetByID("non-existent-id")
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "driver not found")
+	_, err := repo.GetByID(context.Background(), "non-existent-id")
+	assert.ErrorIs(t, err, domain.ErrDriverNotFound)
 }
 
 // TestMongoDriverRepository_Update_NotFound tests updating non-existent driver.
@@ -208,9 +360,8 @@ func TestMongoDriverRepository_Update_NotFound(t *testing.T) {
 		Location: domain.NewPoint(50, 50),
 	}
 
-	err := repo.Update(nonExistentDriver)
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "driver not found")
+	err := repo.Update(context.Background(), nonExistentDriver)
+	assert.ErrorIs(t, err, domain.ErrDriverNotFound)
 }
 
 // TestMongoDriverRepository_Create_EmptyID tests creation with empty ID (auto-generation).
@@ -224,11 +375,11 @@ func TestMongoDriverRepository_Create_EmptyID(t *testing.T) {
 		Location: domain.NewPoint(60, 60),
 	}
 
-	err := repo.Create(drv)
+	err := repo.Create(context.Background(), drv)
 	require.NoError(t, err)
 	assert.NotEmpty(t, drv.ID)
 
-	retrieved, err := repo.GetByID(drv.ID)
+	retrieved, err := repo.GetByID(context.Background(), drv.ID)
 	require.NoError(t, err)
 	assert.Equal(t, drv.ID, retrieved.ID)
 }
@@ -240,7 +391,7 @@ func TestMongoDriverRepository_BatchCreate_EmptyArray(t *testing.T) {
 	defer cleanup()
 
 	emptyDrivers := []*domain.Driver{}
-	err := repo.BatchCreate(emptyDrivers)
+	err := repo.BatchCreate(context.Background(), emptyDrivers)
 	require.NoError(t, err) // Should not error on empty array
 
 	// Collection should still be empty
@@ -262,19 +413,46 @@ func TestMongoDriverRepository_BatchCreate_MixedIDs(t *testing.T) {
 		{ID: "", Location: domain.NewPoint(73, 73)},
 	}
 
-	err := repo.BatchCreate(drivers)
+	err := repo.BatchCreate(context.Background(), drivers)
 	require.NoError(t, err)
 
 	for _, driver := range drivers {
 		assert.NotEmpty(t, driver.ID)
 
-		retrieved, err := repo.GetByID(driver.ID)
+		retrieved, err := repo.GetByID(context.Background(), driver.ID)
 		require.NoError(t, err)
 		assert.Equal(t, driver.Location.Longitude(), retrieved.Location.Longitude())
 		assert.Equal(t, driver.Location.Latitude(), retrieved.Location.Latitude())
 	}
 }
 
+// TestMongoDriverRepository_BatchCreate_Duplicates tests batch creation with taken IDs.
+// Expected: Should name the taken IDs and create none of the drivers of the batch.
+func TestMongoDriverRepository_BatchCreate_Duplicates(t *testing.T) {
+	repo, cleanup := setupMongoTestRepo(t)
+	defer cleanup()
+
+	require.NoError(t, repo.Create(context.Background(), &domain.Driver{ID: "taken-1", Location: domain.NewPoint(70, 70)}))
+	require.NoError(t, repo.Create(context.Background(), &domain.Driver{ID: "taken-2", Location: domain.NewPoint(71, 71)}))
+
+	err := repo.BatchCreate(context.Background(), []*domain.Driver{
+		{ID: "new-1", Location: domain.NewPoint(72, 72)},
+		{ID: "taken-2", Location: domain.NewPoint(73, 73)},
+		{ID: "taken-1", Location: domain.NewPoint(74, 74)},
+	})
+	var duplicate *domain.DuplicateDriverError
+	require.ErrorAs(t, err, &duplicate)
+	assert.ErrorIs(t, err, domain.ErrConflict)
+	assert.Equal(t, []string{"taken-1", "taken-2"}, duplicate.IDs)
+
+	_, err = repo.GetByID(context.Background(), "new-1")
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	err = repo.Create(context.Background(), &domain.Driver{ID: "taken-1", Location: domain.NewPoint(70, 70)})
+	require.ErrorAs(t, err, &duplicate)
+	assert.Equal(t, []string{"taken-1"}, duplicate.IDs)
+}
+
 // TestMongoDriverRepository_SearchNearby_EmptyResult tests search that returns no results.
 // Expected: Should return empty slice when no drivers are in range.
 func TestMongoDriverRepository_SearchNearby_EmptyResult(t *testing.T) {
@@ -282,10 +460,10 @@ func TestMongoDriverRepository_SearchNearby_EmptyResult(t *testing.T) {
 	defer cleanup()
 
 	farDriver := &domain.Driver{ID: "far-driver", Location: domain.NewPoint(80, 80)}
-	require.NoError(t, repo.Create(farDriver))
+	require.NoError(t, repo.Create(context.Background(), farDriver))
 
 	center := domain.NewPoint(10, 10)
-	found, err := repo.SearchNearby(center, 100, 10)
+	found, err := repo.SearchNearby(context.Background(), center, 0, 100, 10, domain.DriverFilter{})
 	require.NoError(t, err)
 	assert.Empty(t, found)
 }
@@ -300,10 +478,459 @@ func TestMongoDriverRepository_SearchNearby_ZeroLimit(t *testing.T) {
 		{ID: "d1", Location: domain.NewPoint(15, 15)},
 		{ID: "d2", Location: domain.NewPoint(15.001, 15.001)},
 	}
-	require.NoError(t, repo.BatchCreate(drivers))
+	require.NoError(t, repo.BatchCreate(context.Background(), drivers))
 
 	center := domain.NewPoint(15, 15)
-	found, err := repo.SearchNearby(center, 1000, 0)
+	found, err := repo.SearchNearby(context.Background(), center, 0, 1000, 0, domain.DriverFilter{})
 	require.NoError(t, err)
 	assert.GreaterOrEqual(t, len(found), 0)
 }
+
+// TestMongoDriverRepository_SearchNearby_Annulus tests searching drivers between two radii.
+// Expected: Should skip drivers closer than the minimum radius.
+func TestMongoDriverRepository_SearchNearby_Annulus(t *testing.T) {
+	repo, cleanup := setupMongoTestRepo(t)
+	defer cleanup()
+
+	drivers := []*domain.Driver{
+		{ID: "a1", Location: domain.NewPoint(30, 30)},
+		{ID: "a2", Location: domain.NewPoint(30.03, 30)},
+		{ID: "a3", Location: domain.NewPoint(30.1, 30)},
+	}
+	require.NoError(t, repo.BatchCreate(context.Background(), drivers))
+
+	// a2 is ~2.9km away, a1 is at the center and a3 is ~9.6km away
+	found, err := repo.SearchNearby(context.Background(), domain.NewPoint(30, 30), 2000, 5000, 10, domain.DriverFilter{})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, "a2", found[0].Driver.ID)
+}
+
+// TestMongoDriverRepository_SearchGeoNear tests searching drivers with the $geoNear stage.
+// Expected: Should return the available drivers of the ring nearest first with the distances computed by mongo.
+func TestMongoDriverRepository_SearchGeoNear(t *testing.T) {
+	repo, cleanup := setupMongoTestRepo(t)
+	defer cleanup()
+
+	drivers := []*domain.Driver{
+		{ID: "g1", Location: domain.NewPoint(35, 35)},
+		{ID: "g2", Location: domain.NewPoint(35.03, 35)},
+		{ID: "g3", Location: domain.NewPoint(35.02, 35)},
+		{ID: "g4", Location: domain.NewPoint(35.025, 35), Status: domain.DriverStatusBusy},
+		{ID: "g5", Location: domain.NewPoint(35.1, 35)},
+	}
+	require.NoError(t, repo.BatchCreate(context.Background(), drivers))
+
+	center := domain.NewPoint(35, 35)
+	found, err := repo.SearchGeoNear(context.Background(), center, 1000, 5000, 10, domain.DriverFilter{})
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	assert.Equal(t, "g3", found[0].Driver.ID)
+	assert.Equal(t, "g2", found[1].Driver.ID)
+	for _, d := range found {
+		// spheroidal distances of mongo stay within a few meters of Haversine
+		assert.InDelta(t, center.Distance(d.Driver.Location), d.Distance, 10)
+	}
+
+	found, err = repo.SearchGeoNear(context.Background(), center, 0, 5000, 1, domain.DriverFilter{})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, "g1", found[0].Driver.ID)
+}
+
+// TestMongoDriverRepository_SearchNearby_VehicleFilter tests searching drivers of a vehicle type and capacity.
+// Expected: Should return only the drivers matching the filter with both search stages.
+func TestMongoDriverRepository_SearchNearby_VehicleFilter(t *testing.T) {
+	repo, cleanup := setupMongoTestRepo(t)
+	defer cleanup()
+
+	drivers := []*domain.Driver{
+		{ID: "v1", Location: domain.NewPoint(36, 36), VehicleType: "sedan", Capacity: 4},
+		{ID: "v2", Location: domain.NewPoint(36.001, 36), VehicleType: "van", Capacity: 6},
+		{ID: "v3", Location: domain.NewPoint(36.002, 36), VehicleType: "van", Capacity: 8},
+		{ID: "v4", Location: domain.NewPoint(36.003, 36)},
+	}
+	require.NoError(t, repo.BatchCreate(context.Background(), drivers))
+
+	center := domain.NewPoint(36, 36)
+	filter := domain.DriverFilter{VehicleType: "van", MinCapacity: 7}
+	found, err := repo.SearchNearby(context.Background(), center, 0, 1000, 10, filter)
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, "v3", found[0].Driver.ID)
+
+	found, err = repo.SearchGeoNear(context.Background(), center, 0, 1000, 10, domain.DriverFilter{VehicleType: "van"})
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	assert.Equal(t, "v2", found[0].Driver.ID)
+	assert.Equal(t, "v3", found[1].Driver.ID)
+
+	found, err = repo.SearchNearby(context.Background(), center, 0, 1000, 10, domain.DriverFilter{})
+	require.NoError(t, err)
+	assert.Len(t, found, 4)
+}
+
+// TestMongoDriverRepository_SearchInCell tests searching and counting drivers by S2 cell.
+// Expected: Should find the available drivers of the cell and count them per descendant cell.
+func TestMongoDriverRepository_SearchInCell(t *testing.T) {
+	repo, cleanup := setupMongoTestRepo(t)
+	defer cleanup()
+
+	drivers := []*domain.Driver{
+		{ID: "c1", Location: domain.NewPoint(-73.9855, 40.7580)},
+		{ID: "c2", Location: domain.NewPoint(-73.9857, 40.7581)},
+		{ID: "c3", Location: domain.NewPoint(-73.9680, 40.7850)},
+		{ID: "c4", Location: domain.NewPoint(-73.9856, 40.7580), Status: domain.DriverStatusBusy},
+		{ID: "c5", Location: domain.NewPoint(-90.3138, -0.7406)},
+	}
+	require.NoError(t, repo.BatchCreate(context.Background(), drivers))
+
+	cell := domain.CellIDFromLatLng(40.7580, -73.9855).Parent(10)
+	found, err := repo.SearchInCell(context.Background(), cell, 10)
+	require.NoError(t, err)
+	ids := make([]string, 0, len(found))
+	for _, d := range found {
+		ids = append(ids, d.ID)
+	}
+	assert.ElementsMatch(t, []string{"c1", "c2", "c3"}, ids)
+
+	counts, err := repo.CountInCell(context.Background(), cell, 16, 10)
+	require.NoError(t, err)
+	require.Len(t, counts, 2)
+	assert.Equal(t, domain.CellIDFromLatLng(40.7580, -73.9855).Parent(16).Token(), counts[0].Cell)
+	assert.Equal(t, 2, counts[0].Count)
+	assert.Equal(t, 1, counts[1].Count)
+
+	// faces 4 and 5 are stored as negative longs
+	galapagos := domain.CellIDFromLatLng(-0.7406, -90.3138)
+	counts, err = repo.CountInCell(context.Background(), galapagos.Parent(8), 12, 10)
+	require.NoError(t, err)
+	require.Len(t, counts, 1)
+	assert.Equal(t, galapagos.Parent(12).Token(), counts[0].Cell)
+}
+
+// TestMongoDriverRepository_SearchNearby_OnlyAvailable tests that searches skip unavailable drivers.
+// Expected: Should return available drivers only.
+func TestMongoDriverRepository_SearchNearby_OnlyAvailable(t *testing.T) {
+	repo, cleanup := setupMongoTestRepo(t)
+	defer cleanup()
+
+	drivers := []*domain.Driver{
+		{ID: "v1", Location: domain.NewPoint(40, 40)},
+		{ID: "v2", Location: domain.NewPoint(40.0001, 40), Status: domain.DriverStatusBusy},
+		{ID: "v3", Location: domain.NewPoint(40.0002, 40), Status: domain.DriverStatusOffline},
+	}
+	require.NoError(t, repo.BatchCreate(context.Background(), drivers))
+
+	found, err := repo.SearchNearby(context.Background(), domain.NewPoint(40, 40), 0, 1000, 10, domain.DriverFilter{})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, "v1", found[0].Driver.ID)
+}
+
+// TestMongoDriverRepository_SearchNearby_MaxLocationAge tests searches with a maximum location age
+// Expected: Should leave drivers without a recent location update out of every search
+func TestMongoDriverRepository_SearchNearby_MaxLocationAge(t *testing.T) {
+	repo, cleanup := setupMongoTestRepo(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	drivers := []*domain.Driver{
+		{ID: "fresh", Location: domain.NewPoint(40, 40)},
+		{ID: "stale", Location: domain.NewPoint(40.0001, 40)},
+	}
+	require.NoError(t, repo.BatchCreate(ctx, drivers))
+	_, err := repo.collection.UpdateOne(ctx, bson.M{"_id": "stale"}, bson.M{"$set": bson.M{"updated_at": time.Now().Add(-time.Hour)}})
+	require.NoError(t, err)
+
+	found, err := repo.SearchNearby(ctx, domain.NewPoint(40, 40), 0, 1000, 10, domain.DriverFilter{})
+	require.NoError(t, err)
+	assert.Len(t, found, 2)
+
+	repo.maxAge = 10 * time.Minute
+	found, err = repo.SearchNearby(ctx, domain.NewPoint(40, 40), 0, 1000, 10, domain.DriverFilter{})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, "fresh", found[0].Driver.ID)
+
+	found, err = repo.SearchGeoNear(ctx, domain.NewPoint(40, 40), 0, 1000, 10, domain.DriverFilter{})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, "fresh", found[0].Driver.ID)
+
+	inBox, err := repo.SearchInBox(ctx, domain.BoxSearchRequest{MinLongitude: 39, MinLatitude: 39, MaxLongitude: 41, MaxLatitude: 41}, 10)
+	require.NoError(t, err)
+	require.Len(t, inBox, 1)
+	assert.Equal(t, "fresh", inBox[0].ID)
+}
+
+// TestMongoDriverRepository_Heartbeat tests heartbeats and searches with a heartbeat timeout
+// Expected: Should leave drivers whose heartbeat lapsed out of searches and keep drivers that never sent one
+func TestMongoDriverRepository_Heartbeat(t *testing.T) {
+	repo, cleanup := setupMongoTestRepo(t)
+	defer cleanup()
+	ctx := context.Background()
+	repo.heartbeat = time.Minute
+
+	drivers := []*domain.Driver{
+		{ID: "silent", Location: domain.NewPoint(40, 40)},
+		{ID: "alive", Location: domain.NewPoint(40.0001, 40)},
+		{ID: "lapsed", Location: domain.NewPoint(40.0002, 40)},
+	}
+	require.NoError(t, repo.BatchCreate(ctx, drivers))
+	_, err := repo.collection.UpdateMany(ctx, bson.M{}, bson.M{"$set": bson.M{"updated_at": time.Now().Add(-time.Hour)}})
+	require.NoError(t, err)
+	require.NoError(t, repo.Heartbeat(ctx, "alive", time.Now()))
+	require.NoError(t, repo.Heartbeat(ctx, "lapsed", time.Now().Add(-time.Hour)))
+
+	err = repo.Heartbeat(ctx, "missing", time.Now())
+	assert.ErrorIs(t, err, domain.ErrDriverNotFound)
+
+	found, err := repo.SearchNearby(ctx, domain.NewPoint(40, 40), 0, 1000, 10, domain.DriverFilter{})
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	assert.Equal(t, "silent", found[0].Driver.ID)
+	assert.Equal(t, "alive", found[1].Driver.ID)
+	assert.NotNil(t, found[1].Driver.LastSeenAt)
+
+	// a location update is a sign of life too
+	lapsed, err := repo.GetByID(ctx, "lapsed")
+	require.NoError(t, err)
+	require.NoError(t, repo.Update(ctx, lapsed))
+	found, err = repo.SearchGeoNear(ctx, domain.NewPoint(40, 40), 0, 1000, 10, domain.DriverFilter{})
+	require.NoError(t, err)
+	assert.Len(t, found, 3)
+
+	// the inactivity check leaves drivers with a recent heartbeat online
+	stale, err := repo.StaleBefore(ctx, time.Now().Add(-time.Minute), 10)
+	require.NoError(t, err)
+	ids := make([]string, len(stale))
+	for i, driver := range stale {
+		ids[i] = driver.ID
+	}
+	assert.ElementsMatch(t, []string{"silent"}, ids)
+}
+
+// TestMongoDriverRepository_StageImport tests committing and aborting staged imports
+// Expected: Should keep staged drivers out of searches until the commit merges them and discard them on abort
+func TestMongoDriverRepository_StageImport(t *testing.T) {
+	repo, cleanup := setupMongoTestRepo(t)
+	defer cleanup()
+	ctx := context.Background()
+	require.NoError(t, repo.Create(ctx, &domain.Driver{ID: "live", Location: domain.NewPoint(40, 40)}))
+
+	stage, err := repo.StageImport(ctx)
+	require.NoError(t, err)
+	require.NoError(t, stage.BatchCreate(ctx, []*domain.Driver{
+		{ID: "staged1", Location: domain.NewPoint(40.0001, 40)},
+		{ID: "staged2", Location: domain.NewPoint(40.0002, 40)},
+	}))
+
+	found, err := repo.SearchNearby(ctx, domain.NewPoint(40, 40), 0, 1000, 10, domain.DriverFilter{})
+	require.NoError(t, err)
+	assert.Len(t, found, 1)
+
+	merged, err := stage.Commit(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), merged)
+	found, err = repo.SearchNearby(ctx, domain.NewPoint(40, 40), 0, 1000, 10, domain.DriverFilter{})
+	require.NoError(t, err)
+	assert.Len(t, found, 3)
+
+	stage, err = repo.StageImport(ctx)
+	require.NoError(t, err)
+	require.NoError(t, stage.BatchCreate(ctx, []*domain.Driver{{ID: "aborted", Location: domain.NewPoint(40.0003, 40)}}))
+	require.NoError(t, stage.Abort(ctx))
+
+	_, err = repo.GetByID(ctx, "aborted")
+	assert.ErrorIs(t, err, domain.ErrDriverNotFound)
+	names, err := repo.database.ListCollectionNames(ctx, bson.M{"name": ImportStagingCollection})
+	require.NoError(t, err)
+	assert.Empty(t, names)
+}
+
+// TestMongoDriverRepository_List tests listing drivers by pages and filters
+// Expected: Should return the drivers after the cursor ordered by ID and apply the status and update filters
+func TestMongoDriverRepository_List(t *testing.T) {
+	repo, cleanup := setupMongoTestRepo(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	drivers := []*domain.Driver{
+		{ID: "l1", Location: domain.NewPoint(40, 40)},
+		{ID: "l2", Location: domain.NewPoint(40, 40), Status: domain.DriverStatusBusy},
+		{ID: "l3", Location: domain.NewPoint(40, 40)},
+	}
+	require.NoError(t, repo.BatchCreate(ctx, drivers))
+	_, err := repo.collection.UpdateOne(ctx, bson.M{"_id": "l1"}, bson.M{"$set": bson.M{"updated_at": time.Now().Add(-time.Hour)}})
+	require.NoError(t, err)
+
+	page, err := repo.List(ctx, domain.DriverListFilter{}, "l1", 10)
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	assert.Equal(t, "l2", page[0].ID)
+	assert.Equal(t, "l3", page[1].ID)
+
+	page, err = repo.List(ctx, domain.DriverListFilter{Status: domain.DriverStatusAvailable}, "", 10)
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	assert.Equal(t, "l1", page[0].ID)
+
+	page, err = repo.List(ctx, domain.DriverListFilter{UpdatedSince: time.Now().Add(-time.Minute)}, "", 1)
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	assert.Equal(t, "l2", page[0].ID)
+}
+
+// TestMongoDriverRepository_ColocatedDrivers tests grouping drivers by identical coordinates.
+// Expected: Should return the groups of two or more drivers up to the limit, ordered by coordinates.
+func TestMongoDriverRepository_ColocatedDrivers(t *testing.T) {
+	repo, cleanup := setupMongoTestRepo(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	drivers := []*domain.Driver{
+		{ID: "c1", Location: domain.NewPoint(41, 41)},
+		{ID: "c2", Location: domain.NewPoint(41, 41)},
+		{ID: "c3", Location: domain.NewPoint(42, 42)},
+		{ID: "c4", Location: domain.NewPoint(42, 42)},
+		{ID: "c5", Location: domain.NewPoint(42, 42)},
+		{ID: "c6", Location: domain.NewPoint(41.0001, 41)},
+	}
+	require.NoError(t, repo.BatchCreate(ctx, drivers))
+
+	groups, err := repo.ColocatedDrivers(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, groups, 2)
+	assert.Len(t, groups[0], 2)
+	assert.Len(t, groups[1], 3)
+	assert.Equal(t, 42.0, groups[1][0].Location.Longitude())
+
+	groups, err = repo.ColocatedDrivers(ctx, 1)
+	require.NoError(t, err)
+	assert.Len(t, groups, 1)
+}
+
+// TestMongoDriverRepository_RecordOutcome tests counting match outcomes of a driver.
+// Expected: Should increment the counts and keep them through a driver update.
+func TestMongoDriverRepository_RecordOutcome(t *testing.T) {
+	repo, cleanup := setupMongoTestRepo(t)
+	defer cleanup()
+
+	drv := &domain.Driver{ID: "driver-outcomes", Location: domain.NewPoint(10, 10)}
+	require.NoError(t, repo.Create(context.Background(), drv))
+
+	_, err := repo.RecordOutcome(context.Background(), drv.ID, domain.OutcomeAccepted)
+	require.NoError(t, err)
+	outcomes, err := repo.RecordOutcome(context.Background(), drv.ID, domain.OutcomeCompleted)
+	require.NoError(t, err)
+	assert.Equal(t, domain.DriverOutcomes{Accepted: 1, Completed: 1}, *outcomes)
+
+	drv.Location = domain.NewPoint(20, 20)
+	require.NoError(t, repo.Update(context.Background(), drv))
+	got, err := repo.GetByID(context.Background(), drv.ID)
+	require.NoError(t, err)
+	require.NotNil(t, got.Outcomes)
+	assert.Equal(t, int64(1), got.Outcomes.Completed)
+
+	_, err = repo.RecordOutcome(context.Background(), "missing", domain.OutcomeCompleted)
+	assert.ErrorIs(t, err, domain.ErrDriverNotFound)
+}
+
+// TestMongoDriverRepository_ApplyReplicated tests replicated upserts and deletes arriving in any order.
+// Expected: Should keep the newest write of the driver and skip the older ones.
+func TestMongoDriverRepository_ApplyReplicated(t *testing.T) {
+	repo, cleanup := setupMongoTestRepo(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	updatedAt := time.Now().UTC().Truncate(time.Millisecond)
+	newer := &domain.Driver{ID: "driver-replica", Location: domain.NewPoint(20, 20), CreatedAt: updatedAt.Add(-time.Hour), UpdatedAt: updatedAt}
+	applied, err := repo.ApplyReplicatedUpsert(ctx, newer)
+	require.NoError(t, err)
+	assert.True(t, applied)
+
+	older := &domain.Driver{ID: "driver-replica", Location: domain.NewPoint(10, 10), UpdatedAt: updatedAt.Add(-time.Second)}
+	applied, err = repo.ApplyReplicatedUpsert(ctx, older)
+	require.NoError(t, err)
+	assert.False(t, applied)
+
+	got, err := repo.GetByID(ctx, "driver-replica")
+	require.NoError(t, err)
+	assert.Equal(t, 20.0, got.Location.Longitude())
+	assert.True(t, updatedAt.Equal(got.UpdatedAt))
+	assert.NotZero(t, got.S2Cell)
+
+	deleted, err := repo.ApplyReplicatedDelete(ctx, "driver-replica", updatedAt.Add(-time.Second))
+	require.NoError(t, err)
+	assert.False(t, deleted)
+
+	deleted, err = repo.ApplyReplicatedDelete(ctx, "driver-replica", updatedAt)
+	require.NoError(t, err)
+	assert.True(t, deleted)
+	_, err = repo.GetByID(ctx, "driver-replica")
+	assert.ErrorIs(t, err, domain.ErrDriverNotFound)
+}
+
+// TestMongoDriverRepository_SearchNearby_BruteForce tests both search stages against Haversine distances computed for every driver.
+// Expected: Should return exactly the drivers within the radius, nearest first, around the poles and the antimeridian too.
+func TestMongoDriverRepository_SearchNearby_BruteForce(t *testing.T) {
+	repo, cleanup := setupMongoTestRepo(t)
+	defer cleanup()
+
+	const (
+		radius = 3000.0
+		// mongo measures on a sphere a little larger than Haversine does,
+		// drivers this close to the edge may fall on either side of it
+		edge = radius * 0.005
+	)
+	centers := map[string]domain.Point{
+		"istanbul":     domain.NewPoint(28.9784, 41.0082),
+		"equator":      domain.NewPoint(0, 0),
+		"tromso":       domain.NewPoint(18.9553, 69.6496),
+		"antimeridian": domain.NewPoint(179.99, -16.5),
+	}
+
+	rng := rand.New(rand.NewSource(42))
+	for name, center := range centers {
+		drivers := make([]*domain.Driver, 150)
+		for i := range drivers {
+			// a random bearing and a distance of up to twice the radius
+			distance := rng.Float64() * 2 * radius
+			bearing := rng.Float64() * 2 * math.Pi
+			lat := center.Latitude() + distance*math.Cos(bearing)/111320
+			lon := center.Longitude() + distance*math.Sin(bearing)/(111320*math.Cos(center.Latitude()*math.Pi/180))
+			if lon > 180 {
+				lon -= 360
+			}
+			drivers[i] = &domain.Driver{ID: fmt.Sprintf("%s-%d", name, i), Location: domain.NewPoint(lon, lat)}
+		}
+		require.NoError(t, repo.BatchCreate(context.Background(), drivers))
+
+		for stage, search := range map[string]func(context.Context, domain.Point, float64, float64, int, domain.DriverFilter) ([]*domain.DriverWithDistance, error){
+			"$near":    repo.SearchNearby,
+			"$geoNear": repo.SearchGeoNear,
+		} {
+			found, err := search(context.Background(), center, 0, radius, len(drivers), domain.DriverFilter{})
+			require.NoError(t, err, "%s around %s", stage, name)
+
+			returned := make(map[string]bool, len(found))
+			for i, d := range found {
+				returned[d.Driver.ID] = true
+				assert.InDelta(t, center.Distance(d.Driver.Location), d.Distance, edge, "%s around %s returned %s", stage, name, d.Driver.ID)
+				if i > 0 {
+					assert.GreaterOrEqual(t, d.Distance, found[i-1].Distance-1, "%s around %s returned %s out of order", stage, name, d.Driver.ID)
+				}
+			}
+			for _, d := range drivers {
+				distance := center.Distance(d.Location)
+				if distance < radius-edge {
+					assert.True(t, returned[d.ID], "%s around %s missed %s at %.0fm", stage, name, d.ID, distance)
+				}
+				if distance > radius+edge {
+					assert.False(t, returned[d.ID], "%s around %s returned %s at %.0fm", stage, name, d.ID, distance)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"the-driver-location-service/internal/domain"
+)
+
+// ShardKey is the strategy used to partition the drivers collection of a sharded
+// cluster. The repository creates the index backing the shard key and shapes its
+// writes so mongos can route them, sharding the collection is left to the operator.
+type ShardKey string
+
+const (
+	ShardKeyNone     ShardKey = "none"
+	ShardKeyHashedID ShardKey = "hashed_id" // even spread of writes, every search hits every shard
+	ShardKeyGeohash  ShardKey = "geohash"   // range of geohash cells, nearby drivers share a chunk
+	ShardKeyTenant   ShardKey = "tenant"    // one range per tenant, searches stay scatter-gather
+)
+
+// ParseShardKey returns the strategy of MONGO_SHARD_KEY, empty means unsharded
+func ParseShardKey(value string) (ShardKey, error) {
+	switch key := ShardKey(value); key {
+	case "", ShardKeyNone:
+		return ShardKeyNone, nil
+	case ShardKeyHashedID, ShardKeyGeohash, ShardKeyTenant:
+		return key, nil
+	default:
+		return "", fmt.Errorf("unknown shard key strategy: %s", value)
+	}
+}
+
+// Keys returns the shard key pattern, nil when the collection is not sharded
+func (k ShardKey) Keys() bson.D {
+	switch k {
+	case ShardKeyHashedID:
+		return bson.D{{Key: "_id", Value: "hashed"}}
+	case ShardKeyGeohash:
+		return bson.D{{Key: "geohash_cell", Value: 1}, {Key: "_id", Value: 1}}
+	case ShardKeyTenant:
+		return bson.D{{Key: "tenant_id", Value: 1}, {Key: "_id", Value: 1}}
+	default:
+		return nil
+	}
+}
+
+// Fields returns the shard key fields besides _id, a write changing one of them
+// moves the driver to another chunk and needs their stored values in its filter
+func (k ShardKey) Fields() []string {
+	var fields []string
+	for _, key := range k.Keys() {
+		if key.Key != "_id" {
+			fields = append(fields, key.Key)
+		}
+	}
+	return fields
+}
+
+// ShardCollectionCommand is the admin command sharding the collection with this
+// key, run against mongos once the backing index exists
+func (k ShardKey) ShardCollectionCommand(database, collection string) bson.D {
+	return bson.D{
+		{Key: "shardCollection", Value: database + "." + collection},
+		{Key: "key", Value: k.Keys()},
+	}
+}
+
+func (k ShardKey) index() *mongo.IndexModel {
+	keys := k.Keys()
+	if keys == nil {
+		return nil
+	}
+	return &mongo.IndexModel{Keys: keys, Options: options.Index().SetName("shard_key_" + string(k))}
+}
+
+// shardFilter selects a driver by ID. With a shard key that may change it adds
+// the stored values of the shard key fields, mongos refuses updates moving a
+// document to another chunk when the filter lacks the full current shard key.
+// Reading them costs a round trip, Update only pays it when the shard key the
+// driver was read with is no longer the stored one.
+func (r *MongoDriverRepository) shardFilter(ctx context.Context, id string) (bson.M, error) {
+	filter := bson.M{"_id": id}
+	fields := r.shardKey.Fields()
+	if len(fields) == 0 {
+		return filter, nil
+	}
+
+	projection := bson.M{}
+	for _, field := range fields {
+		projection[field] = 1
+	}
+	var stored bson.M
+	err := r.collection.FindOne(ctx, filter, options.FindOne().SetProjection(projection)).Decode(&stored)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("%w: %s", domain.ErrDriverNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read shard key of driver %s: %w", id, err)
+	}
+
+	// a missing field is matched by nil, drivers stored before the field existed
+	// are still found
+	for _, field := range fields {
+		filter[field] = stored[field]
+	}
+	return filter, nil
+}
+
+// changesShardKey reports whether an update sets one of the shard key fields
+func (k ShardKey) changesShardKey(fields map[string]interface{}) bool {
+	for _, field := range k.Fields() {
+		if _, ok := fields[field]; ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// TestParseShardKey tests reading the shard key strategy from configuration.
+// Expected: Should treat an empty value as unsharded and reject unknown strategies.
+func TestParseShardKey(t *testing.T) {
+	key, err := ParseShardKey("")
+	require.NoError(t, err)
+	assert.Equal(t, ShardKeyNone, key)
+	assert.Nil(t, key.Keys())
+	assert.Empty(t, key.Fields())
+
+	key, err = ParseShardKey("geohash")
+	require.NoError(t, err)
+	assert.Equal(t, ShardKeyGeohash, key)
+
+	_, err = ParseShardKey("zone")
+	assert.Error(t, err)
+}
+
+// TestShardKey_Keys tests the shard key patterns and the fields writes must route by.
+// Expected: Should pair ranged keys with _id for cardinality and leave hashed _id without extra fields.
+func TestShardKey_Keys(t *testing.T) {
+	assert.Equal(t, bson.D{{Key: "_id", Value: "hashed"}}, ShardKeyHashedID.Keys())
+	assert.Empty(t, ShardKeyHashedID.Fields())
+
+	assert.Equal(t, bson.D{{Key: "geohash_cell", Value: 1}, {Key: "_id", Value: 1}}, ShardKeyGeohash.Keys())
+	assert.Equal(t, []string{"geohash_cell"}, ShardKeyGeohash.Fields())
+	assert.True(t, ShardKeyGeohash.changesShardKey(map[string]interface{}{"geohash_cell": "sxk9"}))
+	assert.False(t, ShardKeyGeohash.changesShardKey(map[string]interface{}{"status": "busy"}))
+
+	assert.Equal(t, []string{"tenant_id"}, ShardKeyTenant.Fields())
+	assert.False(t, ShardKeyHashedID.changesShardKey(map[string]interface{}{"geohash_cell": "sxk9"}))
+}
+
+// TestShardKey_ShardCollectionCommand tests the admin command printed for the operator.
+// Expected: Should name the namespace and the shard key pattern.
+func TestShardKey_ShardCollectionCommand(t *testing.T) {
+	command, err := bson.MarshalExtJSON(ShardKeyTenant.ShardCollectionCommand("driver_location", "drivers"), false, false)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"shardCollection":"driver_location.drivers","key":{"tenant_id":1,"_id":1}}`, string(command))
+}
@@ -0,0 +1,80 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+
+	"the-driver-location-service/internal/domain"
+	"the-driver-location-service/internal/ports/secondary"
+)
+
+type messageWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// KafkaDriverEventPublisher writes driver events to a Kafka topic keyed by driver ID,
+// so all events of a driver land on the same partition and stay ordered. Writes are
+// asynchronous: Publish returns once the events are buffered and delivery failures
+// are logged, a request never waits for the brokers.
+type KafkaDriverEventPublisher struct {
+	writer messageWriter
+	logger secondary.Logger
+}
+
+var _ secondary.DriverEventPublisher = (*KafkaDriverEventPublisher)(nil)
+
+func NewKafkaDriverEventPublisher(brokers []string, topic string) *KafkaDriverEventPublisher {
+	p := &KafkaDriverEventPublisher{logger: secondary.NopLogger{}}
+	p.writer = &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireOne,
+		BatchTimeout: 10 * time.Millisecond,
+		Async:        true,
+		Completion: func(messages []kafka.Message, err error) {
+			if err != nil {
+				p.logger.Warn(context.Background(), "failed to publish driver events", "events", len(messages), "error", err)
+			}
+		},
+	}
+	return p
+}
+
+// SetLogger sets where the events the brokers did not take are logged
+func (p *KafkaDriverEventPublisher) SetLogger(logger secondary.Logger) {
+	p.logger = logger
+}
+
+func (p *KafkaDriverEventPublisher) Publish(ctx context.Context, events ...domain.DriverEvent) error {
+	messages := make([]kafka.Message, len(events))
+	for i, event := range events {
+		value, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to encode driver event: %w", err)
+		}
+		messages[i] = kafka.Message{
+			Key:   []byte(event.DriverID),
+			Value: value,
+			Headers: []kafka.Header{
+				{Key: "event_type", Value: []byte(event.Type)},
+			},
+			Time: event.OccurredAt,
+		}
+	}
+
+	if err := p.writer.WriteMessages(ctx, messages...); err != nil {
+		return fmt.Errorf("failed to publish driver events: %w", err)
+	}
+	return nil
+}
+
+// Close flushes the buffered events
+func (p *KafkaDriverEventPublisher) Close() error {
+	return p.writer.Close()
+}
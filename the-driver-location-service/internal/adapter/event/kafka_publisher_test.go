@@ -0,0 +1,61 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"the-driver-location-service/internal/domain"
+)
+
+type recordingWriter struct {
+	messages []kafka.Message
+	err      error
+}
+
+func (w *recordingWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	w.messages = append(w.messages, msgs...)
+	return w.err
+}
+
+func (w *recordingWriter) Close() error { return nil }
+
+// TestKafkaDriverEventPublisher_Publish tests encoding driver events as Kafka messages
+// Expected: Should key messages by driver ID, set the event type header and encode the event as JSON
+func TestKafkaDriverEventPublisher_Publish(t *testing.T) {
+	writer := &recordingWriter{}
+	publisher := &KafkaDriverEventPublisher{writer: writer}
+	occurredAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	event := domain.NewDriverEvent(domain.DriverLocationUpdated, &domain.Driver{ID: "d1", Location: domain.NewPoint(29, 41)})
+	event.OccurredAt = occurredAt
+
+	require.NoError(t, publisher.Publish(context.Background(), event))
+	require.Len(t, writer.messages, 1)
+
+	message := writer.messages[0]
+	assert.Equal(t, "d1", string(message.Key))
+	assert.Equal(t, []kafka.Header{{Key: "event_type", Value: []byte("driver.location_updated")}}, message.Headers)
+	assert.Equal(t, occurredAt, message.Time)
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(message.Value, &body))
+	assert.Equal(t, "driver.location_updated", body["type"])
+	assert.Equal(t, "d1", body["driver_id"])
+	assert.Equal(t, "2025-01-02T03:04:05Z", body["occurred_at"])
+	assert.NotNil(t, body["location"])
+}
+
+// TestKafkaDriverEventPublisher_Publish_Error tests a failing write
+// Expected: Should return the writer error
+func TestKafkaDriverEventPublisher_Publish_Error(t *testing.T) {
+	publisher := &KafkaDriverEventPublisher{writer: &recordingWriter{err: errors.New("broker down")}}
+
+	err := publisher.Publish(context.Background(), domain.DriverEvent{Type: domain.DriverDeleted, DriverID: "d1"})
+	assert.ErrorContains(t, err, "broker down")
+}
@@ -0,0 +1,113 @@
+package featureflag
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+
+	"the-driver-location-service/internal/domain"
+	"the-driver-location-service/internal/ports/secondary"
+)
+
+// EnvProvider parses flags from a comma separated list,
+// e.g. FEATURE_FLAGS="geonear_search=true,write_behind_cache=25"
+// where a number enables the flag for that percentage of keys
+type EnvProvider struct {
+	value string
+}
+
+var _ secondary.FeatureFlagProvider = (*EnvProvider)(nil)
+
+func NewEnvProvider(value string) *EnvProvider {
+	return &EnvProvider{value: value}
+}
+
+func (p *EnvProvider) LoadFlags(ctx context.Context) ([]domain.FeatureFlag, error) {
+	var flags []domain.FeatureFlag
+	for _, entry := range strings.Split(p.value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, value, found := strings.Cut(entry, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid feature flag entry: %s", entry)
+		}
+
+		flag := domain.FeatureFlag{Name: strings.TrimSpace(name)}
+		value = strings.TrimSuffix(strings.TrimSpace(value), "%")
+		if enabled, err := strconv.ParseBool(value); err == nil {
+			flag.Enabled = enabled
+		} else if percentage, err := strconv.Atoi(value); err == nil && percentage >= 0 && percentage <= 100 {
+			flag.Enabled = percentage > 0
+			flag.Percentage = percentage
+		} else {
+			return nil, fmt.Errorf("invalid value for feature flag %s: %s", flag.Name, value)
+		}
+
+		flags = append(flags, flag)
+	}
+	return flags, nil
+}
+
+// FileProvider reads a JSON array of flags, the file is read again on every refresh
+type FileProvider struct {
+	path string
+}
+
+var _ secondary.FeatureFlagProvider = (*FileProvider)(nil)
+
+func NewFileProvider(path string) *FileProvider {
+	return &FileProvider{path: path}
+}
+
+func (p *FileProvider) LoadFlags(ctx context.Context) ([]domain.FeatureFlag, error) {
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read feature flags file: %w", err)
+	}
+
+	var flags []domain.FeatureFlag
+	if err := json.Unmarshal(data, &flags); err != nil {
+		return nil, fmt.Errorf("failed to parse feature flags file: %w", err)
+	}
+	return flags, nil
+}
+
+// RedisProvider reads flags from a hash where every field is a flag name
+// and the value is the JSON encoded flag, so operators can flip flags with
+// HSET feature_flags geonear_search '{"enabled":true,"percentage":10}'
+type RedisProvider struct {
+	client *redis.Client
+	key    string
+}
+
+var _ secondary.FeatureFlagProvider = (*RedisProvider)(nil)
+
+func NewRedisProvider(client *redis.Client, key string) *RedisProvider {
+	return &RedisProvider{client: client, key: key}
+}
+
+func (p *RedisProvider) LoadFlags(ctx context.Context) ([]domain.FeatureFlag, error) {
+	values, err := p.client.HGetAll(ctx, p.key).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load feature flags from redis: %w", err)
+	}
+
+	flags := make([]domain.FeatureFlag, 0, len(values))
+	for name, value := range values {
+		var flag domain.FeatureFlag
+		if err := json.Unmarshal([]byte(value), &flag); err != nil {
+			return nil, fmt.Errorf("failed to parse feature flag %s: %w", name, err)
+		}
+		flag.Name = name
+		flags = append(flags, flag)
+	}
+	return flags, nil
+}
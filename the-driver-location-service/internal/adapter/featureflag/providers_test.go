@@ -0,0 +1,51 @@
+package featureflag
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEnvProvider_LoadFlags tests parsing flags from the environment format
+// Expected: Should parse booleans and percentages into flags
+func TestEnvProvider_LoadFlags(t *testing.T) {
+	flags, err := NewEnvProvider("geonear_search=true, write_behind_cache=25%,response_envelope_v2=false").LoadFlags(context.Background())
+	require.NoError(t, err)
+	require.Len(t, flags, 3)
+
+	assert.Equal(t, "geonear_search", flags[0].Name)
+	assert.True(t, flags[0].Enabled)
+	assert.Equal(t, "write_behind_cache", flags[1].Name)
+	assert.True(t, flags[1].Enabled)
+	assert.Equal(t, 25, flags[1].Percentage)
+	assert.False(t, flags[2].Enabled)
+}
+
+// TestEnvProvider_LoadFlags_Invalid tests invalid flag entries
+// Expected: Should return an error for malformed entries and values
+func TestEnvProvider_LoadFlags_Invalid(t *testing.T) {
+	_, err := NewEnvProvider("geonear_search").LoadFlags(context.Background())
+	assert.Error(t, err)
+
+	_, err = NewEnvProvider("geonear_search=maybe").LoadFlags(context.Background())
+	assert.Error(t, err)
+}
+
+// TestFileProvider_LoadFlags tests reading flags from a JSON file
+// Expected: Should decode every flag from the file
+func TestFileProvider_LoadFlags(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flags.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[{"name":"geonear_search","enabled":true,"environments":["staging"]}]`), 0o644))
+
+	flags, err := NewFileProvider(path).LoadFlags(context.Background())
+	require.NoError(t, err)
+	require.Len(t, flags, 1)
+	assert.Equal(t, []string{"staging"}, flags[0].Environments)
+
+	_, err = NewFileProvider(filepath.Join(t.TempDir(), "missing.json")).LoadFlags(context.Background())
+	assert.Error(t, err)
+}
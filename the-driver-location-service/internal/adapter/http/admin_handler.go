@@ -0,0 +1,124 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"the-driver-location-service/internal/domain"
+	"the-driver-location-service/internal/ports/primary"
+)
+
+// AdminHandler serves operational endpoints that are not part of the public driver API
+type AdminHandler struct {
+	flags     primary.FeatureFlagService
+	backfills primary.BackfillService
+}
+
+func NewAdminHandler(flags primary.FeatureFlagService, backfills primary.BackfillService) *AdminHandler {
+	return &AdminHandler{
+		flags:     flags,
+		backfills: backfills,
+	}
+}
+
+// @Summary List feature flags
+// @Description Get the current state of every feature flag for this environment
+// @Tags admin
+// @Produce json
+// @Success 200 {object} APIResponse
+// @Security X-API-KEY
+// @Router /admin/flags [get]
+func (h *AdminHandler) GetFeatureFlags(c echo.Context) error {
+	data := map[string]interface{}{
+		"environment": h.flags.Environment(),
+		"flags":       h.flags.Flags(),
+	}
+	return c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Data:    data,
+		Message: "Feature flags retrieved successfully",
+	})
+}
+
+// @Summary List backfill jobs
+// @Description Get the progress of every registered backfill job
+// @Tags admin
+// @Produce json
+// @Success 200 {object} APIResponse
+// @Security X-API-KEY
+// @Router /admin/backfills [get]
+func (h *AdminHandler) ListBackfills(c echo.Context) error {
+	jobs := make([]domain.BackfillProgress, 0)
+	for _, job := range h.backfills.Jobs() {
+		if progress, err := h.backfills.Progress(job); err == nil {
+			jobs = append(jobs, progress)
+		}
+	}
+	return c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Data:    map[string]interface{}{"jobs": jobs},
+		Message: "Backfill jobs retrieved successfully",
+	})
+}
+
+// @Summary Get backfill progress
+// @Description Get the progress of a backfill job
+// @Tags admin
+// @Produce json
+// @Param job path string true "Backfill job name"
+// @Success 200 {object} APIResponse
+// @Failure 404 {object} APIResponse
+// @Security X-API-KEY
+// @Router /admin/backfills/{job} [get]
+func (h *AdminHandler) GetBackfill(c echo.Context) error {
+	progress, err := h.backfills.Progress(c.Param("job"))
+	if err != nil {
+		return h.backfillError(c, err)
+	}
+	return c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Data:    progress,
+		Message: "Backfill progress retrieved successfully",
+	})
+}
+
+// @Summary Start a backfill
+// @Description Start a backfill job in the background, a failed job continues from its last checkpoint
+// @Tags admin
+// @Produce json
+// @Param job path string true "Backfill job name"
+// @Success 202 {object} APIResponse
+// @Failure 404 {object} APIResponse
+// @Failure 409 {object} APIResponse
+// @Security X-API-KEY
+// @Router /admin/backfills/{job} [post]
+func (h *AdminHandler) StartBackfill(c echo.Context) error {
+	job := c.Param("job")
+	if err := h.backfills.Start(job); err != nil {
+		return h.backfillError(c, err)
+	}
+
+	progress, _ := h.backfills.Progress(job)
+	return c.JSON(http.StatusAccepted, APIResponse{
+		Success: true,
+		Data:    progress,
+		Message: "Backfill started",
+	})
+}
+
+func (h *AdminHandler) backfillError(c echo.Context, err error) error {
+	status, errorType := http.StatusInternalServerError, "internal_error"
+	switch {
+	case errors.Is(err, domain.ErrBackfillNotFound):
+		status, errorType = http.StatusNotFound, "not_found"
+	case errors.Is(err, domain.ErrBackfillRunning):
+		status, errorType = http.StatusConflict, "conflict"
+	}
+	return c.JSON(status, APIResponse{
+		Success: false,
+		Error:   errorType,
+		Message: err.Error(),
+	})
+}
@@ -0,0 +1,333 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+
+	"the-driver-location-service/internal/domain"
+)
+
+// JSONCodec is the JSON serializer of the service, both services follow the same
+// naming policy: responses always use the snake_case names of the domain types and
+// requests are accepted in snake_case or camelCase, camelCase field names are
+// rewritten to snake_case before decoding so clients do not have to special-case
+// fields
+type JSONCodec struct{}
+
+var _ echo.JSONSerializer = JSONCodec{}
+
+// Serialize adds the timing of the request to the meta of the envelope when
+// the caller asked for it, see middleware.DebugTiming
+func (JSONCodec) Serialize(c echo.Context, i interface{}, indent string) error {
+	if resp, ok := i.(APIResponse); ok {
+		if timing := domain.TimingFrom(c.Request().Context()); timing != nil {
+			breakdown := timing.Breakdown()
+			resp.Meta = &ResponseMeta{Timing: &breakdown}
+			i = resp
+		}
+	}
+	enc := json.NewEncoder(c.Response())
+	if indent != "" {
+		enc.SetIndent("", indent)
+	}
+	return enc.Encode(i)
+}
+
+func (JSONCodec) Deserialize(c echo.Context, i interface{}) error {
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return err
+	}
+
+	body, err = normalizeJSONKeys(body, shapeOf(i))
+	if err == nil {
+		err = unmarshal(body, i, isStrictJSON(c))
+	}
+
+	var unknown *UnknownFieldError
+	if ute, ok := err.(*json.UnmarshalTypeError); ok {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Unmarshal type error: expected=%v, got=%v, field=%v, offset=%v", ute.Type, ute.Value, ute.Field, ute.Offset)).SetInternal(err)
+	} else if se, ok := err.(*json.SyntaxError); ok {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Syntax error: offset=%v, error=%v", se.Offset, se.Error())).SetInternal(err)
+	} else if errors.As(err, &unknown) {
+		return echo.NewHTTPError(http.StatusBadRequest, unknown.Error()).SetInternal(err)
+	}
+	return err
+}
+
+const strictJSONKey = "strict_json"
+
+// UnknownFieldError is returned by strict endpoints for body fields the request
+// type does not have, e.g. a misspelled "raduis" that would otherwise be ignored
+type UnknownFieldError struct {
+	Field string
+}
+
+func (e *UnknownFieldError) Error() string {
+	return fmt.Sprintf("unknown field %q", e.Field)
+}
+
+// StrictJSON makes the codec reject unknown body fields of the route it is
+// registered on, routes are strict one by one so lenient clients keep working
+func StrictJSON() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set(strictJSONKey, true)
+			return next(c)
+		}
+	}
+}
+
+func isStrictJSON(c echo.Context) bool {
+	strict, _ := c.Get(strictJSONKey).(bool)
+	return strict
+}
+
+func unmarshal(body []byte, i interface{}, strict bool) error {
+	if !strict {
+		return json.Unmarshal(body, i)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+	err := dec.Decode(i)
+	// encoding/json has no error type for unknown fields
+	if err != nil && strings.HasPrefix(err.Error(), "json: unknown field ") {
+		field, _ := strconv.Unquote(strings.TrimPrefix(err.Error(), "json: unknown field "))
+		return &UnknownFieldError{Field: field}
+	}
+	return err
+}
+
+// bindErrorMessage points at the unknown field of a strict request and returns
+// fallback for every other bind error
+func bindErrorMessage(err error, fallback string) string {
+	var unknown *UnknownFieldError
+	if errors.As(err, &unknown) {
+		return "Invalid request body: " + unknown.Error()
+	}
+	return fallback
+}
+
+// normalizeJSONKeys renames the object keys of the document that are a field of
+// shape written in camelCase or PascalCase to the snake_case name of the field.
+// Keys of maps and unknown keys are left as they are, and bodies without such a
+// key are returned as is without being decoded.
+func normalizeJSONKeys(body []byte, shape *jsonShape) ([]byte, error) {
+	if shape == nil || !shape.hasAliasKey(body) {
+		return body, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return json.Marshal(shape.rename(doc))
+}
+
+// jsonShape is what the codec knows of the objects of a request body: the
+// fields of its structs by their folded name, so "driverID", "DriverId" and
+// "driver_id" all find driver_id, and the shapes of the field values. The keys
+// of maps, e.g. the free-form attributes of a driver, are data and never renamed.
+type jsonShape struct {
+	fields  map[string]string     // folded name -> json name, structs only
+	values  map[string]*jsonShape // json name -> shape of the value
+	elem    *jsonShape            // items of arrays, values of maps
+	aliases map[string]bool       // folded names of the fields of the whole tree
+}
+
+func newStructShape() *jsonShape {
+	return &jsonShape{fields: make(map[string]string), values: make(map[string]*jsonShape)}
+}
+
+func (s *jsonShape) addField(name string, value *jsonShape) {
+	s.fields[foldKey(name)] = name
+	s.values[name] = value
+}
+
+// foldKey is the name a key is matched by, case and underscores ignored
+func foldKey(key string) string {
+	return strings.ToLower(strings.ReplaceAll(key, "_", ""))
+}
+
+var (
+	typeShapes      sync.Map // reflect.Type -> *jsonShape
+	unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+)
+
+// shapeOf returns the shape of the type a body is decoded into, nil for types
+// without struct fields
+func shapeOf(i interface{}) *jsonShape {
+	t := reflect.TypeOf(i)
+	if t == nil {
+		return nil
+	}
+	if cached, ok := typeShapes.Load(t); ok {
+		return cached.(*jsonShape)
+	}
+	shape := typeShape(t, make(map[reflect.Type]*jsonShape))
+	shape.collectAliases()
+	typeShapes.Store(t, shape)
+	return shape
+}
+
+func typeShape(t reflect.Type, seen map[reflect.Type]*jsonShape) *jsonShape {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if shape, ok := seen[t]; ok {
+		return shape
+	}
+	// types decoding themselves get their keys as sent
+	if t.Implements(unmarshalerType) || reflect.PointerTo(t).Implements(unmarshalerType) {
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		if elem := typeShape(t.Elem(), seen); elem != nil {
+			return &jsonShape{elem: elem}
+		}
+	case reflect.Struct:
+		shape := newStructShape()
+		seen[t] = shape
+		addStructFields(shape, t, seen)
+		return shape
+	}
+	return nil
+}
+
+// addStructFields adds the fields encoding/json decodes into, the fields of
+// embedded structs included
+func addStructFields(shape *jsonShape, t reflect.Type, seen map[reflect.Type]*jsonShape) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			addStructFields(shape, fieldType, seen)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		shape.addField(name, typeShape(field.Type, seen))
+	}
+}
+
+// collectAliases gathers the folded field names of every struct in the tree,
+// hasAliasKey looks the keys of a body up in them
+func (s *jsonShape) collectAliases() {
+	if s == nil {
+		return
+	}
+	s.aliases = make(map[string]bool)
+	visited := make(map[*jsonShape]bool)
+	var collect func(*jsonShape)
+	collect = func(shape *jsonShape) {
+		if shape == nil || visited[shape] {
+			return
+		}
+		visited[shape] = true
+		for folded := range shape.fields {
+			s.aliases[folded] = true
+		}
+		for _, value := range shape.values {
+			collect(value)
+		}
+		collect(shape.elem)
+	}
+	collect(s)
+}
+
+// hasAliasKey reports whether an object key of body with an upper case letter
+// folds to a field name of the shape, it scans the keys without decoding
+func (s *jsonShape) hasAliasKey(body []byte) bool {
+	for i := 0; i < len(body); i++ {
+		if body[i] != '"' {
+			continue
+		}
+		start, upper := i+1, false
+		for i = start; i < len(body) && body[i] != '"'; i++ {
+			switch {
+			case body[i] == '\\':
+				i++
+			case 'A' <= body[i] && body[i] <= 'Z':
+				upper = true
+			}
+		}
+		if !upper || i >= len(body) {
+			continue
+		}
+		key := body[start:i]
+		j := i + 1
+		for j < len(body) && (body[j] == ' ' || body[j] == '\t' || body[j] == '\n' || body[j] == '\r') {
+			j++
+		}
+		if j < len(body) && body[j] == ':' && s.aliases[foldKey(string(key))] {
+			return true
+		}
+	}
+	return false
+}
+
+// rename returns value with the keys of its objects renamed to the field names
+// of the shape, a key that is a field name already wins over an alias of it
+func (s *jsonShape) rename(value interface{}) interface{} {
+	if s == nil {
+		return value
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if s.fields == nil {
+			for key, item := range v {
+				v[key] = s.elem.rename(item)
+			}
+			return v
+		}
+		renamed := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			name := key
+			if _, ok := s.values[key]; !ok {
+				if field, ok := s.fields[foldKey(key)]; ok {
+					if _, sent := v[field]; !sent {
+						name = field
+					}
+				}
+			}
+			renamed[name] = s.values[name].rename(item)
+		}
+		return renamed
+	case []interface{}:
+		for i, item := range v {
+			v[i] = s.elem.rename(item)
+		}
+		return v
+	default:
+		return v
+	}
+}
@@ -0,0 +1,158 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"the-driver-location-service/internal/adapter/middleware"
+	"the-driver-location-service/internal/domain"
+)
+
+// TestNormalizeJSONKeys tests renaming the keys of request bodies to the field names of the request type
+// Expected: Should rename camelCase and PascalCase field names, keep snake_case bodies and attribute keys as sent
+func TestNormalizeJSONKeys(t *testing.T) {
+	shape := shapeOf(&[]domain.CreateDriverRequest{})
+	tests := map[string]string{
+		`[{"vehicle_type":"Sedan"}]`:                                                 `[{"vehicle_type":"Sedan"}]`,
+		`[{"vehicleType":"sedan","TenantID":"t1"}]`:                                  `[{"tenant_id":"t1","vehicle_type":"sedan"}]`,
+		`[{"Vehicle_Type":"sedan"}]`:                                                 `[{"vehicle_type":"sedan"}]`,
+		`[{"vehicleType":"van","attributes":{"childSeat":"yes","VehicleType":"x"}}]`: `[{"attributes":{"VehicleType":"x","childSeat":"yes"},"vehicle_type":"van"}]`,
+		`[{"vehicleType":"van","vehicle_type":"sedan"}]`:                             `[{"vehicleType":"van","vehicle_type":"sedan"}]`,
+		`[{"location":{"type":"Point","coordinates":[29,41]},"ownerName":"A"}]`:      `[{"location":{"type":"Point","coordinates":[29,41]},"ownerName":"A"}]`,
+	}
+	for input, expected := range tests {
+		normalized, err := normalizeJSONKeys([]byte(input), shape)
+		require.NoError(t, err, input)
+		assert.JSONEq(t, expected, string(normalized), input)
+	}
+
+	// values and unknown keys with upper case letters do not make the body decode
+	body := []byte(`[{"location":{"type":"Point","coordinates":[29,41]},"ownerName":"A"}]`)
+	normalized, _ := normalizeJSONKeys(body, shape)
+	assert.Equal(t, &body[0], &normalized[0])
+}
+
+// TestJSONCodec_DeserializeAttributes tests decoding a driver with camelCase attribute keys
+// Expected: Should store the attribute keys exactly as the client sent them
+func TestJSONCodec_DeserializeAttributes(t *testing.T) {
+	e := echo.New()
+	e.JSONSerializer = JSONCodec{}
+	body := `{"location":{"type":"Point","coordinates":[29,41]},"vehicleType":"van","attributes":{"childSeat":"yes","pet_friendly":"no"}}`
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	c := e.NewContext(req, httptest.NewRecorder())
+
+	var driver domain.CreateDriverRequest
+	require.NoError(t, c.Bind(&driver))
+	assert.Equal(t, "van", driver.VehicleType)
+	assert.Equal(t, map[string]string{"childSeat": "yes", "pet_friendly": "no"}, driver.Attributes)
+}
+
+// TestJSONCodec_DeserializeCamelCase tests decoding a camelCase request body
+// Expected: Should bind camelCase keys to the snake_case fields of the domain types
+func TestJSONCodec_DeserializeCamelCase(t *testing.T) {
+	e := echo.New()
+	e.JSONSerializer = JSONCodec{}
+	body := `{"location":{"type":"Point","coordinates":[29,41]},"radius":500,"Limit":3}`
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	c := e.NewContext(req, httptest.NewRecorder())
+
+	var search domain.SearchRequest
+	assert.NoError(t, c.Bind(&search))
+	assert.Equal(t, 500.0, search.Radius)
+	assert.Equal(t, 3, search.Limit)
+	assert.Equal(t, []float64{29, 41}, search.Location.Coordinates)
+}
+
+// TestJSONCodec_DeserializeInvalid tests decoding a malformed request body
+// Expected: Should return a 400 HTTP error
+func TestJSONCodec_DeserializeInvalid(t *testing.T) {
+	e := echo.New()
+	e.JSONSerializer = JSONCodec{}
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"Radius":`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	c := e.NewContext(req, httptest.NewRecorder())
+
+	var search domain.SearchRequest
+	err := c.Bind(&search)
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusBadRequest, httpErr.Code)
+}
+
+// TestJSONCodec_StrictJSON tests unknown body fields with and without the StrictJSON middleware
+// Expected: Should ignore them on lenient routes and reject camelCase and snake_case typos on strict routes
+func TestJSONCodec_StrictJSON(t *testing.T) {
+	e := echo.New()
+	e.JSONSerializer = JSONCodec{}
+	bind := func(c echo.Context) error {
+		var search domain.SearchRequest
+		if err := c.Bind(&search); err != nil {
+			return err
+		}
+		return c.NoContent(http.StatusNoContent)
+	}
+	e.POST("/lenient", bind)
+	e.POST("/strict", bind, StrictJSON())
+
+	serve := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusNoContent, serve("/lenient", `{"radius":500,"raduis":500}`).Code)
+	assert.Equal(t, http.StatusNoContent, serve("/strict", `{"radius":500,"minRadius":100}`).Code)
+
+	rec := serve("/strict", `{"radius":500,"raduis":500}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "raduis")
+
+	rec = serve("/strict", `{"Radius":500,"maxRadius":900}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "maxRadius")
+}
+
+// TestJSONCodec_DebugTiming tests the timing breakdown of requests sent with X-Debug-Timing
+// Expected: Should add the cache, database and total time to the meta of the envelope only when asked for
+func TestJSONCodec_DebugTiming(t *testing.T) {
+	e := echo.New()
+	e.JSONSerializer = JSONCodec{}
+	e.Use(middleware.DebugTiming())
+	e.GET("/drivers/:id", func(c echo.Context) error {
+		timing := domain.TimingFrom(c.Request().Context())
+		timing.AddCache(1500 * time.Microsecond)
+		timing.AddDB(4 * time.Millisecond)
+		timing.AddDB(2 * time.Millisecond)
+		return c.JSON(http.StatusOK, APIResponse{Success: true, Data: "driver"})
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/drivers/d1", nil)
+	req.Header.Set(middleware.DebugTimingHeader, "true")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	var body struct {
+		Meta ResponseMeta `json:"meta"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	require.NotNil(t, body.Meta.Timing)
+	assert.Equal(t, 1.5, body.Meta.Timing.CacheMs)
+	assert.Equal(t, 6.0, body.Meta.Timing.DBMs)
+	assert.GreaterOrEqual(t, body.Meta.Timing.TotalMs, 0.0)
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/drivers/d1", nil))
+	assert.JSONEq(t, `{"success":true,"data":"driver"}`, rec.Body.String())
+}
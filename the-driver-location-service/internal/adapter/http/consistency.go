@@ -0,0 +1,27 @@
+package http
+
+import (
+	"the-driver-location-service/internal/domain"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ConsistencyHeader lets a reader ask for strong consistency, e.g. the driver
+// app showing the position it has just sent
+const ConsistencyHeader = "X-Consistency"
+
+// ConsistencyHint carries the X-Consistency header of the request into its
+// context, reads of strong requests bypass the cache and go to the primary
+func ConsistencyHint() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			consistency := domain.ParseConsistency(c.Request().Header.Get(ConsistencyHeader))
+			if consistency == domain.ConsistencyStrong {
+				req := c.Request()
+				c.SetRequest(req.WithContext(domain.WithConsistency(req.Context(), consistency)))
+				c.Response().Header().Set(ConsistencyHeader, string(consistency))
+			}
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"the-driver-location-service/internal/domain"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestConsistencyHint tests the X-Consistency header of read routes
+// Expected: Should mark only strong requests and echo the header back for them
+func TestConsistencyHint(t *testing.T) {
+	e := echo.New()
+	e.GET("/read", func(c echo.Context) error {
+		if domain.IsStrongConsistency(c.Request().Context()) {
+			return c.String(http.StatusOK, "strong")
+		}
+		return c.String(http.StatusOK, "eventual")
+	}, ConsistencyHint())
+
+	tests := []struct {
+		header   string
+		expected string
+	}{
+		{"", "eventual"},
+		{"eventual", "eventual"},
+		{"strong", "strong"},
+		{" Strong ", "strong"},
+		{"linearizable", "eventual"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/read", nil)
+		if tt.header != "" {
+			req.Header.Set(ConsistencyHeader, tt.header)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		assert.Equal(t, tt.expected, rec.Body.String(), "header %q", tt.header)
+		if tt.expected == "strong" {
+			assert.Equal(t, "strong", rec.Header().Get(ConsistencyHeader))
+		} else {
+			assert.Empty(t, rec.Header().Get(ConsistencyHeader))
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"the-driver-location-service/internal/domain"
+	"the-driver-location-service/internal/ports/primary"
+)
+
+// DuplicateHandler serves the scan for drivers cloned by repeated CSV imports
+type DuplicateHandler struct {
+	duplicates primary.DuplicateService
+}
+
+func NewDuplicateHandler(duplicates primary.DuplicateService) *DuplicateHandler {
+	return &DuplicateHandler{
+		duplicates: duplicates,
+	}
+}
+
+// @Summary Find duplicate drivers
+// @Description Report drivers with identical coordinates created within a window of each other, merge or delete the clones when asked to
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body domain.DuplicateRequest false "Window, action and number of coordinates to scan"
+// @Success 200 {object} APIResponse{data=domain.DuplicateReport}
+// @Failure 400 {object} APIResponse
+// @Failure 500 {object} APIResponse
+// @Security X-API-KEY
+// @Router /admin/duplicates [post]
+func (h *DuplicateHandler) FindDuplicates(c echo.Context) error {
+	var req domain.DuplicateRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, APIResponse{
+			Success: false,
+			Error:   "invalid_request",
+			Message: "Invalid request body",
+		})
+	}
+
+	report, err := h.duplicates.FindDuplicates(c.Request().Context(), req)
+	if err != nil {
+		status, errorType := http.StatusInternalServerError, "internal_error"
+		if errors.Is(err, domain.ErrValidation) {
+			status, errorType = http.StatusBadRequest, "validation_error"
+		}
+		return c.JSON(status, APIResponse{
+			Success: false,
+			Error:   errorType,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Data:    report,
+		Message: "Duplicate drivers scanned successfully",
+	})
+}
@@ -0,0 +1,59 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+
+	"the-driver-location-service/internal/domain"
+)
+
+type stubDuplicateService struct {
+	report *domain.DuplicateReport
+	err    error
+}
+
+func (s *stubDuplicateService) FindDuplicates(ctx context.Context, req domain.DuplicateRequest) (*domain.DuplicateReport, error) {
+	return s.report, s.err
+}
+
+func serveDuplicates(service *stubDuplicateService, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/admin/duplicates", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	_ = NewDuplicateHandler(service).FindDuplicates(echo.New().NewContext(req, rec))
+	return rec
+}
+
+// TestFindDuplicates_Success tests a duplicate scan with a report
+// Expected: Should return 200 OK with the groups of clones
+func TestFindDuplicates_Success(t *testing.T) {
+	service := &stubDuplicateService{report: &domain.DuplicateReport{
+		Action:     domain.DuplicateActionReport,
+		Groups:     []domain.DuplicateGroup{{KeepID: "d1", DuplicateIDs: []string{"d2"}}},
+		Duplicates: 1,
+	}}
+
+	rec := serveDuplicates(service, `{"window_seconds":60}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"duplicate_ids":["d2"]`)
+}
+
+// TestFindDuplicates_Errors tests invalid bodies, invalid requests and failing scans
+// Expected: Should return 400 for bad input and 500 for other failures
+func TestFindDuplicates_Errors(t *testing.T) {
+	assert.Equal(t, http.StatusBadRequest, serveDuplicates(&stubDuplicateService{}, `{"action":`).Code)
+
+	invalid := &stubDuplicateService{err: fmt.Errorf("%w: unknown action", domain.ErrInvalidDuplicateRequest)}
+	assert.Equal(t, http.StatusBadRequest, serveDuplicates(invalid, `{"action":"purge"}`).Code)
+
+	failing := &stubDuplicateService{err: errors.New("mongo unavailable")}
+	assert.Equal(t, http.StatusInternalServerError, serveDuplicates(failing, `{}`).Code)
+}
@@ -0,0 +1,109 @@
+package http
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"the-driver-location-service/internal/domain"
+)
+
+// MIMEGeoJSON is the media type of GeoJSON, https://datatracker.ietf.org/doc/html/rfc7946#section-12
+const MIMEGeoJSON = "application/geo+json"
+
+// geoJSONFeature is a GeoJSON Feature, the location is the geometry and the
+// other fields of the result are its properties
+type geoJSONFeature struct {
+	Type       string                     `json:"type"`
+	ID         string                     `json:"id,omitempty"`
+	Geometry   domain.Point               `json:"geometry"`
+	Properties map[string]json.RawMessage `json:"properties"`
+}
+
+// wantsGeoJSON reports whether the Accept header of the request lists
+// application/geo+json, the other media types it lists are ignored
+func wantsGeoJSON(c echo.Context) bool {
+	for _, accepted := range strings.Split(c.Request().Header.Get(echo.HeaderAccept), ",") {
+		if mediaType, _, err := mime.ParseMediaType(accepted); err == nil && mediaType == MIMEGeoJSON {
+			return true
+		}
+	}
+	return false
+}
+
+// driverFeature returns the driver as a feature with its ID, its location as
+// the geometry and the rest of its fields as the properties
+func driverFeature(driver *domain.Driver) (geoJSONFeature, error) {
+	encoded, err := json.Marshal(driver)
+	if err != nil {
+		return geoJSONFeature{}, fmt.Errorf("failed to encode driver %s: %w", driver.ID, err)
+	}
+	var properties map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &properties); err != nil {
+		return geoJSONFeature{}, err
+	}
+	delete(properties, "id")
+	delete(properties, "location")
+	return geoJSONFeature{Type: "Feature", ID: driver.ID, Geometry: driver.Location, Properties: properties}, nil
+}
+
+// pointFeature returns a feature without ID, e.g. the center of a cluster
+func pointFeature(center domain.Point, properties map[string]any) (geoJSONFeature, error) {
+	feature := geoJSONFeature{Type: "Feature", Geometry: center, Properties: make(map[string]json.RawMessage, len(properties))}
+	for key, value := range properties {
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return geoJSONFeature{}, err
+		}
+		feature.Properties[key] = encoded
+	}
+	return feature, nil
+}
+
+func driversFeatures(drivers []*domain.Driver) func(i int) (geoJSONFeature, error) {
+	return func(i int) (geoJSONFeature, error) {
+		return driverFeature(drivers[i])
+	}
+}
+
+// geoJSONResponse writes a FeatureCollection of count features one at a time,
+// as streamDrivers does, so a large result is never held as a single document.
+// nextCursor is added as a foreign member for the pages of the driver list.
+func geoJSONResponse(c echo.Context, statusCode int, count int, feature func(i int) (geoJSONFeature, error), nextCursor string) error {
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, MIMEGeoJSON)
+	res.WriteHeader(statusCode)
+
+	w := bufio.NewWriterSize(res, streamBufferSize)
+	w.WriteString(`{"type":"FeatureCollection","features":[`)
+	for i := 0; i < count; i++ {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		f, err := feature(i)
+		if err != nil {
+			return err
+		}
+		encoded, err := json.Marshal(f)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(encoded); err != nil {
+			return err
+		}
+	}
+	w.WriteByte(']')
+	if nextCursor != "" {
+		encoded, err := json.Marshal(nextCursor)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, `,"next_cursor":%s`, encoded)
+	}
+	w.WriteString("}\n")
+	return w.Flush()
+}
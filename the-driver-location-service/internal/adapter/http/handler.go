@@ -2,28 +2,40 @@
 package http
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
 
+	"the-driver-location-service/internal/buildinfo"
 	"the-driver-location-service/internal/domain"
 	"the-driver-location-service/internal/ports/primary"
 )
 
 type DriverHandler struct {
-	driverService primary.DriverService
+	driverService   primary.DriverService
+	streamThreshold int
 }
 
 type APIResponse struct {
-	Success bool        `json:"success"`
-	Data    interface{} `json:"data,omitempty"`
-	Error   string      `json:"error,omitempty"`
-	Message string      `json:"message,omitempty"`
+	Success bool          `json:"success"`
+	Data    interface{}   `json:"data,omitempty"`
+	Error   string        `json:"error,omitempty"`
+	Message string        `json:"message,omitempty"`
+	Meta    *ResponseMeta `json:"meta,omitempty"`
+}
+
+// ResponseMeta describes how the response was produced, it is only sent when
+// asked for, e.g. the timing with the X-Debug-Timing header
+type ResponseMeta struct {
+	Timing *domain.TimingBreakdown `json:"timing,omitempty"`
 }
 
 func NewDriverHandler(driverService primary.DriverService) *DriverHandler {
 	return &DriverHandler{
-		driverService: driverService,
+		driverService:   driverService,
+		streamThreshold: DefaultStreamThreshold,
 	}
 }
 
@@ -35,6 +47,29 @@ func (h *DriverHandler) successResponse(c echo.Context, statusCode int, data int
 	})
 }
 
+// serviceError maps the error kinds of the driver service to statuses, errors
+// of no known kind are server faults
+func (h *DriverHandler) serviceError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, domain.ErrValidation):
+		return h.errorResponse(c, http.StatusBadRequest, "validation_error", err.Error())
+	case errors.Is(err, domain.ErrNotFound):
+		return h.errorResponse(c, http.StatusNotFound, "not_found", err.Error())
+	case errors.Is(err, domain.ErrConflict):
+		var duplicate *domain.DuplicateDriverError
+		if errors.As(err, &duplicate) {
+			return c.JSON(http.StatusConflict, APIResponse{
+				Success: false,
+				Data:    map[string]interface{}{"duplicate_ids": duplicate.IDs},
+				Error:   "conflict",
+				Message: err.Error(),
+			})
+		}
+		return h.errorResponse(c, http.StatusConflict, "conflict", err.Error())
+	}
+	return h.errorResponse(c, http.StatusInternalServerError, "internal_error", err.Error())
+}
+
 func (h *DriverHandler) errorResponse(c echo.Context, statusCode int, errorType string, message string) error {
 	return c.JSON(statusCode, APIResponse{
 		Success: false,
@@ -45,12 +80,13 @@ func (h *DriverHandler) errorResponse(c echo.Context, statusCode int, errorType
 
 // HealthCheck godoc
 // @Summary Health check endpoint
-// @Description Check if the service is healthy
+// @Description Check if the service is alive. The liveness probe does not check MongoDB or Redis, see /health/ready
 // @Tags health
 // @Accept json
 // @Produce json
 // @Success 200 {object} APIResponse
 // @Router /health [get]
+// @Router /health/live [get]
 func (h *DriverHandler) HealthCheck(c echo.Context) error {
 	data := map[string]interface{}{
 		"status":  "healthy",
@@ -59,14 +95,37 @@ func (h *DriverHandler) HealthCheck(c echo.Context) error {
 	return h.successResponse(c, http.StatusOK, data, "Service is healthy")
 }
 
+// Version godoc
+// @Summary Build information
+// @Description Version, git commit and build time of the running binary, set at build time with -ldflags
+// @Tags health
+// @Produce json
+// @Success 200 {object} APIResponse{data=buildinfo.Info}
+// @Router /version [get]
+func (h *DriverHandler) Version(c echo.Context) error {
+	return h.successResponse(c, http.StatusOK, buildinfo.Get(), "Build information")
+}
+
 // @Summary Create driver(s)
 // @Description Create one or multiple drivers in a single request. Supports both single driver and batch operations.
+// @Description A batch with a taken ID creates none of its drivers and answers 409 listing the taken IDs in data.duplicate_ids,
+// @Description with upsert=true the drivers whose IDs are taken are updated instead and the request answers 200.
+// @Description Large imports can pass response=ids to get the count and the IDs of the drivers instead of the drivers,
+// @Description batches above RESPONSE_STREAM_THRESHOLD drivers (1000 by default) are streamed.
+// @Description With ordered=false the drivers that cannot be created (taken IDs) are skipped instead of failing the batch,
+// @Description the others are created in any order and the request answers 207 listing the skipped ones in data.failed.
 // @Tags drivers
 // @Accept json
 // @Produce json
 // @Param drivers body []domain.CreateDriverRequest true "Driver(s) info - send array with single element for one driver, multiple elements for batch"
+// @Param upsert query bool false "Update the drivers whose IDs are taken instead of failing"
+// @Param response query string false "ids to answer with the count and the IDs of the drivers only" Enums(full, ids)
+// @Param ordered query bool false "false to create every driver that can be created instead of stopping at the first that cannot, true by default"
+// @Success 200 {object} APIResponse
 // @Success 201 {object} APIResponse
+// @Success 207 {object} APIResponse{data=CreateDriversPartialData}
 // @Failure 400 {object} APIResponse
+// @Failure 409 {object} APIResponse
 // @Failure 500 {object} APIResponse
 // @Security X-API-KEY
 // @Router /api/v1/drivers [post]
@@ -80,10 +139,41 @@ func (h *DriverHandler) CreateDrivers(c echo.Context) error {
 		return h.errorResponse(c, http.StatusBadRequest, "invalid_request", "At least one driver is required")
 	}
 
-	batchReq := domain.BatchCreateRequest{Drivers: req}
-	drivers, err := h.driverService.BatchCreateDrivers(batchReq)
+	var upsert bool
+	var response string
+	ordered := true
+	if err := echo.QueryParamsBinder(c).Bool("upsert", &upsert).String("response", &response).Bool("ordered", &ordered).BindError(); err != nil {
+		return h.errorResponse(c, http.StatusBadRequest, "invalid_request", "Invalid query parameters")
+	}
+	if response != "" && response != createResponseFull && response != createResponseIDs {
+		return h.errorResponse(c, http.StatusBadRequest, "invalid_request", "response must be full or ids")
+	}
+
+	batchReq := domain.BatchCreateRequest{Drivers: req, Upsert: upsert, Unordered: !ordered}
+	drivers, err := h.driverService.BatchCreateDrivers(c.Request().Context(), batchReq)
+	var partial *domain.PartialBatchError
+	if errors.As(err, &partial) {
+		return h.partialCreateResponse(c, drivers, partial, response)
+	}
 	if err != nil {
-		return h.errorResponse(c, http.StatusInternalServerError, "internal_error", err.Error())
+		return h.serviceError(c, err)
+	}
+
+	status, message := http.StatusCreated, "created"
+	if upsert {
+		status, message = http.StatusOK, "upserted"
+	}
+
+	if response == createResponseIDs {
+		ids := make([]string, len(drivers))
+		for i, driver := range drivers {
+			ids[i] = driver.ID
+		}
+		data := map[string]interface{}{
+			"ids":   ids,
+			"count": len(drivers),
+		}
+		return h.successResponse(c, status, data, "Drivers "+message+" successfully")
 	}
 
 	if len(drivers) == 1 {
@@ -91,22 +181,50 @@ func (h *DriverHandler) CreateDrivers(c echo.Context) error {
 			"driver": drivers[0],
 			"count":  1,
 		}
-		return h.successResponse(c, http.StatusCreated, data, "Driver created successfully")
+		return h.successResponse(c, status, data, "Driver "+message+" successfully")
 	}
 
-	data := map[string]interface{}{
-		"drivers": drivers,
-		"count":   len(drivers),
+	return h.driversResponse(c, status, drivers, "Drivers "+message+" successfully")
+}
+
+// CreateDriversPartialData is the data of an unordered batch that could not
+// create all of its drivers, Drivers is left out for response=ids
+type CreateDriversPartialData struct {
+	Count   int                         `json:"count"`
+	Drivers []*domain.Driver            `json:"drivers,omitempty"`
+	IDs     []string                    `json:"ids,omitempty"`
+	Failed  []domain.BatchCreateFailure `json:"failed"`
+}
+
+// partialCreateResponse answers 207 with the created drivers and the failed
+// ones. It is never streamed, large imports pass response=ids.
+func (h *DriverHandler) partialCreateResponse(c echo.Context, drivers []*domain.Driver, partial *domain.PartialBatchError, response string) error {
+	data := CreateDriversPartialData{Count: len(drivers), Failed: partial.Failed}
+	if response == createResponseIDs {
+		data.IDs = make([]string, len(drivers))
+		for i, driver := range drivers {
+			data.IDs[i] = driver.ID
+		}
+	} else {
+		data.Drivers = drivers
 	}
-	return h.successResponse(c, http.StatusCreated, data, "Drivers created successfully")
+	return h.successResponse(c, http.StatusMultiStatus, data, partial.Error())
 }
 
+// Responses of CreateDrivers, ids leaves the drivers out of the response of
+// large imports
+const (
+	createResponseFull = "full"
+	createResponseIDs  = "ids"
+)
+
 // @Summary Search nearby drivers
-// @Description Find drivers near a given location
+// @Description Find drivers near a given location, the radius is capped at SEARCH_MAX_RADIUS meters (50000 by default) and the limit at SEARCH_MAX_LIMIT drivers (1000 by default, SEARCH_DEFAULT_LIMIT or 10 when omitted)
 // @Tags drivers
 // @Accept json
-// @Produce json
+// @Produce json,application/geo+json
 // @Param search body domain.SearchRequest true "Search params"
+// @Param X-Consistency header string false "strong to bypass the cache and read from the primary"
 // @Success 200 {object} APIResponse
 // @Failure 400 {object} APIResponse
 // @Failure 500 {object} APIResponse
@@ -115,12 +233,21 @@ func (h *DriverHandler) CreateDrivers(c echo.Context) error {
 func (h *DriverHandler) SearchNearbyDrivers(c echo.Context) error {
 	var req domain.SearchRequest
 	if err := c.Bind(&req); err != nil {
-		return h.errorResponse(c, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return h.errorResponse(c, http.StatusBadRequest, "invalid_request", bindErrorMessage(err, "Invalid request body"))
 	}
 
-	drivers, err := h.driverService.SearchNearbyDrivers(req)
+	drivers, err := h.driverService.SearchNearbyDrivers(c.Request().Context(), req)
 	if err != nil {
-		return h.errorResponse(c, http.StatusInternalServerError, "internal_error", err.Error())
+		return h.serviceError(c, err)
+	}
+	if wantsGeoJSON(c) {
+		return geoJSONResponse(c, http.StatusOK, len(drivers), func(i int) (geoJSONFeature, error) {
+			feature, err := driverFeature(&drivers[i].Driver)
+			if err == nil {
+				feature.Properties["distance"], err = json.Marshal(drivers[i].Distance)
+			}
+			return feature, err
+		}, "")
 	}
 
 	data := map[string]interface{}{
@@ -130,11 +257,150 @@ func (h *DriverHandler) SearchNearbyDrivers(c echo.Context) error {
 	return h.successResponse(c, http.StatusOK, data, "Nearby drivers retrieved successfully")
 }
 
+// @Summary Search drivers within an area
+// @Description Find the available drivers inside a GeoJSON Polygon or MultiPolygon
+// @Tags drivers
+// @Accept json
+// @Produce json,application/geo+json
+// @Param search body domain.AreaSearchRequest true "Area and optional limit (100 by default, at most 1000)"
+// @Param X-Consistency header string false "strong to bypass the cache and read from the primary"
+// @Success 200 {object} APIResponse
+// @Failure 400 {object} APIResponse
+// @Failure 500 {object} APIResponse
+// @Security X-API-KEY
+// @Router /api/v1/drivers/search/within [post]
+func (h *DriverHandler) SearchDriversWithin(c echo.Context) error {
+	var req domain.AreaSearchRequest
+	if err := c.Bind(&req); err != nil {
+		return h.errorResponse(c, http.StatusBadRequest, "invalid_request", bindErrorMessage(err, "Invalid request body"))
+	}
+
+	drivers, err := h.driverService.SearchDriversWithin(c.Request().Context(), req)
+	if err != nil {
+		return h.serviceError(c, err)
+	}
+	if wantsGeoJSON(c) {
+		return geoJSONResponse(c, http.StatusOK, len(drivers), driversFeatures(drivers), "")
+	}
+
+	return h.driversResponse(c, http.StatusOK, drivers, "Drivers within area retrieved successfully")
+}
+
+// @Summary Search drivers in a bounding box
+// @Description Find the available drivers inside a longitude/latitude box for map views, or their counts per geohash cell with cluster
+// @Tags drivers
+// @Produce json,application/geo+json
+// @Param min_lon query number true "West edge of the box"
+// @Param min_lat query number true "South edge of the box"
+// @Param max_lon query number true "East edge of the box"
+// @Param max_lat query number true "North edge of the box"
+// @Param limit query int false "Maximum drivers or clusters (500 by default, at most 2000)"
+// @Param cluster query int false "Geohash precision (1-7) to group the drivers by, 0 returns the drivers"
+// @Param X-Consistency header string false "strong to bypass the cache and read from the primary"
+// @Success 200 {object} APIResponse{data=domain.BoxSearchResult}
+// @Failure 400 {object} APIResponse
+// @Failure 500 {object} APIResponse
+// @Security X-API-KEY
+// @Router /api/v1/drivers/search/box [get]
+func (h *DriverHandler) SearchDriversInBox(c echo.Context) error {
+	var req domain.BoxSearchRequest
+	if err := c.Bind(&req); err != nil {
+		return h.errorResponse(c, http.StatusBadRequest, "invalid_request", "Invalid query parameters")
+	}
+
+	result, err := h.driverService.SearchDriversInBox(c.Request().Context(), req)
+	if err != nil {
+		return h.serviceError(c, err)
+	}
+	if wantsGeoJSON(c) && req.Cluster > 0 {
+		// a cluster is a point feature at its center
+		return geoJSONResponse(c, http.StatusOK, len(result.Clusters), func(i int) (geoJSONFeature, error) {
+			cluster := result.Clusters[i]
+			return pointFeature(cluster.Center, map[string]any{"geohash": cluster.Geohash, "count": cluster.Count})
+		}, "")
+	}
+	if wantsGeoJSON(c) {
+		return geoJSONResponse(c, http.StatusOK, len(result.Drivers), driversFeatures(result.Drivers), "")
+	}
+
+	return h.successResponse(c, http.StatusOK, result, "Drivers in box retrieved successfully")
+}
+
+// @Summary Search drivers in an S2 cell
+// @Description Find the available drivers inside the S2 cell of a token, or their counts per descendant cell with count
+// @Tags drivers
+// @Produce json,application/geo+json
+// @Param token path string true "S2 cell token, e.g. 89c25a3"
+// @Param limit query int false "Maximum drivers or cells (500 by default, at most 2000)"
+// @Param count query bool false "Count the drivers per descendant cell instead of returning them"
+// @Param level query int false "Level of the counted cells (up to 20), S2_CELL_COUNT_LEVEL by default"
+// @Param X-Consistency header string false "strong to bypass the cache and read from the primary"
+// @Success 200 {object} APIResponse{data=domain.CellSearchResult}
+// @Failure 400 {object} APIResponse
+// @Failure 500 {object} APIResponse
+// @Security X-API-KEY
+// @Router /api/v1/drivers/cells/{token} [get]
+func (h *DriverHandler) SearchDriversInCell(c echo.Context) error {
+	var req domain.CellSearchRequest
+	if err := c.Bind(&req); err != nil {
+		return h.errorResponse(c, http.StatusBadRequest, "invalid_request", "Invalid query parameters")
+	}
+
+	result, err := h.driverService.SearchDriversInCell(c.Request().Context(), req)
+	if err != nil {
+		return h.serviceError(c, err)
+	}
+	if wantsGeoJSON(c) && req.Count {
+		// a counted cell is a point feature at its center
+		return geoJSONResponse(c, http.StatusOK, len(result.Counts), func(i int) (geoJSONFeature, error) {
+			count := result.Counts[i]
+			return pointFeature(count.Center, map[string]any{"cell": count.Cell, "level": count.Level, "count": count.Count})
+		}, "")
+	}
+	if wantsGeoJSON(c) {
+		return geoJSONResponse(c, http.StatusOK, len(result.Drivers), driversFeatures(result.Drivers), "")
+	}
+
+	return h.successResponse(c, http.StatusOK, result, "Drivers in cell retrieved successfully")
+}
+
+// @Summary List drivers
+// @Description Page through every driver ordered by ID, whatever its status, pass the next_cursor of a page to get the next one
+// @Tags drivers
+// @Produce json,application/geo+json
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param limit query int false "Drivers per page (100 by default, at most 1000)"
+// @Param status query string false "Only drivers with this status: available, busy or offline"
+// @Param updated_since query string false "Only drivers updated at or after this RFC3339 time"
+// @Param X-Consistency header string false "strong to read from the primary"
+// @Success 200 {object} APIResponse{data=domain.DriverPage}
+// @Failure 400 {object} APIResponse
+// @Failure 500 {object} APIResponse
+// @Security X-API-KEY
+// @Router /api/v1/drivers [get]
+func (h *DriverHandler) ListDrivers(c echo.Context) error {
+	var req domain.ListDriversRequest
+	if err := c.Bind(&req); err != nil {
+		return h.errorResponse(c, http.StatusBadRequest, "invalid_request", "Invalid query parameters")
+	}
+
+	page, err := h.driverService.ListDrivers(c.Request().Context(), req)
+	if err != nil {
+		return h.serviceError(c, err)
+	}
+	if wantsGeoJSON(c) {
+		return geoJSONResponse(c, http.StatusOK, len(page.Drivers), driversFeatures(page.Drivers), page.NextCursor)
+	}
+
+	return h.successResponse(c, http.StatusOK, page, "Drivers retrieved successfully")
+}
+
 // @Summary Get driver by ID
 // @Description Get a driver by its ID
 // @Tags drivers
 // @Produce json
 // @Param id path string true "Driver ID"
+// @Param X-Consistency header string false "strong to bypass the cache and read from the primary"
 // @Success 200 {object} APIResponse
 // @Failure 400 {object} APIResponse
 // @Failure 404 {object} APIResponse
@@ -146,7 +412,7 @@ func (h *DriverHandler) GetDriver(c echo.Context) error {
 		return h.errorResponse(c, http.StatusBadRequest, "invalid_request", "Driver ID is required")
 	}
 
-	driver, err := h.driverService.GetDriver(id)
+	driver, err := h.driverService.GetDriver(c.Request().Context(), id)
 	if err != nil {
 		return h.errorResponse(c, http.StatusNotFound, "not_found", "Driver not found")
 	}
@@ -163,6 +429,7 @@ func (h *DriverHandler) GetDriver(c echo.Context) error {
 // @Param driver body domain.Driver true "Driver info"
 // @Success 200 {object} APIResponse
 // @Failure 400 {object} APIResponse
+// @Failure 404 {object} APIResponse
 // @Failure 500 {object} APIResponse
 // @Security X-API-KEY
 // @Router /api/v1/drivers/{id} [put]
@@ -179,8 +446,8 @@ func (h *DriverHandler) UpdateDriver(c echo.Context) error {
 
 	driver.ID = id
 
-	if err := h.driverService.UpdateDriver(&driver); err != nil {
-		return h.errorResponse(c, http.StatusInternalServerError, "internal_error", err.Error())
+	if err := h.driverService.UpdateDriver(c.Request().Context(), &driver); err != nil {
+		return h.serviceError(c, err)
 	}
 
 	return h.successResponse(c, http.StatusOK, driver, "Driver updated successfully")
@@ -192,9 +459,10 @@ func (h *DriverHandler) UpdateDriver(c echo.Context) error {
 // @Accept json
 // @Produce json
 // @Param id path string true "Driver ID"
-// @Param location body domain.Point true "New location"
+// @Param location body domain.LocationUpdate true "New location with optional speed (m/s) and heading (degrees)"
 // @Success 200 {object} APIResponse
 // @Failure 400 {object} APIResponse
+// @Failure 404 {object} APIResponse
 // @Failure 500 {object} APIResponse
 // @Security X-API-KEY
 // @Router /api/v1/drivers/{id}/location [patch]
@@ -204,18 +472,74 @@ func (h *DriverHandler) UpdateDriverLocation(c echo.Context) error {
 		return h.errorResponse(c, http.StatusBadRequest, "invalid_request", "Driver ID is required")
 	}
 
-	var location domain.Point
-	if err := c.Bind(&location); err != nil {
+	var update domain.LocationUpdate
+	if err := c.Bind(&update); err != nil {
 		return h.errorResponse(c, http.StatusBadRequest, "invalid_request", "Invalid request body")
 	}
 
-	if err := h.driverService.UpdateDriverLocation(id, location); err != nil {
-		return h.errorResponse(c, http.StatusInternalServerError, "internal_error", err.Error())
+	if err := h.driverService.UpdateDriverLocation(c.Request().Context(), id, update); err != nil {
+		return h.serviceError(c, err)
 	}
 
 	return h.successResponse(c, http.StatusOK, nil, "Driver location updated successfully")
 }
 
+// @Summary Update driver status
+// @Description Set a driver's availability, only available drivers are returned by nearby searches
+// @Tags drivers
+// @Accept json
+// @Produce json
+// @Param id path string true "Driver ID"
+// @Param status body domain.UpdateStatusRequest true "New status: available, busy or offline"
+// @Success 200 {object} APIResponse
+// @Failure 400 {object} APIResponse
+// @Failure 404 {object} APIResponse
+// @Failure 500 {object} APIResponse
+// @Security X-API-KEY
+// @Router /api/v1/drivers/{id}/status [patch]
+func (h *DriverHandler) UpdateDriverStatus(c echo.Context) error {
+	id := c.Param("id")
+	if id == "" {
+		return h.errorResponse(c, http.StatusBadRequest, "invalid_request", "Driver ID is required")
+	}
+
+	var req domain.UpdateStatusRequest
+	if err := c.Bind(&req); err != nil {
+		return h.errorResponse(c, http.StatusBadRequest, "invalid_request", "Invalid request body")
+	}
+
+	if err := h.driverService.UpdateDriverStatus(c.Request().Context(), id, req.Status); err != nil {
+		return h.serviceError(c, err)
+	}
+
+	return h.successResponse(c, http.StatusOK, nil, "Driver status updated successfully")
+}
+
+// @Summary Record driver heartbeat
+// @Description Keep a driver searchable without a location update, drivers that sent a heartbeat are left out of nearby searches once none arrived within HEARTBEAT_TIMEOUT
+// @Tags drivers
+// @Produce json
+// @Param id path string true "Driver ID"
+// @Success 200 {object} APIResponse{data=domain.Heartbeat}
+// @Failure 400 {object} APIResponse
+// @Failure 404 {object} APIResponse
+// @Failure 500 {object} APIResponse
+// @Security X-API-KEY
+// @Router /api/v1/drivers/{id}/heartbeat [post]
+func (h *DriverHandler) Heartbeat(c echo.Context) error {
+	id := c.Param("id")
+	if id == "" {
+		return h.errorResponse(c, http.StatusBadRequest, "invalid_request", "Driver ID is required")
+	}
+
+	heartbeat, err := h.driverService.Heartbeat(c.Request().Context(), id)
+	if err != nil {
+		return h.serviceError(c, err)
+	}
+
+	return h.successResponse(c, http.StatusOK, heartbeat, "Heartbeat recorded successfully")
+}
+
 // @Summary Delete driver by ID
 // @Description Delete a driver by its ID
 // @Tags drivers
@@ -223,6 +547,7 @@ func (h *DriverHandler) UpdateDriverLocation(c echo.Context) error {
 // @Param id path string true "Driver ID"
 // @Success 200 {object} APIResponse
 // @Failure 400 {object} APIResponse
+// @Failure 404 {object} APIResponse
 // @Failure 500 {object} APIResponse
 // @Security X-API-KEY
 // @Router /api/v1/drivers/{id} [delete]
@@ -232,8 +557,8 @@ func (h *DriverHandler) DeleteDriver(c echo.Context) error {
 		return h.errorResponse(c, http.StatusBadRequest, "invalid_request", "Driver ID is required")
 	}
 
-	if err := h.driverService.DeleteDriver(id); err != nil {
-		return h.errorResponse(c, http.StatusInternalServerError, "internal_error", err.Error())
+	if err := h.driverService.DeleteDriver(c.Request().Context(), id); err != nil {
+		return h.serviceError(c, err)
 	}
 
 	return h.successResponse(c, http.StatusOK, nil, "Driver deleted successfully")
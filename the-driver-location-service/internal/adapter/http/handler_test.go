@@ -1,50 +1,87 @@
 package http
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"the-driver-location-service/internal/domain"
 
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 type MockDriverService struct{ mock.Mock }
 
-func (m *MockDriverService) CreateDriver(req domain.CreateDriverRequest) (*domain.Driver, error) {
+func (m *MockDriverService) CreateDriver(_ context.Context, req domain.CreateDriverRequest) (*domain.Driver, error) {
 	args := m.Called(req)
 	return args.Get(0).(*domain.Driver), args.Error(1)
 }
-func (m *MockDriverService) BatchCreateDrivers(req domain.BatchCreateRequest) ([]*domain.Driver, error) {
+func (m *MockDriverService) BatchCreateDrivers(_ context.Context, req domain.BatchCreateRequest) ([]*domain.Driver, error) {
 	args := m.Called(req)
 	return args.Get(0).([]*domain.Driver), args.Error(1)
 }
-func (m *MockDriverService) SearchNearbyDrivers(req domain.SearchRequest) ([]*domain.DriverWithDistance, error) {
+func (m *MockDriverService) SearchNearbyDrivers(_ context.Context, req domain.SearchRequest) ([]*domain.DriverWithDistance, error) {
 	args := m.Called(req)
 	return args.Get(0).([]*domain.DriverWithDistance), args.Error(1)
 }
-func (m *MockDriverService) GetDriver(id string) (*domain.Driver, error) {
+func (m *MockDriverService) SearchDriversWithin(_ context.Context, req domain.AreaSearchRequest) ([]*domain.Driver, error) {
+	args := m.Called(req)
+	return args.Get(0).([]*domain.Driver), args.Error(1)
+}
+func (m *MockDriverService) SearchDriversInBox(_ context.Context, req domain.BoxSearchRequest) (*domain.BoxSearchResult, error) {
+	args := m.Called(req)
+	return args.Get(0).(*domain.BoxSearchResult), args.Error(1)
+}
+func (m *MockDriverService) SearchDriversInCell(_ context.Context, req domain.CellSearchRequest) (*domain.CellSearchResult, error) {
+	args := m.Called(req)
+	return args.Get(0).(*domain.CellSearchResult), args.Error(1)
+}
+func (m *MockDriverService) GetDriver(_ context.Context, id string) (*domain.Driver, error) {
 	args := m.Called(id)
 	return args.Get(0).(*domain.Driver), args.Error(1)
 }
-func (m *MockDriverService) UpdateDriver(driver *domain.Driver) error {
+func (m *MockDriverService) ListDrivers(_ context.Context, req domain.ListDriversRequest) (*domain.DriverPage, error) {
+	args := m.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.DriverPage), args.Error(1)
+}
+func (m *MockDriverService) UpdateDriver(_ context.Context, driver *domain.Driver) error {
 	args := m.Called(driver)
 	return args.Error(0)
 }
-func (m *MockDriverService) UpdateDriverLocation(id string, location domain.Point) error {
-	args := m.Called(id, location)
+func (m *MockDriverService) UpdateDriverLocation(_ context.Context, id string, update domain.LocationUpdate) error {
+	args := m.Called(id, update)
 	return args.Error(0)
 }
-func (m *MockDriverService) DeleteDriver(id string) error {
+
+func (m *MockDriverService) UpdateDriverStatus(_ context.Context, id string, status string) error {
+	args := m.Called(id, status)
+	return args.Error(0)
+}
+func (m *MockDriverService) DeleteDriver(_ context.Context, id string) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
 
+func (m *MockDriverService) Heartbeat(_ context.Context, id string) (*domain.Heartbeat, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Heartbeat), args.Error(1)
+}
+
 // TestCreateDrivers_SingleDriver_Success tests single driver creation.
 // Expected: Should create a single driver and return correct response.
 func TestCreateDrivers_SingleDriver_Success(t *testing.T) {
@@ -291,7 +328,7 @@ func TestUpdateDriverLocation_Success(t *testing.T) {
 	c := e.NewContext(req, rec)
 	c.SetParamNames("id")
 	c.SetParamValues("d1")
-	mockService.On("UpdateDriverLocation", "d1", domain.NewPoint(29, 41)).Return(nil)
+	mockService.On("UpdateDriverLocation", "d1", domain.LocationUpdate{Point: domain.NewPoint(29, 41)}).Return(nil)
 
 	err := handler.UpdateDriverLocation(c)
 	assert.NoError(t, err)
@@ -320,8 +357,49 @@ func TestDeleteDriver_Success(t *testing.T) {
 	mockService.AssertExpectations(t)
 }
 
+// TestHeartbeat_Success tests recording a driver heartbeat
+// Expected: Should return 200 OK with the driver ID and the time it was last seen
+func TestHeartbeat_Success(t *testing.T) {
+	mockService := new(MockDriverService)
+	handler := NewDriverHandler(mockService)
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/drivers/d1/heartbeat", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues("d1")
+	seenAt := time.Date(2026, 10, 15, 9, 30, 0, 0, time.UTC)
+	mockService.On("Heartbeat", "d1").Return(&domain.Heartbeat{DriverID: "d1", LastSeenAt: seenAt}, nil)
+
+	err := handler.Heartbeat(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"driver_id":"d1"`)
+	assert.Contains(t, rec.Body.String(), `"last_seen_at":"2026-10-15T09:30:00Z"`)
+	mockService.AssertExpectations(t)
+}
+
+// TestHeartbeat_NotFound tests a heartbeat of an unknown driver
+// Expected: Should return 404 Not Found
+func TestHeartbeat_NotFound(t *testing.T) {
+	mockService := new(MockDriverService)
+	handler := NewDriverHandler(mockService)
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/drivers/missing/heartbeat", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues("missing")
+	mockService.On("Heartbeat", "missing").Return(nil, fmt.Errorf("failed to record heartbeat: %w", domain.ErrDriverNotFound))
+
+	err := handler.Heartbeat(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	mockService.AssertExpectations(t)
+}
+
 // TestSearchNearbyDrivers_ValidationError tests validation error in search
-// Expected: Should return 500 when search validation fails
+// Expected: Should return 400 Bad Request when search validation fails
 func TestSearchNearbyDrivers_ValidationError(t *testing.T) {
 	mockService := new(MockDriverService)
 	handler := NewDriverHandler(mockService)
@@ -332,13 +410,57 @@ func TestSearchNearbyDrivers_ValidationError(t *testing.T) {
 	rec := httptest.NewRecorder()
 	c := e.NewContext(req, rec)
 
-	validationErr := errors.New("validation error: invalid coordinates")
+	validationErr := fmt.Errorf("%w: invalid request: invalid coordinates", domain.ErrValidation)
 	mockService.On("SearchNearbyDrivers", mock.Anything).Return(([]*domain.DriverWithDistance)(nil), validationErr)
 
 	err := handler.SearchNearbyDrivers(c)
 	assert.NoError(t, err)
-	assert.Equal(t, http.StatusInternalServerError, rec.Code)
-	assert.Contains(t, rec.Body.String(), "internal_error")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "validation_error")
+	mockService.AssertExpectations(t)
+}
+
+// TestUpdateDriverLocation_WithSpeedAndHeading tests location update with speed and heading.
+// Expected: Should bind speed and heading next to the GeoJSON point.
+func TestUpdateDriverLocation_WithSpeedAndHeading(t *testing.T) {
+	mockService := new(MockDriverService)
+	handler := NewDriverHandler(mockService)
+	e := echo.New()
+	body := `{"type":"Point","coordinates":[29,41],"speed":8.5,"heading":90}`
+	req := httptest.NewRequest(http.MethodPatch, "/api/v1/drivers/d1/location", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues("d1")
+	mockService.On("UpdateDriverLocation", "d1", mock.MatchedBy(func(update domain.LocationUpdate) bool {
+		return update.Longitude() == 29 && update.Speed != nil && *update.Speed == 8.5 && update.Heading != nil && *update.Heading == 90
+	})).Return(nil)
+
+	err := handler.UpdateDriverLocation(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	mockService.AssertExpectations(t)
+}
+
+// TestUpdateDriverStatus_Success tests successful driver status update.
+// Expected: Should pass the status to the service and return 200.
+func TestUpdateDriverStatus_Success(t *testing.T) {
+	mockService := new(MockDriverService)
+	handler := NewDriverHandler(mockService)
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPatch, "/api/v1/drivers/d1/status", strings.NewReader(`{"status":"busy"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues("d1")
+	mockService.On("UpdateDriverStatus", "d1", domain.DriverStatusBusy).Return(nil)
+
+	err := handler.UpdateDriverStatus(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "status updated successfully")
 	mockService.AssertExpectations(t)
 }
 
@@ -383,6 +505,26 @@ func TestSearchNearbyDrivers_InvalidJSON(t *testing.T) {
 	assert.Contains(t, rec.Body.String(), "Invalid request body")
 }
 
+// TestSearchNearbyDrivers_UnknownField tests search with a misspelled field
+// Expected: Should return 400 Bad Request naming the unknown field instead of searching without a radius
+func TestSearchNearbyDrivers_UnknownField(t *testing.T) {
+	mockService := new(MockDriverService)
+	handler := NewDriverHandler(mockService)
+	e := echo.New()
+	e.JSONSerializer = JSONCodec{}
+	e.POST("/api/v1/drivers/search", handler.SearchNearbyDrivers, StrictJSON())
+
+	body := `{"location":{"type":"Point","coordinates":[29,41]},"raduis":1000}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/drivers/search", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), `unknown field \"raduis\"`)
+	mockService.AssertNotCalled(t, "SearchNearbyDrivers", mock.Anything, mock.Anything)
+}
+
 // TestSearchNearbyDrivers_EmptyResults tests search with no results
 // Expected: Should return 200 OK with empty array when no drivers found
 func TestSearchNearbyDrivers_EmptyResults(t *testing.T) {
@@ -486,3 +628,506 @@ func TestUpdateDriverLocation_InvalidJSON(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, rec.Code)
 	assert.Contains(t, rec.Body.String(), "Invalid request body")
 }
+
+// TestDriverWrites_NotFound tests updating and deleting a driver that does not exist
+// Expected: Should return 404 Not Found instead of 500 for every write endpoint
+func TestDriverWrites_NotFound(t *testing.T) {
+	notFound := fmt.Errorf("failed to update driver: %w", fmt.Errorf("%w: d1", domain.ErrDriverNotFound))
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		setup  func(m *MockDriverService)
+		call   func(h *DriverHandler, c echo.Context) error
+	}{
+		{
+			name:   "update",
+			method: http.MethodPut,
+			path:   "/api/v1/drivers/d1",
+			body:   `{"location":{"type":"Point","coordinates":[29,41]}}`,
+			setup:  func(m *MockDriverService) { m.On("UpdateDriver", mock.Anything).Return(notFound) },
+			call:   (*DriverHandler).UpdateDriver,
+		},
+		{
+			name:   "location",
+			method: http.MethodPatch,
+			path:   "/api/v1/drivers/d1/location",
+			body:   `{"type":"Point","coordinates":[29,41]}`,
+			setup:  func(m *MockDriverService) { m.On("UpdateDriverLocation", "d1", mock.Anything).Return(notFound) },
+			call:   (*DriverHandler).UpdateDriverLocation,
+		},
+		{
+			name:   "status",
+			method: http.MethodPatch,
+			path:   "/api/v1/drivers/d1/status",
+			body:   `{"status":"busy"}`,
+			setup:  func(m *MockDriverService) { m.On("UpdateDriverStatus", "d1", "busy").Return(notFound) },
+			call:   (*DriverHandler).UpdateDriverStatus,
+		},
+		{
+			name:   "delete",
+			method: http.MethodDelete,
+			path:   "/api/v1/drivers/d1",
+			setup:  func(m *MockDriverService) { m.On("DeleteDriver", "d1").Return(notFound) },
+			call:   (*DriverHandler).DeleteDriver,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockDriverService)
+			handler := NewDriverHandler(mockService)
+			e := echo.New()
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues("d1")
+			tt.setup(mockService)
+
+			err := tt.call(handler, c)
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+			assert.Contains(t, rec.Body.String(), `"error":"not_found"`)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+// TestDeleteDriver_ServiceError tests a failing delete of an existing driver
+// Expected: Should keep returning 500 for errors other than a missing driver
+func TestDeleteDriver_ServiceError(t *testing.T) {
+	mockService := new(MockDriverService)
+	handler := NewDriverHandler(mockService)
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/drivers/d1", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues("d1")
+	mockService.On("DeleteDriver", "d1").Return(errors.New("failed to delete driver: connection reset"))
+
+	err := handler.DeleteDriver(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	mockService.AssertExpectations(t)
+}
+
+// TestCreateDrivers_Conflict tests creating a driver with an ID that is taken
+// Expected: Should return 409 Conflict instead of 500
+func TestCreateDrivers_Conflict(t *testing.T) {
+	mockService := new(MockDriverService)
+	handler := NewDriverHandler(mockService)
+	e := echo.New()
+	body := `[{"id":"d1","location":{"type":"Point","coordinates":[29,41]}}]`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/drivers", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	conflict := fmt.Errorf("failed to batch create drivers: %w", fmt.Errorf("%w: driver d1 already exists", domain.ErrConflict))
+	mockService.On("BatchCreateDrivers", mock.Anything).Return(([]*domain.Driver)(nil), conflict)
+
+	err := handler.CreateDrivers(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"error":"conflict"`)
+	mockService.AssertExpectations(t)
+}
+
+// TestCreateDrivers_Duplicates tests creating drivers whose IDs are taken
+// Expected: Should return 409 Conflict listing the taken IDs
+func TestCreateDrivers_Duplicates(t *testing.T) {
+	mockService := new(MockDriverService)
+	handler := NewDriverHandler(mockService)
+	e := echo.New()
+	body := `[{"id":"d1","location":{"type":"Point","coordinates":[29,41]}},{"id":"d2","location":{"type":"Point","coordinates":[29,41]}}]`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/drivers", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	conflict := fmt.Errorf("failed to batch create drivers: %w", &domain.DuplicateDriverError{IDs: []string{"d1", "d2"}})
+	mockService.On("BatchCreateDrivers", mock.Anything).Return(([]*domain.Driver)(nil), conflict)
+
+	err := handler.CreateDrivers(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.JSONEq(t, `{"success":false,"data":{"duplicate_ids":["d1","d2"]},"error":"conflict","message":"failed to batch create drivers: conflict: drivers d1, d2 already exist"}`, rec.Body.String())
+	mockService.AssertExpectations(t)
+}
+
+// TestCreateDrivers_Upsert tests creating drivers with upsert=true
+// Expected: Should ask the service to upsert and return 200 OK, an invalid flag returns 400
+func TestCreateDrivers_Upsert(t *testing.T) {
+	mockService := new(MockDriverService)
+	handler := NewDriverHandler(mockService)
+	e := echo.New()
+	body := `[{"id":"d1","location":{"type":"Point","coordinates":[29,41]}}]`
+	mockService.On("BatchCreateDrivers", mock.MatchedBy(func(req domain.BatchCreateRequest) bool { return req.Upsert })).
+		Return([]*domain.Driver{{ID: "d1", Location: domain.NewPoint(29, 41)}}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/drivers?upsert=true", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	assert.NoError(t, handler.CreateDrivers(e.NewContext(req, rec)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Driver upserted successfully")
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/drivers?upsert=maybe", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec = httptest.NewRecorder()
+	assert.NoError(t, handler.CreateDrivers(e.NewContext(req, rec)))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	mockService.AssertNumberOfCalls(t, "BatchCreateDrivers", 1)
+}
+
+// TestCreateDrivers_Unordered tests creating drivers with ordered=false
+// Expected: Should ask the service for an unordered batch and answer 207 with the created drivers and the failed ones
+func TestCreateDrivers_Unordered(t *testing.T) {
+	mockService := new(MockDriverService)
+	handler := NewDriverHandler(mockService)
+	e := echo.New()
+	body := `[{"id":"d1","location":{"type":"Point","coordinates":[29,41]}}, {"id":"d2","location":{"type":"Point","coordinates":[30,42]}}]`
+	mockService.On("BatchCreateDrivers", mock.MatchedBy(func(req domain.BatchCreateRequest) bool { return req.Unordered })).
+		Return([]*domain.Driver{{ID: "d2", Location: domain.NewPoint(30, 42)}}, &domain.PartialBatchError{
+			Failed: []domain.BatchCreateFailure{{Index: 0, ID: "d1", Reason: domain.BatchFailureDuplicateID}},
+		})
+	mockService.On("BatchCreateDrivers", mock.MatchedBy(func(req domain.BatchCreateRequest) bool { return !req.Unordered })).
+		Return([]*domain.Driver{{ID: "d1"}, {ID: "d2"}}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/drivers?ordered=false&response=ids", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	assert.NoError(t, handler.CreateDrivers(e.NewContext(req, rec)))
+	assert.Equal(t, http.StatusMultiStatus, rec.Code)
+	assert.JSONEq(t, `{"success":true,"data":{"count":1,"ids":["d2"],"failed":[{"index":0,"id":"d1","reason":"duplicate_id"}]},"message":"1 driver of the batch was not created"}`, rec.Body.String())
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/drivers?ordered=true", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec = httptest.NewRecorder()
+	assert.NoError(t, handler.CreateDrivers(e.NewContext(req, rec)))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	mockService.AssertExpectations(t)
+}
+
+// TestCreateDrivers_ResponseIDs tests creating drivers with response=ids
+// Expected: Should answer with the count and the IDs of the drivers only, an unknown response returns 400
+func TestCreateDrivers_ResponseIDs(t *testing.T) {
+	mockService := new(MockDriverService)
+	handler := NewDriverHandler(mockService)
+	e := echo.New()
+	body := `[{"id":"d1","location":{"type":"Point","coordinates":[29,41]}}, {"id":"d2","location":{"type":"Point","coordinates":[30,42]}}]`
+	mockService.On("BatchCreateDrivers", mock.Anything).
+		Return([]*domain.Driver{{ID: "d1", Location: domain.NewPoint(29, 41)}, {ID: "d2", Location: domain.NewPoint(30, 42)}}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/drivers?response=ids", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	assert.NoError(t, handler.CreateDrivers(e.NewContext(req, rec)))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.JSONEq(t, `{"success":true,"data":{"count":2,"ids":["d1","d2"]},"message":"Drivers created successfully"}`, rec.Body.String())
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/drivers?response=none", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec = httptest.NewRecorder()
+	assert.NoError(t, handler.CreateDrivers(e.NewContext(req, rec)))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	mockService.AssertNumberOfCalls(t, "BatchCreateDrivers", 1)
+}
+
+// TestCreateDrivers_Streamed tests a batch above the stream threshold
+// Expected: Should stream the same status, headers and body the batch gets below the threshold
+func TestCreateDrivers_Streamed(t *testing.T) {
+	updatedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	drivers := make([]*domain.Driver, 5)
+	for i := range drivers {
+		drivers[i] = &domain.Driver{
+			ID:        fmt.Sprintf("d%d", i),
+			Location:  domain.NewPoint(29+float64(i)/10, 41),
+			Status:    "available",
+			CreatedAt: updatedAt,
+			UpdatedAt: updatedAt,
+		}
+	}
+	drivers[4].ID = "<d4 & \"quoted\">"
+	mockService := new(MockDriverService)
+	mockService.On("BatchCreateDrivers", mock.Anything).Return(drivers, nil)
+
+	create := func(threshold int) *httptest.ResponseRecorder {
+		handler := NewDriverHandler(mockService)
+		handler.SetStreamThreshold(threshold)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/drivers", strings.NewReader(`[{"id":"d0","location":{"type":"Point","coordinates":[29,41]}}]`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		assert.NoError(t, handler.CreateDrivers(echo.New().NewContext(req, rec)))
+		return rec
+	}
+	encoded := create(len(drivers))
+	streamed := create(len(drivers) - 1)
+
+	assert.Equal(t, http.StatusCreated, streamed.Code)
+	assert.Equal(t, encoded.Header().Get(echo.HeaderContentType), streamed.Header().Get(echo.HeaderContentType))
+	assert.Equal(t, encoded.Body.String(), streamed.Body.String())
+}
+
+// TestUpdateDriverStatus_ValidationError tests an unknown status rejected by the service
+// Expected: Should return 400 Bad Request with validation_error
+func TestUpdateDriverStatus_ValidationError(t *testing.T) {
+	mockService := new(MockDriverService)
+	handler := NewDriverHandler(mockService)
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPatch, "/api/v1/drivers/d1/status", strings.NewReader(`{"status":"sleeping"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues("d1")
+	mockService.On("UpdateDriverStatus", "d1", "sleeping").Return(fmt.Errorf("%w: invalid status", domain.ErrValidation))
+
+	err := handler.UpdateDriverStatus(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"error":"validation_error"`)
+	mockService.AssertExpectations(t)
+}
+
+// TestSearchDriversWithin_Success tests searching drivers inside a polygon
+// Expected: Should bind the GeoJSON area and return the drivers with their count
+func TestSearchDriversWithin_Success(t *testing.T) {
+	mockService := new(MockDriverService)
+	handler := NewDriverHandler(mockService)
+	e := echo.New()
+	body := `{"area":{"type":"Polygon","coordinates":[[[29,41],[29.1,41],[29.1,41.1],[29,41.1],[29,41]]]},"limit":20}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/drivers/search/within", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	mockService.On("SearchDriversWithin", mock.MatchedBy(func(r domain.AreaSearchRequest) bool {
+		return r.Area.Type == "Polygon" && r.Limit == 20 && len(r.Area.Coordinates) > 0
+	})).Return([]*domain.Driver{{ID: "d1", Location: domain.NewPoint(29.05, 41.05)}}, nil)
+
+	err := handler.SearchDriversWithin(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"count":1`)
+	assert.Contains(t, rec.Body.String(), "d1")
+	mockService.AssertExpectations(t)
+}
+
+// TestSearchDriversWithin_InvalidArea tests an area the service rejects
+// Expected: Should return 400 Bad Request with validation_error
+func TestSearchDriversWithin_InvalidArea(t *testing.T) {
+	mockService := new(MockDriverService)
+	handler := NewDriverHandler(mockService)
+	e := echo.New()
+	body := `{"area":{"type":"Polygon","coordinates":[[[29,41],[29.1,41],[29,41]]]}}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/drivers/search/within", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	mockService.On("SearchDriversWithin", mock.Anything).Return(([]*domain.Driver)(nil), fmt.Errorf("%w: invalid area: ring 0 of polygon 0 needs at least 4 positions", domain.ErrValidation))
+
+	err := handler.SearchDriversWithin(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "validation_error")
+	mockService.AssertExpectations(t)
+}
+
+// TestSearchDriversInBox_Success tests a map view query with a bounding box
+// Expected: Should bind the query parameters and return the drivers of the box
+func TestSearchDriversInBox_Success(t *testing.T) {
+	mockService := new(MockDriverService)
+	handler := NewDriverHandler(mockService)
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/drivers/search/box?min_lon=28.9&min_lat=40.9&max_lon=29.1&max_lat=41.1&cluster=5", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	expected := domain.BoxSearchRequest{MinLongitude: 28.9, MinLatitude: 40.9, MaxLongitude: 29.1, MaxLatitude: 41.1, Cluster: 5}
+	mockService.On("SearchDriversInBox", expected).Return(&domain.BoxSearchResult{
+		Clusters: []domain.DriverCluster{{Geohash: "sxk9w", Count: 3, Center: domain.NewPoint(29, 41)}},
+		Count:    1,
+	}, nil)
+
+	err := handler.SearchDriversInBox(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"geohash":"sxk9w"`)
+	mockService.AssertExpectations(t)
+}
+
+// TestListDrivers_Success tests listing drivers with a cursor and filters
+// Expected: Should bind the query parameters and return the page with its next cursor
+func TestListDrivers_Success(t *testing.T) {
+	mockService := new(MockDriverService)
+	handler := NewDriverHandler(mockService)
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/drivers?cursor=ZDE&limit=2&status=busy&updated_since=2026-10-15T00:00:00Z", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	expected := domain.ListDriversRequest{Cursor: "ZDE", Limit: 2, Status: "busy", UpdatedSince: "2026-10-15T00:00:00Z"}
+	mockService.On("ListDrivers", expected).Return(&domain.DriverPage{
+		Drivers:    []*domain.Driver{{ID: "d2"}, {ID: "d3"}},
+		Count:      2,
+		NextCursor: "ZDM",
+	}, nil)
+
+	err := handler.ListDrivers(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"next_cursor":"ZDM"`)
+	mockService.AssertExpectations(t)
+}
+
+// TestSearchNearbyDrivers_GeoJSON tests a nearby search asking for GeoJSON
+// Expected: Should answer with a FeatureCollection of the drivers, their location as the geometry and the distance in the properties
+func TestSearchNearbyDrivers_GeoJSON(t *testing.T) {
+	mockService := new(MockDriverService)
+	handler := NewDriverHandler(mockService)
+	e := echo.New()
+	body := `{"location":{"type":"Point","coordinates":[29,41]},"radius":1000}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/drivers/search", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(echo.HeaderAccept, "application/json;q=0.5, application/geo+json")
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	drivers := []*domain.DriverWithDistance{
+		{Driver: domain.Driver{ID: "d1", Location: domain.NewPoint(29.001, 41), Status: domain.DriverStatusAvailable}, Distance: 84},
+	}
+	mockService.On("SearchNearbyDrivers", mock.Anything).Return(drivers, nil)
+
+	err := handler.SearchNearbyDrivers(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, MIMEGeoJSON, rec.Header().Get(echo.HeaderContentType))
+	assert.JSONEq(t, `{"type":"FeatureCollection","features":[{"type":"Feature","id":"d1",
+		"geometry":{"type":"Point","coordinates":[29.001,41]},
+		"properties":{"status":"available","distance":84,"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z"}}]}`, rec.Body.String())
+}
+
+// TestListDrivers_GeoJSON tests a page of the driver list asking for GeoJSON
+// Expected: Should answer with a FeatureCollection of the page and its next cursor
+func TestListDrivers_GeoJSON(t *testing.T) {
+	mockService := new(MockDriverService)
+	handler := NewDriverHandler(mockService)
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/drivers?limit=1", nil)
+	req.Header.Set(echo.HeaderAccept, MIMEGeoJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	mockService.On("ListDrivers", domain.ListDriversRequest{Limit: 1}).Return(&domain.DriverPage{
+		Drivers:    []*domain.Driver{{ID: "d2", Location: domain.NewPoint(29, 41)}},
+		Count:      1,
+		NextCursor: "ZDI",
+	}, nil)
+
+	err := handler.ListDrivers(c)
+	assert.NoError(t, err)
+	var collection struct {
+		Type       string           `json:"type"`
+		Features   []geoJSONFeature `json:"features"`
+		NextCursor string           `json:"next_cursor"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &collection))
+	assert.Equal(t, "FeatureCollection", collection.Type)
+	require.Len(t, collection.Features, 1)
+	assert.Equal(t, "d2", collection.Features[0].ID)
+	assert.NotContains(t, collection.Features[0].Properties, "location")
+	assert.Equal(t, "ZDI", collection.NextCursor)
+}
+
+// TestSearchDriversInBox_GeoJSONClusters tests clustering a box asking for GeoJSON
+// Expected: Should answer with a point feature per cluster at its center
+func TestSearchDriversInBox_GeoJSONClusters(t *testing.T) {
+	mockService := new(MockDriverService)
+	handler := NewDriverHandler(mockService)
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/drivers/search/box?min_lon=28.9&min_lat=40.9&max_lon=29.1&max_lat=41.1&cluster=5", nil)
+	req.Header.Set(echo.HeaderAccept, MIMEGeoJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	mockService.On("SearchDriversInBox", mock.Anything).Return(&domain.BoxSearchResult{
+		Clusters: []domain.DriverCluster{{Geohash: "sxk9w", Count: 3, Center: domain.NewPoint(29, 41)}},
+		Count:    1,
+	}, nil)
+
+	err := handler.SearchDriversInBox(c)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"type":"FeatureCollection","features":[{"type":"Feature",
+		"geometry":{"type":"Point","coordinates":[29,41]},"properties":{"geohash":"sxk9w","count":3}}]}`, rec.Body.String())
+}
+
+// TestListDrivers_InvalidCursor tests listing drivers with a cursor the service rejects
+// Expected: Should return 400 Bad Request
+func TestListDrivers_InvalidCursor(t *testing.T) {
+	mockService := new(MockDriverService)
+	handler := NewDriverHandler(mockService)
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/drivers?cursor=!", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	mockService.On("ListDrivers", domain.ListDriversRequest{Cursor: "!"}).Return(nil, fmt.Errorf("%w: invalid cursor", domain.ErrValidation))
+
+	err := handler.ListDrivers(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	mockService.AssertExpectations(t)
+}
+
+// TestSearchDriversInCell_Success tests counting the drivers of an S2 cell
+// Expected: Should bind the token and query parameters and return the counts
+func TestSearchDriversInCell_Success(t *testing.T) {
+	mockService := new(MockDriverService)
+	handler := NewDriverHandler(mockService)
+	e := echo.New()
+	e.GET("/api/v1/drivers/cells/:token", handler.SearchDriversInCell)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/drivers/cells/89c25a3?count=true&level=14", nil)
+	rec := httptest.NewRecorder()
+	expected := domain.CellSearchRequest{Token: "89c25a3", Count: true, Level: 14}
+	mockService.On("SearchDriversInCell", expected).Return(&domain.CellSearchResult{
+		Cell:   "89c25a3",
+		Level:  12,
+		Counts: []domain.CellCount{{Cell: "89c25a2c", Level: 14, Count: 3, Center: domain.NewPoint(-74, 40.7)}},
+		Count:  1,
+	}, nil)
+
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"cell":"89c25a2c"`)
+	mockService.AssertExpectations(t)
+}
+
+// TestSearchDriversInCell_ValidationError tests an invalid cell token
+// Expected: Should map the validation error of the service to 400
+func TestSearchDriversInCell_ValidationError(t *testing.T) {
+	mockService := new(MockDriverService)
+	handler := NewDriverHandler(mockService)
+	e := echo.New()
+	e.GET("/api/v1/drivers/cells/:token", handler.SearchDriversInCell)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/drivers/cells/zz", nil)
+	rec := httptest.NewRecorder()
+	mockService.On("SearchDriversInCell", domain.CellSearchRequest{Token: "zz"}).
+		Return((*domain.CellSearchResult)(nil), fmt.Errorf("%w: invalid cell token", domain.ErrValidation))
+
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "validation_error")
+}
+
+// TestSearchDriversInBox_InvalidQuery tests query parameters that are not numbers
+// Expected: Should return 400 Bad Request without calling the service
+func TestSearchDriversInBox_InvalidQuery(t *testing.T) {
+	mockService := new(MockDriverService)
+	handler := NewDriverHandler(mockService)
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/drivers/search/box?min_lon=west&min_lat=40.9&max_lon=29.1&max_lat=41.1", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	err := handler.SearchDriversInBox(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "invalid_request")
+	mockService.AssertNotCalled(t, "SearchDriversInBox", mock.Anything)
+}
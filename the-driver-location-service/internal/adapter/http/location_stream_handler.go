@@ -0,0 +1,136 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/net/websocket"
+
+	"the-driver-location-service/internal/domain"
+	"the-driver-location-service/internal/ports/primary"
+	"the-driver-location-service/internal/ports/secondary"
+)
+
+const maxLocationMessageBytes = 4 << 10
+
+type LocationStreamConfig struct {
+	MinInterval time.Duration // updates closer together than this are dropped
+	IdleTimeout time.Duration // the connection is closed when no update arrives in time
+}
+
+// LocationStreamAck answers every message of a location stream
+type LocationStreamAck struct {
+	Status string `json:"status"` // ok, throttled or error
+	Error  string `json:"error,omitempty"`
+}
+
+// LocationStreamHandler accepts a continuous stream of location updates from a
+// driver app over a WebSocket, each message is a LocationUpdate and is stored
+// exactly like a PATCH of the driver location
+type LocationStreamHandler struct {
+	driverService primary.DriverService
+	config        LocationStreamConfig
+	logger        secondary.Logger
+	now           func() time.Time
+}
+
+func NewLocationStreamHandler(driverService primary.DriverService, config LocationStreamConfig) *LocationStreamHandler {
+	if config.IdleTimeout <= 0 {
+		config.IdleTimeout = time.Minute
+	}
+	return &LocationStreamHandler{
+		driverService: driverService,
+		config:        config,
+		logger:        secondary.NopLogger{},
+		now:           time.Now,
+	}
+}
+
+// SetLogger sets where streams closed by a failure are logged
+func (h *LocationStreamHandler) SetLogger(logger secondary.Logger) {
+	h.logger = logger
+}
+
+// @Summary Stream driver location updates
+// @Description Upgrade to a WebSocket and send one location update JSON message per position, every message is answered with an ack whose status is ok, throttled or error
+// @Tags drivers
+// @Param id path string true "Driver ID"
+// @Success 101 {object} LocationStreamAck
+// @Failure 400 {object} APIResponse
+// @Security X-API-KEY
+// @Router /api/v1/drivers/{id}/location/stream [get]
+func (h *LocationStreamHandler) StreamDriverLocation(c echo.Context) error {
+	id := c.Param("id")
+	if id == "" {
+		return c.JSON(http.StatusBadRequest, APIResponse{
+			Success: false,
+			Error:   "invalid_request",
+			Message: "Driver ID is required",
+		})
+	}
+
+	server := websocket.Server{
+		// driver apps are not browsers and send no Origin header, requests are
+		// authenticated by the API key middleware instead
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(ws *websocket.Conn) {
+			defer ws.Close()
+			ws.MaxPayloadBytes = maxLocationMessageBytes
+			h.serve(ws.Request().Context(), id, ws)
+		},
+	}
+	server.ServeHTTP(c.Response(), c.Request())
+	return nil
+}
+
+func (h *LocationStreamHandler) serve(ctx context.Context, id string, ws *websocket.Conn) {
+	var lastStored time.Time
+	for {
+		// the server read and write timeouts are still set on the hijacked connection
+		ws.SetReadDeadline(time.Now().Add(h.config.IdleTimeout))
+
+		var message []byte
+		if err := websocket.Message.Receive(ws, &message); err != nil {
+			if !errors.Is(err, io.EOF) {
+				h.logger.Info(ctx, "location stream closed", "driver_id", id, "error", err)
+			}
+			return
+		}
+
+		ack := h.handle(ctx, id, message, &lastStored)
+
+		ws.SetWriteDeadline(time.Now().Add(h.config.IdleTimeout))
+		if err := websocket.JSON.Send(ws, ack); err != nil {
+			h.logger.Info(ctx, "location stream closed", "driver_id", id, "error", err)
+			return
+		}
+	}
+}
+
+func (h *LocationStreamHandler) handle(ctx context.Context, id string, message []byte, lastStored *time.Time) LocationStreamAck {
+	now := h.now()
+	if !lastStored.IsZero() && now.Sub(*lastStored) < h.config.MinInterval {
+		return LocationStreamAck{Status: "throttled"}
+	}
+
+	var update domain.LocationUpdate
+	body, err := normalizeJSONKeys(message, shapeOf(&update))
+	if err == nil {
+		err = json.Unmarshal(body, &update)
+	}
+	if err != nil {
+		return LocationStreamAck{Status: "error", Error: "Invalid location update"}
+	}
+
+	if err := h.driverService.UpdateDriverLocation(ctx, id, update); err != nil {
+		return LocationStreamAck{Status: "error", Error: err.Error()}
+	}
+
+	*lastStored = now
+	return LocationStreamAck{Status: "ok"}
+}
@@ -0,0 +1,77 @@
+package http
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+
+	"the-driver-location-service/internal/domain"
+)
+
+func newLocationStreamServer(t *testing.T, service *MockDriverService, handler *LocationStreamHandler) *websocket.Conn {
+	e := echo.New()
+	e.GET("/api/v1/drivers/:id/location/stream", handler.StreamDriverLocation)
+	server := httptest.NewServer(e)
+	t.Cleanup(server.Close)
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/v1/drivers/d1/location/stream"
+	ws, err := websocket.Dial(url, "", server.URL)
+	require.NoError(t, err)
+	t.Cleanup(func() { ws.Close() })
+	return ws
+}
+
+func sendLocation(t *testing.T, ws *websocket.Conn, message string) LocationStreamAck {
+	require.NoError(t, websocket.Message.Send(ws, message))
+	var ack LocationStreamAck
+	require.NoError(t, websocket.JSON.Receive(ws, &ack))
+	return ack
+}
+
+// TestStreamDriverLocation tests streaming location updates over a WebSocket
+// Expected: Should store each update, acknowledge invalid messages and service errors with an error ack
+func TestStreamDriverLocation(t *testing.T) {
+	service := new(MockDriverService)
+	speed := 8.0
+	service.On("UpdateDriverLocation", "d1", domain.LocationUpdate{Point: domain.NewPoint(29.01, 41.01), Speed: &speed}).Return(nil).Once()
+	service.On("UpdateDriverLocation", "d1", domain.LocationUpdate{Point: domain.NewPoint(29.02, 41.02)}).Return(errors.New("invalid location")).Once()
+	ws := newLocationStreamServer(t, service, NewLocationStreamHandler(service, LocationStreamConfig{}))
+
+	ack := sendLocation(t, ws, `{"type":"Point","coordinates":[29.01,41.01],"speed":8}`)
+	assert.Equal(t, "ok", ack.Status)
+
+	ack = sendLocation(t, ws, `not json`)
+	assert.Equal(t, "error", ack.Status)
+
+	ack = sendLocation(t, ws, `{"type":"Point","coordinates":[29.02,41.02]}`)
+	assert.Equal(t, "error", ack.Status)
+	assert.Equal(t, "invalid location", ack.Error)
+	service.AssertExpectations(t)
+}
+
+// TestStreamDriverLocation_Throttled tests updates sent faster than the minimum interval
+// Expected: Should store the first update and drop the following ones until the interval has passed
+func TestStreamDriverLocation_Throttled(t *testing.T) {
+	service := new(MockDriverService)
+	service.On("UpdateDriverLocation", "d1", mock.Anything).Return(nil)
+	handler := NewLocationStreamHandler(service, LocationStreamConfig{MinInterval: time.Second})
+	now := time.Now()
+	handler.now = func() time.Time { return now }
+	ws := newLocationStreamServer(t, service, handler)
+
+	message := `{"type":"Point","coordinates":[29.01,41.01]}`
+	assert.Equal(t, "ok", sendLocation(t, ws, message).Status)
+	assert.Equal(t, "throttled", sendLocation(t, ws, message).Status)
+
+	now = now.Add(time.Second)
+	assert.Equal(t, "ok", sendLocation(t, ws, message).Status)
+	service.AssertNumberOfCalls(t, "UpdateDriverLocation", 2)
+}
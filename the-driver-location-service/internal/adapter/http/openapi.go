@@ -0,0 +1,214 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/getkin/kin-openapi/openapi2"
+	"github.com/getkin/kin-openapi/openapi2conv"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/labstack/echo/v4"
+
+	"the-driver-location-service/internal/adapter/middleware"
+)
+
+var errUnauthenticated = errors.New("unauthenticated")
+
+// documentedMethods are the methods an OpenAPI document can describe, the
+// router of the validator does not expect any other
+var documentedMethods = map[string]bool{
+	http.MethodGet: true, http.MethodPost: true, http.MethodPut: true, http.MethodPatch: true,
+	http.MethodDelete: true, http.MethodHead: true, http.MethodOptions: true, http.MethodTrace: true,
+	http.MethodConnect: true,
+}
+
+// RequestValidator checks requests against the Swagger document of the service,
+// the same document the swag annotations of the handlers generate, so a payload
+// the documentation does not allow never reaches a handler
+type RequestValidator struct {
+	router  routers.Router
+	options *openapi3filter.Options
+	shapes  sync.Map // *openapi3.Schema of a request body -> *jsonShape
+}
+
+// NewRequestValidator loads the Swagger 2.0 document generated into the docs package
+func NewRequestValidator(spec string, authConfig middleware.AuthConfig) (*RequestValidator, error) {
+	var doc2 openapi2.T
+	if err := json.Unmarshal([]byte(spec), &doc2); err != nil {
+		return nil, fmt.Errorf("failed to parse the swagger document: %w", err)
+	}
+	doc, err := openapi2conv.ToV3(&doc2)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert the swagger document: %w", err)
+	}
+	// requests are matched by path whatever host the service runs behind
+	doc.Servers = nil
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("invalid swagger document: %w", err)
+	}
+
+	router, err := legacy.NewRouter(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to route the swagger document: %w", err)
+	}
+
+	return &RequestValidator{
+		router: router,
+		options: &openapi3filter.Options{
+			AuthenticationFunc: func(ctx context.Context, input *openapi3filter.AuthenticationInput) error {
+				req := input.RequestValidationInput.Request
+				if middleware.SignedRequest(req.Context()) {
+					return nil
+				}
+				if authConfig.RequireSignature || !middleware.ValidAPIKey(authConfig, req.Header.Get("X-API-Key")) {
+					return errUnauthenticated
+				}
+				return nil
+			},
+		},
+	}, nil
+}
+
+// Middleware answers requests that do not match the document with 400, routes
+// the document does not describe and unauthenticated requests are passed on to
+// the route so its own middleware answers them
+func (v *RequestValidator) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			if !documentedMethods[req.Method] {
+				return next(c)
+			}
+			route, pathParams, err := v.router.FindRoute(req)
+			if err != nil {
+				return next(c)
+			}
+
+			// bodies are checked the way the codec decodes them, camelCase keys included
+			if req.Body != nil && req.Body != http.NoBody {
+				body, err := io.ReadAll(req.Body)
+				if err != nil {
+					return err
+				}
+				if normalized, err := normalizeJSONKeys(body, v.bodyShape(route)); err == nil {
+					body = normalized
+				}
+				req.Body = io.NopCloser(bytes.NewReader(body))
+			}
+
+			err = openapi3filter.ValidateRequest(req.Context(), &openapi3filter.RequestValidationInput{
+				Request:    req,
+				PathParams: pathParams,
+				Route:      route,
+				Options:    v.options,
+			})
+			var securityErr *openapi3filter.SecurityRequirementsError
+			if err == nil || errors.As(err, &securityErr) {
+				return next(c)
+			}
+			return c.JSON(http.StatusBadRequest, APIResponse{
+				Success: false,
+				Error:   "validation_error",
+				Message: validationMessage(err),
+			})
+		}
+	}
+}
+
+// bodyShape returns the shape of the JSON body the route documents, the field
+// names the codec renames camelCase keys to
+func (v *RequestValidator) bodyShape(route *routers.Route) *jsonShape {
+	body := route.Operation.RequestBody
+	if body == nil || body.Value == nil {
+		return nil
+	}
+	media := body.Value.Content.Get(echo.MIMEApplicationJSON)
+	if media == nil || media.Schema == nil || media.Schema.Value == nil {
+		return nil
+	}
+
+	if cached, ok := v.shapes.Load(media.Schema.Value); ok {
+		return cached.(*jsonShape)
+	}
+	shape := schemaShape(media.Schema, make(map[*openapi3.Schema]*jsonShape))
+	shape.collectAliases()
+	v.shapes.Store(media.Schema.Value, shape)
+	return shape
+}
+
+// schemaShape is typeShape for the schemas of the document: properties are
+// fields, additionalProperties are maps whose keys are kept
+func schemaShape(ref *openapi3.SchemaRef, seen map[*openapi3.Schema]*jsonShape) *jsonShape {
+	if ref == nil || ref.Value == nil {
+		return nil
+	}
+	schema := ref.Value
+	if shape, ok := seen[schema]; ok {
+		return shape
+	}
+
+	if len(schema.Properties) == 0 && len(schema.AllOf) == 0 {
+		elem := schema.Items
+		if elem == nil {
+			elem = schema.AdditionalProperties.Schema
+		}
+		if elemShape := schemaShape(elem, seen); elemShape != nil {
+			return &jsonShape{elem: elemShape}
+		}
+		return nil
+	}
+
+	shape := newStructShape()
+	seen[schema] = shape
+	addSchemaProperties(shape, schema, seen)
+	return shape
+}
+
+func addSchemaProperties(shape *jsonShape, schema *openapi3.Schema, seen map[*openapi3.Schema]*jsonShape) {
+	for _, part := range schema.AllOf {
+		if part.Value != nil {
+			addSchemaProperties(shape, part.Value, seen)
+		}
+	}
+	for name, property := range schema.Properties {
+		shape.addField(name, schemaShape(property, seen))
+	}
+}
+
+// validationMessage names the parameter or body field that failed and why,
+// without the schema and value dumps of the validator
+func validationMessage(err error) string {
+	var where []string
+	var requestErr *openapi3filter.RequestError
+	if errors.As(err, &requestErr) && requestErr.Parameter != nil {
+		where = append(where, fmt.Sprintf("%s parameter %q", requestErr.Parameter.In, requestErr.Parameter.Name))
+	}
+
+	var schemaErr *openapi3.SchemaError
+	if errors.As(err, &schemaErr) {
+		// missing properties are named by the reason already
+		if pointer := schemaErr.JSONPointer(); len(pointer) > 0 && !strings.Contains(schemaErr.Reason, strconv.Quote(pointer[len(pointer)-1])) {
+			where = append(where, "field "+strings.Join(pointer, "."))
+		}
+		if len(where) == 0 {
+			return "Invalid request body: " + schemaErr.Reason
+		}
+		return fmt.Sprintf("Invalid request: %s %s", strings.Join(where, " "), schemaErr.Reason)
+	}
+
+	if requestErr != nil {
+		return "Invalid request: " + requestErr.Error()
+	}
+	return "Invalid request: " + err.Error()
+}
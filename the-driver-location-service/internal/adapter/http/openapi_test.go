@@ -0,0 +1,136 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"the-driver-location-service/docs"
+	"the-driver-location-service/internal/adapter/middleware"
+	"the-driver-location-service/internal/domain"
+)
+
+func newValidatedRouter(t *testing.T, service *mockDriverService) *Router {
+	resetPrometheusRegistry()
+	authConfig := middleware.AuthConfig{MatchingAPIKey: "test-key"}
+	validator, err := NewRequestValidator(docs.SwaggerInfo.ReadDoc(), authConfig)
+	require.NoError(t, err)
+
+	router := NewRouter(service, authConfig)
+	router.SetupRequestValidation(validator)
+	return router
+}
+
+func serveValidated(router *Router, method, path, body, apiKey string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	if apiKey != "" {
+		req.Header.Set("X-API-Key", apiKey)
+	}
+	rec := httptest.NewRecorder()
+	router.GetEcho().ServeHTTP(rec, req)
+	return rec
+}
+
+// TestRequestValidator_Search tests nearby searches checked against the API documentation
+// Expected: Should pass documented payloads in snake_case or camelCase to the handler and answer 400 naming the field otherwise
+func TestRequestValidator_Search(t *testing.T) {
+	service := new(mockDriverService)
+	service.On("SearchNearbyDrivers", mock.Anything).Return([]*domain.DriverWithDistance{}, nil)
+	router := newValidatedRouter(t, service)
+
+	rec := serveValidated(router, http.MethodPost, "/api/v1/drivers/search", `{"location": {"type": "Point", "coordinates": [29.0, 41.0]}, "radius": 500}`, "test-key")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = serveValidated(router, http.MethodPost, "/api/v1/drivers/search", `{"location": {"type": "Point", "coordinates": [29.0, 41.0]}, "radius": 500, "minRadius": 100, "vehicleType": "sedan"}`, "test-key")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = serveValidated(router, http.MethodPost, "/api/v1/drivers/search", `{"location": {"type": "Point", "coordinates": [29.0, 41.0]}, "radius": "500"}`, "test-key")
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	var response APIResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, "validation_error", response.Error)
+	assert.Contains(t, response.Message, "field radius")
+
+	rec = serveValidated(router, http.MethodPost, "/api/v1/drivers/search", `{"radius": 500}`, "test-key")
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Contains(t, response.Message, `"location"`)
+
+	service.AssertNumberOfCalls(t, "SearchNearbyDrivers", 2)
+}
+
+// TestRequestValidator_Unauthenticated tests invalid payloads sent without a valid API key
+// Expected: Should leave them to the API key middleware and answer 401
+func TestRequestValidator_Unauthenticated(t *testing.T) {
+	router := newValidatedRouter(t, new(mockDriverService))
+
+	rec := serveValidated(router, http.MethodPost, "/api/v1/drivers/search", `{"radius": "500"}`, "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	rec = serveValidated(router, http.MethodPost, "/api/v1/drivers/search", `{"radius": "500"}`, "wrong-key")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+// TestRequestValidator_Signed tests searches signed with the shared secret while signatures are required
+// Expected: Should validate and serve the signed request without an API key and answer 401 to the API key alone
+func TestRequestValidator_Signed(t *testing.T) {
+	resetPrometheusRegistry()
+	service := new(mockDriverService)
+	service.On("SearchNearbyDrivers", mock.Anything).Return([]*domain.DriverWithDistance{}, nil)
+	authConfig := middleware.AuthConfig{MatchingAPIKey: "test-key", SigningSecret: "shared-secret", RequireSignature: true}
+	validator, err := NewRequestValidator(docs.SwaggerInfo.ReadDoc(), authConfig)
+	require.NoError(t, err)
+	router := NewRouter(service, authConfig)
+	router.SetupRequestValidation(validator)
+
+	body := `{"location": {"type": "Point", "coordinates": [29.0, 41.0]}, "radius": 500}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/drivers/search", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	timestamp := time.Now().Unix()
+	req.Header.Set(middleware.SignatureTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(middleware.SignatureHeader, middleware.SignRequest("shared-secret", timestamp, http.MethodPost, "/api/v1/drivers/search", []byte(body)))
+	rec := httptest.NewRecorder()
+	router.GetEcho().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = serveValidated(router, http.MethodPost, "/api/v1/drivers/search", body, "test-key")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	service.AssertNumberOfCalls(t, "SearchNearbyDrivers", 1)
+}
+
+// TestRequestValidator_Documented tests that the API documentation covers the routes of the service
+// Expected: Should find every registered route except the metrics and the documentation itself in the document
+func TestRequestValidator_Documented(t *testing.T) {
+	router := newValidatedRouter(t, new(mockDriverService))
+	router.SetupAdminRoutes(&AdminHandler{})
+	router.SetupReconcileRoute(&ReconcileHandler{})
+	router.SetupDuplicateRoute(&DuplicateHandler{})
+	router.SetupOutcomeRoute(&OutcomeHandler{})
+	router.SetupLocationStreamRoute(&LocationStreamHandler{})
+	router.SetupReadinessRoute(&ReadinessHandler{})
+	router.SetupReadOnlyMode(&ReadOnlyHandler{})
+	router.SetupReplicationRoute(&ReplicationHandler{})
+
+	validator, err := NewRequestValidator(docs.SwaggerInfo.ReadDoc(), middleware.AuthConfig{})
+	require.NoError(t, err)
+
+	param := regexp.MustCompile(`:[a-z_]+`)
+	for _, route := range router.GetEcho().Routes() {
+		if route.Method == echo.RouteNotFound || route.Path == "/metrics" || strings.HasPrefix(route.Path, "/swagger") || strings.HasSuffix(route.Path, "/*") {
+			continue
+		}
+		req := httptest.NewRequest(route.Method, param.ReplaceAllString(route.Path, "x"), nil)
+		_, _, err := validator.router.FindRoute(req)
+		assert.NoError(t, err, "%s %s is not documented", route.Method, route.Path)
+	}
+}
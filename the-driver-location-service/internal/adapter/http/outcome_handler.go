@@ -0,0 +1,68 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"the-driver-location-service/internal/domain"
+	"the-driver-location-service/internal/ports/primary"
+)
+
+// OutcomeHandler takes the match outcomes the matching service reports back
+type OutcomeHandler struct {
+	outcomes primary.OutcomeService
+}
+
+func NewOutcomeHandler(outcomes primary.OutcomeService) *OutcomeHandler {
+	return &OutcomeHandler{
+		outcomes: outcomes,
+	}
+}
+
+// @Summary Record a match outcome
+// @Description Count an accepted, completed or driver cancelled match of the driver, the counts and the quality score derived from them are returned and show up on the driver
+// @Tags drivers
+// @Accept json
+// @Produce json
+// @Param id path string true "Driver ID"
+// @Param request body domain.OutcomeRequest true "Match and its outcome"
+// @Success 200 {object} APIResponse{data=domain.DriverOutcomes}
+// @Failure 400 {object} APIResponse
+// @Failure 404 {object} APIResponse
+// @Failure 500 {object} APIResponse
+// @Security X-API-KEY
+// @Router /api/v1/drivers/{id}/outcomes [post]
+func (h *OutcomeHandler) RecordOutcome(c echo.Context) error {
+	var req domain.OutcomeRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, APIResponse{
+			Success: false,
+			Error:   "invalid_request",
+			Message: "Invalid request body",
+		})
+	}
+
+	outcomes, err := h.outcomes.RecordOutcome(c.Request().Context(), c.Param("id"), req)
+	if err != nil {
+		status, errorType := http.StatusInternalServerError, "internal_error"
+		switch {
+		case errors.Is(err, domain.ErrValidation):
+			status, errorType = http.StatusBadRequest, "validation_error"
+		case errors.Is(err, domain.ErrNotFound):
+			status, errorType = http.StatusNotFound, "not_found"
+		}
+		return c.JSON(status, APIResponse{
+			Success: false,
+			Error:   errorType,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Data:    outcomes,
+		Message: "Outcome recorded successfully",
+	})
+}
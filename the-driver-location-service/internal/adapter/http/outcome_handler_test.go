@@ -0,0 +1,62 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+
+	"the-driver-location-service/internal/domain"
+)
+
+type stubOutcomeService struct {
+	outcomes *domain.DriverOutcomes
+	err      error
+}
+
+func (s *stubOutcomeService) RecordOutcome(ctx context.Context, id string, req domain.OutcomeRequest) (*domain.DriverOutcomes, error) {
+	return s.outcomes, s.err
+}
+
+func serveOutcome(service *stubOutcomeService, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/drivers/d1/outcomes", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues("d1")
+	_ = NewOutcomeHandler(service).RecordOutcome(c)
+	return rec
+}
+
+// TestRecordOutcome_Success tests reporting a match outcome
+// Expected: Should return 200 OK with the counts and the quality score
+func TestRecordOutcome_Success(t *testing.T) {
+	service := &stubOutcomeService{outcomes: &domain.DriverOutcomes{Accepted: 4, Completed: 3, Cancelled: 1}}
+
+	rec := serveOutcome(service, `{"match_id":"m1","outcome":"completed"}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"completed":3`)
+	assert.Contains(t, rec.Body.String(), `"quality_score":0.667`)
+}
+
+// TestRecordOutcome_Errors tests invalid bodies, invalid outcomes, unknown drivers and failing stores
+// Expected: Should return 400, 404 and 500 respectively
+func TestRecordOutcome_Errors(t *testing.T) {
+	assert.Equal(t, http.StatusBadRequest, serveOutcome(&stubOutcomeService{}, `{"match_id":`).Code)
+
+	invalid := &stubOutcomeService{err: fmt.Errorf("%w: outcome is invalid", domain.ErrValidation)}
+	assert.Equal(t, http.StatusBadRequest, serveOutcome(invalid, `{"match_id":"m1","outcome":"rejected"}`).Code)
+
+	missing := &stubOutcomeService{err: fmt.Errorf("failed to record outcome: %w", domain.ErrDriverNotFound)}
+	assert.Equal(t, http.StatusNotFound, serveOutcome(missing, `{"match_id":"m1","outcome":"completed"}`).Code)
+
+	failing := &stubOutcomeService{err: errors.New("mongo unavailable")}
+	assert.Equal(t, http.StatusInternalServerError, serveOutcome(failing, `{"match_id":"m1","outcome":"completed"}`).Code)
+}
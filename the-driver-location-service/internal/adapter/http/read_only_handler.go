@@ -0,0 +1,115 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"the-driver-location-service/internal/domain"
+	"the-driver-location-service/internal/ports/primary"
+)
+
+// readOnlyAllowed are the routes that keep answering in read-only mode although
+// their method writes: searches posting their query and the toggle itself
+var readOnlyAllowed = map[string]bool{
+	"POST /api/v1/drivers/search":        true,
+	"POST /api/v1/drivers/search/within": true,
+	"POST /api/v1/drivers/reconcile":     true,
+	"PUT /admin/read-only":               true,
+}
+
+// readOnlyRefused are the routes refused in read-only mode although their
+// method reads, the location stream writes every message it receives
+var readOnlyRefused = map[string]bool{
+	"GET /api/v1/drivers/:id/location/stream": true,
+}
+
+// ReadOnlyGuard answers 503 to every request that writes while the service is
+// read-only. Routes are refused unless they read, so a new route is guarded
+// without being listed.
+func ReadOnlyGuard(readOnly primary.ReadOnlyService) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !writes(c.Request().Method, c.Path()) {
+				return next(c)
+			}
+			mode := readOnly.ReadOnly()
+			if !mode.Enabled {
+				return next(c)
+			}
+
+			message := "Service is read-only for maintenance, try again later"
+			if mode.Reason != "" {
+				message += ": " + mode.Reason
+			}
+			return c.JSON(http.StatusServiceUnavailable, APIResponse{
+				Success: false,
+				Data:    mode,
+				Error:   "maintenance",
+				Message: message,
+			})
+		}
+	}
+}
+
+func writes(method, path string) bool {
+	route := method + " " + path
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return readOnlyRefused[route]
+	}
+	return !readOnlyAllowed[route]
+}
+
+// ReadOnlyHandler serves the read-only toggle of the instance
+type ReadOnlyHandler struct {
+	readOnly primary.ReadOnlyService
+}
+
+func NewReadOnlyHandler(readOnly primary.ReadOnlyService) *ReadOnlyHandler {
+	return &ReadOnlyHandler{
+		readOnly: readOnly,
+	}
+}
+
+// @Summary Get the read-only mode
+// @Description Whether the instance refuses writes, turned on through this endpoint (source admin) or by the read_only feature flag (source flag)
+// @Tags admin
+// @Produce json
+// @Success 200 {object} APIResponse{data=domain.ReadOnlyMode}
+// @Security X-API-KEY
+// @Router /admin/read-only [get]
+func (h *ReadOnlyHandler) GetReadOnly(c echo.Context) error {
+	return c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Data:    h.readOnly.ReadOnly(),
+		Message: "Read-only mode retrieved successfully",
+	})
+}
+
+// @Summary Turn the read-only mode on or off
+// @Description Searches and reads keep working in read-only mode, writes are answered with 503 and the maintenance error. The mode applies to this instance. The read_only feature flag turns it on for every instance, or with a percentage for that share of the instances picked by hostname, and keeps it on until the flag is disabled.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body domain.SetReadOnlyRequest true "Read-only mode"
+// @Success 200 {object} APIResponse{data=domain.ReadOnlyMode}
+// @Failure 400 {object} APIResponse
+// @Security X-API-KEY
+// @Router /admin/read-only [put]
+func (h *ReadOnlyHandler) SetReadOnly(c echo.Context) error {
+	var req domain.SetReadOnlyRequest
+	if err := c.Bind(&req); err != nil || req.Enabled == nil {
+		return c.JSON(http.StatusBadRequest, APIResponse{
+			Success: false,
+			Error:   "invalid_request",
+			Message: "enabled is required",
+		})
+	}
+
+	return c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Data:    h.readOnly.SetReadOnly(*req.Enabled, req.Reason),
+		Message: "Read-only mode updated successfully",
+	})
+}
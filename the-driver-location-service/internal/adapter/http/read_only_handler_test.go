@@ -0,0 +1,97 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"the-driver-location-service/internal/adapter/middleware"
+	"the-driver-location-service/internal/application"
+	"the-driver-location-service/internal/domain"
+)
+
+func serveReadOnly(router *Router, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("X-API-Key", "test-key")
+	rec := httptest.NewRecorder()
+	router.GetEcho().ServeHTTP(rec, req)
+	return rec
+}
+
+// TestReadOnlyGuard tests the requests served while the service is read-only
+// Expected: Should answer writes with 503 and the maintenance error while searches and reads keep working
+func TestReadOnlyGuard(t *testing.T) {
+	resetPrometheusRegistry()
+	mockService := new(mockDriverService)
+	router := NewRouter(mockService, middleware.AuthConfig{MatchingAPIKey: "test-key"})
+	readOnly := application.NewReadOnlyApplicationService(nil)
+	router.SetupReadOnlyMode(NewReadOnlyHandler(readOnly))
+	readOnly.SetReadOnly(true, "mongodb failover")
+
+	mockService.On("GetDriver", "d1").Return(&domain.Driver{ID: "d1", Location: domain.NewPoint(29, 41)}, nil)
+	mockService.On("SearchNearbyDrivers", mock.AnythingOfType("domain.SearchRequest")).Return([]*domain.DriverWithDistance{}, nil)
+
+	rec := serveReadOnly(router, http.MethodGet, "/api/v1/drivers/d1", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = serveReadOnly(router, http.MethodPost, "/api/v1/drivers/search", `{"location":{"type":"Point","coordinates":[29,41]},"radius":1000,"limit":10}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	for _, tc := range []struct{ method, path string }{
+		{http.MethodPost, "/api/v1/drivers"},
+		{http.MethodPatch, "/api/v1/drivers/d1/location"},
+		{http.MethodDelete, "/api/v1/drivers/d1"},
+	} {
+		rec = serveReadOnly(router, tc.method, tc.path, `{}`)
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code, "%s %s", tc.method, tc.path)
+
+		var response APIResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		assert.Equal(t, "maintenance", response.Error)
+		assert.Contains(t, response.Message, "mongodb failover")
+	}
+
+	rec = serveReadOnly(router, http.MethodPut, "/admin/read-only", `{"enabled":false}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.False(t, readOnly.ReadOnly().Enabled)
+	mockService.AssertExpectations(t)
+}
+
+// TestReadOnlyHandler tests the read-only toggle endpoint
+// Expected: Should require the API key and the enabled field and return the resulting mode
+func TestReadOnlyHandler(t *testing.T) {
+	resetPrometheusRegistry()
+	router := NewRouter(new(mockDriverService), middleware.AuthConfig{MatchingAPIKey: "test-key"})
+	readOnly := application.NewReadOnlyApplicationService(nil)
+	router.SetupReadOnlyMode(NewReadOnlyHandler(readOnly))
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/read-only", nil)
+	rec := httptest.NewRecorder()
+	router.GetEcho().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = serveReadOnly(router, http.MethodPut, "/admin/read-only", `{"reason":"mongodb upgrade"}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.False(t, readOnly.ReadOnly().Enabled)
+
+	rec = serveReadOnly(router, http.MethodPut, "/admin/read-only", `{"enabled":true,"reason":"mongodb upgrade"}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = serveReadOnly(router, http.MethodGet, "/admin/read-only", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var response struct {
+		Data domain.ReadOnlyMode `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.True(t, response.Data.Enabled)
+	assert.Equal(t, domain.ReadOnlyByAdmin, response.Data.Source)
+	assert.Equal(t, "mongodb upgrade", response.Data.Reason)
+}
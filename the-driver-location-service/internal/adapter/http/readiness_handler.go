@@ -0,0 +1,152 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"the-driver-location-service/internal/ports/primary"
+)
+
+const (
+	DependencyUp   = "up"
+	DependencyDown = "down"
+
+	// DefaultDependencyTimeout bounds a ping of the readiness probe, a hanging
+	// dependency counts as down
+	DefaultDependencyTimeout = 2 * time.Second
+)
+
+// DependencyCheck is a dependency pinged by the readiness probe. An instance is
+// not ready while a required dependency is down, an optional one that is down
+// only degrades it.
+type DependencyCheck struct {
+	Name     string
+	Required bool
+	Ping     func(ctx context.Context) error
+}
+
+// DependencyHealth is the result of pinging a dependency
+type DependencyHealth struct {
+	Status    string  `json:"status"` // up or down
+	Required  bool    `json:"required"`
+	Error     string  `json:"error,omitempty"`
+	LatencyMs float64 `json:"latency_ms"`
+}
+
+// ReadinessHandler reports whether the instance can take traffic. The cache warmup
+// is reported but does not hold back readiness, a cold cache is only slower.
+type ReadinessHandler struct {
+	warmup       primary.WarmupService
+	dependencies []DependencyCheck
+	timeout      time.Duration
+}
+
+func NewReadinessHandler(warmup primary.WarmupService) *ReadinessHandler {
+	return &ReadinessHandler{
+		warmup:  warmup,
+		timeout: DefaultDependencyTimeout,
+	}
+}
+
+// SetDependencies makes the readiness probe ping the dependencies, each within
+// timeout
+func (h *ReadinessHandler) SetDependencies(timeout time.Duration, dependencies ...DependencyCheck) {
+	if timeout > 0 {
+		h.timeout = timeout
+	}
+	h.dependencies = dependencies
+}
+
+// @Summary Readiness check endpoint
+// @Description Check if the service is ready, with the progress of the startup cache warmup and the state of MongoDB and Redis. Answers 503 while a required dependency is down (MongoDB, and Redis when it is the search backend), a cache outage only degrades the status.
+// @Tags health
+// @Produce json
+// @Success 200 {object} APIResponse
+// @Failure 503 {object} APIResponse
+// @Router /health/ready [get]
+// @Router /ready [get]
+func (h *ReadinessHandler) ReadinessCheck(c echo.Context) error {
+	progress := h.warmup.Progress()
+	status := "ready"
+	if !progress.Done() {
+		status = "warming_up"
+	}
+
+	dependencies, ready, degraded := h.checkDependencies(c.Request().Context())
+	if degraded {
+		status = "degraded"
+	}
+
+	data := map[string]interface{}{
+		"status":       status,
+		"service":      "driver-location-service",
+		"warmup":       progress,
+		"dependencies": dependencies,
+	}
+	if !ready {
+		data["status"] = "unavailable"
+		return c.JSON(http.StatusServiceUnavailable, APIResponse{
+			Success: false,
+			Data:    data,
+			Error:   "service_unavailable",
+			Message: "Service is not ready",
+		})
+	}
+	return c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Data:    data,
+		Message: "Service is ready",
+	})
+}
+
+// checkDependencies pings the dependencies at once, the instance is not ready
+// when a required one is down and degraded when an optional one is
+func (h *ReadinessHandler) checkDependencies(ctx context.Context) (map[string]DependencyHealth, bool, bool) {
+	results := make([]DependencyHealth, len(h.dependencies))
+	var wg sync.WaitGroup
+	for i, dependency := range h.dependencies {
+		wg.Add(1)
+		go func(i int, dependency DependencyCheck) {
+			defer wg.Done()
+			results[i] = h.ping(ctx, dependency)
+		}(i, dependency)
+	}
+	wg.Wait()
+
+	dependencies := make(map[string]DependencyHealth, len(results))
+	ready, degraded := true, false
+	for i, result := range results {
+		dependencies[h.dependencies[i].Name] = result
+		if result.Status == DependencyUp {
+			continue
+		}
+		if result.Required {
+			ready = false
+		} else {
+			degraded = true
+		}
+	}
+	return dependencies, ready, degraded
+}
+
+func (h *ReadinessHandler) ping(ctx context.Context, dependency DependencyCheck) DependencyHealth {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := dependency.Ping(ctx)
+	health := DependencyHealth{
+		Status:    DependencyUp,
+		Required:  dependency.Required,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		health.Status = DependencyDown
+		health.Error = err.Error()
+	}
+	return health
+}
@@ -0,0 +1,114 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"the-driver-location-service/internal/domain"
+)
+
+type stubWarmupService struct {
+	progress domain.WarmupProgress
+}
+
+func (s stubWarmupService) Progress() domain.WarmupProgress {
+	return s.progress
+}
+
+func ping(err error) func(ctx context.Context) error {
+	return func(ctx context.Context) error { return err }
+}
+
+type readinessBody struct {
+	Success bool   `json:"success"`
+	Error   string `json:"error"`
+	Data    struct {
+		Status       string                      `json:"status"`
+		Dependencies map[string]DependencyHealth `json:"dependencies"`
+	} `json:"data"`
+}
+
+func serveReadiness(t *testing.T, handler *ReadinessHandler) (int, readinessBody) {
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/health/ready", nil), rec)
+	require.NoError(t, handler.ReadinessCheck(c))
+
+	var body readinessBody
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	return rec.Code, body
+}
+
+// TestReadinessCheck_Dependencies tests the readiness probe with MongoDB and Redis up or down
+// Expected: Should be ready with both up, degraded but ready without an optional Redis and 503 without MongoDB
+func TestReadinessCheck_Dependencies(t *testing.T) {
+	completed := stubWarmupService{progress: domain.WarmupProgress{Status: domain.WarmupCompleted}}
+	for _, tc := range []struct {
+		name           string
+		mongo, redis   error
+		redisRequired  bool
+		expectedCode   int
+		expectedStatus string
+	}{
+		{name: "all up", expectedCode: http.StatusOK, expectedStatus: "ready"},
+		{name: "cache down", redis: errors.New("connection refused"), expectedCode: http.StatusOK, expectedStatus: "degraded"},
+		{name: "geo index down", redis: errors.New("connection refused"), redisRequired: true, expectedCode: http.StatusServiceUnavailable, expectedStatus: "unavailable"},
+		{name: "mongodb down", mongo: errors.New("no reachable servers"), expectedCode: http.StatusServiceUnavailable, expectedStatus: "unavailable"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := NewReadinessHandler(completed)
+			handler.SetDependencies(time.Second,
+				DependencyCheck{Name: "mongodb", Required: true, Ping: ping(tc.mongo)},
+				DependencyCheck{Name: "redis", Required: tc.redisRequired, Ping: ping(tc.redis)},
+			)
+
+			code, body := serveReadiness(t, handler)
+			assert.Equal(t, tc.expectedCode, code)
+			assert.Equal(t, tc.expectedStatus, body.Data.Status)
+			assert.Equal(t, tc.expectedCode == http.StatusOK, body.Success)
+			require.Len(t, body.Data.Dependencies, 2)
+			if tc.redis != nil {
+				assert.Equal(t, DependencyDown, body.Data.Dependencies["redis"].Status)
+				assert.Equal(t, tc.redis.Error(), body.Data.Dependencies["redis"].Error)
+			} else {
+				assert.Equal(t, DependencyUp, body.Data.Dependencies["redis"].Status)
+			}
+		})
+	}
+}
+
+// TestReadinessCheck_Timeout tests a dependency that does not answer its ping
+// Expected: Should count it down once the timeout passed instead of hanging the probe
+func TestReadinessCheck_Timeout(t *testing.T) {
+	handler := NewReadinessHandler(stubWarmupService{progress: domain.WarmupProgress{Status: domain.WarmupRunning}})
+	handler.SetDependencies(20*time.Millisecond, DependencyCheck{Name: "mongodb", Required: true, Ping: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}})
+
+	start := time.Now()
+	code, body := serveReadiness(t, handler)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "service_unavailable", body.Error)
+	assert.Contains(t, body.Data.Dependencies["mongodb"].Error, "deadline exceeded")
+}
+
+// TestReadinessCheck_WarmingUp tests the readiness probe during the cache warmup
+// Expected: Should report warming_up with 200 OK, a cold cache does not hold back readiness
+func TestReadinessCheck_WarmingUp(t *testing.T) {
+	handler := NewReadinessHandler(stubWarmupService{progress: domain.WarmupProgress{Status: domain.WarmupRunning}})
+	handler.SetDependencies(time.Second, DependencyCheck{Name: "mongodb", Required: true, Ping: ping(nil)})
+
+	code, body := serveReadiness(t, handler)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "warming_up", body.Data.Status)
+}
@@ -0,0 +1,64 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"the-driver-location-service/internal/domain"
+	"the-driver-location-service/internal/ports/primary"
+)
+
+// ReconcileHandler serves the reconciliation report fleet partners request nightly
+// instead of pulling a full export
+type ReconcileHandler struct {
+	reconcile primary.ReconcileService
+}
+
+func NewReconcileHandler(reconcile primary.ReconcileService) *ReconcileHandler {
+	return &ReconcileHandler{
+		reconcile: reconcile,
+	}
+}
+
+// @Summary Reconcile drivers
+// @Description Compare a partner's list of drivers with the stored drivers and report the missing, extra and divergent ones
+// @Tags drivers
+// @Accept json
+// @Produce json
+// @Param request body domain.ReconcileRequest true "Drivers as the partner expects them"
+// @Success 200 {object} APIResponse
+// @Failure 400 {object} APIResponse
+// @Failure 500 {object} APIResponse
+// @Security X-API-KEY
+// @Router /api/v1/drivers/reconcile [post]
+func (h *ReconcileHandler) ReconcileDrivers(c echo.Context) error {
+	var req domain.ReconcileRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, APIResponse{
+			Success: false,
+			Error:   "invalid_request",
+			Message: "Invalid request body",
+		})
+	}
+
+	report, err := h.reconcile.Reconcile(c.Request().Context(), req)
+	if err != nil {
+		status, errorType := http.StatusInternalServerError, "internal_error"
+		if errors.Is(err, domain.ErrValidation) {
+			status, errorType = http.StatusBadRequest, "validation_error"
+		}
+		return c.JSON(status, APIResponse{
+			Success: false,
+			Error:   errorType,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Data:    report,
+		Message: "Drivers reconciled successfully",
+	})
+}
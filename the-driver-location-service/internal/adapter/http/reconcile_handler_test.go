@@ -0,0 +1,58 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+
+	"the-driver-location-service/internal/domain"
+)
+
+type stubReconcileService struct {
+	report *domain.ReconcileReport
+	err    error
+}
+
+func (s *stubReconcileService) Reconcile(ctx context.Context, req domain.ReconcileRequest) (*domain.ReconcileReport, error) {
+	return s.report, s.err
+}
+
+func serveReconcile(service *stubReconcileService, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/drivers/reconcile", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	_ = NewReconcileHandler(service).ReconcileDrivers(echo.New().NewContext(req, rec))
+	return rec
+}
+
+// TestReconcileDrivers_Success tests a reconcile request with a report
+// Expected: Should return 200 OK with the report
+func TestReconcileDrivers_Success(t *testing.T) {
+	service := &stubReconcileService{report: &domain.ReconcileReport{Checked: 2, Matched: 1, Missing: []string{"d2"}}}
+
+	rec := serveReconcile(service, `{"drivers":[{"id":"d1"},{"id":"d2"}]}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"missing":["d2"]`)
+}
+
+// TestReconcileDrivers_Errors tests invalid bodies, invalid requests and failing reconciliations
+// Expected: Should return 400 for bad input and 500 for other failures
+func TestReconcileDrivers_Errors(t *testing.T) {
+	assert.Equal(t, http.StatusBadRequest, serveReconcile(&stubReconcileService{}, `{"drivers":`).Code)
+
+	invalid := &stubReconcileService{err: fmt.Errorf("%w: drivers is required", domain.ErrInvalidReconcileRequest)}
+	assert.Equal(t, http.StatusBadRequest, serveReconcile(invalid, `{}`).Code)
+
+	broad := &stubReconcileService{err: fmt.Errorf("%w: id_prefix matches too many drivers", domain.ErrValidation)}
+	assert.Equal(t, http.StatusBadRequest, serveReconcile(broad, `{"id_prefix":"fleet-","drivers":[{"id":"d1"}]}`).Code)
+
+	failing := &stubReconcileService{err: errors.New("mongo unavailable")}
+	assert.Equal(t, http.StatusInternalServerError, serveReconcile(failing, `{"drivers":[{"id":"d1"}]}`).Code)
+}
@@ -0,0 +1,65 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"the-driver-location-service/internal/domain"
+	"the-driver-location-service/internal/ports/primary"
+)
+
+// ReplicationHandler takes the driver changes the primary region replicates to
+// this instance while it runs as the warm standby
+type ReplicationHandler struct {
+	replication primary.ReplicationService
+}
+
+func NewReplicationHandler(replication primary.ReplicationService) *ReplicationHandler {
+	return &ReplicationHandler{
+		replication: replication,
+	}
+}
+
+// @Summary Apply replicated driver changes
+// @Description Called by the driver location service of the primary region. Every change carries the updated_at of the write on the primary, a change older than the stored driver is skipped so retried and reordered batches are safe. A batch with a mirror range also deletes the stored drivers of the range it does not list unless they were updated after the snapshot.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body domain.ReplicationBatch true "Driver changes of the primary region"
+// @Success 200 {object} APIResponse{data=domain.ReplicationResult}
+// @Failure 400 {object} APIResponse
+// @Failure 500 {object} APIResponse
+// @Security X-API-KEY
+// @Router /admin/replication/drivers [post]
+func (h *ReplicationHandler) ApplyChanges(c echo.Context) error {
+	var batch domain.ReplicationBatch
+	if err := c.Bind(&batch); err != nil {
+		return c.JSON(http.StatusBadRequest, APIResponse{
+			Success: false,
+			Error:   "invalid_request",
+			Message: "Invalid request body",
+		})
+	}
+
+	result, err := h.replication.Apply(c.Request().Context(), batch)
+	if err != nil {
+		status, errorType := http.StatusInternalServerError, "internal_error"
+		if errors.Is(err, domain.ErrValidation) {
+			status, errorType = http.StatusBadRequest, "validation_error"
+		}
+		return c.JSON(status, APIResponse{
+			Success: false,
+			Data:    result,
+			Error:   errorType,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Data:    result,
+		Message: "Driver changes applied successfully",
+	})
+}
@@ -0,0 +1,78 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"the-driver-location-service/internal/domain"
+)
+
+type stubReplicationService struct {
+	batches []domain.ReplicationBatch
+	result  *domain.ReplicationResult
+	err     error
+}
+
+func (s *stubReplicationService) Apply(ctx context.Context, batch domain.ReplicationBatch) (*domain.ReplicationResult, error) {
+	s.batches = append(s.batches, batch)
+	return s.result, s.err
+}
+
+// TestApplyChanges_Success tests a batch posted by the replicator of the primary region
+// Expected: Should pass the documented payload with the exact updated_at to the service and return its result
+func TestApplyChanges_Success(t *testing.T) {
+	service := &stubReplicationService{result: &domain.ReplicationResult{Applied: 1, Deleted: 1}}
+	router := newValidatedRouter(t, new(mockDriverService))
+	router.SetupReplicationRoute(NewReplicationHandler(service))
+
+	speed := 12.5
+	updatedAt := time.Date(2025, 1, 2, 3, 4, 5, 678000000, time.UTC)
+	body, err := json.Marshal(domain.ReplicationBatch{
+		Changes: []domain.DriverChange{
+			domain.NewDriverUpsert(&domain.Driver{ID: "d1", Location: domain.NewPoint(29, 41), Status: domain.DriverStatusBusy, Speed: &speed, CreatedAt: updatedAt, UpdatedAt: updatedAt}),
+			{Op: domain.ReplicationDelete, DriverID: "d2", UpdatedAt: updatedAt},
+		},
+		Mirror: &domain.MirrorRange{ThroughID: "d2", SnapshotAt: updatedAt},
+	})
+	require.NoError(t, err)
+
+	rec := serveValidated(router, http.MethodPost, "/admin/replication/drivers", string(body), "test-key")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), `"applied":1`)
+	require.Len(t, service.batches, 1)
+	batch := service.batches[0]
+	require.Len(t, batch.Changes, 2)
+	assert.Equal(t, updatedAt, batch.Changes[0].UpdatedAt)
+	assert.Equal(t, domain.DriverStatusBusy, batch.Changes[0].Driver.Status)
+	assert.Equal(t, "d2", batch.Mirror.ThroughID)
+}
+
+// TestApplyChanges_Errors tests invalid batches, failing stores and missing API keys
+// Expected: Should return 400, 500 and 401 respectively
+func TestApplyChanges_Errors(t *testing.T) {
+	service := &stubReplicationService{}
+	router := newValidatedRouter(t, new(mockDriverService))
+	router.SetupReplicationRoute(NewReplicationHandler(service))
+
+	rec := serveValidated(router, http.MethodPost, "/admin/replication/drivers", `{"changes": [{"op": "upsert"}]}`, "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = serveValidated(router, http.MethodPost, "/admin/replication/drivers", `{"changes": "d1"}`, "test-key")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	service.err = fmt.Errorf("%w: upsert without driver", domain.ErrValidation)
+	rec = serveValidated(router, http.MethodPost, "/admin/replication/drivers", `{"changes": [{"op": "upsert", "driver_id": "d1", "updated_at": "2025-01-02T03:04:05Z"}]}`, "test-key")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	service.err = errors.New("mongo unavailable")
+	rec = serveValidated(router, http.MethodPost, "/admin/replication/drivers", `{"changes": []}`, "test-key")
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+}
@@ -18,6 +18,7 @@ type Router struct {
 
 func NewRouter(driverService primary.DriverService, authConfig middleware.AuthConfig) *Router {
 	e := echo.New()
+	e.JSONSerializer = JSONCodec{}
 	handler := NewDriverHandler(driverService)
 
 	router := &Router{
@@ -33,14 +34,32 @@ func NewRouter(driverService primary.DriverService, authConfig middleware.AuthCo
 }
 
 func (r *Router) setupMiddleware() {
-	r.echo.Use(echomiddleware.Logger())
+	// the request ID and the end user hash are written to the access log and to
+	// the entries logged while handling the request, callers get the request ID
+	// back in X-Request-ID
+	r.echo.Use(middleware.RequestID())
+	r.echo.Use(middleware.RequestLogFields())
+	r.echo.Use(echomiddleware.LoggerWithConfig(echomiddleware.LoggerConfig{Format: middleware.AccessLogFormat}))
 	r.echo.Use(echomiddleware.Recover())
 	r.echo.Use(echomiddleware.CORS())
-	r.echo.Use(echoprometheus.NewMiddleware("driver_location_service"))
+	// signed requests are authenticated before the middlewares that need the
+	// caller, e.g. the rate limiter and the request validation
+	r.echo.Use(middleware.RequestSignature(r.config))
+	r.echo.Use(middleware.ServiceVersion())
+	// X-Debug-Timing: true answers with the time spent in Redis and MongoDB
+	r.echo.Use(middleware.DebugTiming())
+	r.echo.Use(echoprometheus.NewMiddlewareWithConfig(echoprometheus.MiddlewareConfig{
+		Subsystem:         "driver_location_service",
+		LabelFuncs:        middleware.MetricsLabelFuncs(r.config),
+		HistogramOptsFunc: middleware.HistogramBuildLabels,
+		CounterOptsFunc:   middleware.CounterBuildLabels,
+	}))
 }
 
 func (r *Router) setupRoutes() {
 	r.echo.GET("/health", r.handler.HealthCheck)
+	r.echo.GET("/health/live", r.handler.HealthCheck)
+	r.echo.GET("/version", r.handler.Version)
 	r.echo.GET("/metrics", echoprometheus.NewHandler())
 	r.echo.GET("/swagger/*", echoSwagger.WrapHandler)
 
@@ -51,15 +70,129 @@ func (r *Router) setupRoutes() {
 	drivers := v1.Group("/drivers")
 	drivers.Use(middleware.APIKeyAuthMiddleware(r.config))
 	{
-		drivers.POST("", r.handler.CreateDrivers)                      // Create driver(s) - supports both single and batch
-		drivers.POST("/search", r.handler.SearchNearbyDrivers)         // Search nearby drivers
-		drivers.GET("/:id", r.handler.GetDriver)                       // Get driver by ID
-		drivers.PUT("/:id", r.handler.UpdateDriver)                    // Update driver by ID
-		drivers.PATCH("/:id/location", r.handler.UpdateDriverLocation) // Update driver location
-		drivers.DELETE("/:id", r.handler.DeleteDriver)                 // Delete driver
+		drivers.POST("", r.handler.CreateDrivers)                                                      // Create driver(s) - supports both single and batch
+		drivers.GET("", r.handler.ListDrivers, ConsistencyHint())                                      // Page through the drivers
+		drivers.POST("/search", r.handler.SearchNearbyDrivers, StrictJSON(), ConsistencyHint())        // Search nearby drivers, unknown fields are rejected
+		drivers.POST("/search/within", r.handler.SearchDriversWithin, StrictJSON(), ConsistencyHint()) // Search drivers inside a polygon
+		drivers.GET("/search/box", r.handler.SearchDriversInBox, ConsistencyHint())                    // Drivers or clusters inside a bounding box, for maps
+		drivers.GET("/cells/:token", r.handler.SearchDriversInCell, ConsistencyHint())                 // Drivers or counts per cell inside an S2 cell
+		drivers.GET("/:id", r.handler.GetDriver, ConsistencyHint())                                    // Get driver by ID, X-Consistency: strong reads its latest location
+		drivers.PUT("/:id", r.handler.UpdateDriver)                                                    // Update driver by ID
+		drivers.PATCH("/:id/location", r.handler.UpdateDriverLocation)                                 // Update driver location
+		drivers.PATCH("/:id/status", r.handler.UpdateDriverStatus)                                     // Update driver availability
+		drivers.POST("/:id/heartbeat", r.handler.Heartbeat)                                            // Keep a driver searchable without a location update
+		drivers.DELETE("/:id", r.handler.DeleteDriver)                                                 // Delete driver
 	}
 }
 
+// SetupRateLimit limits the requests of every API key, it runs before the
+// middlewares set up after it
+func (r *Router) SetupRateLimit(config middleware.RateLimitConfig) {
+	config.Auth = r.config
+	r.echo.Use(middleware.RateLimit(config))
+}
+
+// lowPriorityRoutes are shed first when the service is overloaded: exports,
+// map and dashboard views and bulk scans, none of them is on the path of a match
+var lowPriorityRoutes = []string{
+	"GET /api/v1/drivers",
+	"GET /api/v1/drivers/search/box",
+	"GET /api/v1/drivers/cells/:token",
+	"POST /api/v1/drivers/reconcile",
+	"POST /admin/duplicates",
+}
+
+// SetupLoadShedding refuses the low priority routes while the handler latency
+// is over the threshold of the config
+func (r *Router) SetupLoadShedding(config middleware.LoadSheddingConfig) {
+	config.LowPriority = lowPriorityRoutes
+	r.echo.Use(middleware.NewLoadShedder(config).Middleware())
+}
+
+// SetupDeprecations marks the responses of the deprecated routes and counts their calls
+func (r *Router) SetupDeprecations(routes []middleware.DeprecatedRoute) {
+	if len(routes) > 0 {
+		r.echo.Use(middleware.Deprecation(routes))
+	}
+}
+
+// SetupRequestValidation checks every documented route against the Swagger
+// document before its handler, see RequestValidator
+func (r *Router) SetupRequestValidation(validator *RequestValidator) {
+	r.echo.Use(validator.Middleware())
+}
+
+// SetupAdminRoutes registers the operational endpoints, they share the API key of the driver routes
+func (r *Router) SetupAdminRoutes(handler *AdminHandler) {
+	admin := r.echo.Group("/admin")
+	admin.Use(middleware.APIKeyAuthMiddleware(r.config))
+	{
+		admin.GET("/flags", handler.GetFeatureFlags)         // Feature flag state
+		admin.GET("/backfills", handler.ListBackfills)       // Backfill jobs and their progress
+		admin.GET("/backfills/:job", handler.GetBackfill)    // Backfill progress
+		admin.POST("/backfills/:job", handler.StartBackfill) // Start or resume a backfill
+	}
+}
+
+// SetupReadOnlyMode registers the read-only toggle next to the admin routes and
+// refuses the writes of every route while the mode is on
+func (r *Router) SetupReadOnlyMode(handler *ReadOnlyHandler) {
+	r.echo.Use(ReadOnlyGuard(handler.readOnly))
+
+	admin := r.echo.Group("/admin")
+	admin.Use(middleware.APIKeyAuthMiddleware(r.config))
+	admin.GET("/read-only", handler.GetReadOnly) // Read-only mode of the instance
+	admin.PUT("/read-only", handler.SetReadOnly) // Turn the read-only mode on or off
+}
+
+// SetupDuplicateRoute registers the duplicate driver scan next to the admin routes
+func (r *Router) SetupDuplicateRoute(handler *DuplicateHandler) {
+	admin := r.echo.Group("/admin")
+	admin.Use(middleware.APIKeyAuthMiddleware(r.config))
+	admin.POST("/duplicates", handler.FindDuplicates) // Report, merge or delete drivers cloned by repeated imports
+}
+
+// SetupReplicationRoute registers the endpoint the primary region replicates
+// driver changes to next to the admin routes
+func (r *Router) SetupReplicationRoute(handler *ReplicationHandler) {
+	admin := r.echo.Group("/admin")
+	admin.Use(middleware.APIKeyAuthMiddleware(r.config))
+	admin.POST("/replication/drivers", handler.ApplyChanges) // Apply driver changes of the primary region
+}
+
+// SetupReconcileRoute registers the partner reconciliation report next to the driver routes
+func (r *Router) SetupReconcileRoute(handler *ReconcileHandler) {
+	drivers := r.echo.Group("/api/v1/drivers")
+	drivers.Use(middleware.APIKeyAuthMiddleware(r.config))
+	drivers.POST("/reconcile", handler.ReconcileDrivers) // Diff a partner's drivers against the stored ones
+}
+
+// SetupOutcomeRoute registers the match outcome feedback of the matching service next to the driver routes
+func (r *Router) SetupOutcomeRoute(handler *OutcomeHandler) {
+	drivers := r.echo.Group("/api/v1/drivers")
+	drivers.Use(middleware.APIKeyAuthMiddleware(r.config))
+	drivers.POST("/:id/outcomes", handler.RecordOutcome, StrictJSON()) // Count a match outcome of the driver
+}
+
+// SetupLocationStreamRoute registers the WebSocket location stream next to the driver routes
+func (r *Router) SetupLocationStreamRoute(handler *LocationStreamHandler) {
+	drivers := r.echo.Group("/api/v1/drivers")
+	drivers.Use(middleware.APIKeyAuthMiddleware(r.config))
+	drivers.GET("/:id/location/stream", handler.StreamDriverLocation) // Stream driver location updates
+}
+
+// SetupReadinessRoute registers the readiness probe, it is public like the health check
+func (r *Router) SetupReadinessRoute(handler *ReadinessHandler) {
+	r.echo.GET("/health/ready", handler.ReadinessCheck)
+	r.echo.GET("/ready", handler.ReadinessCheck)
+}
+
+// SetResponseStreamThreshold sets the number of drivers above which driver lists
+// are streamed, see DriverHandler.SetStreamThreshold
+func (r *Router) SetResponseStreamThreshold(threshold int) {
+	r.handler.SetStreamThreshold(threshold)
+}
+
 func (r *Router) GetEcho() *echo.Echo {
 	return r.echo
 }
@@ -1,9 +1,11 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"runtime"
 	"strings"
 	"testing"
 
@@ -14,6 +16,8 @@ import (
 
 	"errors"
 	"the-driver-location-service/internal/adapter/middleware"
+	"the-driver-location-service/internal/adapter/ratelimit"
+	"the-driver-location-service/internal/buildinfo"
 	"the-driver-location-service/internal/domain"
 )
 
@@ -21,41 +25,74 @@ type mockDriverService struct {
 	mock.Mock
 }
 
-func (m *mockDriverService) CreateDriver(req domain.CreateDriverRequest) (*domain.Driver, error) {
+func (m *mockDriverService) CreateDriver(_ context.Context, req domain.CreateDriverRequest) (*domain.Driver, error) {
 	args := m.Called(req)
 	return args.Get(0).(*domain.Driver), args.Error(1)
 }
 
-func (m *mockDriverService) BatchCreateDrivers(req domain.BatchCreateRequest) ([]*domain.Driver, error) {
+func (m *mockDriverService) BatchCreateDrivers(_ context.Context, req domain.BatchCreateRequest) ([]*domain.Driver, error) {
 	args := m.Called(req)
 	return args.Get(0).([]*domain.Driver), args.Error(1)
 }
 
-func (m *mockDriverService) SearchNearbyDrivers(req domain.SearchRequest) ([]*domain.DriverWithDistance, error) {
+func (m *mockDriverService) SearchNearbyDrivers(_ context.Context, req domain.SearchRequest) ([]*domain.DriverWithDistance, error) {
 	args := m.Called(req)
 	return args.Get(0).([]*domain.DriverWithDistance), args.Error(1)
 }
 
-func (m *mockDriverService) GetDriver(id string) (*domain.Driver, error) {
+func (m *mockDriverService) SearchDriversWithin(_ context.Context, req domain.AreaSearchRequest) ([]*domain.Driver, error) {
+	args := m.Called(req)
+	return args.Get(0).([]*domain.Driver), args.Error(1)
+}
+func (m *mockDriverService) SearchDriversInBox(_ context.Context, req domain.BoxSearchRequest) (*domain.BoxSearchResult, error) {
+	args := m.Called(req)
+	return args.Get(0).(*domain.BoxSearchResult), args.Error(1)
+}
+func (m *mockDriverService) SearchDriversInCell(_ context.Context, req domain.CellSearchRequest) (*domain.CellSearchResult, error) {
+	args := m.Called(req)
+	return args.Get(0).(*domain.CellSearchResult), args.Error(1)
+}
+func (m *mockDriverService) GetDriver(_ context.Context, id string) (*domain.Driver, error) {
 	args := m.Called(id)
 	return args.Get(0).(*domain.Driver), args.Error(1)
 }
 
-func (m *mockDriverService) UpdateDriver(driver *domain.Driver) error {
+func (m *mockDriverService) ListDrivers(_ context.Context, req domain.ListDriversRequest) (*domain.DriverPage, error) {
+	args := m.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.DriverPage), args.Error(1)
+}
+
+func (m *mockDriverService) UpdateDriver(_ context.Context, driver *domain.Driver) error {
 	args := m.Called(driver)
 	return args.Error(0)
 }
 
-func (m *mockDriverService) UpdateDriverLocation(id string, location domain.Point) error {
-	args := m.Called(id, location)
+func (m *mockDriverService) UpdateDriverLocation(_ context.Context, id string, update domain.LocationUpdate) error {
+	args := m.Called(id, update)
 	return args.Error(0)
 }
 
-func (m *mockDriverService) DeleteDriver(id string) error {
+func (m *mockDriverService) UpdateDriverStatus(_ context.Context, id string, status string) error {
+	args := m.Called(id, status)
+	return args.Error(0)
+}
+
+func (m *mockDriverService) DeleteDriver(_ context.Context, id string) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
 
+func (m *mockDriverService) Heartbeat(_ context.Context, id string) (*domain.Heartbeat, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Heartbeat), args.Error(1)
+}
+
 func resetPrometheusRegistry() {
 	prometheus.DefaultRegisterer = prometheus.NewRegistry()
 }
@@ -100,6 +137,31 @@ func TestRouter_HealthCheck(t *testing.T) {
 	assert.Equal(t, "driver-location-service", data["service"])
 }
 
+// TestRouter_Version tests the build information endpoint
+// Expected: Should return 200 OK with the version, git SHA, build time and Go version of the binary
+func TestRouter_Version(t *testing.T) {
+	resetPrometheusRegistry()
+	router := NewRouter(new(mockDriverService), middleware.AuthConfig{MatchingAPIKey: "test-key"})
+
+	defer func(version string) { buildinfo.Version = version }(buildinfo.Version)
+	buildinfo.Version = "1.4.0"
+
+	rec := httptest.NewRecorder()
+	router.GetEcho().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/version", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response struct {
+		Success bool           `json:"success"`
+		Data    buildinfo.Info `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.True(t, response.Success)
+	assert.Equal(t, "1.4.0", response.Data.Version)
+	assert.NotEmpty(t, response.Data.GitSHA)
+	assert.NotEmpty(t, response.Data.BuildTime)
+	assert.Equal(t, runtime.Version(), response.Data.GoVersion)
+}
+
 // TestRouter_CreateDriver_Success tests successful driver creation endpoint
 // Expected: Should return 201 Created with driver data when request is valid
 func TestRouter_CreateDriver_Success(t *testing.T) {
@@ -345,7 +407,7 @@ func TestRouter_UpdateDriverLocation_Success(t *testing.T) {
 	c.SetParamNames("id")
 	c.SetParamValues("driver1")
 
-	mockService.On("UpdateDriverLocation", "driver1", mock.AnythingOfType("domain.Point")).Return(nil)
+	mockService.On("UpdateDriverLocation", "driver1", mock.AnythingOfType("domain.LocationUpdate")).Return(nil)
 
 	err := router.handler.UpdateDriverLocation(c)
 	assert.NoError(t, err)
@@ -426,6 +488,8 @@ func TestRouter_RoutesRegistration(t *testing.T) {
 		"GET /health",
 		"POST /api/v1/drivers",
 		"POST /api/v1/drivers/search",
+		"POST /api/v1/drivers/search/within",
+		"GET /api/v1/drivers/search/box",
 		"GET /api/v1/drivers/:id",
 		"PUT /api/v1/drivers/:id",
 		"PATCH /api/v1/drivers/:id/location",
@@ -478,3 +542,159 @@ func TestRouter_Start(t *testing.T) {
 
 	router.Shutdown()
 }
+
+type stubFlagService struct{}
+
+func (s *stubFlagService) IsEnabled(name, key string) bool { return true }
+func (s *stubFlagService) Flags() []domain.FeatureFlag {
+	return []domain.FeatureFlag{{Name: domain.FlagGeoNearSearch, Enabled: true}}
+}
+func (s *stubFlagService) Environment() string { return "staging" }
+
+// TestRouter_RateLimit tests the rate limit of the API key
+// Expected: Should answer 429 with Retry-After once the API key used up its burst
+func TestRouter_RateLimit(t *testing.T) {
+	resetPrometheusRegistry()
+	mockService := new(mockDriverService)
+	router := NewRouter(mockService, middleware.AuthConfig{MatchingAPIKey: "test-key"})
+	router.SetupRateLimit(middleware.RateLimitConfig{
+		Limiter: ratelimit.NewMemoryLimiter(),
+		Limit:   domain.RateLimit{Rate: 0.5, Burst: 1},
+	})
+	mockService.On("GetDriver", "d1").Return(&domain.Driver{ID: "d1", Location: domain.NewPoint(29, 41)}, nil).Once()
+
+	codes := make([]int, 2)
+	for i := range codes {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/drivers/d1", nil)
+		req.Header.Set("X-API-Key", "test-key")
+		rec := httptest.NewRecorder()
+		router.GetEcho().ServeHTTP(rec, req)
+		codes[i] = rec.Code
+		if rec.Code == http.StatusTooManyRequests {
+			assert.Equal(t, "2", rec.Header().Get("Retry-After"))
+		}
+	}
+	assert.Equal(t, []int{http.StatusOK, http.StatusTooManyRequests}, codes)
+	mockService.AssertExpectations(t)
+}
+
+// TestRouter_LowPriorityRoutes tests the routes shed under load
+// Expected: Should name registered routes only and none of the search path
+func TestRouter_LowPriorityRoutes(t *testing.T) {
+	resetPrometheusRegistry()
+	router := NewRouter(new(mockDriverService), middleware.AuthConfig{MatchingAPIKey: "test-key"})
+	router.SetupReconcileRoute(&ReconcileHandler{})
+	router.SetupDuplicateRoute(&DuplicateHandler{})
+
+	registered := map[string]bool{}
+	for _, route := range router.GetEcho().Routes() {
+		registered[route.Method+" "+route.Path] = true
+	}
+	for _, route := range lowPriorityRoutes {
+		assert.True(t, registered[route], "%s is not registered", route)
+	}
+	assert.NotContains(t, lowPriorityRoutes, "POST /api/v1/drivers/search")
+	assert.NotContains(t, lowPriorityRoutes, "GET /api/v1/drivers/:id")
+}
+
+// TestRouter_AdminFlags tests the feature flag admin endpoint
+// Expected: Should require the API key and return the flag state
+func TestRouter_AdminFlags(t *testing.T) {
+	resetPrometheusRegistry()
+	router := NewRouter(new(mockDriverService), middleware.AuthConfig{MatchingAPIKey: "test-key"})
+	router.SetupAdminRoutes(NewAdminHandler(&stubFlagService{}, &stubBackfillService{}))
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/flags", nil)
+	rec := httptest.NewRecorder()
+	router.GetEcho().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/admin/flags", nil)
+	req.Header.Set("X-API-Key", "test-key")
+	rec = httptest.NewRecorder()
+	router.GetEcho().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "geonear_search")
+	assert.Contains(t, rec.Body.String(), "staging")
+}
+
+// TestRouter_MetricsConsumerLabels tests the consumer labels on the request metrics
+// Expected: Should label requests with the authenticated API key ID and the known tenant
+func TestRouter_MetricsConsumerLabels(t *testing.T) {
+	resetPrometheusRegistry()
+	registry := prometheus.DefaultRegisterer.(*prometheus.Registry)
+	mockService := new(mockDriverService)
+	router := NewRouter(mockService, middleware.AuthConfig{MatchingAPIKey: "test-key", Tenants: []string{"acme"}})
+	mockService.On("GetDriver", "d1").Return(&domain.Driver{ID: "d1", Location: domain.NewPoint(29, 41)}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/drivers/d1", nil)
+	req.Header.Set("X-API-Key", "test-key")
+	req.Header.Set(middleware.TenantHeader, "acme")
+	rec := httptest.NewRecorder()
+	router.GetEcho().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	families, err := registry.Gather()
+	assert.NoError(t, err)
+
+	labels := map[string]string{}
+	for _, family := range families {
+		if family.GetName() != "driver_location_service_requests_total" {
+			continue
+		}
+		for _, label := range family.GetMetric()[0].GetLabel() {
+			labels[label.GetName()] = label.GetValue()
+		}
+	}
+	assert.Equal(t, middleware.APIKeyID("test-key"), labels["api_key_id"])
+	assert.Equal(t, "acme", labels["tenant"])
+}
+
+type stubBackfillService struct {
+	started []string
+}
+
+func (s *stubBackfillService) Jobs() []string { return []string{"driver_defaults"} }
+func (s *stubBackfillService) Start(job string) error {
+	if job != "driver_defaults" {
+		return domain.ErrBackfillNotFound
+	}
+	if len(s.started) > 0 {
+		return domain.ErrBackfillRunning
+	}
+	s.started = append(s.started, job)
+	return nil
+}
+func (s *stubBackfillService) Progress(job string) (domain.BackfillProgress, error) {
+	if job != "driver_defaults" {
+		return domain.BackfillProgress{}, domain.ErrBackfillNotFound
+	}
+	return domain.BackfillProgress{Job: job, Status: domain.BackfillRunning}, nil
+}
+
+// TestRouter_AdminBackfills tests the backfill admin endpoints
+// Expected: Should start known jobs once, report conflicts and unknown jobs
+func TestRouter_AdminBackfills(t *testing.T) {
+	resetPrometheusRegistry()
+	router := NewRouter(new(mockDriverService), middleware.AuthConfig{MatchingAPIKey: "test-key"})
+	backfills := &stubBackfillService{}
+	router.SetupAdminRoutes(NewAdminHandler(&stubFlagService{}, backfills))
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("X-API-Key", "test-key")
+		rec := httptest.NewRecorder()
+		router.GetEcho().ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusAccepted, serve(http.MethodPost, "/admin/backfills/driver_defaults").Code)
+	assert.Equal(t, []string{"driver_defaults"}, backfills.started)
+	assert.Equal(t, http.StatusConflict, serve(http.MethodPost, "/admin/backfills/driver_defaults").Code)
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodPost, "/admin/backfills/unknown").Code)
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, "/admin/backfills/unknown").Code)
+
+	rec := serve(http.MethodGet, "/admin/backfills")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"status":"running"`)
+}
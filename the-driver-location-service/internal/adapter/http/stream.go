@@ -0,0 +1,74 @@
+package http
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+
+	"github.com/labstack/echo/v4"
+
+	"the-driver-location-service/internal/domain"
+)
+
+// DefaultStreamThreshold is the number of drivers above which a driver list is
+// streamed to the client instead of encoded in one piece
+const DefaultStreamThreshold = 1000
+
+// streamBufferSize is how much of a streamed body is held before it is written
+const streamBufferSize = 32 << 10
+
+// SetStreamThreshold sets the number of drivers above which driver lists are
+// streamed, zero or less keeps DefaultStreamThreshold
+func (h *DriverHandler) SetStreamThreshold(threshold int) {
+	if threshold <= 0 {
+		threshold = DefaultStreamThreshold
+	}
+	h.streamThreshold = threshold
+}
+
+// driversResponse answers with the drivers and their count. Lists above the
+// stream threshold are written the way streamDrivers does, the client gets the
+// same body either way.
+func (h *DriverHandler) driversResponse(c echo.Context, statusCode int, drivers []*domain.Driver, message string) error {
+	if len(drivers) <= h.streamThreshold {
+		data := map[string]interface{}{
+			"drivers": drivers,
+			"count":   len(drivers),
+		}
+		return h.successResponse(c, statusCode, data, message)
+	}
+	return streamDrivers(c, statusCode, drivers, message)
+}
+
+// streamDrivers encodes the drivers one at a time straight to the client, so a
+// batch of 10k drivers is never held as a single JSON document. The status is
+// sent before the first driver is encoded, an encoding error can only cut the
+// body short.
+func streamDrivers(c echo.Context, statusCode int, drivers []*domain.Driver, message string) error {
+	encodedMessage, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	res.WriteHeader(statusCode)
+
+	w := bufio.NewWriterSize(res, streamBufferSize)
+	// the keys in the order encoding/json writes APIResponse and the data map
+	fmt.Fprintf(w, `{"success":true,"data":{"count":%d,"drivers":[`, len(drivers))
+	for i, driver := range drivers {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		encoded, err := json.Marshal(driver)
+		if err != nil {
+			return fmt.Errorf("failed to encode driver %s: %w", driver.ID, err)
+		}
+		if _, err := w.Write(encoded); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(w, `]},"message":%s}`+"\n", encodedMessage)
+	return w.Flush()
+}
@@ -0,0 +1,101 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"the-driver-location-service/config"
+	"the-driver-location-service/internal/domain"
+	"the-driver-location-service/internal/ports/secondary"
+)
+
+// ZapLogger writes the log entries with zap, JSON lines on stdout unless the
+// console format is asked for
+type ZapLogger struct {
+	logger *zap.SugaredLogger
+}
+
+var _ secondary.Logger = (*ZapLogger)(nil)
+
+func NewZapLogger(cfg config.LogConfig) (*ZapLogger, error) {
+	level, err := zapcore.ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, fmt.Errorf("invalid log level: %w", err)
+	}
+
+	zapConfig := zap.NewProductionConfig()
+	if cfg.Format == "console" {
+		zapConfig = zap.NewDevelopmentConfig()
+	}
+	zapConfig.Level = zap.NewAtomicLevelAt(level)
+	// the same time field the echo access log writes
+	zapConfig.EncoderConfig.TimeKey = "time"
+	zapConfig.EncoderConfig.EncodeTime = zapcore.RFC3339NanoTimeEncoder
+
+	logger, err := zapConfig.Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build logger: %w", err)
+	}
+	return NewZapLoggerFrom(logger), nil
+}
+
+// NewZapLoggerFrom writes the entries with an existing zap logger
+func NewZapLoggerFrom(logger *zap.Logger) *ZapLogger {
+	// the caller of interest is the one calling the port, not this adapter
+	return &ZapLogger{logger: logger.WithOptions(zap.AddCallerSkip(1)).Sugar()}
+}
+
+func (l *ZapLogger) Debug(ctx context.Context, msg string, keysAndValues ...any) {
+	l.logger.Debugw(msg, withContextFields(ctx, keysAndValues)...)
+}
+
+func (l *ZapLogger) Info(ctx context.Context, msg string, keysAndValues ...any) {
+	l.logger.Infow(msg, withContextFields(ctx, keysAndValues)...)
+}
+
+func (l *ZapLogger) Warn(ctx context.Context, msg string, keysAndValues ...any) {
+	l.logger.Warnw(msg, withContextFields(ctx, keysAndValues)...)
+}
+
+func (l *ZapLogger) Error(ctx context.Context, msg string, keysAndValues ...any) {
+	l.logger.Errorw(msg, withContextFields(ctx, keysAndValues)...)
+}
+
+// Fatal logs the entry and exits, only main gives up on the whole service
+func (l *ZapLogger) Fatal(ctx context.Context, msg string, keysAndValues ...any) {
+	l.logger.Fatalw(msg, withContextFields(ctx, keysAndValues)...)
+}
+
+// Sync flushes buffered entries, it is called before the service exits
+func (l *ZapLogger) Sync() error {
+	return l.logger.Sync()
+}
+
+// withContextFields puts the fields of the context before the ones of the
+// entry, a key the entry sets itself is not repeated from the context
+func withContextFields(ctx context.Context, keysAndValues []any) []any {
+	fields := domain.LogFields(ctx)
+	if len(fields) == 0 {
+		return keysAndValues
+	}
+
+	merged := make([]any, 0, len(fields)+len(keysAndValues))
+	for i := 0; i+1 < len(fields); i += 2 {
+		if !hasKey(keysAndValues, fields[i]) {
+			merged = append(merged, fields[i], fields[i+1])
+		}
+	}
+	return append(merged, keysAndValues...)
+}
+
+func hasKey(keysAndValues []any, key any) bool {
+	for i := 0; i < len(keysAndValues); i += 2 {
+		if keysAndValues[i] == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"the-driver-location-service/config"
+	"the-driver-location-service/internal/domain"
+)
+
+// TestZapLogger_ContextFields tests logging with a context carrying request fields
+// Expected: Should write the fields of the context once, before the fields of the entry, and drop entries below the level
+func TestZapLogger_ContextFields(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := NewZapLoggerFrom(zap.New(core))
+
+	ctx := domain.WithLogFields(context.Background(), "request_id", "req-1")
+	ctx = domain.WithLogFields(ctx, "driver_id", "driver-1")
+	logger.Warn(ctx, "failed to cache driver", "driver_id", "driver-1", "error", errors.New("redis down"))
+	logger.Debug(ctx, "cache hit")
+	logger.Info(context.Background(), "cache warmup finished", "drivers", 3)
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 2)
+	assert.Equal(t, zapcore.WarnLevel, entries[0].Level)
+	assert.Equal(t, "failed to cache driver", entries[0].Message)
+	assert.Len(t, entries[0].Context, 3)
+	assert.Equal(t, map[string]interface{}{
+		"request_id": "req-1",
+		"driver_id":  "driver-1",
+		"error":      "redis down",
+	}, entries[0].ContextMap())
+	assert.Equal(t, map[string]interface{}{"drivers": int64(3)}, entries[1].ContextMap())
+}
+
+// TestNewZapLogger tests building the logger from the configuration
+// Expected: Should accept the configured levels and formats and reject unknown levels
+func TestNewZapLogger(t *testing.T) {
+	for _, cfg := range []config.LogConfig{{}, {Level: "debug", Format: "console"}, {Level: "error", Format: "json"}} {
+		_, err := NewZapLogger(cfg)
+		assert.NoError(t, err, cfg)
+	}
+
+	_, err := NewZapLogger(config.LogConfig{Level: "verbose"})
+	assert.Error(t, err)
+}
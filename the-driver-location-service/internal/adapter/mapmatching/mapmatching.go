@@ -0,0 +1,29 @@
+package mapmatching
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"the-driver-location-service/config"
+	"the-driver-location-service/internal/ports/secondary"
+)
+
+// NewFromConfig returns the configured map matcher, or nil when map matching is disabled
+func NewFromConfig(cfg config.MapMatchingConfig) (secondary.MapMatcher, error) {
+	client := &http.Client{Timeout: cfg.Timeout}
+	if cfg.Timeout <= 0 {
+		client.Timeout = 2 * time.Second
+	}
+
+	switch cfg.Provider {
+	case "", "none":
+		return nil, nil
+	case "osrm":
+		return NewOSRMMatcher(cfg.URL, cfg.Profile, client), nil
+	case "valhalla":
+		return NewValhallaMatcher(cfg.URL, cfg.Profile, client), nil
+	default:
+		return nil, fmt.Errorf("unknown map matching provider: %s", cfg.Provider)
+	}
+}
@@ -0,0 +1,87 @@
+package mapmatching
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"the-driver-location-service/config"
+	"the-driver-location-service/internal/domain"
+)
+
+// TestOSRMMatcher_Match tests snapping a trace with the OSRM match service
+// Expected: Should request lon,lat pairs and keep raw points for null tracepoints
+func TestOSRMMatcher_Match(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/match/v1/driving/28.970000,41.010000;28.971000,41.011000", r.URL.Path)
+		w.Write([]byte(`{"code":"Ok","tracepoints":[null,{"location":[28.9712,41.0108]}]}`))
+	}))
+	defer server.Close()
+
+	trace := []domain.Point{domain.NewPoint(28.97, 41.01), domain.NewPoint(28.971, 41.011)}
+	snapped, err := NewOSRMMatcher(server.URL, "", server.Client()).Match(context.Background(), trace)
+	require.NoError(t, err)
+	require.Len(t, snapped, 2)
+	assert.Equal(t, trace[0], snapped[0])
+	assert.Equal(t, domain.NewPoint(28.9712, 41.0108), snapped[1])
+}
+
+// TestOSRMMatcher_Match_NoMatch tests an OSRM error response
+// Expected: Should return an error containing the OSRM code
+func TestOSRMMatcher_Match_NoMatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code":"NoMatch","message":"Could not match the trace."}`))
+	}))
+	defer server.Close()
+
+	_, err := NewOSRMMatcher(server.URL, "driving", server.Client()).Match(context.Background(), []domain.Point{domain.NewPoint(28.97, 41.01)})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "NoMatch")
+}
+
+// TestValhallaMatcher_Match tests snapping a trace with the Valhalla trace_attributes service
+// Expected: Should post the shape with map_snap and keep raw points that are unmatched
+func TestValhallaMatcher_Match(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/trace_attributes", r.URL.Path)
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "map_snap", body["shape_match"])
+		assert.Equal(t, "auto", body["costing"])
+		assert.Len(t, body["shape"], 2)
+		w.Write([]byte(`{"matched_points":[{"lat":41.0101,"lon":28.9701,"type":"matched"},{"lat":0,"lon":0,"type":"unmatched"}]}`))
+	}))
+	defer server.Close()
+
+	trace := []domain.Point{domain.NewPoint(28.97, 41.01), domain.NewPoint(28.971, 41.011)}
+	snapped, err := NewValhallaMatcher(server.URL, "", server.Client()).Match(context.Background(), trace)
+	require.NoError(t, err)
+	require.Len(t, snapped, 2)
+	assert.Equal(t, domain.NewPoint(28.9701, 41.0101), snapped[0])
+	assert.Equal(t, trace[1], snapped[1])
+}
+
+// TestNewFromConfig tests selecting a map matcher from configuration
+// Expected: Should return nil when disabled, the provider adapter otherwise and an error for unknown providers
+func TestNewFromConfig(t *testing.T) {
+	matcher, err := NewFromConfig(config.MapMatchingConfig{Provider: "none"})
+	require.NoError(t, err)
+	assert.Nil(t, matcher)
+
+	matcher, err = NewFromConfig(config.MapMatchingConfig{Provider: "osrm", URL: "http://osrm:5000"})
+	require.NoError(t, err)
+	assert.IsType(t, &OSRMMatcher{}, matcher)
+
+	matcher, err = NewFromConfig(config.MapMatchingConfig{Provider: "valhalla", URL: "http://valhalla:8002"})
+	require.NoError(t, err)
+	assert.IsType(t, &ValhallaMatcher{}, matcher)
+
+	_, err = NewFromConfig(config.MapMatchingConfig{Provider: "graphhopper"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,81 @@
+package mapmatching
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"the-driver-location-service/internal/domain"
+	"the-driver-location-service/internal/ports/secondary"
+)
+
+// OSRMMatcher uses the OSRM match service
+// http://project-osrm.org/docs/v5.24.0/api/#match-service
+type OSRMMatcher struct {
+	baseURL string
+	profile string
+	client  *http.Client
+}
+
+var _ secondary.MapMatcher = (*OSRMMatcher)(nil)
+
+func NewOSRMMatcher(baseURL, profile string, client *http.Client) *OSRMMatcher {
+	if profile == "" {
+		profile = "driving"
+	}
+	return &OSRMMatcher{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		profile: profile,
+		client:  client,
+	}
+}
+
+type osrmMatchResponse struct {
+	Code        string `json:"code"`
+	Message     string `json:"message"`
+	Tracepoints []*struct {
+		Location []float64 `json:"location"`
+	} `json:"tracepoints"`
+}
+
+func (m *OSRMMatcher) Match(ctx context.Context, trace []domain.Point) ([]domain.Point, error) {
+	coordinates := make([]string, len(trace))
+	for i, point := range trace {
+		coordinates[i] = fmt.Sprintf("%f,%f", point.Longitude(), point.Latitude())
+	}
+
+	endpoint := fmt.Sprintf("%s/match/v1/%s/%s?overview=false&gaps=ignore&tidy=true",
+		m.baseURL, url.PathEscape(m.profile), strings.Join(coordinates, ";"))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create osrm request: %w", err)
+	}
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("osrm request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body osrmMatchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode osrm response: %w", err)
+	}
+	if body.Code != "Ok" {
+		return nil, fmt.Errorf("osrm match failed: %s %s", body.Code, body.Message)
+	}
+
+	snapped := make([]domain.Point, len(trace))
+	copy(snapped, trace)
+	for i, tracepoint := range body.Tracepoints {
+		// tracepoints that were treated as outliers are null
+		if i < len(snapped) && tracepoint != nil && len(tracepoint.Location) == 2 {
+			snapped[i] = domain.NewPoint(tracepoint.Location[0], tracepoint.Location[1])
+		}
+	}
+	return snapped, nil
+}
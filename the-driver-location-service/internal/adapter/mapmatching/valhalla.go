@@ -0,0 +1,97 @@
+package mapmatching
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"the-driver-location-service/internal/domain"
+	"the-driver-location-service/internal/ports/secondary"
+)
+
+// ValhallaMatcher uses the Valhalla trace_attributes service with map_snap matching
+// https://valhalla.github.io/valhalla/api/map-matching/api-reference/
+type ValhallaMatcher struct {
+	baseURL string
+	costing string
+	client  *http.Client
+}
+
+var _ secondary.MapMatcher = (*ValhallaMatcher)(nil)
+
+func NewValhallaMatcher(baseURL, costing string, client *http.Client) *ValhallaMatcher {
+	if costing == "" {
+		costing = "auto"
+	}
+	return &ValhallaMatcher{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		costing: costing,
+		client:  client,
+	}
+}
+
+type valhallaPoint struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+type valhallaMatchedPoint struct {
+	Lat  float64 `json:"lat"`
+	Lon  float64 `json:"lon"`
+	Type string  `json:"type"` // matched, interpolated or unmatched
+}
+
+func (m *ValhallaMatcher) Match(ctx context.Context, trace []domain.Point) ([]domain.Point, error) {
+	shape := make([]valhallaPoint, len(trace))
+	for i, point := range trace {
+		shape[i] = valhallaPoint{Lat: point.Latitude(), Lon: point.Longitude()}
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"shape":       shape,
+		"costing":     m.costing,
+		"shape_match": "map_snap",
+		"filters": map[string]interface{}{
+			"attributes": []string{"matched.point", "matched.type"},
+			"action":     "include",
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode valhalla request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.baseURL+"/trace_attributes", bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create valhalla request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("valhalla request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("valhalla match failed with status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		MatchedPoints []valhallaMatchedPoint `json:"matched_points"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode valhalla response: %w", err)
+	}
+
+	snapped := make([]domain.Point, len(trace))
+	copy(snapped, trace)
+	for i, point := range body.MatchedPoints {
+		if i < len(snapped) && point.Type != "unmatched" {
+			snapped[i] = domain.NewPoint(point.Lon, point.Lat)
+		}
+	}
+	return snapped, nil
+}
@@ -1,23 +1,95 @@
 package middleware
 
 import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+
+	"the-driver-location-service/internal/domain"
+	"the-driver-location-service/internal/ports/secondary"
 )
 
 type AuthConfig struct {
-	MatchingAPIKey string `json:"matching_api_key"`
-	RequireAuth    bool   `json:"require_auth"`
+	MatchingAPIKey string   `json:"matching_api_key"`
+	RequireAuth    bool     `json:"require_auth"`
+	Tenants        []string `json:"tenants"` // known X-Tenant-ID values, used as metrics labels
+
+	// MatchingAPIKeyHash is the hex SHA-256 of the API key, it is used instead
+	// of MatchingAPIKey so the key itself does not have to be deployed
+	MatchingAPIKeyHash string `json:"-"`
+
+	// SigningSecret is shared with the callers that sign their requests, see
+	// RequestSignature. RequireSignature refuses requests with only an API key.
+	SigningSecret      string        `json:"-"`
+	RequireSignature   bool          `json:"require_signature"`
+	SignatureTolerance time.Duration `json:"signature_tolerance"`   // how far the signature timestamp may be from now, 5 minutes by default
+	MaxSignedBodyBytes int64         `json:"max_signed_body_bytes"` // largest body a signature is verified over, DefaultMaxSignedBodyBytes when 0
+
+	Logger secondary.Logger `json:"-"` // where rejected API keys are logged, nil discards them
+}
+
+// keyHash returns the SHA-256 of the accepted API key, false when none is set
+func (c AuthConfig) keyHash() ([]byte, bool) {
+	if hash := strings.TrimSpace(c.MatchingAPIKeyHash); hash != "" {
+		sum, err := hex.DecodeString(hash)
+		return sum, err == nil && len(sum) == sha256.Size
+	}
+	key := strings.TrimSpace(c.MatchingAPIKey)
+	if key == "" {
+		return nil, false
+	}
+	sum := sha256.Sum256([]byte(key))
+	return sum[:], true
+}
+
+// APIKeyIDContextKey holds the ID of the API key that authenticated the request
+const APIKeyIDContextKey = "api_key_id"
+
+// APIKeyID derives a short, non reversible ID from an API key so it can be
+// used in metrics and logs without leaking the key itself
+func APIKeyID(apiKey string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(apiKey)))
+	return hex.EncodeToString(sum[:4])
+}
+
+// ValidAPIKey reports whether the key is the one APIKeyAuthMiddleware accepts.
+// The hashes are compared in constant time, so neither the content nor the
+// length of the key can be guessed from how long a rejection takes.
+func ValidAPIKey(config AuthConfig, apiKey string) bool {
+	expected, ok := config.keyHash()
+	if !ok {
+		return false
+	}
+	sum := sha256.Sum256([]byte(strings.TrimSpace(apiKey)))
+	return subtle.ConstantTimeCompare(sum[:], expected) == 1
 }
 
 // Instead of using API key authentication, I could have alternatively
 // restricted access to the service at the network level.
 func APIKeyAuthMiddleware(config AuthConfig) echo.MiddlewareFunc {
+	logger := config.Logger
+	if logger == nil {
+		logger = secondary.NopLogger{}
+	}
+
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
+			if SignedRequest(c.Request().Context()) {
+				return next(c)
+			}
+			if config.RequireSignature {
+				return c.JSON(http.StatusUnauthorized, map[string]interface{}{
+					"error":   "unauthorized",
+					"message": "Request signature is required",
+				})
+			}
+
 			apiKey := c.Request().Header.Get("X-API-Key")
 			if apiKey == "" {
 				return c.JSON(http.StatusUnauthorized, map[string]interface{}{
@@ -26,26 +98,36 @@ func APIKeyAuthMiddleware(config AuthConfig) echo.MiddlewareFunc {
 				})
 			}
 
-			expectedKey := strings.TrimSpace(config.MatchingAPIKey)
-			if expectedKey == "" {
+			if _, ok := config.keyHash(); !ok {
 				return c.JSON(http.StatusUnauthorized, map[string]interface{}{
 					"error":   "unauthorized",
 					"message": "Server misconfiguration: API key is not set",
 				})
 			}
 
-			if strings.TrimSpace(apiKey) != expectedKey {
+			if !ValidAPIKey(config, apiKey) {
+				// the ID of the key tells a stale key from a probe without leaking either
+				logger.Warn(c.Request().Context(), "rejected API key", "api_key_id", APIKeyID(apiKey), "remote_ip", c.RealIP(), "path", c.Path())
 				return c.JSON(http.StatusUnauthorized, map[string]interface{}{
 					"error":   "unauthorized",
 					"message": "Invalid API key",
 				})
 			}
 
+			setAPIKeyID(c, APIKeyID(apiKey))
 			return next(c)
 		}
 	}
 }
 
+// setAPIKeyID records the key that authenticated the request, for the metrics
+// and as the key the feature flags of the request are rolled out by
+func setAPIKeyID(c echo.Context, id string) {
+	c.Set(APIKeyIDContextKey, id)
+	req := c.Request()
+	c.SetRequest(req.WithContext(domain.WithRolloutKey(req.Context(), id)))
+}
+
 func CORSMiddleware() echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
@@ -1,14 +1,70 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"the-driver-location-service/internal/domain"
+	"the-driver-location-service/internal/ports/secondary"
 )
 
+// recordingLogger keeps the fields of every warning
+type recordingLogger struct {
+	secondary.NopLogger
+	warnings [][]any
+}
+
+func (l *recordingLogger) Warn(ctx context.Context, msg string, keysAndValues ...any) {
+	l.warnings = append(l.warnings, keysAndValues)
+}
+
+func serveAPIKey(config AuthConfig, apiKey string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/drivers", nil)
+	req.Header.Set("X-API-Key", apiKey)
+	rec := httptest.NewRecorder()
+	h := APIKeyAuthMiddleware(config)(func(c echo.Context) error {
+		return c.String(http.StatusOK, "ok")
+	})
+	_ = h(echo.New().NewContext(req, rec))
+	return rec
+}
+
+// TestAPIKeyAuthMiddleware_KeyHash tests authentication against the hash of the API key
+// Expected: Should accept the key whose SHA-256 is configured and reject others, and report a malformed hash as a misconfiguration
+func TestAPIKeyAuthMiddleware_KeyHash(t *testing.T) {
+	config := AuthConfig{MatchingAPIKeyHash: "2bb80d537b1da3e38bd30361aa855686bde0eacd7162fef6a25fe97bf527a25b"}
+
+	assert.Equal(t, http.StatusOK, serveAPIKey(config, "secret").Code)
+	assert.Equal(t, http.StatusOK, serveAPIKey(config, " secret ").Code)
+	assert.Equal(t, http.StatusUnauthorized, serveAPIKey(config, "Secret").Code)
+
+	config.MatchingAPIKey = "other"
+	assert.Equal(t, http.StatusOK, serveAPIKey(config, "secret").Code, "the hash takes precedence over the key")
+	assert.Equal(t, http.StatusUnauthorized, serveAPIKey(config, "other").Code)
+
+	rec := serveAPIKey(AuthConfig{MatchingAPIKeyHash: "not-hex"}, "secret")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Contains(t, rec.Body.String(), "misconfiguration")
+}
+
+// TestAPIKeyAuthMiddleware_LogsRejectedKey tests the log entry of a rejected API key
+// Expected: Should log the ID of the key and never the key itself
+func TestAPIKeyAuthMiddleware_LogsRejectedKey(t *testing.T) {
+	logger := &recordingLogger{}
+	rec := serveAPIKey(AuthConfig{MatchingAPIKey: "secret", Logger: logger}, "stale-key")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	require.Len(t, logger.warnings, 1)
+	assert.Contains(t, logger.warnings[0], APIKeyID("stale-key"))
+	assert.NotContains(t, logger.warnings[0], "stale-key")
+}
+
 // TestAPIKeyAuthMiddleware_NoAPIKey tests authentication when no API key is provided
 // Expected: Should return 401 Unauthorized with "API key is required" message
 func TestAPIKeyAuthMiddleware_NoAPIKey(t *testing.T) {
@@ -390,3 +446,24 @@ func TestAPIKeyAuthMiddleware_WhitespaceConfigKey(t *testing.T) {
 	assert.Equal(t, http.StatusUnauthorized, rec.Code)
 	assert.Contains(t, rec.Body.String(), "Server misconfiguration")
 }
+
+// TestAPIKeyAuthMiddleware_SetsAPIKeyID tests authentication with the correct API key
+// Expected: Should call the next handler and store the API key ID in the context
+func TestAPIKeyAuthMiddleware_SetsAPIKeyID(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/drivers", nil)
+	req.Header.Set("X-API-Key", "secret")
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	mw := APIKeyAuthMiddleware(AuthConfig{MatchingAPIKey: "secret"})
+	h := mw(func(c echo.Context) error {
+		return c.String(http.StatusOK, "ok")
+	})
+
+	err := h(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, APIKeyID("secret"), c.Get(APIKeyIDContextKey))
+	assert.Equal(t, APIKeyID("secret"), domain.RolloutKeyFrom(c.Request().Context()))
+}
@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	DeprecationHeader = "Deprecation"
+	SunsetHeader      = "Sunset"
+
+	deprecationDateLayout = "2006-01-02"
+)
+
+// deprecatedRequestsTotal counts the calls of deprecated routes per API key, so
+// the consumers still calling a route can be told before its sunset
+var deprecatedRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "driver_location_service",
+	Name:      "deprecated_requests_total",
+	Help:      "Number of requests to deprecated routes by route and API key.",
+}, []string{"method", "route", "api_key_id"})
+
+// DeprecatedRoute is a route that is going away. Path is the route as it is
+// registered, e.g. /api/v1/drivers/:id, and Sunset is zero until the date the
+// route stops answering is known.
+type DeprecatedRoute struct {
+	Method       string
+	Path         string
+	DeprecatedAt time.Time
+	Sunset       time.Time
+	Link         string // migration guide, sent as a Link header
+}
+
+// ParseDeprecatedRoutes parses entries of the form
+// "METHOD PATH|DEPRECATED_AT[|SUNSET]" with dates as YYYY-MM-DD, link is the
+// migration guide of every route
+func ParseDeprecatedRoutes(entries []string, link string) ([]DeprecatedRoute, error) {
+	routes := make([]DeprecatedRoute, 0, len(entries))
+	for _, entry := range entries {
+		parts := strings.Split(entry, "|")
+		if len(parts) < 2 || len(parts) > 3 {
+			return nil, fmt.Errorf("invalid deprecated route %q: expected METHOD PATH|DEPRECATED_AT[|SUNSET]", entry)
+		}
+
+		method, path, ok := strings.Cut(strings.TrimSpace(parts[0]), " ")
+		path = strings.TrimSpace(path)
+		if !ok || method == "" || !strings.HasPrefix(path, "/") {
+			return nil, fmt.Errorf("invalid deprecated route %q: expected a method and a path", entry)
+		}
+
+		route := DeprecatedRoute{Method: strings.ToUpper(method), Path: path, Link: strings.TrimSpace(link)}
+		var err error
+		if route.DeprecatedAt, err = time.Parse(deprecationDateLayout, strings.TrimSpace(parts[1])); err != nil {
+			return nil, fmt.Errorf("invalid deprecation date of %q: %w", entry, err)
+		}
+		if len(parts) == 3 {
+			if route.Sunset, err = time.Parse(deprecationDateLayout, strings.TrimSpace(parts[2])); err != nil {
+				return nil, fmt.Errorf("invalid sunset date of %q: %w", entry, err)
+			}
+			if !route.Sunset.After(route.DeprecatedAt) {
+				return nil, fmt.Errorf("invalid deprecated route %q: sunset must be after the deprecation", entry)
+			}
+		}
+		routes = append(routes, route)
+	}
+	return routes, nil
+}
+
+// Deprecation marks the responses of deprecated routes with the Deprecation
+// header (RFC 9745), the Sunset header (RFC 8594) and a Link to the migration
+// guide, and counts their calls. It must run after routing so the registered
+// path of the request is known, every echo.Use middleware does.
+func Deprecation(routes []DeprecatedRoute) echo.MiddlewareFunc {
+	byRoute := make(map[string]DeprecatedRoute, len(routes))
+	for _, route := range routes {
+		byRoute[route.Method+" "+route.Path] = route
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			route, ok := byRoute[c.Request().Method+" "+c.Path()]
+			if !ok {
+				return next(c)
+			}
+
+			header := c.Response().Header()
+			header.Set(DeprecationHeader, "@"+strconv.FormatInt(route.DeprecatedAt.Unix(), 10))
+			if !route.Sunset.IsZero() {
+				header.Set(SunsetHeader, route.Sunset.UTC().Format(http.TimeFormat))
+			}
+			if route.Link != "" {
+				header.Add("Link", fmt.Sprintf("<%s>; rel=\"deprecation\"", route.Link))
+			}
+
+			err := next(c)
+
+			// the key is only known once the route's own auth middleware ran
+			keyID, _ := c.Get(APIKeyIDContextKey).(string)
+			if keyID == "" {
+				keyID = labelNone
+			}
+			deprecatedRequestsTotal.WithLabelValues(route.Method, route.Path, keyID).Inc()
+			return err
+		}
+	}
+}
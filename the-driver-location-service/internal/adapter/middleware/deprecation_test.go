@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseDeprecatedRoutes tests parsing the configured deprecated routes
+// Expected: Should parse the method, path and dates and reject malformed entries
+func TestParseDeprecatedRoutes(t *testing.T) {
+	routes, err := ParseDeprecatedRoutes([]string{
+		"get /api/v1/drivers/:id|2026-10-01|2027-01-31",
+		"POST /api/v1/drivers/search|2026-10-01",
+	}, "https://docs.example.com/v2")
+	require.NoError(t, err)
+	require.Len(t, routes, 2)
+	assert.Equal(t, DeprecatedRoute{
+		Method:       http.MethodGet,
+		Path:         "/api/v1/drivers/:id",
+		DeprecatedAt: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+		Sunset:       time.Date(2027, 1, 31, 0, 0, 0, 0, time.UTC),
+		Link:         "https://docs.example.com/v2",
+	}, routes[0])
+	assert.True(t, routes[1].Sunset.IsZero())
+
+	for _, entry := range []string{
+		"/api/v1/drivers|2026-10-01",
+		"GET /api/v1/drivers",
+		"GET /api/v1/drivers|10/01/2026",
+		"GET /api/v1/drivers|2026-10-01|2026-09-01",
+	} {
+		_, err := ParseDeprecatedRoutes([]string{entry}, "")
+		assert.Error(t, err, entry)
+	}
+}
+
+// TestDeprecation tests the responses and metrics of deprecated and current routes
+// Expected: Should add the deprecation headers and count the call per API key only on deprecated routes
+func TestDeprecation(t *testing.T) {
+	routes, err := ParseDeprecatedRoutes([]string{"GET /api/v1/drivers/:id|2026-10-01|2027-01-31"}, "https://docs.example.com/v2")
+	require.NoError(t, err)
+
+	e := echo.New()
+	e.Use(Deprecation(routes))
+	authenticated := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set(APIKeyIDContextKey, "k1")
+			return next(c)
+		}
+	}
+	ok := func(c echo.Context) error { return c.NoContent(http.StatusOK) }
+	e.GET("/api/v1/drivers/:id", ok, authenticated)
+	e.PUT("/api/v1/drivers/:id", ok, authenticated)
+
+	counter := deprecatedRequestsTotal.WithLabelValues(http.MethodGet, "/api/v1/drivers/:id", "k1")
+	before := counterValue(t, counter)
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/drivers/d1", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "@1790812800", w.Header().Get(DeprecationHeader))
+	assert.Equal(t, "Sun, 31 Jan 2027 00:00:00 GMT", w.Header().Get(SunsetHeader))
+	assert.Equal(t, `<https://docs.example.com/v2>; rel="deprecation"`, w.Header().Get("Link"))
+	assert.Equal(t, before+1, counterValue(t, counter))
+
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/api/v1/drivers/d1", nil))
+	assert.Empty(t, w.Header().Get(DeprecationHeader))
+	assert.Empty(t, w.Header().Get(SunsetHeader))
+}
+
+func counterValue(t *testing.T, counter prometheus.Counter) float64 {
+	t.Helper()
+	var metric dto.Metric
+	require.NoError(t, counter.Write(&metric))
+	return metric.GetCounter().GetValue()
+}
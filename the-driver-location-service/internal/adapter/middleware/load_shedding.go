@@ -0,0 +1,173 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"the-driver-location-service/internal/ports/secondary"
+)
+
+const (
+	// maxLatencySamples bounds the latencies kept, under heavy load the window
+	// holds the most recent ones
+	maxLatencySamples = 4096
+
+	// p99Interval is how often the p99 is computed again, not on every request
+	p99Interval = time.Second
+)
+
+var (
+	shedRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "driver_location_service",
+		Name:      "shed_requests_total",
+		Help:      "Number of low priority requests refused while the handler latency was over the threshold by route.",
+	}, []string{"method", "route"})
+
+	handlerLatencyP99 = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "driver_location_service",
+		Name:      "handler_latency_p99_seconds",
+		Help:      "p99 handler latency of the API routes over the load shedding window.",
+	})
+)
+
+// LoadSheddingConfig refuses the LowPriority routes, "METHOD PATH" as they are
+// registered, while the p99 handler latency of the API over Window is above
+// Threshold. The p99 of fewer than MinSamples requests is not trusted.
+type LoadSheddingConfig struct {
+	Threshold   time.Duration
+	Window      time.Duration
+	MinSamples  int
+	LowPriority []string
+	Logger      secondary.Logger // where shedding starts and stops, nil discards it
+}
+
+type latencySample struct {
+	at      time.Time
+	latency time.Duration
+}
+
+// LoadShedder measures the handler latency of the API routes and refuses the
+// low priority ones with 503 while it is too high, so exports and dashboards
+// give way to the searches of riders. Shedding stops once the p99 of the
+// requests still served is below the threshold again.
+type LoadShedder struct {
+	config      LoadSheddingConfig
+	lowPriority map[string]bool
+	logger      secondary.Logger
+	now         func() time.Time
+
+	mu         sync.Mutex
+	samples    []latencySample // ring buffer of the latest samples
+	next       int
+	p99        time.Duration
+	computedAt time.Time
+	shedding   bool
+}
+
+func NewLoadShedder(config LoadSheddingConfig) *LoadShedder {
+	lowPriority := make(map[string]bool, len(config.LowPriority))
+	for _, route := range config.LowPriority {
+		lowPriority[route] = true
+	}
+	logger := config.Logger
+	if logger == nil {
+		logger = secondary.NopLogger{}
+	}
+
+	return &LoadShedder{
+		config:      config,
+		lowPriority: lowPriority,
+		logger:      logger,
+		now:         time.Now,
+		samples:     make([]latencySample, 0, maxLatencySamples),
+	}
+}
+
+// Middleware refuses the low priority routes while shedding and measures the
+// others. Only the /api routes are measured, except for the location stream
+// that stays open for as long as its driver sends.
+func (s *LoadShedder) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			route := c.Request().Method + " " + c.Path()
+			if s.lowPriority[route] && s.Shedding() {
+				shedRequestsTotal.WithLabelValues(c.Request().Method, c.Path()).Inc()
+				c.Response().Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(s.config.Window.Seconds()))))
+				return c.JSON(http.StatusServiceUnavailable, map[string]interface{}{
+					"success": false,
+					"error":   "overloaded",
+					"message": "Service is overloaded, low priority requests are refused for now",
+				})
+			}
+
+			if !strings.HasPrefix(c.Path(), "/api/") || strings.HasSuffix(c.Path(), "/stream") {
+				return next(c)
+			}
+			start := time.Now()
+			err := next(c)
+			s.Observe(time.Since(start))
+			return err
+		}
+	}
+}
+
+// Observe records the latency of a handled request
+func (s *LoadShedder) Observe(latency time.Duration) {
+	sample := latencySample{at: s.now(), latency: latency}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.samples) < maxLatencySamples {
+		s.samples = append(s.samples, sample)
+	} else {
+		s.samples[s.next] = sample
+	}
+	s.next = (s.next + 1) % maxLatencySamples
+}
+
+// Shedding reports whether the low priority routes are refused, the p99 it
+// decides on is computed at most once per second
+func (s *LoadShedder) Shedding() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.computedAt) < p99Interval {
+		return s.shedding
+	}
+	s.computedAt = now
+
+	latencies := make([]time.Duration, 0, len(s.samples))
+	for _, sample := range s.samples {
+		if now.Sub(sample.at) <= s.config.Window {
+			latencies = append(latencies, sample.latency)
+		}
+	}
+	s.p99 = 0
+	if len(latencies) >= max(1, s.config.MinSamples) {
+		slices.Sort(latencies)
+		s.p99 = latencies[int(math.Ceil(float64(len(latencies))*0.99))-1]
+	}
+	handlerLatencyP99.Set(s.p99.Seconds())
+
+	shedding := s.p99 > s.config.Threshold
+	if shedding != s.shedding {
+		if shedding {
+			s.logger.Warn(context.Background(), "handler latency over the threshold, shedding low priority requests", "p99", s.p99, "threshold", s.config.Threshold, "samples", len(latencies))
+		} else {
+			s.logger.Info(context.Background(), "handler latency back under the threshold, serving low priority requests", "p99", s.p99, "threshold", s.config.Threshold)
+		}
+		s.shedding = shedding
+	}
+	return s.shedding
+}
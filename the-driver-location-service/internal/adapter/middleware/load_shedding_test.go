@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestLoadShedder(now *time.Time) (*LoadShedder, *echo.Echo) {
+	shedder := NewLoadShedder(LoadSheddingConfig{
+		Threshold:   200 * time.Millisecond,
+		Window:      10 * time.Second,
+		MinSamples:  10,
+		LowPriority: []string{"GET /api/v1/drivers"},
+	})
+	shedder.now = func() time.Time { return *now }
+
+	e := echo.New()
+	e.Use(shedder.Middleware())
+	ok := func(c echo.Context) error { return c.String(http.StatusOK, "ok") }
+	e.GET("/api/v1/drivers", ok)
+	e.POST("/api/v1/drivers/search", ok)
+	return shedder, e
+}
+
+func serveShed(e *echo.Echo, method, path string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+	return rec
+}
+
+// TestLoadShedder_Shedding tests refusing low priority routes while the p99 latency is over the threshold
+// Expected: Should answer 503 with Retry-After to low priority routes only, until the slow requests left the window
+func TestLoadShedder_Shedding(t *testing.T) {
+	now := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+	shedder, e := newTestLoadShedder(&now)
+
+	for i := 0; i < 20; i++ {
+		shedder.Observe(time.Second)
+	}
+	rec := serveShed(e, http.MethodGet, "/api/v1/drivers")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "10", rec.Header().Get("Retry-After"))
+	assert.Contains(t, rec.Body.String(), "overloaded")
+	assert.Equal(t, http.StatusOK, serveShed(e, http.MethodPost, "/api/v1/drivers/search").Code)
+
+	now = now.Add(11 * time.Second)
+	for i := 0; i < 20; i++ {
+		shedder.Observe(10 * time.Millisecond)
+	}
+	assert.Equal(t, http.StatusOK, serveShed(e, http.MethodGet, "/api/v1/drivers").Code)
+}
+
+// TestLoadShedder_P99 tests the p99 the shedding is decided on
+// Expected: Should ignore a slow tail under 1% and windows with fewer samples than the minimum
+func TestLoadShedder_P99(t *testing.T) {
+	now := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+	shedder, _ := newTestLoadShedder(&now)
+
+	for i := 0; i < 5; i++ {
+		shedder.Observe(time.Second)
+	}
+	assert.False(t, shedder.Shedding())
+
+	for i := 0; i < 995; i++ {
+		shedder.Observe(50 * time.Millisecond)
+	}
+	now = now.Add(p99Interval)
+	assert.False(t, shedder.Shedding())
+	assert.Equal(t, 50*time.Millisecond, shedder.p99)
+
+	for i := 0; i < 10; i++ {
+		shedder.Observe(time.Second)
+	}
+	now = now.Add(p99Interval)
+	assert.True(t, shedder.Shedding())
+}
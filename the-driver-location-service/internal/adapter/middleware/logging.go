@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"encoding/hex"
+
+	"github.com/labstack/echo/v4"
+
+	"the-driver-location-service/internal/domain"
+)
+
+// EndUserHeader carries the hash of the end user an internal caller acts for,
+// the matching service sends the hashed JWT subject of the rider so its calls
+// can be attributed without the token or the user ID leaving it
+const EndUserHeader = "X-End-User-Hash"
+
+// AccessLogFormat is the echo access log format with the end user hash added
+const AccessLogFormat = `{"time":"${time_rfc3339_nano}","id":"${id}","end_user":"${header:` + EndUserHeader + `}","remote_ip":"${remote_ip}",` +
+	`"host":"${host}","method":"${method}","uri":"${uri}","user_agent":"${user_agent}",` +
+	`"status":${status},"error":"${error}","latency":${latency},"latency_human":"${latency_human}"` +
+	`,"bytes_in":${bytes_in},"bytes_out":${bytes_out}}` + "\n"
+
+// RequestLogFields adds the request ID, the end user hash the caller sent and,
+// on driver routes, the driver ID to the context of the request, so every entry
+// logged while handling it can be traced back to the request. The request ID
+// is the X-Request-ID the caller sent, the matching service sends the ID of its
+// match request, or the one the RequestID middleware generated when it runs
+// first. An end user hash that is not a hex SHA-256 is dropped, before the
+// access log writes it.
+func RequestLogFields() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			var fields []any
+			requestID := c.Response().Header().Get(echo.HeaderXRequestID)
+			if requestID == "" {
+				requestID = c.Request().Header.Get(echo.HeaderXRequestID)
+			}
+			if requestID != "" {
+				fields = append(fields, "request_id", requestID)
+			}
+			if endUser := c.Request().Header.Get(EndUserHeader); endUser != "" {
+				if validEndUserHash(endUser) {
+					fields = append(fields, "end_user", endUser)
+				} else {
+					c.Request().Header.Del(EndUserHeader)
+				}
+			}
+			if driverID := c.Param("id"); driverID != "" {
+				fields = append(fields, "driver_id", driverID)
+			}
+
+			if len(fields) > 0 {
+				req := c.Request()
+				c.SetRequest(req.WithContext(domain.WithLogFields(req.Context(), fields...)))
+			}
+			return next(c)
+		}
+	}
+}
+
+func validEndUserHash(hash string) bool {
+	decoded, err := hex.DecodeString(hash)
+	return err == nil && len(decoded) == 32
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"the-driver-location-service/internal/domain"
+)
+
+// TestRequestLogFields tests the log fields added to requests with and without a driver in the path
+// Expected: Should add the request ID the caller sent or the generated one and the driver ID of driver routes only
+func TestRequestLogFields(t *testing.T) {
+	e := echo.New()
+	e.Use(RequestID())
+	e.Use(RequestLogFields())
+	var fields []any
+	handler := func(c echo.Context) error {
+		fields = domain.LogFields(c.Request().Context())
+		return c.NoContent(http.StatusOK)
+	}
+	e.GET("/api/v1/drivers/:id", handler)
+	e.GET("/health", handler)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/drivers/driver-1", nil)
+	req.Header.Set(echo.HeaderXRequestID, "correlation-1")
+	e.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, []any{"request_id", "correlation-1", "driver_id", "driver-1"}, fields)
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	generated := rec.Header().Get(echo.HeaderXRequestID)
+	require.NotEmpty(t, generated)
+	assert.Equal(t, []any{"request_id", generated}, fields)
+}
+
+// TestRequestLogFields_EndUser tests the end user hash internal callers send
+// Expected: Should add a hex SHA-256 to the log fields and drop any other value from the request
+func TestRequestLogFields_EndUser(t *testing.T) {
+	e := echo.New()
+	e.Use(RequestLogFields())
+	var fields []any
+	var header string
+	e.GET("/health", func(c echo.Context) error {
+		fields = domain.LogFields(c.Request().Context())
+		header = c.Request().Header.Get(EndUserHeader)
+		return c.NoContent(http.StatusOK)
+	})
+
+	hash := strings.Repeat("ab", 32)
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	req.Header.Set(EndUserHeader, hash)
+	e.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, []any{"end_user", hash}, fields)
+	assert.Equal(t, hash, header)
+
+	req = httptest.NewRequest(http.MethodGet, "/health", nil)
+	req.Header.Set(EndUserHeader, `rider-1","admin":"true`)
+	e.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Empty(t, fields)
+	assert.Empty(t, header)
+}
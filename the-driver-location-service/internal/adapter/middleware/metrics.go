@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/labstack/echo-contrib/echoprometheus"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	TenantHeader = "X-Tenant-ID"
+
+	labelNone  = "none"
+	labelOther = "other"
+)
+
+// MetricsLabelFuncs returns the consumer labels added to the request metrics.
+// Both labels have a bounded set of values: the API key ID is only set for keys
+// that passed authentication and tenants outside the configured list are
+// reported as "other", so a client cannot blow up the series count
+func MetricsLabelFuncs(config AuthConfig) map[string]echoprometheus.LabelValueFunc {
+	tenants := make(map[string]struct{}, len(config.Tenants))
+	for _, tenant := range config.Tenants {
+		if tenant = strings.TrimSpace(tenant); tenant != "" {
+			tenants[tenant] = struct{}{}
+		}
+	}
+
+	return map[string]echoprometheus.LabelValueFunc{
+		"api_key_id": func(c echo.Context, err error) string {
+			if id, ok := c.Get(APIKeyIDContextKey).(string); ok && id != "" {
+				return id
+			}
+			return labelNone
+		},
+		"tenant": func(c echo.Context, err error) string {
+			tenant := strings.TrimSpace(c.Request().Header.Get(TenantHeader))
+			if tenant == "" {
+				return labelNone
+			}
+			if _, ok := tenants[tenant]; ok {
+				return tenant
+			}
+			return labelOther
+		},
+	}
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMetricsLabelFuncs_APIKeyID tests the API key label
+// Expected: Should use the authenticated key ID and "none" for unauthenticated requests
+func TestMetricsLabelFuncs_APIKeyID(t *testing.T) {
+	labels := MetricsLabelFuncs(AuthConfig{})
+	e := echo.New()
+
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+	assert.Equal(t, "none", labels["api_key_id"](c, nil))
+
+	c.Set(APIKeyIDContextKey, APIKeyID("secret"))
+	assert.Equal(t, APIKeyID("secret"), labels["api_key_id"](c, nil))
+	assert.Len(t, APIKeyID("secret"), 8)
+	assert.NotEqual(t, APIKeyID("secret"), APIKeyID("other"))
+}
+
+// TestMetricsLabelFuncs_Tenant tests the tenant label
+// Expected: Should keep known tenants and collapse unknown ones into "other"
+func TestMetricsLabelFuncs_Tenant(t *testing.T) {
+	labels := MetricsLabelFuncs(AuthConfig{Tenants: []string{"acme"}})
+	e := echo.New()
+
+	tests := map[string]string{"": "none", "acme": "acme", "random-tenant": "other"}
+	for header, expected := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if header != "" {
+			req.Header.Set(TenantHeader, header)
+		}
+		c := e.NewContext(req, httptest.NewRecorder())
+		assert.Equal(t, expected, labels["tenant"](c, nil), "tenant header %q", header)
+	}
+}
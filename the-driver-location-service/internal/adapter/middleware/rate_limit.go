@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"the-driver-location-service/internal/domain"
+	"the-driver-location-service/internal/ports/secondary"
+)
+
+const (
+	RateLimitLimitHeader     = "X-RateLimit-Limit"
+	RateLimitRemainingHeader = "X-RateLimit-Remaining"
+)
+
+// rateLimitedRequestsTotal counts the requests refused with 429 per API key
+var rateLimitedRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "driver_location_service",
+	Name:      "rate_limited_requests_total",
+	Help:      "Number of requests refused by the rate limiter by API key.",
+}, []string{"api_key_id"})
+
+// RateLimitConfig limits the requests of every valid API key or signing secret,
+// requests without either are refused by APIKeyAuthMiddleware and not limited
+type RateLimitConfig struct {
+	Limiter secondary.RateLimiter
+	Limit   domain.RateLimit
+	Auth    AuthConfig
+	Logger  secondary.Logger // where limiter failures are logged, nil discards them
+}
+
+// authenticatedKeyID returns the ID of the key the request authenticates with,
+// a signed request is limited under the ID of the signing secret
+func authenticatedKeyID(c echo.Context, config AuthConfig) (string, bool) {
+	if SignedRequest(c.Request().Context()) {
+		return SigningKeyID(config.SigningSecret), true
+	}
+	apiKey := c.Request().Header.Get("X-API-Key")
+	if config.RequireSignature || !ValidAPIKey(config, apiKey) {
+		return "", false
+	}
+	return APIKeyID(apiKey), true
+}
+
+// RateLimit answers 429 with Retry-After once an API key used up its limit. The
+// requests pass when the limiter fails, an unavailable redis must not take the
+// API down with it.
+func RateLimit(config RateLimitConfig) echo.MiddlewareFunc {
+	logger := config.Logger
+	if logger == nil {
+		logger = secondary.NopLogger{}
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			keyID, ok := authenticatedKeyID(c, config.Auth)
+			if !ok {
+				return next(c)
+			}
+
+			decision, err := config.Limiter.Allow(c.Request().Context(), "api_key:"+keyID, config.Limit)
+			if err != nil {
+				logger.Warn(c.Request().Context(), "rate limiter failed, letting the request through", "error", err)
+				return next(c)
+			}
+
+			header := c.Response().Header()
+			header.Set(RateLimitLimitHeader, strconv.Itoa(config.Limit.Burst))
+			header.Set(RateLimitRemainingHeader, strconv.Itoa(decision.Remaining))
+			if decision.Allowed {
+				return next(c)
+			}
+
+			retryAfter := max(1, int(math.Ceil(decision.RetryAfter.Seconds())))
+			header.Set("Retry-After", strconv.Itoa(retryAfter))
+			rateLimitedRequestsTotal.WithLabelValues(keyID).Inc()
+			return c.JSON(http.StatusTooManyRequests, map[string]interface{}{
+				"error":   "rate_limited",
+				"message": "Too many requests, retry in " + strconv.Itoa(retryAfter) + "s",
+			})
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+
+	"the-driver-location-service/internal/domain"
+)
+
+type stubRateLimiter struct {
+	decision domain.RateLimitDecision
+	err      error
+	keys     []string
+}
+
+func (l *stubRateLimiter) Allow(ctx context.Context, key string, limit domain.RateLimit) (domain.RateLimitDecision, error) {
+	l.keys = append(l.keys, key)
+	return l.decision, l.err
+}
+
+func serveRateLimited(limiter *stubRateLimiter, apiKey string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/drivers/search", nil)
+	if apiKey != "" {
+		req.Header.Set("X-API-Key", apiKey)
+	}
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+
+	h := RateLimit(RateLimitConfig{
+		Limiter: limiter,
+		Limit:   domain.RateLimit{Rate: 50, Burst: 100},
+		Auth:    AuthConfig{MatchingAPIKey: "test-key"},
+	})(func(c echo.Context) error {
+		return c.String(http.StatusOK, "ok")
+	})
+	_ = h(c)
+	return rec
+}
+
+// TestRateLimit_Allowed tests a request within the limit of its API key
+// Expected: Should pass the request on, keyed by the API key ID, with the limit and the remaining requests in the headers
+func TestRateLimit_Allowed(t *testing.T) {
+	limiter := &stubRateLimiter{decision: domain.RateLimitDecision{Allowed: true, Remaining: 99}}
+	rec := serveRateLimited(limiter, "test-key")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, []string{"api_key:" + APIKeyID("test-key")}, limiter.keys)
+	assert.Equal(t, "100", rec.Header().Get(RateLimitLimitHeader))
+	assert.Equal(t, "99", rec.Header().Get(RateLimitRemainingHeader))
+	assert.Empty(t, rec.Header().Get("Retry-After"))
+}
+
+// TestRateLimit_Exceeded tests a request over the limit of its API key
+// Expected: Should return 429 with Retry-After rounded up to whole seconds
+func TestRateLimit_Exceeded(t *testing.T) {
+	limiter := &stubRateLimiter{decision: domain.RateLimitDecision{RetryAfter: 20 * time.Millisecond}}
+	rec := serveRateLimited(limiter, "test-key")
+
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+	assert.Contains(t, rec.Body.String(), "rate_limited")
+}
+
+// TestRateLimit_Unlimited tests requests the rate limit does not take from
+// Expected: Should let requests without a valid API key and requests the limiter failed on through
+func TestRateLimit_Unlimited(t *testing.T) {
+	limiter := &stubRateLimiter{}
+	assert.Equal(t, http.StatusOK, serveRateLimited(limiter, "").Code)
+	assert.Equal(t, http.StatusOK, serveRateLimited(limiter, "wrong-key").Code)
+	assert.Empty(t, limiter.keys)
+
+	limiter = &stubRateLimiter{err: errors.New("connection refused")}
+	rec := serveRateLimited(limiter, "test-key")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get(RateLimitLimitHeader))
+}
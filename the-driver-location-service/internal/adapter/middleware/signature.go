@@ -0,0 +1,147 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"the-driver-location-service/internal/ports/secondary"
+)
+
+const (
+	SignatureHeader          = "X-Signature"
+	SignatureTimestampHeader = "X-Signature-Timestamp"
+
+	// signatureVersion prefixes the signature, a new scheme gets a new version
+	// so both can be accepted while callers move over
+	signatureVersion = "v1="
+
+	defaultSignatureTolerance = 5 * time.Minute
+
+	// DefaultMaxSignedBodyBytes bounds the body read to verify a signature, the
+	// check runs before authentication so anyone can make the service read it
+	DefaultMaxSignedBodyBytes = 8 << 20
+)
+
+var (
+	errSignatureMalformed = errors.New("malformed request signature")
+	errSignatureExpired   = errors.New("request signature timestamp is outside the tolerance")
+	errSignatureMismatch  = errors.New("request signature does not match")
+	errSignedBodyTooLarge = errors.New("signed request body is too large")
+)
+
+type signedRequestKey struct{}
+
+// SignedRequest reports whether the request of ctx carried a valid signature
+func SignedRequest(ctx context.Context) bool {
+	signed, _ := ctx.Value(signedRequestKey{}).(bool)
+	return signed
+}
+
+// SigningKeyID is the ID signed requests are counted and rate limited under,
+// it is derived from the secret the way APIKeyID is derived from a key
+func SigningKeyID(secret string) string {
+	return "hmac-" + APIKeyID(secret)
+}
+
+// SignRequest computes the X-Signature value of a request: the hex HMAC-SHA256
+// with the shared secret of the timestamp, the method, the path with its query
+// and the body, separated by newlines
+func SignRequest(secret string, timestamp int64, method, requestURI string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "\n" + method + "\n" + requestURI + "\n"))
+	mac.Write(body)
+	return signatureVersion + hex.EncodeToString(mac.Sum(nil))
+}
+
+// verifySignature checks the signature headers of req against the secret. The
+// body is read to be signed and restored for the handlers after it.
+func verifySignature(config AuthConfig, req *http.Request, now time.Time) error {
+	timestamp, err := strconv.ParseInt(req.Header.Get(SignatureTimestampHeader), 10, 64)
+	if err != nil {
+		return errSignatureMalformed
+	}
+	tolerance := config.SignatureTolerance
+	if tolerance <= 0 {
+		tolerance = defaultSignatureTolerance
+	}
+	// the timestamp bounds how long a captured request can be replayed
+	if skew := now.Sub(time.Unix(timestamp, 0)); skew > tolerance || skew < -tolerance {
+		return errSignatureExpired
+	}
+
+	limit := config.MaxSignedBodyBytes
+	if limit <= 0 {
+		limit = DefaultMaxSignedBodyBytes
+	}
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err = io.ReadAll(http.MaxBytesReader(nil, req.Body, limit))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return errSignedBodyTooLarge
+		}
+		if err != nil {
+			return err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	expected := SignRequest(config.SigningSecret, timestamp, req.Method, req.URL.RequestURI(), body)
+	if !hmac.Equal([]byte(strings.TrimSpace(req.Header.Get(SignatureHeader))), []byte(expected)) {
+		return errSignatureMismatch
+	}
+	return nil
+}
+
+// RequestSignature authenticates the requests signed with the shared secret.
+// It runs before every other middleware, so the rate limiter, the request
+// validation and APIKeyAuthMiddleware accept the signed request without an API
+// key. Unsigned requests are passed on, APIKeyAuthMiddleware refuses them when
+// signatures are required. Bodies above MaxSignedBodyBytes are answered 413
+// without being read to the end.
+func RequestSignature(config AuthConfig) echo.MiddlewareFunc {
+	logger := config.Logger
+	if logger == nil {
+		logger = secondary.NopLogger{}
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			if config.SigningSecret == "" || req.Header.Get(SignatureHeader) == "" {
+				return next(c)
+			}
+
+			err := verifySignature(config, req, time.Now())
+			if errors.Is(err, errSignedBodyTooLarge) {
+				logger.Warn(req.Context(), "rejected request signature", "reason", err, "remote_ip", c.RealIP(), "path", req.URL.Path)
+				return c.JSON(http.StatusRequestEntityTooLarge, map[string]interface{}{
+					"error":   "request_too_large",
+					"message": "Request body is too large",
+				})
+			}
+			if err != nil {
+				logger.Warn(req.Context(), "rejected request signature", "reason", err, "remote_ip", c.RealIP(), "path", req.URL.Path)
+				return c.JSON(http.StatusUnauthorized, map[string]interface{}{
+					"error":   "unauthorized",
+					"message": "Invalid request signature",
+				})
+			}
+
+			c.SetRequest(req.WithContext(context.WithValue(req.Context(), signedRequestKey{}, true)))
+			setAPIKeyID(c, SigningKeyID(config.SigningSecret))
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// serveSigned runs the request through RequestSignature and APIKeyAuthMiddleware
+// the way the router chains them, the handler echoes the body it reads
+func serveSigned(config AuthConfig, req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h := RequestSignature(config)(APIKeyAuthMiddleware(config)(func(c echo.Context) error {
+		body, _ := io.ReadAll(c.Request().Body)
+		return c.String(http.StatusOK, string(body)+" "+c.Get(APIKeyIDContextKey).(string))
+	}))
+	_ = h(echo.New().NewContext(req, rec))
+	return rec
+}
+
+func signedRequest(secret string, timestamp time.Time, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/drivers/search?limit=5", strings.NewReader(body))
+	ts := timestamp.Unix()
+	req.Header.Set(SignatureTimestampHeader, strconv.FormatInt(ts, 10))
+	req.Header.Set(SignatureHeader, SignRequest(secret, ts, req.Method, req.URL.RequestURI(), []byte(body)))
+	return req
+}
+
+// TestRequestSignature tests authenticating requests signed with the shared secret
+// Expected: Should accept a valid signature without an API key and hand the body on, and reject tampered, expired or foreign signatures
+func TestRequestSignature(t *testing.T) {
+	config := AuthConfig{MatchingAPIKey: "api-key", SigningSecret: "shared-secret"}
+	body := `{"latitude":41,"longitude":29}`
+
+	rec := serveSigned(config, signedRequest("shared-secret", time.Now(), body))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, body+" "+SigningKeyID("shared-secret"), rec.Body.String())
+
+	tampered := signedRequest("shared-secret", time.Now(), body)
+	tampered.Body = io.NopCloser(strings.NewReader(`{"latitude":0,"longitude":0}`))
+	assert.Equal(t, http.StatusUnauthorized, serveSigned(config, tampered).Code)
+
+	expired := signedRequest("shared-secret", time.Now().Add(-10*time.Minute), body)
+	assert.Equal(t, http.StatusUnauthorized, serveSigned(config, expired).Code)
+
+	rec = serveSigned(config, signedRequest("other-secret", time.Now(), body))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Invalid request signature")
+
+	config.SignatureTolerance = 15 * time.Minute
+	assert.Equal(t, http.StatusOK, serveSigned(config, expired).Code)
+}
+
+// TestRequestSignature_BodyLimit tests signed requests with a body above MaxSignedBodyBytes
+// Expected: Should answer 413 without reading the body to the end and accept bodies up to the limit
+func TestRequestSignature_BodyLimit(t *testing.T) {
+	config := AuthConfig{MatchingAPIKey: "api-key", SigningSecret: "shared-secret", MaxSignedBodyBytes: 16}
+
+	rec := serveSigned(config, signedRequest("shared-secret", time.Now(), `{"radius":500}`))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// an unauthenticated caller only has to send the header
+	req := signedRequest("other-secret", time.Now(), "")
+	body := &countingReader{Reader: strings.NewReader(strings.Repeat("x", 1<<20))}
+	req.Body = io.NopCloser(body)
+	rec = serveSigned(config, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	assert.Contains(t, rec.Body.String(), "request_too_large")
+	assert.Less(t, body.read, 1<<20)
+}
+
+// countingReader counts the bytes read from it
+type countingReader struct {
+	io.Reader
+	read int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.read += n
+	return n, err
+}
+
+// TestRequestSignature_Required tests refusing requests that only carry the API key
+// Expected: Should accept the API key while signatures are optional and refuse it once they are required
+func TestRequestSignature_Required(t *testing.T) {
+	config := AuthConfig{MatchingAPIKey: "api-key", SigningSecret: "shared-secret"}
+	req := func() *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/drivers", nil)
+		req.Header.Set("X-API-Key", "api-key")
+		return req
+	}
+
+	assert.Equal(t, http.StatusOK, serveSigned(config, req()).Code)
+
+	config.RequireSignature = true
+	rec := serveSigned(config, req())
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Request signature is required")
+	assert.Equal(t, http.StatusOK, serveSigned(config, signedRequest("shared-secret", time.Now(), "")).Code)
+}
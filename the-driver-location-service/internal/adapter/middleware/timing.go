@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"the-driver-location-service/internal/domain"
+)
+
+// DebugTimingHeader asks for the timing breakdown of the request in the meta
+// of the response, e.g. X-Debug-Timing: true
+const DebugTimingHeader = "X-Debug-Timing"
+
+// DebugTiming records the time the request spends in Redis and MongoDB when
+// the caller sent DebugTimingHeader, the JSON codec writes it to the response.
+// Requests without the header record nothing.
+func DebugTiming() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if enabled, _ := strconv.ParseBool(c.Request().Header.Get(DebugTimingHeader)); enabled {
+				req := c.Request()
+				c.SetRequest(req.WithContext(domain.WithTiming(req.Context())))
+			}
+			return next(c)
+		}
+	}
+}
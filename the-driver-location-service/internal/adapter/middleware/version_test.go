@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"the-driver-location-service/internal/buildinfo"
+)
+
+// TestServiceVersion tests the version header of the responses
+// Expected: Should answer with the version the binary was built with
+func TestServiceVersion(t *testing.T) {
+	defer func(version string) { buildinfo.Version = version }(buildinfo.Version)
+	buildinfo.Version = "1.4.0"
+
+	rec := httptest.NewRecorder()
+	h := ServiceVersion()(func(c echo.Context) error { return c.NoContent(http.StatusNoContent) })
+	require.NoError(t, h(echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/health", nil), rec)))
+	assert.Equal(t, "1.4.0", rec.Header().Get(ServiceVersionHeader))
+}
+
+// TestBuildLabels tests the build of the binary in the metrics
+// Expected: Should export a build_info gauge of 1 and add the version and commit to the request metrics
+func TestBuildLabels(t *testing.T) {
+	families, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+	var labels map[string]string
+	for _, family := range families {
+		if family.GetName() != "driver_location_service_build_info" {
+			continue
+		}
+		require.Len(t, family.GetMetric(), 1)
+		assert.Equal(t, 1.0, family.GetMetric()[0].GetGauge().GetValue())
+		labels = make(map[string]string)
+		for _, label := range family.GetMetric()[0].GetLabel() {
+			labels[label.GetName()] = label.GetValue()
+		}
+	}
+	require.NotNil(t, labels, "build_info is not registered")
+	assert.Equal(t, buildinfo.Get().Version, labels["version"])
+	assert.Equal(t, buildinfo.Get().GoVersion, labels["go_version"])
+
+	info := buildinfo.Get()
+	expected := prometheus.Labels{"version": info.Version, "git_sha": info.GitSHA}
+	assert.Equal(t, expected, HistogramBuildLabels(prometheus.HistogramOpts{Name: "request_duration_seconds"}).ConstLabels)
+	assert.Equal(t, expected, CounterBuildLabels(prometheus.CounterOpts{Name: "requests_total"}).ConstLabels)
+}
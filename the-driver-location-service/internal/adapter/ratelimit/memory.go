@@ -0,0 +1,79 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"the-driver-location-service/internal/domain"
+	"the-driver-location-service/internal/ports/secondary"
+)
+
+// sweepInterval is how often Allow drops the buckets that refilled
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	full    time.Time // when the bucket is back to its burst, it can be dropped then
+}
+
+// MemoryLimiter keeps the buckets of this instance, an API key spreading its
+// requests over several instances gets the limit of each
+type MemoryLimiter struct {
+	now func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	nextSweep time.Time
+}
+
+var _ secondary.RateLimiter = (*MemoryLimiter)(nil)
+
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{
+		now:     time.Now,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+func (l *MemoryLimiter) Allow(ctx context.Context, key string, limit domain.RateLimit) (domain.RateLimitDecision, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updated: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+now.Sub(b.updated).Seconds()*limit.Rate)
+	b.updated = now
+	decision := take(&b.tokens, limit)
+	b.full = now.Add(time.Duration((float64(limit.Burst) - b.tokens) / limit.Rate * float64(time.Second)))
+	return decision, nil
+}
+
+// take takes a token when there is one, or tells how long until there is
+func take(tokens *float64, limit domain.RateLimit) domain.RateLimitDecision {
+	if *tokens >= 1 {
+		*tokens--
+		return domain.RateLimitDecision{Allowed: true, Remaining: int(*tokens)}
+	}
+	return domain.RateLimitDecision{RetryAfter: time.Duration((1 - *tokens) / limit.Rate * float64(time.Second))}
+}
+
+func (l *MemoryLimiter) sweep(now time.Time) {
+	if now.Before(l.nextSweep) {
+		return
+	}
+	for key, b := range l.buckets {
+		if !now.Before(b.full) {
+			delete(l.buckets, key)
+		}
+	}
+	l.nextSweep = now.Add(sweepInterval)
+}
@@ -0,0 +1,67 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	"the-driver-location-service/internal/domain"
+)
+
+func setupRedisLimiter(t *testing.T) (*RedisLimiter, *redis.Client) {
+	t.Helper()
+	ctx := context.Background()
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        "redis:7",
+			ExposedPorts: []string{"6379/tcp"},
+			WaitingFor:   wait.ForListeningPort("6379/tcp").WithStartupTimeout(20 * time.Second),
+		},
+		Started: true,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { container.Terminate(ctx) })
+
+	host, err := container.Host(ctx)
+	require.NoError(t, err)
+	port, err := container.MappedPort(ctx, "6379")
+	require.NoError(t, err)
+
+	client := redis.NewClient(&redis.Options{Addr: fmt.Sprintf("%s:%s", host, port.Port())})
+	t.Cleanup(func() { client.Close() })
+	require.NoError(t, client.Ping(ctx).Err())
+	return NewRedisLimiter(client), client
+}
+
+// TestRedisLimiter_Allow tests taking tokens from a bucket shared in redis
+// Expected: Should allow the burst, refuse the next request with the time until a token refilled and expire the bucket
+func TestRedisLimiter_Allow(t *testing.T) {
+	limiter, client := setupRedisLimiter(t)
+	ctx := context.Background()
+	limit := domain.RateLimit{Rate: 1, Burst: 2}
+
+	for remaining := 1; remaining >= 0; remaining-- {
+		decision, err := limiter.Allow(ctx, "api_key:1f2e3d4c", limit)
+		require.NoError(t, err)
+		assert.True(t, decision.Allowed)
+		assert.Equal(t, remaining, decision.Remaining)
+	}
+
+	decision, err := limiter.Allow(ctx, "api_key:1f2e3d4c", limit)
+	require.NoError(t, err)
+	assert.False(t, decision.Allowed)
+	assert.Greater(t, decision.RetryAfter, time.Duration(0))
+	assert.LessOrEqual(t, decision.RetryAfter, time.Second)
+
+	ttl, err := client.PTTL(ctx, keyPrefix+"api_key:1f2e3d4c").Result()
+	require.NoError(t, err)
+	assert.Greater(t, ttl, time.Duration(0))
+	assert.LessOrEqual(t, ttl, 3*time.Second)
+}
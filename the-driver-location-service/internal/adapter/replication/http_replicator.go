@@ -0,0 +1,225 @@
+package replication
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"the-driver-location-service/internal/domain"
+	"the-driver-location-service/internal/ports/secondary"
+)
+
+var ErrReplicatorClosed = errors.New("driver replicator is closed")
+
+// replicationPath is the route of the standby applying the batches
+const replicationPath = "/admin/replication/drivers"
+
+var droppedChangesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "driver_location_service_replication_dropped_changes_total",
+	Help: "Driver changes that never reached the standby region, left to the reconciliation job",
+}, []string{"reason"}) // queue_full or delivery_failed
+
+// Config controls batching and retries. A batch is sent when it holds BatchSize
+// changes or FlushInterval passed since the last send, a failed delivery is
+// retried MaxRetries times with a backoff that doubles from RetryBackoff.
+type Config struct {
+	TargetURL     string // base URL of the driver location service of the standby region
+	APIKey        string
+	BatchSize     int
+	QueueSize     int
+	FlushInterval time.Duration
+	MaxRetries    int
+	RetryBackoff  time.Duration
+	Timeout       time.Duration
+	Logger        secondary.Logger // dropped changes are logged, nil discards the entries
+}
+
+// HTTPReplicator posts driver changes to the driver location service of the
+// standby region. Changes are queued and sent in batches from a single
+// goroutine, so a slow or unreachable standby never delays a write of the
+// primary. A full queue drops the change instead of blocking, the
+// reconciliation job catches the standby up.
+type HTTPReplicator struct {
+	config Config
+	client *http.Client
+	queue  chan domain.DriverChange
+	stop   chan struct{}
+	done   chan struct{}
+	once   sync.Once
+}
+
+var _ secondary.DriverReplicator = (*HTTPReplicator)(nil)
+
+func NewHTTPReplicator(config Config) *HTTPReplicator {
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = config.BatchSize * 100
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = time.Second
+	}
+	if config.RetryBackoff <= 0 {
+		config.RetryBackoff = time.Second
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 5 * time.Second
+	}
+	if config.Logger == nil {
+		config.Logger = secondary.NopLogger{}
+	}
+	config.TargetURL = strings.TrimRight(config.TargetURL, "/")
+
+	r := &HTTPReplicator{
+		config: config,
+		client: &http.Client{Timeout: config.Timeout},
+		queue:  make(chan domain.DriverChange, config.QueueSize),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go r.run()
+	return r
+}
+
+func (r *HTTPReplicator) Replicate(ctx context.Context, changes ...domain.DriverChange) error {
+	select {
+	case <-r.stop:
+		return ErrReplicatorClosed
+	default:
+	}
+
+	for i, change := range changes {
+		select {
+		case r.queue <- change:
+		default:
+			dropped := len(changes) - i
+			droppedChangesTotal.WithLabelValues("queue_full").Add(float64(dropped))
+			return fmt.Errorf("replication queue is full, dropped %d changes", dropped)
+		}
+	}
+	return nil
+}
+
+// Send posts the batch right away, it is retried like the queued changes
+func (r *HTTPReplicator) Send(ctx context.Context, batch domain.ReplicationBatch) (*domain.ReplicationResult, error) {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode replication batch: %w", err)
+	}
+	return r.deliver(ctx, body)
+}
+
+// Close sends the queued changes and waits until they are delivered or dropped
+func (r *HTTPReplicator) Close() error {
+	r.once.Do(func() { close(r.stop) })
+	<-r.done
+	return nil
+}
+
+func (r *HTTPReplicator) run() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]domain.DriverChange, 0, r.config.BatchSize)
+	flush := func() {
+		if len(batch) > 0 {
+			r.send(batch)
+			batch = make([]domain.DriverChange, 0, r.config.BatchSize)
+		}
+	}
+
+	for {
+		select {
+		case change := <-r.queue:
+			batch = append(batch, change)
+			if len(batch) >= r.config.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-r.stop:
+			for {
+				select {
+				case change := <-r.queue:
+					batch = append(batch, change)
+					if len(batch) >= r.config.BatchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+func (r *HTTPReplicator) send(batch []domain.DriverChange) {
+	ctx := context.Background()
+	if _, err := r.Send(ctx, domain.ReplicationBatch{Changes: batch}); err != nil {
+		droppedChangesTotal.WithLabelValues("delivery_failed").Add(float64(len(batch)))
+		r.config.Logger.Warn(ctx, "dropped driver changes for the standby region", "changes", len(batch), "error", err)
+	}
+}
+
+func (r *HTTPReplicator) deliver(ctx context.Context, body []byte) (*domain.ReplicationResult, error) {
+	backoff := r.config.RetryBackoff
+	for attempt := 1; ; attempt++ {
+		result, retry, err := r.post(ctx, body, attempt)
+		if err == nil || !retry || attempt > r.config.MaxRetries {
+			return result, err
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		backoff *= 2
+	}
+}
+
+// post reports whether a failed delivery is worth retrying, client errors other
+// than 429 mean the standby rejected the batch and will do so again. The
+// standby answers 503 while it is read-only, the batch is retried.
+func (r *HTTPReplicator) post(ctx context.Context, body []byte, attempt int) (*domain.ReplicationResult, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.config.TargetURL+replicationPath, bytes.NewReader(body))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to create replication request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", r.config.APIKey)
+	req.Header.Set("X-Delivery-Attempt", strconv.Itoa(attempt))
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, true, fmt.Errorf("replication request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+		return nil, retry, fmt.Errorf("standby returned status %d", resp.StatusCode)
+	}
+
+	var response struct {
+		Data domain.ReplicationResult `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, false, fmt.Errorf("failed to decode replication response: %w", err)
+	}
+	return &response.Data, false, nil
+}
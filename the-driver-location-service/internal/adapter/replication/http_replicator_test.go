@@ -0,0 +1,143 @@
+package replication
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"the-driver-location-service/internal/domain"
+)
+
+type standby struct {
+	mu       sync.Mutex
+	statuses []int // response status per request, 200 once they run out
+	apiKeys  []string
+	batches  []domain.ReplicationBatch
+}
+
+func (s *standby) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.apiKeys = append(s.apiKeys, r.Header.Get("X-API-Key"))
+	status := http.StatusOK
+	if len(s.statuses) > 0 {
+		status, s.statuses = s.statuses[0], s.statuses[1:]
+	}
+	if status != http.StatusOK || r.URL.Path != replicationPath {
+		w.WriteHeader(status)
+		return
+	}
+
+	var batch domain.ReplicationBatch
+	if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	s.batches = append(s.batches, batch)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    domain.ReplicationResult{Applied: len(batch.Changes)},
+	})
+}
+
+func newTestReplicator(t *testing.T, s *standby, config Config) *HTTPReplicator {
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+
+	config.TargetURL = server.URL + "/"
+	config.APIKey = "standby-key"
+	if config.FlushInterval == 0 {
+		config.FlushInterval = time.Hour
+	}
+	config.RetryBackoff = time.Millisecond
+	return NewHTTPReplicator(config)
+}
+
+func upserts(ids ...string) []domain.DriverChange {
+	changes := make([]domain.DriverChange, len(ids))
+	for i, id := range ids {
+		changes[i] = domain.NewDriverUpsert(&domain.Driver{
+			ID:        id,
+			Location:  domain.NewPoint(29, 41),
+			UpdatedAt: time.Date(2025, 1, 2, 3, 4, 5, 678000000, time.UTC),
+		})
+	}
+	return changes
+}
+
+// TestHTTPReplicator_Batches tests batching queued changes
+// Expected: Should post full batches right away and the rest on Close, keeping the updated_at of the primary to the millisecond
+func TestHTTPReplicator_Batches(t *testing.T) {
+	s := &standby{}
+	r := newTestReplicator(t, s, Config{BatchSize: 2})
+
+	require.NoError(t, r.Replicate(context.Background(), upserts("d1", "d2", "d3")...))
+	require.NoError(t, r.Close())
+
+	require.Len(t, s.batches, 2)
+	assert.Len(t, s.batches[0].Changes, 2)
+	assert.Len(t, s.batches[1].Changes, 1)
+	assert.Equal(t, "standby-key", s.apiKeys[0])
+	change := s.batches[0].Changes[0]
+	assert.Equal(t, domain.ReplicationUpsert, change.Op)
+	assert.Equal(t, "d1", change.DriverID)
+	assert.Equal(t, 678, change.UpdatedAt.Nanosecond()/int(time.Millisecond))
+
+	assert.ErrorIs(t, r.Replicate(context.Background(), upserts("d4")...), ErrReplicatorClosed)
+}
+
+// TestHTTPReplicator_QueueFull tests replicating more changes than the queue holds
+// Expected: Should drop the changes that do not fit instead of blocking the write
+func TestHTTPReplicator_QueueFull(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	// the first change is stuck in flight, the queue holds two more
+	r := NewHTTPReplicator(Config{TargetURL: server.URL, BatchSize: 1, QueueSize: 2})
+	err := r.Replicate(context.Background(), upserts("d1", "d2", "d3", "d4", "d5")...)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "queue is full")
+}
+
+// TestHTTPReplicator_Send tests sending a batch with retries
+// Expected: Should retry 503 answers of a read-only standby and return the result of the standby
+func TestHTTPReplicator_Send(t *testing.T) {
+	s := &standby{statuses: []int{http.StatusServiceUnavailable}}
+	r := newTestReplicator(t, s, Config{MaxRetries: 2})
+	defer r.Close()
+
+	result, err := r.Send(context.Background(), domain.ReplicationBatch{
+		Changes: upserts("d1", "d2"),
+		Mirror:  &domain.MirrorRange{SnapshotAt: time.Now()},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Applied)
+	require.Len(t, s.batches, 1)
+	assert.NotNil(t, s.batches[0].Mirror)
+}
+
+// TestHTTPReplicator_Send_Rejected tests a batch the standby rejects
+// Expected: Should not retry client errors
+func TestHTTPReplicator_Send_Rejected(t *testing.T) {
+	s := &standby{statuses: []int{http.StatusUnauthorized}}
+	r := newTestReplicator(t, s, Config{MaxRetries: 3})
+	defer r.Close()
+
+	_, err := r.Send(context.Background(), domain.ReplicationBatch{Changes: upserts("d1")})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "401")
+	assert.Len(t, s.apiKeys, 1)
+}
@@ -0,0 +1,196 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"the-driver-location-service/internal/domain"
+	"the-driver-location-service/internal/ports/secondary"
+)
+
+var ErrDispatcherClosed = errors.New("webhook dispatcher is closed")
+
+// Config controls batching and retries. A batch is sent when it holds BatchSize
+// notifications or FlushInterval passed since the last send, a failed delivery is
+// retried MaxRetries times with a backoff that doubles from RetryBackoff.
+type Config struct {
+	URLs          []string
+	BatchSize     int
+	FlushInterval time.Duration
+	MaxRetries    int
+	RetryBackoff  time.Duration
+	Timeout       time.Duration
+	Logger        secondary.Logger // dropped notifications are logged, nil discards the entries
+}
+
+// Payload is the body posted to the fleet partner endpoints
+type Payload struct {
+	Type    domain.DriverEventType    `json:"type"`
+	Drivers []domain.DriverInactivity `json:"drivers"`
+}
+
+// InactivityDispatcher posts driver inactivity notifications to every configured
+// URL. Notifications are queued and sent in batches from a single goroutine, so a
+// slow partner delays the notifications but never the inactivity check.
+type InactivityDispatcher struct {
+	config Config
+	client *http.Client
+	queue  chan domain.DriverInactivity
+	stop   chan struct{}
+	done   chan struct{}
+	once   sync.Once
+}
+
+var _ secondary.InactivityNotifier = (*InactivityDispatcher)(nil)
+
+func NewInactivityDispatcher(config Config) *InactivityDispatcher {
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = 5 * time.Second
+	}
+	if config.RetryBackoff <= 0 {
+		config.RetryBackoff = time.Second
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 5 * time.Second
+	}
+	if config.Logger == nil {
+		config.Logger = secondary.NopLogger{}
+	}
+
+	d := &InactivityDispatcher{
+		config: config,
+		client: &http.Client{Timeout: config.Timeout},
+		queue:  make(chan domain.DriverInactivity, config.BatchSize*10),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go d.run()
+	return d
+}
+
+func (d *InactivityDispatcher) Notify(ctx context.Context, inactivities ...domain.DriverInactivity) error {
+	select {
+	case <-d.stop:
+		return ErrDispatcherClosed
+	default:
+	}
+
+	for _, inactivity := range inactivities {
+		select {
+		case d.queue <- inactivity:
+		case <-d.stop:
+			return ErrDispatcherClosed
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// Close sends the queued notifications and waits until they are delivered or dropped
+func (d *InactivityDispatcher) Close() error {
+	d.once.Do(func() { close(d.stop) })
+	<-d.done
+	return nil
+}
+
+func (d *InactivityDispatcher) run() {
+	defer close(d.done)
+
+	ticker := time.NewTicker(d.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]domain.DriverInactivity, 0, d.config.BatchSize)
+	flush := func() {
+		if len(batch) > 0 {
+			d.send(batch)
+			batch = make([]domain.DriverInactivity, 0, d.config.BatchSize)
+		}
+	}
+
+	for {
+		select {
+		case inactivity := <-d.queue:
+			batch = append(batch, inactivity)
+			if len(batch) >= d.config.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-d.stop:
+			for {
+				select {
+				case inactivity := <-d.queue:
+					batch = append(batch, inactivity)
+					if len(batch) >= d.config.BatchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+func (d *InactivityDispatcher) send(batch []domain.DriverInactivity) {
+	body, err := json.Marshal(Payload{Type: domain.DriverWentOffline, Drivers: batch})
+	if err != nil {
+		d.config.Logger.Error(context.Background(), "failed to encode inactivity notifications", "notifications", len(batch), "error", err)
+		return
+	}
+
+	for _, url := range d.config.URLs {
+		if err := d.deliver(url, body); err != nil {
+			d.config.Logger.Warn(context.Background(), "dropped inactivity notifications", "notifications", len(batch), "url", url, "error", err)
+		}
+	}
+}
+
+func (d *InactivityDispatcher) deliver(url string, body []byte) error {
+	backoff := d.config.RetryBackoff
+	var err error
+	for attempt := 1; ; attempt++ {
+		var retry bool
+		retry, err = d.post(url, body, attempt)
+		if err == nil || !retry || attempt > d.config.MaxRetries {
+			return err
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// post reports whether a failed delivery is worth retrying, client errors other
+// than 429 mean the partner rejected the payload and will do so again
+func (d *InactivityDispatcher) post(url string, body []byte, attempt int) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Delivery-Attempt", strconv.Itoa(attempt))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("webhook request failed: %w", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+	return retry, fmt.Errorf("webhook returned status %d", resp.StatusCode)
+}
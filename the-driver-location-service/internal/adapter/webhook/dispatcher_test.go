@@ -0,0 +1,129 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"the-driver-location-service/internal/domain"
+)
+
+type partner struct {
+	mu       sync.Mutex
+	statuses []int // response status per request, 200 once they run out
+	attempts []string
+	batches  []Payload
+}
+
+func (p *partner) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.attempts = append(p.attempts, r.Header.Get("X-Delivery-Attempt"))
+	status := http.StatusOK
+	if len(p.statuses) > 0 {
+		status, p.statuses = p.statuses[0], p.statuses[1:]
+	}
+	if status == http.StatusOK {
+		var payload Payload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err == nil {
+			p.batches = append(p.batches, payload)
+		}
+	}
+	w.WriteHeader(status)
+}
+
+func newTestDispatcher(t *testing.T, p *partner, config Config) *InactivityDispatcher {
+	server := httptest.NewServer(p)
+	t.Cleanup(server.Close)
+
+	config.URLs = []string{server.URL}
+	if config.FlushInterval == 0 {
+		config.FlushInterval = time.Hour
+	}
+	config.RetryBackoff = time.Millisecond
+	return NewInactivityDispatcher(config)
+}
+
+func inactivities(n int) []domain.DriverInactivity {
+	result := make([]domain.DriverInactivity, n)
+	for i := range result {
+		result[i] = domain.DriverInactivity{
+			DriverID:   fmt.Sprintf("d%d", i),
+			Location:   domain.NewPoint(29, 41),
+			LastSeenAt: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+			DetectedAt: time.Date(2025, 1, 2, 3, 10, 0, 0, time.UTC),
+		}
+	}
+	return result
+}
+
+// TestInactivityDispatcher_Batches tests batching queued notifications
+// Expected: Should post full batches right away and the rest on Close
+func TestInactivityDispatcher_Batches(t *testing.T) {
+	p := &partner{}
+	d := newTestDispatcher(t, p, Config{BatchSize: 2})
+
+	require.NoError(t, d.Notify(context.Background(), inactivities(5)...))
+	require.NoError(t, d.Close())
+
+	require.Len(t, p.batches, 3)
+	assert.Len(t, p.batches[0].Drivers, 2)
+	assert.Len(t, p.batches[2].Drivers, 1)
+	assert.Equal(t, domain.DriverWentOffline, p.batches[0].Type)
+	assert.Equal(t, "d0", p.batches[0].Drivers[0].DriverID)
+	assert.Equal(t, "2025-01-02T03:04:05Z", p.batches[0].Drivers[0].LastSeenAt.Format(time.RFC3339))
+
+	assert.ErrorIs(t, d.Notify(context.Background(), inactivities(1)...), ErrDispatcherClosed)
+}
+
+// TestInactivityDispatcher_FlushInterval tests a batch that does not fill up
+// Expected: Should post it once the flush interval passed
+func TestInactivityDispatcher_FlushInterval(t *testing.T) {
+	p := &partner{}
+	d := newTestDispatcher(t, p, Config{BatchSize: 10, FlushInterval: 10 * time.Millisecond})
+	defer d.Close()
+
+	require.NoError(t, d.Notify(context.Background(), inactivities(3)...))
+
+	assert.Eventually(t, func() bool {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		return len(p.batches) == 1 && len(p.batches[0].Drivers) == 3
+	}, time.Second, 5*time.Millisecond)
+}
+
+// TestInactivityDispatcher_Retries tests deliveries that fail with retryable statuses
+// Expected: Should retry server errors and 429 with numbered attempts until the partner accepts the batch
+func TestInactivityDispatcher_Retries(t *testing.T) {
+	p := &partner{statuses: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}}
+	d := newTestDispatcher(t, p, Config{BatchSize: 1, MaxRetries: 3})
+
+	require.NoError(t, d.Notify(context.Background(), inactivities(1)...))
+	require.NoError(t, d.Close())
+
+	assert.Equal(t, []string{"1", "2", "3"}, p.attempts)
+	assert.Len(t, p.batches, 1)
+}
+
+// TestInactivityDispatcher_GivesUp tests deliveries that keep failing
+// Expected: Should stop after MaxRetries retries and never retry a rejected payload
+func TestInactivityDispatcher_GivesUp(t *testing.T) {
+	p := &partner{statuses: []int{500, 500, 500, http.StatusBadRequest}}
+	d := newTestDispatcher(t, p, Config{BatchSize: 1, MaxRetries: 2})
+
+	require.NoError(t, d.Notify(context.Background(), inactivities(2)...))
+	require.NoError(t, d.Close())
+
+	// 3 attempts for the first batch, then the second is rejected once
+	assert.Equal(t, []string{"1", "2", "3", "1"}, p.attempts)
+	assert.Empty(t, p.batches)
+}
@@ -0,0 +1,245 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"the-driver-location-service/internal/domain"
+	"the-driver-location-service/internal/ports/primary"
+	"the-driver-location-service/internal/ports/secondary"
+)
+
+// BackfillJob describes one backfill, Transform returns the fields to set on a
+// driver or nothing when the driver is already up to date
+type BackfillJob struct {
+	Name      string
+	Transform func(driver *domain.Driver) map[string]interface{}
+}
+
+// DriverDefaultsBackfill populates status, geohash and S2 cells and version on drivers
+// stored before those fields existed
+var DriverDefaultsBackfill = BackfillJob{
+	Name: "driver_defaults",
+	Transform: func(driver *domain.Driver) map[string]interface{} {
+		return driver.ApplyDefaults()
+	},
+}
+
+// ShardKeyBackfill populates the fields the shard key strategies partition on,
+// the geohash cell and the tenant (defaultTenant for drivers without one). It
+// is the first step of sharding the drivers collection, see db.ShardKey.
+func ShardKeyBackfill(defaultTenant string) BackfillJob {
+	return BackfillJob{
+		Name: "shard_key",
+		Transform: func(driver *domain.Driver) map[string]interface{} {
+			changed := make(map[string]interface{})
+			if cell, ok := driver.ApplyDefaults()["geohash_cell"]; ok {
+				changed["geohash_cell"] = cell
+			}
+			if driver.TenantID == "" && defaultTenant != "" {
+				driver.TenantID = defaultTenant
+				changed["tenant_id"] = defaultTenant
+			}
+			return changed
+		},
+	}
+}
+
+type BackfillOptions struct {
+	BatchSize int
+	Rate      float64 // batches per second, 0 disables rate limiting
+}
+
+// BackfillApplicationService walks the drivers collection in ID order, one batch at
+// a time, so a job can run against a live database without loading it. A failed job
+// keeps its last ID and the next run continues from there.
+type BackfillApplicationService struct {
+	store   secondary.DriverBackfillStore
+	logger  secondary.Logger
+	options BackfillOptions
+	ctx     context.Context
+
+	mu       sync.Mutex
+	jobs     map[string]BackfillJob
+	progress map[string]domain.BackfillProgress
+}
+
+var _ primary.BackfillService = (*BackfillApplicationService)(nil)
+
+func NewBackfillApplicationService(store secondary.DriverBackfillStore, options BackfillOptions, jobs ...BackfillJob) *BackfillApplicationService {
+	if options.BatchSize <= 0 {
+		options.BatchSize = 500
+	}
+
+	s := &BackfillApplicationService{
+		store:    store,
+		logger:   secondary.NopLogger{},
+		options:  options,
+		ctx:      context.Background(),
+		jobs:     make(map[string]BackfillJob, len(jobs)),
+		progress: make(map[string]domain.BackfillProgress, len(jobs)),
+	}
+	for _, job := range jobs {
+		s.jobs[job.Name] = job
+		s.progress[job.Name] = domain.BackfillProgress{Job: job.Name, Status: domain.BackfillIdle}
+	}
+	return s
+}
+
+// SetLogger sets where the progress of the jobs is logged
+func (s *BackfillApplicationService) SetLogger(logger secondary.Logger) {
+	s.logger = logger
+}
+
+// SetContext sets the context of the jobs started with Start, cancelling it on
+// shutdown stops them at the next batch and leaves a checkpoint to resume from
+func (s *BackfillApplicationService) SetContext(ctx context.Context) {
+	s.ctx = ctx
+}
+
+func (s *BackfillApplicationService) Jobs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := make([]string, 0, len(s.jobs))
+	for name := range s.jobs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s *BackfillApplicationService) Progress(job string) (domain.BackfillProgress, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	progress, ok := s.progress[job]
+	if !ok {
+		return domain.BackfillProgress{}, domain.ErrBackfillNotFound
+	}
+	return progress, nil
+}
+
+// Start runs the job in the background, progress is available through Progress
+func (s *BackfillApplicationService) Start(job string) error {
+	backfill, err := s.begin(job)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		ctx := s.ctx
+		if _, err := s.run(ctx, backfill); err != nil {
+			s.logger.Warn(ctx, "backfill failed", "job", job, "error", err)
+		}
+	}()
+	return nil
+}
+
+// Run runs the job and blocks until it is done, it is used by the backfill command
+func (s *BackfillApplicationService) Run(ctx context.Context, job string) (domain.BackfillProgress, error) {
+	backfill, err := s.begin(job)
+	if err != nil {
+		return domain.BackfillProgress{}, err
+	}
+	return s.run(ctx, backfill)
+}
+
+func (s *BackfillApplicationService) begin(job string) (BackfillJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	backfill, ok := s.jobs[job]
+	if !ok {
+		return BackfillJob{}, domain.ErrBackfillNotFound
+	}
+
+	progress := s.progress[job]
+	if progress.Status == domain.BackfillRunning {
+		return BackfillJob{}, domain.ErrBackfillRunning
+	}
+
+	startedAt := time.Now()
+	next := domain.BackfillProgress{Job: job, Status: domain.BackfillRunning, StartedAt: &startedAt}
+	if progress.Status == domain.BackfillFailed {
+		// resume from the checkpoint instead of scanning everything again
+		next.LastID = progress.LastID
+	}
+	s.progress[job] = next
+	return backfill, nil
+}
+
+func (s *BackfillApplicationService) run(ctx context.Context, job BackfillJob) (domain.BackfillProgress, error) {
+	var throttle <-chan time.Time
+	if s.options.Rate > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / s.options.Rate))
+		defer ticker.Stop()
+		throttle = ticker.C
+	}
+
+	progress, _ := s.Progress(job.Name)
+	for {
+		drivers, err := s.store.ScanAfter(ctx, progress.LastID, s.options.BatchSize)
+		if err != nil {
+			return s.finish(progress, fmt.Errorf("failed to scan drivers: %w", err))
+		}
+		if len(drivers) == 0 {
+			break
+		}
+
+		var updates []domain.DriverFieldUpdate
+		for _, driver := range drivers {
+			location := driver.Location
+			if fields := job.Transform(driver); len(fields) > 0 {
+				updates = append(updates, domain.DriverFieldUpdate{ID: driver.ID, Location: location, Fields: fields})
+			}
+		}
+		var updated int64
+		if len(updates) > 0 {
+			if updated, err = s.store.ApplyUpdates(ctx, updates); err != nil {
+				return s.finish(progress, fmt.Errorf("failed to update drivers: %w", err))
+			}
+		}
+
+		progress.Scanned += int64(len(drivers))
+		progress.Updated += updated
+		progress.LastID = drivers[len(drivers)-1].ID
+		s.save(progress)
+		s.logger.Info(ctx, "backfill progress", "job", job.Name, "scanned", progress.Scanned, "updated", progress.Updated, "last_id", progress.LastID)
+
+		if len(drivers) < s.options.BatchSize {
+			break
+		}
+
+		if throttle != nil {
+			select {
+			case <-ctx.Done():
+				return s.finish(progress, ctx.Err())
+			case <-throttle:
+			}
+		}
+	}
+
+	return s.finish(progress, nil)
+}
+
+func (s *BackfillApplicationService) finish(progress domain.BackfillProgress, err error) (domain.BackfillProgress, error) {
+	finishedAt := time.Now()
+	progress.FinishedAt = &finishedAt
+	progress.Status = domain.BackfillCompleted
+	if err != nil {
+		progress.Status = domain.BackfillFailed
+		progress.Error = err.Error()
+	}
+	s.save(progress)
+	return progress, err
+}
+
+func (s *BackfillApplicationService) save(progress domain.BackfillProgress) {
+	s.mu.Lock()
+	s.progress[progress.Job] = progress
+	s.mu.Unlock()
+}
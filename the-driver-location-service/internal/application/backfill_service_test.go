@@ -0,0 +1,204 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"the-driver-location-service/internal/domain"
+)
+
+type memoryBackfillStore struct {
+	drivers map[string]*domain.Driver
+	scanErr error
+	failAt  string
+	scans   int
+	applied int
+	// beforeApply runs between the scan and the update of a batch
+	beforeApply func()
+}
+
+func newMemoryBackfillStore(count int) *memoryBackfillStore {
+	store := &memoryBackfillStore{drivers: make(map[string]*domain.Driver)}
+	for i := 0; i < count; i++ {
+		id := fmt.Sprintf("d%03d", i)
+		store.drivers[id] = &domain.Driver{ID: id, Location: domain.NewPoint(29, 41)}
+	}
+	return store
+}
+
+func (s *memoryBackfillStore) ScanAfter(ctx context.Context, afterID string, limit int) ([]*domain.Driver, error) {
+	s.scans++
+	if s.failAt != "" && afterID == s.failAt {
+		return nil, s.scanErr
+	}
+
+	ids := make([]string, 0, len(s.drivers))
+	for id := range s.drivers {
+		if id > afterID {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+
+	batch := make([]*domain.Driver, len(ids))
+	for i, id := range ids {
+		copied := *s.drivers[id]
+		batch[i] = &copied
+	}
+	return batch, nil
+}
+
+func (s *memoryBackfillStore) ApplyUpdates(ctx context.Context, updates []domain.DriverFieldUpdate) (int64, error) {
+	if s.beforeApply != nil {
+		s.beforeApply()
+	}
+
+	var updated int64
+	for _, update := range updates {
+		driver := s.drivers[update.ID]
+		if !reflect.DeepEqual(driver.Location, update.Location) {
+			continue
+		}
+		if status, ok := update.Fields["status"].(string); ok {
+			driver.Status = status
+		}
+		if cell, ok := update.Fields["geohash_cell"].(string); ok {
+			driver.GeohashCell = cell
+		}
+		if cell, ok := update.Fields["s2_cell"].(int64); ok {
+			driver.S2Cell = cell
+		}
+		if version, ok := update.Fields["version"].(int64); ok {
+			driver.Version = version
+		} else {
+			driver.Version++
+		}
+		s.applied++
+		updated++
+	}
+	return updated, nil
+}
+
+// TestBackfillService_Run tests running the driver defaults backfill over several batches
+// Expected: Should update every driver once and report the progress
+func TestBackfillService_Run(t *testing.T) {
+	store := newMemoryBackfillStore(25)
+	service := NewBackfillApplicationService(store, BackfillOptions{BatchSize: 10}, DriverDefaultsBackfill)
+
+	progress, err := service.Run(context.Background(), DriverDefaultsBackfill.Name)
+	require.NoError(t, err)
+	assert.Equal(t, domain.BackfillCompleted, progress.Status)
+	assert.Equal(t, int64(25), progress.Scanned)
+	assert.Equal(t, int64(25), progress.Updated)
+	assert.Equal(t, "d024", progress.LastID)
+	assert.NotNil(t, progress.FinishedAt)
+	assert.Equal(t, domain.DriverStatusAvailable, store.drivers["d007"].Status)
+	assert.Equal(t, int64(1), store.drivers["d007"].Version)
+
+	// a second run has nothing left to update
+	progress, err = service.Run(context.Background(), DriverDefaultsBackfill.Name)
+	require.NoError(t, err)
+	assert.Equal(t, int64(25), progress.Scanned)
+	assert.Equal(t, int64(0), progress.Updated)
+}
+
+// TestBackfillService_RunDriverMoved tests a location update between the scan and the update of a batch
+// Expected: Should skip the driver that moved and keep the cells of its new location
+func TestBackfillService_RunDriverMoved(t *testing.T) {
+	store := newMemoryBackfillStore(5)
+	moved := domain.NewPoint(30, 42)
+	store.beforeApply = func() {
+		driver := store.drivers["d003"]
+		driver.Location = moved
+		driver.ApplyDefaults()
+	}
+	service := NewBackfillApplicationService(store, BackfillOptions{BatchSize: 10}, DriverDefaultsBackfill)
+
+	progress, err := service.Run(context.Background(), DriverDefaultsBackfill.Name)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), progress.Scanned)
+	assert.Equal(t, int64(4), progress.Updated)
+	assert.Equal(t, domain.Geohash(42, 30, domain.GeohashPrecision), store.drivers["d003"].GeohashCell)
+}
+
+// TestBackfillService_StartCancelled tests cancelling the context of the jobs started in the background
+// Expected: Should stop the job and leave it failed with its checkpoint
+func TestBackfillService_StartCancelled(t *testing.T) {
+	store := newMemoryBackfillStore(25)
+	service := NewBackfillApplicationService(store, BackfillOptions{BatchSize: 10, Rate: 0.001}, DriverDefaultsBackfill)
+	ctx, cancel := context.WithCancel(context.Background())
+	service.SetContext(ctx)
+
+	require.NoError(t, service.Start(DriverDefaultsBackfill.Name))
+	require.Eventually(t, func() bool {
+		progress, _ := service.Progress(DriverDefaultsBackfill.Name)
+		return progress.Scanned == 10
+	}, time.Second, 5*time.Millisecond)
+	cancel()
+
+	require.Eventually(t, func() bool {
+		progress, _ := service.Progress(DriverDefaultsBackfill.Name)
+		return progress.Status == domain.BackfillFailed
+	}, time.Second, 5*time.Millisecond)
+	progress, _ := service.Progress(DriverDefaultsBackfill.Name)
+	assert.Equal(t, "d009", progress.LastID)
+	assert.Contains(t, progress.Error, context.Canceled.Error())
+}
+
+// TestShardKeyBackfill tests populating the shard key fields of a stored driver
+// Expected: Should set the geohash cell and the default tenant only where they are missing
+func TestShardKeyBackfill(t *testing.T) {
+	job := ShardKeyBackfill("acme")
+
+	driver := &domain.Driver{ID: "d1", Location: domain.NewPoint(29, 41)}
+	fields := job.Transform(driver)
+	assert.Equal(t, map[string]interface{}{
+		"geohash_cell": domain.Geohash(41, 29, domain.GeohashPrecision),
+		"tenant_id":    "acme",
+	}, fields)
+
+	// status and version are left to the driver_defaults backfill
+	assert.Empty(t, job.Transform(&domain.Driver{ID: "d2", Location: domain.NewPoint(29, 41), GeohashCell: domain.Geohash(41, 29, domain.GeohashPrecision), TenantID: "globex"}))
+}
+
+// TestBackfillService_ResumeAfterFailure tests rerunning a failed backfill
+// Expected: Should keep the checkpoint of the failed run and continue after it
+func TestBackfillService_ResumeAfterFailure(t *testing.T) {
+	store := newMemoryBackfillStore(25)
+	store.failAt, store.scanErr = "d019", errors.New("connection reset")
+	service := NewBackfillApplicationService(store, BackfillOptions{BatchSize: 10}, DriverDefaultsBackfill)
+
+	progress, err := service.Run(context.Background(), DriverDefaultsBackfill.Name)
+	assert.Error(t, err)
+	assert.Equal(t, domain.BackfillFailed, progress.Status)
+	assert.Equal(t, "d019", progress.LastID)
+	assert.Contains(t, progress.Error, "connection reset")
+
+	store.failAt = ""
+	progress, err = service.Run(context.Background(), DriverDefaultsBackfill.Name)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), progress.Scanned)
+	assert.Equal(t, 25, store.applied)
+}
+
+// TestBackfillService_UnknownJob tests starting a job that is not registered
+// Expected: Should return ErrBackfillNotFound
+func TestBackfillService_UnknownJob(t *testing.T) {
+	service := NewBackfillApplicationService(newMemoryBackfillStore(0), BackfillOptions{}, DriverDefaultsBackfill)
+
+	assert.ErrorIs(t, service.Start("unknown"), domain.ErrBackfillNotFound)
+	_, err := service.Progress("unknown")
+	assert.ErrorIs(t, err, domain.ErrBackfillNotFound)
+	assert.Equal(t, []string{"driver_defaults"}, service.Jobs())
+}
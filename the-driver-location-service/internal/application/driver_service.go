@@ -2,6 +2,7 @@ package application
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -16,7 +17,21 @@ import (
 type DriverApplicationService struct {
 	repo      secondary.DriverRepository
 	cache     secondary.DriverCache
+	flags     primary.FeatureFlagService
+	matcher   secondary.MapMatcher
+	events    secondary.DriverEventPublisher
+	replicas  secondary.DriverReplicator
+	logger    secondary.Logger
 	validator *validator.Validate
+	cellLevel int
+	maxRadius float64
+	cacheTTL  time.Duration
+	// defaultRadius is the radius of searches without one, 0 keeps it required
+	defaultRadius float64
+	defaultLimit  int
+	maxLimit      int
+	// notFoundTTL caches lookups of missing IDs, 0 does not cache them
+	notFoundTTL time.Duration
 }
 
 var _ primary.DriverService = (*DriverApplicationService)(nil)
@@ -29,177 +44,638 @@ func NewDriverApplicationService(repo secondary.DriverRepository, cache secondar
 	return &DriverApplicationService{
 		repo:      repo,
 		cache:     cache,
-		validator: validator.New(),
+		logger:    secondary.NopLogger{},
+		validator: domain.NewValidator(),
+		cellLevel: domain.DefaultCellCountLevel,
+		maxRadius: domain.DefaultMaxSearchRadius,
+		cacheTTL:  DriverCacheTTL,
+
+		defaultLimit: domain.DefaultSearchLimit,
+		maxLimit:     domain.DefaultMaxSearchLimit,
 	}
 }
 
-func (s *DriverApplicationService) CreateDriver(req domain.CreateDriverRequest) (*domain.Driver, error) {
+// SetFeatureFlags lets the service consult feature flags before taking risky code paths
+func (s *DriverApplicationService) SetFeatureFlags(flags primary.FeatureFlagService) {
+	s.flags = flags
+}
+
+// SetMapMatcher enables snapping location updates to the road network
+func (s *DriverApplicationService) SetMapMatcher(matcher secondary.MapMatcher) {
+	s.matcher = matcher
+}
+
+// SetEventPublisher enables publishing driver changes to downstream consumers
+func (s *DriverApplicationService) SetEventPublisher(events secondary.DriverEventPublisher) {
+	s.events = events
+}
+
+// SetReplicator enables replicating driver changes to the standby region
+func (s *DriverApplicationService) SetReplicator(replicas secondary.DriverReplicator) {
+	s.replicas = replicas
+}
+
+// SetLogger sets where the failures the service tolerates are logged
+func (s *DriverApplicationService) SetLogger(logger secondary.Logger) {
+	s.logger = logger
+}
+
+// SetCacheTTL sets how long the drivers the service reads or writes stay
+// cached, 0 keeps DriverCacheTTL
+func (s *DriverApplicationService) SetCacheTTL(ttl time.Duration) {
+	if ttl > 0 {
+		s.cacheTTL = ttl
+	}
+}
+
+// SetNotFoundCacheTTL caches the IDs lookups find no driver for that long, so
+// a client polling a removed driver does not reach MongoDB on every request.
+// Creating the driver clears the entry unless it came from a batch without
+// the write-behind cache, those are found once the TTL runs out.
+func (s *DriverApplicationService) SetNotFoundCacheTTL(ttl time.Duration) {
+	s.notFoundTTL = ttl
+}
+
+// SetCellCountLevel sets the level drivers are counted by in cell searches that
+// don't ask for one, 0 keeps domain.DefaultCellCountLevel
+func (s *DriverApplicationService) SetCellCountLevel(level int) {
+	if level > 0 {
+		s.cellLevel = level
+	}
+}
+
+// SetMaxSearchRadius sets the largest radius in meters nearby searches accept,
+// 0 keeps domain.DefaultMaxSearchRadius
+func (s *DriverApplicationService) SetMaxSearchRadius(meters float64) {
+	if meters > 0 {
+		s.maxRadius = meters
+	}
+}
+
+// SetDefaultSearchRadius sets the radius in meters of nearby searches that
+// don't send one, 0 keeps the radius required
+func (s *DriverApplicationService) SetDefaultSearchRadius(meters float64) {
+	if meters >= 0 {
+		s.defaultRadius = meters
+	}
+}
+
+// SetSearchLimits sets the number of drivers of nearby searches without a limit
+// and the largest limit they accept, 0 keeps domain.DefaultSearchLimit and
+// domain.DefaultMaxSearchLimit
+func (s *DriverApplicationService) SetSearchLimits(defaultLimit, maxLimit int) {
+	if maxLimit > 0 {
+		s.maxLimit = maxLimit
+	}
+	if defaultLimit > 0 {
+		s.defaultLimit = defaultLimit
+	}
+	s.defaultLimit = min(s.defaultLimit, s.maxLimit)
+}
+
+// publish emits events of changes that are already stored, a failure is only
+// logged because the change itself succeeded
+func (s *DriverApplicationService) publish(ctx context.Context, events ...domain.DriverEvent) {
+	if s.events == nil || len(events) == 0 {
+		return
+	}
+	if err := s.events.Publish(context.WithoutCancel(ctx), events...); err != nil {
+		s.logger.Warn(ctx, "failed to publish driver events", "events", len(events), "error", err)
+	}
+}
+
+// replicate queues stored changes for the standby region, like publish a
+// failure is only logged. The reconciliation job sends what was dropped.
+func (s *DriverApplicationService) replicate(ctx context.Context, changes ...domain.DriverChange) {
+	if s.replicas == nil || len(changes) == 0 {
+		return
+	}
+	if err := s.replicas.Replicate(context.WithoutCancel(ctx), changes...); err != nil {
+		s.logger.Warn(ctx, "failed to replicate driver changes", "changes", len(changes), "error", err)
+	}
+}
+
+// featureEnabled is false when no flag service is configured so new paths stay
+// off by default. key is the rollout key of the request, see domain.WithRolloutKey.
+func (s *DriverApplicationService) featureEnabled(name, key string) bool {
+	return s.flags != nil && s.flags.IsEnabled(name, key)
+}
+
+func (s *DriverApplicationService) CreateDriver(ctx context.Context, req domain.CreateDriverRequest) (*domain.Driver, error) {
 	if err := s.validator.Struct(req); err != nil {
-		return nil, fmt.Errorf("invalid request: %w", err)
+		return nil, fmt.Errorf("%w: invalid request: %w", domain.ErrValidation, err)
 	}
 
 	driver := &domain.Driver{
-		Location: req.Location,
+		Location:    req.Location,
+		TenantID:    strings.TrimSpace(req.TenantID),
+		VehicleType: domain.NormalizeVehicleType(req.VehicleType),
+		Capacity:    req.Capacity,
+		Attributes:  req.Attributes,
 	}
 
 	if req.ID != "" {
 		driver.ID = strings.TrimSpace(req.ID)
 	}
 
-	if err := s.repo.Create(driver); err != nil {
+	if err := s.repo.Create(ctx, driver); err != nil {
 		return nil, fmt.Errorf("failed to create driver: %w", err)
 	}
 
-	ctx := context.Background()
 	if s.cache != nil {
-		if err := s.cache.Set(ctx, driver.ID, driver, DriverCacheTTL); err != nil {
-			fmt.Printf("Warning: failed to cache driver %s: %v\n", driver.ID, err)
+		if err := s.cache.Set(ctx, driver.ID, driver, s.cacheTTL); err != nil {
+			s.logger.Warn(ctx, "failed to cache driver", "driver_id", driver.ID, "error", err)
 		}
 	}
 
+	s.publish(ctx, domain.NewDriverEvent(domain.DriverCreated, driver))
+	s.replicate(ctx, domain.NewDriverUpsert(driver))
 	return driver, nil
 }
 
-func (s *DriverApplicationService) BatchCreateDrivers(req domain.BatchCreateRequest) ([]*domain.Driver, error) {
+// BatchCreateDrivers creates the drivers of the request. An ordered batch with a
+// taken ID creates none of them; an unordered one creates every driver it can
+// and returns the others in a *domain.PartialBatchError next to the created ones.
+func (s *DriverApplicationService) BatchCreateDrivers(ctx context.Context, req domain.BatchCreateRequest) ([]*domain.Driver, error) {
 	if err := s.validator.Struct(req); err != nil {
-		return nil, fmt.Errorf("invalid request: %w", err)
+		return nil, fmt.Errorf("%w: invalid request: %w", domain.ErrValidation, err)
+	}
+	if req.Upsert && req.Unordered {
+		return nil, fmt.Errorf("%w: invalid request: upsert requires an ordered batch", domain.ErrValidation)
 	}
 
 	drivers := make([]*domain.Driver, len(req.Drivers))
+	seen := make(map[string]bool, len(req.Drivers))
 	for i, driverReq := range req.Drivers {
 		drivers[i] = &domain.Driver{
-			Location: driverReq.Location,
+			Location:    driverReq.Location,
+			TenantID:    strings.TrimSpace(driverReq.TenantID),
+			VehicleType: domain.NormalizeVehicleType(driverReq.VehicleType),
+			Capacity:    driverReq.Capacity,
+			Attributes:  driverReq.Attributes,
 		}
 
 		if driverReq.ID != "" {
 			drivers[i].ID = strings.TrimSpace(driverReq.ID)
+			if seen[drivers[i].ID] {
+				return nil, fmt.Errorf("%w: invalid request: driver %s is listed twice", domain.ErrValidation, drivers[i].ID)
+			}
+			seen[drivers[i].ID] = true
 		}
 	}
 
-	if err := s.repo.BatchCreate(drivers); err != nil {
+	if req.Unordered {
+		failures, err := s.repo.BatchCreateUnordered(ctx, drivers)
+		if err != nil {
+			return nil, fmt.Errorf("failed to batch create drivers: %w", err)
+		}
+		created := domain.CreatedDrivers(drivers, failures)
+		s.afterBatchCreate(ctx, created)
+		if len(failures) > 0 {
+			return created, &domain.PartialBatchError{Failed: failures}
+		}
+		return created, nil
+	}
+
+	created := drivers
+	err := s.repo.BatchCreate(ctx, created)
+	var duplicate *domain.DuplicateDriverError
+	if req.Upsert && errors.As(err, &duplicate) && len(duplicate.IDs) > 0 {
+		created, err = s.upsertDrivers(ctx, drivers, duplicate.IDs)
+	}
+	if err != nil {
 		return nil, fmt.Errorf("failed to batch create drivers: %w", err)
 	}
+	s.afterBatchCreate(ctx, created)
 
 	return drivers, nil
 }
 
-func (s *DriverApplicationService) SearchNearbyDrivers(req domain.SearchRequest) ([]*domain.DriverWithDistance, error) {
+// afterBatchCreate warms the cache with the created drivers of a batch and
+// publishes and replicates their creation
+func (s *DriverApplicationService) afterBatchCreate(ctx context.Context, created []*domain.Driver) {
+	if s.cache != nil && s.featureEnabled(domain.FlagWriteBehindCache, domain.RolloutKeyFrom(ctx)) {
+		go s.warmCache(context.WithoutCancel(ctx), created)
+	}
+
+	events := make([]domain.DriverEvent, len(created))
+	changes := make([]domain.DriverChange, len(created))
+	for i, driver := range created {
+		events[i] = domain.NewDriverEvent(domain.DriverCreated, driver)
+		changes[i] = domain.NewDriverUpsert(driver)
+	}
+	s.publish(ctx, events...)
+	s.replicate(ctx, changes...)
+}
+
+// upsertDrivers updates the drivers of the batch whose IDs are taken and creates
+// the others, it returns the created ones. An existing driver gets the location,
+// vehicle and attributes of the request and keeps its status, tenant and history.
+func (s *DriverApplicationService) upsertDrivers(ctx context.Context, drivers []*domain.Driver, takenIDs []string) ([]*domain.Driver, error) {
+	taken := make(map[string]bool, len(takenIDs))
+	for _, id := range takenIDs {
+		taken[id] = true
+	}
+
+	created := make([]*domain.Driver, 0, len(drivers)-len(takenIDs))
+	for i, driver := range drivers {
+		if !taken[driver.ID] {
+			created = append(created, driver)
+			continue
+		}
+
+		existing, err := s.repo.GetByID(ctx, driver.ID)
+		if err != nil {
+			return nil, err
+		}
+		existing.Location = driver.Location
+		existing.RawLocation = nil
+		existing.VehicleType = driver.VehicleType
+		existing.Capacity = driver.Capacity
+		existing.Attributes = driver.Attributes
+		if err := s.UpdateDriver(ctx, existing); err != nil {
+			return nil, err
+		}
+		drivers[i] = existing
+	}
+
+	if err := s.repo.BatchCreate(ctx, created); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// warmCache writes freshly created drivers to the cache behind the request
+// so the first lookups after a batch import don't all miss
+func (s *DriverApplicationService) warmCache(ctx context.Context, drivers []*domain.Driver) {
+	for _, driver := range drivers {
+		if err := s.cache.Set(ctx, driver.ID, driver, s.cacheTTL); err != nil {
+			s.logger.Warn(ctx, "failed to cache driver", "driver_id", driver.ID, "error", err)
+		}
+	}
+}
+
+func (s *DriverApplicationService) SearchNearbyDrivers(ctx context.Context, req domain.SearchRequest) ([]*domain.DriverWithDistance, error) {
+	if req.Radius == 0 {
+		req.Radius = s.defaultRadius
+	}
+	if req.Limit == 0 {
+		req.Limit = s.defaultLimit
+	}
 	if err := s.validator.Struct(req); err != nil {
-		return nil, fmt.Errorf("invalid request: %w", err)
+		return nil, fmt.Errorf("%w: invalid request: %w", domain.ErrValidation, err)
+	}
+	if req.Radius > s.maxRadius {
+		return nil, fmt.Errorf("%w: invalid request: radius must not exceed %g meters", domain.ErrValidation, s.maxRadius)
+	}
+	if req.Limit > s.maxLimit {
+		return nil, fmt.Errorf("%w: invalid request: limit must not exceed %d drivers", domain.ErrValidation, s.maxLimit)
+	}
+	limit := req.Limit
+
+	search := s.repo.SearchNearby
+	if s.featureEnabled(domain.FlagGeoNearSearch, domain.RolloutKeyFrom(ctx)) {
+		// distances computed by mongo instead of Haversine, being rolled out
+		search = s.repo.SearchGeoNear
+	}
+
+	drivers, err := search(ctx, req.Location, req.MinRadius, req.Radius, limit, req.Filter())
+	if err != nil {
+		return nil, fmt.Errorf("failed to search nearby drivers: %w", err)
+	}
+
+	return drivers, nil
+}
+
+// SearchDriversWithin returns the available drivers inside a polygon, e.g. for
+// dispatching to a zone instead of around a pickup point
+func (s *DriverApplicationService) SearchDriversWithin(ctx context.Context, req domain.AreaSearchRequest) ([]*domain.Driver, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("%w: invalid request: %w", domain.ErrValidation, err)
+	}
+	if _, err := req.Area.Polygons(); err != nil {
+		return nil, fmt.Errorf("%w: invalid area: %w", domain.ErrValidation, err)
 	}
 
 	limit := req.Limit
 	if limit <= 0 {
-		limit = 10
+		limit = domain.DefaultAreaSearchLimit
 	}
 
-	drivers, err := s.repo.SearchNearby(req.Location, req.Radius, limit)
+	drivers, err := s.repo.SearchWithin(ctx, req.Area, limit)
 	if err != nil {
-		return nil, fmt.Errorf("failed to search nearby drivers: %w", err)
+		return nil, fmt.Errorf("failed to search drivers within area: %w", err)
 	}
 
 	return drivers, nil
 }
 
-func (s *DriverApplicationService) GetDriver(id string) (*domain.Driver, error) {
-	if strings.TrimSpace(id) == "" {
-		return nil, fmt.Errorf("driver ID is required")
+// SearchDriversInBox returns the available drivers inside a bounding box, or
+// their clusters when req.Cluster is set, so maps can render markers without
+// issuing radius searches
+func (s *DriverApplicationService) SearchDriversInBox(ctx context.Context, req domain.BoxSearchRequest) (*domain.BoxSearchResult, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("%w: invalid request: %w", domain.ErrValidation, err)
+	}
+	if err := req.Check(); err != nil {
+		return nil, fmt.Errorf("%w: invalid box: %w", domain.ErrValidation, err)
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = domain.DefaultBoxSearchLimit
 	}
 
-	ctx := context.Background()
+	if req.Cluster > 0 {
+		clusters, err := s.repo.ClusterInBox(ctx, req, limit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to cluster drivers in box: %w", err)
+		}
+		return &domain.BoxSearchResult{Clusters: clusters, Count: len(clusters)}, nil
+	}
 
-	if s.cache != nil {
-		cachedDriver, err := s.cache.Get(ctx, id)
+	drivers, err := s.repo.SearchInBox(ctx, req, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search drivers in box: %w", err)
+	}
+	return &domain.BoxSearchResult{Drivers: drivers, Count: len(drivers)}, nil
+}
+
+// SearchDriversInCell returns the available drivers inside an S2 cell, or their
+// counts per descendant cell when req.Count is set. Cells are ranges over the
+// indexed leaf cell of the drivers, zones made of cells need no geo query.
+func (s *DriverApplicationService) SearchDriversInCell(ctx context.Context, req domain.CellSearchRequest) (*domain.CellSearchResult, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("%w: invalid request: %w", domain.ErrValidation, err)
+	}
+	cell, err := req.Cell()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", domain.ErrValidation, err)
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = domain.DefaultCellSearchLimit
+	}
+	result := &domain.CellSearchResult{Cell: cell.Token(), Level: cell.Level()}
+
+	if req.Count {
+		level := req.Level
+		if level == 0 {
+			// the configured level may be coarser than a small cell
+			level = min(max(s.cellLevel, cell.Level()+1), domain.MaxCellCountLevel)
+		}
+		if level <= cell.Level() {
+			return nil, fmt.Errorf("%w: level must be finer than the level %d of the cell", domain.ErrValidation, cell.Level())
+		}
+
+		counts, err := s.repo.CountInCell(ctx, cell, level, limit)
 		if err != nil {
-			fmt.Printf("Warning: failed to get driver from cache: %v\n", err)
+			return nil, fmt.Errorf("failed to count drivers in cell: %w", err)
+		}
+		result.Counts, result.Count = counts, len(counts)
+		return result, nil
+	}
+
+	drivers, err := s.repo.SearchInCell(ctx, cell, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search drivers in cell: %w", err)
+	}
+	result.Drivers, result.Count = drivers, len(drivers)
+	return result, nil
+}
+
+func (s *DriverApplicationService) GetDriver(ctx context.Context, id string) (*domain.Driver, error) {
+	if strings.TrimSpace(id) == "" {
+		return nil, fmt.Errorf("%w: driver ID is required", domain.ErrValidation)
+	}
+
+	// strong reads skip the cache but still refresh it with what they read
+	if s.cache != nil && !domain.IsStrongConsistency(ctx) {
+		cachedDriver, err := s.cache.Get(ctx, id)
+		if errors.Is(err, domain.ErrDriverNotFound) {
+			return nil, fmt.Errorf("failed to get driver: %w", err)
+		} else if err != nil {
+			s.logger.Warn(ctx, "failed to get driver from cache", "driver_id", id, "error", err)
 		} else if cachedDriver != nil {
 			return cachedDriver, nil
 		}
 	}
 
-	driver, err := s.repo.GetByID(id)
+	driver, err := s.repo.GetByID(ctx, id)
 	if err != nil {
+		if s.cache != nil && s.notFoundTTL > 0 && errors.Is(err, domain.ErrDriverNotFound) {
+			if err := s.cache.SetNotFound(ctx, id, s.notFoundTTL); err != nil {
+				s.logger.Warn(ctx, "failed to cache missing driver", "driver_id", id, "error", err)
+			}
+		}
 		return nil, fmt.Errorf("failed to get driver: %w", err)
 	}
 
 	if s.cache != nil {
-		if err := s.cache.Set(ctx, id, driver, DriverCacheTTL); err != nil {
-			fmt.Printf("Warning: failed to cache driver: %v\n", err)
+		if err := s.cache.Set(ctx, id, driver, s.cacheTTL); err != nil {
+			s.logger.Warn(ctx, "failed to cache driver", "driver_id", id, "error", err)
 		}
 	}
 
 	return driver, nil
 }
 
-func (s *DriverApplicationService) DeleteDriver(id string) error {
+func (s *DriverApplicationService) DeleteDriver(ctx context.Context, id string) error {
 	if strings.TrimSpace(id) == "" {
-		return fmt.Errorf("driver ID is required")
+		return fmt.Errorf("%w: driver ID is required", domain.ErrValidation)
 	}
 
-	if err := s.repo.Delete(id); err != nil {
+	if err := s.repo.Delete(ctx, id); err != nil {
 		return fmt.Errorf("failed to delete driver: %w", err)
 	}
 
-	ctx := context.Background()
+	// the change is stored, the cache has to follow even when the caller went away
 	if s.cache != nil {
-		if err := s.cache.Delete(ctx, id); err != nil {
-			fmt.Printf("Warning: failed to delete driver from cache: %v\n", err)
+		if err := s.cache.Delete(context.WithoutCancel(ctx), id); err != nil {
+			s.logger.Warn(ctx, "failed to delete driver from cache", "driver_id", id, "error", err)
 		}
 	}
 
+	deletedAt := time.Now().UTC()
+	s.publish(ctx, domain.DriverEvent{Type: domain.DriverDeleted, DriverID: id, OccurredAt: deletedAt})
+	s.replicate(ctx, domain.DriverChange{Op: domain.ReplicationDelete, DriverID: id, UpdatedAt: deletedAt})
 	return nil
 }
 
-func (s *DriverApplicationService) UpdateDriverLocation(id string, location domain.Point) error {
+// ListDrivers returns a page of the drivers ordered by ID, one driver more than
+// the page is read to know whether another page follows
+func (s *DriverApplicationService) ListDrivers(ctx context.Context, req domain.ListDriversRequest) (*domain.DriverPage, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("%w: invalid request: %w", domain.ErrValidation, err)
+	}
+	filter, err := req.Filter()
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid request: %w", domain.ErrValidation, err)
+	}
+
+	var afterID string
+	if req.Cursor != "" {
+		if afterID, err = domain.DecodeListCursor(req.Cursor); err != nil {
+			return nil, fmt.Errorf("%w: %w", domain.ErrValidation, err)
+		}
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = domain.DefaultListLimit
+	}
+
+	drivers, err := s.repo.List(ctx, filter, afterID, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list drivers: %w", err)
+	}
+
+	page := &domain.DriverPage{Drivers: drivers}
+	if len(drivers) > limit {
+		page.Drivers = drivers[:limit]
+		page.NextCursor = domain.EncodeListCursor(page.Drivers[limit-1].ID)
+	}
+	page.Count = len(page.Drivers)
+	return page, nil
+}
+
+// Heartbeat records that the driver is still online without a location update,
+// the cached driver is dropped so reads do not return the previous LastSeenAt
+func (s *DriverApplicationService) Heartbeat(ctx context.Context, id string) (*domain.Heartbeat, error) {
 	if strings.TrimSpace(id) == "" {
-		return fmt.Errorf("driver ID is required")
+		return nil, fmt.Errorf("%w: driver ID is required", domain.ErrValidation)
 	}
 
-	if err := s.validator.Struct(location); err != nil {
-		return fmt.Errorf("invalid location: %w", err)
+	now := time.Now()
+	if err := s.repo.Heartbeat(ctx, id, now); err != nil {
+		return nil, fmt.Errorf("failed to record heartbeat: %w", err)
 	}
 
-	driver, err := s.repo.GetByID(id)
+	if s.cache != nil {
+		if err := s.cache.Delete(context.WithoutCancel(ctx), id); err != nil {
+			s.logger.Warn(ctx, "failed to delete driver from cache", "driver_id", id, "error", err)
+		}
+	}
+
+	return &domain.Heartbeat{DriverID: id, LastSeenAt: now}, nil
+}
+
+// UpdateDriverLocation also replaces speed and heading, an update without them
+// clears the previous values instead of keeping a stale movement
+func (s *DriverApplicationService) UpdateDriverLocation(ctx context.Context, id string, update domain.LocationUpdate) error {
+	if strings.TrimSpace(id) == "" {
+		return fmt.Errorf("%w: driver ID is required", domain.ErrValidation)
+	}
+
+	if err := s.validator.Struct(update); err != nil {
+		return fmt.Errorf("%w: invalid location: %w", domain.ErrValidation, err)
+	}
+
+	driver, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return fmt.Errorf("failed to get driver: %w", err)
 	}
 
-	driver.Location = location
+	driver.Location, driver.RawLocation = s.snapToRoad(ctx, driver, update.Point)
+	driver.Speed = update.Speed
+	driver.Heading = update.Heading
 	driver.UpdatedAt = time.Now()
 
-	if err := s.repo.Update(driver); err != nil {
+	if err := s.repo.Update(ctx, driver); err != nil {
 		return fmt.Errorf("failed to update driver location: %w", err)
 	}
 
-	ctx := context.Background()
 	if s.cache != nil {
-		if err := s.cache.Delete(ctx, id); err != nil {
-			fmt.Printf("Warning: failed to delete driver from cache: %v\n", err)
+		if err := s.cache.Delete(context.WithoutCancel(ctx), id); err != nil {
+			s.logger.Warn(ctx, "failed to delete driver from cache", "driver_id", id, "error", err)
+		}
+	}
+
+	s.publish(ctx, domain.NewDriverEvent(domain.DriverLocationUpdated, driver))
+	s.replicate(ctx, domain.NewDriverUpsert(driver))
+	return nil
+}
+
+// snapToRoad matches the previous and the new position so the matcher can pick the
+// road the driver is driving on, the raw position is kept next to the snapped one.
+// Matching is best effort: on failure the raw position is used as the location.
+func (s *DriverApplicationService) snapToRoad(ctx context.Context, driver *domain.Driver, location domain.Point) (domain.Point, *domain.Point) {
+	if s.matcher == nil {
+		return location, nil
+	}
+
+	trace := []domain.Point{location}
+	previous := driver.Location
+	if driver.RawLocation != nil {
+		previous = *driver.RawLocation
+	}
+	if len(previous.Coordinates) == 2 {
+		trace = []domain.Point{previous, location}
+	}
+
+	snapped, err := s.matcher.Match(ctx, trace)
+	if err != nil || len(snapped) != len(trace) {
+		s.logger.Warn(ctx, "failed to snap location of driver", "driver_id", driver.ID, "error", err)
+		return location, nil
+	}
+
+	raw := location
+	return snapped[len(snapped)-1], &raw
+}
+
+func (s *DriverApplicationService) UpdateDriverStatus(ctx context.Context, id string, status string) error {
+	if strings.TrimSpace(id) == "" {
+		return fmt.Errorf("%w: driver ID is required", domain.ErrValidation)
+	}
+
+	if err := s.validator.Struct(domain.UpdateStatusRequest{Status: status}); err != nil {
+		return fmt.Errorf("%w: invalid status: %w", domain.ErrValidation, err)
+	}
+
+	driver, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get driver: %w", err)
+	}
+
+	driver.Status = status
+	driver.UpdatedAt = time.Now()
+
+	if err := s.repo.Update(ctx, driver); err != nil {
+		return fmt.Errorf("failed to update driver status: %w", err)
+	}
+
+	if s.cache != nil {
+		if err := s.cache.Delete(context.WithoutCancel(ctx), id); err != nil {
+			s.logger.Warn(ctx, "failed to delete driver from cache", "driver_id", id, "error", err)
 		}
 	}
 
+	s.publish(ctx, domain.NewDriverEvent(domain.DriverStatusChanged, driver))
+	s.replicate(ctx, domain.NewDriverUpsert(driver))
 	return nil
 }
 
-func (s *DriverApplicationService) UpdateDriver(driver *domain.Driver) error {
+func (s *DriverApplicationService) UpdateDriver(ctx context.Context, driver *domain.Driver) error {
 	if driver == nil {
-		return fmt.Errorf("driver is required")
+		return fmt.Errorf("%w: driver is required", domain.ErrValidation)
 	}
 
 	if err := s.validator.Struct(driver); err != nil {
-		return fmt.Errorf("invalid driver: %w", err)
+		return fmt.Errorf("%w: invalid driver: %w", domain.ErrValidation, err)
 	}
+	driver.VehicleType = domain.NormalizeVehicleType(driver.VehicleType)
 
-	if err := s.repo.Update(driver); err != nil {
+	if err := s.repo.Update(ctx, driver); err != nil {
 		return fmt.Errorf("failed to update driver: %w", err)
 	}
 
-	ctx := context.Background()
 	if s.cache != nil {
-		if err := s.cache.Delete(ctx, driver.ID); err != nil {
-			fmt.Printf("Warning: failed to delete driver from cache: %v\n", err)
+		if err := s.cache.Delete(context.WithoutCancel(ctx), driver.ID); err != nil {
+			s.logger.Warn(ctx, "failed to delete driver from cache", "driver_id", driver.ID, "error", err)
 		}
 	}
 
+	s.replicate(ctx, domain.NewDriverUpsert(driver))
 	return nil
 }
@@ -2,41 +2,86 @@ package application
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
 	"the-driver-location-service/internal/domain"
+	"the-driver-location-service/internal/ports/secondary"
 )
 
 type mockRepo struct{ mock.Mock }
 type mockCache struct{ mock.Mock }
 
 // --- mockRepo implementation ---
-func (m *mockRepo) Create(driver *domain.Driver) error {
+func (m *mockRepo) Create(_ context.Context, driver *domain.Driver) error {
 	args := m.Called(driver)
 	return args.Error(0)
 }
-func (m *mockRepo) BatchCreate(drivers []*domain.Driver) error {
+func (m *mockRepo) BatchCreate(_ context.Context, drivers []*domain.Driver) error {
 	args := m.Called(drivers)
 	return args.Error(0)
 }
-func (m *mockRepo) SearchNearby(location domain.Point, radiusMeters float64, limit int) ([]*domain.DriverWithDistance, error) {
-	args := m.Called(location, radiusMeters, limit)
+func (m *mockRepo) BatchCreateUnordered(_ context.Context, drivers []*domain.Driver) ([]domain.BatchCreateFailure, error) {
+	args := m.Called(drivers)
+	failures, _ := args.Get(0).([]domain.BatchCreateFailure)
+	return failures, args.Error(1)
+}
+func (m *mockRepo) SearchNearby(_ context.Context, location domain.Point, minRadiusMeters, radiusMeters float64, limit int, filter domain.DriverFilter) ([]*domain.DriverWithDistance, error) {
+	args := m.Called(location, minRadiusMeters, radiusMeters, limit, filter)
+	return args.Get(0).([]*domain.DriverWithDistance), args.Error(1)
+}
+func (m *mockRepo) SearchGeoNear(_ context.Context, location domain.Point, minRadiusMeters, radiusMeters float64, limit int, filter domain.DriverFilter) ([]*domain.DriverWithDistance, error) {
+	args := m.Called(location, minRadiusMeters, radiusMeters, limit, filter)
 	return args.Get(0).([]*domain.DriverWithDistance), args.Error(1)
 }
-func (m *mockRepo) GetByID(id string) (*domain.Driver, error) {
+func (m *mockRepo) SearchWithin(_ context.Context, area domain.Area, limit int) ([]*domain.Driver, error) {
+	args := m.Called(area, limit)
+	return args.Get(0).([]*domain.Driver), args.Error(1)
+}
+func (m *mockRepo) SearchInBox(_ context.Context, req domain.BoxSearchRequest, limit int) ([]*domain.Driver, error) {
+	args := m.Called(req, limit)
+	return args.Get(0).([]*domain.Driver), args.Error(1)
+}
+func (m *mockRepo) ClusterInBox(_ context.Context, req domain.BoxSearchRequest, limit int) ([]domain.DriverCluster, error) {
+	args := m.Called(req, limit)
+	return args.Get(0).([]domain.DriverCluster), args.Error(1)
+}
+func (m *mockRepo) SearchInCell(_ context.Context, cell domain.CellID, limit int) ([]*domain.Driver, error) {
+	args := m.Called(cell, limit)
+	return args.Get(0).([]*domain.Driver), args.Error(1)
+}
+func (m *mockRepo) CountInCell(_ context.Context, cell domain.CellID, level, limit int) ([]domain.CellCount, error) {
+	args := m.Called(cell, level, limit)
+	return args.Get(0).([]domain.CellCount), args.Error(1)
+}
+func (m *mockRepo) GetByID(_ context.Context, id string) (*domain.Driver, error) {
 	args := m.Called(id)
 	return args.Get(0).(*domain.Driver), args.Error(1)
 }
-func (m *mockRepo) Update(driver *domain.Driver) error {
+func (m *mockRepo) List(_ context.Context, filter domain.DriverListFilter, afterID string, limit int) ([]*domain.Driver, error) {
+	args := m.Called(filter, afterID, limit)
+	return args.Get(0).([]*domain.Driver), args.Error(1)
+}
+func (m *mockRepo) Update(_ context.Context, driver *domain.Driver) error {
 	args := m.Called(driver)
 	return args.Error(0)
 }
-func (m *mockRepo) Delete(id string) error { args := m.Called(id); return args.Error(0) }
+func (m *mockRepo) Delete(_ context.Context, id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *mockRepo) Heartbeat(_ context.Context, id string, at time.Time) error {
+	args := m.Called(id, at)
+	return args.Error(0)
+}
 
 // --- mockCache implementation ---
 func (m *mockCache) Get(ctx context.Context, driverID string) (*domain.Driver, error) {
@@ -47,12 +92,65 @@ func (m *mockCache) Set(ctx context.Context, driverID string, driver *domain.Dri
 	args := m.Called(ctx, driverID, driver, ttl)
 	return args.Error(0)
 }
+func (m *mockCache) SetNotFound(ctx context.Context, driverID string, ttl time.Duration) error {
+	args := m.Called(ctx, driverID, ttl)
+	return args.Error(0)
+}
 func (m *mockCache) Delete(ctx context.Context, driverID string) error {
 	args := m.Called(ctx, driverID)
 	return args.Error(0)
 }
 func (m *mockCache) IsHealthy(ctx context.Context) bool { return true }
 
+type mockPublisher struct{ mock.Mock }
+
+func (m *mockPublisher) Publish(ctx context.Context, events ...domain.DriverEvent) error {
+	args := m.Called(events)
+	return args.Error(0)
+}
+func (m *mockPublisher) Close() error { return nil }
+
+// recordingReplicator keeps the queued changes
+type recordingReplicator struct {
+	changes []domain.DriverChange
+}
+
+func (r *recordingReplicator) Replicate(ctx context.Context, changes ...domain.DriverChange) error {
+	r.changes = append(r.changes, changes...)
+	return nil
+}
+
+func (r *recordingReplicator) Send(ctx context.Context, batch domain.ReplicationBatch) (*domain.ReplicationResult, error) {
+	return &domain.ReplicationResult{}, nil
+}
+
+func (r *recordingReplicator) Close() error { return nil }
+
+// recordingLogger keeps the message and fields of every warning
+type recordingLogger struct {
+	secondary.NopLogger
+	warnings []logEntry
+}
+
+type logEntry struct {
+	msg    string
+	fields []any
+}
+
+func (l *recordingLogger) Warn(ctx context.Context, msg string, keysAndValues ...any) {
+	l.warnings = append(l.warnings, logEntry{msg: msg, fields: keysAndValues})
+}
+
+type mockMatcher struct{ mock.Mock }
+
+func (m *mockMatcher) Match(ctx context.Context, trace []domain.Point) ([]domain.Point, error) {
+	args := m.Called(trace)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Point), args.Error(1)
+}
+
 // TestCreateDriver_Success tests successful driver creation with valid request data
 // Expected: Should create driver successfully, cache the driver, and return driver with correct data
 func TestCreateDriver_Success(t *testing.T) {
@@ -68,7 +166,7 @@ func TestCreateDriver_Success(t *testing.T) {
 	repo.On("Create", mock.AnythingOfType("*domain.Driver")).Return(nil)
 	cache.On("Set", mock.Anything, "driver1", mock.AnythingOfType("*domain.Driver"), mock.Anything).Return(nil)
 
-	d, err := service.CreateDriver(req)
+	d, err := service.CreateDriver(context.Background(), req)
 	assert.NoError(t, err)
 	assert.Equal(t, req.ID, d.ID)
 	assert.Equal(t, req.Location, d.Location)
@@ -98,7 +196,7 @@ func TestCreateDriver_WithEmptyID(t *testing.T) {
 	}).Return(nil)
 	cache.On("Set", mock.Anything, "auto-generated-id", mock.AnythingOfType("*domain.Driver"), mock.Anything).Return(nil)
 
-	d, err := service.CreateDriver(req)
+	d, err := service.CreateDriver(context.Background(), req)
 	assert.NoError(t, err)
 	assert.NotEmpty(t, d.ID)
 	assert.Equal(t, req.Location, d.Location)
@@ -107,6 +205,33 @@ func TestCreateDriver_WithEmptyID(t *testing.T) {
 	cache.AssertExpectations(t)
 }
 
+// TestCreateDriver_VehicleMetadata tests driver creation with vehicle type, capacity and attributes
+// Expected: Should store the vehicle type lower case and keep the capacity and attributes
+func TestCreateDriver_VehicleMetadata(t *testing.T) {
+	repo := new(mockRepo)
+	service := NewDriverApplicationService(repo, nil)
+
+	req := domain.CreateDriverRequest{
+		ID:          "driver1",
+		Location:    domain.NewPoint(29.0, 41.0),
+		VehicleType: " Van ",
+		Capacity:    6,
+		Attributes:  map[string]string{"wheelchair": "true"},
+	}
+	repo.On("Create", mock.AnythingOfType("*domain.Driver")).Return(nil)
+
+	d, err := service.CreateDriver(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, "van", d.VehicleType)
+	assert.Equal(t, 6, d.Capacity)
+	assert.Equal(t, map[string]string{"wheelchair": "true"}, d.Attributes)
+
+	req.Capacity = 101
+	_, err = service.CreateDriver(context.Background(), req)
+	assert.ErrorIs(t, err, domain.ErrValidation)
+	repo.AssertNumberOfCalls(t, "Create", 1)
+}
+
 // TestCreateDriver_InvalidRequest tests driver creation with invalid request data
 // Expected: Should return validation error and nil driver when request validation fails
 func TestCreateDriver_InvalidRequest(t *testing.T) {
@@ -115,10 +240,11 @@ func TestCreateDriver_InvalidRequest(t *testing.T) {
 	service := NewDriverApplicationService(repo, cache)
 
 	req := domain.CreateDriverRequest{ID: "", Location: domain.Point{}}
-	d, err := service.CreateDriver(req)
+	d, err := service.CreateDriver(context.Background(), req)
 	assert.Error(t, err)
 	assert.Nil(t, d)
 	assert.Contains(t, err.Error(), "invalid request")
+	assert.ErrorIs(t, err, domain.ErrValidation)
 }
 
 // TestCreateDriver_RepoError tests driver creation when repository operation fails
@@ -135,7 +261,7 @@ func TestCreateDriver_RepoError(t *testing.T) {
 
 	repo.On("Create", mock.AnythingOfType("*domain.Driver")).Return(errors.New("db error"))
 
-	d, err := service.CreateDriver(req)
+	d, err := service.CreateDriver(context.Background(), req)
 	assert.Error(t, err)
 	assert.Nil(t, d)
 	assert.Contains(t, err.Error(), "failed to create driver")
@@ -158,7 +284,7 @@ func TestCreateDriver_CacheError(t *testing.T) {
 	repo.On("Create", mock.AnythingOfType("*domain.Driver")).Return(nil)
 	cache.On("Set", mock.Anything, "driver3", mock.AnythingOfType("*domain.Driver"), mock.Anything).Return(errors.New("cache error"))
 
-	d, err := service.CreateDriver(req)
+	d, err := service.CreateDriver(context.Background(), req)
 	assert.NoError(t, err)
 	assert.Equal(t, req.ID, d.ID)
 
@@ -174,7 +300,7 @@ func TestGetDriver_CacheHit(t *testing.T) {
 	service := NewDriverApplicationService(repo, cache)
 	drv := &domain.Driver{ID: "d1", Location: domain.NewPoint(1, 2)}
 	cache.On("Get", mock.Anything, "d1").Return(drv, nil)
-	d, err := service.GetDriver("d1")
+	d, err := service.GetDriver(context.Background(), "d1")
 	assert.NoError(t, err)
 	assert.Equal(t, drv, d)
 	cache.AssertExpectations(t)
@@ -190,9 +316,45 @@ func TestGetDriver_CacheMiss(t *testing.T) {
 	cache.On("Get", mock.Anything, "d2").Return((*domain.Driver)(nil), nil)
 	repo.On("GetByID", "d2").Return(drv, nil)
 	cache.On("Set", mock.Anything, "d2", drv, mock.Anything).Return(nil)
-	d, err := service.GetDriver("d2")
+	d, err := service.GetDriver(context.Background(), "d2")
+	assert.NoError(t, err)
+	assert.Equal(t, drv, d)
+	repo.AssertExpectations(t)
+	cache.AssertExpectations(t)
+}
+
+// TestGetDriver_CacheTTL tests caching a driver with a configured TTL
+// Expected: Should cache the driver read from the repository for the configured TTL
+func TestGetDriver_CacheTTL(t *testing.T) {
+	repo := new(mockRepo)
+	cache := new(mockCache)
+	service := NewDriverApplicationService(repo, cache)
+	service.SetCacheTTL(10 * time.Minute)
+	drv := &domain.Driver{ID: "d2", Location: domain.NewPoint(1, 2)}
+	cache.On("Get", mock.Anything, "d2").Return((*domain.Driver)(nil), nil)
+	repo.On("GetByID", "d2").Return(drv, nil)
+	cache.On("Set", mock.Anything, "d2", drv, 10*time.Minute).Return(nil)
+
+	_, err := service.GetDriver(context.Background(), "d2")
+	assert.NoError(t, err)
+	cache.AssertExpectations(t)
+}
+
+// TestGetDriver_StrongConsistency tests a read asking for strong consistency
+// Expected: Should skip the cache, read the repository and refresh the cache
+func TestGetDriver_StrongConsistency(t *testing.T) {
+	repo := new(mockRepo)
+	cache := new(mockCache)
+	service := NewDriverApplicationService(repo, cache)
+	drv := &domain.Driver{ID: "d3", Location: domain.NewPoint(1, 2)}
+	repo.On("GetByID", "d3").Return(drv, nil)
+	cache.On("Set", mock.Anything, "d3", drv, mock.Anything).Return(nil)
+
+	ctx := domain.WithConsistency(context.Background(), domain.ConsistencyStrong)
+	d, err := service.GetDriver(ctx, "d3")
 	assert.NoError(t, err)
 	assert.Equal(t, drv, d)
+	cache.AssertNotCalled(t, "Get", mock.Anything, "d3")
 	repo.AssertExpectations(t)
 	cache.AssertExpectations(t)
 }
@@ -204,12 +366,12 @@ func TestGetDriver_EmptyID(t *testing.T) {
 	cache := new(mockCache)
 	service := NewDriverApplicationService(repo, cache)
 
-	d, err := service.GetDriver("")
+	d, err := service.GetDriver(context.Background(), "")
 	assert.Error(t, err)
 	assert.Nil(t, d)
 	assert.Contains(t, err.Error(), "driver ID is required")
 
-	d, err = service.GetDriver("   ")
+	d, err = service.GetDriver(context.Background(), "   ")
 	assert.Error(t, err)
 	assert.Nil(t, d)
 	assert.Contains(t, err.Error(), "driver ID is required")
@@ -225,7 +387,7 @@ func TestGetDriver_RepoError(t *testing.T) {
 	cache.On("Get", mock.Anything, "d3").Return((*domain.Driver)(nil), nil)
 	repo.On("GetByID", "d3").Return((*domain.Driver)(nil), errors.New("driver not found"))
 
-	d, err := service.GetDriver("d3")
+	d, err := service.GetDriver(context.Background(), "d3")
 	assert.Error(t, err)
 	assert.Nil(t, d)
 	assert.Contains(t, err.Error(), "failed to get driver")
@@ -234,21 +396,63 @@ func TestGetDriver_RepoError(t *testing.T) {
 	cache.AssertExpectations(t)
 }
 
+// TestGetDriver_CachesNotFound tests looking up an ID no driver has with negative caching on
+// Expected: Should cache the missing ID after the repository lookup and answer the next lookup from the cache
+func TestGetDriver_CachesNotFound(t *testing.T) {
+	repo := new(mockRepo)
+	cache := new(mockCache)
+	service := NewDriverApplicationService(repo, cache)
+	service.SetNotFoundCacheTTL(5 * time.Second)
+	notFound := fmt.Errorf("%w: d5", domain.ErrDriverNotFound)
+
+	cache.On("Get", mock.Anything, "d5").Return((*domain.Driver)(nil), nil).Once()
+	repo.On("GetByID", "d5").Return((*domain.Driver)(nil), notFound).Once()
+	cache.On("SetNotFound", mock.Anything, "d5", 5*time.Second).Return(nil).Once()
+	_, err := service.GetDriver(context.Background(), "d5")
+	assert.ErrorIs(t, err, domain.ErrDriverNotFound)
+
+	cache.On("Get", mock.Anything, "d5").Return((*domain.Driver)(nil), notFound).Once()
+	_, err = service.GetDriver(context.Background(), "d5")
+	assert.ErrorIs(t, err, domain.ErrDriverNotFound)
+	repo.AssertNumberOfCalls(t, "GetByID", 1)
+	cache.AssertExpectations(t)
+}
+
+// TestGetDriver_NotFoundNotCached tests looking up an ID no driver has without negative caching
+// Expected: Should not cache the missing ID
+func TestGetDriver_NotFoundNotCached(t *testing.T) {
+	repo := new(mockRepo)
+	cache := new(mockCache)
+	service := NewDriverApplicationService(repo, cache)
+
+	cache.On("Get", mock.Anything, "d6").Return((*domain.Driver)(nil), nil)
+	repo.On("GetByID", "d6").Return((*domain.Driver)(nil), fmt.Errorf("%w: d6", domain.ErrDriverNotFound))
+	_, err := service.GetDriver(context.Background(), "d6")
+	assert.ErrorIs(t, err, domain.ErrDriverNotFound)
+	cache.AssertNotCalled(t, "SetNotFound", mock.Anything, mock.Anything, mock.Anything)
+}
+
 // TestGetDriver_CacheError tests driver retrieval when cache operations fail
-// Expected: Should fallback to repository and continue operation even when cache fails
+// Expected: Should fallback to repository, continue operation even when cache fails and log both failures with the driver ID
 func TestGetDriver_CacheError(t *testing.T) {
 	repo := new(mockRepo)
 	cache := new(mockCache)
+	logger := &recordingLogger{}
 	service := NewDriverApplicationService(repo, cache)
+	service.SetLogger(logger)
 	drv := &domain.Driver{ID: "d4", Location: domain.NewPoint(1, 2)}
 
 	cache.On("Get", mock.Anything, "d4").Return((*domain.Driver)(nil), errors.New("cache error"))
 	repo.On("GetByID", "d4").Return(drv, nil)
 	cache.On("Set", mock.Anything, "d4", drv, mock.Anything).Return(errors.New("cache error"))
 
-	d, err := service.GetDriver("d4")
+	d, err := service.GetDriver(context.Background(), "d4")
 	assert.NoError(t, err)
 	assert.Equal(t, drv, d)
+	require.Len(t, logger.warnings, 2)
+	assert.Equal(t, "failed to get driver from cache", logger.warnings[0].msg)
+	assert.Equal(t, []any{"driver_id", "d4", "error", errors.New("cache error")}, logger.warnings[0].fields)
+	assert.Equal(t, "failed to cache driver", logger.warnings[1].msg)
 
 	repo.AssertExpectations(t)
 	cache.AssertExpectations(t)
@@ -262,8 +466,8 @@ func TestSearchNearbyDrivers_Success(t *testing.T) {
 	service := NewDriverApplicationService(repo, cache)
 	req := domain.SearchRequest{Location: domain.NewPoint(1, 2), Radius: 100, Limit: 5}
 	drivers := []*domain.DriverWithDistance{{Driver: domain.Driver{ID: "d1"}, Distance: 10}}
-	repo.On("SearchNearby", req.Location, req.Radius, req.Limit).Return(drivers, nil)
-	result, err := service.SearchNearbyDrivers(req)
+	repo.On("SearchNearby", req.Location, req.MinRadius, req.Radius, req.Limit, domain.DriverFilter{}).Return(drivers, nil)
+	result, err := service.SearchNearbyDrivers(context.Background(), req)
 	assert.NoError(t, err)
 	assert.Equal(t, drivers, result)
 	repo.AssertExpectations(t)
@@ -277,7 +481,7 @@ func TestSearchNearbyDrivers_InvalidRequest(t *testing.T) {
 	service := NewDriverApplicationService(repo, cache)
 
 	req := domain.SearchRequest{Location: domain.Point{}, Radius: -1, Limit: -5}
-	result, err := service.SearchNearbyDrivers(req)
+	result, err := service.SearchNearbyDrivers(context.Background(), req)
 	assert.Error(t, err)
 	assert.Nil(t, result)
 	assert.Contains(t, err.Error(), "invalid request")
@@ -292,15 +496,321 @@ func TestSearchNearbyDrivers_DefaultLimit(t *testing.T) {
 	req := domain.SearchRequest{Location: domain.NewPoint(1, 2), Radius: 100, Limit: 0}
 	drivers := []*domain.DriverWithDistance{{Driver: domain.Driver{ID: "d1"}, Distance: 10}}
 
-	repo.On("SearchNearby", req.Location, req.Radius, 10).Return(drivers, nil)
+	repo.On("SearchNearby", req.Location, req.MinRadius, req.Radius, 10, domain.DriverFilter{}).Return(drivers, nil)
+
+	result, err := service.SearchNearbyDrivers(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, drivers, result)
+
+	repo.AssertExpectations(t)
+}
+
+// TestSearchNearbyDrivers_GeoNear tests nearby driver search with the geonear_search flag enabled
+// Expected: Should search with $geoNear instead of $near and keep the radii and limit
+func TestSearchNearbyDrivers_GeoNear(t *testing.T) {
+	repo := new(mockRepo)
+	service := NewDriverApplicationService(repo, nil)
+	flags := NewFeatureFlagApplicationService(&stubFlagProvider{flags: []domain.FeatureFlag{{Name: domain.FlagGeoNearSearch, Enabled: true}}}, "development")
+	assert.NoError(t, flags.Refresh(context.Background()))
+	service.SetFeatureFlags(flags)
+
+	req := domain.SearchRequest{Location: domain.NewPoint(1, 2), MinRadius: 100, Radius: 1000, Limit: 5}
+	drivers := []*domain.DriverWithDistance{{Driver: domain.Driver{ID: "d1"}, Distance: 412.5}}
+	repo.On("SearchGeoNear", req.Location, 100.0, 1000.0, 5, domain.DriverFilter{}).Return(drivers, nil)
+
+	result, err := service.SearchNearbyDrivers(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, drivers, result)
+	repo.AssertNotCalled(t, "SearchNearby", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	repo.AssertExpectations(t)
+}
+
+// TestSearchNearbyDrivers_GeoNearRollout tests nearby driver searches of several API keys with the geonear_search flag at 50%
+// Expected: Should search with $geoNear for some keys and with $near for the others, the same way for every search of a key
+func TestSearchNearbyDrivers_GeoNearRollout(t *testing.T) {
+	repo := new(mockRepo)
+	service := NewDriverApplicationService(repo, nil)
+	flag := domain.FeatureFlag{Name: domain.FlagGeoNearSearch, Enabled: true, Percentage: 50}
+	flags := NewFeatureFlagApplicationService(&stubFlagProvider{flags: []domain.FeatureFlag{flag}}, "development")
+	assert.NoError(t, flags.Refresh(context.Background()))
+	service.SetFeatureFlags(flags)
+
+	req := domain.SearchRequest{Location: domain.NewPoint(1, 2), Radius: 1000, Limit: 5}
+	repo.On("SearchGeoNear", req.Location, 0.0, 1000.0, 5, domain.DriverFilter{}).Return([]*domain.DriverWithDistance{}, nil)
+	repo.On("SearchNearby", req.Location, 0.0, 1000.0, 5, domain.DriverFilter{}).Return([]*domain.DriverWithDistance{}, nil)
+
+	geoNear := 0
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		ctx := domain.WithRolloutKey(context.Background(), key)
+		_, err := service.SearchNearbyDrivers(ctx, req)
+		assert.NoError(t, err)
+		_, err = service.SearchNearbyDrivers(ctx, req)
+		assert.NoError(t, err)
+		if flag.IsEnabledFor("development", key) {
+			geoNear++
+		}
+	}
 
-	result, err := service.SearchNearbyDrivers(req)
+	assert.Greater(t, geoNear, 20)
+	assert.Less(t, geoNear, 80)
+	repo.AssertNumberOfCalls(t, "SearchGeoNear", 2*geoNear)
+	repo.AssertNumberOfCalls(t, "SearchNearby", 2*(100-geoNear))
+}
+
+// TestSearchNearbyDrivers_VehicleFilter tests nearby driver search for a vehicle type and capacity
+// Expected: Should pass the lower case vehicle type and minimum capacity to the repository
+func TestSearchNearbyDrivers_VehicleFilter(t *testing.T) {
+	repo := new(mockRepo)
+	service := NewDriverApplicationService(repo, nil)
+	req := domain.SearchRequest{Location: domain.NewPoint(1, 2), Radius: 1000, Limit: 5, VehicleType: "SUV", MinCapacity: 6}
+	drivers := []*domain.DriverWithDistance{{Driver: domain.Driver{ID: "d1", VehicleType: "suv", Capacity: 7}, Distance: 120}}
+
+	repo.On("SearchNearby", req.Location, 0.0, 1000.0, 5, domain.DriverFilter{VehicleType: "suv", MinCapacity: 6}).Return(drivers, nil)
+
+	result, err := service.SearchNearbyDrivers(context.Background(), req)
 	assert.NoError(t, err)
 	assert.Equal(t, drivers, result)
+	repo.AssertExpectations(t)
+}
+
+// TestSearchNearbyDrivers_Annulus tests nearby driver search with a minimum radius
+// Expected: Should pass the minimum radius to the repository and reject one that is not below the radius
+func TestSearchNearbyDrivers_Annulus(t *testing.T) {
+	repo := new(mockRepo)
+	cache := new(mockCache)
+	service := NewDriverApplicationService(repo, cache)
+	req := domain.SearchRequest{Location: domain.NewPoint(1, 2), MinRadius: 2000, Radius: 5000, Limit: 5}
+	drivers := []*domain.DriverWithDistance{{Driver: domain.Driver{ID: "d1"}, Distance: 3000}}
+
+	repo.On("SearchNearby", req.Location, 2000.0, 5000.0, 5, domain.DriverFilter{}).Return(drivers, nil)
+
+	result, err := service.SearchNearbyDrivers(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, drivers, result)
+
+	req.MinRadius = 5000
+	result, err = service.SearchNearbyDrivers(context.Background(), req)
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	assert.Contains(t, err.Error(), "invalid request")
+
+	repo.AssertExpectations(t)
+}
+
+// TestSearchNearbyDrivers_MaxRadius tests nearby driver search beyond the largest radius
+// Expected: Should reject radii above the configured maximum naming the limit and search up to it
+func TestSearchNearbyDrivers_MaxRadius(t *testing.T) {
+	repo := new(mockRepo)
+	service := NewDriverApplicationService(repo, nil)
+	req := domain.SearchRequest{Location: domain.NewPoint(1, 2), Radius: 50001, Limit: 5}
+
+	result, err := service.SearchNearbyDrivers(context.Background(), req)
+	assert.ErrorIs(t, err, domain.ErrValidation)
+	assert.Nil(t, result)
+	assert.Contains(t, err.Error(), "radius must not exceed 50000 meters")
+
+	service.SetMaxSearchRadius(10000)
+	req.Radius = 10000
+	repo.On("SearchNearby", req.Location, 0.0, 10000.0, 5, domain.DriverFilter{}).Return([]*domain.DriverWithDistance{}, nil)
+	_, err = service.SearchNearbyDrivers(context.Background(), req)
+	assert.NoError(t, err)
+
+	req.Radius = 10000.5
+	_, err = service.SearchNearbyDrivers(context.Background(), req)
+	assert.Contains(t, err.Error(), "radius must not exceed 10000 meters")
+	repo.AssertExpectations(t)
+}
+
+// TestSearchNearbyDrivers_Defaults tests nearby driver search without a radius or a limit
+// Expected: Should require the radius until a default is set, search with the default radius and limit, and reject limits above the maximum
+func TestSearchNearbyDrivers_Defaults(t *testing.T) {
+	repo := new(mockRepo)
+	service := NewDriverApplicationService(repo, nil)
+	req := domain.SearchRequest{Location: domain.NewPoint(1, 2)}
+
+	_, err := service.SearchNearbyDrivers(context.Background(), req)
+	assert.ErrorIs(t, err, domain.ErrValidation)
+
+	service.SetDefaultSearchRadius(3000)
+	service.SetSearchLimits(25, 100)
+	repo.On("SearchNearby", req.Location, 0.0, 3000.0, 25, domain.DriverFilter{}).Return([]*domain.DriverWithDistance{}, nil)
+	_, err = service.SearchNearbyDrivers(context.Background(), req)
+	assert.NoError(t, err)
+
+	req.Limit = 101
+	_, err = service.SearchNearbyDrivers(context.Background(), req)
+	assert.ErrorIs(t, err, domain.ErrValidation)
+	assert.Contains(t, err.Error(), "limit must not exceed 100 drivers")
+	repo.AssertExpectations(t)
+}
+
+// TestSearchDriversWithin_DefaultLimit tests an area search without a limit
+// Expected: Should search the repository with the default area limit and return its drivers
+func TestSearchDriversWithin_DefaultLimit(t *testing.T) {
+	repo := new(mockRepo)
+	service := NewDriverApplicationService(repo, nil)
+	area := domain.Area{Type: "Polygon", Coordinates: json.RawMessage(`[[[29,41],[29.1,41],[29.1,41.1],[29,41.1],[29,41]]]`)}
+	repo.On("SearchWithin", area, domain.DefaultAreaSearchLimit).Return([]*domain.Driver{{ID: "d1"}}, nil)
+
+	drivers, err := service.SearchDriversWithin(context.Background(), domain.AreaSearchRequest{Area: area})
+	assert.NoError(t, err)
+	assert.Len(t, drivers, 1)
+	repo.AssertExpectations(t)
+}
+
+// TestSearchDriversWithin_InvalidArea tests area searches the service rejects
+// Expected: Should return a validation error without touching the repository
+func TestSearchDriversWithin_InvalidArea(t *testing.T) {
+	repo := new(mockRepo)
+	service := NewDriverApplicationService(repo, nil)
+
+	for _, req := range []domain.AreaSearchRequest{
+		{Area: domain.Area{Type: "Circle", Coordinates: json.RawMessage(`[29,41]`)}},
+		{Area: domain.Area{Type: "Polygon", Coordinates: json.RawMessage(`[[[29,41],[29.1,41],[29.1,41.1],[29,41.1]]]`)}},
+		{Area: domain.Area{Type: "Polygon", Coordinates: json.RawMessage(`[[[29,41],[29.1,41],[29.1,41.1],[29,41]]]`)}, Limit: 5000},
+	} {
+		_, err := service.SearchDriversWithin(context.Background(), req)
+		assert.ErrorIs(t, err, domain.ErrValidation)
+	}
+	repo.AssertNotCalled(t, "SearchWithin", mock.Anything, mock.Anything)
+}
+
+// TestSearchDriversInBox tests bounding box searches with and without clustering
+// Expected: Should return drivers by default and clusters when a geohash precision is given
+func TestSearchDriversInBox(t *testing.T) {
+	repo := new(mockRepo)
+	service := NewDriverApplicationService(repo, nil)
+	req := domain.BoxSearchRequest{MinLongitude: 28.9, MinLatitude: 40.9, MaxLongitude: 29.1, MaxLatitude: 41.1}
+	repo.On("SearchInBox", req, domain.DefaultBoxSearchLimit).Return([]*domain.Driver{{ID: "d1"}, {ID: "d2"}}, nil)
+
+	result, err := service.SearchDriversInBox(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result.Count)
+	assert.Len(t, result.Drivers, 2)
+	assert.Empty(t, result.Clusters)
+
+	req.Cluster, req.Limit = 5, 50
+	repo.On("ClusterInBox", req, 50).Return([]domain.DriverCluster{{Geohash: "sxk9w", Count: 2}}, nil)
+
+	result, err = service.SearchDriversInBox(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.Count)
+	assert.Empty(t, result.Drivers)
+	repo.AssertExpectations(t)
+}
+
+// TestListDrivers tests paging through the drivers
+// Expected: Should read one driver more than the page and return the cursor of the last driver of the page
+func TestListDrivers(t *testing.T) {
+	repo := new(mockRepo)
+	service := NewDriverApplicationService(repo, nil)
+	since := time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)
+	filter := domain.DriverListFilter{Status: domain.DriverStatusBusy, UpdatedSince: since}
+	repo.On("List", filter, "d1", 3).Return([]*domain.Driver{{ID: "d2"}, {ID: "d3"}, {ID: "d4"}}, nil)
+
+	page, err := service.ListDrivers(context.Background(), domain.ListDriversRequest{
+		Cursor:       domain.EncodeListCursor("d1"),
+		Limit:        2,
+		Status:       domain.DriverStatusBusy,
+		UpdatedSince: "2026-10-15T00:00:00Z",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, page.Count)
+	assert.Equal(t, "d3", page.Drivers[1].ID)
+	assert.Equal(t, domain.EncodeListCursor("d3"), page.NextCursor)
+
+	// the last page has no cursor
+	repo.On("List", domain.DriverListFilter{}, "", domain.DefaultListLimit+1).Return([]*domain.Driver{{ID: "d1"}}, nil)
+	page, err = service.ListDrivers(context.Background(), domain.ListDriversRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, page.Count)
+	assert.Empty(t, page.NextCursor)
+	repo.AssertExpectations(t)
+}
+
+// TestListDrivers_InvalidRequest tests listing drivers with invalid parameters
+// Expected: Should return a validation error for a bad cursor, limit, status or timestamp
+func TestListDrivers_InvalidRequest(t *testing.T) {
+	service := NewDriverApplicationService(new(mockRepo), nil)
+	for _, req := range []domain.ListDriversRequest{
+		{Cursor: "not base64!"},
+		{Limit: domain.MaxListLimit + 1},
+		{Status: "sleeping"},
+		{UpdatedSince: "yesterday"},
+	} {
+		_, err := service.ListDrivers(context.Background(), req)
+		assert.ErrorIs(t, err, domain.ErrValidation, "%+v", req)
+	}
+}
+
+// TestSearchDriversInBox_InvalidBox tests boxes the service rejects
+// Expected: Should return a validation error without touching the repository
+func TestSearchDriversInBox_InvalidBox(t *testing.T) {
+	repo := new(mockRepo)
+	service := NewDriverApplicationService(repo, nil)
+
+	for _, req := range []domain.BoxSearchRequest{
+		{MinLongitude: 29.1, MinLatitude: 40.9, MaxLongitude: 28.9, MaxLatitude: 41.1},
+		{MinLongitude: 28.9, MinLatitude: 41.1, MaxLongitude: 29.1, MaxLatitude: 41.1},
+		{MinLongitude: 28.9, MinLatitude: 40.9, MaxLongitude: 190, MaxLatitude: 41.1},
+		{MinLongitude: 28.9, MinLatitude: 40.9, MaxLongitude: 29.1, MaxLatitude: 41.1, Cluster: 9},
+	} {
+		_, err := service.SearchDriversInBox(context.Background(), req)
+		assert.ErrorIs(t, err, domain.ErrValidation)
+	}
+	repo.AssertNotCalled(t, "SearchInBox", mock.Anything, mock.Anything)
+}
+
+// TestSearchDriversInCell tests S2 cell searches with and without counting
+// Expected: Should search the range of the cell and count by the configured level unless one is asked for
+func TestSearchDriversInCell(t *testing.T) {
+	repo := new(mockRepo)
+	service := NewDriverApplicationService(repo, nil)
+	service.SetCellCountLevel(14)
+	cell := domain.CellIDFromToken("89c25a3")
 
+	repo.On("SearchInCell", cell, domain.DefaultCellSearchLimit).Return([]*domain.Driver{{ID: "d1"}}, nil)
+	result, err := service.SearchDriversInCell(context.Background(), domain.CellSearchRequest{Token: "89c25a3"})
+	assert.NoError(t, err)
+	assert.Equal(t, "89c25a3", result.Cell)
+	assert.Equal(t, 12, result.Level)
+	assert.Equal(t, 1, result.Count)
+
+	repo.On("CountInCell", cell, 14, 50).Return([]domain.CellCount{{Cell: "89c25a2c", Level: 14, Count: 3}}, nil)
+	result, err = service.SearchDriversInCell(context.Background(), domain.CellSearchRequest{Token: "89c25a3", Count: true, Limit: 50})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.Count)
+	assert.Empty(t, result.Drivers)
+
+	// the configured level is not finer than a level 15 cell
+	small := cell.Parent(12).RangeMin().Parent(15)
+	repo.On("CountInCell", small, 16, domain.DefaultCellSearchLimit).Return([]domain.CellCount{}, nil)
+	_, err = service.SearchDriversInCell(context.Background(), domain.CellSearchRequest{Token: small.Token(), Count: true})
+	assert.NoError(t, err)
 	repo.AssertExpectations(t)
 }
 
+// TestSearchDriversInCell_Invalid tests cell searches the service rejects
+// Expected: Should return a validation error without touching the repository
+func TestSearchDriversInCell_Invalid(t *testing.T) {
+	repo := new(mockRepo)
+	service := NewDriverApplicationService(repo, nil)
+
+	for _, req := range []domain.CellSearchRequest{
+		{},
+		{Token: "zz"},
+		{Token: "89c25a3", Limit: 5000},
+		{Token: "89c25a3", Count: true, Level: 12},
+		{Token: "89c25a3", Count: true, Level: 25},
+	} {
+		_, err := service.SearchDriversInCell(context.Background(), req)
+		assert.ErrorIs(t, err, domain.ErrValidation)
+	}
+	repo.AssertNotCalled(t, "SearchInCell", mock.Anything, mock.Anything)
+	repo.AssertNotCalled(t, "CountInCell", mock.Anything, mock.Anything, mock.Anything)
+}
+
 // TestSearchNearbyDrivers_RepoError tests nearby driver search when repository operation fails
 // Expected: Should return repository error when search operation fails
 func TestSearchNearbyDrivers_RepoError(t *testing.T) {
@@ -309,9 +819,9 @@ func TestSearchNearbyDrivers_RepoError(t *testing.T) {
 	service := NewDriverApplicationService(repo, cache)
 	req := domain.SearchRequest{Location: domain.NewPoint(1, 2), Radius: 100, Limit: 5}
 
-	repo.On("SearchNearby", req.Location, req.Radius, req.Limit).Return(([]*domain.DriverWithDistance)(nil), errors.New("search error"))
+	repo.On("SearchNearby", req.Location, req.MinRadius, req.Radius, req.Limit, domain.DriverFilter{}).Return(([]*domain.DriverWithDistance)(nil), errors.New("search error"))
 
-	result, err := service.SearchNearbyDrivers(req)
+	result, err := service.SearchNearbyDrivers(context.Background(), req)
 	assert.Error(t, err)
 	assert.Nil(t, result)
 	assert.Contains(t, err.Error(), "failed to search nearby drivers")
@@ -326,29 +836,123 @@ func TestUpdateDriverLocation_Success(t *testing.T) {
 	cache := new(mockCache)
 	service := NewDriverApplicationService(repo, cache)
 	drv := &domain.Driver{ID: "d1", Location: domain.NewPoint(1, 2)}
-	newLoc := domain.NewPoint(3, 4)
+	newLoc := domain.LocationUpdate{Point: domain.NewPoint(3, 4)}
 	repo.On("GetByID", "d1").Return(drv, nil)
 	repo.On("Update", mock.Anything).Return(nil)
 	cache.On("Delete", mock.Anything, "d1").Return(nil)
-	err := service.UpdateDriverLocation("d1", newLoc)
+	err := service.UpdateDriverLocation(context.Background(), "d1", newLoc)
 	assert.NoError(t, err)
 	repo.AssertExpectations(t)
 	cache.AssertExpectations(t)
 }
 
+// TestUpdateDriverLocation_SpeedAndHeading tests driver location update with movement data
+// Expected: Should store speed and heading, clear them when omitted and reject an invalid heading
+func TestUpdateDriverLocation_SpeedAndHeading(t *testing.T) {
+	repo := new(mockRepo)
+	cache := new(mockCache)
+	service := NewDriverApplicationService(repo, cache)
+	speed, heading := 12.5, 270.0
+	drv := &domain.Driver{ID: "d1", Location: domain.NewPoint(1, 2)}
+	repo.On("GetByID", "d1").Return(drv, nil)
+	repo.On("Update", mock.Anything).Return(nil)
+	cache.On("Delete", mock.Anything, "d1").Return(nil)
+
+	err := service.UpdateDriverLocation(context.Background(), "d1", domain.LocationUpdate{Point: domain.NewPoint(3, 4), Speed: &speed, Heading: &heading})
+	assert.NoError(t, err)
+	assert.Equal(t, 12.5, *drv.Speed)
+	assert.Equal(t, 270.0, *drv.Heading)
+
+	err = service.UpdateDriverLocation(context.Background(), "d1", domain.LocationUpdate{Point: domain.NewPoint(3, 5)})
+	assert.NoError(t, err)
+	assert.Nil(t, drv.Speed)
+	assert.Nil(t, drv.Heading)
+
+	invalidHeading := 360.0
+	err = service.UpdateDriverLocation(context.Background(), "d1", domain.LocationUpdate{Point: domain.NewPoint(3, 4), Heading: &invalidHeading})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid location")
+	assert.ErrorIs(t, err, domain.ErrValidation)
+}
+
+// TestUpdateDriverLocation_MapMatching tests snapping location updates to roads
+// Expected: Should store the snapped location with the raw one and fall back to the raw location when matching fails
+func TestUpdateDriverLocation_MapMatching(t *testing.T) {
+	repo := new(mockRepo)
+	cache := new(mockCache)
+	matcher := new(mockMatcher)
+	service := NewDriverApplicationService(repo, cache)
+	service.SetMapMatcher(matcher)
+	drv := &domain.Driver{ID: "d1", Location: domain.NewPoint(1, 2)}
+	repo.On("GetByID", "d1").Return(drv, nil)
+	repo.On("Update", mock.Anything).Return(nil)
+	cache.On("Delete", mock.Anything, "d1").Return(nil)
+
+	trace := []domain.Point{domain.NewPoint(1, 2), domain.NewPoint(3, 4)}
+	matcher.On("Match", trace).Return([]domain.Point{domain.NewPoint(1, 2), domain.NewPoint(3.1, 4.1)}, nil).Once()
+	err := service.UpdateDriverLocation(context.Background(), "d1", domain.LocationUpdate{Point: domain.NewPoint(3, 4)})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.NewPoint(3.1, 4.1), drv.Location)
+	assert.Equal(t, domain.NewPoint(3, 4), *drv.RawLocation)
+
+	// the next trace starts from the previous raw position, not the snapped one
+	trace = []domain.Point{domain.NewPoint(3, 4), domain.NewPoint(5, 6)}
+	matcher.On("Match", trace).Return(nil, errors.New("osrm unavailable")).Once()
+	err = service.UpdateDriverLocation(context.Background(), "d1", domain.LocationUpdate{Point: domain.NewPoint(5, 6)})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.NewPoint(5, 6), drv.Location)
+	assert.Nil(t, drv.RawLocation)
+	matcher.AssertExpectations(t)
+}
+
+// TestUpdateDriverStatus_Success tests driver status update
+// Expected: Should store the new status and invalidate the cached driver
+func TestUpdateDriverStatus_Success(t *testing.T) {
+	repo := new(mockRepo)
+	cache := new(mockCache)
+	service := NewDriverApplicationService(repo, cache)
+	drv := &domain.Driver{ID: "d1", Location: domain.NewPoint(1, 2), Status: domain.DriverStatusAvailable}
+	repo.On("GetByID", "d1").Return(drv, nil)
+	repo.On("Update", mock.Anything).Return(nil)
+	cache.On("Delete", mock.Anything, "d1").Return(nil)
+
+	err := service.UpdateDriverStatus(context.Background(), "d1", domain.DriverStatusBusy)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.DriverStatusBusy, drv.Status)
+	repo.AssertExpectations(t)
+	cache.AssertExpectations(t)
+}
+
+// TestUpdateDriverStatus_InvalidStatus tests driver status update with an unknown status
+// Expected: Should return a validation error without touching the repository
+func TestUpdateDriverStatus_InvalidStatus(t *testing.T) {
+	repo := new(mockRepo)
+	cache := new(mockCache)
+	service := NewDriverApplicationService(repo, cache)
+
+	err := service.UpdateDriverStatus(context.Background(), "d1", "sleeping")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid status")
+	assert.ErrorIs(t, err, domain.ErrValidation)
+
+	err = service.UpdateDriverStatus(context.Background(), "", domain.DriverStatusBusy)
+	assert.Error(t, err)
+	repo.AssertExpectations(t)
+}
+
 // TestUpdateDriverLocation_EmptyID tests driver location update with empty driver ID
 // Expected: Should return error when driver ID is empty or whitespace
 func TestUpdateDriverLocation_EmptyID(t *testing.T) {
 	repo := new(mockRepo)
 	cache := new(mockCache)
 	service := NewDriverApplicationService(repo, cache)
-	newLoc := domain.NewPoint(3, 4)
+	newLoc := domain.LocationUpdate{Point: domain.NewPoint(3, 4)}
 
-	err := service.UpdateDriverLocation("", newLoc)
+	err := service.UpdateDriverLocation(context.Background(), "", newLoc)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "driver ID is required")
 
-	err = service.UpdateDriverLocation("   ", newLoc)
+	err = service.UpdateDriverLocation(context.Background(), "   ", newLoc)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "driver ID is required")
 }
@@ -359,9 +963,9 @@ func TestUpdateDriverLocation_InvalidLocation(t *testing.T) {
 	repo := new(mockRepo)
 	cache := new(mockCache)
 	service := NewDriverApplicationService(repo, cache)
-	invalidLoc := domain.Point{}
+	invalidLoc := domain.LocationUpdate{}
 
-	err := service.UpdateDriverLocation("d1", invalidLoc)
+	err := service.UpdateDriverLocation(context.Background(), "d1", invalidLoc)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "invalid location")
 }
@@ -372,11 +976,11 @@ func TestUpdateDriverLocation_DriverNotFound(t *testing.T) {
 	repo := new(mockRepo)
 	cache := new(mockCache)
 	service := NewDriverApplicationService(repo, cache)
-	newLoc := domain.NewPoint(3, 4)
+	newLoc := domain.LocationUpdate{Point: domain.NewPoint(3, 4)}
 
 	repo.On("GetByID", "d1").Return((*domain.Driver)(nil), errors.New("driver not found"))
 
-	err := service.UpdateDriverLocation("d1", newLoc)
+	err := service.UpdateDriverLocation(context.Background(), "d1", newLoc)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to get driver")
 
@@ -390,12 +994,12 @@ func TestUpdateDriverLocation_UpdateError(t *testing.T) {
 	cache := new(mockCache)
 	service := NewDriverApplicationService(repo, cache)
 	drv := &domain.Driver{ID: "d1", Location: domain.NewPoint(1, 2)}
-	newLoc := domain.NewPoint(3, 4)
+	newLoc := domain.LocationUpdate{Point: domain.NewPoint(3, 4)}
 
 	repo.On("GetByID", "d1").Return(drv, nil)
 	repo.On("Update", mock.Anything).Return(errors.New("update error"))
 
-	err := service.UpdateDriverLocation("d1", newLoc)
+	err := service.UpdateDriverLocation(context.Background(), "d1", newLoc)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to update driver location")
 
@@ -403,15 +1007,64 @@ func TestUpdateDriverLocation_UpdateError(t *testing.T) {
 }
 
 // TestDeleteDriver_Success tests successful driver deletion
-// Expected: Should delete driver from repository and cache, invalidate nearby cache
+// Expected: Should delete driver from repository and cache
 func TestDeleteDriver_Success(t *testing.T) {
 	repo := new(mockRepo)
 	cache := new(mockCache)
 	service := NewDriverApplicationService(repo, cache)
 	repo.On("Delete", "d1").Return(nil)
 	cache.On("Delete", mock.Anything, "d1").Return(nil)
-	err := service.DeleteDriver("d1")
+	err := service.DeleteDriver(context.Background(), "d1")
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+	cache.AssertExpectations(t)
+}
+
+// TestHeartbeat_Success tests recording a driver heartbeat
+// Expected: Should record the heartbeat at the current time and delete the cached driver
+func TestHeartbeat_Success(t *testing.T) {
+	repo := new(mockRepo)
+	cache := new(mockCache)
+	service := NewDriverApplicationService(repo, cache)
+	repo.On("Heartbeat", "d1", mock.AnythingOfType("time.Time")).Return(nil)
+	cache.On("Delete", mock.Anything, "d1").Return(nil)
+
+	before := time.Now()
+	heartbeat, err := service.Heartbeat(context.Background(), "d1")
+	assert.NoError(t, err)
+	assert.Equal(t, "d1", heartbeat.DriverID)
+	assert.False(t, heartbeat.LastSeenAt.Before(before))
+	repo.AssertExpectations(t)
+	cache.AssertExpectations(t)
+}
+
+// TestHeartbeat_Errors tests heartbeats with an empty ID and of an unknown driver
+// Expected: Should return a validation error and the not found error of the repository
+func TestHeartbeat_Errors(t *testing.T) {
+	repo := new(mockRepo)
+	service := NewDriverApplicationService(repo, nil)
+
+	_, err := service.Heartbeat(context.Background(), " ")
+	assert.ErrorIs(t, err, domain.ErrValidation)
+
+	repo.On("Heartbeat", "missing", mock.AnythingOfType("time.Time")).Return(domain.ErrDriverNotFound)
+	_, err = service.Heartbeat(context.Background(), "missing")
+	assert.ErrorIs(t, err, domain.ErrDriverNotFound)
+}
+
+// TestHeartbeat_CacheError tests recording a heartbeat when the cached driver cannot be deleted
+// Expected: Should record the heartbeat and not fail the request
+func TestHeartbeat_CacheError(t *testing.T) {
+	repo := new(mockRepo)
+	cache := new(mockCache)
+	service := NewDriverApplicationService(repo, cache)
+
+	repo.On("Heartbeat", "d1", mock.AnythingOfType("time.Time")).Return(nil)
+	cache.On("Delete", mock.Anything, "d1").Return(errors.New("cache error"))
+
+	_, err := service.Heartbeat(context.Background(), "d1")
 	assert.NoError(t, err)
+
 	repo.AssertExpectations(t)
 	cache.AssertExpectations(t)
 }
@@ -423,11 +1076,11 @@ func TestDeleteDriver_EmptyID(t *testing.T) {
 	cache := new(mockCache)
 	service := NewDriverApplicationService(repo, cache)
 
-	err := service.DeleteDriver("")
+	err := service.DeleteDriver(context.Background(), "")
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "driver ID is required")
 
-	err = service.DeleteDriver("   ")
+	err = service.DeleteDriver(context.Background(), "   ")
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "driver ID is required")
 }
@@ -441,7 +1094,7 @@ func TestDeleteDriver_RepoError(t *testing.T) {
 
 	repo.On("Delete", "d1").Return(errors.New("delete error"))
 
-	err := service.DeleteDriver("d1")
+	err := service.DeleteDriver(context.Background(), "d1")
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to delete driver")
 
@@ -458,7 +1111,7 @@ func TestDeleteDriver_CacheError(t *testing.T) {
 	repo.On("Delete", "d1").Return(nil)
 	cache.On("Delete", mock.Anything, "d1").Return(errors.New("cache error"))
 
-	err := service.DeleteDriver("d1")
+	err := service.DeleteDriver(context.Background(), "d1")
 	assert.NoError(t, err)
 
 	repo.AssertExpectations(t)
@@ -474,7 +1127,7 @@ func TestUpdateDriver_Success(t *testing.T) {
 	drv := &domain.Driver{ID: "d1", Location: domain.NewPoint(1, 2)}
 	repo.On("Update", drv).Return(nil)
 	cache.On("Delete", mock.Anything, "d1").Return(nil)
-	err := service.UpdateDriver(drv)
+	err := service.UpdateDriver(context.Background(), drv)
 	assert.NoError(t, err)
 	repo.AssertExpectations(t)
 	cache.AssertExpectations(t)
@@ -487,7 +1140,7 @@ func TestUpdateDriver_NilDriver(t *testing.T) {
 	cache := new(mockCache)
 	service := NewDriverApplicationService(repo, cache)
 
-	err := service.UpdateDriver(nil)
+	err := service.UpdateDriver(context.Background(), nil)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "driver is required")
 }
@@ -500,7 +1153,7 @@ func TestUpdateDriver_InvalidDriver(t *testing.T) {
 	service := NewDriverApplicationService(repo, cache)
 	invalidDriver := &domain.Driver{ID: "d1", Location: domain.Point{}}
 
-	err := service.UpdateDriver(invalidDriver)
+	err := service.UpdateDriver(context.Background(), invalidDriver)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "invalid driver")
 }
@@ -515,7 +1168,7 @@ func TestUpdateDriver_RepoError(t *testing.T) {
 
 	repo.On("Update", drv).Return(errors.New("update error"))
 
-	err := service.UpdateDriver(drv)
+	err := service.UpdateDriver(context.Background(), drv)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to update driver")
 
@@ -538,7 +1191,7 @@ func TestBatchCreateDrivers_Success(t *testing.T) {
 
 	repo.On("BatchCreate", mock.Anything).Return(nil)
 
-	result, err := service.BatchCreateDrivers(req)
+	result, err := service.BatchCreateDrivers(context.Background(), req)
 	assert.NoError(t, err)
 	assert.Len(t, result, 2)
 	repo.AssertExpectations(t)
@@ -553,7 +1206,7 @@ func TestBatchCreateDrivers_EmptyDrivers(t *testing.T) {
 
 	req := domain.BatchCreateRequest{Drivers: []domain.CreateDriverRequest{}}
 
-	result, err := service.BatchCreateDrivers(req)
+	result, err := service.BatchCreateDrivers(context.Background(), req)
 	assert.Error(t, err)
 	assert.Nil(t, result)
 	assert.Contains(t, err.Error(), "invalid request")
@@ -567,7 +1220,7 @@ func TestBatchCreateDrivers_NilDrivers(t *testing.T) {
 	service := NewDriverApplicationService(repo, cache)
 
 	req := domain.BatchCreateRequest{Drivers: nil}
-	result, err := service.BatchCreateDrivers(req)
+	result, err := service.BatchCreateDrivers(context.Background(), req)
 	assert.Error(t, err)
 	assert.Nil(t, result)
 }
@@ -586,9 +1239,8 @@ func TestBatchCreateDrivers_RepoError(t *testing.T) {
 	}
 
 	repo.On("BatchCreate", mock.Anything).Return(errors.New("db error"))
-	cache.On("InvalidateNearbyCache", mock.Anything).Return(nil).Maybe()
 
-	result, err := service.BatchCreateDrivers(req)
+	result, err := service.BatchCreateDrivers(context.Background(), req)
 	assert.Error(t, err)
 	assert.Nil(t, result)
 	assert.Contains(t, err.Error(), "failed to batch create drivers")
@@ -610,7 +1262,7 @@ func TestBatchCreateDrivers_CacheError(t *testing.T) {
 
 	repo.On("BatchCreate", mock.Anything).Return(nil)
 
-	result, err := service.BatchCreateDrivers(req)
+	result, err := service.BatchCreateDrivers(context.Background(), req)
 	assert.NoError(t, err)
 	assert.Len(t, result, 1)
 
@@ -642,7 +1294,7 @@ func TestBatchCreateDrivers_WithEmptyIDs(t *testing.T) {
 		}
 	}).Return(nil)
 
-	result, err := service.BatchCreateDrivers(req)
+	result, err := service.BatchCreateDrivers(context.Background(), req)
 	assert.NoError(t, err)
 	assert.Len(t, result, 3)
 	assert.Equal(t, "d1", result[0].ID)
@@ -651,3 +1303,205 @@ func TestBatchCreateDrivers_WithEmptyIDs(t *testing.T) {
 
 	repo.AssertExpectations(t)
 }
+
+// TestBatchCreateDrivers_DuplicateInBatch tests a batch listing the same driver ID twice
+// Expected: Should return a validation error without touching the repository
+func TestBatchCreateDrivers_DuplicateInBatch(t *testing.T) {
+	repo := new(mockRepo)
+	service := NewDriverApplicationService(repo, nil)
+
+	req := domain.BatchCreateRequest{
+		Drivers: []domain.CreateDriverRequest{
+			{ID: "d1", Location: domain.NewPoint(1, 2)},
+			{ID: " d1", Location: domain.NewPoint(3, 4)},
+		},
+	}
+
+	_, err := service.BatchCreateDrivers(context.Background(), req)
+	assert.ErrorIs(t, err, domain.ErrValidation)
+	repo.AssertNotCalled(t, "BatchCreate", mock.Anything)
+}
+
+// TestBatchCreateDrivers_Conflict tests a batch with taken IDs without upsert
+// Expected: Should return the duplicate driver error naming the taken IDs
+func TestBatchCreateDrivers_Conflict(t *testing.T) {
+	repo := new(mockRepo)
+	service := NewDriverApplicationService(repo, nil)
+	repo.On("BatchCreate", mock.Anything).Return(&domain.DuplicateDriverError{IDs: []string{"d1"}})
+
+	req := domain.BatchCreateRequest{
+		Drivers: []domain.CreateDriverRequest{
+			{ID: "d1", Location: domain.NewPoint(1, 2)},
+			{ID: "d2", Location: domain.NewPoint(3, 4)},
+		},
+	}
+
+	_, err := service.BatchCreateDrivers(context.Background(), req)
+	assert.ErrorIs(t, err, domain.ErrConflict)
+	var duplicate *domain.DuplicateDriverError
+	assert.ErrorAs(t, err, &duplicate)
+	assert.Equal(t, []string{"d1"}, duplicate.IDs)
+	repo.AssertNumberOfCalls(t, "BatchCreate", 1)
+}
+
+// TestBatchCreateDrivers_Upsert tests a batch with taken IDs and upsert
+// Expected: Should update the taken drivers keeping their status and creation time, create the others and announce only those
+func TestBatchCreateDrivers_Upsert(t *testing.T) {
+	repo := new(mockRepo)
+	cache := new(mockCache)
+	publisher := new(mockPublisher)
+	service := NewDriverApplicationService(repo, cache)
+	service.SetEventPublisher(publisher)
+
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	existing := &domain.Driver{ID: "d1", Location: domain.NewPoint(1, 2), Status: domain.DriverStatusBusy, Version: 3, CreatedAt: createdAt}
+	repo.On("BatchCreate", mock.MatchedBy(func(drivers []*domain.Driver) bool { return len(drivers) == 2 })).
+		Return(&domain.DuplicateDriverError{IDs: []string{"d1"}}).Once()
+	repo.On("BatchCreate", mock.MatchedBy(func(drivers []*domain.Driver) bool { return len(drivers) == 1 && drivers[0].ID == "d2" })).
+		Return(nil).Once()
+	repo.On("GetByID", "d1").Return(existing, nil)
+	repo.On("Update", mock.Anything).Return(nil)
+	cache.On("Delete", mock.Anything, "d1").Return(nil)
+	publisher.On("Publish", mock.MatchedBy(func(events []domain.DriverEvent) bool {
+		return len(events) == 1 && events[0].DriverID == "d2"
+	})).Return(nil)
+
+	req := domain.BatchCreateRequest{
+		Drivers: []domain.CreateDriverRequest{
+			{ID: "d1", Location: domain.NewPoint(5, 6), VehicleType: "Van", Capacity: 6},
+			{ID: "d2", Location: domain.NewPoint(3, 4)},
+		},
+		Upsert: true,
+	}
+
+	result, err := service.BatchCreateDrivers(context.Background(), req)
+	require.NoError(t, err)
+	require.Len(t, result, 2)
+	assert.Equal(t, "d1", result[0].ID)
+	assert.Equal(t, domain.NewPoint(5, 6), result[0].Location)
+	assert.Equal(t, "van", result[0].VehicleType)
+	assert.Equal(t, 6, result[0].Capacity)
+	assert.Equal(t, domain.DriverStatusBusy, result[0].Status)
+	assert.Equal(t, createdAt, result[0].CreatedAt)
+	assert.Equal(t, "d2", result[1].ID)
+
+	repo.AssertExpectations(t)
+	cache.AssertExpectations(t)
+	publisher.AssertExpectations(t)
+}
+
+// TestBatchCreateDrivers_Unordered tests an unordered batch with a taken ID
+// Expected: Should create the other drivers, announce only those and list the taken one in a partial batch error
+func TestBatchCreateDrivers_Unordered(t *testing.T) {
+	repo := new(mockRepo)
+	publisher := new(mockPublisher)
+	service := NewDriverApplicationService(repo, nil)
+	service.SetEventPublisher(publisher)
+	failures := []domain.BatchCreateFailure{{Index: 0, ID: "d1", Reason: domain.BatchFailureDuplicateID}}
+	repo.On("BatchCreateUnordered", mock.Anything).Return(failures, nil)
+	publisher.On("Publish", mock.MatchedBy(func(events []domain.DriverEvent) bool {
+		return len(events) == 2 && events[0].DriverID == "d2" && events[1].DriverID == "d3"
+	})).Return(nil)
+
+	req := domain.BatchCreateRequest{
+		Drivers: []domain.CreateDriverRequest{
+			{ID: "d1", Location: domain.NewPoint(1, 2)},
+			{ID: "d2", Location: domain.NewPoint(3, 4)},
+			{ID: "d3", Location: domain.NewPoint(5, 6)},
+		},
+		Unordered: true,
+	}
+
+	result, err := service.BatchCreateDrivers(context.Background(), req)
+	var partial *domain.PartialBatchError
+	require.ErrorAs(t, err, &partial)
+	assert.Equal(t, failures, partial.Failed)
+	require.Len(t, result, 2)
+	assert.Equal(t, "d2", result[0].ID)
+	assert.Equal(t, "d3", result[1].ID)
+	repo.AssertNotCalled(t, "BatchCreate", mock.Anything)
+	publisher.AssertExpectations(t)
+
+	req.Upsert = true
+	_, err = service.BatchCreateDrivers(context.Background(), req)
+	assert.ErrorIs(t, err, domain.ErrValidation)
+}
+
+// TestDriverEvents tests publishing driver events after stored changes
+// Expected: Should publish created, location updated, status changed and deleted events and ignore publish failures
+func TestDriverEvents(t *testing.T) {
+	repo := new(mockRepo)
+	cache := new(mockCache)
+	publisher := new(mockPublisher)
+	service := NewDriverApplicationService(repo, cache)
+	service.SetEventPublisher(publisher)
+	drv := &domain.Driver{ID: "d1", Location: domain.NewPoint(1, 2)}
+	repo.On("Create", mock.Anything).Return(nil)
+	repo.On("GetByID", "d1").Return(drv, nil)
+	repo.On("Update", mock.Anything).Return(nil)
+	repo.On("Delete", "d1").Return(nil)
+	cache.On("Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	cache.On("Delete", mock.Anything, "d1").Return(nil)
+	publisher.On("Publish", mock.Anything).Return(nil).Times(3)
+	publisher.On("Publish", mock.Anything).Return(errors.New("broker down")).Once()
+
+	_, err := service.CreateDriver(context.Background(), domain.CreateDriverRequest{ID: "d1", Location: domain.NewPoint(1, 2)})
+	assert.NoError(t, err)
+	err = service.UpdateDriverLocation(context.Background(), "d1", domain.LocationUpdate{Point: domain.NewPoint(3, 4)})
+	assert.NoError(t, err)
+	err = service.UpdateDriverStatus(context.Background(), "d1", domain.DriverStatusAvailable)
+	assert.NoError(t, err)
+	err = service.DeleteDriver(context.Background(), "d1")
+	assert.NoError(t, err)
+
+	var types []domain.DriverEventType
+	for _, call := range publisher.Calls {
+		events := call.Arguments.Get(0).([]domain.DriverEvent)
+		assert.Len(t, events, 1)
+		assert.Equal(t, "d1", events[0].DriverID)
+		types = append(types, events[0].Type)
+	}
+	assert.Equal(t, []domain.DriverEventType{domain.DriverCreated, domain.DriverLocationUpdated, domain.DriverStatusChanged, domain.DriverDeleted}, types)
+	assert.Equal(t, domain.NewPoint(3, 4), *publisher.Calls[1].Arguments.Get(0).([]domain.DriverEvent)[0].Location)
+	assert.Equal(t, domain.DriverStatusAvailable, publisher.Calls[2].Arguments.Get(0).([]domain.DriverEvent)[0].Status)
+}
+
+// TestDriverReplication tests replicating stored driver changes to the standby region
+// Expected: Should queue an upsert with the stored driver for every write and a delete with the deletion time
+func TestDriverReplication(t *testing.T) {
+	repo := new(mockRepo)
+	cache := new(mockCache)
+	replicator := &recordingReplicator{}
+	service := NewDriverApplicationService(repo, cache)
+	service.SetReplicator(replicator)
+	drv := &domain.Driver{ID: "d1", Location: domain.NewPoint(1, 2)}
+	repo.On("Create", mock.Anything).Return(nil)
+	repo.On("GetByID", "d1").Return(drv, nil)
+	repo.On("Update", mock.Anything).Return(nil)
+	repo.On("Delete", "d1").Return(nil)
+	cache.On("Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	cache.On("Delete", mock.Anything, "d1").Return(nil)
+
+	_, err := service.CreateDriver(context.Background(), domain.CreateDriverRequest{ID: "d1", Location: domain.NewPoint(1, 2)})
+	require.NoError(t, err)
+	require.NoError(t, service.UpdateDriverLocation(context.Background(), "d1", domain.LocationUpdate{Point: domain.NewPoint(3, 4)}))
+	require.NoError(t, service.UpdateDriverStatus(context.Background(), "d1", domain.DriverStatusBusy))
+	before := time.Now()
+	require.NoError(t, service.DeleteDriver(context.Background(), "d1"))
+
+	require.Len(t, replicator.changes, 4)
+	for _, change := range replicator.changes[:3] {
+		assert.Equal(t, domain.ReplicationUpsert, change.Op)
+		assert.Equal(t, "d1", change.DriverID)
+		require.NotNil(t, change.Driver)
+		assert.Equal(t, change.Driver.UpdatedAt, change.UpdatedAt)
+	}
+	assert.Equal(t, domain.NewPoint(3, 4), replicator.changes[1].Driver.Location)
+	assert.Equal(t, domain.DriverStatusBusy, replicator.changes[2].Driver.Status)
+	assert.NotEqual(t, domain.DriverStatusBusy, replicator.changes[1].Driver.Status)
+
+	deleted := replicator.changes[3]
+	assert.Equal(t, domain.ReplicationDelete, deleted.Op)
+	assert.Nil(t, deleted.Driver)
+	assert.False(t, deleted.UpdatedAt.Before(before))
+}
@@ -0,0 +1,149 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+
+	"the-driver-location-service/internal/domain"
+	"the-driver-location-service/internal/ports/primary"
+	"the-driver-location-service/internal/ports/secondary"
+)
+
+// DuplicateApplicationService finds the clones repeated CSV imports leave behind.
+// Clones are read from the database, merges and deletes go through the driver
+// service so the cache, the geo index and the event stream follow.
+type DuplicateApplicationService struct {
+	store     secondary.DriverDuplicateStore
+	drivers   primary.DriverService
+	validator *validator.Validate
+}
+
+var _ primary.DuplicateService = (*DuplicateApplicationService)(nil)
+
+func NewDuplicateApplicationService(store secondary.DriverDuplicateStore, drivers primary.DriverService) *DuplicateApplicationService {
+	return &DuplicateApplicationService{
+		store:     store,
+		drivers:   drivers,
+		validator: domain.NewValidator(),
+	}
+}
+
+func (s *DuplicateApplicationService) FindDuplicates(ctx context.Context, req domain.DuplicateRequest) (*domain.DuplicateReport, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidDuplicateRequest, err)
+	}
+
+	action := req.Action
+	if action == "" {
+		action = domain.DuplicateActionReport
+	}
+	window := domain.DefaultDuplicateWindow
+	if req.WindowSeconds > 0 {
+		window = time.Duration(req.WindowSeconds) * time.Second
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = domain.DefaultDuplicateGroupLimit
+	}
+
+	colocated, err := s.store.ColocatedDrivers(ctx, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find colocated drivers: %w", err)
+	}
+
+	report := &domain.DuplicateReport{
+		Action:    action,
+		Groups:    []domain.DuplicateGroup{},
+		Truncated: len(colocated) >= limit,
+	}
+	for _, drivers := range colocated {
+		for _, clones := range clusterByCreation(drivers, window) {
+			keep, latest := pickKept(clones, action)
+			group := domain.DuplicateGroup{Location: keep.Location, KeepID: keep.ID}
+			for _, driver := range clones {
+				if driver.ID != keep.ID {
+					group.DuplicateIDs = append(group.DuplicateIDs, driver.ID)
+				}
+			}
+
+			if action != domain.DuplicateActionReport {
+				if err := s.resolve(ctx, keep, latest, group.DuplicateIDs); err != nil {
+					return nil, err
+				}
+				report.Removed += len(group.DuplicateIDs)
+			}
+			report.Duplicates += len(group.DuplicateIDs)
+			report.Groups = append(report.Groups, group)
+		}
+	}
+
+	sort.Slice(report.Groups, func(i, j int) bool { return report.Groups[i].KeepID < report.Groups[j].KeepID })
+	return report, nil
+}
+
+// resolve gives the kept driver the state of the latest clone and deletes the
+// others, a clone that is already gone was removed by a concurrent scan
+func (s *DuplicateApplicationService) resolve(ctx context.Context, keep, latest *domain.Driver, duplicateIDs []string) error {
+	if latest.ID != keep.ID {
+		merged := *latest
+		merged.ID = keep.ID
+		merged.CreatedAt = keep.CreatedAt
+		merged.Version = keep.Version
+		if err := s.drivers.UpdateDriver(ctx, &merged); err != nil {
+			return fmt.Errorf("failed to merge driver %s into %s: %w", latest.ID, keep.ID, err)
+		}
+	}
+
+	for _, id := range duplicateIDs {
+		if err := s.drivers.DeleteDriver(ctx, id); err != nil && !errors.Is(err, domain.ErrNotFound) {
+			return fmt.Errorf("failed to delete duplicate driver %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// clusterByCreation splits drivers sharing coordinates into the runs created
+// within window of the previous driver, runs of a single driver are not clones
+func clusterByCreation(drivers []*domain.Driver, window time.Duration) [][]*domain.Driver {
+	sorted := append([]*domain.Driver(nil), drivers...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if !sorted[i].CreatedAt.Equal(sorted[j].CreatedAt) {
+			return sorted[i].CreatedAt.Before(sorted[j].CreatedAt)
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	var clusters [][]*domain.Driver
+	start := 0
+	for i := 1; i <= len(sorted); i++ {
+		if i < len(sorted) && sorted[i].CreatedAt.Sub(sorted[i-1].CreatedAt) <= window {
+			continue
+		}
+		if i-start > 1 {
+			clusters = append(clusters, sorted[start:i])
+		}
+		start = i
+	}
+	return clusters
+}
+
+// pickKept returns the driver to keep and the most recently updated clone. A
+// driver app updating one of the clones makes it the latest, delete keeps it
+// as is while merge keeps the first ID handed out with the latest state.
+func pickKept(clones []*domain.Driver, action string) (keep, latest *domain.Driver) {
+	latest = clones[0]
+	for _, driver := range clones[1:] {
+		if driver.UpdatedAt.After(latest.UpdatedAt) {
+			latest = driver
+		}
+	}
+	if action == domain.DuplicateActionMerge {
+		return clones[0], latest
+	}
+	return latest, latest
+}
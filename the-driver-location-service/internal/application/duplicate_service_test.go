@@ -0,0 +1,144 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"the-driver-location-service/internal/domain"
+	"the-driver-location-service/internal/ports/primary"
+)
+
+type memoryDuplicateStore struct {
+	groups [][]*domain.Driver
+	limit  int
+	err    error
+}
+
+func (s *memoryDuplicateStore) ColocatedDrivers(ctx context.Context, limit int) ([][]*domain.Driver, error) {
+	s.limit = limit
+	return s.groups, s.err
+}
+
+// recordingDriverService records the writes of a duplicate scan, the other
+// driver service methods are not used by it
+type recordingDriverService struct {
+	primary.DriverService
+	updated []*domain.Driver
+	deleted []string
+	err     error
+}
+
+func (s *recordingDriverService) UpdateDriver(ctx context.Context, driver *domain.Driver) error {
+	s.updated = append(s.updated, driver)
+	return s.err
+}
+
+func (s *recordingDriverService) DeleteDriver(ctx context.Context, id string) error {
+	s.deleted = append(s.deleted, id)
+	return s.err
+}
+
+func newDuplicateStore() *memoryDuplicateStore {
+	imported := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	location := domain.NewPoint(29.0, 41.0)
+	return &memoryDuplicateStore{groups: [][]*domain.Driver{
+		{
+			{ID: "a", Location: location, Status: domain.DriverStatusAvailable, CreatedAt: imported, UpdatedAt: imported},
+			{ID: "b", Location: location, Status: domain.DriverStatusBusy, CreatedAt: imported.Add(time.Second), UpdatedAt: imported.Add(time.Hour)},
+			{ID: "c", Location: location, CreatedAt: imported.Add(2 * time.Second), UpdatedAt: imported.Add(2 * time.Second)},
+			// created a day later, a driver at the same place and not a clone
+			{ID: "d", Location: location, CreatedAt: imported.Add(24 * time.Hour), UpdatedAt: imported.Add(24 * time.Hour)},
+		},
+	}}
+}
+
+// TestFindDuplicates_Report tests scanning drivers at identical coordinates
+// Expected: Should group the drivers created within the window, keep the latest and change nothing
+func TestFindDuplicates_Report(t *testing.T) {
+	store := newDuplicateStore()
+	drivers := &recordingDriverService{}
+	service := NewDuplicateApplicationService(store, drivers)
+
+	report, err := service.FindDuplicates(context.Background(), domain.DuplicateRequest{})
+	require.NoError(t, err)
+
+	assert.Equal(t, domain.DuplicateActionReport, report.Action)
+	require.Len(t, report.Groups, 1)
+	assert.Equal(t, "b", report.Groups[0].KeepID)
+	assert.Equal(t, []string{"a", "c"}, report.Groups[0].DuplicateIDs)
+	assert.Equal(t, 2, report.Duplicates)
+	assert.Zero(t, report.Removed)
+	assert.False(t, report.Truncated)
+	assert.Equal(t, domain.DefaultDuplicateGroupLimit, store.limit)
+	assert.Empty(t, drivers.updated)
+	assert.Empty(t, drivers.deleted)
+
+	report, err = service.FindDuplicates(context.Background(), domain.DuplicateRequest{WindowSeconds: 2 * 86400, Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, report.Groups, 1)
+	assert.Equal(t, "d", report.Groups[0].KeepID)
+	assert.Equal(t, []string{"a", "b", "c"}, report.Groups[0].DuplicateIDs)
+	assert.True(t, report.Truncated)
+}
+
+// TestFindDuplicates_Delete tests deleting the clones
+// Expected: Should keep the most recently updated driver as it is and delete the others
+func TestFindDuplicates_Delete(t *testing.T) {
+	drivers := &recordingDriverService{}
+	service := NewDuplicateApplicationService(newDuplicateStore(), drivers)
+
+	report, err := service.FindDuplicates(context.Background(), domain.DuplicateRequest{Action: domain.DuplicateActionDelete})
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, report.Removed)
+	assert.Empty(t, drivers.updated)
+	assert.Equal(t, []string{"a", "c"}, drivers.deleted)
+}
+
+// TestFindDuplicates_Merge tests merging the clones
+// Expected: Should keep the first created ID with the state of the latest clone and delete the others
+func TestFindDuplicates_Merge(t *testing.T) {
+	drivers := &recordingDriverService{}
+	service := NewDuplicateApplicationService(newDuplicateStore(), drivers)
+
+	report, err := service.FindDuplicates(context.Background(), domain.DuplicateRequest{Action: domain.DuplicateActionMerge})
+	require.NoError(t, err)
+
+	require.Len(t, report.Groups, 1)
+	assert.Equal(t, "a", report.Groups[0].KeepID)
+	require.Len(t, drivers.updated, 1)
+	assert.Equal(t, "a", drivers.updated[0].ID)
+	assert.Equal(t, domain.DriverStatusBusy, drivers.updated[0].Status)
+	assert.Equal(t, time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC), drivers.updated[0].CreatedAt)
+	assert.Equal(t, []string{"b", "c"}, drivers.deleted)
+	assert.Equal(t, 2, report.Removed)
+}
+
+// TestFindDuplicates_Errors tests invalid requests and failing stores and writes
+// Expected: Should reject invalid requests, ignore clones already deleted and fail on other errors
+func TestFindDuplicates_Errors(t *testing.T) {
+	service := NewDuplicateApplicationService(newDuplicateStore(), &recordingDriverService{})
+	_, err := service.FindDuplicates(context.Background(), domain.DuplicateRequest{Action: "purge"})
+	assert.ErrorIs(t, err, domain.ErrInvalidDuplicateRequest)
+	assert.ErrorIs(t, err, domain.ErrValidation)
+
+	service = NewDuplicateApplicationService(&memoryDuplicateStore{err: errors.New("mongo unavailable")}, &recordingDriverService{})
+	_, err = service.FindDuplicates(context.Background(), domain.DuplicateRequest{})
+	assert.Error(t, err)
+
+	gone := &recordingDriverService{err: fmt.Errorf("%w: c", domain.ErrDriverNotFound)}
+	service = NewDuplicateApplicationService(newDuplicateStore(), gone)
+	_, err = service.FindDuplicates(context.Background(), domain.DuplicateRequest{Action: domain.DuplicateActionDelete})
+	assert.NoError(t, err)
+
+	failing := &recordingDriverService{err: errors.New("mongo unavailable")}
+	service = NewDuplicateApplicationService(newDuplicateStore(), failing)
+	_, err = service.FindDuplicates(context.Background(), domain.DuplicateRequest{Action: domain.DuplicateActionDelete})
+	assert.ErrorContains(t, err, "failed to delete duplicate driver a")
+}
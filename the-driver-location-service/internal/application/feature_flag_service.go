@@ -0,0 +1,103 @@
+package application
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"the-driver-location-service/internal/domain"
+	"the-driver-location-service/internal/ports/primary"
+	"the-driver-location-service/internal/ports/secondary"
+)
+
+// FeatureFlagApplicationService keeps the last loaded flags in memory so
+// checking a flag on the request path never touches the provider
+type FeatureFlagApplicationService struct {
+	provider    secondary.FeatureFlagProvider
+	environment string
+	logger      secondary.Logger
+
+	mu    sync.RWMutex
+	flags map[string]domain.FeatureFlag
+}
+
+var _ primary.FeatureFlagService = (*FeatureFlagApplicationService)(nil)
+
+func NewFeatureFlagApplicationService(provider secondary.FeatureFlagProvider, environment string) *FeatureFlagApplicationService {
+	return &FeatureFlagApplicationService{
+		provider:    provider,
+		environment: environment,
+		logger:      secondary.NopLogger{},
+		flags:       make(map[string]domain.FeatureFlag),
+	}
+}
+
+// SetLogger sets where failed refreshes are logged
+func (s *FeatureFlagApplicationService) SetLogger(logger secondary.Logger) {
+	s.logger = logger
+}
+
+// Refresh reloads the flags, the previous state is kept when the provider fails
+func (s *FeatureFlagApplicationService) Refresh(ctx context.Context) error {
+	flags, err := s.provider.LoadFlags(ctx)
+	if err != nil {
+		return err
+	}
+
+	loaded := make(map[string]domain.FeatureFlag, len(flags))
+	for _, flag := range flags {
+		loaded[flag.Name] = flag
+	}
+
+	s.mu.Lock()
+	s.flags = loaded
+	s.mu.Unlock()
+	return nil
+}
+
+// Start refreshes the flags every interval until the context is cancelled
+func (s *FeatureFlagApplicationService) Start(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := s.Refresh(ctx); err != nil {
+					s.logger.Warn(ctx, "failed to refresh feature flags", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+func (s *FeatureFlagApplicationService) IsEnabled(name, key string) bool {
+	s.mu.RLock()
+	flag, ok := s.flags[name]
+	s.mu.RUnlock()
+
+	return ok && flag.IsEnabledFor(s.environment, key)
+}
+
+func (s *FeatureFlagApplicationService) Flags() []domain.FeatureFlag {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	flags := make([]domain.FeatureFlag, 0, len(s.flags))
+	for _, flag := range s.flags {
+		flags = append(flags, flag)
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Name < flags[j].Name })
+	return flags
+}
+
+func (s *FeatureFlagApplicationService) Environment() string {
+	return s.environment
+}
@@ -0,0 +1,49 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"the-driver-location-service/internal/domain"
+)
+
+type stubFlagProvider struct {
+	flags []domain.FeatureFlag
+	err   error
+}
+
+func (p *stubFlagProvider) LoadFlags(ctx context.Context) ([]domain.FeatureFlag, error) {
+	return p.flags, p.err
+}
+
+// TestFeatureFlagService_RefreshAndIsEnabled tests loading flags and evaluating them
+// Expected: Should report loaded flags as enabled and unknown flags as disabled
+func TestFeatureFlagService_RefreshAndIsEnabled(t *testing.T) {
+	provider := &stubFlagProvider{flags: []domain.FeatureFlag{
+		{Name: domain.FlagGeoNearSearch, Enabled: true},
+		{Name: domain.FlagWriteBehindCache, Enabled: true, Environments: []string{"production"}},
+	}}
+	service := NewFeatureFlagApplicationService(provider, "development")
+
+	assert.NoError(t, service.Refresh(context.Background()))
+	assert.True(t, service.IsEnabled(domain.FlagGeoNearSearch, "driver-1"))
+	assert.False(t, service.IsEnabled(domain.FlagWriteBehindCache, "driver-1"))
+	assert.False(t, service.IsEnabled("unknown", "driver-1"))
+	assert.Len(t, service.Flags(), 2)
+	assert.Equal(t, domain.FlagGeoNearSearch, service.Flags()[0].Name)
+}
+
+// TestFeatureFlagService_RefreshErrorKeepsState tests a failing provider
+// Expected: Should return the error and keep the previously loaded flags
+func TestFeatureFlagService_RefreshErrorKeepsState(t *testing.T) {
+	provider := &stubFlagProvider{flags: []domain.FeatureFlag{{Name: domain.FlagGeoNearSearch, Enabled: true}}}
+	service := NewFeatureFlagApplicationService(provider, "development")
+	assert.NoError(t, service.Refresh(context.Background()))
+
+	provider.err = errors.New("redis down")
+	assert.Error(t, service.Refresh(context.Background()))
+	assert.True(t, service.IsEnabled(domain.FlagGeoNearSearch, "driver-1"))
+}
@@ -0,0 +1,161 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"the-driver-location-service/internal/domain"
+	"the-driver-location-service/internal/ports/primary"
+	"the-driver-location-service/internal/ports/secondary"
+)
+
+type InactivityOptions struct {
+	Threshold time.Duration // drivers without a location update for this long are taken offline
+	Interval  time.Duration
+	BatchSize int
+}
+
+// InactivityApplicationService takes drivers offline when their heartbeats stop and
+// notifies the fleet partners, so they can ask the drivers to reopen the app
+type InactivityApplicationService struct {
+	store     secondary.DriverInactivityStore
+	cache     secondary.DriverCache
+	notifier  secondary.InactivityNotifier
+	publisher secondary.DriverEventPublisher
+	readOnly  primary.ReadOnlyService
+	logger    secondary.Logger
+	options   InactivityOptions
+	now       func() time.Time
+}
+
+func NewInactivityApplicationService(store secondary.DriverInactivityStore, cache secondary.DriverCache, notifier secondary.InactivityNotifier, options InactivityOptions) *InactivityApplicationService {
+	if options.Threshold <= 0 {
+		options.Threshold = 5 * time.Minute
+	}
+	if options.Interval <= 0 {
+		options.Interval = time.Minute
+	}
+	if options.BatchSize <= 0 {
+		options.BatchSize = 500
+	}
+
+	return &InactivityApplicationService{
+		store:    store,
+		cache:    cache,
+		notifier: notifier,
+		logger:   secondary.NopLogger{},
+		options:  options,
+		now:      time.Now,
+	}
+}
+
+// SetEventPublisher also publishes a driver.went_offline event for every driver taken offline
+func (s *InactivityApplicationService) SetEventPublisher(publisher secondary.DriverEventPublisher) {
+	s.publisher = publisher
+}
+
+// SetReadOnlyMode skips the checks while the service is read-only, drivers are
+// not taken offline during a database maintenance
+func (s *InactivityApplicationService) SetReadOnlyMode(readOnly primary.ReadOnlyService) {
+	s.readOnly = readOnly
+}
+
+// SetLogger sets where the failures the service tolerates are logged
+func (s *InactivityApplicationService) SetLogger(logger secondary.Logger) {
+	s.logger = logger
+}
+
+// Start runs the check every interval until ctx is cancelled
+func (s *InactivityApplicationService) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(s.options.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if s.readOnly != nil && s.readOnly.ReadOnly().Enabled {
+					continue
+				}
+				count, err := s.Run(ctx)
+				if err != nil {
+					s.logger.Warn(ctx, "inactivity check failed", "offline", count, "error", err)
+				} else if count > 0 {
+					s.logger.Info(ctx, "inactivity check took drivers offline", "offline", count)
+				}
+			}
+		}
+	}()
+}
+
+// Run takes every stale driver offline and returns how many it took offline
+func (s *InactivityApplicationService) Run(ctx context.Context) (int, error) {
+	detectedAt := s.now()
+	cutoff := detectedAt.Add(-s.options.Threshold)
+
+	total := 0
+	for {
+		drivers, err := s.store.StaleBefore(ctx, cutoff, s.options.BatchSize)
+		if err != nil {
+			return total, fmt.Errorf("failed to find stale drivers: %w", err)
+		}
+
+		// a driver that moved since it was found stays online and is not returned again
+		var offline []*domain.Driver
+		for _, driver := range drivers {
+			changed, err := s.store.MarkOffline(ctx, driver.ID, driver.UpdatedAt)
+			if err != nil {
+				return total, fmt.Errorf("failed to take driver %s offline: %w", driver.ID, err)
+			}
+			if !changed {
+				continue
+			}
+			if s.cache != nil {
+				if err := s.cache.Delete(ctx, driver.ID); err != nil {
+					s.logger.Warn(ctx, "failed to delete driver from cache", "driver_id", driver.ID, "error", err)
+				}
+			}
+			offline = append(offline, driver)
+		}
+
+		if err := s.notify(ctx, offline, detectedAt); err != nil {
+			return total, err
+		}
+		total += len(offline)
+
+		if len(drivers) < s.options.BatchSize || len(offline) == 0 {
+			return total, nil
+		}
+	}
+}
+
+func (s *InactivityApplicationService) notify(ctx context.Context, drivers []*domain.Driver, detectedAt time.Time) error {
+	if len(drivers) == 0 {
+		return nil
+	}
+
+	if s.notifier != nil {
+		inactivities := make([]domain.DriverInactivity, len(drivers))
+		for i, driver := range drivers {
+			inactivities[i] = domain.NewDriverInactivity(driver, detectedAt)
+		}
+		if err := s.notifier.Notify(ctx, inactivities...); err != nil {
+			return fmt.Errorf("failed to queue inactivity notifications: %w", err)
+		}
+	}
+
+	if s.publisher != nil {
+		events := make([]domain.DriverEvent, len(drivers))
+		for i, driver := range drivers {
+			events[i] = domain.NewDriverEvent(domain.DriverWentOffline, driver)
+		}
+		if err := s.publisher.Publish(ctx, events...); err != nil {
+			s.logger.Warn(ctx, "failed to publish driver events", "events", len(events), "error", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,165 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"the-driver-location-service/internal/domain"
+)
+
+type memoryInactivityStore struct {
+	drivers []*domain.Driver
+	moved   map[string]bool // drivers that send an update between the query and MarkOffline
+	err     error
+}
+
+func (s *memoryInactivityStore) StaleBefore(ctx context.Context, before time.Time, limit int) ([]*domain.Driver, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	var batch []*domain.Driver
+	for _, driver := range s.drivers {
+		if driver.Status != domain.DriverStatusOffline && driver.UpdatedAt.Before(before) && len(batch) < limit {
+			copied := *driver
+			batch = append(batch, &copied)
+		}
+	}
+	return batch, nil
+}
+
+func (s *memoryInactivityStore) MarkOffline(ctx context.Context, id string, lastSeenAt time.Time) (bool, error) {
+	for _, driver := range s.drivers {
+		if driver.ID != id {
+			continue
+		}
+		if s.moved[id] {
+			driver.UpdatedAt = time.Now()
+			return false, nil
+		}
+		if !driver.UpdatedAt.Equal(lastSeenAt) || driver.Status == domain.DriverStatusOffline {
+			return false, nil
+		}
+		driver.Status = domain.DriverStatusOffline
+		return true, nil
+	}
+	return false, nil
+}
+
+type recordingNotifier struct {
+	inactivities []domain.DriverInactivity
+	err          error
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, inactivities ...domain.DriverInactivity) error {
+	n.inactivities = append(n.inactivities, inactivities...)
+	return n.err
+}
+
+func (n *recordingNotifier) Close() error { return nil }
+
+func newMemoryInactivityStore(active, stale int) *memoryInactivityStore {
+	store := &memoryInactivityStore{moved: map[string]bool{}}
+	for i := 0; i < active+stale; i++ {
+		updatedAt := time.Now().Add(-time.Minute)
+		if i >= active {
+			updatedAt = time.Now().Add(-time.Hour)
+		}
+		store.drivers = append(store.drivers, &domain.Driver{
+			ID:        fmt.Sprintf("d%03d", i),
+			Location:  domain.NewPoint(29, 41),
+			Status:    domain.DriverStatusAvailable,
+			UpdatedAt: updatedAt,
+		})
+	}
+	return store
+}
+
+// TestInactivity_Run tests taking drivers without recent location updates offline
+// Expected: Should mark every stale driver offline, drop it from the cache and notify the partners
+func TestInactivity_Run(t *testing.T) {
+	store := newMemoryInactivityStore(3, 12)
+	cache := new(mockCache)
+	cache.On("Delete", mock.Anything, mock.Anything).Return(nil)
+	notifier := &recordingNotifier{}
+	service := NewInactivityApplicationService(store, cache, notifier, InactivityOptions{
+		Threshold: 5 * time.Minute,
+		BatchSize: 5,
+	})
+
+	count, err := service.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 12, count)
+	cache.AssertNumberOfCalls(t, "Delete", 12)
+	require.Len(t, notifier.inactivities, 12)
+	assert.Equal(t, "d003", notifier.inactivities[0].DriverID)
+	assert.Equal(t, store.drivers[3].UpdatedAt, notifier.inactivities[0].LastSeenAt)
+
+	for i, driver := range store.drivers {
+		if i < 3 {
+			assert.Equal(t, domain.DriverStatusAvailable, driver.Status)
+		} else {
+			assert.Equal(t, domain.DriverStatusOffline, driver.Status)
+		}
+	}
+
+	count, err = service.Run(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+// TestInactivity_Run_DriverMoved tests a driver that sends an update while it is taken offline
+// Expected: Should keep the driver online and not notify about it
+func TestInactivity_Run_DriverMoved(t *testing.T) {
+	store := newMemoryInactivityStore(0, 2)
+	store.moved["d000"] = true
+	notifier := &recordingNotifier{}
+	service := NewInactivityApplicationService(store, nil, notifier, InactivityOptions{})
+
+	count, err := service.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, domain.DriverStatusAvailable, store.drivers[0].Status)
+	require.Len(t, notifier.inactivities, 1)
+	assert.Equal(t, "d001", notifier.inactivities[0].DriverID)
+}
+
+// TestInactivity_Run_PublishesEvents tests the driver events of an inactivity check
+// Expected: Should publish a driver.went_offline event per driver taken offline
+func TestInactivity_Run_PublishesEvents(t *testing.T) {
+	store := newMemoryInactivityStore(1, 2)
+	publisher := new(mockPublisher)
+	publisher.On("Publish", mock.Anything).Return(nil)
+	service := NewInactivityApplicationService(store, nil, nil, InactivityOptions{})
+	service.SetEventPublisher(publisher)
+
+	_, err := service.Run(context.Background())
+	require.NoError(t, err)
+
+	events := publisher.Calls[0].Arguments.Get(0).([]domain.DriverEvent)
+	require.Len(t, events, 2)
+	assert.Equal(t, domain.DriverWentOffline, events[0].Type)
+	assert.Equal(t, "d001", events[0].DriverID)
+}
+
+// TestInactivity_Run_Errors tests failing store queries and notifications
+// Expected: Should return the error
+func TestInactivity_Run_Errors(t *testing.T) {
+	store := &memoryInactivityStore{err: errors.New("mongo unavailable")}
+	service := NewInactivityApplicationService(store, nil, nil, InactivityOptions{})
+	_, err := service.Run(context.Background())
+	assert.ErrorContains(t, err, "mongo unavailable")
+
+	notifier := &recordingNotifier{err: context.DeadlineExceeded}
+	service = NewInactivityApplicationService(newMemoryInactivityStore(0, 1), nil, notifier, InactivityOptions{})
+	count, err := service.Run(context.Background())
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Zero(t, count)
+}
@@ -0,0 +1,60 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+
+	"the-driver-location-service/internal/domain"
+	"the-driver-location-service/internal/ports/primary"
+	"the-driver-location-service/internal/ports/secondary"
+)
+
+// OutcomeApplicationService counts the outcomes of the drivers' matches. The
+// counts are written to the database only, the cached driver is dropped so the
+// next read returns them.
+type OutcomeApplicationService struct {
+	store     secondary.DriverOutcomeStore
+	cache     secondary.DriverCache
+	logger    secondary.Logger
+	validator *validator.Validate
+}
+
+var _ primary.OutcomeService = (*OutcomeApplicationService)(nil)
+
+func NewOutcomeApplicationService(store secondary.DriverOutcomeStore, cache secondary.DriverCache) *OutcomeApplicationService {
+	return &OutcomeApplicationService{
+		store:     store,
+		cache:     cache,
+		logger:    secondary.NopLogger{},
+		validator: domain.NewValidator(),
+	}
+}
+
+// SetLogger sets where the failures the service tolerates are logged
+func (s *OutcomeApplicationService) SetLogger(logger secondary.Logger) {
+	s.logger = logger
+}
+
+func (s *OutcomeApplicationService) RecordOutcome(ctx context.Context, id string, req domain.OutcomeRequest) (*domain.DriverOutcomes, error) {
+	if strings.TrimSpace(id) == "" {
+		return nil, fmt.Errorf("%w: driver ID is required", domain.ErrValidation)
+	}
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrValidation, err)
+	}
+
+	outcomes, err := s.store.RecordOutcome(ctx, id, req.Outcome)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record outcome of match %s: %w", req.MatchID, err)
+	}
+
+	if s.cache != nil {
+		if err := s.cache.Delete(context.WithoutCancel(ctx), id); err != nil {
+			s.logger.Warn(ctx, "failed to delete driver from cache", "driver_id", id, "error", err)
+		}
+	}
+	return outcomes, nil
+}
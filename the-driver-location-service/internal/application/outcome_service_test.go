@@ -0,0 +1,72 @@
+package application
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"the-driver-location-service/internal/domain"
+)
+
+type memoryOutcomeStore struct {
+	outcomes map[string]*domain.DriverOutcomes
+}
+
+func (s *memoryOutcomeStore) RecordOutcome(ctx context.Context, id, outcome string) (*domain.DriverOutcomes, error) {
+	outcomes, ok := s.outcomes[id]
+	if !ok {
+		return nil, domain.ErrDriverNotFound
+	}
+	switch outcome {
+	case domain.OutcomeAccepted:
+		outcomes.Accepted++
+	case domain.OutcomeCompleted:
+		outcomes.Completed++
+	case domain.OutcomeCancelled:
+		outcomes.Cancelled++
+	}
+	counted := *outcomes
+	return &counted, nil
+}
+
+// TestOutcomeService_RecordOutcome tests counting the outcomes of a driver's matches
+// Expected: Should count every outcome, drop the cached driver and lower the score with cancellations
+func TestOutcomeService_RecordOutcome(t *testing.T) {
+	store := &memoryOutcomeStore{outcomes: map[string]*domain.DriverOutcomes{"d1": {}}}
+	cache := new(mockCache)
+	cache.On("Delete", mock.Anything, "d1").Return(nil)
+	service := NewOutcomeApplicationService(store, cache)
+
+	for _, outcome := range []string{domain.OutcomeAccepted, domain.OutcomeCompleted, domain.OutcomeAccepted, domain.OutcomeCompleted, domain.OutcomeAccepted} {
+		_, err := service.RecordOutcome(context.Background(), "d1", domain.OutcomeRequest{MatchID: "m1", Outcome: outcome})
+		require.NoError(t, err)
+	}
+	outcomes, err := service.RecordOutcome(context.Background(), "d1", domain.OutcomeRequest{MatchID: "m3", Outcome: domain.OutcomeCancelled})
+	require.NoError(t, err)
+
+	assert.Equal(t, domain.DriverOutcomes{Accepted: 3, Completed: 2, Cancelled: 1}, *outcomes)
+	assert.Equal(t, 0.6, outcomes.QualityScore())
+	assert.Equal(t, 0.5, domain.DriverOutcomes{}.QualityScore())
+	cache.AssertNumberOfCalls(t, "Delete", 6)
+}
+
+// TestOutcomeService_RecordOutcome_Errors tests invalid outcomes and unknown drivers
+// Expected: Should return validation errors without touching the store and not found for unknown drivers
+func TestOutcomeService_RecordOutcome_Errors(t *testing.T) {
+	store := &memoryOutcomeStore{outcomes: map[string]*domain.DriverOutcomes{"d1": {}}}
+	service := NewOutcomeApplicationService(store, nil)
+
+	_, err := service.RecordOutcome(context.Background(), "d1", domain.OutcomeRequest{MatchID: "m1", Outcome: "rejected"})
+	assert.ErrorIs(t, err, domain.ErrValidation)
+	_, err = service.RecordOutcome(context.Background(), "d1", domain.OutcomeRequest{Outcome: domain.OutcomeCompleted})
+	assert.ErrorIs(t, err, domain.ErrValidation)
+	_, err = service.RecordOutcome(context.Background(), " ", domain.OutcomeRequest{MatchID: "m1", Outcome: domain.OutcomeCompleted})
+	assert.ErrorIs(t, err, domain.ErrValidation)
+	assert.Zero(t, *store.outcomes["d1"])
+
+	_, err = service.RecordOutcome(context.Background(), "d2", domain.OutcomeRequest{MatchID: "m1", Outcome: domain.OutcomeCompleted})
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
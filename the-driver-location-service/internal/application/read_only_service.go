@@ -0,0 +1,90 @@
+package application
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	"the-driver-location-service/internal/domain"
+	"the-driver-location-service/internal/ports/primary"
+	"the-driver-location-service/internal/ports/secondary"
+)
+
+// ReadOnlyApplicationService keeps the read-only mode of the instance. An
+// operator turns it on for one instance through the admin endpoint, or with the
+// read_only feature flag for every instance, or the share of the instances its
+// percentage picks by hostname; either one is enough.
+type ReadOnlyApplicationService struct {
+	flags  primary.FeatureFlagService
+	logger secondary.Logger
+	now    func() time.Time
+	// instance is the rollout key of the read_only flag: the mode belongs to
+	// the instance, a flag at 50% turns half of the instances read-only
+	instance string
+
+	mu   sync.RWMutex
+	mode domain.ReadOnlyMode
+}
+
+var _ primary.ReadOnlyService = (*ReadOnlyApplicationService)(nil)
+
+// NewReadOnlyApplicationService creates the mode turned off, flags may be nil
+func NewReadOnlyApplicationService(flags primary.FeatureFlagService) *ReadOnlyApplicationService {
+	return &ReadOnlyApplicationService{
+		flags:  flags,
+		logger: secondary.NopLogger{},
+		now:    time.Now,
+
+		instance: instanceName(),
+	}
+}
+
+func instanceName() string {
+	name, err := os.Hostname()
+	if err != nil {
+		return "local"
+	}
+	return name
+}
+
+// SetLogger sets where the mode changes are logged
+func (s *ReadOnlyApplicationService) SetLogger(logger secondary.Logger) {
+	s.logger = logger
+}
+
+// ReadOnly returns the mode set through the admin endpoint, or the one of the
+// read_only flag when the endpoint left it off
+func (s *ReadOnlyApplicationService) ReadOnly() domain.ReadOnlyMode {
+	s.mu.RLock()
+	mode := s.mode
+	s.mu.RUnlock()
+
+	if !mode.Enabled && s.flags != nil && s.flags.IsEnabled(domain.FlagReadOnly, s.instance) {
+		return domain.ReadOnlyMode{Enabled: true, Source: domain.ReadOnlyByFlag}
+	}
+	return mode
+}
+
+// SetReadOnly turns the read-only mode of the instance on or off and returns
+// the resulting mode, the read_only flag still applies after turning it off
+func (s *ReadOnlyApplicationService) SetReadOnly(enabled bool, reason string) domain.ReadOnlyMode {
+	s.mu.Lock()
+	switch {
+	case !enabled:
+		s.mode = domain.ReadOnlyMode{}
+	case !s.mode.Enabled:
+		since := s.now().UTC()
+		s.mode = domain.ReadOnlyMode{Enabled: true, Source: domain.ReadOnlyByAdmin, Reason: reason, Since: &since}
+	default:
+		s.mode.Reason = reason
+	}
+	s.mu.Unlock()
+
+	if enabled {
+		s.logger.Warn(context.Background(), "read-only mode turned on, writes are refused", "reason", reason)
+	} else {
+		s.logger.Info(context.Background(), "read-only mode turned off")
+	}
+	return s.ReadOnly()
+}
@@ -0,0 +1,47 @@
+package application
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"the-driver-location-service/internal/domain"
+)
+
+// TestReadOnlyService_SetReadOnly tests turning the read-only mode on and off through the admin endpoint
+// Expected: Should keep the reason and the time it was turned on until it is turned off
+func TestReadOnlyService_SetReadOnly(t *testing.T) {
+	service := NewReadOnlyApplicationService(nil)
+	assert.False(t, service.ReadOnly().Enabled)
+
+	mode := service.SetReadOnly(true, "mongodb failover")
+	assert.True(t, mode.Enabled)
+	assert.Equal(t, domain.ReadOnlyByAdmin, mode.Source)
+	assert.Equal(t, "mongodb failover", mode.Reason)
+	require.NotNil(t, mode.Since)
+
+	since := *mode.Since
+	mode = service.SetReadOnly(true, "mongodb upgrade")
+	assert.Equal(t, "mongodb upgrade", mode.Reason)
+	assert.Equal(t, since, *mode.Since)
+
+	assert.Equal(t, domain.ReadOnlyMode{}, service.SetReadOnly(false, ""))
+}
+
+// TestReadOnlyService_Flag tests the read_only feature flag
+// Expected: Should be read-only while the flag is enabled, whatever the admin endpoint set
+func TestReadOnlyService_Flag(t *testing.T) {
+	provider := &stubFlagProvider{flags: []domain.FeatureFlag{{Name: domain.FlagReadOnly, Enabled: true}}}
+	flags := NewFeatureFlagApplicationService(provider, "development")
+	require.NoError(t, flags.Refresh(context.Background()))
+	service := NewReadOnlyApplicationService(flags)
+
+	assert.Equal(t, domain.ReadOnlyMode{Enabled: true, Source: domain.ReadOnlyByFlag}, service.ReadOnly())
+	assert.True(t, service.SetReadOnly(false, "").Enabled)
+
+	provider.flags = nil
+	require.NoError(t, flags.Refresh(context.Background()))
+	assert.False(t, service.ReadOnly().Enabled)
+}
@@ -0,0 +1,131 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/go-playground/validator/v10"
+
+	"the-driver-location-service/internal/domain"
+	"the-driver-location-service/internal/ports/primary"
+	"the-driver-location-service/internal/ports/secondary"
+)
+
+// ReconcileApplicationService builds the nightly reconciliation report of fleet
+// partners. It reads the database and not the cache, the report has to reflect
+// what is stored.
+type ReconcileApplicationService struct {
+	store     secondary.DriverReconcileStore
+	validator *validator.Validate
+}
+
+var _ primary.ReconcileService = (*ReconcileApplicationService)(nil)
+
+func NewReconcileApplicationService(store secondary.DriverReconcileStore) *ReconcileApplicationService {
+	return &ReconcileApplicationService{
+		store:     store,
+		validator: domain.NewValidator(),
+	}
+}
+
+func (s *ReconcileApplicationService) Reconcile(ctx context.Context, req domain.ReconcileRequest) (*domain.ReconcileReport, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidReconcileRequest, err)
+	}
+
+	tolerance := req.LocationToleranceMeters
+	if tolerance <= 0 {
+		tolerance = domain.DefaultLocationToleranceMeters
+	}
+
+	expected := make(map[string]domain.ReconcileDriver, len(req.Drivers))
+	ids := make([]string, 0, len(req.Drivers))
+	for _, driver := range req.Drivers {
+		if _, ok := expected[driver.ID]; ok {
+			return nil, fmt.Errorf("%w: driver %s is listed twice", domain.ErrInvalidReconcileRequest, driver.ID)
+		}
+		expected[driver.ID] = driver
+		ids = append(ids, driver.ID)
+	}
+
+	stored, err := s.store.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load drivers: %w", err)
+	}
+	actual := make(map[string]*domain.Driver, len(stored))
+	for _, driver := range stored {
+		actual[driver.ID] = driver
+	}
+
+	report := &domain.ReconcileReport{
+		Checked:   len(req.Drivers),
+		Missing:   []string{},
+		Extra:     []string{},
+		Divergent: []domain.DriverDivergence{},
+	}
+	for _, id := range ids {
+		driver, ok := actual[id]
+		if !ok {
+			report.Missing = append(report.Missing, id)
+			continue
+		}
+		if divergence, diverges := compareDriver(expected[id], driver, tolerance); diverges {
+			report.Divergent = append(report.Divergent, divergence)
+			continue
+		}
+		report.Matched++
+	}
+
+	if req.IDPrefix != "" {
+		// one more than allowed tells a prefix at the limit from one above it
+		prefixed, err := s.store.IDsWithPrefix(ctx, req.IDPrefix, domain.MaxPrefixedDrivers+1)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list partner drivers: %w", err)
+		}
+		if len(prefixed) > domain.MaxPrefixedDrivers {
+			return nil, fmt.Errorf("%w: id_prefix %q matches more than %d drivers, use a longer prefix",
+				domain.ErrValidation, req.IDPrefix, domain.MaxPrefixedDrivers)
+		}
+		for _, id := range prefixed {
+			if _, ok := expected[id]; !ok {
+				report.Extra = append(report.Extra, id)
+			}
+		}
+	}
+
+	sort.Strings(report.Missing)
+	sort.Slice(report.Divergent, func(i, j int) bool { return report.Divergent[i].ID < report.Divergent[j].ID })
+	return report, nil
+}
+
+func compareDriver(expected domain.ReconcileDriver, actual *domain.Driver, tolerance float64) (domain.DriverDivergence, bool) {
+	divergence := domain.DriverDivergence{ID: expected.ID}
+	diverges := false
+
+	// drivers stored before statuses existed are available, see Driver.ApplyDefaults
+	status := actual.Status
+	if status == "" {
+		status = domain.DriverStatusAvailable
+	}
+	if expected.Status != "" && expected.Status != status {
+		divergence.ExpectedStatus = expected.Status
+		divergence.ActualStatus = status
+		diverges = true
+	}
+
+	if expected.Location != nil && len(actual.Location.Coordinates) == 2 {
+		distance := expected.Location.Distance(actual.Location)
+		if distance > tolerance {
+			location := actual.Location
+			rounded := math.Round(distance*100) / 100
+			divergence.ExpectedLocation = expected.Location
+			divergence.ActualLocation = &location
+			divergence.DistanceMeters = &rounded
+			diverges = true
+		}
+	}
+
+	return divergence, diverges
+}
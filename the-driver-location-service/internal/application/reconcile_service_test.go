@@ -0,0 +1,158 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"the-driver-location-service/internal/domain"
+)
+
+type memoryReconcileStore struct {
+	drivers []*domain.Driver
+	err     error
+}
+
+func (s *memoryReconcileStore) GetByIDs(ctx context.Context, ids []string) ([]*domain.Driver, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	wanted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	var drivers []*domain.Driver
+	for _, driver := range s.drivers {
+		if wanted[driver.ID] {
+			drivers = append(drivers, driver)
+		}
+	}
+	return drivers, nil
+}
+
+func (s *memoryReconcileStore) IDsWithPrefix(ctx context.Context, prefix string, limit int) ([]string, error) {
+	var ids []string
+	for _, driver := range s.drivers {
+		if strings.HasPrefix(driver.ID, prefix) && len(ids) < limit {
+			ids = append(ids, driver.ID)
+		}
+	}
+	return ids, nil
+}
+
+func newMemoryReconcileStore() *memoryReconcileStore {
+	return &memoryReconcileStore{drivers: []*domain.Driver{
+		{ID: "fleet-a-1", Status: domain.DriverStatusAvailable, Location: domain.NewPoint(29.0, 41.0)},
+		{ID: "fleet-a-2", Status: domain.DriverStatusBusy, Location: domain.NewPoint(29.0, 41.0)},
+		{ID: "fleet-a-3", Location: domain.NewPoint(29.0, 41.0)},
+		{ID: "fleet-a-4", Status: domain.DriverStatusAvailable, Location: domain.NewPoint(29.0, 41.0)},
+		{ID: "fleet-b-1", Status: domain.DriverStatusAvailable, Location: domain.NewPoint(29.0, 41.0)},
+	}}
+}
+
+// TestReconcile_Report tests reconciling a partner's drivers with the stored drivers
+// Expected: Should report missing, extra and divergent drivers and count the matching ones
+func TestReconcile_Report(t *testing.T) {
+	service := NewReconcileApplicationService(newMemoryReconcileStore())
+	moved := domain.NewPoint(29.01, 41.0)
+	nearby := domain.NewPoint(29.0001, 41.0)
+
+	report, err := service.Reconcile(context.Background(), domain.ReconcileRequest{
+		IDPrefix: "fleet-a-",
+		Drivers: []domain.ReconcileDriver{
+			{ID: "fleet-a-1", Status: domain.DriverStatusAvailable, Location: &nearby},
+			{ID: "fleet-a-2", Status: domain.DriverStatusAvailable, Location: &moved},
+			{ID: "fleet-a-3", Status: domain.DriverStatusAvailable},
+			{ID: "fleet-a-9", Status: domain.DriverStatusAvailable},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 4, report.Checked)
+	assert.Equal(t, 2, report.Matched)
+	assert.Equal(t, []string{"fleet-a-9"}, report.Missing)
+	assert.Equal(t, []string{"fleet-a-4"}, report.Extra)
+	require.Len(t, report.Divergent, 1)
+
+	divergence := report.Divergent[0]
+	assert.Equal(t, "fleet-a-2", divergence.ID)
+	assert.Equal(t, domain.DriverStatusAvailable, divergence.ExpectedStatus)
+	assert.Equal(t, domain.DriverStatusBusy, divergence.ActualStatus)
+	require.NotNil(t, divergence.DistanceMeters)
+	assert.InDelta(t, 839, *divergence.DistanceMeters, 1)
+}
+
+// TestReconcile_WithoutPrefix tests a reconcile request without an ID prefix
+// Expected: Should not report extra drivers and honor the location tolerance of the request
+func TestReconcile_WithoutPrefix(t *testing.T) {
+	service := NewReconcileApplicationService(newMemoryReconcileStore())
+	moved := domain.NewPoint(29.01, 41.0)
+
+	report, err := service.Reconcile(context.Background(), domain.ReconcileRequest{
+		LocationToleranceMeters: 1000,
+		Drivers:                 []domain.ReconcileDriver{{ID: "fleet-a-1", Location: &moved}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Matched)
+	assert.Empty(t, report.Extra)
+	assert.Empty(t, report.Divergent)
+}
+
+// TestReconcile_InvalidRequest tests reconcile requests that fail validation
+// Expected: Should return ErrInvalidReconcileRequest, a validation error, for empty lists, bad statuses, duplicate IDs and short prefixes
+func TestReconcile_InvalidRequest(t *testing.T) {
+	service := NewReconcileApplicationService(newMemoryReconcileStore())
+
+	requests := []domain.ReconcileRequest{
+		{},
+		{Drivers: []domain.ReconcileDriver{{ID: "fleet-a-1", Status: "parked"}}},
+		{Drivers: []domain.ReconcileDriver{{ID: "fleet-a-1"}, {ID: "fleet-a-1"}}},
+		{IDPrefix: "f", Drivers: []domain.ReconcileDriver{{ID: "fleet-a-1"}}},
+	}
+	for _, req := range requests {
+		_, err := service.Reconcile(context.Background(), req)
+		assert.ErrorIs(t, err, domain.ErrInvalidReconcileRequest)
+		assert.ErrorIs(t, err, domain.ErrValidation)
+	}
+}
+
+// TestReconcile_PrefixTooBroad tests an ID prefix matching more drivers than a report lists
+// Expected: Should return a validation error instead of listing them
+func TestReconcile_PrefixTooBroad(t *testing.T) {
+	store := &memoryReconcileStore{}
+	for i := 0; i <= domain.MaxPrefixedDrivers; i++ {
+		store.drivers = append(store.drivers, &domain.Driver{ID: fmt.Sprintf("fleet-%05d", i)})
+	}
+	service := NewReconcileApplicationService(store)
+
+	_, err := service.Reconcile(context.Background(), domain.ReconcileRequest{
+		IDPrefix: "fleet-",
+		Drivers:  []domain.ReconcileDriver{{ID: "fleet-00001"}},
+	})
+	assert.ErrorIs(t, err, domain.ErrValidation)
+	assert.ErrorContains(t, err, "use a longer prefix")
+
+	// at the limit the extra drivers are still listed
+	store.drivers = store.drivers[:domain.MaxPrefixedDrivers]
+	report, err := service.Reconcile(context.Background(), domain.ReconcileRequest{
+		IDPrefix: "fleet-",
+		Drivers:  []domain.ReconcileDriver{{ID: "fleet-00001"}},
+	})
+	require.NoError(t, err)
+	assert.Len(t, report.Extra, domain.MaxPrefixedDrivers-1)
+}
+
+// TestReconcile_StoreError tests a failing database query
+// Expected: Should return the store error
+func TestReconcile_StoreError(t *testing.T) {
+	service := NewReconcileApplicationService(&memoryReconcileStore{err: errors.New("mongo unavailable")})
+
+	_, err := service.Reconcile(context.Background(), domain.ReconcileRequest{Drivers: []domain.ReconcileDriver{{ID: "d1"}}})
+	assert.ErrorContains(t, err, "mongo unavailable")
+	assert.NotErrorIs(t, err, domain.ErrInvalidReconcileRequest)
+}
@@ -0,0 +1,113 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"the-driver-location-service/internal/domain"
+	"the-driver-location-service/internal/ports/secondary"
+)
+
+type ReplicationReconcileOptions struct {
+	Interval  time.Duration
+	BatchSize int
+}
+
+// ReplicationReconcileService catches the standby region up with the changes
+// the replicator dropped. Every interval it sends all drivers of the primary
+// page by page, each page with the ID range it covers so the standby deletes
+// the drivers of the range the primary no longer has. It also carries the
+// writes that keep updated_at, e.g. drivers taken offline or match outcomes,
+// which are not replicated as they happen.
+type ReplicationReconcileService struct {
+	store      secondary.DriverBackfillStore
+	replicator secondary.DriverReplicator
+	logger     secondary.Logger
+	options    ReplicationReconcileOptions
+	now        func() time.Time
+}
+
+func NewReplicationReconcileService(store secondary.DriverBackfillStore, replicator secondary.DriverReplicator, options ReplicationReconcileOptions) *ReplicationReconcileService {
+	if options.Interval <= 0 {
+		options.Interval = 15 * time.Minute
+	}
+	if options.BatchSize <= 0 {
+		options.BatchSize = 500
+	}
+
+	return &ReplicationReconcileService{
+		store:      store,
+		replicator: replicator,
+		logger:     secondary.NopLogger{},
+		options:    options,
+		now:        time.Now,
+	}
+}
+
+// SetLogger sets where the failed reconciliations are logged
+func (s *ReplicationReconcileService) SetLogger(logger secondary.Logger) {
+	s.logger = logger
+}
+
+// Start runs the reconciliation every interval until ctx is cancelled
+func (s *ReplicationReconcileService) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(s.options.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				result, err := s.Run(ctx)
+				if err != nil {
+					s.logger.Warn(ctx, "replication reconciliation failed", "applied", result.Applied, "deleted", result.Deleted, "error", err)
+					continue
+				}
+				s.logger.Info(ctx, "replication reconciliation completed", "applied", result.Applied, "skipped", result.Skipped, "deleted", result.Deleted)
+			}
+		}
+	}()
+}
+
+// Run sends every driver to the standby and returns what the standby changed
+func (s *ReplicationReconcileService) Run(ctx context.Context) (domain.ReplicationResult, error) {
+	// drivers the standby stores that were updated after the snapshot were
+	// replicated since the scan began, they are not deleted
+	snapshotAt := s.now()
+
+	var total domain.ReplicationResult
+	afterID := ""
+	for {
+		drivers, err := s.store.ScanAfter(ctx, afterID, s.options.BatchSize)
+		if err != nil {
+			return total, fmt.Errorf("failed to scan drivers: %w", err)
+		}
+
+		batch := domain.ReplicationBatch{
+			Changes: make([]domain.DriverChange, len(drivers)),
+			Mirror:  &domain.MirrorRange{AfterID: afterID, SnapshotAt: snapshotAt},
+		}
+		for i, driver := range drivers {
+			batch.Changes[i] = domain.NewDriverUpsert(driver)
+		}
+		// the last page mirrors up to the last driver of the standby
+		last := len(drivers) < s.options.BatchSize
+		if !last {
+			batch.Mirror.ThroughID = drivers[len(drivers)-1].ID
+		}
+
+		result, err := s.replicator.Send(ctx, batch)
+		if err != nil {
+			return total, fmt.Errorf("failed to send drivers after %q: %w", afterID, err)
+		}
+		total.Add(*result)
+
+		if last {
+			return total, nil
+		}
+		afterID = batch.Mirror.ThroughID
+	}
+}
@@ -0,0 +1,130 @@
+package application
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-playground/validator/v10"
+
+	"the-driver-location-service/internal/domain"
+	"the-driver-location-service/internal/ports/primary"
+	"the-driver-location-service/internal/ports/secondary"
+)
+
+// replicationScanBatchSize is how many stored drivers a mirror range reads at once
+const replicationScanBatchSize = 500
+
+// ReplicationApplicationService applies the driver changes of the primary region
+// on the standby. Changes of a driver may arrive out of order, from several
+// instances of the primary or retried, the newest updated_at wins.
+type ReplicationApplicationService struct {
+	store     secondary.DriverReplicaStore
+	cache     secondary.DriverCache
+	logger    secondary.Logger
+	validator *validator.Validate
+}
+
+var _ primary.ReplicationService = (*ReplicationApplicationService)(nil)
+
+func NewReplicationApplicationService(store secondary.DriverReplicaStore, cache secondary.DriverCache) *ReplicationApplicationService {
+	return &ReplicationApplicationService{
+		store:     store,
+		cache:     cache,
+		logger:    secondary.NopLogger{},
+		validator: domain.NewValidator(),
+	}
+}
+
+// SetLogger sets where the failures the service tolerates are logged
+func (s *ReplicationApplicationService) SetLogger(logger secondary.Logger) {
+	s.logger = logger
+}
+
+// Apply stores the changes that are newer than the stored drivers and, with a
+// mirror range, deletes the drivers of the range the batch does not list
+func (s *ReplicationApplicationService) Apply(ctx context.Context, batch domain.ReplicationBatch) (*domain.ReplicationResult, error) {
+	if err := s.validator.Struct(batch); err != nil {
+		return nil, fmt.Errorf("%w: invalid replication batch: %w", domain.ErrValidation, err)
+	}
+
+	result := &domain.ReplicationResult{}
+	listed := make(map[string]bool, len(batch.Changes))
+	for _, change := range batch.Changes {
+		listed[change.DriverID] = true
+		applied, err := s.apply(ctx, change)
+		if err != nil {
+			return result, fmt.Errorf("failed to apply change of driver %s: %w", change.DriverID, err)
+		}
+		if !applied {
+			result.Skipped++
+			continue
+		}
+		result.Applied++
+		s.evict(ctx, change.DriverID)
+	}
+
+	if batch.Mirror != nil {
+		deleted, err := s.mirror(ctx, *batch.Mirror, listed)
+		result.Deleted = deleted
+		if err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+func (s *ReplicationApplicationService) apply(ctx context.Context, change domain.DriverChange) (bool, error) {
+	if change.Op == domain.ReplicationDelete {
+		return s.store.ApplyReplicatedDelete(ctx, change.DriverID, change.UpdatedAt)
+	}
+
+	driver := *change.Driver
+	driver.ID = change.DriverID
+	// the driver JSON has updated_at to the second, the change keeps it exact
+	driver.UpdatedAt = change.UpdatedAt
+	return s.store.ApplyReplicatedUpsert(ctx, &driver)
+}
+
+// mirror deletes the stored drivers of the range that the primary no longer
+// has, drivers updated after the snapshot were written since and are kept
+func (s *ReplicationApplicationService) mirror(ctx context.Context, mirror domain.MirrorRange, listed map[string]bool) (int, error) {
+	deleted := 0
+	afterID := mirror.AfterID
+	for {
+		drivers, err := s.store.ScanAfter(ctx, afterID, replicationScanBatchSize)
+		if err != nil {
+			return deleted, fmt.Errorf("failed to scan drivers: %w", err)
+		}
+
+		for _, driver := range drivers {
+			if mirror.ThroughID != "" && driver.ID > mirror.ThroughID {
+				return deleted, nil
+			}
+			if listed[driver.ID] {
+				continue
+			}
+			removed, err := s.store.ApplyReplicatedDelete(ctx, driver.ID, mirror.SnapshotAt)
+			if err != nil {
+				return deleted, fmt.Errorf("failed to delete driver %s: %w", driver.ID, err)
+			}
+			if removed {
+				deleted++
+				s.evict(ctx, driver.ID)
+			}
+		}
+
+		if len(drivers) < replicationScanBatchSize {
+			return deleted, nil
+		}
+		afterID = drivers[len(drivers)-1].ID
+	}
+}
+
+func (s *ReplicationApplicationService) evict(ctx context.Context, id string) {
+	if s.cache == nil {
+		return
+	}
+	if err := s.cache.Delete(context.WithoutCancel(ctx), id); err != nil {
+		s.logger.Warn(ctx, "failed to delete driver from cache", "driver_id", id, "error", err)
+	}
+}
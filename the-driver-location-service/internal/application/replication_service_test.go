@@ -0,0 +1,210 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"the-driver-location-service/internal/domain"
+)
+
+// memoryReplicaStore keeps drivers by ID, it is the store of both regions in
+// the tests
+type memoryReplicaStore struct {
+	drivers map[string]*domain.Driver
+}
+
+func newMemoryReplicaStore(drivers ...*domain.Driver) *memoryReplicaStore {
+	store := &memoryReplicaStore{drivers: make(map[string]*domain.Driver)}
+	for _, driver := range drivers {
+		store.drivers[driver.ID] = driver
+	}
+	return store
+}
+
+func (s *memoryReplicaStore) ScanAfter(ctx context.Context, afterID string, limit int) ([]*domain.Driver, error) {
+	ids := make([]string, 0, len(s.drivers))
+	for id := range s.drivers {
+		if id > afterID {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+	drivers := make([]*domain.Driver, len(ids))
+	for i, id := range ids {
+		drivers[i] = s.drivers[id]
+	}
+	return drivers, nil
+}
+
+func (s *memoryReplicaStore) ApplyUpdates(ctx context.Context, updates []domain.DriverFieldUpdate) (int64, error) {
+	return 0, nil
+}
+
+func (s *memoryReplicaStore) ApplyReplicatedUpsert(ctx context.Context, driver *domain.Driver) (bool, error) {
+	if stored, ok := s.drivers[driver.ID]; ok && stored.UpdatedAt.After(driver.UpdatedAt) {
+		return false, nil
+	}
+	s.drivers[driver.ID] = driver
+	return true, nil
+}
+
+func (s *memoryReplicaStore) ApplyReplicatedDelete(ctx context.Context, id string, deletedAt time.Time) (bool, error) {
+	stored, ok := s.drivers[id]
+	if !ok || stored.UpdatedAt.After(deletedAt) {
+		return false, nil
+	}
+	delete(s.drivers, id)
+	return true, nil
+}
+
+// localReplicator applies the batches on a standby of the same process
+type localReplicator struct {
+	standby *ReplicationApplicationService
+	batches int
+}
+
+func (r *localReplicator) Replicate(ctx context.Context, changes ...domain.DriverChange) error {
+	_, err := r.standby.Apply(ctx, domain.ReplicationBatch{Changes: changes})
+	return err
+}
+
+func (r *localReplicator) Send(ctx context.Context, batch domain.ReplicationBatch) (*domain.ReplicationResult, error) {
+	r.batches++
+	return r.standby.Apply(ctx, batch)
+}
+
+func (r *localReplicator) Close() error { return nil }
+
+func replicaDriver(id string, updatedAt time.Time) *domain.Driver {
+	return &domain.Driver{ID: id, Location: domain.NewPoint(29, 41), UpdatedAt: updatedAt}
+}
+
+// TestReplicationApply_LastWriteWins tests changes of a driver arriving out of order
+// Expected: Should keep the newest write, skip older upserts and deletes and drop the driver from the cache when it changed
+func TestReplicationApply_LastWriteWins(t *testing.T) {
+	base := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	store := newMemoryReplicaStore()
+	cache := new(mockCache)
+	cache.On("Delete", mock.Anything, "d1").Return(nil)
+	service := NewReplicationApplicationService(store, cache)
+
+	newer := replicaDriver("d1", base.Add(time.Second))
+	newer.Status = domain.DriverStatusBusy
+	result, err := service.Apply(context.Background(), domain.ReplicationBatch{Changes: []domain.DriverChange{
+		domain.NewDriverUpsert(newer),
+		domain.NewDriverUpsert(replicaDriver("d1", base)),
+		{Op: domain.ReplicationDelete, DriverID: "d1", UpdatedAt: base},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, domain.ReplicationResult{Applied: 1, Skipped: 2}, *result)
+	assert.Equal(t, domain.DriverStatusBusy, store.drivers["d1"].Status)
+	cache.AssertNumberOfCalls(t, "Delete", 1)
+
+	result, err = service.Apply(context.Background(), domain.ReplicationBatch{Changes: []domain.DriverChange{
+		{Op: domain.ReplicationDelete, DriverID: "d1", UpdatedAt: base.Add(2 * time.Second)},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Applied)
+	assert.Empty(t, store.drivers)
+}
+
+// TestReplicationApply_KeepsExactUpdatedAt tests an upsert whose driver JSON has updated_at to the second
+// Expected: Should store the updated_at of the change so a later write of the same second still wins
+func TestReplicationApply_KeepsExactUpdatedAt(t *testing.T) {
+	store := newMemoryReplicaStore()
+	service := NewReplicationApplicationService(store, nil)
+	updatedAt := time.Date(2025, 1, 2, 3, 4, 5, 678000000, time.UTC)
+
+	change := domain.NewDriverUpsert(replicaDriver("d1", updatedAt))
+	change.Driver.UpdatedAt = updatedAt.Truncate(time.Second)
+	_, err := service.Apply(context.Background(), domain.ReplicationBatch{Changes: []domain.DriverChange{change}})
+	require.NoError(t, err)
+	assert.Equal(t, updatedAt, store.drivers["d1"].UpdatedAt)
+}
+
+// TestReplicationApply_Invalid tests batches the standby cannot apply
+// Expected: Should return a validation error for an upsert without a driver or an unknown operation
+func TestReplicationApply_Invalid(t *testing.T) {
+	service := NewReplicationApplicationService(newMemoryReplicaStore(), nil)
+	for _, change := range []domain.DriverChange{
+		{Op: domain.ReplicationUpsert, DriverID: "d1", UpdatedAt: time.Now()},
+		{Op: "merge", DriverID: "d1", UpdatedAt: time.Now()},
+		{Op: domain.ReplicationDelete, UpdatedAt: time.Now()},
+	} {
+		_, err := service.Apply(context.Background(), domain.ReplicationBatch{Changes: []domain.DriverChange{change}})
+		assert.ErrorIs(t, err, domain.ErrValidation)
+	}
+}
+
+// TestReplicationApply_Mirror tests a batch covering an ID range of the primary
+// Expected: Should delete the drivers of the range missing from the batch, keep the ones outside the range and the ones updated after the snapshot
+func TestReplicationApply_Mirror(t *testing.T) {
+	snapshotAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	old := snapshotAt.Add(-time.Hour)
+	store := newMemoryReplicaStore(
+		replicaDriver("a", old),
+		replicaDriver("b", old),
+		replicaDriver("c", old),
+		replicaDriver("d", snapshotAt.Add(time.Second)),
+		replicaDriver("e", old),
+		replicaDriver("f", old),
+	)
+	service := NewReplicationApplicationService(store, nil)
+
+	result, err := service.Apply(context.Background(), domain.ReplicationBatch{
+		Changes: []domain.DriverChange{domain.NewDriverUpsert(replicaDriver("c", old))},
+		Mirror:  &domain.MirrorRange{AfterID: "a", ThroughID: "e", SnapshotAt: snapshotAt},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Deleted)
+	assert.Contains(t, store.drivers, "a")
+	assert.NotContains(t, store.drivers, "b")
+	assert.Contains(t, store.drivers, "c")
+	assert.Contains(t, store.drivers, "d")
+	assert.NotContains(t, store.drivers, "e")
+	assert.Contains(t, store.drivers, "f")
+}
+
+// TestReplicationReconcile_Run tests catching up a standby that missed changes
+// Expected: Should send every page of the primary and leave the standby with the drivers of the primary
+func TestReplicationReconcile_Run(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	primaryStore := newMemoryReplicaStore()
+	standbyStore := newMemoryReplicaStore()
+	for i := 0; i < 7; i++ {
+		id := fmt.Sprintf("d%d", i)
+		primaryStore.drivers[id] = replicaDriver(id, now.Add(-time.Minute))
+		if i%2 == 0 {
+			standbyStore.drivers[id] = replicaDriver(id, now.Add(-time.Hour))
+		}
+	}
+	// deleted on the primary while the standby was unreachable
+	standbyStore.drivers["d9"] = replicaDriver("d9", now.Add(-time.Hour))
+
+	replicator := &localReplicator{standby: NewReplicationApplicationService(standbyStore, nil)}
+	service := NewReplicationReconcileService(primaryStore, replicator, ReplicationReconcileOptions{BatchSize: 3})
+	service.now = func() time.Time { return now }
+
+	result, err := service.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, domain.ReplicationResult{Applied: 7, Deleted: 1}, result)
+	assert.Equal(t, 3, replicator.batches)
+	require.Len(t, standbyStore.drivers, 7)
+	for id, driver := range primaryStore.drivers {
+		assert.Equal(t, driver.UpdatedAt, standbyStore.drivers[id].UpdatedAt)
+	}
+
+	result, err = service.Run(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, result.Deleted)
+}
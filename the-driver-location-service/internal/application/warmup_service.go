@@ -0,0 +1,139 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"the-driver-location-service/internal/domain"
+	"the-driver-location-service/internal/ports/primary"
+	"the-driver-location-service/internal/ports/secondary"
+)
+
+type WarmupOptions struct {
+	Window    time.Duration // only drivers updated within the window are loaded
+	BatchSize int
+	CacheTTL  time.Duration
+}
+
+// WarmupApplicationService loads the recently active drivers from the database into
+// the cache after a restart, so the first requests after a deploy are not all cache
+// misses. The warmup is best effort: a failure only leaves the cache cold.
+type WarmupApplicationService struct {
+	source  secondary.DriverWarmupSource
+	cache   secondary.DriverCache
+	logger  secondary.Logger
+	options WarmupOptions
+
+	mu       sync.Mutex
+	progress domain.WarmupProgress
+}
+
+var _ primary.WarmupService = (*WarmupApplicationService)(nil)
+
+func NewWarmupApplicationService(source secondary.DriverWarmupSource, cache secondary.DriverCache, options WarmupOptions) *WarmupApplicationService {
+	if options.BatchSize <= 0 {
+		options.BatchSize = 500
+	}
+	if options.CacheTTL <= 0 {
+		options.CacheTTL = DriverCacheTTL
+	}
+
+	status := domain.WarmupPending
+	if cache == nil {
+		status = domain.WarmupDisabled
+	}
+
+	return &WarmupApplicationService{
+		source:   source,
+		cache:    cache,
+		logger:   secondary.NopLogger{},
+		options:  options,
+		progress: domain.WarmupProgress{Status: status},
+	}
+}
+
+// SetLogger sets where the outcome of the warmup is logged
+func (s *WarmupApplicationService) SetLogger(logger secondary.Logger) {
+	s.logger = logger
+}
+
+func (s *WarmupApplicationService) Progress() domain.WarmupProgress {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.progress
+}
+
+// Start runs the warmup in the background, it stops when ctx is cancelled
+func (s *WarmupApplicationService) Start(ctx context.Context) {
+	go func() {
+		progress, err := s.Run(ctx)
+		if err != nil {
+			s.logger.Warn(ctx, "cache warmup failed", "loaded", progress.Loaded, "error", err)
+			return
+		}
+		if progress.Status == domain.WarmupCompleted {
+			s.logger.Info(ctx, "cache warmup finished", "loaded", progress.Loaded, "duration", progress.FinishedAt.Sub(*progress.StartedAt).Round(time.Millisecond))
+		}
+	}()
+}
+
+// Run loads the drivers and blocks until it is done
+func (s *WarmupApplicationService) Run(ctx context.Context) (domain.WarmupProgress, error) {
+	progress := s.Progress()
+	if progress.Status != domain.WarmupPending {
+		return progress, nil
+	}
+
+	startedAt := time.Now()
+	since := startedAt.Add(-s.options.Window)
+	progress = domain.WarmupProgress{Status: domain.WarmupRunning, Since: &since, StartedAt: &startedAt}
+	s.save(progress)
+
+	lastID := ""
+	for {
+		drivers, err := s.source.UpdatedSince(ctx, since, lastID, s.options.BatchSize)
+		if err != nil {
+			return s.finish(progress, fmt.Errorf("failed to load drivers: %w", err))
+		}
+
+		for _, driver := range drivers {
+			if err := s.cache.Set(ctx, driver.ID, driver, s.options.CacheTTL); err != nil {
+				return s.finish(progress, fmt.Errorf("failed to cache driver %s: %w", driver.ID, err))
+			}
+		}
+
+		progress.Loaded += int64(len(drivers))
+		s.save(progress)
+
+		if len(drivers) < s.options.BatchSize {
+			break
+		}
+		lastID = drivers[len(drivers)-1].ID
+
+		if err := ctx.Err(); err != nil {
+			return s.finish(progress, err)
+		}
+	}
+
+	return s.finish(progress, nil)
+}
+
+func (s *WarmupApplicationService) finish(progress domain.WarmupProgress, err error) (domain.WarmupProgress, error) {
+	finishedAt := time.Now()
+	progress.FinishedAt = &finishedAt
+	progress.Status = domain.WarmupCompleted
+	if err != nil {
+		progress.Status = domain.WarmupFailed
+		progress.Error = err.Error()
+	}
+	s.save(progress)
+	return progress, err
+}
+
+func (s *WarmupApplicationService) save(progress domain.WarmupProgress) {
+	s.mu.Lock()
+	s.progress = progress
+	s.mu.Unlock()
+}
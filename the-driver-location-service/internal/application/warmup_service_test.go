@@ -0,0 +1,96 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"the-driver-location-service/internal/domain"
+)
+
+type memoryWarmupSource struct {
+	drivers []*domain.Driver
+	err     error
+}
+
+func (s *memoryWarmupSource) UpdatedSince(ctx context.Context, since time.Time, afterID string, limit int) ([]*domain.Driver, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	var batch []*domain.Driver
+	for _, driver := range s.drivers {
+		if driver.ID > afterID && !driver.UpdatedAt.Before(since) && len(batch) < limit {
+			batch = append(batch, driver)
+		}
+	}
+	return batch, nil
+}
+
+func newMemoryWarmupSource(recent, stale int) *memoryWarmupSource {
+	source := &memoryWarmupSource{}
+	for i := 0; i < recent+stale; i++ {
+		updatedAt := time.Now().Add(-time.Minute)
+		if i >= recent {
+			updatedAt = time.Now().Add(-2 * time.Hour)
+		}
+		source.drivers = append(source.drivers, &domain.Driver{ID: fmt.Sprintf("d%03d", i), UpdatedAt: updatedAt})
+	}
+	sort.Slice(source.drivers, func(i, j int) bool { return source.drivers[i].ID < source.drivers[j].ID })
+	return source
+}
+
+// TestWarmup_Run tests loading recently updated drivers into the cache
+// Expected: Should cache every driver updated within the window in batches and report completion
+func TestWarmup_Run(t *testing.T) {
+	cache := new(mockCache)
+	cache.On("Set", mock.Anything, mock.Anything, mock.Anything, 5*time.Minute).Return(nil)
+	service := NewWarmupApplicationService(newMemoryWarmupSource(25, 5), cache, WarmupOptions{
+		Window:    time.Hour,
+		BatchSize: 10,
+		CacheTTL:  5 * time.Minute,
+	})
+	assert.Equal(t, domain.WarmupPending, service.Progress().Status)
+	assert.False(t, service.Progress().Done())
+
+	progress, err := service.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, domain.WarmupCompleted, progress.Status)
+	assert.Equal(t, int64(25), progress.Loaded)
+	assert.True(t, service.Progress().Done())
+	cache.AssertNumberOfCalls(t, "Set", 25)
+}
+
+// TestWarmup_Run_SourceError tests a warmup whose database query fails
+// Expected: Should report the failure without caching anything
+func TestWarmup_Run_SourceError(t *testing.T) {
+	cache := new(mockCache)
+	source := &memoryWarmupSource{err: errors.New("mongo unavailable")}
+	service := NewWarmupApplicationService(source, cache, WarmupOptions{Window: time.Hour})
+
+	progress, err := service.Run(context.Background())
+	require.Error(t, err)
+	assert.Equal(t, domain.WarmupFailed, progress.Status)
+	assert.Contains(t, progress.Error, "mongo unavailable")
+	assert.True(t, progress.Done())
+	cache.AssertNotCalled(t, "Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestWarmup_Disabled tests a warmup without a cache
+// Expected: Should report disabled and never query the database
+func TestWarmup_Disabled(t *testing.T) {
+	source := &memoryWarmupSource{err: errors.New("should not be called")}
+	service := NewWarmupApplicationService(source, nil, WarmupOptions{Window: time.Hour})
+
+	progress, err := service.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, domain.WarmupDisabled, progress.Status)
+	assert.True(t, progress.Done())
+}
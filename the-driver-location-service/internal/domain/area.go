@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// DefaultAreaSearchLimit is used when an area search has no limit, zones are
+// larger than a pickup radius so more drivers are returned than by SearchRequest
+const DefaultAreaSearchLimit = 100
+
+// Area is a GeoJSON Polygon or MultiPolygon, rings are lists of [longitude, latitude]
+// positions whose first and last positions are the same
+type Area struct {
+	Type        string          `json:"type" validate:"required,oneof=Polygon MultiPolygon" example:"Polygon"`
+	Coordinates json.RawMessage `json:"coordinates" validate:"required" swaggertype:"array,number"`
+}
+
+type AreaSearchRequest struct {
+	Area  Area `json:"area" validate:"required"`
+	Limit int  `json:"limit,omitempty" validate:"omitempty,gte=0,lte=1000"`
+}
+
+// Polygons returns the rings of every polygon of the area, a Polygon is returned
+// as a single polygon. Rings that are not closed, too short or have positions out
+// of range are rejected.
+func (a Area) Polygons() ([][][][]float64, error) {
+	var polygons [][][][]float64
+	switch a.Type {
+	case "Polygon":
+		var polygon [][][]float64
+		if err := json.Unmarshal(a.Coordinates, &polygon); err != nil {
+			return nil, fmt.Errorf("invalid polygon coordinates: %w", err)
+		}
+		polygons = [][][][]float64{polygon}
+	case "MultiPolygon":
+		if err := json.Unmarshal(a.Coordinates, &polygons); err != nil {
+			return nil, fmt.Errorf("invalid multipolygon coordinates: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported area type %q", a.Type)
+	}
+
+	if len(polygons) == 0 {
+		return nil, errors.New("area has no polygon")
+	}
+	for i, polygon := range polygons {
+		if len(polygon) == 0 {
+			return nil, fmt.Errorf("polygon %d has no ring", i)
+		}
+		for j, ring := range polygon {
+			if len(ring) < 4 {
+				return nil, fmt.Errorf("ring %d of polygon %d needs at least 4 positions", j, i)
+			}
+			for _, position := range ring {
+				if len(position) != 2 || !ValidCoordinates(position[0], position[1]) {
+					return nil, fmt.Errorf("ring %d of polygon %d has an invalid position %v", j, i, position)
+				}
+			}
+			first, last := ring[0], ring[len(ring)-1]
+			if first[0] != last[0] || first[1] != last[1] {
+				return nil, fmt.Errorf("ring %d of polygon %d is not closed", j, i)
+			}
+		}
+	}
+	return polygons, nil
+}
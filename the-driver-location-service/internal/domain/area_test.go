@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestArea_Polygons tests parsing the polygons of valid areas
+// Expected: Should return a Polygon as a single polygon and every polygon of a MultiPolygon
+func TestArea_Polygons(t *testing.T) {
+	square := `[[[29,41],[29.1,41],[29.1,41.1],[29,41.1],[29,41]]]`
+
+	polygons, err := Area{Type: "Polygon", Coordinates: json.RawMessage(square)}.Polygons()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(polygons) != 1 || len(polygons[0][0]) != 5 {
+		t.Errorf("expected one polygon with a ring of 5 positions, got %v", polygons)
+	}
+
+	polygons, err = Area{Type: "MultiPolygon", Coordinates: json.RawMessage("[" + square + "," + square + "]")}.Polygons()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(polygons) != 2 {
+		t.Errorf("expected two polygons, got %d", len(polygons))
+	}
+}
+
+// TestArea_PolygonsInvalid tests areas MongoDB would reject or misread
+// Expected: Should return an error for each of them
+func TestArea_PolygonsInvalid(t *testing.T) {
+	tests := map[string]Area{
+		"point":          {Type: "Point", Coordinates: json.RawMessage(`[29,41]`)},
+		"not closed":     {Type: "Polygon", Coordinates: json.RawMessage(`[[[29,41],[29.1,41],[29.1,41.1],[29,41.1]]]`)},
+		"too short":      {Type: "Polygon", Coordinates: json.RawMessage(`[[[29,41],[29.1,41],[29,41]]]`)},
+		"out of range":   {Type: "Polygon", Coordinates: json.RawMessage(`[[[29,41],[190,41],[29.1,41.1],[29,41]]]`)},
+		"no ring":        {Type: "Polygon", Coordinates: json.RawMessage(`[]`)},
+		"no polygon":     {Type: "MultiPolygon", Coordinates: json.RawMessage(`[]`)},
+		"wrong nesting":  {Type: "MultiPolygon", Coordinates: json.RawMessage(`[[[29,41],[29.1,41],[29.1,41.1],[29,41]]]`)},
+		"3d coordinates": {Type: "Polygon", Coordinates: json.RawMessage(`[[[29,41,0],[29.1,41,0],[29.1,41.1,0],[29,41,0]]]`)},
+	}
+
+	for name, area := range tests {
+		if _, err := area.Polygons(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
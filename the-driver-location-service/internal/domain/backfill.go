@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrBackfillNotFound = errors.New("backfill job not found")
+	ErrBackfillRunning  = errors.New("backfill job is already running")
+)
+
+type BackfillStatus string
+
+const (
+	BackfillIdle      BackfillStatus = "idle"
+	BackfillRunning   BackfillStatus = "running"
+	BackfillCompleted BackfillStatus = "completed"
+	BackfillFailed    BackfillStatus = "failed"
+)
+
+// BackfillProgress is the state of a backfill job, LastID is the checkpoint
+// a rerun continues from
+type BackfillProgress struct {
+	Job        string         `json:"job"`
+	Status     BackfillStatus `json:"status"`
+	Scanned    int64          `json:"scanned"`
+	Updated    int64          `json:"updated"`
+	LastID     string         `json:"last_id,omitempty"`
+	StartedAt  *time.Time     `json:"started_at,omitempty"`
+	FinishedAt *time.Time     `json:"finished_at,omitempty"`
+	Error      string         `json:"error,omitempty"`
+}
+
+// DriverFieldUpdate sets the given fields (by their stored name) on one driver.
+// Location is the location the fields were computed from, the update is skipped
+// when the driver has moved since.
+type DriverFieldUpdate struct {
+	ID       string
+	Location Point
+	Fields   map[string]interface{}
+}
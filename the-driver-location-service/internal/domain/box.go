@@ -0,0 +1,47 @@
+package domain
+
+import "errors"
+
+const (
+	DefaultBoxSearchLimit = 500
+	MaxBoxSearchLimit     = 2000
+)
+
+// BoxSearchRequest selects the drivers inside a bounding box for map views.
+// With Cluster set to a geohash precision the drivers are grouped by the geohash
+// cell of that precision instead of being returned one by one.
+type BoxSearchRequest struct {
+	MinLongitude float64 `query:"min_lon" validate:"gte=-180,lte=180"`
+	MinLatitude  float64 `query:"min_lat" validate:"gte=-90,lte=90"`
+	MaxLongitude float64 `query:"max_lon" validate:"gte=-180,lte=180"`
+	MaxLatitude  float64 `query:"max_lat" validate:"gte=-90,lte=90"`
+	Limit        int     `query:"limit" validate:"gte=0,lte=2000"`
+	Cluster      int     `query:"cluster" validate:"gte=0,lte=7"`
+}
+
+// Check rejects boxes whose corners are swapped, boxes crossing the antimeridian
+// have to be split into two requests
+func (r BoxSearchRequest) Check() error {
+	if r.MinLongitude >= r.MaxLongitude {
+		return errors.New("min_lon must be less than max_lon")
+	}
+	if r.MinLatitude >= r.MaxLatitude {
+		return errors.New("min_lat must be less than max_lat")
+	}
+	return nil
+}
+
+// DriverCluster is a group of drivers sharing a geohash cell, Center is the
+// average position of the drivers rather than the center of the cell
+type DriverCluster struct {
+	Geohash string `json:"geohash"`
+	Count   int    `json:"count"`
+	Center  Point  `json:"center"`
+}
+
+// BoxSearchResult holds either the drivers or the clusters of a box search
+type BoxSearchResult struct {
+	Drivers  []*Driver       `json:"drivers,omitempty"`
+	Clusters []DriverCluster `json:"clusters,omitempty"`
+	Count    int             `json:"count"`
+}
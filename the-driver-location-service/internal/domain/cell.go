@@ -0,0 +1,47 @@
+package domain
+
+import "fmt"
+
+const (
+	DefaultCellSearchLimit = 500
+	MaxCellSearchLimit     = 2000
+	DefaultCellCountLevel  = 13
+	MaxCellCountLevel      = 20
+)
+
+// CellSearchRequest selects the available drivers inside the S2 cell of a token.
+// With Count set the drivers are counted per descendant cell of Level instead of
+// being returned one by one, e.g. for density maps of a zone.
+type CellSearchRequest struct {
+	Token string `param:"token" validate:"required"`
+	Limit int    `query:"limit" validate:"gte=0,lte=2000"`
+	Count bool   `query:"count"`
+	Level int    `query:"level" validate:"gte=0,lte=20"`
+}
+
+// Cell returns the cell of the token
+func (r CellSearchRequest) Cell() (CellID, error) {
+	cell := CellIDFromToken(r.Token)
+	if !cell.IsValid() {
+		return 0, fmt.Errorf("invalid cell token %q", r.Token)
+	}
+	return cell, nil
+}
+
+// CellCount is the number of drivers inside a cell, Center is the average
+// position of the drivers rather than the center of the cell
+type CellCount struct {
+	Cell   string `json:"cell"`
+	Level  int    `json:"level"`
+	Count  int    `json:"count"`
+	Center Point  `json:"center"`
+}
+
+// CellSearchResult holds either the drivers or the cell counts of a cell search
+type CellSearchResult struct {
+	Cell    string      `json:"cell"`
+	Level   int         `json:"level"`
+	Drivers []*Driver   `json:"drivers,omitempty"`
+	Counts  []CellCount `json:"counts,omitempty"`
+	Count   int         `json:"count"`
+}
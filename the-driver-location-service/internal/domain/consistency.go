@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"context"
+	"strings"
+)
+
+// Consistency is the freshness a reader asks for. Eventual reads may be served
+// from the driver cache and from secondaries, strong reads go to the primary so
+// a driver sees its own location update right after sending it.
+type Consistency string
+
+const (
+	ConsistencyEventual Consistency = "eventual"
+	ConsistencyStrong   Consistency = "strong"
+)
+
+type consistencyKey struct{}
+
+// ParseConsistency returns the consistency of an X-Consistency header, anything
+// but strong keeps the default eventual reads
+func ParseConsistency(value string) Consistency {
+	if strings.EqualFold(strings.TrimSpace(value), string(ConsistencyStrong)) {
+		return ConsistencyStrong
+	}
+	return ConsistencyEventual
+}
+
+// WithConsistency returns a context carrying the consistency of the reads made with it
+func WithConsistency(ctx context.Context, consistency Consistency) context.Context {
+	return context.WithValue(ctx, consistencyKey{}, consistency)
+}
+
+// IsStrongConsistency reports whether the reads of ctx must bypass caches and
+// read from the primary
+func IsStrongConsistency(ctx context.Context) bool {
+	consistency, _ := ctx.Value(consistencyKey{}).(Consistency)
+	return consistency == ConsistencyStrong
+}
@@ -1,19 +1,121 @@
 package domain
 
 import (
+	"encoding/json"
 	"math"
+	"strings"
 	"time"
 )
 
+// FormatTimestamp is the single timestamp format of the API: RFC3339 in UTC,
+// so the zone designator is always present
+func FormatTimestamp(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
 type Point struct {
 	Type        string    `json:"type" bson:"type" validate:"required,eq=Point"`
-	Coordinates []float64 `json:"coordinates" bson:"coordinates" validate:"required,len=2,dive"`
+	Coordinates []float64 `json:"coordinates" bson:"coordinates" validate:"required,len=2,coordinates"`
 }
 type Driver struct {
-	ID        string    `json:"id" bson:"_id,omitempty"`
-	Location  Point     `json:"location" bson:"location" validate:"required"`
-	CreatedAt time.Time `json:"created_at" bson:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+	ID          string            `json:"id" bson:"_id,omitempty"`
+	Location    Point             `json:"location" bson:"location" validate:"required"`
+	RawLocation *Point            `json:"raw_location,omitempty" bson:"raw_location,omitempty"` // reported GPS position when Location was snapped to a road
+	Status      string            `json:"status,omitempty" bson:"status,omitempty" validate:"omitempty,oneof=available busy offline"`
+	GeohashCell string            `json:"geohash_cell,omitempty" bson:"geohash_cell,omitempty"`
+	S2Cell      int64             `json:"-" bson:"s2_cell,omitempty"`                                                                                // leaf CellID of Location, stored signed for mongo
+	TenantID    string            `json:"tenant_id,omitempty" bson:"tenant_id,omitempty"`                                                            // fleet partner owning the driver, a shard key candidate
+	VehicleType string            `json:"vehicle_type,omitempty" bson:"vehicle_type,omitempty" validate:"omitempty,max=32"`                          // stored lower case, e.g. sedan, van or motorcycle
+	Capacity    int               `json:"capacity,omitempty" bson:"capacity,omitempty" validate:"gte=0,lte=100"`                                     // passenger seats, 0 when unknown
+	Attributes  map[string]string `json:"attributes,omitempty" bson:"attributes,omitempty" validate:"max=20,dive,keys,min=1,max=64,endkeys,max=256"` // free-form, e.g. pet_friendly or wheelchair
+	Version     int64             `json:"version,omitempty" bson:"version,omitempty"`
+	Speed       *float64          `json:"speed,omitempty" bson:"speed"`     // meters per second, nil when unknown
+	Heading     *float64          `json:"heading,omitempty" bson:"heading"` // degrees clockwise from north, nil when unknown
+	CreatedAt   time.Time         `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at" bson:"updated_at"`
+	LastSeenAt  *time.Time        `json:"last_seen_at,omitempty" bson:"last_seen_at,omitempty"` // last heartbeat or update, nil for drivers that never sent a heartbeat
+	Outcomes    *DriverOutcomes   `json:"outcomes,omitempty" bson:"outcomes,omitempty"`         // match outcomes reported by the matching service, nil before the first
+}
+
+// Only available drivers are returned by nearby searches, drivers on a trip are
+// busy and drivers that ended their shift are offline
+const (
+	DriverStatusAvailable = "available"
+	DriverStatusBusy      = "busy"
+	DriverStatusOffline   = "offline"
+)
+
+type UpdateStatusRequest struct {
+	Status string `json:"status" validate:"required,oneof=available busy offline" example:"busy"`
+}
+
+// ApplyDefaults fills the fields that were added after the first drivers were
+// stored, it returns the stored names and values of the fields it changed so
+// the backfill job can update existing documents with the same rules
+func (d *Driver) ApplyDefaults() map[string]interface{} {
+	changed := make(map[string]interface{})
+	if d.Status == "" {
+		d.Status = DriverStatusAvailable
+		changed["status"] = d.Status
+	}
+	if len(d.Location.Coordinates) == 2 {
+		if cell := Geohash(d.Location.Latitude(), d.Location.Longitude(), GeohashPrecision); cell != d.GeohashCell {
+			d.GeohashCell = cell
+			changed["geohash_cell"] = cell
+		}
+		if cell := int64(CellIDFromLatLng(d.Location.Latitude(), d.Location.Longitude())); cell != d.S2Cell {
+			d.S2Cell = cell
+			changed["s2_cell"] = cell
+		}
+	}
+	if d.Version == 0 {
+		d.Version = 1
+		changed["version"] = d.Version
+	}
+	return changed
+}
+
+func (d Driver) MarshalJSON() ([]byte, error) {
+	type driver Driver
+	var lastSeenAt string
+	if d.LastSeenAt != nil {
+		lastSeenAt = FormatTimestamp(*d.LastSeenAt)
+	}
+	return json.Marshal(struct {
+		driver
+		CreatedAt  string `json:"created_at"`
+		UpdatedAt  string `json:"updated_at"`
+		LastSeenAt string `json:"last_seen_at,omitempty"`
+	}{
+		driver:     driver(d),
+		CreatedAt:  FormatTimestamp(d.CreatedAt),
+		UpdatedAt:  FormatTimestamp(d.UpdatedAt),
+		LastSeenAt: lastSeenAt,
+	})
+}
+
+// Heartbeat is the answer to a driver heartbeat
+type Heartbeat struct {
+	DriverID   string    `json:"driver_id"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+}
+
+func (h Heartbeat) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		DriverID   string `json:"driver_id"`
+		LastSeenAt string `json:"last_seen_at"`
+	}{
+		DriverID:   h.DriverID,
+		LastSeenAt: FormatTimestamp(h.LastSeenAt),
+	})
+}
+
+// LocationUpdate is the payload of a location update, speed and heading are
+// optional so plain GeoJSON points are still accepted
+type LocationUpdate struct {
+	Point
+	Speed   *float64 `json:"speed,omitempty" validate:"omitempty,gte=0"`
+	Heading *float64 `json:"heading,omitempty" validate:"omitempty,gte=0,lt=360"`
 }
 
 type DriverWithDistance struct {
@@ -21,19 +123,92 @@ type DriverWithDistance struct {
 	Distance float64 `json:"distance"` // meter
 }
 
+// DefaultMaxSearchRadius is the largest nearby search radius in meters unless
+// SEARCH_MAX_RADIUS sets another, the matching service accepts up to the same
+const DefaultMaxSearchRadius = 50000
+
+const (
+	// DefaultSearchLimit is the number of drivers of a nearby search without a
+	// limit unless SEARCH_DEFAULT_LIMIT sets another
+	DefaultSearchLimit = 10
+	// DefaultMaxSearchLimit is the largest limit of a nearby search unless
+	// SEARCH_MAX_LIMIT sets another
+	DefaultMaxSearchLimit = 1000
+)
+
 type SearchRequest struct {
-	Location Point   `json:"location" validate:"required"`
-	Radius   float64 `json:"radius" validate:"required,gt=0"` // radius in meters
-	Limit    int     `json:"limit,omitempty" validate:"omitempty,gte=0"`
+	Location    Point   `json:"location" validate:"required"`
+	MinRadius   float64 `json:"min_radius,omitempty" validate:"omitempty,gte=0,ltfield=Radius"` // inner radius in meters, turns the search into an annulus
+	Radius      float64 `json:"radius" validate:"required,gt=0" example:"500" maximum:"50000"`  // radius in meters, at most SEARCH_MAX_RADIUS, SEARCH_DEFAULT_RADIUS when omitted and set
+	Limit       int     `json:"limit,omitempty" validate:"omitempty,gte=0"`
+	VehicleType string  `json:"vehicle_type,omitempty" validate:"omitempty,max=32" example:"sedan"` // only drivers of this vehicle type
+	MinCapacity int     `json:"min_capacity,omitempty" validate:"gte=0,lte=100" example:"4"`        // only drivers with at least this many seats
+}
+
+// Filter returns the driver filter of the search
+func (r SearchRequest) Filter() DriverFilter {
+	return DriverFilter{VehicleType: NormalizeVehicleType(r.VehicleType), MinCapacity: r.MinCapacity}
+}
+
+// DriverFilter narrows a nearby search to the drivers a rider can take, zero
+// fields do not filter
+type DriverFilter struct {
+	VehicleType string
+	MinCapacity int
+}
+
+func (f DriverFilter) IsZero() bool {
+	return f == DriverFilter{}
+}
+
+// NormalizeVehicleType returns the stored form of a vehicle type, so searches
+// match whatever case a client used
+func NormalizeVehicleType(vehicleType string) string {
+	return strings.ToLower(strings.TrimSpace(vehicleType))
 }
 
 type BatchCreateRequest struct {
-	Drivers []CreateDriverRequest `json:"drivers" validate:"required,min=1,dive"`
+	Drivers   []CreateDriverRequest `json:"drivers" validate:"required,min=1,dive"`
+	Upsert    bool                  `json:"-"` // update the drivers whose IDs are taken instead of failing with a conflict
+	Unordered bool                  `json:"-"` // create every driver that can be, instead of stopping at the first that cannot
+}
+
+// BatchFailureDuplicateID is the reason of a driver of an unordered batch whose ID is taken
+const BatchFailureDuplicateID = "duplicate_id"
+
+// BatchCreateFailure is a driver of an unordered batch that was not created,
+// Index is its position in the request
+type BatchCreateFailure struct {
+	Index  int    `json:"index"`
+	ID     string `json:"id,omitempty"`
+	Reason string `json:"reason"` // duplicate_id, or the error of the database
+}
+
+// CreatedDrivers returns the drivers of an unordered batch that are not among the failures
+func CreatedDrivers(drivers []*Driver, failures []BatchCreateFailure) []*Driver {
+	if len(failures) == 0 {
+		return drivers
+	}
+	failed := make(map[int]bool, len(failures))
+	for _, failure := range failures {
+		failed[failure.Index] = true
+	}
+	created := make([]*Driver, 0, len(drivers)-len(failed))
+	for i, driver := range drivers {
+		if !failed[i] {
+			created = append(created, driver)
+		}
+	}
+	return created
 }
 
 type CreateDriverRequest struct {
-	ID       string `json:"id,omitempty"`
-	Location Point  `json:"location" validate:"required"`
+	ID          string            `json:"id,omitempty"`
+	TenantID    string            `json:"tenant_id,omitempty"`
+	Location    Point             `json:"location" validate:"required"`
+	VehicleType string            `json:"vehicle_type,omitempty" validate:"omitempty,max=32" example:"sedan"`
+	Capacity    int               `json:"capacity,omitempty" validate:"gte=0,lte=100" example:"4"`
+	Attributes  map[string]string `json:"attributes,omitempty" validate:"max=20,dive,keys,min=1,max=64,endkeys,max=256"`
 }
 
 func NewPoint(longitude, latitude float64) Point {
@@ -1,8 +1,11 @@
 package domain
 
 import (
+	"encoding/json"
 	"math"
+	"strings"
 	"testing"
+	"time"
 )
 
 // TestNewPointAndAccessors tests the NewPoint constructor and its accessors.
@@ -88,3 +91,88 @@ func TestNewPoint_NegativeCoordinates(t *testing.T) {
 		t.Errorf("Latitude should be -40.0, got %v", p.Latitude())
 	}
 }
+
+// TestDriver_MarshalJSON_Timestamps tests the JSON encoding of driver timestamps.
+// Expected: Should encode timestamps as RFC3339 in UTC and decode them back.
+func TestDriver_MarshalJSON_Timestamps(t *testing.T) {
+	istanbul := time.FixedZone("TRT", 3*60*60)
+	driver := Driver{
+		ID:        "d1",
+		Location:  NewPoint(29, 41),
+		CreatedAt: time.Date(2024, 1, 1, 15, 0, 0, 0, istanbul),
+		UpdatedAt: time.Date(2024, 1, 1, 15, 30, 0, 0, istanbul),
+	}
+
+	data, err := json.Marshal(driver)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if !strings.Contains(string(data), `"created_at":"2024-01-01T12:00:00Z"`) {
+		t.Errorf("created_at should be RFC3339 UTC, got %s", data)
+	}
+	if !strings.Contains(string(data), `"id":"d1"`) {
+		t.Errorf("Driver fields should be kept, got %s", data)
+	}
+
+	var decoded Driver
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if !decoded.UpdatedAt.Equal(driver.UpdatedAt) {
+		t.Errorf("UpdatedAt should round trip, got %v", decoded.UpdatedAt)
+	}
+	if strings.Contains(string(data), "last_seen_at") {
+		t.Errorf("last_seen_at should be omitted without a heartbeat, got %s", data)
+	}
+
+	driver.LastSeenAt = &driver.UpdatedAt
+	data, err = json.Marshal(driver)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if !strings.Contains(string(data), `"last_seen_at":"2024-01-01T12:30:00Z"`) {
+		t.Errorf("last_seen_at should be RFC3339 UTC, got %s", data)
+	}
+	decoded = Driver{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if decoded.LastSeenAt == nil || !decoded.LastSeenAt.Equal(driver.UpdatedAt) {
+		t.Errorf("LastSeenAt should round trip, got %v", decoded.LastSeenAt)
+	}
+}
+
+// TestGeohash tests geohash encoding of a known coordinate.
+// Expected: Should match the reference geohash and be truncated to the precision.
+func TestGeohash(t *testing.T) {
+	// reference value from https://en.wikipedia.org/wiki/Geohash
+	if got := Geohash(42.605, -5.603, 5); got != "ezs42" {
+		t.Errorf("Geohash should be ezs42, got %s", got)
+	}
+	if got := Geohash(41.0082, 28.9784, GeohashPrecision); len(got) != GeohashPrecision {
+		t.Errorf("Geohash should have %d characters, got %s", GeohashPrecision, got)
+	}
+}
+
+// TestDriver_ApplyDefaults tests filling the fields added after the first release.
+// Expected: Should fill missing fields once and report nothing for an up to date driver.
+func TestDriver_ApplyDefaults(t *testing.T) {
+	driver := Driver{ID: "d1", Location: NewPoint(28.9784, 41.0082)}
+
+	changed := driver.ApplyDefaults()
+	if len(changed) != 4 {
+		t.Fatalf("Should change status, geohash cell, S2 cell and version, got %v", changed)
+	}
+	if driver.Status != DriverStatusAvailable || driver.Version != 1 || driver.GeohashCell == "" || driver.S2Cell == 0 {
+		t.Errorf("Defaults should be set, got %+v", driver)
+	}
+
+	if changed := driver.ApplyDefaults(); len(changed) != 0 {
+		t.Errorf("Up to date driver should not change, got %v", changed)
+	}
+
+	driver.Location = NewPoint(32.8597, 39.9334)
+	if changed := driver.ApplyDefaults(); len(changed) != 2 || changed["geohash_cell"] == nil || changed["s2_cell"] == nil {
+		t.Errorf("Moved driver should only get new cells, got %v", changed)
+	}
+}
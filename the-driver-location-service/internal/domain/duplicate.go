@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// ErrInvalidDuplicateRequest is a validation error, handlers answer it like any other
+var ErrInvalidDuplicateRequest = fmt.Errorf("%w: invalid duplicate request", ErrValidation)
+
+// What a duplicate scan does with the clones it finds, report only lists them
+const (
+	DuplicateActionReport = "report"
+	DuplicateActionMerge  = "merge"
+	DuplicateActionDelete = "delete"
+)
+
+const (
+	// DefaultDuplicateWindow is how far apart the creation times of drivers at the
+	// same coordinates may be for them to count as clones of one import
+	DefaultDuplicateWindow = time.Minute
+	// DefaultDuplicateGroupLimit is the number of shared coordinates a scan looks at
+	DefaultDuplicateGroupLimit = 1000
+)
+
+// DuplicateRequest scans for drivers with identical coordinates created within
+// WindowSeconds of each other. The coordinate CSV has no IDs, so every repeated
+// import creates such clones.
+type DuplicateRequest struct {
+	WindowSeconds int    `json:"window_seconds,omitempty" validate:"gte=0,lte=2592000" example:"60"`
+	Action        string `json:"action,omitempty" validate:"omitempty,oneof=report merge delete" example:"report"`
+	Limit         int    `json:"limit,omitempty" validate:"gte=0,lte=10000" example:"1000"`
+}
+
+// DuplicateGroup is a driver and its clones. With delete the kept driver is the
+// most recently updated one, with merge it is the first created one and takes
+// the state of the most recently updated clone.
+type DuplicateGroup struct {
+	Location     Point    `json:"location"`
+	KeepID       string   `json:"keep_id"`
+	DuplicateIDs []string `json:"duplicate_ids"`
+}
+
+// DuplicateReport lists the groups of clones found, Removed counts the clones
+// deleted by a merge or delete and Truncated is set when the scan stopped at
+// its limit, running it again finds the rest once the clones are gone
+type DuplicateReport struct {
+	Action     string           `json:"action"`
+	Groups     []DuplicateGroup `json:"groups"`
+	Duplicates int              `json:"duplicates"`
+	Removed    int              `json:"removed"`
+	Truncated  bool             `json:"truncated"`
+}
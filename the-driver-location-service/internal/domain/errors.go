@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Kinds of errors the handlers map to HTTP statuses, errors of the services
+// wrap one of them so transports don't have to match on messages
+var (
+	ErrValidation = errors.New("validation failed")
+	ErrNotFound   = errors.New("not found")
+	ErrConflict   = errors.New("conflict")
+)
+
+// ErrDriverNotFound is wrapped by the repository when no driver has the given ID
+var ErrDriverNotFound = fmt.Errorf("driver %w", ErrNotFound)
+
+// DuplicateDriverError is the conflict of creating drivers whose IDs are taken,
+// it names them so clients can drop them from a retry or upsert them instead
+type DuplicateDriverError struct {
+	IDs []string
+}
+
+func (e *DuplicateDriverError) Error() string {
+	switch len(e.IDs) {
+	case 0:
+		return "conflict: a driver already exists"
+	case 1:
+		return fmt.Sprintf("conflict: driver %s already exists", e.IDs[0])
+	}
+	return fmt.Sprintf("conflict: drivers %s already exist", strings.Join(e.IDs, ", "))
+}
+
+func (e *DuplicateDriverError) Unwrap() error {
+	return ErrConflict
+}
+
+// PartialBatchError is returned next to the created drivers of an unordered
+// batch that could not create all of them, it lists the others
+type PartialBatchError struct {
+	Failed []BatchCreateFailure
+}
+
+func (e *PartialBatchError) Error() string {
+	if len(e.Failed) == 1 {
+		return "1 driver of the batch was not created"
+	}
+	return fmt.Sprintf("%d drivers of the batch were not created", len(e.Failed))
+}
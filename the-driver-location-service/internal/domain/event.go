@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type DriverEventType string
+
+const (
+	DriverCreated         DriverEventType = "driver.created"
+	DriverLocationUpdated DriverEventType = "driver.location_updated"
+	DriverDeleted         DriverEventType = "driver.deleted"
+	DriverWentOffline     DriverEventType = "driver.went_offline"
+	DriverStatusChanged   DriverEventType = "driver.status_changed"
+)
+
+// DriverEvent is published after a driver change is stored, Location, Speed,
+// Heading and Status are the state after the change and are empty for deletions
+type DriverEvent struct {
+	Type       DriverEventType `json:"type"`
+	DriverID   string          `json:"driver_id"`
+	Location   *Point          `json:"location,omitempty"`
+	Speed      *float64        `json:"speed,omitempty"`
+	Heading    *float64        `json:"heading,omitempty"`
+	Status     string          `json:"status,omitempty"`
+	OccurredAt time.Time       `json:"occurred_at"`
+}
+
+func NewDriverEvent(eventType DriverEventType, driver *Driver) DriverEvent {
+	location := driver.Location
+	return DriverEvent{
+		Type:       eventType,
+		DriverID:   driver.ID,
+		Location:   &location,
+		Speed:      driver.Speed,
+		Heading:    driver.Heading,
+		Status:     driver.Status,
+		OccurredAt: time.Now().UTC(),
+	}
+}
+
+func (e DriverEvent) MarshalJSON() ([]byte, error) {
+	type event DriverEvent
+	return json.Marshal(struct {
+		event
+		OccurredAt string `json:"occurred_at"`
+	}{
+		event:      event(e),
+		OccurredAt: FormatTimestamp(e.OccurredAt),
+	})
+}
@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"context"
+	"hash/fnv"
+	"strings"
+)
+
+// Known feature flags, risky code paths are only taken when their flag is enabled
+const (
+	FlagWriteBehindCache = "write_behind_cache"
+	FlagGeoNearSearch    = "geonear_search"
+	FlagReadOnly         = "read_only" // refuses writes on the instances it is rolled out to by hostname, see ReadOnlyMode
+)
+
+type rolloutKey struct{}
+
+// WithRolloutKey returns a context carrying the key percentage flags are
+// rolled out by for the request, the ID of the API key that authenticated it
+func WithRolloutKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, rolloutKey{}, key)
+}
+
+// RolloutKeyFrom returns the rollout key of the request, empty outside of an
+// authenticated request
+func RolloutKeyFrom(ctx context.Context) string {
+	key, _ := ctx.Value(rolloutKey{}).(string)
+	return key
+}
+
+type FeatureFlag struct {
+	Name         string   `json:"name"`
+	Enabled      bool     `json:"enabled"`
+	Percentage   int      `json:"percentage"`             // rollout percentage, 100 when empty
+	Environments []string `json:"environments,omitempty"` // empty means every environment
+}
+
+// IsEnabledFor reports whether the flag is on for the given environment and rollout key.
+// The key (driver ID, API key...) is hashed together with the flag name so every
+// flag picks a different, but stable, slice of keys.
+func (f FeatureFlag) IsEnabledFor(environment, key string) bool {
+	if !f.Enabled {
+		return false
+	}
+
+	if len(f.Environments) > 0 {
+		found := false
+		for _, env := range f.Environments {
+			if strings.EqualFold(env, environment) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if f.Percentage <= 0 || f.Percentage >= 100 {
+		// 0 is treated as "not set" so a plain enabled flag is on for everyone
+		return true
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(f.Name + ":" + key))
+	return int(h.Sum32()%100) < f.Percentage
+}
@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestFeatureFlag_IsEnabledFor_Disabled tests a disabled flag.
+// Expected: Should be off for every key.
+func TestFeatureFlag_IsEnabledFor_Disabled(t *testing.T) {
+	flag := FeatureFlag{Name: FlagGeoNearSearch, Enabled: false}
+	if flag.IsEnabledFor("production", "driver-1") {
+		t.Error("Disabled flag should be off")
+	}
+}
+
+// TestFeatureFlag_IsEnabledFor_Environments tests environment restricted flags.
+// Expected: Should be on only in the listed environments.
+func TestFeatureFlag_IsEnabledFor_Environments(t *testing.T) {
+	flag := FeatureFlag{Name: FlagGeoNearSearch, Enabled: true, Environments: []string{"staging"}}
+	if !flag.IsEnabledFor("Staging", "driver-1") {
+		t.Error("Flag should be on in staging")
+	}
+	if flag.IsEnabledFor("production", "driver-1") {
+		t.Error("Flag should be off in production")
+	}
+}
+
+// TestFeatureFlag_IsEnabledFor_Percentage tests percentage rollouts.
+// Expected: Should enable a stable share of keys close to the percentage.
+func TestFeatureFlag_IsEnabledFor_Percentage(t *testing.T) {
+	flag := FeatureFlag{Name: FlagGeoNearSearch, Enabled: true, Percentage: 30}
+
+	enabled := 0
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("driver-%d", i)
+		if flag.IsEnabledFor("production", key) {
+			enabled++
+		}
+		if flag.IsEnabledFor("production", key) != flag.IsEnabledFor("production", key) {
+			t.Fatalf("Rollout should be stable for key %s", key)
+		}
+	}
+
+	if enabled < 200 || enabled > 400 {
+		t.Errorf("About 30%% of keys should be enabled, got %d/1000", enabled)
+	}
+}
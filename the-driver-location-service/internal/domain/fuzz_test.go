@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+// FuzzPoint_UnmarshalJSON feeds arbitrary request bodies to the GeoJSON point of the driver requests
+// Expected: Should never panic and only accept points with a finite longitude and latitude in range
+func FuzzPoint_UnmarshalJSON(f *testing.F) {
+	for _, seed := range []string{
+		`{"type": "Point", "coordinates": [29.0, 41.0]}`,
+		`{"type": "Point", "coordinates": [-180, -90]}`,
+		`{"type": "Point", "coordinates": [180.0001, 0]}`,
+		`{"type": "Point", "coordinates": [0, 1e309]}`,
+		`{"type": "Point", "coordinates": [29.0]}`,
+		`{"type": "Point", "coordinates": [29.0, 41.0, 12]}`,
+		`{"type": "Point", "coordinates": null}`,
+		`{"type": "Polygon", "coordinates": [29.0, 41.0]}`,
+		`{"coordinates": ["29", "41"]}`,
+		`[]`,
+	} {
+		f.Add([]byte(seed))
+	}
+
+	validate := NewValidator()
+	f.Fuzz(func(t *testing.T, body []byte) {
+		var req CreateDriverRequest
+		if err := json.Unmarshal(append(append([]byte(`{"location": `), body...), '}'), &req); err != nil {
+			return
+		}
+		if err := validate.Struct(req); err != nil {
+			return
+		}
+
+		longitude, latitude := req.Location.Longitude(), req.Location.Latitude()
+		if !(longitude >= -180 && longitude <= 180 && latitude >= -90 && latitude <= 90) {
+			t.Fatalf("accepted point out of range: %v", req.Location.Coordinates)
+		}
+		driver := &Driver{Location: req.Location}
+		driver.ApplyDefaults()
+		if distance := req.Location.Distance(NewPoint(29, 41)); math.IsNaN(distance) {
+			t.Fatalf("distance to %v is NaN", req.Location.Coordinates)
+		}
+	})
+}
+
+// FuzzArea_Polygons feeds arbitrary GeoJSON areas to the polygon parser of the area searches
+// Expected: Should never panic and only return closed rings of at least 4 positions in range
+func FuzzArea_Polygons(f *testing.F) {
+	for _, seed := range []string{
+		`{"type": "Polygon", "coordinates": [[[28.9, 41.0], [29.1, 41.0], [29.1, 41.1], [28.9, 41.0]]]}`,
+		`{"type": "MultiPolygon", "coordinates": [[[[28.9, 41.0], [29.1, 41.0], [29.1, 41.1], [28.9, 41.0]]]]}`,
+		`{"type": "Polygon", "coordinates": [[[28.9, 41.0], [29.1, 41.0], [28.9, 41.0]]]}`,
+		`{"type": "Polygon", "coordinates": [[[28.9], [29.1, 41.0], [29.1, 41.1], [28.9]]]}`,
+		`{"type": "Polygon", "coordinates": [[]]}`,
+		`{"type": "Polygon", "coordinates": []}`,
+		`{"type": "MultiPolygon", "coordinates": [[]]}`,
+		`{"type": "Point", "coordinates": [29.0, 41.0]}`,
+	} {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, body []byte) {
+		var area Area
+		if err := json.Unmarshal(body, &area); err != nil {
+			return
+		}
+		polygons, err := area.Polygons()
+		if err != nil {
+			return
+		}
+
+		for _, polygon := range polygons {
+			for _, ring := range polygon {
+				if len(ring) < 4 {
+					t.Fatalf("accepted ring of %d positions", len(ring))
+				}
+				for _, position := range ring {
+					if len(position) != 2 || !ValidCoordinates(position[0], position[1]) {
+						t.Fatalf("accepted position %v", position)
+					}
+				}
+				if first, last := ring[0], ring[len(ring)-1]; first[0] != last[0] || first[1] != last[1] {
+					t.Fatalf("accepted open ring %v", ring)
+				}
+			}
+		}
+	})
+}
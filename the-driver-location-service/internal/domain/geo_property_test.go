@@ -0,0 +1,129 @@
+package domain
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// earthRadius is the radius HaversineDistance measures with, half the
+// circumference is the longest distance between two points
+const earthRadius = 6371000
+
+// propertyRuns is how many random points each property is checked with, the
+// seed is fixed so a failure can be reproduced
+const propertyRuns = 10000
+
+func randomLatLon(rng *rand.Rand) (lat, lon float64) {
+	return rng.Float64()*180 - 90, rng.Float64()*360 - 180
+}
+
+// TestHaversineDistance_ZeroDistance tests the distance of random points to themselves
+// Expected: Should be exactly zero everywhere, poles and antimeridian included
+func TestHaversineDistance_ZeroDistance(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < propertyRuns; i++ {
+		lat, lon := randomLatLon(rng)
+		if dist := HaversineDistance(lat, lon, lat, lon); dist != 0 {
+			t.Fatalf("distance of (%v, %v) to itself should be 0, got %v", lat, lon, dist)
+		}
+	}
+	for _, p := range [][2]float64{{90, 0}, {-90, 180}, {0, 180}, {0, -180}} {
+		if dist := HaversineDistance(p[0], p[1], p[0], p[1]); dist != 0 {
+			t.Errorf("distance of %v to itself should be 0, got %v", p, dist)
+		}
+	}
+}
+
+// TestHaversineDistance_Symmetry tests the distance between random pairs of points both ways
+// Expected: Should be the same in both directions
+func TestHaversineDistance_Symmetry(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for i := 0; i < propertyRuns; i++ {
+		lat1, lon1 := randomLatLon(rng)
+		lat2, lon2 := randomLatLon(rng)
+		there := HaversineDistance(lat1, lon1, lat2, lon2)
+		back := HaversineDistance(lat2, lon2, lat1, lon1)
+		if math.Abs(there-back) > 1e-6 {
+			t.Fatalf("distance between (%v, %v) and (%v, %v) is %v there and %v back", lat1, lon1, lat2, lon2, there, back)
+		}
+	}
+}
+
+// TestHaversineDistance_Bounds tests the distance between random pairs of points
+// Expected: Should never be negative, NaN or longer than half the circumference of the earth
+func TestHaversineDistance_Bounds(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	for i := 0; i < propertyRuns; i++ {
+		lat1, lon1 := randomLatLon(rng)
+		lat2, lon2 := randomLatLon(rng)
+		dist := HaversineDistance(lat1, lon1, lat2, lon2)
+		if !(dist >= 0 && dist <= math.Pi*earthRadius+1e-6) {
+			t.Fatalf("distance between (%v, %v) and (%v, %v) out of bounds: %v", lat1, lon1, lat2, lon2, dist)
+		}
+	}
+}
+
+// TestHaversineDistance_TriangleInequality tests random triangles of points
+// Expected: Should never find a detour through a third point shorter than the direct distance
+func TestHaversineDistance_TriangleInequality(t *testing.T) {
+	rng := rand.New(rand.NewSource(4))
+	for i := 0; i < propertyRuns; i++ {
+		latA, lonA := randomLatLon(rng)
+		latB, lonB := randomLatLon(rng)
+		latC, lonC := randomLatLon(rng)
+		if i%2 == 0 {
+			// points a few kilometers apart, the distances searches deal with
+			latB, lonB = latA+rng.Float64()*0.05, lonA+rng.Float64()*0.05
+			latC, lonC = latA-rng.Float64()*0.05, lonA+rng.Float64()*0.05
+		}
+
+		direct := HaversineDistance(latA, lonA, latC, lonC)
+		detour := HaversineDistance(latA, lonA, latB, lonB) + HaversineDistance(latB, lonB, latC, lonC)
+		if direct > detour+1e-6 {
+			t.Fatalf("distance from (%v, %v) to (%v, %v) is %v, the detour through (%v, %v) only %v", latA, lonA, latC, lonC, direct, latB, lonB, detour)
+		}
+	}
+}
+
+// TestHaversineDistance_Antipodal tests the distance of random points to their antipodes
+// Expected: Should be half the circumference of the earth, the longest distance there is
+func TestHaversineDistance_Antipodal(t *testing.T) {
+	rng := rand.New(rand.NewSource(5))
+	for i := 0; i < propertyRuns; i++ {
+		lat, lon := randomLatLon(rng)
+		antipodeLon := lon + 180
+		if antipodeLon > 180 {
+			antipodeLon -= 360
+		}
+		dist := HaversineDistance(lat, lon, -lat, antipodeLon)
+		if math.Abs(dist-math.Pi*earthRadius) > 1 {
+			t.Fatalf("distance of (%v, %v) to its antipode should be %v, got %v", lat, lon, math.Pi*earthRadius, dist)
+		}
+	}
+}
+
+// TestHaversineDistance_GreatCircles tests random distances along a meridian and along the equator
+// Expected: Should be the arc length of the angle between the points and ignore which side of the antimeridian they are on
+func TestHaversineDistance_GreatCircles(t *testing.T) {
+	rng := rand.New(rand.NewSource(6))
+	for i := 0; i < propertyRuns; i++ {
+		lat1, lon := randomLatLon(rng)
+		lat2, _ := randomLatLon(rng)
+		want := math.Abs(lat2-lat1) * math.Pi / 180 * earthRadius
+		if dist := HaversineDistance(lat1, lon, lat2, lon); math.Abs(dist-want) > 1e-3 {
+			t.Fatalf("distance between (%v, %v) and (%v, %v) along the meridian should be %v, got %v", lat1, lon, lat2, lon, want, dist)
+		}
+
+		lon1 := rng.Float64()*360 - 180
+		delta := rng.Float64() * 180
+		lon2 := lon1 + delta
+		if lon2 > 180 {
+			lon2 -= 360
+		}
+		want = delta * math.Pi / 180 * earthRadius
+		if dist := HaversineDistance(0, lon1, 0, lon2); math.Abs(dist-want) > 1e-3 {
+			t.Fatalf("distance between (0, %v) and (0, %v) along the equator should be %v, got %v", lon1, lon2, want, dist)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package domain
+
+import "strings"
+
+// GeohashPrecision is the precision of Driver.GeohashCell, 7 characters is a cell of about 150m x 150m
+const GeohashPrecision = 7
+
+const geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// Geohash encodes a coordinate into a geohash of the given length
+// https://en.wikipedia.org/wiki/Geohash
+func Geohash(latitude, longitude float64, precision int) string {
+	latRange := [2]float64{-90, 90}
+	lonRange := [2]float64{-180, 180}
+
+	var b strings.Builder
+	bit, ch, even := 0, 0, true
+	for b.Len() < precision {
+		if even {
+			mid := (lonRange[0] + lonRange[1]) / 2
+			if longitude >= mid {
+				ch |= 1 << (4 - bit)
+				lonRange[0] = mid
+			} else {
+				lonRange[1] = mid
+			}
+		} else {
+			mid := (latRange[0] + latRange[1]) / 2
+			if latitude >= mid {
+				ch |= 1 << (4 - bit)
+				latRange[0] = mid
+			} else {
+				latRange[1] = mid
+			}
+		}
+		even = !even
+
+		if bit < 4 {
+			bit++
+		} else {
+			b.WriteByte(geohashAlphabet[ch])
+			bit, ch = 0, 0
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// DriverInactivity is reported when a driver stopped sending location updates and
+// was taken offline, LastSeenAt is the time of the last update
+type DriverInactivity struct {
+	DriverID   string    `json:"driver_id"`
+	Location   Point     `json:"location"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	DetectedAt time.Time `json:"detected_at"`
+}
+
+func NewDriverInactivity(driver *Driver, detectedAt time.Time) DriverInactivity {
+	return DriverInactivity{
+		DriverID:   driver.ID,
+		Location:   driver.Location,
+		LastSeenAt: driver.UpdatedAt,
+		DetectedAt: detectedAt,
+	}
+}
+
+func (i DriverInactivity) MarshalJSON() ([]byte, error) {
+	type inactivity DriverInactivity
+	return json.Marshal(struct {
+		inactivity
+		LastSeenAt string `json:"last_seen_at"`
+		DetectedAt string `json:"detected_at"`
+	}{
+		inactivity: inactivity(i),
+		LastSeenAt: FormatTimestamp(i.LastSeenAt),
+		DetectedAt: FormatTimestamp(i.DetectedAt),
+	})
+}
@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"encoding/base64"
+	"errors"
+	"time"
+)
+
+const (
+	DefaultListLimit = 100
+	MaxListLimit     = 1000
+)
+
+// ListDriversRequest pages through the drivers ordered by ID. Cursor is the
+// next_cursor of the previous page, without one the first page is returned.
+type ListDriversRequest struct {
+	Cursor       string `query:"cursor"`
+	Limit        int    `query:"limit" validate:"gte=0,lte=1000"`
+	Status       string `query:"status" validate:"omitempty,oneof=available busy offline"`
+	UpdatedSince string `query:"updated_since"` // RFC3339
+}
+
+// DriverListFilter narrows a driver list, zero fields do not filter
+type DriverListFilter struct {
+	Status       string
+	UpdatedSince time.Time
+}
+
+// Filter returns the filter of the request
+func (r ListDriversRequest) Filter() (DriverListFilter, error) {
+	filter := DriverListFilter{Status: r.Status}
+	if r.UpdatedSince != "" {
+		since, err := time.Parse(time.RFC3339, r.UpdatedSince)
+		if err != nil {
+			return filter, errors.New("updated_since must be an RFC3339 timestamp")
+		}
+		filter.UpdatedSince = since
+	}
+	return filter, nil
+}
+
+// DriverPage is a page of the driver list, NextCursor is empty on the last page
+type DriverPage struct {
+	Drivers    []*Driver `json:"drivers"`
+	Count      int       `json:"count"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+}
+
+// EncodeListCursor returns the cursor of the page after the driver with the
+// given ID, clients pass it back as is
+func EncodeListCursor(id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(id))
+}
+
+// DecodeListCursor returns the ID of the last driver of the previous page
+func DecodeListCursor(cursor string) (string, error) {
+	id, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(id) == 0 {
+		return "", errors.New("invalid cursor")
+	}
+	return string(id), nil
+}
@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"encoding/json"
+	"math"
+)
+
+// Outcomes of a match the matching service reports for the matched driver
+const (
+	OutcomeAccepted  = "accepted"
+	OutcomeCompleted = "completed"
+	OutcomeCancelled = "cancelled"
+)
+
+// OutcomeRequest reports what became of a match of the driver, cancelled is
+// only reported for rides the driver cancelled
+type OutcomeRequest struct {
+	MatchID string `json:"match_id" validate:"required,max=64" example:"9b2d4c6e8f0a1b3c5d7e9f1a2b3c4d5e"`
+	Outcome string `json:"outcome" validate:"required,oneof=accepted completed cancelled" example:"completed"`
+}
+
+// DriverOutcomes counts the reported outcomes of the driver's matches, they are
+// the supply side quality signal searches may rank by
+type DriverOutcomes struct {
+	Accepted  int64 `json:"accepted" bson:"accepted,omitempty"`
+	Completed int64 `json:"completed" bson:"completed,omitempty"`
+	Cancelled int64 `json:"cancelled" bson:"cancelled,omitempty"`
+}
+
+// QualityScore is the share of the driver's finished rides that were completed,
+// smoothed towards 0.5 so a few rides do not decide the score of a new driver
+func (o DriverOutcomes) QualityScore() float64 {
+	score := float64(o.Completed+1) / float64(o.Completed+o.Cancelled+2)
+	return math.Round(score*1000) / 1000
+}
+
+func (o DriverOutcomes) MarshalJSON() ([]byte, error) {
+	type outcomes DriverOutcomes
+	return json.Marshal(struct {
+		outcomes
+		QualityScore float64 `json:"quality_score"`
+	}{
+		outcomes:     outcomes(o),
+		QualityScore: o.QualityScore(),
+	})
+}
@@ -0,0 +1,25 @@
+package domain
+
+import "time"
+
+// Sources of the read-only mode
+const (
+	ReadOnlyByAdmin = "admin"
+	ReadOnlyByFlag  = "flag"
+)
+
+// ReadOnlyMode is the emergency read-only state of the service. While it is on,
+// searches and reads keep working and every write is refused, so the database
+// can be maintained without taking matching down.
+type ReadOnlyMode struct {
+	Enabled bool       `json:"enabled"`
+	Source  string     `json:"source,omitempty"` // admin or flag
+	Reason  string     `json:"reason,omitempty"`
+	Since   *time.Time `json:"since,omitempty"`
+}
+
+// SetReadOnlyRequest turns the read-only mode of an instance on or off
+type SetReadOnlyRequest struct {
+	Enabled *bool  `json:"enabled" validate:"required" example:"true"`
+	Reason  string `json:"reason,omitempty" example:"MongoDB primary failover"`
+}
@@ -0,0 +1,57 @@
+package domain
+
+import "fmt"
+
+// ErrInvalidReconcileRequest is a validation error, handlers answer it like any other
+var ErrInvalidReconcileRequest = fmt.Errorf("%w: invalid reconcile request", ErrValidation)
+
+// DefaultLocationToleranceMeters is how far a stored location may be from the
+// expected one before the driver is reported as divergent
+const DefaultLocationToleranceMeters = 50.0
+
+// The ID prefix of a reconcile request lists the stored drivers of a partner, a
+// prefix shorter than MinIDPrefixLength or matching more than MaxPrefixedDrivers
+// drivers would list a large part of the collection and is refused
+const (
+	MinIDPrefixLength  = 3
+	MaxPrefixedDrivers = 20000
+)
+
+// ReconcileDriver is a driver as the partner expects it, an empty status or a
+// missing location is not compared
+type ReconcileDriver struct {
+	ID       string `json:"id" validate:"required" example:"driver-123"`
+	Status   string `json:"status,omitempty" validate:"omitempty,oneof=available busy offline" example:"available"`
+	Location *Point `json:"location,omitempty" validate:"omitempty"`
+}
+
+// ReconcileRequest is the partner's list of drivers, at most 5000 per request. Drivers the partner does not
+// know about can only be found among the drivers whose ID starts with IDPrefix,
+// extra drivers are not reported without a prefix.
+type ReconcileRequest struct {
+	IDPrefix                string            `json:"id_prefix,omitempty" validate:"omitempty,min=3" example:"fleet-a-" minLength:"3"`
+	LocationToleranceMeters float64           `json:"location_tolerance_meters,omitempty" validate:"omitempty,gt=0" example:"50"`
+	Drivers                 []ReconcileDriver `json:"drivers" validate:"required,min=1,max=5000,dive"`
+}
+
+// DriverDivergence is a driver whose stored state differs from the expected one,
+// only the fields that differ are set
+type DriverDivergence struct {
+	ID               string   `json:"id"`
+	ExpectedStatus   string   `json:"expected_status,omitempty"`
+	ActualStatus     string   `json:"actual_status,omitempty"`
+	ExpectedLocation *Point   `json:"expected_location,omitempty"`
+	ActualLocation   *Point   `json:"actual_location,omitempty"`
+	DistanceMeters   *float64 `json:"distance_meters,omitempty"`
+}
+
+// ReconcileReport lists the drivers the partner expects but are not stored (Missing),
+// the stored drivers with the partner's prefix it did not list (Extra) and the
+// drivers that differ (Divergent)
+type ReconcileReport struct {
+	Checked   int                `json:"checked"`
+	Matched   int                `json:"matched"`
+	Missing   []string           `json:"missing"`
+	Extra     []string           `json:"extra"`
+	Divergent []DriverDivergence `json:"divergent"`
+}
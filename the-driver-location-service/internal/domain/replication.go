@@ -0,0 +1,60 @@
+package domain
+
+import "time"
+
+// ReplicationOp is what a replicated change does to the driver on the standby
+type ReplicationOp string
+
+const (
+	ReplicationUpsert ReplicationOp = "upsert"
+	ReplicationDelete ReplicationOp = "delete"
+)
+
+// DriverChange is a driver write of the primary region replayed on the standby.
+// UpdatedAt is the time of the write on the primary, the standby keeps whichever
+// write of a driver is the latest and skips older ones arriving late.
+type DriverChange struct {
+	Op        ReplicationOp `json:"op" validate:"required,oneof=upsert delete" example:"upsert"`
+	DriverID  string        `json:"driver_id" validate:"required" example:"driver-123"`
+	Driver    *Driver       `json:"driver,omitempty" validate:"required_if=Op upsert"`
+	UpdatedAt time.Time     `json:"updated_at" validate:"required"`
+}
+
+// NewDriverUpsert replicates the stored state of the driver, it is copied since
+// the change is sent after the caller moved on
+func NewDriverUpsert(driver *Driver) DriverChange {
+	stored := *driver
+	return DriverChange{Op: ReplicationUpsert, DriverID: driver.ID, Driver: &stored, UpdatedAt: driver.UpdatedAt}
+}
+
+// MirrorRange asks the standby to delete the drivers it stores with IDs after
+// AfterID up to ThroughID that are missing from the batch, unless they were
+// updated after SnapshotAt. An empty ThroughID runs to the last driver. The
+// reconciliation job sends every page of the primary with the range it covers,
+// so deletions the standby missed are caught up.
+type MirrorRange struct {
+	AfterID    string    `json:"after_id"`
+	ThroughID  string    `json:"through_id"`
+	SnapshotAt time.Time `json:"snapshot_at" validate:"required"`
+}
+
+// ReplicationBatch is the body the primary posts to the standby
+type ReplicationBatch struct {
+	Changes []DriverChange `json:"changes" validate:"max=1000,dive"`
+	Mirror  *MirrorRange   `json:"mirror,omitempty"`
+}
+
+// ReplicationResult counts what the standby did with a batch, changes older than
+// the stored driver are skipped
+type ReplicationResult struct {
+	Applied int `json:"applied"`
+	Skipped int `json:"skipped"`
+	Deleted int `json:"deleted"` // drivers of the mirror range missing on the primary
+}
+
+// Add sums the results of the batches of a reconciliation
+func (r *ReplicationResult) Add(other ReplicationResult) {
+	r.Applied += other.Applied
+	r.Skipped += other.Skipped
+	r.Deleted += other.Deleted
+}
@@ -0,0 +1,158 @@
+package domain
+
+import (
+	"math"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// CellID is an S2 cell, a square of the Hilbert curve over the six faces of a
+// cube projected on the sphere. The cells of a level all have about the same
+// area, level 13 is about 1km² and level 30 (leaf) about 1cm², and the IDs of
+// the descendants of a cell are a contiguous range so any cell can be queried
+// with a range over stored leaf IDs.
+// https://s2geometry.io/devguide/s2cell_hierarchy
+type CellID uint64
+
+const (
+	MaxCellLevel = 30
+
+	cellPosBits = 2*MaxCellLevel + 1
+	cellMaxSize = 1 << MaxCellLevel
+
+	cellSwapMask   = 1
+	cellInvertMask = 2
+)
+
+// cellIJToPos maps the (i, j) quadrant of a cell to its position on the Hilbert
+// curve for each orientation, cellPosToOrientation is the orientation change of
+// the sub-curve at a position
+var (
+	cellIJToPos = [4][4]uint64{
+		{0, 1, 3, 2}, // canonical order
+		{0, 3, 1, 2}, // axes swapped
+		{2, 3, 1, 0}, // bits inverted
+		{2, 1, 3, 0}, // swapped & inverted
+	}
+	cellPosToOrientation = [4]int{cellSwapMask, 0, 0, cellInvertMask | cellSwapMask}
+)
+
+// CellIDFromLatLng returns the leaf cell containing a coordinate
+func CellIDFromLatLng(latitude, longitude float64) CellID {
+	lat := latitude * math.Pi / 180
+	lng := longitude * math.Pi / 180
+	x := math.Cos(lat) * math.Cos(lng)
+	y := math.Cos(lat) * math.Sin(lng)
+	z := math.Sin(lat)
+
+	face, u, v := cellFaceUV(x, y, z)
+	i := cellSTToIJ(cellUVToST(u))
+	j := cellSTToIJ(cellUVToST(v))
+
+	orientation := face & cellSwapMask
+	var pos uint64
+	for k := MaxCellLevel - 1; k >= 0; k-- {
+		quadrant := ((i>>k)&1)<<1 | (j>>k)&1
+		p := cellIJToPos[orientation][quadrant]
+		pos |= p << (2 * k)
+		orientation ^= cellPosToOrientation[p]
+	}
+	return CellID(uint64(face)<<cellPosBits | pos<<1 | 1)
+}
+
+// cellFaceUV projects a point of the unit sphere on the cube face it falls on
+func cellFaceUV(x, y, z float64) (int, float64, float64) {
+	face := 0
+	ax, ay, az := math.Abs(x), math.Abs(y), math.Abs(z)
+	if ay > ax {
+		face = 1
+	}
+	if az > math.Max(ax, ay) {
+		face = 2
+	}
+	if (face == 0 && x < 0) || (face == 1 && y < 0) || (face == 2 && z < 0) {
+		face += 3
+	}
+
+	switch face {
+	case 0:
+		return face, y / x, z / x
+	case 1:
+		return face, -x / y, z / y
+	case 2:
+		return face, -x / z, -y / z
+	case 3:
+		return face, z / x, y / x
+	case 4:
+		return face, z / y, -x / y
+	default:
+		return face, -y / z, -x / z
+	}
+}
+
+// cellUVToST applies the quadratic transform S2 uses to even out cell areas
+func cellUVToST(u float64) float64 {
+	if u >= 0 {
+		return 0.5 * math.Sqrt(1+3*u)
+	}
+	return 1 - 0.5*math.Sqrt(1-3*u)
+}
+
+func cellSTToIJ(s float64) int {
+	return max(0, min(cellMaxSize-1, int(math.Floor(cellMaxSize*s))))
+}
+
+// CellIDFromToken parses a cell token, invalid tokens return an invalid cell
+func CellIDFromToken(token string) CellID {
+	if len(token) == 0 || len(token) > 16 {
+		return 0
+	}
+	id, err := strconv.ParseUint(token+strings.Repeat("0", 16-len(token)), 16, 64)
+	if err != nil {
+		return 0
+	}
+	return CellID(id)
+}
+
+// Token is the compact hex form of the cell, its ID without the trailing zeros
+func (c CellID) Token() string {
+	if c == 0 {
+		return "X"
+	}
+	return strings.TrimRight(strconv.FormatUint(uint64(c), 16), "0")
+}
+
+// IsValid reports whether c is the ID of a cell of one of the six faces
+func (c CellID) IsValid() bool {
+	return c>>cellPosBits < 6 && c.lsb()&0x1555555555555555 != 0
+}
+
+// Level returns the level of the cell, 0 for a face and MaxCellLevel for a leaf
+func (c CellID) Level() int {
+	return MaxCellLevel - bits.TrailingZeros64(uint64(c))>>1
+}
+
+// Parent returns the cell of the given level containing c, level must not be
+// finer than the level of c
+func (c CellID) Parent(level int) CellID {
+	lsb := cellLSBForLevel(level)
+	return CellID((uint64(c) & -lsb) | lsb)
+}
+
+// RangeMin and RangeMax are the first and last leaf cells inside c
+func (c CellID) RangeMin() CellID { return c - CellID(c.lsb()-1) }
+func (c CellID) RangeMax() CellID { return c + CellID(c.lsb()-1) }
+
+// Contains reports whether other is c or one of its descendants
+func (c CellID) Contains(other CellID) bool {
+	return other >= c.RangeMin() && other <= c.RangeMax()
+}
+
+func (c CellID) lsb() uint64 {
+	return uint64(c) & -uint64(c)
+}
+
+func cellLSBForLevel(level int) uint64 {
+	return 1 << (2 * (MaxCellLevel - level))
+}
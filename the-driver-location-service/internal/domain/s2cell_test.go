@@ -0,0 +1,75 @@
+package domain
+
+import "testing"
+
+// TestCellIDFromLatLng tests computing the leaf cell of coordinates
+// Expected: Should match the S2 cell IDs and keep nearby points in the same coarse cells
+func TestCellIDFromLatLng(t *testing.T) {
+	if cell := CellIDFromLatLng(0, 0); cell != 0x1000000000000001 {
+		t.Errorf("Leaf cell of (0, 0) should be 0x1000000000000001, got %#x", uint64(cell))
+	}
+	if token := CellIDFromLatLng(40.7128, -74.0060).Parent(12).Token(); token != "89c25a3" {
+		t.Errorf("Level 12 cell of New York should be 89c25a3, got %s", token)
+	}
+
+	istanbul := CellIDFromLatLng(41.0082, 28.9784)
+	nearby := CellIDFromLatLng(41.0085, 28.9788)
+	if istanbul.Level() != MaxCellLevel || !istanbul.IsValid() {
+		t.Fatalf("Should return a valid leaf cell, got level %d", istanbul.Level())
+	}
+	if istanbul.Parent(13) != nearby.Parent(13) {
+		t.Errorf("Points 50m apart should share their level 13 cell")
+	}
+	if istanbul.Parent(13) == CellIDFromLatLng(39.9334, 32.8597).Parent(13) {
+		t.Errorf("Istanbul and Ankara should not share a level 13 cell")
+	}
+}
+
+// TestCellID_Faces tests the cells of the six cube faces
+// Expected: Should put each axis direction on its own face
+func TestCellID_Faces(t *testing.T) {
+	tests := []struct {
+		lat, lng float64
+		token    string
+	}{
+		{0, 0, "1"},
+		{0, 90, "3"},
+		{90, 0, "5"},
+		{0, 180, "7"},
+		{0, -90, "9"},
+		{-90, 0, "b"},
+	}
+
+	for _, tt := range tests {
+		if token := CellIDFromLatLng(tt.lat, tt.lng).Parent(0).Token(); token != tt.token {
+			t.Errorf("Face of (%v, %v) should be %s, got %s", tt.lat, tt.lng, tt.token, token)
+		}
+	}
+}
+
+// TestCellID_Hierarchy tests parents, ranges and tokens
+// Expected: Should contain the descendants in the range of a cell and round trip tokens
+func TestCellID_Hierarchy(t *testing.T) {
+	leaf := CellIDFromLatLng(-33.8688, 151.2093)
+	for level := 0; level < MaxCellLevel; level++ {
+		parent := leaf.Parent(level)
+		if parent.Level() != level || !parent.IsValid() {
+			t.Fatalf("Parent should be a valid cell of level %d, got level %d", level, parent.Level())
+		}
+		if !parent.Contains(leaf) || !parent.Contains(leaf.Parent(level+1)) {
+			t.Errorf("Cell of level %d should contain its descendants", level)
+		}
+		if parent.Contains(parent.RangeMax() + 2) {
+			t.Errorf("Cell of level %d should not contain the leaf after its range", level)
+		}
+		if CellIDFromToken(parent.Token()) != parent {
+			t.Errorf("Token %s should parse back to the cell", parent.Token())
+		}
+	}
+
+	for _, token := range []string{"", "X", "zz", "d", "12345678901234567", "3000000000000002"} {
+		if CellIDFromToken(token).IsValid() {
+			t.Errorf("Token %q should not be a valid cell", token)
+		}
+	}
+}
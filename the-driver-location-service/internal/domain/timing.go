@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+type timingKey struct{}
+
+// Timing adds up the time a request spends in the cache and the database. The
+// adapters add to it from the goroutines they run on, so it is safe for
+// concurrent use.
+type Timing struct {
+	start time.Time
+	cache atomic.Int64 // nanoseconds
+	db    atomic.Int64 // nanoseconds
+}
+
+// TimingBreakdown is the timing of a request in milliseconds
+type TimingBreakdown struct {
+	CacheMs float64 `json:"cache_ms"`
+	DBMs    float64 `json:"db_ms"`
+	TotalMs float64 `json:"total_ms"`
+}
+
+// WithTiming returns a context the cache and database calls made with record
+// their time in, the total is measured from now
+func WithTiming(ctx context.Context) context.Context {
+	return context.WithValue(ctx, timingKey{}, &Timing{start: time.Now()})
+}
+
+// TimingFrom returns the timing the context records, nil when it records none
+func TimingFrom(ctx context.Context) *Timing {
+	timing, _ := ctx.Value(timingKey{}).(*Timing)
+	return timing
+}
+
+// AddCache records a cache call, calls on a nil timing are ignored
+func (t *Timing) AddCache(d time.Duration) {
+	if t != nil {
+		t.cache.Add(int64(d))
+	}
+}
+
+// AddDB records a database call, calls on a nil timing are ignored
+func (t *Timing) AddDB(d time.Duration) {
+	if t != nil {
+		t.db.Add(int64(d))
+	}
+}
+
+// Breakdown returns the time recorded so far and the time since the start.
+// Concurrent calls overlap, so the parts can add up to more than the total.
+func (t *Timing) Breakdown() TimingBreakdown {
+	return TimingBreakdown{
+		CacheMs: milliseconds(time.Duration(t.cache.Load())),
+		DBMs:    milliseconds(time.Duration(t.db.Load())),
+		TotalMs: milliseconds(time.Since(t.start)),
+	}
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,24 @@
+package domain
+
+import (
+	"github.com/go-playground/validator/v10"
+)
+
+// NewValidator returns the validator of the service requests, it knows the
+// coordinates tag of GeoJSON positions next to the built-in tags
+func NewValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterValidation("coordinates", validateCoordinates)
+	return v
+}
+
+// ValidCoordinates reports whether a position is a longitude from -180 to 180
+// and a latitude from -90 to 90, NaN is out of every range
+func ValidCoordinates(longitude, latitude float64) bool {
+	return longitude >= -180 && longitude <= 180 && latitude >= -90 && latitude <= 90
+}
+
+func validateCoordinates(fl validator.FieldLevel) bool {
+	coordinates, ok := fl.Field().Interface().([]float64)
+	return ok && len(coordinates) == 2 && ValidCoordinates(coordinates[0], coordinates[1])
+}
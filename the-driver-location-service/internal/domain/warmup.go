@@ -0,0 +1,29 @@
+package domain
+
+import "time"
+
+type WarmupStatus string
+
+const (
+	WarmupDisabled  WarmupStatus = "disabled"
+	WarmupPending   WarmupStatus = "pending"
+	WarmupRunning   WarmupStatus = "running"
+	WarmupCompleted WarmupStatus = "completed"
+	WarmupFailed    WarmupStatus = "failed"
+)
+
+// WarmupProgress is the state of the startup cache warmup, Since is the oldest
+// location update that is loaded into the cache
+type WarmupProgress struct {
+	Status     WarmupStatus `json:"status"`
+	Loaded     int64        `json:"loaded"`
+	Since      *time.Time   `json:"since,omitempty"`
+	StartedAt  *time.Time   `json:"started_at,omitempty"`
+	FinishedAt *time.Time   `json:"finished_at,omitempty"`
+	Error      string       `json:"error,omitempty"`
+}
+
+// Done is true once the warmup can no longer make progress
+func (p WarmupProgress) Done() bool {
+	return p.Status == WarmupCompleted || p.Status == WarmupFailed || p.Status == WarmupDisabled
+}
@@ -0,0 +1,229 @@
+package importer
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"the-driver-location-service/internal/domain"
+	"the-driver-location-service/internal/ports/secondary"
+)
+
+const (
+	DefaultFilePath  = "Coordinates.csv"
+	DefaultBatchSize = 100
+	DefaultWorkers   = 4
+)
+
+type Options struct {
+	FilePath  string // CSV file with latitude,longitude records and a header line
+	BatchSize int
+	Workers   int
+	Logger    secondary.Logger // skipped records and failed batches are logged, nil discards the entries
+}
+
+type Result struct {
+	RequestedCount int
+	CreatedCount   int // written to the repository or, for a staged import, to the stage
+	ErrorCount     int
+	CommittedCount int // merged into the live drivers by the commit of a staged import
+}
+
+// batchWriter is where the batches of an import go, the repository or a stage
+type batchWriter interface {
+	BatchCreate(ctx context.Context, drivers []*domain.Driver) error
+}
+
+// Importer loads driver locations from a CSV file straight into the repository,
+// the server runs it in process on startup and cmd/importer runs it on demand
+type Importer struct {
+	writer  batchWriter
+	stage   secondary.DriverImportStage // nil when batches go straight to the repository
+	options Options
+}
+
+func New(repo secondary.DriverRepository, options Options) *Importer {
+	return newImporter(repo, nil, options)
+}
+
+// NewStaged returns an importer writing to stage. Run commits the stage once the
+// whole file was imported and aborts it when the import was cancelled or a batch
+// failed, so a failed import leaves the live drivers as they were.
+func NewStaged(stage secondary.DriverImportStage, options Options) *Importer {
+	return newImporter(stage, stage, options)
+}
+
+func newImporter(writer batchWriter, stage secondary.DriverImportStage, options Options) *Importer {
+	if options.FilePath == "" {
+		options.FilePath = DefaultFilePath
+	}
+	if options.BatchSize <= 0 {
+		options.BatchSize = DefaultBatchSize
+	}
+	if options.Workers <= 0 {
+		options.Workers = DefaultWorkers
+	}
+	if options.Logger == nil {
+		options.Logger = secondary.NopLogger{}
+	}
+	return &Importer{writer: writer, stage: stage, options: options}
+}
+
+// Run reads the file and inserts its drivers in batches, batches are written by a
+// worker pool so a slow insert does not hold the reader. It stops reading when ctx
+// is cancelled, batches already handed to the workers are still written.
+func (i *Importer) Run(ctx context.Context) (*Result, error) {
+	file, err := os.Open(i.options.FilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open CSV file: %w", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+
+	// Skip header
+	if _, err := reader.Read(); err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	batchCh := make(chan []*domain.Driver, i.options.Workers*2)
+	var wg sync.WaitGroup
+	var totalRequested, totalCreated, totalErrors int64
+
+	for w := 0; w < i.options.Workers; w++ {
+		wg.Add(1)
+		go func(workerID int) {
+			defer wg.Done()
+
+			for batch := range batchCh {
+				result := i.processBatch(ctx, batch, workerID)
+				atomic.AddInt64(&totalRequested, int64(result.RequestedCount))
+				atomic.AddInt64(&totalCreated, int64(result.CreatedCount))
+				atomic.AddInt64(&totalErrors, int64(result.ErrorCount))
+			}
+		}(w)
+	}
+
+	var batch []*domain.Driver
+	recordCount := 0
+	var readErr error
+
+	for {
+		if err := ctx.Err(); err != nil {
+			readErr = err
+			break
+		}
+
+		record, err := reader.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			i.options.Logger.Warn(ctx, "failed to read CSV record", "line", recordCount+2, "error", err) // +2 for header and 1-indexed
+			continue
+		}
+
+		recordCount++
+		driver, err := parseDriverLocation(record)
+		if err != nil {
+			i.options.Logger.Warn(ctx, "failed to parse driver location", "record", recordCount, "fields", record, "error", err)
+			continue
+		}
+
+		batch = append(batch, driver)
+		if len(batch) >= i.options.BatchSize {
+			batchCh <- batch
+			batch = nil
+		}
+	}
+
+	if len(batch) > 0 && readErr == nil {
+		batchCh <- batch
+	}
+
+	close(batchCh)
+	wg.Wait()
+
+	result := &Result{
+		RequestedCount: int(totalRequested),
+		CreatedCount:   int(totalCreated),
+		ErrorCount:     int(totalErrors),
+	}
+
+	i.options.Logger.Info(ctx, "CSV processing completed", "records", recordCount, "created", result.CreatedCount, "failed", result.ErrorCount)
+	if i.stage != nil {
+		return result, i.settleStage(ctx, result, readErr)
+	}
+	return result, readErr
+}
+
+// settleStage commits the stage of a complete import and aborts it otherwise,
+// also when ctx was cancelled so no half import is left staged
+func (i *Importer) settleStage(ctx context.Context, result *Result, readErr error) error {
+	ctx = context.WithoutCancel(ctx)
+
+	var err error
+	switch {
+	case readErr != nil:
+		err = fmt.Errorf("staged import aborted: %w", readErr)
+	case result.ErrorCount > 0:
+		err = fmt.Errorf("staged import aborted: %d drivers failed", result.ErrorCount)
+	default:
+		committed, commitErr := i.stage.Commit(ctx)
+		if commitErr == nil {
+			result.CommittedCount = int(committed)
+			i.options.Logger.Info(ctx, "staged import committed", "merged", committed)
+			return nil
+		}
+		err = fmt.Errorf("failed to commit staged import: %w", commitErr)
+	}
+
+	if abortErr := i.stage.Abort(ctx); abortErr != nil {
+		i.options.Logger.Warn(ctx, "failed to abort staged import", "error", abortErr)
+	}
+	return err
+}
+
+func (i *Importer) processBatch(ctx context.Context, batch []*domain.Driver, workerID int) Result {
+	result := Result{RequestedCount: len(batch)}
+
+	if err := i.writer.BatchCreate(ctx, batch); err != nil {
+		i.options.Logger.Warn(ctx, "failed to insert batch", "worker", workerID, "drivers", len(batch), "error", err)
+		result.ErrorCount = len(batch)
+		return result
+	}
+
+	result.CreatedCount = len(batch)
+	i.options.Logger.Debug(ctx, "batch inserted", "worker", workerID, "drivers", len(batch))
+	return result
+}
+
+func parseDriverLocation(record []string) (*domain.Driver, error) {
+	if len(record) < 2 {
+		return nil, fmt.Errorf("invalid record format: expected at least 2 fields (latitude,longitude), got %d", len(record))
+	}
+
+	latitudeStr := record[0]
+	longitudeStr := record[1]
+
+	latitude, err := strconv.ParseFloat(latitudeStr, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid latitude '%s': %w", latitudeStr, err)
+	}
+	longitude, err := strconv.ParseFloat(longitudeStr, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid longitude '%s': %w", longitudeStr, err)
+	}
+	// ParseFloat accepts NaN and Inf, mongo would reject the batch of the driver
+	if !domain.ValidCoordinates(longitude, latitude) {
+		return nil, fmt.Errorf("invalid position '%s,%s': latitude must be within -90 and 90, longitude within -180 and 180", latitudeStr, longitudeStr)
+	}
+
+	return &domain.Driver{Location: domain.NewPoint(longitude, latitude)}, nil
+}
@@ -0,0 +1,288 @@
+package importer
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"the-driver-location-service/internal/domain"
+)
+
+type memoryRepo struct {
+	mu      sync.Mutex
+	drivers []*domain.Driver
+	err     error
+}
+
+func (r *memoryRepo) Create(_ context.Context, driver *domain.Driver) error { return nil }
+func (r *memoryRepo) BatchCreate(_ context.Context, drivers []*domain.Driver) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.drivers = append(r.drivers, drivers...)
+	return nil
+}
+func (r *memoryRepo) BatchCreateUnordered(_ context.Context, drivers []*domain.Driver) ([]domain.BatchCreateFailure, error) {
+	return nil, nil
+}
+func (r *memoryRepo) SearchNearby(_ context.Context, location domain.Point, minRadiusMeters, radiusMeters float64, limit int, filter domain.DriverFilter) ([]*domain.DriverWithDistance, error) {
+	return nil, nil
+}
+func (r *memoryRepo) SearchGeoNear(_ context.Context, location domain.Point, minRadiusMeters, radiusMeters float64, limit int, filter domain.DriverFilter) ([]*domain.DriverWithDistance, error) {
+	return nil, nil
+}
+func (r *memoryRepo) SearchWithin(_ context.Context, area domain.Area, limit int) ([]*domain.Driver, error) {
+	return nil, nil
+}
+func (r *memoryRepo) SearchInBox(_ context.Context, req domain.BoxSearchRequest, limit int) ([]*domain.Driver, error) {
+	return nil, nil
+}
+func (r *memoryRepo) ClusterInBox(_ context.Context, req domain.BoxSearchRequest, limit int) ([]domain.DriverCluster, error) {
+	return nil, nil
+}
+func (r *memoryRepo) SearchInCell(_ context.Context, cell domain.CellID, limit int) ([]*domain.Driver, error) {
+	return nil, nil
+}
+func (r *memoryRepo) CountInCell(_ context.Context, cell domain.CellID, level, limit int) ([]domain.CellCount, error) {
+	return nil, nil
+}
+func (r *memoryRepo) GetByID(_ context.Context, id string) (*domain.Driver, error) { return nil, nil }
+func (r *memoryRepo) List(_ context.Context, filter domain.DriverListFilter, afterID string, limit int) ([]*domain.Driver, error) {
+	return nil, nil
+}
+func (r *memoryRepo) Update(_ context.Context, driver *domain.Driver) error      { return nil }
+func (r *memoryRepo) Delete(_ context.Context, id string) error                  { return nil }
+func (r *memoryRepo) Heartbeat(_ context.Context, id string, at time.Time) error { return nil }
+
+func writeCSV(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "drivers.csv")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write CSV: %v", err)
+	}
+	return path
+}
+
+// TestParseDriverLocation_Success tests parsing a valid record.
+// Expected: Should parse the record correctly and return no error.
+func TestParseDriverLocation_Success(t *testing.T) {
+	record := []string{"41.12345", "29.98765"}
+	driver, err := parseDriverLocation(record)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v (should not error for valid record)", err)
+	}
+
+	expected := &domain.Driver{
+		Location: domain.Point{
+			Type:        "Point",
+			Coordinates: []float64{29.98765, 41.12345},
+		},
+	}
+
+	if !reflect.DeepEqual(driver, expected) {
+		t.Errorf("Expected: %+v, Got: %+v (should parse valid record correctly)", expected, driver)
+	}
+}
+
+// TestParseDriverLocation_InvalidLength tests parsing a record with missing fields.
+// Expected: Should return error when record has missing fields.
+func TestParseDriverLocation_InvalidLength(t *testing.T) {
+	record := []string{"41.12345"} // missing field
+	_, err := parseDriverLocation(record)
+	if err == nil {
+		t.Error("Expected error: should return error when record has missing fields, but got nil")
+	}
+}
+
+// TestParseDriverLocation_InvalidLatitude tests parsing a record with invalid latitude.
+// Expected: Should return error when latitude is not a float.
+func TestParseDriverLocation_InvalidLatitude(t *testing.T) {
+	record := []string{"not-a-float", "29.98765"}
+	_, err := parseDriverLocation(record)
+	if err == nil {
+		t.Error("Expected error: should return error when latitude is not a float, but got nil")
+	}
+}
+
+// TestParseDriverLocation_InvalidLongitude tests parsing a record with invalid longitude.
+// Expected: Should return error when longitude is not a float.
+func TestParseDriverLocation_InvalidLongitude(t *testing.T) {
+	record := []string{"41.12345", "not-a-float"}
+	_, err := parseDriverLocation(record)
+	if err == nil {
+		t.Error("Expected error: should return error when longitude is not a float, but got nil")
+	}
+}
+
+// TestParseDriverLocation_OutOfRange tests parsing records whose coordinates are not positions.
+// Expected: Should return error for NaN, infinite and out of range latitudes and longitudes.
+func TestParseDriverLocation_OutOfRange(t *testing.T) {
+	for _, record := range [][]string{
+		{"NaN", "29.98765"},
+		{"41.12345", "+Inf"},
+		{"90.5", "29.98765"},
+		{"41.12345", "-180.1"},
+	} {
+		if _, err := parseDriverLocation(record); err == nil {
+			t.Errorf("Expected error: should return error for record %v, but got nil", record)
+		}
+	}
+}
+
+// FuzzParseDriverLocation feeds arbitrary CSV input to the record parser of the importer.
+// Expected: Should never panic and only return drivers at a valid position.
+func FuzzParseDriverLocation(f *testing.F) {
+	for _, seed := range []string{
+		"41.12345,29.98765\n",
+		"-90,-180\n90,180\n",
+		"41.12345\n",
+		"NaN,29.98765\n",
+		"41.12345,Inf\n",
+		"0x1p-2,1e3\n",
+		"\"41.1\",\"29.9\",extra\n",
+		"\"unterminated,29\n",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, content string) {
+		reader := csv.NewReader(strings.NewReader(content))
+		for {
+			record, err := reader.Read()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if err != nil {
+				continue
+			}
+
+			driver, err := parseDriverLocation(record)
+			if err != nil {
+				continue
+			}
+			if !domain.ValidCoordinates(driver.Location.Longitude(), driver.Location.Latitude()) {
+				t.Fatalf("record %q parsed to invalid position %v", record, driver.Location.Coordinates)
+			}
+		}
+	})
+}
+
+// TestImporter_Run tests importing a CSV file into the repository.
+// Expected: Should insert every valid record in batches and skip invalid records.
+func TestImporter_Run(t *testing.T) {
+	path := writeCSV(t, "latitude,longitude\n41.1,29.1\n41.2,29.2\nbad,29.3\n41.4,29.4\n41.5,29.5\n")
+	repo := &memoryRepo{}
+
+	result, err := New(repo, Options{FilePath: path, BatchSize: 2, Workers: 2}).Run(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if result.RequestedCount != 4 || result.CreatedCount != 4 || result.ErrorCount != 0 {
+		t.Errorf("Expected 4 requested and created without errors, got %+v", result)
+	}
+	if len(repo.drivers) != 4 {
+		t.Errorf("Expected 4 drivers in the repository, got %d", len(repo.drivers))
+	}
+}
+
+// TestImporter_Run_RepoError tests an import whose inserts fail.
+// Expected: Should count every record of the failed batches as an error.
+func TestImporter_Run_RepoError(t *testing.T) {
+	path := writeCSV(t, "latitude,longitude\n41.1,29.1\n41.2,29.2\n41.3,29.3\n")
+	repo := &memoryRepo{err: errors.New("mongo unavailable")}
+
+	result, err := New(repo, Options{FilePath: path, BatchSize: 2}).Run(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if result.RequestedCount != 3 || result.CreatedCount != 0 || result.ErrorCount != 3 {
+		t.Errorf("Expected 3 requested and 3 errors, got %+v", result)
+	}
+}
+
+// TestImporter_Run_MissingFile tests importing a file that does not exist.
+// Expected: Should return an error without touching the repository.
+func TestImporter_Run_MissingFile(t *testing.T) {
+	_, err := New(&memoryRepo{}, Options{FilePath: filepath.Join(t.TempDir(), "missing.csv")}).Run(context.Background())
+	if err == nil {
+		t.Error("Expected error: should return error when the file does not exist, but got nil")
+	}
+}
+
+// memoryStage keeps the staged drivers until the import commits them to live
+type memoryStage struct {
+	memoryRepo
+	live      *memoryRepo
+	committed bool
+	aborted   bool
+}
+
+func (s *memoryStage) Commit(_ context.Context) (int64, error) {
+	s.live.drivers = append(s.live.drivers, s.drivers...)
+	s.committed = true
+	return int64(len(s.drivers)), nil
+}
+
+func (s *memoryStage) Abort(_ context.Context) error {
+	s.drivers = nil
+	s.aborted = true
+	return nil
+}
+
+// TestImporter_Run_Staged tests a staged import of a CSV file.
+// Expected: Should write the drivers to the stage and commit them to the live drivers.
+func TestImporter_Run_Staged(t *testing.T) {
+	path := writeCSV(t, "latitude,longitude\n41.1,29.1\n41.2,29.2\n41.3,29.3\n")
+	live := &memoryRepo{}
+	stage := &memoryStage{live: live}
+
+	result, err := NewStaged(stage, Options{FilePath: path, BatchSize: 2}).Run(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if result.CreatedCount != 3 || result.CommittedCount != 3 {
+		t.Errorf("Expected 3 drivers staged and committed, got %+v", result)
+	}
+	if !stage.committed || len(live.drivers) != 3 {
+		t.Errorf("Expected the stage committed with 3 live drivers, got %d", len(live.drivers))
+	}
+}
+
+// TestImporter_Run_StagedAborted tests staged imports with a failed batch and a cancelled import.
+// Expected: Should abort the stage and leave the live drivers untouched.
+func TestImporter_Run_StagedAborted(t *testing.T) {
+	path := writeCSV(t, "latitude,longitude\n41.1,29.1\n41.2,29.2\n41.3,29.3\n")
+
+	live := &memoryRepo{}
+	stage := &memoryStage{memoryRepo: memoryRepo{err: errors.New("mongo unavailable")}, live: live}
+	_, err := NewStaged(stage, Options{FilePath: path, BatchSize: 2}).Run(context.Background())
+	if err == nil {
+		t.Error("Expected error: should fail the import when a batch failed, but got nil")
+	}
+	if !stage.aborted || stage.committed || len(live.drivers) != 0 {
+		t.Errorf("Expected the stage aborted without live drivers, got %d", len(live.drivers))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	stage = &memoryStage{live: live}
+	_, err = NewStaged(stage, Options{FilePath: path}).Run(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the cancellation, got %v", err)
+	}
+	if !stage.aborted || len(live.drivers) != 0 {
+		t.Errorf("Expected the cancelled import aborted without live drivers, got %d", len(live.drivers))
+	}
+}
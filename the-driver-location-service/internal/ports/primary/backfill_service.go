@@ -0,0 +1,9 @@
+package primary
+
+import "the-driver-location-service/internal/domain"
+
+type BackfillService interface {
+	Jobs() []string
+	Start(job string) error
+	Progress(job string) (domain.BackfillProgress, error)
+}
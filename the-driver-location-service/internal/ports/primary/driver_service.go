@@ -1,13 +1,23 @@
 package primary
 
-import "the-driver-location-service/internal/domain"
+import (
+	"context"
+
+	"the-driver-location-service/internal/domain"
+)
 
 type DriverService interface {
-	CreateDriver(req domain.CreateDriverRequest) (*domain.Driver, error)
-	BatchCreateDrivers(req domain.BatchCreateRequest) ([]*domain.Driver, error)
-	SearchNearbyDrivers(req domain.SearchRequest) ([]*domain.DriverWithDistance, error)
-	GetDriver(id string) (*domain.Driver, error)
-	UpdateDriver(driver *domain.Driver) error
-	UpdateDriverLocation(id string, location domain.Point) error
-	DeleteDriver(id string) error
+	CreateDriver(ctx context.Context, req domain.CreateDriverRequest) (*domain.Driver, error)
+	BatchCreateDrivers(ctx context.Context, req domain.BatchCreateRequest) ([]*domain.Driver, error)
+	SearchNearbyDrivers(ctx context.Context, req domain.SearchRequest) ([]*domain.DriverWithDistance, error)
+	SearchDriversWithin(ctx context.Context, req domain.AreaSearchRequest) ([]*domain.Driver, error)
+	SearchDriversInBox(ctx context.Context, req domain.BoxSearchRequest) (*domain.BoxSearchResult, error)
+	SearchDriversInCell(ctx context.Context, req domain.CellSearchRequest) (*domain.CellSearchResult, error)
+	GetDriver(ctx context.Context, id string) (*domain.Driver, error)
+	ListDrivers(ctx context.Context, req domain.ListDriversRequest) (*domain.DriverPage, error)
+	UpdateDriver(ctx context.Context, driver *domain.Driver) error
+	UpdateDriverLocation(ctx context.Context, id string, update domain.LocationUpdate) error
+	UpdateDriverStatus(ctx context.Context, id string, status string) error
+	DeleteDriver(ctx context.Context, id string) error
+	Heartbeat(ctx context.Context, id string) (*domain.Heartbeat, error)
 }
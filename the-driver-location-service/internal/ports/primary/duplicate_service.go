@@ -0,0 +1,12 @@
+package primary
+
+import (
+	"context"
+
+	"the-driver-location-service/internal/domain"
+)
+
+// DuplicateService finds drivers cloned by repeated imports and optionally removes the clones
+type DuplicateService interface {
+	FindDuplicates(ctx context.Context, req domain.DuplicateRequest) (*domain.DuplicateReport, error)
+}
@@ -0,0 +1,9 @@
+package primary
+
+import "the-driver-location-service/internal/domain"
+
+type FeatureFlagService interface {
+	IsEnabled(name, key string) bool
+	Flags() []domain.FeatureFlag
+	Environment() string
+}
@@ -0,0 +1,12 @@
+package primary
+
+import (
+	"context"
+
+	"the-driver-location-service/internal/domain"
+)
+
+// OutcomeService records the match outcomes the matching service reports for a driver
+type OutcomeService interface {
+	RecordOutcome(ctx context.Context, id string, req domain.OutcomeRequest) (*domain.DriverOutcomes, error)
+}
@@ -0,0 +1,8 @@
+package primary
+
+import "the-driver-location-service/internal/domain"
+
+type ReadOnlyService interface {
+	ReadOnly() domain.ReadOnlyMode
+	SetReadOnly(enabled bool, reason string) domain.ReadOnlyMode
+}
@@ -0,0 +1,12 @@
+package primary
+
+import (
+	"context"
+
+	"the-driver-location-service/internal/domain"
+)
+
+// ReconcileService compares a fleet partner's view of its drivers with the stored drivers
+type ReconcileService interface {
+	Reconcile(ctx context.Context, req domain.ReconcileRequest) (*domain.ReconcileReport, error)
+}
@@ -0,0 +1,13 @@
+package primary
+
+import (
+	"context"
+
+	"the-driver-location-service/internal/domain"
+)
+
+// ReplicationService applies the driver changes the primary region replicates
+// to this instance while it runs as the warm standby
+type ReplicationService interface {
+	Apply(ctx context.Context, batch domain.ReplicationBatch) (*domain.ReplicationResult, error)
+}
@@ -0,0 +1,7 @@
+package primary
+
+import "the-driver-location-service/internal/domain"
+
+type WarmupService interface {
+	Progress() domain.WarmupProgress
+}
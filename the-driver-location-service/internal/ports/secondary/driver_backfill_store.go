@@ -0,0 +1,14 @@
+package secondary
+
+import (
+	"context"
+
+	"the-driver-location-service/internal/domain"
+)
+
+// DriverBackfillStore iterates the stored drivers in ID order and applies partial
+// updates, ApplyUpdates returns how many drivers were updated
+type DriverBackfillStore interface {
+	ScanAfter(ctx context.Context, afterID string, limit int) ([]*domain.Driver, error)
+	ApplyUpdates(ctx context.Context, updates []domain.DriverFieldUpdate) (int64, error)
+}
@@ -8,8 +8,12 @@ import (
 )
 
 type DriverCache interface {
+	// Get returns nil for a driver that is not cached, and an error wrapping
+	// domain.ErrDriverNotFound for one cached as missing by SetNotFound
 	Get(ctx context.Context, driverID string) (*domain.Driver, error)
 	Set(ctx context.Context, driverID string, driver *domain.Driver, ttl time.Duration) error
+	// SetNotFound caches that no driver has the ID, Set and Delete clear it
+	SetNotFound(ctx context.Context, driverID string, ttl time.Duration) error
 	Delete(ctx context.Context, driverID string) error
 	IsHealthy(ctx context.Context) bool
 }
@@ -0,0 +1,13 @@
+package secondary
+
+import (
+	"context"
+
+	"the-driver-location-service/internal/domain"
+)
+
+// DriverDuplicateStore groups the drivers that share identical coordinates,
+// returning at most limit groups of two or more drivers
+type DriverDuplicateStore interface {
+	ColocatedDrivers(ctx context.Context, limit int) ([][]*domain.Driver, error)
+}
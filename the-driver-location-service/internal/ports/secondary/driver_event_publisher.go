@@ -0,0 +1,14 @@
+package secondary
+
+import (
+	"context"
+
+	"the-driver-location-service/internal/domain"
+)
+
+// DriverEventPublisher emits driver changes to downstream consumers, events of
+// the same driver are delivered in order
+type DriverEventPublisher interface {
+	Publish(ctx context.Context, events ...domain.DriverEvent) error
+	Close() error
+}
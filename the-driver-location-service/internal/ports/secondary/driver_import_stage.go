@@ -0,0 +1,22 @@
+package secondary
+
+import (
+	"context"
+
+	"the-driver-location-service/internal/domain"
+)
+
+// DriverImportStager opens import stages, drivers written to a stage stay out of
+// every search until the stage is committed
+type DriverImportStager interface {
+	StageImport(ctx context.Context) (DriverImportStage, error)
+}
+
+// DriverImportStage holds the drivers of an import apart from the live drivers.
+// Commit merges them into the live drivers and returns how many it merged, Abort
+// discards them.
+type DriverImportStage interface {
+	BatchCreate(ctx context.Context, drivers []*domain.Driver) error
+	Commit(ctx context.Context) (int64, error)
+	Abort(ctx context.Context) error
+}
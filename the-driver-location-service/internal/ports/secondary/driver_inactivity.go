@@ -0,0 +1,24 @@
+package secondary
+
+import (
+	"context"
+	"time"
+
+	"the-driver-location-service/internal/domain"
+)
+
+// DriverInactivityStore finds the drivers that are not offline and were last
+// updated before the given time. MarkOffline only changes the status when the
+// driver was not updated since lastSeenAt and reports whether it did, so a
+// location update racing the check keeps the driver online.
+type DriverInactivityStore interface {
+	StaleBefore(ctx context.Context, before time.Time, limit int) ([]*domain.Driver, error)
+	MarkOffline(ctx context.Context, id string, lastSeenAt time.Time) (bool, error)
+}
+
+// InactivityNotifier tells fleet partners about drivers that were taken offline,
+// Notify only queues the notifications and Close delivers the queued ones
+type InactivityNotifier interface {
+	Notify(ctx context.Context, inactivities ...domain.DriverInactivity) error
+	Close() error
+}
@@ -0,0 +1,13 @@
+package secondary
+
+import (
+	"context"
+
+	"the-driver-location-service/internal/domain"
+)
+
+// DriverOutcomeStore counts the match outcomes of a driver, RecordOutcome
+// increments the count of the outcome and returns all counts after it
+type DriverOutcomeStore interface {
+	RecordOutcome(ctx context.Context, id, outcome string) (*domain.DriverOutcomes, error)
+}
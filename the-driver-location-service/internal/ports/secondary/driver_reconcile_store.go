@@ -0,0 +1,14 @@
+package secondary
+
+import (
+	"context"
+
+	"the-driver-location-service/internal/domain"
+)
+
+// DriverReconcileStore loads the drivers a partner asked about in one query and lists
+// the IDs starting with the partner's prefix, at most limit of them
+type DriverReconcileStore interface {
+	GetByIDs(ctx context.Context, ids []string) ([]*domain.Driver, error)
+	IDsWithPrefix(ctx context.Context, prefix string, limit int) ([]string, error)
+}
@@ -0,0 +1,26 @@
+package secondary
+
+import (
+	"context"
+	"time"
+
+	"the-driver-location-service/internal/domain"
+)
+
+// DriverReplicaStore stores the changes replicated from the primary region. The
+// writes keep the updated_at of the primary and are skipped when the stored
+// driver is newer, they report whether they landed.
+type DriverReplicaStore interface {
+	ScanAfter(ctx context.Context, afterID string, limit int) ([]*domain.Driver, error)
+	ApplyReplicatedUpsert(ctx context.Context, driver *domain.Driver) (bool, error)
+	ApplyReplicatedDelete(ctx context.Context, id string, deletedAt time.Time) (bool, error)
+}
+
+// DriverReplicator sends driver changes to the standby region. Replicate only
+// queues the changes and Close sends the queued ones, Send posts a batch and
+// waits for the standby to apply it.
+type DriverReplicator interface {
+	Replicate(ctx context.Context, changes ...domain.DriverChange) error
+	Send(ctx context.Context, batch domain.ReplicationBatch) (*domain.ReplicationResult, error)
+	Close() error
+}
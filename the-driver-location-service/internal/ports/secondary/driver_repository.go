@@ -1,12 +1,26 @@
 package secondary
 
-import "the-driver-location-service/internal/domain"
+import (
+	"context"
+	"time"
+
+	"the-driver-location-service/internal/domain"
+)
 
 type DriverRepository interface {
-	Create(driver *domain.Driver) error
-	BatchCreate(drivers []*domain.Driver) error
-	SearchNearby(location domain.Point, radiusMeters float64, limit int) ([]*domain.DriverWithDistance, error)
-	GetByID(id string) (*domain.Driver, error)
-	Update(driver *domain.Driver) error
-	Delete(id string) error
+	Create(ctx context.Context, driver *domain.Driver) error
+	BatchCreate(ctx context.Context, drivers []*domain.Driver) error
+	BatchCreateUnordered(ctx context.Context, drivers []*domain.Driver) ([]domain.BatchCreateFailure, error)
+	SearchNearby(ctx context.Context, location domain.Point, minRadiusMeters, radiusMeters float64, limit int, filter domain.DriverFilter) ([]*domain.DriverWithDistance, error)
+	SearchGeoNear(ctx context.Context, location domain.Point, minRadiusMeters, radiusMeters float64, limit int, filter domain.DriverFilter) ([]*domain.DriverWithDistance, error)
+	SearchWithin(ctx context.Context, area domain.Area, limit int) ([]*domain.Driver, error)
+	SearchInBox(ctx context.Context, req domain.BoxSearchRequest, limit int) ([]*domain.Driver, error)
+	ClusterInBox(ctx context.Context, req domain.BoxSearchRequest, limit int) ([]domain.DriverCluster, error)
+	SearchInCell(ctx context.Context, cell domain.CellID, limit int) ([]*domain.Driver, error)
+	CountInCell(ctx context.Context, cell domain.CellID, level, limit int) ([]domain.CellCount, error)
+	GetByID(ctx context.Context, id string) (*domain.Driver, error)
+	List(ctx context.Context, filter domain.DriverListFilter, afterID string, limit int) ([]*domain.Driver, error)
+	Update(ctx context.Context, driver *domain.Driver) error
+	Delete(ctx context.Context, id string) error
+	Heartbeat(ctx context.Context, id string, at time.Time) error
 }
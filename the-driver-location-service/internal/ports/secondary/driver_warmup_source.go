@@ -0,0 +1,13 @@
+package secondary
+
+import (
+	"context"
+	"time"
+
+	"the-driver-location-service/internal/domain"
+)
+
+// DriverWarmupSource pages through the drivers updated since the given time in ID order
+type DriverWarmupSource interface {
+	UpdatedSince(ctx context.Context, since time.Time, afterID string, limit int) ([]*domain.Driver, error)
+}
@@ -0,0 +1,11 @@
+package secondary
+
+import (
+	"context"
+
+	"the-driver-location-service/internal/domain"
+)
+
+type FeatureFlagProvider interface {
+	LoadFlags(ctx context.Context) ([]domain.FeatureFlag, error)
+}
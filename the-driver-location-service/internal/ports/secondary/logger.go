@@ -0,0 +1,22 @@
+package secondary
+
+import "context"
+
+// Logger writes structured log entries. Each entry is a message and key value
+// pairs, the fields the context carries, like the request and driver ID of the
+// request being handled, are written with it.
+type Logger interface {
+	Debug(ctx context.Context, msg string, keysAndValues ...any)
+	Info(ctx context.Context, msg string, keysAndValues ...any)
+	Warn(ctx context.Context, msg string, keysAndValues ...any)
+	Error(ctx context.Context, msg string, keysAndValues ...any)
+}
+
+// NopLogger discards every entry, it is the logger of components that were not
+// given one
+type NopLogger struct{}
+
+func (NopLogger) Debug(ctx context.Context, msg string, keysAndValues ...any) {}
+func (NopLogger) Info(ctx context.Context, msg string, keysAndValues ...any)  {}
+func (NopLogger) Warn(ctx context.Context, msg string, keysAndValues ...any)  {}
+func (NopLogger) Error(ctx context.Context, msg string, keysAndValues ...any) {}
@@ -0,0 +1,13 @@
+package secondary
+
+import (
+	"context"
+
+	"the-driver-location-service/internal/domain"
+)
+
+// MapMatcher snaps a GPS trace to the road network, the result has one point per
+// trace point and points that could not be matched are returned unchanged
+type MapMatcher interface {
+	Match(ctx context.Context, trace []domain.Point) ([]domain.Point, error)
+}
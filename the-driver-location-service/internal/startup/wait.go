@@ -0,0 +1,77 @@
+package startup
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"the-driver-location-service/internal/ports/secondary"
+)
+
+const (
+	DefaultMaxAttempts    = 10
+	DefaultInitialBackoff = time.Second
+	DefaultMaxBackoff     = 30 * time.Second
+)
+
+// Options controls waiting for a dependency, failed attempts are retried with a
+// backoff that doubles from InitialBackoff up to MaxBackoff
+type Options struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Logger         secondary.Logger // failed attempts are logged, nil discards the entries
+}
+
+// Wait calls connect until it succeeds, MaxAttempts attempts failed or ctx is
+// cancelled. Dependencies started next to the service (docker compose, a pod)
+// often come up later than it, failing on the first attempt would only get the
+// service restarted by the orchestrator.
+func Wait[T any](ctx context.Context, name string, options Options, connect func() (T, error)) (T, error) {
+	if options.MaxAttempts <= 0 {
+		options.MaxAttempts = DefaultMaxAttempts
+	}
+	return retry(ctx, name, options, connect)
+}
+
+// Reconnect calls connect until it succeeds or ctx is cancelled, with the backoff
+// of Wait and no limit on the attempts. It brings back the optional dependencies
+// the service started without, it runs in the background for as long as they
+// stay down.
+func Reconnect[T any](ctx context.Context, name string, options Options, connect func() (T, error)) (T, error) {
+	options.MaxAttempts = 0
+	return retry(ctx, name, options, connect)
+}
+
+// retry is Wait, without a limit on the attempts when options.MaxAttempts is 0
+func retry[T any](ctx context.Context, name string, options Options, connect func() (T, error)) (T, error) {
+	if options.InitialBackoff <= 0 {
+		options.InitialBackoff = DefaultInitialBackoff
+	}
+	if options.MaxBackoff <= 0 {
+		options.MaxBackoff = DefaultMaxBackoff
+	}
+	options.MaxBackoff = max(options.MaxBackoff, options.InitialBackoff)
+	if options.Logger == nil {
+		options.Logger = secondary.NopLogger{}
+	}
+
+	backoff := options.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		value, err := connect()
+		if err == nil {
+			return value, nil
+		}
+		if options.MaxAttempts > 0 && attempt >= options.MaxAttempts {
+			return value, fmt.Errorf("%s not available after %d attempts: %w", name, attempt, err)
+		}
+
+		options.Logger.Warn(ctx, "dependency not available, retrying", "dependency", name, "attempt", attempt, "max_attempts", options.MaxAttempts, "backoff", backoff, "error", err)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return value, fmt.Errorf("stopped waiting for %s: %w", name, ctx.Err())
+		}
+		backoff = min(backoff*2, options.MaxBackoff)
+	}
+}
@@ -0,0 +1,76 @@
+package startup
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errUnavailable = errors.New("connection refused")
+
+// TestWait_EventuallyAvailable tests a dependency that comes up after a few attempts
+// Expected: Should retry until the connection succeeds and return it
+func TestWait_EventuallyAvailable(t *testing.T) {
+	attempts := 0
+	value, err := Wait(context.Background(), "mongo", Options{MaxAttempts: 5, InitialBackoff: time.Millisecond}, func() (string, error) {
+		attempts++
+		if attempts < 3 {
+			return "", errUnavailable
+		}
+		return "connected", nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "connected", value)
+	assert.Equal(t, 3, attempts)
+}
+
+// TestWait_GivesUp tests a dependency that never comes up
+// Expected: Should stop after MaxAttempts and wrap the last error
+func TestWait_GivesUp(t *testing.T) {
+	attempts := 0
+	_, err := Wait(context.Background(), "redis", Options{MaxAttempts: 3, InitialBackoff: time.Millisecond}, func() (int, error) {
+		attempts++
+		return 0, errUnavailable
+	})
+
+	assert.ErrorIs(t, err, errUnavailable)
+	assert.ErrorContains(t, err, "redis not available after 3 attempts")
+	assert.Equal(t, 3, attempts)
+}
+
+// TestWait_Cancelled tests a shutdown while waiting for a dependency
+// Expected: Should stop waiting and return the context error
+func TestWait_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	attempts := 0
+	_, err := Wait(ctx, "mongo", Options{MaxAttempts: 10, InitialBackoff: time.Hour}, func() (int, error) {
+		attempts++
+		cancel()
+		return 0, errUnavailable
+	})
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, attempts)
+}
+
+// TestReconnect_EventuallyAvailable tests reconnecting to a dependency that stays down longer than MaxAttempts
+// Expected: Should keep retrying past MaxAttempts until the connection succeeds
+func TestReconnect_EventuallyAvailable(t *testing.T) {
+	attempts := 0
+	value, err := Reconnect(context.Background(), "redis", Options{MaxAttempts: 2, InitialBackoff: time.Millisecond}, func() (string, error) {
+		attempts++
+		if attempts < 5 {
+			return "", errUnavailable
+		}
+		return "connected", nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "connected", value)
+	assert.Equal(t, 5, attempts)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"io"
+	"log"
+	"os"
+
+	"the-matching-service/config"
+	"the-matching-service/internal/adapter/decisionlog"
+	"the-matching-service/internal/application"
+	"the-matching-service/internal/domain"
+
+	"github.com/joho/godotenv"
+)
+
+// replay re-runs the recorded match decisions against a strategy and prints how
+// the outcomes would differ as JSON, e.g.
+// go run ./cmd/replay -strategy weighted -input decisions.jsonl -changes 20
+// The strategy options are read from the environment as the service reads them.
+func main() {
+	if err := godotenv.Load(); err != nil {
+		log.Println(".env file not found or could not be loaded, environment variables will be read from the shell")
+	}
+	cfg := config.LoadConfig()
+
+	strategy := flag.String("strategy", cfg.Strategy.Name, "strategy to replay the decisions against")
+	input := flag.String("input", "-", "decision log to replay, - reads stdin")
+	changes := flag.Int("changes", 0, "changed decisions to list in the report")
+	flag.Parse()
+
+	var decisions io.Reader = os.Stdin
+	if *input != "-" {
+		file, err := os.Open(*input)
+		if err != nil {
+			log.Fatalf("Failed to open decision log: %v", err)
+		}
+		defer file.Close()
+		decisions = file
+	}
+
+	replay, err := application.NewReplay(*strategy, application.StrategyOptions{
+		AverageSpeedKmh: cfg.Strategy.AverageSpeedKmh,
+		History:         application.NewMatchHistory(cfg.Strategy.HistoryWindow),
+		Weights: application.ScoreWeights{
+			Distance:  cfg.Strategy.DistanceWeight,
+			Idle:      cfg.Strategy.IdleWeight,
+			Freshness: cfg.Strategy.FreshnessWeight,
+		},
+	})
+	if err != nil {
+		log.Fatalf("Failed to configure strategy: %v", err)
+	}
+
+	listed := []application.ReplayChange{}
+	err = decisionlog.ReadDecisions(decisions, func(event domain.DecisionEvent) error {
+		if change, changed := replay.Add(event); changed && len(listed) < *changes {
+			listed = append(listed, change)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Fatalf("Failed to replay decisions: %v", err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(struct {
+		application.ReplayReport
+		Changes []application.ReplayChange `json:"changes"`
+	}{replay.Report(), listed}); err != nil {
+		log.Fatalf("Failed to write report: %v", err)
+	}
+}
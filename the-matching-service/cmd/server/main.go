@@ -1,14 +1,35 @@
 package main
 
 import (
+	"context"
 	"log"
+	"time"
+
 	"the-matching-service/config"
 	_ "the-matching-service/docs"
+	"the-matching-service/internal/adapter/blocklist"
+	"the-matching-service/internal/adapter/decisionlog"
+	"the-matching-service/internal/adapter/discovery"
+	"the-matching-service/internal/adapter/event"
+	"the-matching-service/internal/adapter/geofence"
 	httpadapter "the-matching-service/internal/adapter/http"
+	"the-matching-service/internal/adapter/idempotency"
+	"the-matching-service/internal/adapter/jwks"
+	"the-matching-service/internal/adapter/logging"
+	"the-matching-service/internal/adapter/matchstore"
+	"the-matching-service/internal/adapter/middleware"
+	"the-matching-service/internal/adapter/ratelimit"
+	"the-matching-service/internal/adapter/ridestore"
+	"the-matching-service/internal/adapter/routing"
+	"the-matching-service/internal/adapter/searchcache"
+	"the-matching-service/internal/adapter/webhook"
 	"the-matching-service/internal/application"
+	"the-matching-service/internal/buildinfo"
 	"the-matching-service/internal/domain"
+	"the-matching-service/internal/ports/secondary"
 
 	"github.com/joho/godotenv"
+	"github.com/redis/go-redis/v9"
 )
 
 // @title           Matching Service API
@@ -21,24 +42,302 @@ import (
 // @in header
 // @name Authorization
 // @description Type "Bearer" followed by a space and JWT token.
+
+// @securityDefinitions.apikey AdminAPIKey
+// @in header
+// @name X-API-Key
+// @description API key of the admin endpoints (ADMIN_API_KEY).
 func main() {
-	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found, using system environment variables")
-	}
+	envErr := godotenv.Load()
 
 	cfg := config.LoadConfig()
-	log.Println("cfg", cfg.JWTSecret)
+	// failures before the logger exists go to the standard logger
+	logger, err := logging.NewZapLogger(cfg.Log)
+	if err != nil {
+		log.Fatalf("Failed to create logger: %v", err)
+	}
+	defer logger.Sync()
+	logger.SetPrivacy(httpadapter.NewPrivacy(cfg))
+
+	ctx := context.Background()
+	if envErr != nil {
+		logger.Info(ctx, "no .env file found, using system environment variables")
+	}
 
 	_ = domain.NewCustomValidator()
-	log.Println("Custom validator initialized")
 
-	client := httpadapter.NewDriverLocationClient(cfg.DriverLocationBaseURL, cfg.DriverLocationAPIKey)
-	service := application.NewMatchingService(client)
+	resolver, err := discovery.NewResolverFromConfig(cfg)
+	if err != nil {
+		logger.Fatal(ctx, "failed to configure service discovery", "error", err)
+	}
+	logger.Info(ctx, "resolving driver location service", "discovery", cfg.Discovery.Mode)
+
+	jsonCodec, err := httpadapter.NewJSONCodec(cfg.JSONEngine)
+	if err != nil {
+		logger.Fatal(ctx, "failed to configure JSON engine", "error", err)
+	}
+
+	client := httpadapter.NewDriverLocationClientWithResolver(resolver, cfg.DriverLocationAPIKey)
+	client.SetJSONCodec(jsonCodec)
+	if cfg.DriverLocationSigningSecret != "" {
+		client.SetSigningSecret(cfg.DriverLocationSigningSecret)
+		logger.Info(ctx, "signing requests to driver location service")
+	}
+	client.SetEndUserHashKey(cfg.EndUserHashKey)
+	client.SetMaxConcurrentCalls(httpadapter.OperationSearch, cfg.Bulkhead.SearchMaxConcurrency)
+	transport, err := httpadapter.NewTransport(httpadapter.TransportOptions{
+		ProxyURL: cfg.Outbound.ProxyURL,
+		NoProxy:  cfg.Outbound.NoProxy,
+		CABundle: cfg.Outbound.CABundle,
+	})
+	if err != nil {
+		logger.Fatal(ctx, "failed to configure outbound transport", "error", err)
+	}
+	client.SetTransport(transport)
+	if cfg.Outbound.ProxyURL != "" {
+		logger.Info(ctx, "reaching driver location service through proxy", "proxy", cfg.Outbound.ProxyURL)
+	}
+	// driverEventHandlers are handed every driver event read from Kafka
+	var driverEventHandlers []func(ctx context.Context, event domain.DriverEvent)
+	var driverLocationService secondary.DriverLocationService = client
+	if cfg.SearchCache.TTL > 0 {
+		searchCache := searchcache.New(client, searchcache.Options{
+			TTL:         cfg.SearchCache.TTL,
+			CellDegrees: cfg.SearchCache.CellDegrees,
+			MaxEntries:  cfg.SearchCache.MaxEntries,
+		})
+		driverLocationService = searchCache
+		driverEventHandlers = append(driverEventHandlers, searchCache.HandleDriverEvent)
+		logger.Info(ctx, "caching driver searches", "ttl", cfg.SearchCache.TTL, "cell_degrees", cfg.SearchCache.CellDegrees)
+		if len(cfg.Queue.KafkaBrokers) > 0 {
+			logger.Info(ctx, "updating cached driver searches on driver events from Kafka", "topic", cfg.Queue.Topic)
+		}
+	}
+	service := application.NewMatchingService(driverLocationService)
+	service.SetLogger(logger)
+	strategy, err := application.NewStrategy(cfg.Strategy.Name, application.StrategyOptions{
+		AverageSpeedKmh: cfg.Strategy.AverageSpeedKmh,
+		History:         application.NewMatchHistory(cfg.Strategy.HistoryWindow),
+		Weights: application.ScoreWeights{
+			Distance:  cfg.Strategy.DistanceWeight,
+			Idle:      cfg.Strategy.IdleWeight,
+			Freshness: cfg.Strategy.FreshnessWeight,
+		},
+	})
+	if err != nil {
+		logger.Fatal(ctx, "failed to configure matching strategy", "error", err)
+	}
+	service.SetStrategyRollout(application.StrategyRollout{
+		Control:    strategy,
+		Candidate:  application.NewETAStrategy(cfg.Strategy.AverageSpeedKmh),
+		Percentage: cfg.Strategy.RolloutPercentage,
+	})
+	logger.Info(ctx, "matching riders", "strategy", strategy.Name(), "eta_rollout_percentage", cfg.Strategy.RolloutPercentage)
+	service.SetAverageSpeed(cfg.Strategy.AverageSpeedKmh)
+	service.SetRadiusExpansion(application.RadiusExpansion{
+		Factor:    cfg.RadiusExpansion.Factor,
+		MaxRadius: cfg.RadiusExpansion.MaxRadius,
+	})
+	service.SetSearchLimits(application.SearchLimits{
+		Default: cfg.SearchLimit.Default,
+		Max:     cfg.SearchLimit.Max,
+	})
+	if cfg.Geofence.ServiceAreaFile != "" {
+		serviceArea, err := geofence.Load(cfg.Geofence.ServiceAreaFile)
+		if err != nil {
+			logger.Fatal(ctx, "failed to load service area", "error", err)
+		}
+		service.SetGeofence(serviceArea)
+		logger.Info(ctx, "matching riders inside the service area only", "service_area_file", cfg.Geofence.ServiceAreaFile)
+	}
+	if cfg.Pooling.Enabled {
+		service.SetPooling(application.Pooling{
+			Rides:     ridestore.NewMemoryRideStore(cfg.Pooling.RideTTL),
+			Routes:    routing.StraightLine{},
+			MaxDetour: cfg.Pooling.MaxDetour,
+		})
+		logger.Info(ctx, "pooling rides", "max_detour_meters", cfg.Pooling.MaxDetour)
+	}
+	switch {
+	case cfg.DecisionLog.File != "":
+		decisionLog, err := decisionlog.NewFileDecisionLog(cfg.DecisionLog.File)
+		if err != nil {
+			logger.Fatal(ctx, "failed to configure decision log", "error", err)
+		}
+		defer decisionLog.Close()
+		service.SetDecisionLog(application.DecisionLog{Sink: decisionLog, Privacy: httpadapter.NewPrivacy(cfg)})
+		logger.Info(ctx, "recording match decisions", "file", cfg.DecisionLog.File)
+	case cfg.DecisionLog.Topic != "":
+		if len(cfg.Queue.KafkaBrokers) == 0 {
+			logger.Fatal(ctx, "DECISION_LOG_TOPIC needs KAFKA_BROKERS")
+		}
+		decisionLog := decisionlog.NewKafkaDecisionLog(cfg.Queue.KafkaBrokers, cfg.DecisionLog.Topic, logger)
+		defer decisionLog.Close()
+		service.SetDecisionLog(application.DecisionLog{Sink: decisionLog, Privacy: httpadapter.NewPrivacy(cfg)})
+		logger.Info(ctx, "publishing match decisions", "topic", cfg.DecisionLog.Topic)
+	}
 	handler := httpadapter.NewMatchHandler(service)
+	upstreamProbe := httpadapter.NewUpstreamProbe(client, cfg.Health.ProbeTimeout, cfg.Health.ProbeCacheTTL)
+	// an outage of the driver location service hits every instance alike, it
+	// degrades readiness instead of taking all of them out of the load balancer
+	dependencies := []httpadapter.DependencyCheck{{Name: "driver-location-service", Ping: upstreamProbe.Ping}}
+	if cfg.Health.ProbeUpstream {
+		handler.SetUpstreamProbe(upstreamProbe)
+		logger.Info(ctx, "probing driver location service health", "cache_ttl", cfg.Health.ProbeCacheTTL)
+	}
 	router := httpadapter.NewRouter(handler, cfg)
+	router.SetJSONCodec(jsonCodec)
+	if cfg.JWT.JWKSURL != "" {
+		keySet := jwks.NewKeySet(cfg.JWT.JWKSURL, cfg.JWT.JWKSRefreshInterval)
+		keySet.SetTransport(transport)
+		// the keys are fetched again on the first token, a wrong URL only shows in the logs
+		if err := keySet.Refresh(ctx); err != nil {
+			logger.Warn(ctx, "failed to fetch JWKS", "url", cfg.JWT.JWKSURL, "error", err)
+		}
+		router.SetJWTKeySet(keySet)
+		logger.Info(ctx, "verifying RSA tokens with JWKS", "url", cfg.JWT.JWKSURL, "refresh_interval", cfg.JWT.JWKSRefreshInterval)
+	}
+
+	if cfg.Blocklist.RedisAddress != "" {
+		redisClient := redis.NewClient(&redis.Options{
+			Addr:     cfg.Blocklist.RedisAddress,
+			Password: cfg.Blocklist.RedisPassword,
+			DB:       cfg.Blocklist.RedisDB,
+		})
+		defer redisClient.Close()
+		dependencies = append(dependencies, httpadapter.DependencyCheck{Name: "redis-blocklist", Required: true, Ping: pingRedis(redisClient)})
 
-	log.Printf("Matching Service listening on %s", cfg.Port)
+		redisBlocklist := blocklist.NewRedisBlocklist(redisClient)
+		service.SetBlocklist(redisBlocklist)
+		router.SetupBlocklistRoutes(httpadapter.NewBlocklistHandler(application.NewBlocklistService(redisBlocklist)))
+		logger.Info(ctx, "skipping blocked rider and driver pairs", "redis", cfg.Blocklist.RedisAddress)
+	}
+
+	if cfg.Idempotency.TTL > 0 {
+		var store secondary.IdempotencyStore = idempotency.NewMemoryStore()
+		if cfg.Idempotency.RedisAddress != "" {
+			redisClient := redis.NewClient(&redis.Options{
+				Addr:     cfg.Idempotency.RedisAddress,
+				Password: cfg.Idempotency.RedisPassword,
+				DB:       cfg.Idempotency.RedisDB,
+			})
+			defer redisClient.Close()
+			dependencies = append(dependencies, httpadapter.DependencyCheck{Name: "redis-idempotency", Required: true, Ping: pingRedis(redisClient)})
+			store = idempotency.NewRedisStore(redisClient)
+			logger.Info(ctx, "keeping idempotency keys in Redis", "ttl", cfg.Idempotency.TTL, "redis", cfg.Idempotency.RedisAddress)
+		} else {
+			logger.Info(ctx, "keeping idempotency keys in memory", "ttl", cfg.Idempotency.TTL)
+		}
+		service.SetIdempotency(application.Idempotency{Store: store, TTL: cfg.Idempotency.TTL})
+	}
+
+	if cfg.RateLimit.Rate > 0 {
+		limit := domain.RateLimit{Rate: cfg.RateLimit.Rate, Burst: max(1, cfg.RateLimit.Burst)}
+		var limiter secondary.RateLimiter = ratelimit.NewMemoryLimiter()
+		if cfg.RateLimit.RedisAddress != "" {
+			redisClient := redis.NewClient(&redis.Options{
+				Addr:     cfg.RateLimit.RedisAddress,
+				Password: cfg.RateLimit.RedisPassword,
+				DB:       cfg.RateLimit.RedisDB,
+			})
+			defer redisClient.Close()
+			// requests pass while the limiter is down, so it only degrades readiness
+			dependencies = append(dependencies, httpadapter.DependencyCheck{Name: "redis-rate-limit", Ping: pingRedis(redisClient)})
+			limiter = ratelimit.NewRedisLimiter(redisClient)
+			logger.Info(ctx, "rate limiting users in Redis", "rate", limit.Rate, "burst", limit.Burst, "redis", cfg.RateLimit.RedisAddress)
+		} else {
+			logger.Info(ctx, "rate limiting users in memory", "rate", limit.Rate, "burst", limit.Burst)
+		}
+		router.SetRateLimit(middleware.RateLimitConfig{
+			Limiter: limiter,
+			Limit:   limit,
+			Key:     middleware.UserRateLimitKey,
+			Logger:  logger,
+		})
+	}
+
+	if cfg.MatchStore.RedisAddress != "" {
+		redisClient := redis.NewClient(&redis.Options{
+			Addr:     cfg.MatchStore.RedisAddress,
+			Password: cfg.MatchStore.RedisPassword,
+			DB:       cfg.MatchStore.RedisDB,
+		})
+		defer redisClient.Close()
+		dependencies = append(dependencies, httpadapter.DependencyCheck{Name: "redis-matches", Required: true, Ping: pingRedis(redisClient)})
+
+		matchStore := matchstore.NewRedisMatchStore(redisClient, cfg.MatchStore.Retention)
+		service.SetMatchStore(matchStore)
+		matchQueryService := application.NewMatchQueryService(matchStore)
+		router.SetupMatchQueryRoutes(httpadapter.NewMatchQueryHandler(matchQueryService))
+		logger.Info(ctx, "storing matches in Redis", "retention", cfg.MatchStore.Retention, "redis", cfg.MatchStore.RedisAddress)
+
+		if cfg.MatchStore.ProposalTimeout > 0 {
+			service.SetMatchWorkflow(application.MatchWorkflow{
+				ProposalTimeout: cfg.MatchStore.ProposalTimeout,
+				MaxRematches:    cfg.MatchStore.MaxRematches,
+			})
+			matchQueryService.SetMatchingService(service)
+			service.SetOutcomeReporter(client)
+			router.SetupMatchResponseRoutes(httpadapter.NewMatchResponseHandler(service))
+			logger.Info(ctx, "drivers answer matches, outcomes are reported to the driver location service", "proposal_timeout", cfg.MatchStore.ProposalTimeout, "max_rematches", cfg.MatchStore.MaxRematches)
+		}
+	}
+
+	if cfg.Queue.Wait > 0 {
+		queueCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		queue := application.NewMatchQueue(service, cfg.Queue.Wait)
+		if cfg.Queue.WebhookURL != "" {
+			queue.SetNotifier(webhook.NewQueueNotifier(cfg.Queue.WebhookURL, 5*time.Second))
+		}
+		go queue.Run(queueCtx, time.Second)
+		handler.SetMatchQueue(queue)
+		queueHandler := httpadapter.NewMatchQueueHandler(queue)
+		queueHandler.SetLogger(logger)
+		router.SetupMatchQueueRoutes(queueHandler)
+
+		driverEventHandlers = append(driverEventHandlers, queue.HandleDriverEvent)
+		if len(cfg.Queue.KafkaBrokers) > 0 {
+			logger.Info(ctx, "retrying waiting riders on driver events from Kafka", "topic", cfg.Queue.Topic)
+		} else {
+			logger.Warn(ctx, "KAFKA_BROKERS is not set, waiting riders expire without retries", "wait", cfg.Queue.Wait)
+		}
+		logger.Info(ctx, "riders asking to wait are queued", "wait", cfg.Queue.Wait)
+	}
+
+	if len(cfg.Queue.KafkaBrokers) > 0 && len(driverEventHandlers) > 0 {
+		consumerCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		consumer := event.NewKafkaDriverEventConsumer(cfg.Queue.KafkaBrokers, cfg.Queue.Topic, cfg.Queue.GroupID, func(ctx context.Context, event domain.DriverEvent) {
+			for _, handle := range driverEventHandlers {
+				handle(ctx, event)
+			}
+		})
+		consumer.SetLogger(logger)
+		defer consumer.Close()
+		go func() {
+			if err := consumer.Run(consumerCtx); err != nil {
+				logger.Error(ctx, "failed to read driver events", "error", err)
+			}
+		}()
+	}
+
+	readinessHandler := httpadapter.NewReadinessHandler()
+	readinessHandler.SetDependencies(cfg.Health.ProbeTimeout, dependencies...)
+	router.SetupReadinessRoute(readinessHandler)
+	router.SetupStatusRoute(httpadapter.NewStatusHandler(upstreamProbe, router.RequestErrorRates()))
+
+	logger.Info(ctx, "matching service listening", "port", cfg.Port, "version", buildinfo.Version)
 	if err := router.Start(cfg.Port); err != nil {
-		log.Fatalf("Server error: %v", err)
+		logger.Fatal(ctx, "server error", "error", err)
+	}
+}
+
+// pingRedis is the readiness check of a Redis store
+func pingRedis(client *redis.Client) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		return client.Ping(ctx).Err()
 	}
 }
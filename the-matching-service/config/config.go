@@ -2,7 +2,9 @@ package config
 
 import (
 	"os"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type Config struct {
@@ -10,6 +12,213 @@ type Config struct {
 	Port                  string
 	JWTSecret             string
 	DriverLocationAPIKey  string
+	// DriverLocationSigningSecret signs the requests to the driver location
+	// service with HMAC-SHA256 instead of sending DriverLocationAPIKey
+	DriverLocationSigningSecret string
+	EndUserHashKey              string // keys the hash of the rider sent to the driver location service, SHA-256 when empty
+	AdminAPIKey                 string
+	JSONEngine                  string // std or jsoniter, see httpadapter.NewJSONCodec
+	JWT                         JWTConfig
+	Discovery                   DiscoveryConfig
+	Strategy                    StrategyConfig
+	Bulkhead                    BulkheadConfig
+	SearchCache                 SearchCacheConfig
+	RadiusExpansion             RadiusExpansionConfig
+	SearchLimit                 SearchLimitConfig
+	Blocklist                   BlocklistConfig
+	Outbound                    OutboundConfig
+	MatchStore                  MatchStoreConfig
+	Health                      HealthConfig
+	Geofence                    GeofenceConfig
+	Pooling                     PoolingConfig
+	Queue                       QueueConfig
+	Idempotency                 IdempotencyConfig
+	RateLimit                   RateLimitConfig
+	Log                         LogConfig
+	Privacy                     PrivacyConfig
+	DecisionLog                 DecisionLogConfig
+}
+
+// LogConfig sets the level of the entries written, debug, info, warn or error,
+// and their format, json lines for log collectors or console for a terminal
+type LogConfig struct {
+	Level  string
+	Format string
+}
+
+// PrivacyConfig truncates the rider coordinates written to the logs and the
+// match audits to CoordinateDecimals, and with HashRiderIDs replaces the rider
+// IDs they carry with their END_USER_HASH_KEY hash
+type PrivacyConfig struct {
+	CoordinateDecimals int
+	HashRiderIDs       bool
+}
+
+// DecisionLogConfig appends every match decision as a JSON line to File, or
+// publishes it to Topic on the KAFKA_BROKERS, for replays of new strategies.
+// Both empty turn the decision log off.
+type DecisionLogConfig struct {
+	File  string
+	Topic string
+}
+
+// RateLimitConfig lets every user of a JWT make Rate requests per second to the
+// /api/v1 routes, with bursts of Burst; 0 turns the limit off. Limits are kept
+// in memory per instance unless RedisAddress is set.
+type RateLimitConfig struct {
+	Rate          float64
+	Burst         int
+	RedisAddress  string
+	RedisPassword string
+	RedisDB       int
+}
+
+// IdempotencyConfig keeps the Idempotency-Key of match requests for TTL, 0 turns
+// keys off. Keys are kept in memory per instance unless RedisAddress is set.
+type IdempotencyConfig struct {
+	TTL           time.Duration
+	RedisAddress  string
+	RedisPassword string
+	RedisDB       int
+}
+
+// QueueConfig lets riders that found no driver wait up to Wait for one, the queue
+// is off while Wait is 0. Waiting riders are retried on the driver events read
+// from KafkaBrokers, GroupID must be unique per instance since every instance
+// keeps its own queue and search cache. Finished waits are posted to WebhookURL when it is set.
+type QueueConfig struct {
+	Wait         time.Duration
+	WebhookURL   string
+	KafkaBrokers []string
+	Topic        string
+	GroupID      string
+}
+
+// PoolingConfig lets riders asking for a pooled ride join a driver whose route
+// passes within MaxDetour meters of their pickup and destination. Pooled rides
+// are kept per instance and forgotten RideTTL after they started.
+type PoolingConfig struct {
+	Enabled   bool
+	MaxDetour float64
+	RideTTL   time.Duration
+}
+
+// GeofenceConfig points to a GeoJSON file with the Polygon or MultiPolygon the
+// service operates in, riders outside of it are rejected. Without a file only
+// riders at (0,0) are.
+type GeofenceConfig struct {
+	ServiceAreaFile string
+}
+
+// JWTConfig sets the claims the tokens must carry: iss equal to Issuer and aud
+// listing Audience when they are set, and an exp with RequireExpiry. Expired
+// tokens are refused whether or not exp is required, within Leeway of clock
+// skew. With JWKSURL tokens signed with RS256 are verified with the keys of
+// the set, refreshed every JWKSRefreshInterval; HS256 tokens are then only
+// accepted when JWT_SECRET is set. With EnforceScopes every /api/v1 route
+// requires the rider or driver scope and the admin role opens /admin.
+type JWTConfig struct {
+	Issuer              string
+	Audience            string
+	RequireExpiry       bool
+	Leeway              time.Duration
+	JWKSURL             string
+	JWKSRefreshInterval time.Duration
+	EnforceScopes       bool
+}
+
+// HealthConfig controls whether the health check probes the driver location
+// service, a probe result is reused for ProbeCacheTTL
+type HealthConfig struct {
+	ProbeUpstream bool
+	ProbeTimeout  time.Duration
+	ProbeCacheTTL time.Duration
+}
+
+// MatchStoreConfig points to the Redis keeping the matches for Retention, matches
+// are not stored and the match lookup endpoints are off without an address. With
+// a ProposalTimeout drivers accept or reject their matches within it, a rejected
+// or timed out match goes to the next driver up to MaxRematches times.
+type MatchStoreConfig struct {
+	RedisAddress    string
+	RedisPassword   string
+	RedisDB         int
+	Retention       time.Duration
+	ProposalTimeout time.Duration
+	MaxRematches    int
+}
+
+// OutboundConfig controls the requests to the driver location service. ProxyURL
+// overrides the HTTP_PROXY and HTTPS_PROXY environment variables, NoProxy lists the
+// hosts reached directly, CABundle is a PEM file trusted next to the system roots.
+type OutboundConfig struct {
+	ProxyURL string
+	NoProxy  string
+	CABundle string
+}
+
+// BlocklistConfig points to the Redis holding the rider and driver pairs that must
+// never be matched, the blocklist is off without an address
+type BlocklistConfig struct {
+	RedisAddress  string
+	RedisPassword string
+	RedisDB       int
+}
+
+// RadiusExpansionConfig controls retrying a match without drivers with the radius
+// multiplied by Factor, up to MaxRadius meters
+type RadiusExpansionConfig struct {
+	Factor    float64
+	MaxRadius float64
+}
+
+// SearchLimitConfig controls how many nearby drivers a match chooses from, Default
+// when the match request has no limit and at most Max
+type SearchLimitConfig struct {
+	Default int
+	Max     int
+}
+
+// SearchCacheConfig controls the short lived cache of driver location searches,
+// riders in the same CellDegrees grid cell share one search. A zero TTL disables it.
+// With Queue.KafkaBrokers the entries follow the driver events until they expire.
+type SearchCacheConfig struct {
+	TTL         time.Duration
+	CellDegrees float64
+	MaxEntries  int
+}
+
+// BulkheadConfig limits the concurrent calls to each driver location service
+// operation, calls beyond the limit fail fast instead of piling up
+type BulkheadConfig struct {
+	SearchMaxConcurrency int
+}
+
+// StrategyConfig selects the matching strategy (nearest, least_recently_matched,
+// weighted or eta) and controls the A/B rollout of the ETA based strategy:
+// RolloutPercentage of the riders (by user_id hash) get the ETA strategy and
+// the rest keep the selected strategy
+type StrategyConfig struct {
+	Name              string
+	RolloutPercentage int
+	AverageSpeedKmh   float64
+	HistoryWindow     time.Duration // how long a match counts for least_recently_matched and weighted
+	DistanceWeight    float64
+	IdleWeight        float64
+	FreshnessWeight   float64
+}
+
+// DiscoveryConfig describes how the driver location service address is resolved.
+// Mode "static" keeps using DriverLocationBaseURL, other modes look the address up
+// from DNS (kubernetes headless services, SRV records), Consul or etcd.
+type DiscoveryConfig struct {
+	Mode            string
+	ServiceName     string
+	Scheme          string
+	ConsulAddress   string
+	EtcdEndpoint    string
+	EtcdKey         string
+	RefreshInterval time.Duration
 }
 
 func LoadConfig() *Config {
@@ -17,25 +226,197 @@ func LoadConfig() *Config {
 	if baseURL == "" {
 		baseURL = "http://localhost:8087"
 	}
-
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = ":8087"
 	} else if !strings.HasPrefix(port, ":") {
 		port = ":" + port
 	}
-
 	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
+	jwksURL := os.Getenv("JWT_JWKS_URL")
+	if jwtSecret == "" && jwksURL == "" {
 		jwtSecret = "changeme"
 	}
-
 	apiKey := os.Getenv("DRIVER_LOCATION_API_KEY")
 
 	return &Config{
-		DriverLocationBaseURL: baseURL,
-		Port:                  port,
-		JWTSecret:             jwtSecret,
-		DriverLocationAPIKey:  apiKey,
+		DriverLocationBaseURL:       baseURL,
+		Port:                        port,
+		JWTSecret:                   jwtSecret,
+		DriverLocationAPIKey:        apiKey,
+		DriverLocationSigningSecret: os.Getenv("DRIVER_LOCATION_SIGNING_SECRET"),
+		EndUserHashKey:              os.Getenv("END_USER_HASH_KEY"),
+		AdminAPIKey:                 os.Getenv("ADMIN_API_KEY"),
+		JSONEngine:                  strings.ToLower(getEnv("JSON_ENGINE", "std")),
+		JWT: JWTConfig{
+			Issuer:              os.Getenv("JWT_ISSUER"),
+			Audience:            os.Getenv("JWT_AUDIENCE"),
+			RequireExpiry:       getBoolEnv("JWT_REQUIRE_EXPIRY", false),
+			Leeway:              getDurationEnv("JWT_LEEWAY", 30*time.Second),
+			JWKSURL:             jwksURL,
+			JWKSRefreshInterval: getDurationEnv("JWT_JWKS_REFRESH_INTERVAL", 15*time.Minute),
+			EnforceScopes:       getBoolEnv("JWT_ENFORCE_SCOPES", false),
+		},
+		Discovery: DiscoveryConfig{
+			Mode:            strings.ToLower(getEnv("DISCOVERY_MODE", "static")),
+			ServiceName:     getEnv("DISCOVERY_SERVICE_NAME", "driver-location-service"),
+			Scheme:          getEnv("DISCOVERY_SCHEME", "http"),
+			ConsulAddress:   getEnv("CONSUL_ADDRESS", "http://localhost:8500"),
+			EtcdEndpoint:    getEnv("ETCD_ENDPOINT", "http://localhost:2379"),
+			EtcdKey:         getEnv("ETCD_KEY", "/services/driver-location-service"),
+			RefreshInterval: getDurationEnv("DISCOVERY_REFRESH_INTERVAL", 30*time.Second),
+		},
+		Strategy: StrategyConfig{
+			Name:              strings.ToLower(getEnv("MATCH_STRATEGY", "nearest")),
+			RolloutPercentage: getIntEnv("ETA_STRATEGY_ROLLOUT_PERCENTAGE", 0),
+			AverageSpeedKmh:   getFloatEnv("ETA_AVERAGE_SPEED_KMH", 30),
+			HistoryWindow:     getDurationEnv("MATCH_HISTORY_WINDOW", time.Hour),
+			DistanceWeight:    getFloatEnv("MATCH_WEIGHT_DISTANCE", 0.6),
+			IdleWeight:        getFloatEnv("MATCH_WEIGHT_IDLE", 0.3),
+			FreshnessWeight:   getFloatEnv("MATCH_WEIGHT_FRESHNESS", 0.1),
+		},
+		SearchCache: SearchCacheConfig{
+			TTL:         getDurationEnv("SEARCH_CACHE_TTL", 0),
+			CellDegrees: getFloatEnv("SEARCH_CACHE_CELL_DEGREES", 0.001),
+			MaxEntries:  getIntEnv("SEARCH_CACHE_MAX_ENTRIES", 10000),
+		},
+		RadiusExpansion: RadiusExpansionConfig{
+			Factor:    getFloatEnv("MATCH_RADIUS_EXPANSION_FACTOR", 2),
+			MaxRadius: getFloatEnv("MATCH_MAX_RADIUS", 2000),
+		},
+		SearchLimit: SearchLimitConfig{
+			Default: getIntEnv("MATCH_SEARCH_LIMIT", 10),
+			Max:     getIntEnv("MATCH_SEARCH_MAX_LIMIT", 50),
+		},
+		Blocklist: BlocklistConfig{
+			RedisAddress:  getEnv("BLOCKLIST_REDIS_ADDRESS", ""),
+			RedisPassword: getEnv("BLOCKLIST_REDIS_PASSWORD", ""),
+			RedisDB:       getIntEnv("BLOCKLIST_REDIS_DB", 0),
+		},
+		MatchStore: MatchStoreConfig{
+			RedisAddress:    getEnv("MATCH_STORE_REDIS_ADDRESS", ""),
+			RedisPassword:   getEnv("MATCH_STORE_REDIS_PASSWORD", ""),
+			RedisDB:         getIntEnv("MATCH_STORE_REDIS_DB", 0),
+			Retention:       getDurationEnv("MATCH_STORE_RETENTION", 30*24*time.Hour),
+			ProposalTimeout: getDurationEnv("MATCH_PROPOSAL_TIMEOUT", 0),
+			MaxRematches:    getIntEnv("MATCH_MAX_REMATCHES", 3),
+		},
+		Outbound: OutboundConfig{
+			ProxyURL: getEnv("OUTBOUND_PROXY_URL", ""),
+			NoProxy:  getEnv("OUTBOUND_NO_PROXY", os.Getenv("NO_PROXY")),
+			CABundle: getEnv("OUTBOUND_CA_BUNDLE", ""),
+		},
+		Health: HealthConfig{
+			ProbeUpstream: getBoolEnv("HEALTH_PROBE_UPSTREAM", false),
+			ProbeTimeout:  getDurationEnv("HEALTH_PROBE_TIMEOUT", 2*time.Second),
+			ProbeCacheTTL: getDurationEnv("HEALTH_PROBE_CACHE_TTL", 5*time.Second),
+		},
+		Geofence: GeofenceConfig{
+			ServiceAreaFile: getEnv("SERVICE_AREA_FILE", ""),
+		},
+		Pooling: PoolingConfig{
+			Enabled:   getBoolEnv("POOLING_ENABLED", false),
+			MaxDetour: getFloatEnv("POOL_MAX_DETOUR_METERS", 500),
+			RideTTL:   getDurationEnv("POOL_RIDE_TTL", 45*time.Minute),
+		},
+		Queue: QueueConfig{
+			Wait:         getDurationEnv("MATCH_QUEUE_WAIT", 0),
+			WebhookURL:   getEnv("MATCH_QUEUE_WEBHOOK_URL", ""),
+			KafkaBrokers: getSliceEnv("KAFKA_BROKERS", nil),
+			Topic:        getEnv("DRIVER_EVENTS_TOPIC", "driver-events"),
+			GroupID:      getEnv("DRIVER_EVENTS_GROUP_ID", "matching-service-"+hostname()),
+		},
+		Idempotency: IdempotencyConfig{
+			TTL:           getDurationEnv("IDEMPOTENCY_KEY_TTL", 24*time.Hour),
+			RedisAddress:  getEnv("IDEMPOTENCY_REDIS_ADDRESS", ""),
+			RedisPassword: getEnv("IDEMPOTENCY_REDIS_PASSWORD", ""),
+			RedisDB:       getIntEnv("IDEMPOTENCY_REDIS_DB", 0),
+		},
+		RateLimit: RateLimitConfig{
+			Rate:          getFloatEnv("RATE_LIMIT_RPS", 0),
+			Burst:         getIntEnv("RATE_LIMIT_BURST", 20),
+			RedisAddress:  getEnv("RATE_LIMIT_REDIS_ADDRESS", ""),
+			RedisPassword: getEnv("RATE_LIMIT_REDIS_PASSWORD", ""),
+			RedisDB:       getIntEnv("RATE_LIMIT_REDIS_DB", 0),
+		},
+		Log: LogConfig{
+			Level:  strings.ToLower(getEnv("LOG_LEVEL", "info")),
+			Format: strings.ToLower(getEnv("LOG_FORMAT", "json")),
+		},
+		Privacy: PrivacyConfig{
+			CoordinateDecimals: getIntEnv("PRIVACY_COORDINATE_DECIMALS", 2),
+			HashRiderIDs:       getBoolEnv("PRIVACY_HASH_RIDER_IDS", false),
+		},
+		DecisionLog: DecisionLogConfig{
+			File:  getEnv("DECISION_LOG_FILE", ""),
+			Topic: getEnv("DECISION_LOG_TOPIC", ""),
+		},
+		Bulkhead: BulkheadConfig{
+			SearchMaxConcurrency: getIntEnv("DRIVER_LOCATION_SEARCH_MAX_CONCURRENCY", 100),
+		},
+	}
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := strings.TrimSpace(os.Getenv(key)); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func getSliceEnv(key string, defaultValue []string) []string {
+	if value := os.Getenv(key); value != "" {
+		var values []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				values = append(values, item)
+			}
+		}
+		return values
+	}
+	return defaultValue
+}
+
+func hostname() string {
+	name, err := os.Hostname()
+	if err != nil {
+		return "local"
+	}
+	return name
+}
+
+func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration
+		}
+	}
+	return defaultValue
+}
+
+func getBoolEnv(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
+func getIntEnv(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if intValue, err := strconv.Atoi(value); err == nil {
+			return intValue
+		}
+	}
+	return defaultValue
+}
+
+func getFloatEnv(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil && floatValue > 0 {
+			return floatValue
+		}
 	}
+	return defaultValue
 }
@@ -2,7 +2,9 @@ package config
 
 import (
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -20,6 +22,38 @@ func TestLoadConfig_Defaults(t *testing.T) {
 	assert.Equal(t, ":8087", cfg.Port)
 	assert.Equal(t, "changeme", cfg.JWTSecret)
 	assert.Equal(t, "", cfg.DriverLocationAPIKey)
+	assert.Equal(t, "static", cfg.Discovery.Mode)
+	assert.Equal(t, "driver-location-service", cfg.Discovery.ServiceName)
+	assert.Equal(t, 30*time.Second, cfg.Discovery.RefreshInterval)
+	assert.Equal(t, 0, cfg.Strategy.RolloutPercentage)
+	assert.Equal(t, 30.0, cfg.Strategy.AverageSpeedKmh)
+	assert.Equal(t, 100, cfg.Bulkhead.SearchMaxConcurrency)
+	assert.Equal(t, time.Duration(0), cfg.SearchCache.TTL)
+	assert.Equal(t, 0.001, cfg.SearchCache.CellDegrees)
+	assert.Equal(t, 10000, cfg.SearchCache.MaxEntries)
+	assert.False(t, cfg.Health.ProbeUpstream)
+	assert.Equal(t, 2*time.Second, cfg.Health.ProbeTimeout)
+	assert.Empty(t, cfg.Geofence.ServiceAreaFile)
+	assert.False(t, cfg.Pooling.Enabled)
+	assert.Zero(t, cfg.MatchStore.ProposalTimeout)
+	assert.Equal(t, 3, cfg.MatchStore.MaxRematches)
+	assert.Equal(t, 500.0, cfg.Pooling.MaxDetour)
+	assert.Equal(t, 45*time.Minute, cfg.Pooling.RideTTL)
+	assert.Zero(t, cfg.Queue.Wait)
+	assert.Empty(t, cfg.Queue.KafkaBrokers)
+	assert.Equal(t, "info", cfg.Log.Level)
+	assert.Equal(t, "json", cfg.Log.Format)
+	assert.Equal(t, "driver-events", cfg.Queue.Topic)
+	assert.True(t, strings.HasPrefix(cfg.Queue.GroupID, "matching-service-"))
+	assert.Equal(t, 24*time.Hour, cfg.Idempotency.TTL)
+	assert.Empty(t, cfg.Idempotency.RedisAddress)
+	assert.Zero(t, cfg.RateLimit.Rate)
+	assert.Equal(t, 20, cfg.RateLimit.Burst)
+	assert.Equal(t, 5*time.Second, cfg.Health.ProbeCacheTTL)
+	assert.Equal(t, 2, cfg.Privacy.CoordinateDecimals)
+	assert.False(t, cfg.Privacy.HashRiderIDs)
+	assert.Empty(t, cfg.DecisionLog.File)
+	assert.Empty(t, cfg.DecisionLog.Topic)
 }
 
 // TestLoadConfig_EnvOverride tests configuration loading with environment variable overrides
@@ -36,3 +70,70 @@ func TestLoadConfig_EnvOverride(t *testing.T) {
 	assert.Equal(t, "mysecret", cfg.JWTSecret)
 	assert.Equal(t, "apikey123", cfg.DriverLocationAPIKey)
 }
+
+// TestLoadConfig_DiscoveryOverride tests service discovery configuration from environment variables
+// Expected: Should load discovery mode, service name and refresh interval from the environment
+func TestLoadConfig_DiscoveryOverride(t *testing.T) {
+	os.Setenv("DISCOVERY_MODE", "Consul")
+	os.Setenv("DISCOVERY_SERVICE_NAME", "drivers")
+	os.Setenv("CONSUL_ADDRESS", "http://consul:8500")
+	os.Setenv("DISCOVERY_REFRESH_INTERVAL", "5s")
+	defer func() {
+		os.Unsetenv("DISCOVERY_MODE")
+		os.Unsetenv("DISCOVERY_SERVICE_NAME")
+		os.Unsetenv("CONSUL_ADDRESS")
+		os.Unsetenv("DISCOVERY_REFRESH_INTERVAL")
+	}()
+
+	cfg := LoadConfig()
+	assert.Equal(t, "consul", cfg.Discovery.Mode)
+	assert.Equal(t, "drivers", cfg.Discovery.ServiceName)
+	assert.Equal(t, "http://consul:8500", cfg.Discovery.ConsulAddress)
+	assert.Equal(t, 5*time.Second, cfg.Discovery.RefreshInterval)
+}
+
+// TestLoadConfig_HealthOverride tests the upstream health probe configuration from environment variables
+// Expected: Should enable the probe with the given timeout and cache TTL
+func TestLoadConfig_HealthOverride(t *testing.T) {
+	os.Setenv("HEALTH_PROBE_UPSTREAM", "true")
+	os.Setenv("HEALTH_PROBE_TIMEOUT", "500ms")
+	os.Setenv("HEALTH_PROBE_CACHE_TTL", "10s")
+	defer func() {
+		os.Unsetenv("HEALTH_PROBE_UPSTREAM")
+		os.Unsetenv("HEALTH_PROBE_TIMEOUT")
+		os.Unsetenv("HEALTH_PROBE_CACHE_TTL")
+	}()
+
+	cfg := LoadConfig()
+	assert.True(t, cfg.Health.ProbeUpstream)
+	assert.Equal(t, 500*time.Millisecond, cfg.Health.ProbeTimeout)
+	assert.Equal(t, 10*time.Second, cfg.Health.ProbeCacheTTL)
+}
+
+// TestLoadConfig_JWTOverride tests loading the JWT claim checks and the JWKS
+// Expected: Should read the claims and the JWKS and leave the JWT secret unset when only the JWKS is configured
+func TestLoadConfig_JWTOverride(t *testing.T) {
+	os.Unsetenv("JWT_SECRET")
+	os.Setenv("JWT_ISSUER", "https://auth.example.com")
+	os.Setenv("JWT_AUDIENCE", "matching")
+	os.Setenv("JWT_REQUIRE_EXPIRY", "true")
+	os.Setenv("JWT_JWKS_URL", "https://auth.example.com/.well-known/jwks.json")
+	os.Setenv("JWT_ENFORCE_SCOPES", "true")
+	defer func() {
+		os.Unsetenv("JWT_ISSUER")
+		os.Unsetenv("JWT_AUDIENCE")
+		os.Unsetenv("JWT_REQUIRE_EXPIRY")
+		os.Unsetenv("JWT_JWKS_URL")
+		os.Unsetenv("JWT_ENFORCE_SCOPES")
+	}()
+
+	cfg := LoadConfig()
+	assert.Equal(t, "", cfg.JWTSecret)
+	assert.Equal(t, "https://auth.example.com", cfg.JWT.Issuer)
+	assert.Equal(t, "matching", cfg.JWT.Audience)
+	assert.True(t, cfg.JWT.RequireExpiry)
+	assert.Equal(t, 30*time.Second, cfg.JWT.Leeway)
+	assert.Equal(t, "https://auth.example.com/.well-known/jwks.json", cfg.JWT.JWKSURL)
+	assert.Equal(t, 15*time.Minute, cfg.JWT.JWKSRefreshInterval)
+	assert.True(t, cfg.JWT.EnforceScopes)
+}
@@ -15,6 +15,185 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/admin/riders/{rider_id}/blocked-drivers": {
+            "get": {
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ],
+                "description": "Get the drivers that are never matched with the rider, the most recently blocked first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List blocked drivers of a rider",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Rider ID",
+                        "name": "rider_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success: data contains the blocked pairs",
+                        "schema": {
+                            "$ref": "#/definitions/domain.SuccessResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - a JWT without the admin role",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ],
+                "description": "Never match the driver with the rider again, e.g. after a complaint",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Block a driver for a rider",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Rider ID",
+                        "name": "rider_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Driver to block",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.BlockDriverRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Success: data contains the blocked pair",
+                        "schema": {
+                            "$ref": "#/definitions/domain.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Validation error or invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - a JWT without the admin role",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/riders/{rider_id}/blocked-drivers/{driver_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ],
+                "description": "Allow matching the driver with the rider again",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Unblock a driver for a rider",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Rider ID",
+                        "name": "rider_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Driver ID",
+                        "name": "driver_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.SuccessResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - a JWT without the admin role",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/api/v1/match": {
             "post": {
                 "security": [
@@ -22,49 +201,718 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Find the nearest driver for a rider based on location and radius",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Find the nearest driver for a rider based on location and radius",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "matching"
+                ],
+                "summary": "Match rider with nearby driver",
+                "parameters": [
+                    {
+                        "description": "Match request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.MatchRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Retries with the same key return the match of the first request, answered with Idempotent-Replayed: true",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success: data contains MatchResponse",
+                        "schema": {
+                            "$ref": "#/definitions/domain.SuccessResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "Accepted: no driver nearby, data contains the QueueEntry of a rider that asked to wait",
+                        "schema": {
+                            "$ref": "#/definitions/domain.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Validation error or invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - User not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - without the rider scope once JWT_ENFORCE_SCOPES is set, or include_candidates without the match:candidates scope",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found - No drivers found nearby",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict - The first request with the Idempotency-Key is still matching",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity - Location at (0,0) or outside the service area, or an Idempotency-Key sent with another request",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests - Rate limit of the user exceeded, retry after Retry-After seconds",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway - Driver location service unavailable, rate limited or refusing the credentials of the matching service",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout - Driver location service timed out",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/match/candidates": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Find the nearby drivers ranked by estimated time of arrival, nearest first among equal ETAs. The limit is the number of candidates returned. Nothing is matched, the rider app can show the options and match again without a new search.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "matching"
+                ],
+                "summary": "List drivers a rider can choose from",
+                "parameters": [
+                    {
+                        "description": "Match request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.MatchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success: data contains CandidatesResponse",
+                        "schema": {
+                            "$ref": "#/definitions/domain.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Validation error or invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - User not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - without the rider scope once JWT_ENFORCE_SCOPES is set",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found - No drivers found nearby",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity - Location at (0,0) or outside the service area",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests - Rate limit of the user exceeded, retry after Retry-After seconds",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway - Driver location service unavailable, rate limited or refusing the credentials of the matching service",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout - Driver location service timed out",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/match/queue/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the status of the authenticated rider's queue entry, the position while waiting and the match once matched. Finished entries are kept for the wait window.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "matching"
+                ],
+                "summary": "Get a wait in the match queue",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Queue entry ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success: data contains the QueueEntry",
+                        "schema": {
+                            "$ref": "#/definitions/domain.SuccessResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - User not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - without the rider scope once JWT_ENFORCE_SCOPES is set",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found - Unknown queue entry",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests - Rate limit of the user exceeded, retry after Retry-After seconds",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/match/queue/{id}/stream": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Upgrade to a WebSocket that receives the queue entry once right away and once more when the rider is matched or the wait expires, then closes",
+                "tags": [
+                    "matching"
+                ],
+                "summary": "Watch a wait in the match queue",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Queue entry ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols",
+                        "schema": {
+                            "$ref": "#/definitions/domain.QueueEntry"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - User not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - without the rider scope once JWT_ENFORCE_SCOPES is set",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found - Unknown queue entry",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests - Rate limit of the user exceeded, retry after Retry-After seconds",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/matches": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the stored matches of the authenticated rider, the most recent first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "matching"
+                ],
+                "summary": "List the matches of the rider",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Matches per page, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Matches to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success: data contains a MatchPage",
+                        "schema": {
+                            "$ref": "#/definitions/domain.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid limit or offset",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - User not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - without the rider scope once JWT_ENFORCE_SCOPES is set",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests - Rate limit of the user exceeded, retry after Retry-After seconds",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/matches/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a stored match of the authenticated rider by its ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "matching"
+                ],
+                "summary": "Get a match of the rider",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Match ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success: data contains the MatchResult",
+                        "schema": {
+                            "$ref": "#/definitions/domain.SuccessResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - User not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - without the rider scope once JWT_ENFORCE_SCOPES is set",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found - Unknown or expired match",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests - Rate limit of the user exceeded, retry after Retry-After seconds",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/matches/{id}/accept": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Confirm a match proposed to the authenticated driver before it times out",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "matching"
+                ],
+                "summary": "Accept a proposed match",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Match ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success: data contains the accepted MatchResult",
+                        "schema": {
+                            "$ref": "#/definitions/domain.SuccessResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - User not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - without the driver scope once JWT_ENFORCE_SCOPES is set",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found - Unknown match or a match of another driver",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict - Match accepted, rejected or timed out already",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests - Rate limit of the user exceeded, retry after Retry-After seconds",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/matches/{id}/cancel": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Cancel a match of the authenticated rider while it is proposed or accepted, or an accepted match of the authenticated driver. Only driver cancellations are reported to the driver location service.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "matching"
+                ],
+                "summary": "Cancel a match",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Match ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success: data contains the cancelled MatchResult",
+                        "schema": {
+                            "$ref": "#/definitions/domain.SuccessResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - User not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - without the rider or driver scope once JWT_ENFORCE_SCOPES is set",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found - Unknown match or a match of another rider or driver",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict - Match cannot be cancelled anymore",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests - Rate limit of the user exceeded, retry after Retry-After seconds",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/matches/{id}/complete": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "End the ride of a match the authenticated driver accepted, the outcome is reported to the driver location service",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "matching"
+                ],
+                "summary": "Complete an accepted match",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Match ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success: data contains the completed MatchResult",
+                        "schema": {
+                            "$ref": "#/definitions/domain.SuccessResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - User not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - without the driver scope once JWT_ENFORCE_SCOPES is set",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found - Unknown match or a match of another driver",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict - Match not accepted or ended already",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests - Rate limit of the user exceeded, retry after Retry-After seconds",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/matches/{id}/reject": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Decline a match proposed to the authenticated driver, the rider is proposed to the next driver while re-matches are left. The response holds the match after the re-match.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "matching"
                 ],
-                "summary": "Match rider with nearby driver",
+                "summary": "Reject a proposed match",
                 "parameters": [
                     {
-                        "description": "Match request",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/domain.MatchRequest"
-                        }
+                        "type": "string",
+                        "description": "Match ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Success: data contains MatchResponse",
+                        "description": "Success: data contains the MatchResult",
                         "schema": {
                             "$ref": "#/definitions/domain.SuccessResponse"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request - Validation error or invalid request",
+                    "401": {
+                        "description": "Unauthorized - User not authenticated",
                         "schema": {
                             "$ref": "#/definitions/domain.ErrorResponse"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized - User not authenticated",
+                    "403": {
+                        "description": "Forbidden - without the driver scope once JWT_ENFORCE_SCOPES is set",
                         "schema": {
                             "$ref": "#/definitions/domain.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "Not Found - No drivers found nearby",
+                        "description": "Not Found - Unknown match or a match of another driver",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict - Match accepted, rejected or timed out already",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests - Rate limit of the user exceeded, retry after Retry-After seconds",
                         "schema": {
                             "$ref": "#/definitions/domain.ErrorResponse"
                         }
@@ -80,7 +928,7 @@ const docTemplate = `{
         },
         "/health": {
             "get": {
-                "description": "Check if the service is healthy",
+                "description": "Check if the service is healthy. With HEALTH_PROBE_UPSTREAM the driver location service is probed too and the status is degraded while it is down or a circuit breaker is not closed, the response stays 200 so an upstream outage does not restart the service.",
                 "consumes": [
                     "application/json"
                 ],
@@ -101,9 +949,133 @@ const docTemplate = `{
                     }
                 }
             }
+        },
+        "/health/live": {
+            "get": {
+                "description": "Check if the service is alive without calling any dependency, see /health/ready",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Liveness check endpoint",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/health/ready": {
+            "get": {
+                "description": "Check if the service is ready. The configured Redis stores (blocklist, idempotency keys, matches) are required and answer 503 while down, the driver location service only degrades the status: its outage is shared by every instance and handled by the circuit breakers.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Readiness check endpoint",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/status": {
+            "get": {
+                "description": "Build, uptime, health and circuit breakers of the driver location service, and the error rates of the API requests and of the upstream calls over the last 1, 5 and 15 minutes. The upstream probe is cached for HEALTH_PROBE_CACHE_TTL. The status is degraded while the driver location service is down or a breaker is not closed, the response stays 200.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Status page document",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/httpadapter.StatusReport"
+                        }
+                    }
+                }
+            }
+        },
+        "/version": {
+            "get": {
+                "description": "Version, git commit and build time of the running binary, set at build time with -ldflags",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Build information",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/buildinfo.Info"
+                        }
+                    }
+                }
+            }
         }
     },
     "definitions": {
+        "buildinfo.Info": {
+            "type": "object",
+            "properties": {
+                "build_time": {
+                    "type": "string"
+                },
+                "git_sha": {
+                    "type": "string"
+                },
+                "go_version": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.BlockDriverRequest": {
+            "description": "Request to never match a driver with a rider",
+            "type": "object",
+            "required": [
+                "driver_id"
+            ],
+            "properties": {
+                "driver_id": {
+                    "type": "string",
+                    "example": "driver-123"
+                },
+                "reason": {
+                    "type": "string",
+                    "maxLength": 500,
+                    "example": "complaint"
+                }
+            }
+        },
         "domain.ErrorResponse": {
             "type": "object",
             "properties": {
@@ -123,7 +1095,6 @@ const docTemplate = `{
             "description": "GeoJSON Point location with longitude and latitude coordinates",
             "type": "object",
             "required": [
-                "coordinates",
                 "type"
             ],
             "properties": {
@@ -151,12 +1122,154 @@ const docTemplate = `{
                 "radius"
             ],
             "properties": {
+                "destination": {
+                    "$ref": "#/definitions/domain.Location"
+                },
+                "include_candidates": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "limit": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 10
+                },
                 "location": {
                     "$ref": "#/definitions/domain.Location"
                 },
+                "min_capacity": {
+                    "type": "integer",
+                    "maximum": 100,
+                    "minimum": 0,
+                    "example": 4
+                },
+                "pool": {
+                    "type": "boolean",
+                    "example": true
+                },
                 "radius": {
                     "type": "number",
+                    "maximum": 50000,
+                    "minimum": 0.1,
                     "example": 500
+                },
+                "vehicle_type": {
+                    "type": "string",
+                    "maxLength": 32,
+                    "example": "sedan"
+                },
+                "wait": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "domain.MatchResult": {
+            "type": "object",
+            "properties": {
+                "cancelled_by": {
+                    "description": "rider or driver",
+                    "type": "string"
+                },
+                "declined_drivers": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "distance": {
+                    "description": "meters",
+                    "type": "number"
+                },
+                "driver_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "matched_at": {
+                    "type": "string"
+                },
+                "pooled": {
+                    "type": "boolean"
+                },
+                "rematches": {
+                    "type": "integer"
+                },
+                "respond_by": {
+                    "type": "string"
+                },
+                "rider_id": {
+                    "type": "string"
+                },
+                "search": {
+                    "$ref": "#/definitions/domain.MatchSearch"
+                },
+                "status": {
+                    "description": "set when drivers answer matches, empty otherwise",
+                    "type": "string"
+                },
+                "strategy": {
+                    "description": "matching strategy variant that picked the driver",
+                    "type": "string"
+                }
+            }
+        },
+        "domain.MatchSearch": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "integer"
+                },
+                "location": {
+                    "$ref": "#/definitions/domain.Location"
+                },
+                "preferences": {
+                    "$ref": "#/definitions/domain.RiderPreferences"
+                },
+                "radius": {
+                    "type": "number"
+                }
+            }
+        },
+        "domain.QueueEntry": {
+            "type": "object",
+            "properties": {
+                "enqueued_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "9b2d4c6e8f0a1b3c5d7e9f1a2b3c4d5e"
+                },
+                "match": {
+                    "$ref": "#/definitions/domain.MatchResult"
+                },
+                "position": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "rider_id": {
+                    "type": "string",
+                    "example": "rider-456"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "waiting"
+                }
+            }
+        },
+        "domain.RiderPreferences": {
+            "type": "object",
+            "properties": {
+                "min_capacity": {
+                    "type": "integer"
+                },
+                "vehicle_type": {
+                    "type": "string"
                 }
             }
         },
@@ -171,9 +1284,102 @@ const docTemplate = `{
                     "type": "boolean"
                 }
             }
+        },
+        "httpadapter.ErrorRate": {
+            "type": "object",
+            "properties": {
+                "errors": {
+                    "type": "integer"
+                },
+                "rate": {
+                    "type": "number"
+                },
+                "requests": {
+                    "type": "integer"
+                }
+            }
+        },
+        "httpadapter.StatusErrorRates": {
+            "type": "object",
+            "properties": {
+                "requests": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/httpadapter.ErrorRate"
+                    }
+                },
+                "upstream": {
+                    "description": "operation -\u003e window -\u003e rate",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "object",
+                        "additionalProperties": {
+                            "$ref": "#/definitions/httpadapter.ErrorRate"
+                        }
+                    }
+                }
+            }
+        },
+        "httpadapter.StatusReport": {
+            "type": "object",
+            "properties": {
+                "build": {
+                    "$ref": "#/definitions/buildinfo.Info"
+                },
+                "error_rates": {
+                    "$ref": "#/definitions/httpadapter.StatusErrorRates"
+                },
+                "service": {
+                    "type": "string"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "operational, or degraded while the upstream is unhealthy",
+                    "type": "string"
+                },
+                "upstream": {
+                    "$ref": "#/definitions/httpadapter.UpstreamHealth"
+                },
+                "uptime_seconds": {
+                    "type": "integer"
+                }
+            }
+        },
+        "httpadapter.UpstreamHealth": {
+            "type": "object",
+            "properties": {
+                "checked_at": {
+                    "type": "string"
+                },
+                "circuit_breakers": {
+                    "description": "operation -\u003e closed, half-open or open",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "error": {
+                    "type": "string"
+                },
+                "latency_ms": {
+                    "type": "number"
+                },
+                "status": {
+                    "description": "up or down",
+                    "type": "string"
+                }
+            }
         }
     },
     "securityDefinitions": {
+        "AdminAPIKey": {
+            "description": "API key of the admin endpoints (ADMIN_API_KEY).",
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
+        },
         "BearerAuth": {
             "description": "Type \"Bearer\" followed by a space and JWT token.",
             "type": "apiKey",
@@ -0,0 +1,72 @@
+package blocklist
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"the-matching-service/internal/domain"
+	"the-matching-service/internal/ports/secondary"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const keyPrefix = "blocklist:rider:"
+
+type entry struct {
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RedisBlocklist keeps the blocked drivers of a rider in one hash keyed by driver ID,
+// so the check before every match is a single HGETALL
+type RedisBlocklist struct {
+	client *redis.Client
+}
+
+var _ secondary.Blocklist = (*RedisBlocklist)(nil)
+
+func NewRedisBlocklist(client *redis.Client) *RedisBlocklist {
+	return &RedisBlocklist{client: client}
+}
+
+func (b *RedisBlocklist) BlockedDrivers(ctx context.Context, riderID string) ([]domain.BlockedPair, error) {
+	fields, err := b.client.HGetAll(ctx, keyPrefix+riderID).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blocked drivers: %w", err)
+	}
+
+	pairs := make([]domain.BlockedPair, 0, len(fields))
+	for driverID, value := range fields {
+		var e entry
+		if err := json.Unmarshal([]byte(value), &e); err != nil {
+			return nil, fmt.Errorf("failed to decode blocked driver %s: %w", driverID, err)
+		}
+		pairs = append(pairs, domain.BlockedPair{
+			RiderID:   riderID,
+			DriverID:  driverID,
+			Reason:    e.Reason,
+			CreatedAt: e.CreatedAt,
+		})
+	}
+	return pairs, nil
+}
+
+func (b *RedisBlocklist) Block(ctx context.Context, pair domain.BlockedPair) error {
+	value, err := json.Marshal(entry{Reason: pair.Reason, CreatedAt: pair.CreatedAt})
+	if err != nil {
+		return fmt.Errorf("failed to encode blocked driver: %w", err)
+	}
+	if err := b.client.HSet(ctx, keyPrefix+pair.RiderID, pair.DriverID, value).Err(); err != nil {
+		return fmt.Errorf("failed to block driver: %w", err)
+	}
+	return nil
+}
+
+func (b *RedisBlocklist) Unblock(ctx context.Context, riderID, driverID string) error {
+	if err := b.client.HDel(ctx, keyPrefix+riderID, driverID).Err(); err != nil {
+		return fmt.Errorf("failed to unblock driver: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package decisionlog
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"the-matching-service/internal/domain"
+	"the-matching-service/internal/ports/secondary"
+)
+
+// maxDecisionLine bounds a line of the log, far above a decision with the
+// candidates of the largest search limit
+const maxDecisionLine = 4 << 20
+
+// FileDecisionLog appends the decisions to a file as JSON lines. Every instance
+// needs its own file, lines of instances sharing one may interleave.
+type FileDecisionLog struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+var _ secondary.DecisionLog = (*FileDecisionLog)(nil)
+
+// NewFileDecisionLog opens the file for appending, creating it when missing
+func NewFileDecisionLog(path string) (*FileDecisionLog, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o640)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open decision log: %w", err)
+	}
+	return &FileDecisionLog{file: file}, nil
+}
+
+func (l *FileDecisionLog) Append(ctx context.Context, event domain.DecisionEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode decision %s: %w", event.MatchID, err)
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// one write per line, a crash never leaves half of a decision behind another
+	if _, err := l.file.Write(line); err != nil {
+		return fmt.Errorf("failed to append decision %s: %w", event.MatchID, err)
+	}
+	return nil
+}
+
+func (l *FileDecisionLog) Close() error {
+	return l.file.Close()
+}
+
+// ReadDecisions hands the decisions of a JSON lines log to handle in order,
+// the lines of the file log and the messages of the Kafka topic alike. Empty
+// lines are skipped, a malformed one fails with its line number.
+func ReadDecisions(r io.Reader, handle func(event domain.DecisionEvent) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxDecisionLine)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var event domain.DecisionEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return fmt.Errorf("invalid decision on line %d: %w", line, err)
+		}
+		if err := handle(event); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
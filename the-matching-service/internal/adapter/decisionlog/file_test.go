@@ -0,0 +1,72 @@
+package decisionlog
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"the-matching-service/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFileDecisionLog_Append tests appending decisions to a file and reading them back
+// Expected: Should append one JSON line per decision, keep the lines of a reopened log and read them in order
+func TestFileDecisionLog_Append(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "decisions.jsonl")
+	event := domain.DecisionEvent{
+		MatchID:    "m1",
+		Rider:      domain.Rider{ID: "rider-1", Location: domain.Location{Type: "Point", Coordinates: [2]float64{28.97, 41}}},
+		Radius:     500,
+		Limit:      10,
+		Candidates: []domain.DriverDistancePair{{Driver: domain.Driver{ID: "d1", UpdatedAt: time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)}, Distance: 120}},
+		Strategy:   "nearest",
+		DriverID:   "d1",
+		Distance:   120,
+		DecidedAt:  time.Date(2026, 10, 15, 9, 30, 0, 0, time.UTC),
+	}
+
+	log, err := NewFileDecisionLog(path)
+	require.NoError(t, err)
+	require.NoError(t, log.Append(context.Background(), event))
+	require.NoError(t, log.Close())
+
+	log, err = NewFileDecisionLog(path)
+	require.NoError(t, err)
+	second := event
+	second.MatchID = "m2"
+	require.NoError(t, log.Append(context.Background(), second))
+	require.NoError(t, log.Close())
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+	var read []domain.DecisionEvent
+	require.NoError(t, ReadDecisions(file, func(event domain.DecisionEvent) error {
+		read = append(read, event)
+		return nil
+	}))
+	require.Len(t, read, 2)
+	assert.Equal(t, "m1", read[0].MatchID)
+	assert.Equal(t, "m2", read[1].MatchID)
+	assert.Equal(t, event.Rider.Location, read[0].Rider.Location)
+	assert.Equal(t, "d1", read[0].Candidates[0].Driver.ID)
+	assert.True(t, event.Candidates[0].Driver.UpdatedAt.Equal(read[0].Candidates[0].Driver.UpdatedAt))
+	assert.True(t, event.DecidedAt.Equal(read[0].DecidedAt))
+}
+
+// TestReadDecisions_malformed tests reading a decision log with a malformed line
+// Expected: Should skip empty lines and fail with the number of the malformed line
+func TestReadDecisions_malformed(t *testing.T) {
+	var read int
+	err := ReadDecisions(strings.NewReader("{\"match_id\":\"m1\"}\n\nnot json\n"), func(event domain.DecisionEvent) error {
+		read++
+		return nil
+	})
+	assert.ErrorContains(t, err, "invalid decision on line 3")
+	assert.Equal(t, 1, read)
+}
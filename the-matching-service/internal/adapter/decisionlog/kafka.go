@@ -0,0 +1,55 @@
+package decisionlog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"the-matching-service/internal/domain"
+	"the-matching-service/internal/ports/secondary"
+
+	"github.com/segmentio/kafka-go"
+)
+
+type messageWriter interface {
+	WriteMessages(ctx context.Context, messages ...kafka.Message) error
+	Close() error
+}
+
+// KafkaDecisionLog publishes the decisions to a Kafka topic keyed by match ID.
+// Messages are written in the background so a match never waits for the
+// brokers; decisions the brokers refuse are logged and lost.
+type KafkaDecisionLog struct {
+	writer messageWriter
+}
+
+var _ secondary.DecisionLog = (*KafkaDecisionLog)(nil)
+
+func NewKafkaDecisionLog(brokers []string, topic string, logger secondary.Logger) *KafkaDecisionLog {
+	return &KafkaDecisionLog{writer: &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{},
+		BatchTimeout: 50 * time.Millisecond,
+		Async:        true,
+		Completion: func(messages []kafka.Message, err error) {
+			if err != nil {
+				logger.Error(context.Background(), "failed to publish match decisions", "decisions", len(messages), "error", err)
+			}
+		},
+	}}
+}
+
+func (l *KafkaDecisionLog) Append(ctx context.Context, event domain.DecisionEvent) error {
+	value, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode decision %s: %w", event.MatchID, err)
+	}
+	return l.writer.WriteMessages(ctx, kafka.Message{Key: []byte(event.MatchID), Value: value})
+}
+
+// Close flushes the decisions not written yet
+func (l *KafkaDecisionLog) Close() error {
+	return l.writer.Close()
+}
@@ -0,0 +1,39 @@
+package decisionlog
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"the-matching-service/internal/domain"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingWriter struct {
+	messages []kafka.Message
+}
+
+func (w *recordingWriter) WriteMessages(ctx context.Context, messages ...kafka.Message) error {
+	w.messages = append(w.messages, messages...)
+	return nil
+}
+
+func (w *recordingWriter) Close() error { return nil }
+
+// TestKafkaDecisionLog_Append tests publishing a decision to Kafka
+// Expected: Should write the decision as JSON keyed by its match ID
+func TestKafkaDecisionLog_Append(t *testing.T) {
+	writer := &recordingWriter{}
+	log := &KafkaDecisionLog{writer: writer}
+
+	require.NoError(t, log.Append(context.Background(), domain.DecisionEvent{MatchID: "m1", Strategy: "nearest", DriverID: "d1"}))
+
+	require.Len(t, writer.messages, 1)
+	assert.Equal(t, []byte("m1"), writer.messages[0].Key)
+	var event domain.DecisionEvent
+	require.NoError(t, json.Unmarshal(writer.messages[0].Value, &event))
+	assert.Equal(t, "d1", event.DriverID)
+}
@@ -0,0 +1,75 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ConsulSource returns the passing instances of a service registered in Consul
+// https://developer.hashicorp.com/consul/api-docs/health#list-service-instances-for-service
+type ConsulSource struct {
+	address     string
+	serviceName string
+	scheme      string
+	httpClient  *http.Client
+}
+
+type consulServiceEntry struct {
+	Node struct {
+		Address string `json:"Address"`
+	} `json:"Node"`
+	Service struct {
+		Address string `json:"Address"`
+		Port    int    `json:"Port"`
+	} `json:"Service"`
+}
+
+func NewConsulSource(address, serviceName, scheme string) *ConsulSource {
+	return &ConsulSource{
+		address:     strings.TrimSuffix(address, "/"),
+		serviceName: serviceName,
+		scheme:      scheme,
+		httpClient:  &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func (s *ConsulSource) Lookup(ctx context.Context) ([]string, error) {
+	endpoint := fmt.Sprintf("%s/v1/health/service/%s?passing=true", s.address, url.PathEscape(s.serviceName))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("consul lookup failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("consul lookup failed: unexpected status %d", resp.StatusCode)
+	}
+
+	var entries []consulServiceEntry
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("failed to decode consul response: %w", err)
+	}
+
+	endpoints := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		// an empty service address means the service listens on the node address
+		host := entry.Service.Address
+		if host == "" {
+			host = entry.Node.Address
+		}
+		endpoints = append(endpoints, fmt.Sprintf("%s://%s", s.scheme, net.JoinHostPort(host, strconv.Itoa(entry.Service.Port))))
+	}
+	return endpoints, nil
+}
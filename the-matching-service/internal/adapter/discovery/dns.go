@@ -0,0 +1,53 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// DNSSource resolves a service through DNS. A name with a port
+// (driver-location-service.default.svc.cluster.local:8087) is looked up
+// with A/AAAA records, a name without a port is looked up as an SRV record
+// (_http._tcp.driver-location-service.default.svc.cluster.local) so
+// kubernetes headless services return every pod.
+type DNSSource struct {
+	name     string
+	scheme   string
+	resolver *net.Resolver
+}
+
+func NewDNSSource(name, scheme string) *DNSSource {
+	return &DNSSource{
+		name:     name,
+		scheme:   scheme,
+		resolver: net.DefaultResolver,
+	}
+}
+
+func (s *DNSSource) Lookup(ctx context.Context) ([]string, error) {
+	if host, port, err := net.SplitHostPort(s.name); err == nil {
+		addrs, err := s.resolver.LookupHost(ctx, host)
+		if err != nil {
+			return nil, fmt.Errorf("dns lookup failed for %s: %w", host, err)
+		}
+		endpoints := make([]string, len(addrs))
+		for i, addr := range addrs {
+			endpoints[i] = fmt.Sprintf("%s://%s", s.scheme, net.JoinHostPort(addr, port))
+		}
+		return endpoints, nil
+	}
+
+	_, records, err := s.resolver.LookupSRV(ctx, "", "", s.name)
+	if err != nil {
+		return nil, fmt.Errorf("dns srv lookup failed for %s: %w", s.name, err)
+	}
+	endpoints := make([]string, len(records))
+	for i, record := range records {
+		target := strings.TrimSuffix(record.Target, ".")
+		endpoints[i] = fmt.Sprintf("%s://%s", s.scheme, net.JoinHostPort(target, strconv.Itoa(int(record.Port))))
+	}
+	return endpoints, nil
+}
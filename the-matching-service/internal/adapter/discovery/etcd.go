@@ -0,0 +1,91 @@
+package discovery
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// EtcdSource reads base URLs stored under a key prefix in etcd through the
+// v3 JSON gateway, so we don't need to pull in the grpc client.
+// Every key under the prefix holds one base URL, e.g.
+// /services/driver-location-service/pod-1 = http://10.0.0.12:8087
+type EtcdSource struct {
+	endpoint   string
+	key        string
+	httpClient *http.Client
+}
+
+type etcdRangeResponse struct {
+	Kvs []struct {
+		Value string `json:"value"`
+	} `json:"kvs"`
+}
+
+func NewEtcdSource(endpoint, key string) *EtcdSource {
+	return &EtcdSource{
+		endpoint:   strings.TrimSuffix(endpoint, "/"),
+		key:        key,
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func (s *EtcdSource) Lookup(ctx context.Context) ([]string, error) {
+	body, err := json.Marshal(map[string]string{
+		"key":       base64.StdEncoding.EncodeToString([]byte(s.key)),
+		"range_end": base64.StdEncoding.EncodeToString(prefixRangeEnd(s.key)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint+"/v3/kv/range", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("etcd lookup failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("etcd lookup failed: unexpected status %d", resp.StatusCode)
+	}
+
+	var rangeResp etcdRangeResponse
+	if err := json.NewDecoder(resp.Body).Decode(&rangeResp); err != nil {
+		return nil, fmt.Errorf("failed to decode etcd response: %w", err)
+	}
+
+	endpoints := make([]string, 0, len(rangeResp.Kvs))
+	for _, kv := range rangeResp.Kvs {
+		value, err := base64.StdEncoding.DecodeString(kv.Value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode etcd value: %w", err)
+		}
+		if v := strings.TrimSpace(string(value)); v != "" {
+			endpoints = append(endpoints, v)
+		}
+	}
+	return endpoints, nil
+}
+
+// prefixRangeEnd returns the range end that matches every key with the given prefix
+func prefixRangeEnd(prefix string) []byte {
+	end := []byte(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return []byte{0}
+}
@@ -0,0 +1,144 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"the-matching-service/config"
+	"the-matching-service/internal/ports/secondary"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// refreshTimeout bounds a lookup refreshing expired endpoints, it runs after
+// the request that noticed them expired has moved on
+const refreshTimeout = 5 * time.Second
+
+// Source looks up the current base URLs of a service
+type Source interface {
+	Lookup(ctx context.Context) ([]string, error)
+}
+
+// Resolver caches the endpoints returned by a Source for the refresh interval
+// and hands them out round robin. A failed call invalidates the cache so the
+// next request re-resolves instead of hammering a dead instance. Concurrent
+// requests share one lookup, and expired endpoints keep being served while
+// they are looked up again in the background.
+type Resolver struct {
+	source          Source
+	refreshInterval time.Duration
+	lookups         singleflight.Group
+
+	mu        sync.Mutex
+	endpoints []string
+	resolved  time.Time
+	next      int
+}
+
+var _ secondary.ServiceResolver = (*Resolver)(nil)
+
+func NewResolver(source Source, refreshInterval time.Duration) *Resolver {
+	return &Resolver{
+		source:          source,
+		refreshInterval: refreshInterval,
+	}
+}
+
+// NewStaticResolver always resolves to the given base URL
+func NewStaticResolver(baseURL string) *Resolver {
+	return NewResolver(StaticSource{BaseURL: baseURL}, 0)
+}
+
+// NewResolverFromConfig builds the resolver selected by DISCOVERY_MODE
+func NewResolverFromConfig(cfg *config.Config) (*Resolver, error) {
+	d := cfg.Discovery
+	switch d.Mode {
+	case "", "static":
+		return NewStaticResolver(cfg.DriverLocationBaseURL), nil
+	case "dns":
+		return NewResolver(NewDNSSource(d.ServiceName, d.Scheme), d.RefreshInterval), nil
+	case "consul":
+		return NewResolver(NewConsulSource(d.ConsulAddress, d.ServiceName, d.Scheme), d.RefreshInterval), nil
+	case "etcd":
+		return NewResolver(NewEtcdSource(d.EtcdEndpoint, d.EtcdKey), d.RefreshInterval), nil
+	default:
+		return nil, fmt.Errorf("unknown discovery mode: %s", d.Mode)
+	}
+}
+
+func (r *Resolver) Resolve(ctx context.Context) (string, error) {
+	r.mu.Lock()
+	if len(r.endpoints) > 0 {
+		if r.expired() {
+			refreshCtx := context.WithoutCancel(ctx)
+			r.lookups.DoChan("", func() (interface{}, error) {
+				lookupCtx, cancel := context.WithTimeout(refreshCtx, refreshTimeout)
+				defer cancel()
+				return r.lookup(lookupCtx)
+			})
+		}
+		defer r.mu.Unlock()
+		return r.pick(r.endpoints), nil
+	}
+	r.mu.Unlock()
+
+	endpoints, err, _ := r.lookups.Do("", func() (interface{}, error) {
+		return r.lookup(ctx)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.pick(endpoints.([]string)), nil
+}
+
+// lookup asks the source for the endpoints and caches them, it runs without
+// holding the lock so a slow source does not block requests served from the cache
+func (r *Resolver) lookup(ctx context.Context) ([]string, error) {
+	endpoints, err := r.source.Lookup(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve service address: %w", err)
+	}
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("failed to resolve service address: no endpoints found")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.endpoints = endpoints
+	r.resolved = time.Now()
+	r.next = 0
+	return endpoints, nil
+}
+
+func (r *Resolver) pick(endpoints []string) string {
+	endpoint := endpoints[r.next%len(endpoints)]
+	r.next++
+	return endpoint
+}
+
+func (r *Resolver) Invalidate() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.endpoints = nil
+}
+
+func (r *Resolver) expired() bool {
+	return r.refreshInterval > 0 && time.Since(r.resolved) > r.refreshInterval
+}
+
+// StaticSource returns a fixed base URL
+type StaticSource struct {
+	BaseURL string
+}
+
+func (s StaticSource) Lookup(ctx context.Context) ([]string, error) {
+	if s.BaseURL == "" {
+		return nil, fmt.Errorf("base URL is not configured")
+	}
+	return []string{s.BaseURL}, nil
+}
@@ -0,0 +1,195 @@
+package discovery
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"the-matching-service/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type countingSource struct {
+	endpoints []string
+	err       error
+	calls     int
+}
+
+func (s *countingSource) Lookup(ctx context.Context) ([]string, error) {
+	s.calls++
+	return s.endpoints, s.err
+}
+
+// TestStaticResolver_Resolve tests resolving a static base URL
+// Expected: Should always return the configured base URL
+func TestStaticResolver_Resolve(t *testing.T) {
+	resolver := NewStaticResolver("http://localhost:8087")
+
+	addr, err := resolver.Resolve(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "http://localhost:8087", addr)
+}
+
+// TestResolver_CachesAndRoundRobins tests endpoint caching and round robin selection
+// Expected: Should look the endpoints up once and rotate through them
+func TestResolver_CachesAndRoundRobins(t *testing.T) {
+	source := &countingSource{endpoints: []string{"http://a:8087", "http://b:8087"}}
+	resolver := NewResolver(source, time.Minute)
+
+	first, _ := resolver.Resolve(context.Background())
+	second, _ := resolver.Resolve(context.Background())
+	third, _ := resolver.Resolve(context.Background())
+
+	assert.Equal(t, "http://a:8087", first)
+	assert.Equal(t, "http://b:8087", second)
+	assert.Equal(t, "http://a:8087", third)
+	assert.Equal(t, 1, source.calls)
+}
+
+// TestResolver_InvalidateReResolves tests re-resolution after a failed call
+// Expected: Should look the endpoints up again after Invalidate
+func TestResolver_InvalidateReResolves(t *testing.T) {
+	source := &countingSource{endpoints: []string{"http://a:8087"}}
+	resolver := NewResolver(source, time.Minute)
+
+	_, err := resolver.Resolve(context.Background())
+	require.NoError(t, err)
+	resolver.Invalidate()
+	_, err = resolver.Resolve(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, source.calls)
+}
+
+// TestResolver_LookupError tests resolution when the source fails or returns nothing
+// Expected: Should return an error in both cases
+func TestResolver_LookupError(t *testing.T) {
+	_, err := NewResolver(&countingSource{err: errors.New("boom")}, time.Minute).Resolve(context.Background())
+	assert.Error(t, err)
+
+	_, err = NewResolver(&countingSource{}, time.Minute).Resolve(context.Background())
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "no endpoints found")
+}
+
+// blockingSource returns its endpoints once release is closed
+type blockingSource struct {
+	endpoints []string
+	release   chan struct{}
+	calls     atomic.Int32
+}
+
+func (s *blockingSource) Lookup(ctx context.Context) ([]string, error) {
+	s.calls.Add(1)
+	<-s.release
+	return s.endpoints, nil
+}
+
+// TestResolver_ConcurrentLookup tests concurrent resolution with an empty cache and a slow source
+// Expected: Should look the endpoints up once for every waiting request
+func TestResolver_ConcurrentLookup(t *testing.T) {
+	source := &blockingSource{endpoints: []string{"http://a:8087"}, release: make(chan struct{})}
+	resolver := NewResolver(source, time.Minute)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			addr, err := resolver.Resolve(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, "http://a:8087", addr)
+		}()
+	}
+	require.Eventually(t, func() bool { return source.calls.Load() == 1 }, time.Second, time.Millisecond)
+	close(source.release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), source.calls.Load())
+}
+
+// TestResolver_ServesExpiredWhileRefreshing tests resolution while the lookup refreshing expired endpoints hangs
+// Expected: Should keep returning the expired endpoints right away and switch once the lookup returns
+func TestResolver_ServesExpiredWhileRefreshing(t *testing.T) {
+	source := &blockingSource{endpoints: []string{"http://b:8087"}, release: make(chan struct{})}
+	resolver := NewResolver(source, time.Minute)
+	resolver.endpoints = []string{"http://a:8087"}
+	resolver.resolved = time.Now().Add(-2 * time.Minute)
+
+	for i := 0; i < 3; i++ {
+		addr, err := resolver.Resolve(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "http://a:8087", addr)
+	}
+	require.Eventually(t, func() bool { return source.calls.Load() == 1 }, time.Second, time.Millisecond)
+
+	close(source.release)
+	require.Eventually(t, func() bool {
+		addr, _ := resolver.Resolve(context.Background())
+		return addr == "http://b:8087"
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, int32(1), source.calls.Load())
+}
+
+// TestConsulSource_Lookup tests resolving passing instances from Consul
+// Expected: Should build base URLs from service addresses, falling back to node addresses
+func TestConsulSource_Lookup(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/health/service/driver-location-service", r.URL.Path)
+		assert.Equal(t, "true", r.URL.Query().Get("passing"))
+		w.Write([]byte(`[
+			{"Node": {"Address": "10.0.0.1"}, "Service": {"Address": "10.0.1.1", "Port": 8087}},
+			{"Node": {"Address": "10.0.0.2"}, "Service": {"Address": "", "Port": 8088}}
+		]`))
+	}))
+	defer ts.Close()
+
+	endpoints, err := NewConsulSource(ts.URL, "driver-location-service", "http").Lookup(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"http://10.0.1.1:8087", "http://10.0.0.2:8088"}, endpoints)
+}
+
+// TestEtcdSource_Lookup tests resolving base URLs stored under an etcd prefix
+// Expected: Should decode every value under the prefix
+func TestEtcdSource_Lookup(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v3/kv/range", r.URL.Path)
+		var body map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		key, _ := base64.StdEncoding.DecodeString(body["key"])
+		assert.Equal(t, "/services/driver-location-service", string(key))
+
+		value := base64.StdEncoding.EncodeToString([]byte("http://10.0.0.12:8087"))
+		w.Write([]byte(`{"kvs": [{"value": "` + value + `"}]}`))
+	}))
+	defer ts.Close()
+
+	endpoints, err := NewEtcdSource(ts.URL, "/services/driver-location-service").Lookup(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"http://10.0.0.12:8087"}, endpoints)
+}
+
+// TestNewResolverFromConfig tests resolver selection by discovery mode
+// Expected: Should build static resolvers by default and reject unknown modes
+func TestNewResolverFromConfig(t *testing.T) {
+	cfg := &config.Config{DriverLocationBaseURL: "http://localhost:8087"}
+	resolver, err := NewResolverFromConfig(cfg)
+	require.NoError(t, err)
+	addr, err := resolver.Resolve(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "http://localhost:8087", addr)
+
+	cfg.Discovery.Mode = "zookeeper"
+	_, err = NewResolverFromConfig(cfg)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown discovery mode")
+}
@@ -0,0 +1,71 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"the-matching-service/internal/domain"
+	"the-matching-service/internal/ports/secondary"
+
+	"github.com/segmentio/kafka-go"
+)
+
+type messageReader interface {
+	ReadMessage(ctx context.Context) (kafka.Message, error)
+	Close() error
+}
+
+// KafkaDriverEventConsumer reads the driver events the driver location service
+// publishes. Every instance keeps its own match queue, so every instance needs
+// all events: the group ID must be unique per instance and a new group starts at
+// the latest events, older ones cannot free a driver anymore.
+type KafkaDriverEventConsumer struct {
+	reader messageReader
+	handle func(ctx context.Context, event domain.DriverEvent)
+	logger secondary.Logger
+}
+
+func NewKafkaDriverEventConsumer(brokers []string, topic, groupID string, handle func(ctx context.Context, event domain.DriverEvent)) *KafkaDriverEventConsumer {
+	return &KafkaDriverEventConsumer{
+		reader: kafka.NewReader(kafka.ReaderConfig{
+			Brokers:     brokers,
+			Topic:       topic,
+			GroupID:     groupID,
+			StartOffset: kafka.LastOffset,
+		}),
+		handle: handle,
+		logger: secondary.NopLogger{},
+	}
+}
+
+// SetLogger sets where skipped events are logged
+func (c *KafkaDriverEventConsumer) SetLogger(logger secondary.Logger) {
+	c.logger = logger
+}
+
+// Run hands the events to the handler one at a time until ctx is done, malformed
+// events are logged and skipped
+func (c *KafkaDriverEventConsumer) Run(ctx context.Context) error {
+	for {
+		message, err := c.reader.ReadMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, context.Canceled) {
+				return nil
+			}
+			return fmt.Errorf("failed to read driver events: %w", err)
+		}
+
+		var event domain.DriverEvent
+		if err := json.Unmarshal(message.Value, &event); err != nil {
+			c.logger.Warn(ctx, "skipping malformed driver event", "offset", message.Offset, "error", err)
+			continue
+		}
+		c.handle(ctx, event)
+	}
+}
+
+func (c *KafkaDriverEventConsumer) Close() error {
+	return c.reader.Close()
+}
@@ -0,0 +1,79 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"the-matching-service/internal/domain"
+	"the-matching-service/internal/ports/secondary"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptedReader hands out its messages and then the error, or waits for ctx
+type scriptedReader struct {
+	messages []kafka.Message
+	err      error
+}
+
+func (r *scriptedReader) ReadMessage(ctx context.Context) (kafka.Message, error) {
+	if len(r.messages) > 0 {
+		message := r.messages[0]
+		r.messages = r.messages[1:]
+		return message, nil
+	}
+	if r.err != nil {
+		return kafka.Message{}, r.err
+	}
+	<-ctx.Done()
+	return kafka.Message{}, ctx.Err()
+}
+
+func (r *scriptedReader) Close() error { return nil }
+
+// TestKafkaDriverEventConsumer_Run tests decoding the driver events read from Kafka
+// Expected: Should hand the decoded events to the handler, skip malformed ones and stop without error once ctx is done
+func TestKafkaDriverEventConsumer_Run(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var events []domain.DriverEvent
+	consumer := &KafkaDriverEventConsumer{
+		reader: &scriptedReader{messages: []kafka.Message{
+			{Value: []byte(`{"type":"driver.status_changed","driver_id":"d1","status":"available","location":{"type":"Point","coordinates":[28.9,41.0]},"occurred_at":"2025-01-02T03:04:05Z"}`)},
+			{Value: []byte(`not json`)},
+			{Value: []byte(`{"type":"driver.deleted","driver_id":"d2"}`)},
+		}},
+		handle: func(ctx context.Context, event domain.DriverEvent) {
+			events = append(events, event)
+			if len(events) == 2 {
+				cancel()
+			}
+		},
+		logger: secondary.NopLogger{},
+	}
+
+	require.NoError(t, consumer.Run(ctx))
+	require.Len(t, events, 2)
+	assert.Equal(t, domain.DriverStatusChanged, events[0].Type)
+	assert.Equal(t, "d1", events[0].DriverID)
+	assert.Equal(t, "available", events[0].Status)
+	require.NotNil(t, events[0].Location)
+	assert.Equal(t, [2]float64{28.9, 41.0}, events[0].Location.Coordinates)
+	assert.True(t, events[0].MayFreeDriver())
+	assert.Equal(t, "driver.deleted", events[1].Type)
+	assert.False(t, events[1].MayFreeDriver())
+}
+
+// TestKafkaDriverEventConsumer_Run_Error tests a failing read
+// Expected: Should return the reader error
+func TestKafkaDriverEventConsumer_Run_Error(t *testing.T) {
+	consumer := &KafkaDriverEventConsumer{
+		reader: &scriptedReader{err: errors.New("broker down")},
+		handle: func(ctx context.Context, event domain.DriverEvent) {},
+		logger: secondary.NopLogger{},
+	}
+
+	assert.ErrorContains(t, consumer.Run(context.Background()), "broker down")
+}
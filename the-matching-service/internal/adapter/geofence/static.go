@@ -0,0 +1,148 @@
+package geofence
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"the-matching-service/internal/domain"
+	"the-matching-service/internal/ports/secondary"
+)
+
+// polygon is an outer ring followed by its holes, positions are [longitude, latitude]
+type polygon [][][2]float64
+
+// StaticGeofence holds the service area loaded from a GeoJSON file, the area is
+// small enough to test every polygon and changes with a deploy
+type StaticGeofence struct {
+	polygons []polygon
+}
+
+var _ secondary.Geofence = (*StaticGeofence)(nil)
+
+// geoJSON covers the objects a service area file may hold: a Polygon or
+// MultiPolygon geometry, a Feature of one or a FeatureCollection of them
+type geoJSON struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+	Geometry    *geoJSON        `json:"geometry"`
+	Features    []geoJSON       `json:"features"`
+}
+
+// Load reads the service area from a GeoJSON file
+func Load(path string) (*StaticGeofence, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service area: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse reads the service area from GeoJSON, it needs at least one polygon
+func Parse(data []byte) (*StaticGeofence, error) {
+	var object geoJSON
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, fmt.Errorf("invalid service area: %w", err)
+	}
+	polygons, err := collectPolygons(object)
+	if err != nil {
+		return nil, fmt.Errorf("invalid service area: %w", err)
+	}
+	if len(polygons) == 0 {
+		return nil, fmt.Errorf("invalid service area: no polygons")
+	}
+	return &StaticGeofence{polygons: polygons}, nil
+}
+
+func collectPolygons(object geoJSON) ([]polygon, error) {
+	switch object.Type {
+	case "FeatureCollection":
+		var polygons []polygon
+		for _, feature := range object.Features {
+			featurePolygons, err := collectPolygons(feature)
+			if err != nil {
+				return nil, err
+			}
+			polygons = append(polygons, featurePolygons...)
+		}
+		return polygons, nil
+	case "Feature":
+		if object.Geometry == nil {
+			return nil, nil
+		}
+		return collectPolygons(*object.Geometry)
+	case "Polygon":
+		var p polygon
+		if err := json.Unmarshal(object.Coordinates, &p); err != nil {
+			return nil, fmt.Errorf("invalid Polygon coordinates: %w", err)
+		}
+		return []polygon{p}, validate(p)
+	case "MultiPolygon":
+		var polygons []polygon
+		if err := json.Unmarshal(object.Coordinates, &polygons); err != nil {
+			return nil, fmt.Errorf("invalid MultiPolygon coordinates: %w", err)
+		}
+		for _, p := range polygons {
+			if err := validate(p); err != nil {
+				return nil, err
+			}
+		}
+		return polygons, nil
+	default:
+		return nil, fmt.Errorf("unsupported GeoJSON type %q, use Polygon or MultiPolygon", object.Type)
+	}
+}
+
+// validate checks the rings the same way the driver location service checks
+// area searches: closed and at least 4 positions
+func validate(p polygon) error {
+	if len(p) == 0 {
+		return fmt.Errorf("polygon without rings")
+	}
+	for _, ring := range p {
+		if len(ring) < 4 {
+			return fmt.Errorf("ring with %d positions, at least 4 are required", len(ring))
+		}
+		if ring[0] != ring[len(ring)-1] {
+			return fmt.Errorf("ring is not closed, the first and last positions must be equal")
+		}
+	}
+	return nil
+}
+
+// Serves reports whether the location is inside one of the polygons and outside
+// its holes
+func (g *StaticGeofence) Serves(_ context.Context, location domain.Location) (bool, error) {
+	point := location.Coordinates
+	for _, p := range g.polygons {
+		if !inRing(point, p[0]) {
+			continue
+		}
+		inHole := false
+		for _, hole := range p[1:] {
+			if inRing(point, hole) {
+				inHole = true
+				break
+			}
+		}
+		if !inHole {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// inRing casts a ray along the latitude of the point and counts the edges it
+// crosses, service areas are small enough to treat degrees as planar
+func inRing(point [2]float64, ring [][2]float64) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		a, b := ring[i], ring[j]
+		if (a[1] > point[1]) != (b[1] > point[1]) &&
+			point[0] < (b[0]-a[0])*(point[1]-a[1])/(b[1]-a[1])+a[0] {
+			inside = !inside
+		}
+	}
+	return inside
+}
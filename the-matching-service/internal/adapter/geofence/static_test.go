@@ -0,0 +1,84 @@
+package geofence
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"the-matching-service/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// istanbul with a hole over the Bosphorus and a second polygon around Ankara
+const serviceArea = `{
+	"type": "FeatureCollection",
+	"features": [
+		{"type": "Feature", "properties": {"name": "istanbul"}, "geometry": {
+			"type": "Polygon",
+			"coordinates": [
+				[[28.5, 40.8], [29.5, 40.8], [29.5, 41.3], [28.5, 41.3], [28.5, 40.8]],
+				[[29.0, 41.0], [29.05, 41.0], [29.05, 41.1], [29.0, 41.1], [29.0, 41.0]]
+			]
+		}},
+		{"type": "Feature", "properties": {"name": "ankara"}, "geometry": {
+			"type": "MultiPolygon",
+			"coordinates": [[[[32.5, 39.7], [33.1, 39.7], [33.1, 40.1], [32.5, 40.1], [32.5, 39.7]]]]
+		}}
+	]
+}`
+
+func point(lon, lat float64) domain.Location {
+	return domain.Location{Type: "Point", Coordinates: [2]float64{lon, lat}}
+}
+
+// TestStaticGeofence_Serves tests locations inside, outside and in a hole of the service area
+// Expected: Should serve locations inside any polygon and outside its holes only
+func TestStaticGeofence_Serves(t *testing.T) {
+	geofence, err := Parse([]byte(serviceArea))
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name     string
+		location domain.Location
+		serves   bool
+	}{
+		{name: "istanbul", location: point(28.9, 41.0), serves: true},
+		{name: "bosphorus hole", location: point(29.02, 41.05), serves: false},
+		{name: "ankara", location: point(32.85, 39.93), serves: true},
+		{name: "black sea", location: point(30.0, 42.5), serves: false},
+		{name: "null island", location: point(0, 0), serves: false},
+	} {
+		serves, err := geofence.Serves(context.Background(), tc.location)
+		assert.NoError(t, err, tc.name)
+		assert.Equal(t, tc.serves, serves, tc.name)
+	}
+}
+
+// TestLoad tests loading the service area from a file
+// Expected: Should load a valid file and reject missing files and invalid areas
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "service-area.geojson")
+	require.NoError(t, os.WriteFile(path, []byte(serviceArea), 0o600))
+
+	geofence, err := Load(path)
+	require.NoError(t, err)
+	assert.Len(t, geofence.polygons, 2)
+
+	_, err = Load(filepath.Join(dir, "missing.geojson"))
+	assert.Error(t, err)
+
+	for _, invalid := range []string{
+		`{"type": "Point", "coordinates": [28.9, 41.0]}`,
+		`{"type": "Polygon", "coordinates": [[[28.5, 40.8], [29.5, 40.8], [28.5, 40.8]]]}`,
+		`{"type": "Polygon", "coordinates": [[[28.5, 40.8], [29.5, 40.8], [29.5, 41.3], [28.5, 41.3]]]}`,
+		`{"type": "FeatureCollection", "features": []}`,
+		`{"type": `,
+	} {
+		_, err := Parse([]byte(invalid))
+		assert.Error(t, err, invalid)
+	}
+}
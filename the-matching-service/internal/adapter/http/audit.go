@@ -0,0 +1,63 @@
+package httpadapter
+
+import (
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"the-matching-service/config"
+	"the-matching-service/internal/domain"
+
+	"github.com/labstack/echo/v4"
+)
+
+// NewAuditLogger returns the logger match audits are written to, one JSON line
+// per request on stdout
+func NewAuditLogger() *slog.Logger {
+	return slog.New(slog.NewJSONHandler(os.Stdout, nil))
+}
+
+// NewPrivacy returns the privacy policy of the logs and the match audits, rider
+// IDs are hashed with the key of the hash sent to the driver location service
+func NewPrivacy(cfg *config.Config) domain.Privacy {
+	return domain.Privacy{
+		CoordinateDecimals: cfg.Privacy.CoordinateDecimals,
+		HashRiderIDs:       cfg.Privacy.HashRiderIDs,
+		HashKey:            cfg.EndUserHashKey,
+	}
+}
+
+// MatchAuditLog threads a domain.MatchAudit through the match request and logs
+// it as a single "match audit" event once the response is written, so a match
+// can be debugged from its log line alone: the rider cell, every upstream search
+// with its timing and correlation ID, and the decision of the strategy. The
+// duration is observed by area, radius band, strategy and outcome as well. The
+// rider ID and cell are written as the privacy policy allows.
+func MatchAuditLog(logger *slog.Logger, privacy domain.Privacy) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			audit := domain.NewMatchAudit(time.Now())
+			req := c.Request()
+			c.SetRequest(req.WithContext(domain.WithMatchAudit(req.Context(), audit)))
+
+			err := next(c)
+			if err != nil {
+				// written by the error handler now so the status is known
+				c.Error(err)
+			}
+
+			event := privacy.Audit(audit.Finish(c.Response().Status, time.Now()))
+			if event.Area != "" {
+				matchDurationSeconds.WithLabelValues(event.Area, event.RadiusBand, event.Strategy, event.Outcome).
+					Observe(event.DurationMs / 1000)
+			}
+			level := slog.LevelInfo
+			if event.Status >= http.StatusInternalServerError {
+				level = slog.LevelError
+			}
+			logger.LogAttrs(req.Context(), level, "match audit", slog.Any("match", event))
+			return nil
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package httpadapter
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"the-matching-service/internal/domain"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMatchAuditLog tests the audit middleware around a match that failed upstream
+// Expected: Should log one JSON event at error level with what the handler recorded and the response status
+func TestMatchAuditLog(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	e := echo.New()
+	e.POST("/match", func(c echo.Context) error {
+		audit := domain.MatchAuditFrom(c.Request().Context())
+		audit.RecordUpstream(domain.UpstreamCall{Operation: OperationSearch, Radius: 500, Status: 503, CorrelationID: "corr-1", Error: "unavailable"})
+		audit.SetOutcome("upstream_unavailable", nil)
+		return c.JSON(http.StatusBadGateway, map[string]string{"error": "upstream_unavailable"})
+	}, MatchAuditLog(logger, domain.DefaultPrivacy()))
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/match", nil))
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+
+	var line struct {
+		Level string            `json:"level"`
+		Msg   string            `json:"msg"`
+		Match domain.AuditEvent `json:"match"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	assert.Equal(t, "ERROR", line.Level)
+	assert.Equal(t, "match audit", line.Msg)
+	assert.Equal(t, http.StatusBadGateway, line.Match.Status)
+	assert.Equal(t, "upstream_unavailable", line.Match.Outcome)
+	require.Len(t, line.Match.Upstream, 1)
+	assert.Equal(t, "corr-1", line.Match.Upstream[0].CorrelationID)
+}
+
+// TestMatchAuditLog_DurationByArea tests the match duration metric of an audited match
+// Expected: Should observe the request under its coarse area, radius band, strategy and outcome
+func TestMatchAuditLog_DurationByArea(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	rider := domain.Rider{ID: "rider-1", Location: domain.Location{Type: "Point", Coordinates: [2]float64{28.9784, 41.0082}}}
+
+	e := echo.New()
+	e.POST("/match", func(c echo.Context) error {
+		audit := domain.MatchAuditFrom(c.Request().Context())
+		audit.SetRequest(rider, 2500, 5, "area-test")
+		audit.SetOutcome("matched", nil)
+		return c.NoContent(http.StatusOK)
+	}, MatchAuditLog(logger, domain.DefaultPrivacy()))
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/match", nil))
+
+	var metric dto.Metric
+	require.NoError(t, matchDurationSeconds.WithLabelValues("sxk", "3000", "area-test", "matched").(prometheus.Histogram).Write(&metric))
+	assert.Equal(t, uint64(1), metric.GetHistogram().GetSampleCount())
+}
+
+// TestMatchAuditLog_HandlerError tests the audit middleware around a handler returning an error
+// Expected: Should log the status the error handler wrote at error level
+func TestMatchAuditLog_HandlerError(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	e := echo.New()
+	e.POST("/match", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusInternalServerError, "boom")
+	}, MatchAuditLog(logger, domain.DefaultPrivacy()))
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/match", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, 1, bytes.Count(buf.Bytes(), []byte("\n")))
+	assert.Contains(t, buf.String(), `"level":"ERROR"`)
+	assert.Contains(t, buf.String(), `"status":500`)
+}
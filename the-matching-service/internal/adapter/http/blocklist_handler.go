@@ -0,0 +1,137 @@
+package httpadapter
+
+import (
+	"errors"
+	"net/http"
+
+	"the-matching-service/internal/application"
+	"the-matching-service/internal/domain"
+
+	"github.com/labstack/echo/v4"
+)
+
+type BlocklistHandler struct {
+	blocklistService *application.BlocklistService
+}
+
+func NewBlocklistHandler(blocklistService *application.BlocklistService) *BlocklistHandler {
+	return &BlocklistHandler{blocklistService: blocklistService}
+}
+
+// ListBlockedDrivers godoc
+// @Summary List blocked drivers of a rider
+// @Description Get the drivers that are never matched with the rider, the most recently blocked first
+// @Tags admin
+// @Produce json
+// @Param rider_id path string true "Rider ID"
+// @Success 200 {object} domain.SuccessResponse "Success: data contains the blocked pairs"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized - Invalid API key"
+// @Failure 403 {object} domain.ErrorResponse "Forbidden - a JWT without the admin role"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Security AdminAPIKey
+// @Router /admin/riders/{rider_id}/blocked-drivers [get]
+func (h *BlocklistHandler) ListBlockedDrivers(c echo.Context) error {
+	pairs, err := h.blocklistService.BlockedDrivers(c.Request().Context(), c.Param("rider_id"))
+	if err != nil {
+		return blocklistErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, domain.SuccessResponse{
+		Success: true,
+		Data:    pairs,
+		Message: "Blocked drivers retrieved successfully",
+	})
+}
+
+// BlockDriver godoc
+// @Summary Block a driver for a rider
+// @Description Never match the driver with the rider again, e.g. after a complaint
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param rider_id path string true "Rider ID"
+// @Param request body domain.BlockDriverRequest true "Driver to block"
+// @Success 201 {object} domain.SuccessResponse "Success: data contains the blocked pair"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Validation error or invalid request"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized - Invalid API key"
+// @Failure 403 {object} domain.ErrorResponse "Forbidden - a JWT without the admin role"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Security AdminAPIKey
+// @Router /admin/riders/{rider_id}/blocked-drivers [post]
+func (h *BlocklistHandler) BlockDriver(c echo.Context) error {
+	var req domain.BlockDriverRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, domain.ErrorResponse{
+			Success: false,
+			Error:   "invalid_request",
+			Message: "Invalid request body",
+		})
+	}
+
+	if err := domain.ValidateStruct(&req); err != nil {
+		if validationErrors, ok := err.(*domain.ValidationErrors); ok {
+			return c.JSON(http.StatusBadRequest, domain.ErrorResponse{
+				Success: false,
+				Error:   "validation_error",
+				Message: "Request validation failed",
+				Details: validationErrors.Errors,
+			})
+		}
+		return c.JSON(http.StatusBadRequest, domain.ErrorResponse{
+			Success: false,
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+	}
+
+	pair, err := h.blocklistService.Block(c.Request().Context(), c.Param("rider_id"), req.DriverID, req.Reason)
+	if err != nil {
+		return blocklistErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, domain.SuccessResponse{
+		Success: true,
+		Data:    pair,
+		Message: "Driver blocked successfully",
+	})
+}
+
+// UnblockDriver godoc
+// @Summary Unblock a driver for a rider
+// @Description Allow matching the driver with the rider again
+// @Tags admin
+// @Produce json
+// @Param rider_id path string true "Rider ID"
+// @Param driver_id path string true "Driver ID"
+// @Success 200 {object} domain.SuccessResponse
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized - Invalid API key"
+// @Failure 403 {object} domain.ErrorResponse "Forbidden - a JWT without the admin role"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Security AdminAPIKey
+// @Router /admin/riders/{rider_id}/blocked-drivers/{driver_id} [delete]
+func (h *BlocklistHandler) UnblockDriver(c echo.Context) error {
+	if err := h.blocklistService.Unblock(c.Request().Context(), c.Param("rider_id"), c.Param("driver_id")); err != nil {
+		return blocklistErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, domain.SuccessResponse{
+		Success: true,
+		Message: "Driver unblocked successfully",
+	})
+}
+
+func blocklistErrorResponse(c echo.Context, err error) error {
+	if errors.Is(err, application.ErrInvalidBlockedPair) {
+		return c.JSON(http.StatusBadRequest, domain.ErrorResponse{
+			Success: false,
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusInternalServerError, domain.ErrorResponse{
+		Success: false,
+		Error:   "internal_error",
+		Message: err.Error(),
+	})
+}
@@ -0,0 +1,130 @@
+package httpadapter
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"the-matching-service/config"
+	"the-matching-service/internal/application"
+	"the-matching-service/internal/domain"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryBlocklist struct {
+	pairs map[string]domain.BlockedPair // keyed by rider|driver
+}
+
+func (b *memoryBlocklist) BlockedDrivers(ctx context.Context, riderID string) ([]domain.BlockedPair, error) {
+	var pairs []domain.BlockedPair
+	for _, pair := range b.pairs {
+		if pair.RiderID == riderID {
+			pairs = append(pairs, pair)
+		}
+	}
+	return pairs, nil
+}
+
+func (b *memoryBlocklist) Block(ctx context.Context, pair domain.BlockedPair) error {
+	b.pairs[pair.RiderID+"|"+pair.DriverID] = pair
+	return nil
+}
+
+func (b *memoryBlocklist) Unblock(ctx context.Context, riderID, driverID string) error {
+	delete(b.pairs, riderID+"|"+driverID)
+	return nil
+}
+
+func newBlocklistTestServer() *echo.Echo {
+	// NewRouter registers the prometheus middleware, which can only happen once per process
+	router := &Router{echo: echo.New(), config: &config.Config{AdminAPIKey: "admin-key"}}
+	blocklist := &memoryBlocklist{pairs: map[string]domain.BlockedPair{}}
+	router.SetupBlocklistRoutes(NewBlocklistHandler(application.NewBlocklistService(blocklist)))
+	return router.GetEcho()
+}
+
+func serveAdmin(e *echo.Echo, method, path, body, apiKey string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	if apiKey != "" {
+		req.Header.Set("X-API-Key", apiKey)
+	}
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	return w
+}
+
+// TestBlocklistHandler_Lifecycle tests blocking, listing and unblocking a driver through the admin endpoints
+// Expected: Should return 201 for a block, list the blocked pair and drop it after the unblock
+func TestBlocklistHandler_Lifecycle(t *testing.T) {
+	e := newBlocklistTestServer()
+
+	w := serveAdmin(e, http.MethodPost, "/admin/riders/rider-1/blocked-drivers", `{"driver_id":"driver-1","reason":"complaint"}`, "admin-key")
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"driver_id":"driver-1"`)
+
+	w = serveAdmin(e, http.MethodGet, "/admin/riders/rider-1/blocked-drivers", "", "admin-key")
+	require.Equal(t, http.StatusOK, w.Code)
+	var listed struct {
+		Data []map[string]interface{} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listed))
+	require.Len(t, listed.Data, 1)
+	assert.Equal(t, "complaint", listed.Data[0]["reason"])
+
+	w = serveAdmin(e, http.MethodDelete, "/admin/riders/rider-1/blocked-drivers/driver-1", "", "admin-key")
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = serveAdmin(e, http.MethodGet, "/admin/riders/rider-1/blocked-drivers", "", "admin-key")
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listed))
+	assert.Empty(t, listed.Data)
+}
+
+// TestBlocklistHandler_Validation tests blocking without a driver ID
+// Expected: Should return 400 Bad Request with a validation error
+func TestBlocklistHandler_Validation(t *testing.T) {
+	e := newBlocklistTestServer()
+
+	w := serveAdmin(e, http.MethodPost, "/admin/riders/rider-1/blocked-drivers", `{"reason":"complaint"}`, "admin-key")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "validation_error")
+}
+
+// TestBlocklistHandler_Unauthorized tests the admin endpoints without a valid API key
+// Expected: Should return 401 Unauthorized
+func TestBlocklistHandler_Unauthorized(t *testing.T) {
+	e := newBlocklistTestServer()
+
+	assert.Equal(t, http.StatusUnauthorized, serveAdmin(e, http.MethodGet, "/admin/riders/rider-1/blocked-drivers", "", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, serveAdmin(e, http.MethodGet, "/admin/riders/rider-1/blocked-drivers", "", "wrong").Code)
+}
+
+// TestBlocklistHandler_AdminRole tests the admin endpoints with the JWT of an admin
+// Expected: Should accept a token with the admin role once scopes are enforced and refuse other users with 403
+func TestBlocklistHandler_AdminRole(t *testing.T) {
+	cfg := &config.Config{AdminAPIKey: "admin-key", JWTSecret: "testsecret"}
+	router := &Router{echo: echo.New(), config: cfg}
+	router.SetupBlocklistRoutes(NewBlocklistHandler(application.NewBlocklistService(&memoryBlocklist{pairs: map[string]domain.BlockedPair{}})))
+	serve := func(roles ...string) int {
+		token := generateJWT(cfg.JWTSecret, jwt.MapClaims{"user_id": "operator-1", "roles": roles})
+		req := httptest.NewRequest(http.MethodGet, "/admin/riders/rider-1/blocked-drivers", nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.GetEcho().ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, serve("admin"))
+
+	cfg.JWT.EnforceScopes = true
+	assert.Equal(t, http.StatusOK, serve("admin"))
+	assert.Equal(t, http.StatusForbidden, serve("rider"))
+	assert.Equal(t, http.StatusOK, serveAdmin(router.GetEcho(), http.MethodGet, "/admin/riders/rider-1/blocked-drivers", "", "admin-key").Code)
+}
@@ -0,0 +1,210 @@
+package httpadapter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"the-matching-service/internal/application"
+	"the-matching-service/internal/domain"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNormalizeJSONKeys tests renaming the keys of request bodies to the field names of the request type
+// Expected: Should rename camelCase and PascalCase field names and keep snake_case, unknown and attribute keys as sent
+func TestNormalizeJSONKeys(t *testing.T) {
+	tests := map[string]string{
+		`{"vehicle_type":"Sedan","radius":500}`:             `{"vehicle_type":"Sedan","radius":500}`,
+		`{"vehicleType":"sedan","MinCapacity":4}`:           `{"vehicle_type":"sedan","min_capacity":4}`,
+		`{"includeCandidates":true,"waitForIt":true}`:       `{"include_candidates":true,"waitForIt":true}`,
+		`{"minCapacity":2,"min_capacity":4}`:                `{"minCapacity":2,"min_capacity":4}`,
+		`{"location":{"type":"Point","coordinates":[1,2]}}`: `{"location":{"type":"Point","coordinates":[1,2]}}`,
+	}
+	shape := shapeOf(&domain.MatchRequest{})
+	for input, expected := range tests {
+		normalized, err := JSONCodec{}.normalizeJSONKeys([]byte(input), shape)
+		require.NoError(t, err, input)
+		assert.JSONEq(t, expected, string(normalized), input)
+	}
+
+	normalized, err := JSONCodec{}.normalizeJSONKeys([]byte(`{"vehicleType":"van","attributes":{"childSeat":"yes"}}`), shapeOf(&domain.Driver{}))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"vehicle_type":"van","attributes":{"childSeat":"yes"}}`, string(normalized))
+}
+
+// TestJSONCodec_DeserializeCamelCase tests decoding a camelCase match request
+// Expected: Should bind camelCase keys to the snake_case fields of the domain types
+func TestJSONCodec_DeserializeCamelCase(t *testing.T) {
+	e := echo.New()
+	e.JSONSerializer = JSONCodec{}
+	body := `{"Location":{"Type":"Point","Coordinates":[28.9,41.0]},"Radius":500}`
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	c := e.NewContext(req, httptest.NewRecorder())
+
+	var matchReq domain.MatchRequest
+	assert.NoError(t, c.Bind(&matchReq))
+	assert.Equal(t, 500.0, matchReq.Radius)
+	assert.Equal(t, "Point", matchReq.Location.Type)
+	assert.Equal(t, [2]float64{28.9, 41.0}, matchReq.Location.Coordinates)
+}
+
+// TestJSONCodec_Engines tests the JSON engines against each other
+// Expected: Should produce the same response bytes and reject malformed bodies with 400
+func TestJSONCodec_Engines(t *testing.T) {
+	response := domain.SuccessResponse{
+		Success: true,
+		Data: domain.MatchResult{
+			ID:        "m1",
+			RiderID:   "rider-1",
+			DriverID:  "driver-1",
+			Distance:  123.45,
+			Strategy:  "nearest",
+			MatchedAt: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		},
+		Message: "Matched successfully",
+	}
+
+	var outputs []string
+	for _, engine := range []string{JSONEngineStd, JSONEngineJSONIter} {
+		codec, err := NewJSONCodec(engine)
+		require.NoError(t, err)
+
+		e := echo.New()
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+		require.NoError(t, codec.Serialize(c, response, ""))
+		outputs = append(outputs, rec.Body.String())
+
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"radius": "far"}`))
+		c = e.NewContext(req, httptest.NewRecorder())
+		var matchReq domain.MatchRequest
+		var httpErr *echo.HTTPError
+		require.True(t, errors.As(codec.Deserialize(c, &matchReq), &httpErr), engine)
+		assert.Equal(t, http.StatusBadRequest, httpErr.Code, engine)
+
+		req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"vehicleType": "sedan", "raduis": 500}`))
+		c = e.NewContext(req, httptest.NewRecorder())
+		c.Set(strictJSONKey, true)
+		var unknown *UnknownFieldError
+		require.True(t, errors.As(codec.Deserialize(c, &matchReq), &unknown), engine)
+		assert.Equal(t, "raduis", unknown.Field, engine)
+		assert.Equal(t, "sedan", matchReq.VehicleType, engine)
+	}
+	assert.Equal(t, outputs[0], outputs[1])
+	assert.Contains(t, outputs[0], `"matched_at":"2025-01-02T03:04:05Z"`)
+
+	_, err := NewJSONCodec("sonic")
+	assert.Error(t, err)
+}
+
+// BenchmarkMatchHandler measures the match handler throughput per JSON engine,
+// run with go test -bench MatchHandler ./internal/adapter/http/
+func BenchmarkMatchHandler(b *testing.B) {
+	body := `{"location": {"type": "Point", "coordinates": [28.9, 41.0]}, "radius": 500}`
+	// the match audit log would dominate the measurement
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	for _, engine := range []string{JSONEngineStd, JSONEngineJSONIter} {
+		b.Run(engine, func(b *testing.B) {
+			codec, err := NewJSONCodec(engine)
+			require.NoError(b, err)
+
+			e := echo.New()
+			e.JSONSerializer = codec
+			handler := NewMatchHandler(application.NewMatchingService(&mockDriverLocationServiceForHandler{}))
+			e.POST("/api/v1/match", handler.Match, func(next echo.HandlerFunc) echo.HandlerFunc {
+				return func(c echo.Context) error {
+					c.Set("is_authenticated", true)
+					c.Set("user_id", "")
+					return next(c)
+				}
+			})
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				req := httptest.NewRequest(http.MethodPost, "/api/v1/match", strings.NewReader(body))
+				req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+				rec := httptest.NewRecorder()
+				e.ServeHTTP(rec, req)
+				if rec.Code != http.StatusOK {
+					b.Fatalf("unexpected status %d: %s", rec.Code, rec.Body.String())
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkSearchResponseDecoding measures decoding a driver location search
+// response of 100 drivers per JSON engine
+func BenchmarkJSONCodec_Deserialize(b *testing.B) {
+	bodies := map[string]string{
+		"snake_case": `{"location": {"type": "Point", "coordinates": [28.9, 41.0]}, "radius": 500, "min_capacity": 4, "vehicle_type": "sedan"}`,
+		"camelCase":  `{"location": {"type": "Point", "coordinates": [28.9, 41.0]}, "radius": 500, "minCapacity": 4, "vehicleType": "sedan"}`,
+	}
+
+	for _, engine := range []string{JSONEngineStd, JSONEngineJSONIter} {
+		codec, err := NewJSONCodec(engine)
+		require.NoError(b, err)
+
+		for name, body := range bodies {
+			b.Run(engine+"/"+name, func(b *testing.B) {
+				e := echo.New()
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+					c := e.NewContext(req, httptest.NewRecorder())
+					c.Set(strictJSONKey, true)
+					var matchReq domain.MatchRequest
+					if err := codec.Deserialize(c, &matchReq); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkSearchResponseDecoding(b *testing.B) {
+	var drivers []string
+	for i := 0; i < 100; i++ {
+		drivers = append(drivers, fmt.Sprintf(`{"driver":{"id":"driver-%d","location":{"type":"Point","coordinates":[28.9,41.0]},"created_at":"2024-01-01T10:00:00Z","updated_at":"2024-01-01T10:05:00Z"},"distance":%d.5}`, i, i*10))
+	}
+	payload := []byte(`{"success":true,"data":{"count":100,"drivers":[` + strings.Join(drivers, ",") + `]}}`)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(payload)
+	}))
+	defer ts.Close()
+
+	location := domain.Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}}
+	for _, engine := range []string{JSONEngineStd, JSONEngineJSONIter} {
+		b.Run(engine, func(b *testing.B) {
+			codec, err := NewJSONCodec(engine)
+			require.NoError(b, err)
+			client := NewDriverLocationClient(ts.URL, "")
+			client.SetJSONCodec(codec)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := client.FindNearbyDrivers(context.Background(), location, 500, 0, domain.RiderPreferences{}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -3,108 +3,477 @@ package httpadapter
 import (
 	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 
+	"the-matching-service/internal/adapter/discovery"
 	"the-matching-service/internal/domain"
+	"the-matching-service/internal/ports/secondary"
+)
 
-	"github.com/sony/gobreaker"
+const (
+	requestIDHeader          = "X-Request-ID"
+	endUserHeader            = "X-End-User-Hash"
+	signatureHeader          = "X-Signature"
+	signatureTimestampHeader = "X-Signature-Timestamp"
 )
 
 type DriverLocationClient struct {
-	baseURL    string
+	resolver   secondary.ServiceResolver
 	httpClient *http.Client
-	breaker    *gobreaker.CircuitBreaker
+	operations map[string]*upstreamOperation
 	apiKey     string
+	secret     string // signs the requests instead of the API key when set
+	endUserKey string // keys the hash of the end user, SHA-256 when empty
+	codec      JSONCodec
 }
 
 func NewDriverLocationClient(baseURL, apiKey string) *DriverLocationClient {
-	cbSettings := gobreaker.Settings{
-		Name:        "DriverLocationService",
-		MaxRequests: 3,
-		Interval:    60 * time.Second,
-		Timeout:     10 * time.Second,
-	}
+	return NewDriverLocationClientWithResolver(discovery.NewStaticResolver(baseURL), apiKey)
+}
+
+// NewDriverLocationClientWithResolver creates a client that looks the driver
+// location service address up through service discovery on every call
+func NewDriverLocationClientWithResolver(resolver secondary.ServiceResolver, apiKey string) *DriverLocationClient {
 	return &DriverLocationClient{
-		baseURL:    baseURL,
+		resolver:   resolver,
 		httpClient: &http.Client{Timeout: 30 * time.Second},
-		breaker:    gobreaker.NewCircuitBreaker(cbSettings),
-		apiKey:     apiKey,
+		operations: map[string]*upstreamOperation{
+			OperationSearch:  newUpstreamOperation(OperationSearch, DefaultMaxConcurrentCalls),
+			OperationOutcome: newUpstreamOperation(OperationOutcome, DefaultMaxConcurrentCalls),
+		},
+		apiKey: apiKey,
+	}
+}
+
+// SetMaxConcurrentCalls sets the bulkhead size of an operation, it resets the
+// breaker of the operation and must be called before the client is used
+func (c *DriverLocationClient) SetMaxConcurrentCalls(operation string, limit int) {
+	c.operations[operation] = newUpstreamOperation(operation, limit)
+}
+
+// SetSigningSecret makes the client sign its requests with HMAC-SHA256 and the
+// secret shared with the driver location service instead of sending the API
+// key, so a captured request cannot be altered or replayed after a few minutes
+func (c *DriverLocationClient) SetSigningSecret(secret string) {
+	c.secret = secret
+}
+
+// SetEndUserHashKey keys the HMAC-SHA256 of the JWT subject sent in
+// X-End-User-Hash, so the driver location service can tell the calls of one
+// rider apart without being able to hash a known user ID to find theirs
+func (c *DriverLocationClient) SetEndUserHashKey(key string) {
+	c.endUserKey = key
+}
+
+// SetJSONCodec replaces the encoding/json codec of the search requests, their
+// responses and the error bodies of the driver location service
+func (c *DriverLocationClient) SetJSONCodec(codec JSONCodec) {
+	c.codec = codec
+}
+
+// SetTransport replaces the transport of the client, e.g. one from NewTransport
+// to go through an egress proxy, and must be called before the client is used
+func (c *DriverLocationClient) SetTransport(transport http.RoundTripper) {
+	c.httpClient.Transport = transport
+}
+
+// CheckHealth calls the health endpoint of the driver location service. It goes
+// around the breakers so a probe neither trips one nor is rejected by an open one.
+func (c *DriverLocationClient) CheckHealth(ctx context.Context) error {
+	baseURL, err := c.resolver.Resolve(ctx)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/health", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.resolver.Invalidate()
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// BreakerStates returns the circuit breaker state of every operation: closed,
+// half-open or open
+func (c *DriverLocationClient) BreakerStates() map[string]string {
+	states := make(map[string]string, len(c.operations))
+	for name, operation := range c.operations {
+		states[name] = operation.breaker.State().String()
 	}
+	return states
 }
 
-func (c *DriverLocationClient) FindNearbyDrivers(ctx context.Context, location domain.Location, radius float64) ([]domain.DriverDistancePair, error) {
+// ErrorRates returns the error rates of the calls of every operation
+func (c *DriverLocationClient) ErrorRates() map[string]map[string]ErrorRate {
+	rates := make(map[string]map[string]ErrorRate, len(c.operations))
+	for name, operation := range c.operations {
+		rates[name] = operation.errorRates.Rates()
+	}
+	return rates
+}
+
+// FindNearbyDrivers searches the driver location service, the call is recorded
+// in the audit of the match request
+func (c *DriverLocationClient) FindNearbyDrivers(ctx context.Context, location domain.Location, radius float64, limit int, preferences domain.RiderPreferences) ([]domain.DriverDistancePair, error) {
+	start := time.Now()
+	correlationID := correlationIDFor(ctx)
+	drivers, skipped, err := c.findNearbyDrivers(ctx, location, radius, limit, preferences, &correlationID)
+
+	call := domain.UpstreamCall{
+		Operation:     OperationSearch,
+		Radius:        radius,
+		DurationMs:    domain.Milliseconds(time.Since(start)),
+		Status:        http.StatusOK,
+		CorrelationID: correlationID,
+		Drivers:       len(drivers),
+		Skipped:       skipped,
+	}
+	if err != nil {
+		call.Status = 0
+		var upstreamErr *domain.UpstreamError
+		if errors.As(err, &upstreamErr) {
+			call.Status = upstreamErr.StatusCode
+		}
+		call.Error = err.Error()
+	}
+	domain.MatchAuditFrom(ctx).RecordUpstream(call)
+	return drivers, err
+}
+
+// findNearbyDrivers returns the usable drivers of a search and the number of
+// invalid ones it skipped
+func (c *DriverLocationClient) findNearbyDrivers(ctx context.Context, location domain.Location, radius float64, limit int, preferences domain.RiderPreferences, correlationID *string) ([]domain.DriverDistancePair, int, error) {
 	requestBody := map[string]interface{}{
 		"location": location,
 		"radius":   radius,
 	}
-	bodyBytes, err := json.Marshal(requestBody)
+	if limit > 0 {
+		requestBody["limit"] = limit
+	}
+	if preferences.VehicleType != "" {
+		requestBody["vehicle_type"] = preferences.VehicleType
+	}
+	if preferences.MinCapacity > 0 {
+		requestBody["min_capacity"] = preferences.MinCapacity
+	}
+	bodyBytes, err := c.codec.Marshal(requestBody)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	var resp *http.Response
-	result, err := c.breaker.Execute(func() (interface{}, error) {
-		req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/v1/drivers/search", bytes.NewReader(bodyBytes))
+	result, err := c.operations[OperationSearch].execute(ctx, correlationID, func() (interface{}, error) {
+		baseURL, err := c.resolver.Resolve(ctx)
+		if err != nil {
+			return nil, domain.NewUpstreamError(domain.UpstreamUnavailable, 0, *correlationID, err)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "POST", baseURL+"/api/v1/drivers/search", bytes.NewReader(bodyBytes))
 		if err != nil {
 			return nil, err
 		}
 		req.Header.Set("Content-Type", "application/json")
-		if c.apiKey != "" {
-			req.Header.Set("X-API-Key", c.apiKey)
-		}
+		req.Header.Set(requestIDHeader, *correlationID)
+		c.setEndUser(req)
+		c.authenticate(req, bodyBytes)
 
 		resp, err = c.httpClient.Do(req)
 		if err != nil {
-			return nil, err
+			// the instance may be gone, resolve the address again on the next call
+			c.resolver.Invalidate()
+			return nil, classifyTransportError(err, *correlationID)
+		}
+		if id := resp.Header.Get(requestIDHeader); id != "" {
+			*correlationID = id
 		}
 		if resp.StatusCode != http.StatusOK {
+			defer resp.Body.Close()
 			b, _ := io.ReadAll(resp.Body)
-			return nil, fmt.Errorf("unexpected status: %d, body: %s", resp.StatusCode, string(b))
+			return nil, c.classifyStatusError(resp.StatusCode, b, *correlationID)
 		}
 		return resp, nil
 	})
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	resp, ok := result.(*http.Response)
 	if !ok || resp == nil {
-		return nil, fmt.Errorf("invalid response type from circuit breaker")
+		return nil, 0, fmt.Errorf("invalid response type from circuit breaker")
 	}
 	defer resp.Body.Close()
 
-	var serviceResp domain.DriverLocationServiceResponse
-	if err := json.NewDecoder(resp.Body).Decode(&serviceResp); err != nil {
-		return nil, err
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, classifyTransportError(err, *correlationID)
+	}
+
+	// decoded straight into the domain types, the search response is the
+	// largest body the service handles
+	var serviceResp searchResponse[searchResult]
+	malformed := 0
+	if err := c.codec.Unmarshal(body, &serviceResp); err != nil {
+		// one malformed driver fails the whole body, decode the drivers one
+		// by one to keep the others
+		serviceResp, malformed = c.decodeDriverByDriver(body)
+		if serviceResp.Data == nil {
+			return nil, 0, domain.NewUpstreamError(domain.UpstreamUnavailable, resp.StatusCode, *correlationID,
+				fmt.Errorf("invalid response body: %w", err))
+		}
+		upstreamInvalidDriversTotal.WithLabelValues(invalidDriverMalformed).Add(float64(malformed))
 	}
 
 	if !serviceResp.Success {
-		return nil, fmt.Errorf("driver location service error: %s - %s", serviceResp.Error, serviceResp.Message)
+		err := fmt.Errorf("driver location service error: %s - %s", serviceResp.Error, serviceResp.Message)
+		if serviceResp.Error == "validation_error" || serviceResp.Error == "invalid_request" {
+			return nil, 0, domain.NewUpstreamError(domain.UpstreamValidation, resp.StatusCode, *correlationID, err)
+		}
+		return nil, 0, domain.NewUpstreamError(domain.UpstreamUnavailable, resp.StatusCode, *correlationID, err)
+	}
+
+	if serviceResp.Data == nil || serviceResp.Data.Drivers == nil {
+		return nil, 0, fmt.Errorf("invalid drivers data format from driver location service")
+	}
+
+	// drivers without an ID, a location or a distance, or with missing or
+	// inconsistent timestamps, are skipped here so nothing past the client has
+	// to deal with them
+	results := serviceResp.Data.Drivers
+	drivers := make([]domain.DriverDistancePair, 0, len(results))
+	var firstInvalid error
+	for _, result := range results {
+		reason, err := validateSearchResult(result)
+		if err != nil {
+			upstreamInvalidDriversTotal.WithLabelValues(reason).Inc()
+			if firstInvalid == nil {
+				firstInvalid = fmt.Errorf("invalid driver %q: %w", result.Driver.ID, err)
+			}
+			continue
+		}
+		drivers = append(drivers, domain.DriverDistancePair{Driver: result.Driver, Distance: *result.Distance})
 	}
+	skipped := malformed + len(results) - len(drivers)
 
-	data, ok := serviceResp.Data.(map[string]interface{})
-	if !ok {
-		return nil, fmt.Errorf("invalid response data format from driver location service")
+	// a response without a single usable driver is broken rather than partial
+	if len(drivers) == 0 && skipped > 0 {
+		if firstInvalid == nil {
+			firstInvalid = fmt.Errorf("%d malformed drivers", malformed)
+		}
+		return nil, skipped, domain.NewUpstreamError(domain.UpstreamUnavailable, resp.StatusCode, *correlationID, firstInvalid)
 	}
+	return drivers, skipped, nil
+}
+
+// searchResponse is the body of a driver location search
+type searchResponse[T any] struct {
+	Success bool `json:"success"`
+	Data    *struct {
+		Drivers []T `json:"drivers"`
+	} `json:"data"`
+	Error   string `json:"error"`
+	Message string `json:"message"`
+}
 
-	driversData, ok := data["drivers"].([]interface{})
-	if !ok {
-		return nil, fmt.Errorf("invalid drivers data format from driver location service")
+// searchResult is a driver of a search response, the distance is a pointer to
+// tell a missing distance from a driver right at the rider
+type searchResult struct {
+	Driver   domain.Driver `json:"driver"`
+	Distance *float64      `json:"distance"`
+}
+
+// decodeDriverByDriver decodes the drivers of a search response one at a time
+// and returns the response with the drivers that decoded and the number of
+// malformed ones, Data is nil when the envelope itself is malformed
+func (c *DriverLocationClient) decodeDriverByDriver(body []byte) (searchResponse[searchResult], int) {
+	var raw searchResponse[json.RawMessage]
+	if err := c.codec.Unmarshal(body, &raw); err != nil || raw.Data == nil {
+		return searchResponse[searchResult]{}, 0
+	}
+
+	resp := searchResponse[searchResult]{Success: raw.Success, Error: raw.Error, Message: raw.Message}
+	resp.Data = &struct {
+		Drivers []searchResult `json:"drivers"`
+	}{Drivers: make([]searchResult, 0, len(raw.Data.Drivers))}
+	malformed := 0
+	for _, driver := range raw.Data.Drivers {
+		var result searchResult
+		if err := c.codec.Unmarshal(driver, &result); err != nil {
+			malformed++
+			continue
+		}
+		resp.Data.Drivers = append(resp.Data.Drivers, result)
 	}
+	return resp, malformed
+}
+
+// Reasons a driver of a search response is skipped, the reason label of
+// matching_service_upstream_invalid_drivers_total
+const (
+	invalidDriverMalformed       = "malformed"
+	invalidDriverFields          = "invalid_driver"
+	invalidDriverMissingLocation = "missing_location"
+	invalidDriverLocation        = "invalid_location"
+	invalidDriverMissingDistance = "missing_distance"
+	invalidDriverDistance        = "invalid_distance"
+)
 
-	var drivers []domain.DriverDistancePair
-	driversBytes, err := json.Marshal(driversData)
+// validateSearchResult returns why a driver of a search response cannot be
+// matched, or a nil error for a usable driver
+func validateSearchResult(result searchResult) (string, error) {
+	// the location is checked first, validating the driver covers it too
+	if result.Driver.Location == (domain.Location{}) {
+		return invalidDriverMissingLocation, errors.New("location is missing")
+	}
+	if err := domain.ValidateStruct(&result.Driver.Location); err != nil {
+		return invalidDriverLocation, err
+	}
+	if err := domain.ValidateStruct(&result.Driver); err != nil {
+		return invalidDriverFields, err
+	}
+	if result.Distance == nil {
+		return invalidDriverMissingDistance, errors.New("distance is missing")
+	}
+	if *result.Distance < 0 {
+		return invalidDriverDistance, fmt.Errorf("distance %v is negative", *result.Distance)
+	}
+	return "", nil
+}
+
+// ReportOutcome posts the outcome of a match to the outcomes of its driver
+func (c *DriverLocationClient) ReportOutcome(ctx context.Context, outcome domain.MatchOutcome) error {
+	bodyBytes, err := c.codec.Marshal(outcome)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal drivers data: %w", err)
+		return err
+	}
+
+	correlationID := correlationIDFor(ctx)
+	_, err = c.operations[OperationOutcome].execute(ctx, &correlationID, func() (interface{}, error) {
+		baseURL, err := c.resolver.Resolve(ctx)
+		if err != nil {
+			return nil, domain.NewUpstreamError(domain.UpstreamUnavailable, 0, correlationID, err)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "POST", baseURL+"/api/v1/drivers/"+url.PathEscape(outcome.DriverID)+"/outcomes", bytes.NewReader(bodyBytes))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(requestIDHeader, correlationID)
+		c.setEndUser(req)
+		c.authenticate(req, bodyBytes)
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			c.resolver.Invalidate()
+			return nil, classifyTransportError(err, correlationID)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			b, _ := io.ReadAll(resp.Body)
+			return nil, c.classifyStatusError(resp.StatusCode, b, correlationID)
+		}
+		return nil, nil
+	})
+	return err
+}
+
+// authenticate signs the request when a signing secret is set and sends the API
+// key otherwise. The signature covers the timestamp, the method, the path with
+// its query and the body, the way the driver location service verifies it.
+func (c *DriverLocationClient) authenticate(req *http.Request, body []byte) {
+	if c.secret == "" {
+		if c.apiKey != "" {
+			req.Header.Set("X-API-Key", c.apiKey)
+		}
+		return
 	}
 
-	if err := json.Unmarshal(driversBytes, &drivers); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal drivers: %w", err)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(c.secret))
+	mac.Write([]byte(timestamp + "\n" + req.Method + "\n" + req.URL.RequestURI() + "\n"))
+	mac.Write(body)
+	req.Header.Set(signatureTimestampHeader, timestamp)
+	req.Header.Set(signatureHeader, "v1="+hex.EncodeToString(mac.Sum(nil)))
+}
+
+// classifyTransportError maps errors where no response was received
+func classifyTransportError(err error, correlationID string) error {
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return domain.NewUpstreamError(domain.UpstreamTimeout, 0, correlationID, err)
+	}
+	return domain.NewUpstreamError(domain.UpstreamUnavailable, 0, correlationID, err)
+}
+
+// classifyStatusError maps non 200 responses, keeping the upstream error message when it is readable
+func (c *DriverLocationClient) classifyStatusError(statusCode int, body []byte, correlationID string) error {
+	err := fmt.Errorf("unexpected status: %d, body: %s", statusCode, string(body))
+	var apiResp domain.DriverLocationServiceResponse
+	if c.codec.Unmarshal(body, &apiResp) == nil && apiResp.Error != "" {
+		err = fmt.Errorf("driver location service error: %s - %s", apiResp.Error, apiResp.Message)
+	}
+
+	switch {
+	case statusCode == http.StatusGatewayTimeout || statusCode == http.StatusRequestTimeout:
+		return domain.NewUpstreamError(domain.UpstreamTimeout, statusCode, correlationID, err)
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		return domain.NewUpstreamError(domain.UpstreamAuth, statusCode, correlationID, err)
+	case statusCode == http.StatusTooManyRequests:
+		return domain.NewUpstreamError(domain.UpstreamUnavailable, statusCode, correlationID, err)
+	case statusCode >= 400 && statusCode < 500:
+		return domain.NewUpstreamError(domain.UpstreamValidation, statusCode, correlationID, err)
+	default:
+		return domain.NewUpstreamError(domain.UpstreamUnavailable, statusCode, correlationID, err)
+	}
+}
+
+// setEndUser sends the hash of the rider the request is made for, the raw
+// subject and the token stay in the matching service. Calls made outside of a
+// request, like retries of the match queue, go without.
+func (c *DriverLocationClient) setEndUser(req *http.Request) {
+	subject := domain.EndUserFrom(req.Context())
+	if subject == "" {
+		return
 	}
+	req.Header.Set(endUserHeader, domain.HashEndUser(c.endUserKey, subject))
+}
 
-	return drivers, nil
+// correlationIDFor forwards the X-Request-ID of the request being handled, so
+// the driver location service logs its calls under the ID of the match. Calls
+// made outside of a request, like retries of the match queue, get their own.
+func correlationIDFor(ctx context.Context) string {
+	if requestID := domain.RequestIDFrom(ctx); requestID != "" {
+		return requestID
+	}
+	return newCorrelationID()
+}
+
+func newCorrelationID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
 }
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"the-matching-service/internal/domain"
 
@@ -31,7 +32,12 @@ func TestDriverLocationClient_FindNearbyDrivers_integration(t *testing.T) {
 				"count": 1,
 				"drivers": []domain.DriverDistancePair{
 					{
-						Driver:   domain.Driver{ID: "driver-1"},
+						Driver: domain.Driver{
+							ID:        "driver-1",
+							Location:  domain.Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}},
+							CreatedAt: time.Now().Add(-time.Hour),
+							UpdatedAt: time.Now(),
+						},
 						Distance: 100.0,
 					},
 				},
@@ -47,7 +53,7 @@ func TestDriverLocationClient_FindNearbyDrivers_integration(t *testing.T) {
 
 	client := NewDriverLocationClient(ts.URL, "test-api-key")
 	location := domain.Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}}
-	result, err := client.FindNearbyDrivers(context.Background(), location, 500)
+	result, err := client.FindNearbyDrivers(context.Background(), location, 500, 0, domain.RiderPreferences{})
 
 	assert.NoError(t, err)
 	assert.Len(t, result, 1)
@@ -2,14 +2,26 @@ package httpadapter
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"the-matching-service/config"
 	"the-matching-service/internal/domain"
 
+	dto "github.com/prometheus/client_model/go"
+	"github.com/sony/gobreaker"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TestDriverLocationClient_FindNearbyDrivers_error tests error handling when driver location service returns an error
@@ -26,7 +38,7 @@ func TestDriverLocationClient_FindNearbyDrivers_error(t *testing.T) {
 	cfg := config.LoadConfig()
 	client := NewDriverLocationClient(ts.URL, cfg.DriverLocationAPIKey)
 	location := domain.Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}}
-	result, err := client.FindNearbyDrivers(context.Background(), location, 500)
+	result, err := client.FindNearbyDrivers(context.Background(), location, 500, 0, domain.RiderPreferences{})
 
 	assert.Error(t, err)
 	assert.Nil(t, result)
@@ -46,7 +58,7 @@ func TestDriverLocationClient_FindNearbyDrivers_invalidJSON(t *testing.T) {
 	cfg := config.LoadConfig()
 	client := NewDriverLocationClient(ts.URL, cfg.DriverLocationAPIKey)
 	location := domain.Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}}
-	result, err := client.FindNearbyDrivers(context.Background(), location, 500)
+	result, err := client.FindNearbyDrivers(context.Background(), location, 500, 0, domain.RiderPreferences{})
 
 	assert.Error(t, err)
 	assert.Nil(t, result)
@@ -66,7 +78,7 @@ func TestDriverLocationClient_FindNearbyDrivers_serviceError(t *testing.T) {
 	cfg := config.LoadConfig()
 	client := NewDriverLocationClient(ts.URL, cfg.DriverLocationAPIKey)
 	location := domain.Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}}
-	result, err := client.FindNearbyDrivers(context.Background(), location, 500)
+	result, err := client.FindNearbyDrivers(context.Background(), location, 500, 0, domain.RiderPreferences{})
 
 	assert.Error(t, err)
 	assert.Nil(t, result)
@@ -79,7 +91,7 @@ func TestDriverLocationClient_FindNearbyDrivers_networkError(t *testing.T) {
 	cfg := config.LoadConfig()
 	client := NewDriverLocationClient("http://127.0.0.1:0", cfg.DriverLocationAPIKey)
 	location := domain.Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}}
-	result, err := client.FindNearbyDrivers(context.Background(), location, 500)
+	result, err := client.FindNearbyDrivers(context.Background(), location, 500, 0, domain.RiderPreferences{})
 
 	assert.Error(t, err)
 	assert.Nil(t, result)
@@ -99,7 +111,7 @@ func TestDriverLocationClient_FindNearbyDrivers_emptyList(t *testing.T) {
 	cfg := config.LoadConfig()
 	client := NewDriverLocationClient(ts.URL, cfg.DriverLocationAPIKey)
 	location := domain.Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}}
-	result, err := client.FindNearbyDrivers(context.Background(), location, 500)
+	result, err := client.FindNearbyDrivers(context.Background(), location, 500, 0, domain.RiderPreferences{})
 
 	assert.NoError(t, err)
 	assert.NotNil(t, result)
@@ -122,7 +134,9 @@ func TestDriverLocationClient_FindNearbyDrivers_successWithDrivers(t *testing.T)
 							"location": {
 								"type": "Point",
 								"coordinates": [28.9, 41.0]
-							}
+							},
+							"created_at": "2024-01-01T10:00:00Z",
+							"updated_at": "2024-01-01T13:05:00+03:00"
 						},
 						"distance": 250.5
 					}
@@ -139,11 +153,547 @@ func TestDriverLocationClient_FindNearbyDrivers_successWithDrivers(t *testing.T)
 	cfg := config.LoadConfig()
 	client := NewDriverLocationClient(ts.URL, cfg.DriverLocationAPIKey)
 	location := domain.Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}}
-	result, err := client.FindNearbyDrivers(context.Background(), location, 500)
+	result, err := client.FindNearbyDrivers(context.Background(), location, 500, 0, domain.RiderPreferences{})
 
 	assert.NoError(t, err)
 	assert.NotNil(t, result)
 	assert.Len(t, result, 1)
 	assert.Equal(t, "driver-123", result[0].Driver.ID)
 	assert.Equal(t, 250.5, result[0].Distance)
+	assert.True(t, result[0].Driver.UpdatedAt.Equal(time.Date(2024, 1, 1, 10, 5, 0, 0, time.UTC)))
+}
+
+// TestDriverLocationClient_FindNearbyDrivers_invalidTimestamps tests a response whose only driver has unusable timestamps
+// Expected: Should reject ad-hoc or missing timestamps with an upstream unavailable error, a response without a usable driver is broken
+func TestDriverLocationClient_FindNearbyDrivers_invalidTimestamps(t *testing.T) {
+	payloads := []string{
+		`{"id":"driver-1","location":{"type":"Point","coordinates":[28.9,41.0]},"created_at":"01/01/2024","updated_at":"01/01/2024"}`,
+		`{"id":"driver-1","location":{"type":"Point","coordinates":[28.9,41.0]}}`,
+	}
+
+	for _, payload := range payloads {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"success":true,"data":{"count":1,"drivers":[{"driver":` + payload + `,"distance":10}]}}`))
+		}))
+
+		client := NewDriverLocationClient(ts.URL, "test-api-key")
+		location := domain.Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}}
+		_, err := client.FindNearbyDrivers(context.Background(), location, 500, 0, domain.RiderPreferences{})
+		ts.Close()
+
+		var upstreamErr *domain.UpstreamError
+		assert.ErrorAs(t, err, &upstreamErr)
+		assert.Equal(t, domain.UpstreamUnavailable, upstreamErr.Kind)
+	}
+}
+
+// invalidDrivers reads the count of drivers skipped for reason
+func invalidDrivers(t *testing.T, reason string) float64 {
+	var metric dto.Metric
+	require.NoError(t, upstreamInvalidDriversTotal.WithLabelValues(reason).Write(&metric))
+	return metric.GetCounter().GetValue()
+}
+
+// TestDriverLocationClient_FindNearbyDrivers_partialData tests a search response with some unusable drivers
+// Expected: Should skip and count the invalid and malformed drivers, record them in the audit and return the valid ones
+func TestDriverLocationClient_FindNearbyDrivers_partialData(t *testing.T) {
+	const timestamps = `"created_at":"2024-01-01T10:00:00Z","updated_at":"2024-01-01T10:05:00Z"`
+	drivers := []string{
+		`{"driver":{"id":"driver-valid",` + timestamps + `,"location":{"type":"Point","coordinates":[28.9,41.0]}},"distance":120}`,
+		`{"driver":{"id":"driver-no-location",` + timestamps + `},"distance":100}`,
+		`{"driver":{"id":"driver-bad-location",` + timestamps + `,"location":{"type":"Point","coordinates":[28.9,141.0]}},"distance":100}`,
+		`{"driver":{"id":"driver-no-distance",` + timestamps + `,"location":{"type":"Point","coordinates":[28.9,41.0]}}}`,
+		`{"driver":{"id":"driver-negative",` + timestamps + `,"location":{"type":"Point","coordinates":[28.9,41.0]}},"distance":-5}`,
+		`{"driver":{"location":{"type":"Point","coordinates":[28.9,41.0]}},"distance":100}`,
+		`{"driver":{"id":"driver-at-rider",` + timestamps + `,"location":{"type":"Point","coordinates":[28.9,41.0]}},"distance":0}`,
+	}
+
+	for _, tc := range []struct {
+		name      string
+		drivers   []string
+		malformed float64
+	}{
+		{name: "invalid fields", drivers: drivers},
+		{name: "malformed driver", drivers: append([]string{`{"driver":{"id":"driver-3d","location":{"type":"Point","coordinates":[28.9,41.0,10]}},"distance":100}`}, drivers...), malformed: 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"success":true,"data":{"count":7,"drivers":[` + strings.Join(tc.drivers, ",") + `]}}`))
+			}))
+			defer ts.Close()
+
+			reasons := []string{invalidDriverMalformed, invalidDriverFields, invalidDriverMissingLocation, invalidDriverLocation, invalidDriverMissingDistance, invalidDriverDistance}
+			before := make(map[string]float64)
+			for _, reason := range reasons {
+				before[reason] = invalidDrivers(t, reason)
+			}
+
+			client := NewDriverLocationClient(ts.URL, "")
+			audit := domain.NewMatchAudit(time.Now())
+			location := domain.Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}}
+			result, err := client.FindNearbyDrivers(domain.WithMatchAudit(context.Background(), audit), location, 500, 0, domain.RiderPreferences{})
+
+			require.NoError(t, err)
+			require.Len(t, result, 2)
+			assert.Equal(t, "driver-valid", result[0].Driver.ID)
+			assert.Equal(t, 120.0, result[0].Distance)
+			assert.Equal(t, "driver-at-rider", result[1].Driver.ID)
+			assert.Equal(t, 0.0, result[1].Distance)
+
+			expected := map[string]float64{
+				invalidDriverMalformed:       tc.malformed,
+				invalidDriverFields:          1,
+				invalidDriverMissingLocation: 1,
+				invalidDriverLocation:        1,
+				invalidDriverMissingDistance: 1,
+				invalidDriverDistance:        1,
+			}
+			for _, reason := range reasons {
+				assert.Equal(t, expected[reason], invalidDrivers(t, reason)-before[reason], reason)
+			}
+
+			event := audit.Finish(http.StatusOK, time.Now())
+			require.Len(t, event.Upstream, 1)
+			assert.Equal(t, 2, event.Upstream[0].Drivers)
+			assert.Equal(t, 5+int(tc.malformed), event.Upstream[0].Skipped)
+		})
+	}
+}
+
+// TestDriverLocationClient_FindNearbyDrivers_malformedEnvelope tests a search response that is not JSON
+// Expected: Should fail with an upstream unavailable error instead of salvaging drivers
+func TestDriverLocationClient_FindNearbyDrivers_malformedEnvelope(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"success":true,"data":{"drivers":[{"driver":{"id":"driver-1"}`))
+	}))
+	defer ts.Close()
+
+	client := NewDriverLocationClient(ts.URL, "")
+	location := domain.Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}}
+	_, err := client.FindNearbyDrivers(context.Background(), location, 500, 0, domain.RiderPreferences{})
+
+	var upstreamErr *domain.UpstreamError
+	require.ErrorAs(t, err, &upstreamErr)
+	assert.Equal(t, domain.UpstreamUnavailable, upstreamErr.Kind)
+	assert.Contains(t, err.Error(), "invalid response body")
+}
+
+type countingResolver struct {
+	baseURL     string
+	invalidated int
+}
+
+func (r *countingResolver) Resolve(ctx context.Context) (string, error) { return r.baseURL, nil }
+func (r *countingResolver) Invalidate()                                 { r.invalidated++ }
+
+// TestDriverLocationClient_FindNearbyDrivers_invalidatesResolver tests re-resolution after a network error
+// Expected: Should invalidate the resolved address when the upstream is unreachable
+func TestDriverLocationClient_FindNearbyDrivers_invalidatesResolver(t *testing.T) {
+	resolver := &countingResolver{baseURL: "http://127.0.0.1:0"}
+	client := NewDriverLocationClientWithResolver(resolver, "")
+	location := domain.Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}}
+	_, err := client.FindNearbyDrivers(context.Background(), location, 500, 0, domain.RiderPreferences{})
+
+	assert.Error(t, err)
+	assert.Equal(t, 1, resolver.invalidated)
+}
+
+// TestDriverLocationClient_FindNearbyDrivers_upstreamErrorKinds tests mapping of upstream status codes to typed errors
+// Expected: 401 and 403 map to upstream_auth, 429 and 5xx to upstream_unavailable, 504 to upstream_timeout,
+// other 4xx to upstream_validation, and only upstream_validation is not a failure of the breaker
+func TestDriverLocationClient_FindNearbyDrivers_upstreamErrorKinds(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		kind       domain.UpstreamErrorKind
+		failure    bool
+	}{
+		{name: "bad request", statusCode: http.StatusBadRequest, kind: domain.UpstreamValidation},
+		{name: "unprocessable entity", statusCode: http.StatusUnprocessableEntity, kind: domain.UpstreamValidation},
+		{name: "unauthorized", statusCode: http.StatusUnauthorized, kind: domain.UpstreamAuth, failure: true},
+		{name: "forbidden", statusCode: http.StatusForbidden, kind: domain.UpstreamAuth, failure: true},
+		{name: "too many requests", statusCode: http.StatusTooManyRequests, kind: domain.UpstreamUnavailable, failure: true},
+		{name: "internal error", statusCode: http.StatusInternalServerError, kind: domain.UpstreamUnavailable, failure: true},
+		{name: "gateway timeout", statusCode: http.StatusGatewayTimeout, kind: domain.UpstreamTimeout, failure: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Request-ID", "upstream-id")
+				w.WriteHeader(tt.statusCode)
+				w.Write([]byte(`{"success": false, "error": "some_error", "message": "failed"}`))
+			}))
+			defer ts.Close()
+
+			client := NewDriverLocationClient(ts.URL, "")
+			location := domain.Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}}
+			_, err := client.FindNearbyDrivers(context.Background(), location, 500, 0, domain.RiderPreferences{})
+
+			var upstreamErr *domain.UpstreamError
+			assert.True(t, errors.As(err, &upstreamErr))
+			assert.Equal(t, tt.kind, upstreamErr.Kind)
+			assert.Equal(t, tt.statusCode, upstreamErr.StatusCode)
+			assert.Equal(t, "upstream-id", upstreamErr.CorrelationID)
+			assert.Equal(t, tt.failure, !upstreamSuccessful(err))
+		})
+	}
+}
+
+// TestDriverLocationClient_FindNearbyDrivers_errorBody tests reading the error body of the driver location service
+// Expected: Should keep the upstream error message with every JSON engine
+func TestDriverLocationClient_FindNearbyDrivers_errorBody(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"success": false, "error": "service_unavailable", "message": "read only"}`))
+	}))
+	defer ts.Close()
+
+	for _, engine := range []string{JSONEngineStd, JSONEngineJSONIter} {
+		codec, err := NewJSONCodec(engine)
+		require.NoError(t, err)
+
+		client := NewDriverLocationClient(ts.URL, "")
+		client.SetJSONCodec(codec)
+		location := domain.Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}}
+		_, err = client.FindNearbyDrivers(context.Background(), location, 500, 0, domain.RiderPreferences{})
+
+		require.Error(t, err, engine)
+		assert.Contains(t, err.Error(), "driver location service error: service_unavailable - read only", engine)
+	}
+}
+
+// TestDriverLocationClient_FindNearbyDrivers_sendsCorrelationID tests the correlation ID header on outgoing calls
+// Expected: Should send an X-Request-ID header and return it on network failures
+func TestDriverLocationClient_FindNearbyDrivers_sendsCorrelationID(t *testing.T) {
+	var received string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get("X-Request-ID")
+		w.Write([]byte(`{"success": true, "data": {"count": 0, "drivers": []}}`))
+	}))
+	defer ts.Close()
+
+	client := NewDriverLocationClient(ts.URL, "")
+	location := domain.Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}}
+	_, err := client.FindNearbyDrivers(context.Background(), location, 500, 0, domain.RiderPreferences{})
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, received)
+}
+
+// TestDriverLocationClient_forwardsRequestID tests calls made while handling a request with an X-Request-ID
+// Expected: Should send the ID of the request to the driver location service and record it as the correlation ID
+func TestDriverLocationClient_forwardsRequestID(t *testing.T) {
+	var received []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Header.Get("X-Request-ID"))
+		w.Write([]byte(`{"success": true, "data": {"count": 0, "drivers": []}}`))
+	}))
+	defer ts.Close()
+
+	client := NewDriverLocationClient(ts.URL, "")
+	audit := domain.NewMatchAudit(time.Now())
+	ctx := domain.WithMatchAudit(domain.WithRequestID(context.Background(), "request-1"), audit)
+	location := domain.Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}}
+
+	_, err := client.FindNearbyDrivers(ctx, location, 500, 0, domain.RiderPreferences{})
+	require.NoError(t, err)
+	require.NoError(t, client.ReportOutcome(ctx, domain.MatchOutcome{MatchID: "match-1", DriverID: "driver-1", Outcome: domain.MatchCompleted}))
+
+	assert.Equal(t, []string{"request-1", "request-1"}, received)
+	event := audit.Finish(http.StatusOK, time.Now())
+	require.Len(t, event.Upstream, 1)
+	assert.Equal(t, "request-1", event.Upstream[0].CorrelationID)
+}
+
+// TestDriverLocationClient_sendsEndUserHash tests calls made for an authenticated rider
+// Expected: Should send the SHA-256 of the subject, keyed when a key is set, and no header without a rider
+func TestDriverLocationClient_sendsEndUserHash(t *testing.T) {
+	var received []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Header.Get("X-End-User-Hash"))
+		w.Write([]byte(`{"success": true, "data": {"count": 0, "drivers": []}}`))
+	}))
+	defer ts.Close()
+
+	client := NewDriverLocationClient(ts.URL, "")
+	ctx := domain.WithEndUser(context.Background(), "rider-1")
+	location := domain.Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}}
+
+	_, err := client.FindNearbyDrivers(ctx, location, 500, 0, domain.RiderPreferences{})
+	require.NoError(t, err)
+	require.NoError(t, client.ReportOutcome(ctx, domain.MatchOutcome{MatchID: "match-1", DriverID: "driver-1", Outcome: domain.MatchCompleted}))
+	_, err = client.FindNearbyDrivers(context.Background(), location, 500, 0, domain.RiderPreferences{})
+	require.NoError(t, err)
+
+	sum := sha256.Sum256([]byte("rider-1"))
+	assert.Equal(t, []string{hex.EncodeToString(sum[:]), hex.EncodeToString(sum[:]), ""}, received)
+
+	client.SetEndUserHashKey("hash-key")
+	_, err = client.FindNearbyDrivers(ctx, location, 500, 0, domain.RiderPreferences{})
+	require.NoError(t, err)
+	mac := hmac.New(sha256.New, []byte("hash-key"))
+	mac.Write([]byte("rider-1"))
+	assert.Equal(t, hex.EncodeToString(mac.Sum(nil)), received[3])
+}
+
+// TestDriverLocationClient_FindNearbyDrivers_sendsLimit tests the limit in the search request body
+// Expected: Should send the limit when given and leave it to the service default otherwise
+func TestDriverLocationClient_FindNearbyDrivers_sendsLimit(t *testing.T) {
+	var bodies []map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		bodies = append(bodies, body)
+		w.Write([]byte(`{"success": true, "data": {"count": 0, "drivers": []}}`))
+	}))
+	defer ts.Close()
+
+	client := NewDriverLocationClient(ts.URL, "")
+	location := domain.Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}}
+	_, err := client.FindNearbyDrivers(context.Background(), location, 500, 25, domain.RiderPreferences{})
+	assert.NoError(t, err)
+	_, err = client.FindNearbyDrivers(context.Background(), location, 500, 0, domain.RiderPreferences{})
+	assert.NoError(t, err)
+
+	require.Len(t, bodies, 2)
+	assert.Equal(t, 25.0, bodies[0]["limit"])
+	assert.NotContains(t, bodies[1], "limit")
+}
+
+// TestDriverLocationClient_signsRequests tests requests signed with the secret shared with the driver location service
+// Expected: Should send the timestamp and the HMAC-SHA256 of the timestamp, method, path and body instead of the API key
+func TestDriverLocationClient_signsRequests(t *testing.T) {
+	var verified []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp := r.Header.Get("X-Signature-Timestamp")
+		mac := hmac.New(sha256.New, []byte("shared-secret"))
+		mac.Write([]byte(timestamp + "\n" + r.Method + "\n" + r.URL.RequestURI() + "\n"))
+		mac.Write(body)
+		if r.Header.Get("X-API-Key") == "" && r.Header.Get("X-Signature") == "v1="+hex.EncodeToString(mac.Sum(nil)) {
+			verified = append(verified, r.URL.Path)
+		}
+		w.Write([]byte(`{"success": true, "data": {"count": 0, "drivers": []}}`))
+	}))
+	defer ts.Close()
+
+	client := NewDriverLocationClient(ts.URL, "api-key")
+	client.SetSigningSecret("shared-secret")
+	location := domain.Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}}
+	_, err := client.FindNearbyDrivers(context.Background(), location, 500, 0, domain.RiderPreferences{})
+	require.NoError(t, err)
+	require.NoError(t, client.ReportOutcome(context.Background(), domain.MatchOutcome{MatchID: "m1", DriverID: "driver 1", Outcome: domain.MatchCompleted}))
+
+	assert.Equal(t, []string{"/api/v1/drivers/search", "/api/v1/drivers/driver 1/outcomes"}, verified)
+}
+
+// TestDriverLocationClient_FindNearbyDrivers_sendsPreferences tests the rider preferences in the search request body
+// Expected: Should send the vehicle type and minimum capacity when given and leave them out otherwise
+func TestDriverLocationClient_FindNearbyDrivers_sendsPreferences(t *testing.T) {
+	var bodies []map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		bodies = append(bodies, body)
+		w.Write([]byte(`{"success": true, "data": {"count": 0, "drivers": []}}`))
+	}))
+	defer ts.Close()
+
+	client := NewDriverLocationClient(ts.URL, "")
+	location := domain.Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}}
+	_, err := client.FindNearbyDrivers(context.Background(), location, 500, 0, domain.RiderPreferences{VehicleType: "van", MinCapacity: 6})
+	assert.NoError(t, err)
+	_, err = client.FindNearbyDrivers(context.Background(), location, 500, 0, domain.RiderPreferences{})
+	assert.NoError(t, err)
+
+	require.Len(t, bodies, 2)
+	assert.Equal(t, "van", bodies[0]["vehicle_type"])
+	assert.Equal(t, 6.0, bodies[0]["min_capacity"])
+	assert.NotContains(t, bodies[1], "vehicle_type")
+	assert.NotContains(t, bodies[1], "min_capacity")
+}
+
+// TestDriverLocationClient_FindNearbyDrivers_bulkhead tests the concurrency limit of the search operation
+// Expected: Should reject calls beyond the limit as upstream_unavailable without calling the service
+func TestDriverLocationClient_FindNearbyDrivers_bulkhead(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		close(started)
+		<-release
+		w.Write([]byte(`{"success": true, "data": {"drivers": []}}`))
+	}))
+	defer ts.Close()
+
+	client := NewDriverLocationClient(ts.URL, "")
+	client.SetMaxConcurrentCalls(OperationSearch, 1)
+	location := domain.Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}}
+
+	done := make(chan error)
+	go func() {
+		_, err := client.FindNearbyDrivers(context.Background(), location, 500, 0, domain.RiderPreferences{})
+		done <- err
+	}()
+	<-started
+
+	_, err := client.FindNearbyDrivers(context.Background(), location, 500, 0, domain.RiderPreferences{})
+	var upstreamErr *domain.UpstreamError
+	assert.True(t, errors.As(err, &upstreamErr))
+	assert.Equal(t, domain.UpstreamUnavailable, upstreamErr.Kind)
+	assert.ErrorIs(t, err, ErrBulkheadFull)
+
+	close(release)
+	assert.NoError(t, <-done)
+	assert.Equal(t, 1, calls)
+}
+
+// TestDriverLocationClient_breakerPerOperation tests that operations have separate circuit breakers
+// Expected: Should open the search breaker after repeated failures and leave the outcome breaker closed
+func TestDriverLocationClient_breakerPerOperation(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	client := NewDriverLocationClient(ts.URL, "")
+	location := domain.Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}}
+	for i := 0; i < 6; i++ {
+		client.FindNearbyDrivers(context.Background(), location, 500, 0, domain.RiderPreferences{})
+	}
+
+	_, err := client.FindNearbyDrivers(context.Background(), location, 500, 0, domain.RiderPreferences{})
+	assert.ErrorIs(t, err, gobreaker.ErrOpenState)
+	assert.Equal(t, gobreaker.StateOpen, client.operations[OperationSearch].breaker.State())
+	assert.Equal(t, gobreaker.StateClosed, client.operations[OperationOutcome].breaker.State())
+}
+
+// TestDriverLocationClient_breakerIgnoresAbandonedCalls tests searches the caller cancels or runs out of time for
+// Expected: Should leave the breaker closed and keep the calls out of the error rate, the upstream did not fail them
+func TestDriverLocationClient_breakerIgnoresAbandonedCalls(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer ts.Close()
+	defer close(release)
+
+	client := NewDriverLocationClient(ts.URL, "")
+	location := domain.Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}}
+	for i := 0; i < 6; i++ {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(10*time.Millisecond, cancel)
+		_, err := client.FindNearbyDrivers(ctx, location, 500, 0, domain.RiderPreferences{})
+		assert.ErrorIs(t, err, context.Canceled)
+
+		ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+		_, err = client.FindNearbyDrivers(ctx, location, 500, 0, domain.RiderPreferences{})
+		cancel()
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	}
+
+	assert.Equal(t, gobreaker.StateClosed, client.operations[OperationSearch].breaker.State())
+	assert.Zero(t, client.operations[OperationSearch].breaker.Counts().TotalFailures)
+	assert.Zero(t, client.ErrorRates()[OperationSearch]["1m"].Errors)
+}
+
+// TestDriverLocationClient_breakerTransitions_parallel tests parallel searches while the search breaker opens, half-opens and closes
+// Expected: Should open after the failures, reject every call while open, let only MaxRequests trial calls through while half-open and close once they succeed
+func TestDriverLocationClient_breakerTransitions_parallel(t *testing.T) {
+	const parallel = 20
+	var failing atomic.Bool
+	var calls atomic.Int32
+	failing.Store(true)
+	arrived := make(chan struct{}, parallel)
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		arrived <- struct{}{}
+		<-release
+		w.Write([]byte(`{"success": true, "data": {"drivers": []}}`))
+	}))
+	defer ts.Close()
+
+	client := NewDriverLocationClient(ts.URL, "")
+	settings := breakerSettings(OperationSearch)
+	settings.Timeout = 50 * time.Millisecond
+	client.operations[OperationSearch].breaker = gobreaker.NewCircuitBreaker(settings)
+	location := domain.Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}}
+	search := func(results chan<- error) {
+		_, err := client.FindNearbyDrivers(context.Background(), location, 500, 0, domain.RiderPreferences{})
+		results <- err
+	}
+
+	results := make(chan error, parallel)
+	for i := 0; i < parallel; i++ {
+		go search(results)
+	}
+	for i := 0; i < parallel; i++ {
+		assert.Error(t, <-results)
+	}
+	require.Equal(t, gobreaker.StateOpen, client.operations[OperationSearch].breaker.State())
+
+	seen := calls.Load()
+	for i := 0; i < parallel; i++ {
+		go search(results)
+	}
+	for i := 0; i < parallel; i++ {
+		assert.ErrorIs(t, <-results, gobreaker.ErrOpenState)
+	}
+	assert.Equal(t, seen, calls.Load())
+
+	failing.Store(false)
+	time.Sleep(2 * settings.Timeout)
+	require.Equal(t, gobreaker.StateHalfOpen, client.operations[OperationSearch].breaker.State())
+	for i := 0; i < parallel; i++ {
+		go search(results)
+	}
+	// the trial calls wait in the upstream, every other call is rejected meanwhile
+	for i := 0; i < parallel-int(settings.MaxRequests); i++ {
+		err := <-results
+		var upstreamErr *domain.UpstreamError
+		require.True(t, errors.As(err, &upstreamErr))
+		assert.Equal(t, domain.UpstreamUnavailable, upstreamErr.Kind)
+		assert.ErrorIs(t, err, gobreaker.ErrTooManyRequests)
+	}
+	close(release)
+	for i := 0; i < int(settings.MaxRequests); i++ {
+		assert.NoError(t, <-results)
+	}
+	assert.Len(t, arrived, int(settings.MaxRequests))
+	assert.Equal(t, gobreaker.StateClosed, client.operations[OperationSearch].breaker.State())
+}
+
+// TestDriverLocationClient_ReportOutcome tests reporting a match outcome to the driver location service
+// Expected: Should post the match and outcome to the outcomes of the driver with the API key and return upstream errors
+func TestDriverLocationClient_ReportOutcome(t *testing.T) {
+	var path, apiKey string
+	var body map[string]string
+	status := http.StatusOK
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, apiKey = r.URL.Path, r.Header.Get("X-API-Key")
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		w.WriteHeader(status)
+		w.Write([]byte(`{"success": true}`))
+	}))
+	defer ts.Close()
+
+	client := NewDriverLocationClient(ts.URL, "secret")
+	outcome := domain.MatchOutcome{MatchID: "m1", DriverID: "driver-1", Outcome: domain.MatchCompleted}
+	require.NoError(t, client.ReportOutcome(context.Background(), outcome))
+	assert.Equal(t, "/api/v1/drivers/driver-1/outcomes", path)
+	assert.Equal(t, "secret", apiKey)
+	assert.Equal(t, map[string]string{"match_id": "m1", "outcome": "completed"}, body)
+
+	status = http.StatusNotFound
+	err := client.ReportOutcome(context.Background(), outcome)
+	var upstreamErr *domain.UpstreamError
+	require.True(t, errors.As(err, &upstreamErr))
+	assert.Equal(t, http.StatusNotFound, upstreamErr.StatusCode)
 }
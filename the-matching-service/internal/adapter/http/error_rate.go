@@ -0,0 +1,121 @@
+package httpadapter
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	errorRateBucketSize = 10 * time.Second
+	errorRateBuckets    = int(15 * time.Minute / errorRateBucketSize)
+)
+
+// errorRateWindows are the windows the status page reports error rates over
+var errorRateWindows = map[string]time.Duration{
+	"1m":  time.Minute,
+	"5m":  5 * time.Minute,
+	"15m": 15 * time.Minute,
+}
+
+// ErrorRate is the share of failed calls over a window, 0 without calls
+type ErrorRate struct {
+	Requests int64   `json:"requests"`
+	Errors   int64   `json:"errors"`
+	Rate     float64 `json:"rate"`
+}
+
+type errorRateBucket struct {
+	start    int64 // unix time of the bucket start, in bucket sizes
+	requests int64
+	errors   int64
+}
+
+// ErrorRateTracker counts calls and failures in 10 second buckets over the last
+// 15 minutes. Unlike the Prometheus counters it needs no query to read, the
+// status page reports it as is.
+type ErrorRateTracker struct {
+	mu      sync.Mutex
+	buckets [errorRateBuckets]errorRateBucket
+}
+
+func NewErrorRateTracker() *ErrorRateTracker {
+	return &ErrorRateTracker{}
+}
+
+// Record counts a call, failed or not
+func (t *ErrorRateTracker) Record(failed bool) {
+	t.record(time.Now(), failed)
+}
+
+func (t *ErrorRateTracker) record(now time.Time, failed bool) {
+	start := now.UnixNano() / int64(errorRateBucketSize)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	bucket := &t.buckets[start%int64(errorRateBuckets)]
+	if bucket.start != start {
+		*bucket = errorRateBucket{start: start}
+	}
+	bucket.requests++
+	if failed {
+		bucket.errors++
+	}
+}
+
+// Rates returns the error rate of every window: 1m, 5m and 15m
+func (t *ErrorRateTracker) Rates() map[string]ErrorRate {
+	return t.rates(time.Now())
+}
+
+func (t *ErrorRateTracker) rates(now time.Time) map[string]ErrorRate {
+	current := now.UnixNano() / int64(errorRateBucketSize)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	rates := make(map[string]ErrorRate, len(errorRateWindows))
+	for name, window := range errorRateWindows {
+		oldest := current - int64(window/errorRateBucketSize) + 1
+		var rate ErrorRate
+		for _, bucket := range t.buckets {
+			if bucket.start >= oldest && bucket.start <= current {
+				rate.Requests += bucket.requests
+				rate.Errors += bucket.errors
+			}
+		}
+		if rate.Requests > 0 {
+			rate.Rate = float64(rate.Errors) / float64(rate.Requests)
+		}
+		rates[name] = rate
+	}
+	return rates
+}
+
+// Middleware records the /api requests, answered with a 5xx or not. Health
+// checks and scrapes are left out so they do not water the rate down.
+func (t *ErrorRateTracker) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			err := next(c)
+			if !strings.HasPrefix(c.Path(), "/api/") {
+				return err
+			}
+
+			status := c.Response().Status
+			if err != nil {
+				// the error handler has not written the response yet
+				status = http.StatusInternalServerError
+				var httpErr *echo.HTTPError
+				if errors.As(err, &httpErr) {
+					status = httpErr.Code
+				}
+			}
+			t.Record(status >= http.StatusInternalServerError)
+			return err
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package httpadapter
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestErrorRateTracker_Windows tests error rates over the 1, 5 and 15 minute windows
+// Expected: Should count every call in the windows it falls in and forget the calls older than 15 minutes
+func TestErrorRateTracker_Windows(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	tracker := NewErrorRateTracker()
+	tracker.record(now.Add(-20*time.Minute), true)
+	tracker.record(now.Add(-10*time.Minute), true)
+	tracker.record(now.Add(-3*time.Minute), false)
+	tracker.record(now.Add(-3*time.Minute), true)
+	tracker.record(now.Add(-10*time.Second), false)
+	tracker.record(now, false)
+
+	rates := tracker.rates(now)
+	assert.Equal(t, ErrorRate{Requests: 2}, rates["1m"])
+	assert.Equal(t, ErrorRate{Requests: 4, Errors: 1, Rate: 0.25}, rates["5m"])
+	assert.Equal(t, ErrorRate{Requests: 5, Errors: 2, Rate: 0.4}, rates["15m"])
+
+	assert.Equal(t, ErrorRate{}, tracker.rates(now.Add(time.Hour))["15m"])
+}
+
+// TestErrorRateTracker_Middleware tests recording the requests of a router
+// Expected: Should count API requests answered with a 5xx or an error as failed and leave other routes out
+func TestErrorRateTracker_Middleware(t *testing.T) {
+	tracker := NewErrorRateTracker()
+	e := echo.New()
+	e.Use(tracker.Middleware())
+	e.GET("/health", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+	e.GET("/api/v1/ok", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+	e.GET("/api/v1/bad", func(c echo.Context) error { return echo.NewHTTPError(http.StatusBadRequest) })
+	e.GET("/api/v1/gateway", func(c echo.Context) error { return c.NoContent(http.StatusBadGateway) })
+	e.GET("/api/v1/error", func(c echo.Context) error { return errors.New("boom") })
+
+	for _, path := range []string{"/health", "/api/v1/ok", "/api/v1/bad", "/api/v1/gateway", "/api/v1/error"} {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	assert.Equal(t, ErrorRate{Requests: 4, Errors: 2, Rate: 0.5}, tracker.Rates()["1m"])
+}
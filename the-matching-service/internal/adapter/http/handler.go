@@ -1,37 +1,93 @@
 package httpadapter
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 
+	"the-matching-service/internal/adapter/middleware"
 	"the-matching-service/internal/application"
+	"the-matching-service/internal/buildinfo"
 	"the-matching-service/internal/domain"
 
 	"github.com/labstack/echo/v4"
 )
 
+// Headers of idempotent match requests, a retry with the key of a successful
+// request is answered with its match and Idempotent-Replayed: true
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+)
+
 type MatchHandler struct {
 	matchingService *application.MatchingService
+	upstream        *UpstreamProbe
+	queue           *application.MatchQueue
 }
 
 func NewMatchHandler(matchingService *application.MatchingService) *MatchHandler {
 	return &MatchHandler{matchingService: matchingService}
 }
 
+// SetUpstreamProbe makes the health check report the driver location service
+func (h *MatchHandler) SetUpstreamProbe(probe *UpstreamProbe) {
+	h.upstream = probe
+}
+
+// SetMatchQueue lets riders asking to wait join the match queue when no driver is nearby
+func (h *MatchHandler) SetMatchQueue(queue *application.MatchQueue) {
+	h.queue = queue
+}
+
 // HealthCheck godoc
 // @Summary Health check endpoint
-// @Description Check if the service is healthy
+// @Description Check if the service is healthy. With HEALTH_PROBE_UPSTREAM the driver location service is probed too and the status is degraded while it is down or a circuit breaker is not closed, the response stays 200 so an upstream outage does not restart the service.
 // @Tags health
 // @Accept json
 // @Produce json
 // @Success 200 {object} map[string]interface{}
 // @Router /health [get]
 func (h *MatchHandler) HealthCheck(c echo.Context) error {
-	return c.JSON(http.StatusOK, map[string]interface{}{
+	data := map[string]interface{}{
 		"status":  "healthy",
 		"service": "matching-service",
+	}
+	if h.upstream != nil {
+		upstream := h.upstream.Check(c.Request().Context())
+		data["upstream"] = upstream
+		if !upstream.Healthy() {
+			data["status"] = "degraded"
+		}
+	}
+	return c.JSON(http.StatusOK, data)
+}
+
+// LivenessCheck godoc
+// @Summary Liveness check endpoint
+// @Description Check if the service is alive without calling any dependency, see /health/ready
+// @Tags health
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /health/live [get]
+func (h *MatchHandler) LivenessCheck(c echo.Context) error {
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status":  "alive",
+		"service": "matching-service",
 	})
 }
 
+// Version godoc
+// @Summary Build information
+// @Description Version, git commit and build time of the running binary, set at build time with -ldflags
+// @Tags health
+// @Produce json
+// @Success 200 {object} buildinfo.Info
+// @Router /version [get]
+func (h *MatchHandler) Version(c echo.Context) error {
+	return c.JSON(http.StatusOK, buildinfo.Get())
+}
+
 // Match godoc
 // @Summary Match rider with nearby driver
 // @Description Find the nearest driver for a rider based on location and radius
@@ -39,71 +95,246 @@ func (h *MatchHandler) HealthCheck(c echo.Context) error {
 // @Accept json
 // @Produce json
 // @Param request body domain.MatchRequest true "Match request"
+// @Param Idempotency-Key header string false "Retries with the same key return the match of the first request, answered with Idempotent-Replayed: true"
 // @Success 200 {object} domain.SuccessResponse "Success: data contains MatchResponse"
+// @Success 202 {object} domain.SuccessResponse "Accepted: no driver nearby, data contains the QueueEntry of a rider that asked to wait"
 // @Failure 400 {object} domain.ErrorResponse "Bad Request - Validation error or invalid request"
 // @Failure 401 {object} domain.ErrorResponse "Unauthorized - User not authenticated"
+// @Failure 429 {object} domain.ErrorResponse "Too Many Requests - Rate limit of the user exceeded, retry after Retry-After seconds"
+// @Failure 403 {object} domain.ErrorResponse "Forbidden - without the rider scope once JWT_ENFORCE_SCOPES is set, or include_candidates without the match:candidates scope"
 // @Failure 404 {object} domain.ErrorResponse "Not Found - No drivers found nearby"
+// @Failure 409 {object} domain.ErrorResponse "Conflict - The first request with the Idempotency-Key is still matching"
+// @Failure 422 {object} domain.ErrorResponse "Unprocessable Entity - Location at (0,0) or outside the service area, or an Idempotency-Key sent with another request"
 // @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Failure 502 {object} domain.ErrorResponse "Bad Gateway - Driver location service unavailable, rate limited or refusing the credentials of the matching service"
+// @Failure 504 {object} domain.ErrorResponse "Gateway Timeout - Driver location service timed out"
 // @Security BearerAuth
 // @Router /api/v1/match [post]
 func (h *MatchHandler) Match(c echo.Context) error {
+	req, userID, ok, err := bindMatchRequest(c)
+	if !ok {
+		return err
+	}
+
+	key := c.Request().Header.Get(idempotencyKeyHeader)
+	if len(key) > domain.MaxIdempotencyKeyLength {
+		return c.JSON(http.StatusBadRequest, domain.ErrorResponse{
+			Success: false,
+			Error:   "invalid_request",
+			Message: fmt.Sprintf("%s must be at most %d characters", idempotencyKeyHeader, domain.MaxIdempotencyKeyLength),
+		})
+	}
+
+	if req.IncludeCandidates && !middleware.HasScope(c, domain.ScopeMatchCandidates) {
+		return c.JSON(http.StatusForbidden, domain.ErrorResponse{
+			Success: false,
+			Error:   "forbidden",
+			Message: "include_candidates requires the " + domain.ScopeMatchCandidates + " scope",
+		})
+	}
+
+	rider := req.CreateRider(userID)
+	strategy := h.matchingService.StrategyFor(userID)
+	result, replayed, err := h.matchingService.MatchOnce(c.Request().Context(), key, *rider, req.Radius, req.Limit)
+	if replayed {
+		matchesTotal.WithLabelValues(result.Strategy, "replayed").Inc()
+		domain.MatchAuditFrom(c.Request().Context()).SetOutcome("replayed", nil)
+		c.Response().Header().Set(idempotentReplayedHeader, "true")
+		return c.JSON(http.StatusOK, domain.SuccessResponse{
+			Success: true,
+			Data:    newMatchResponse(result, req.IncludeCandidates),
+			Message: "Matched successfully",
+		})
+	}
+	if errors.Is(err, domain.ErrNoDriversFound) && req.Wait && h.queue != nil {
+		matchesTotal.WithLabelValues(strategy, "queued").Inc()
+		domain.MatchAuditFrom(c.Request().Context()).SetOutcome("queued", nil)
+		return c.JSON(http.StatusAccepted, domain.SuccessResponse{
+			Success: true,
+			Data:    h.queue.Enqueue(*rider, req.Radius, req.Limit),
+			Message: "Waiting for a driver",
+		})
+	}
+	if err != nil {
+		matchesTotal.WithLabelValues(strategy, matchOutcome(err)).Inc()
+		domain.MatchAuditFrom(c.Request().Context()).SetOutcome(matchOutcome(err), err)
+		return h.matchErrorResponse(c, err)
+	}
+	matchesTotal.WithLabelValues(result.Strategy, "matched").Inc()
+	domain.MatchAuditFrom(c.Request().Context()).SetOutcome("matched", nil)
+
+	return c.JSON(http.StatusOK, domain.SuccessResponse{
+		Success: true,
+		Data:    newMatchResponse(result, req.IncludeCandidates),
+		Message: "Matched successfully",
+	})
+}
+
+// newMatchResponse adds the candidates of the match when they were asked for
+func newMatchResponse(result *domain.MatchResult, includeCandidates bool) *domain.MatchResponse {
+	response := domain.NewMatchResponse(result)
+	if includeCandidates {
+		response.Candidates = result.Candidates
+	}
+	return response
+}
+
+// Candidates godoc
+// @Summary List drivers a rider can choose from
+// @Description Find the nearby drivers ranked by estimated time of arrival, nearest first among equal ETAs. The limit is the number of candidates returned. Nothing is matched, the rider app can show the options and match again without a new search.
+// @Tags matching
+// @Accept json
+// @Produce json
+// @Param request body domain.MatchRequest true "Match request"
+// @Success 200 {object} domain.SuccessResponse "Success: data contains CandidatesResponse"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Validation error or invalid request"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized - User not authenticated"
+// @Failure 403 {object} domain.ErrorResponse "Forbidden - without the rider scope once JWT_ENFORCE_SCOPES is set"
+// @Failure 429 {object} domain.ErrorResponse "Too Many Requests - Rate limit of the user exceeded, retry after Retry-After seconds"
+// @Failure 404 {object} domain.ErrorResponse "Not Found - No drivers found nearby"
+// @Failure 422 {object} domain.ErrorResponse "Unprocessable Entity - Location at (0,0) or outside the service area"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Failure 502 {object} domain.ErrorResponse "Bad Gateway - Driver location service unavailable, rate limited or refusing the credentials of the matching service"
+// @Failure 504 {object} domain.ErrorResponse "Gateway Timeout - Driver location service timed out"
+// @Security BearerAuth
+// @Router /api/v1/match/candidates [post]
+func (h *MatchHandler) Candidates(c echo.Context) error {
+	req, userID, ok, err := bindMatchRequest(c)
+	if !ok {
+		return err
+	}
+
+	rider := req.CreateRider(userID)
+	candidates, err := h.matchingService.FindCandidates(c.Request().Context(), *rider, req.Radius, req.Limit)
+	if err != nil {
+		return h.matchErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, domain.SuccessResponse{
+		Success: true,
+		Data: &domain.CandidatesResponse{
+			Rider:      rider.ID,
+			Count:      len(candidates),
+			Candidates: candidates,
+		},
+		Message: "Candidates found successfully",
+	})
+}
+
+// bindMatchRequest authenticates the rider and binds and validates the body, when
+// ok is false the error response has been written and err is its result
+func bindMatchRequest(c echo.Context) (req domain.MatchRequest, userID string, ok bool, err error) {
 	isAuth, _ := c.Get("is_authenticated").(bool)
 	if !isAuth {
-		return c.JSON(http.StatusUnauthorized, domain.ErrorResponse{
+		return req, "", false, c.JSON(http.StatusUnauthorized, domain.ErrorResponse{
 			Success: false,
 			Error:   "unauthorized",
 			Message: "User not authenticated",
 		})
 	}
-	userID, _ := c.Get("user_id").(string)
+	userID, _ = c.Get("user_id").(string)
 
-	var req domain.MatchRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, domain.ErrorResponse{
+		return req, "", false, c.JSON(http.StatusBadRequest, domain.ErrorResponse{
 			Success: false,
 			Error:   "invalid_request",
-			Message: "Invalid request body",
+			Message: bindErrorMessage(err, "Invalid request body"),
 		})
 	}
 
 	// Validate the request
 	if err := domain.ValidateStruct(&req); err != nil {
+		domain.MatchAuditFrom(c.Request().Context()).SetOutcome("validation_error", err)
 		if validationErrors, ok := err.(*domain.ValidationErrors); ok {
-			return c.JSON(http.StatusBadRequest, domain.ErrorResponse{
+			return req, "", false, c.JSON(http.StatusBadRequest, domain.ErrorResponse{
 				Success: false,
 				Error:   "validation_error",
 				Message: "Request validation failed",
 				Details: validationErrors.Errors,
 			})
 		}
-		return c.JSON(http.StatusBadRequest, domain.ErrorResponse{
+		return req, "", false, c.JSON(http.StatusBadRequest, domain.ErrorResponse{
 			Success: false,
 			Error:   "validation_error",
 			Message: err.Error(),
 		})
 	}
+	return req, userID, true, nil
+}
 
-	rider := req.CreateRider(userID)
-	result, err := h.matchingService.MatchRiderToDriver(c.Request().Context(), *rider, req.Radius)
-	if err != nil {
-		if err.Error() == "no drivers found" {
-			return c.JSON(http.StatusNotFound, domain.ErrorResponse{
-				Success: false,
-				Error:   "not_found",
-				Message: "No drivers found nearby",
-			})
+func matchOutcome(err error) string {
+	if errors.Is(err, domain.ErrNoDriversFound) {
+		return "no_drivers"
+	}
+	if errors.Is(err, domain.ErrIdempotencyKeyReused) || errors.Is(err, domain.ErrIdempotencyKeyInFlight) {
+		return "idempotency_conflict"
+	}
+	if errors.Is(err, domain.ErrOutOfServiceArea) {
+		return "out_of_service_area"
+	}
+	var upstreamErr *domain.UpstreamError
+	if errors.As(err, &upstreamErr) {
+		return string(upstreamErr.Kind)
+	}
+	return "error"
+}
+
+// matchErrorResponse maps matching errors to status codes, upstream failures keep
+// the correlation ID so they can be found in the driver location service logs
+func (h *MatchHandler) matchErrorResponse(c echo.Context, err error) error {
+	if errors.Is(err, domain.ErrNoDriversFound) {
+		return c.JSON(http.StatusNotFound, domain.ErrorResponse{
+			Success: false,
+			Error:   "not_found",
+			Message: "No drivers found nearby",
+		})
+	}
+	if errors.Is(err, domain.ErrOutOfServiceArea) {
+		return c.JSON(http.StatusUnprocessableEntity, domain.ErrorResponse{
+			Success: false,
+			Error:   "out_of_service_area",
+			Message: "Location is outside the service area",
+		})
+	}
+
+	if errors.Is(err, domain.ErrIdempotencyKeyReused) {
+		return c.JSON(http.StatusUnprocessableEntity, domain.ErrorResponse{
+			Success: false,
+			Error:   "idempotency_key_reused",
+			Message: "Idempotency-Key was already used with another match request",
+		})
+	}
+	if errors.Is(err, domain.ErrIdempotencyKeyInFlight) {
+		return c.JSON(http.StatusConflict, domain.ErrorResponse{
+			Success: false,
+			Error:   "idempotency_key_in_flight",
+			Message: "A match request with this Idempotency-Key is in progress",
+		})
+	}
+
+	var upstreamErr *domain.UpstreamError
+	if errors.As(err, &upstreamErr) {
+		status := http.StatusInternalServerError
+		switch upstreamErr.Kind {
+		case domain.UpstreamUnavailable, domain.UpstreamAuth:
+			status = http.StatusBadGateway
+		case domain.UpstreamTimeout:
+			status = http.StatusGatewayTimeout
 		}
-		return c.JSON(http.StatusInternalServerError, domain.ErrorResponse{
+		return c.JSON(status, domain.ErrorResponse{
 			Success: false,
-			Error:   "internal_error",
-			Message: err.Error(),
+			Error:   string(upstreamErr.Kind),
+			Message: upstreamErr.Error(),
+			Details: map[string]interface{}{
+				"correlation_id":  upstreamErr.CorrelationID,
+				"upstream_status": upstreamErr.StatusCode,
+			},
 		})
 	}
 
-	response := domain.NewMatchResponse(result)
-	return c.JSON(http.StatusOK, domain.SuccessResponse{
-		Success: true,
-		Data:    response,
-		Message: "Matched successfully",
+	return c.JSON(http.StatusInternalServerError, domain.ErrorResponse{
+		Success: false,
+		Error:   "internal_error",
+		Message: err.Error(),
 	})
 }
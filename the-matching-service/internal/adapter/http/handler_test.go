@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	"the-matching-service/config"
+	"the-matching-service/internal/adapter/idempotency"
 	"the-matching-service/internal/adapter/middleware"
 	"the-matching-service/internal/application"
 	"the-matching-service/internal/domain"
@@ -17,11 +18,12 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type mockDriverLocationServiceForHandler struct{}
 
-func (m *mockDriverLocationServiceForHandler) FindNearbyDrivers(ctx context.Context, location domain.Location, radius float64) ([]domain.DriverDistancePair, error) {
+func (m *mockDriverLocationServiceForHandler) FindNearbyDrivers(ctx context.Context, location domain.Location, radius float64, limit int, preferences domain.RiderPreferences) ([]domain.DriverDistancePair, error) {
 	return []domain.DriverDistancePair{
 		{
 			Driver:   domain.Driver{ID: "driver-1"},
@@ -32,16 +34,24 @@ func (m *mockDriverLocationServiceForHandler) FindNearbyDrivers(ctx context.Cont
 
 type mockDriverLocationServiceForHandlerNoDrivers struct{}
 
-func (m *mockDriverLocationServiceForHandlerNoDrivers) FindNearbyDrivers(ctx context.Context, location domain.Location, radius float64) ([]domain.DriverDistancePair, error) {
+func (m *mockDriverLocationServiceForHandlerNoDrivers) FindNearbyDrivers(ctx context.Context, location domain.Location, radius float64, limit int, preferences domain.RiderPreferences) ([]domain.DriverDistancePair, error) {
 	return []domain.DriverDistancePair{}, nil
 }
 
 type mockDriverLocationServiceForHandlerError struct{}
 
-func (m *mockDriverLocationServiceForHandlerError) FindNearbyDrivers(ctx context.Context, location domain.Location, radius float64) ([]domain.DriverDistancePair, error) {
+func (m *mockDriverLocationServiceForHandlerError) FindNearbyDrivers(ctx context.Context, location domain.Location, radius float64, limit int, preferences domain.RiderPreferences) ([]domain.DriverDistancePair, error) {
 	return nil, errors.New("database connection failed")
 }
 
+type mockDriverLocationServiceForHandlerUpstreamError struct {
+	err error
+}
+
+func (m *mockDriverLocationServiceForHandlerUpstreamError) FindNearbyDrivers(ctx context.Context, location domain.Location, radius float64, limit int, preferences domain.RiderPreferences) ([]domain.DriverDistancePair, error) {
+	return nil, m.err
+}
+
 func generateJWT(secret string, claims jwt.MapClaims) string {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	t, _ := token.SignedString([]byte(secret))
@@ -112,6 +122,32 @@ func TestMatchHandler_ValidationError(t *testing.T) {
 	assert.Contains(t, w.Body.String(), "Request validation failed")
 }
 
+// TestMatchHandler_NegativeLimit tests a match request with a negative limit
+// Expected: HTTP 400 Bad Request with validation error instead of falling back to the default limit
+func TestMatchHandler_NegativeLimit(t *testing.T) {
+	cfg := &config.Config{JWTSecret: "testsecret"}
+	handler := NewMatchHandler(application.NewMatchingService(&mockDriverLocationServiceForHandler{}))
+
+	e := echo.New()
+	e.Use(middleware.JWTAuthMiddleware(cfg))
+	e.POST("/api/v1/match", handler.Match)
+
+	token := generateJWT(cfg.JWTSecret, jwt.MapClaims{"user_id": "user-1", "authenticated": true})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/match", strings.NewReader(`{
+		"location": {"type": "Point", "coordinates": [28.9, 41.0]},
+		"radius": 500,
+		"limit": -1
+	}`))
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	w := httptest.NewRecorder()
+
+	e.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "validation_error")
+}
+
 // TestMatchHandler_Unauthorized tests unauthorized access without authentication
 // Expected: HTTP 401 Unauthorized when user is not authenticated
 func TestMatchHandler_Unauthorized(t *testing.T) {
@@ -231,6 +267,35 @@ func TestMatchHandler_NoDriversFound(t *testing.T) {
 	assert.Contains(t, w.Body.String(), "No drivers found nearby")
 }
 
+// TestMatchHandler_OutOfServiceArea tests a match request at (0,0)
+// Expected: HTTP 422 with out_of_service_area instead of searching and answering no drivers found
+func TestMatchHandler_OutOfServiceArea(t *testing.T) {
+	cfg := &config.Config{JWTSecret: "testsecret"}
+
+	matchingService := application.NewMatchingService(&mockDriverLocationServiceForHandlerError{})
+	handler := NewMatchHandler(matchingService)
+
+	e := echo.New()
+	e.Use(middleware.JWTAuthMiddleware(cfg))
+	e.POST("/api/v1/match", handler.Match)
+
+	claims := jwt.MapClaims{"user_id": "user-1", "authenticated": true}
+	token := generateJWT(cfg.JWTSecret, claims)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/match", strings.NewReader(`{
+		"location": {"type": "Point", "coordinates": [0, 0]},
+		"radius": 500
+	}`))
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	w := httptest.NewRecorder()
+
+	e.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), "out_of_service_area")
+}
+
 // TestMatchHandler_GeoJSONPointSearch_NoDriversFound tests 404 response for GeoJSON point search with no matching drivers
 // Expected: HTTP 404 Not Found when searching with valid GeoJSON Point coordinates but no drivers match criteria
 func TestMatchHandler_GeoJSONPointSearch_NoDriversFound(t *testing.T) {
@@ -352,3 +417,246 @@ func TestMatchHandler_GeoJSONPointSearch_Success(t *testing.T) {
 		})
 	}
 }
+
+// TestMatchHandler_UpstreamErrors tests status code mapping of typed upstream errors
+// Expected: 502 for unavailable and auth, 504 for timeout and 500 for validation errors, each carrying the correlation ID
+func TestMatchHandler_UpstreamErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		kind       domain.UpstreamErrorKind
+		statusCode int
+	}{
+		{name: "unavailable", kind: domain.UpstreamUnavailable, statusCode: http.StatusBadGateway},
+		{name: "auth", kind: domain.UpstreamAuth, statusCode: http.StatusBadGateway},
+		{name: "timeout", kind: domain.UpstreamTimeout, statusCode: http.StatusGatewayTimeout},
+		{name: "validation", kind: domain.UpstreamValidation, statusCode: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{JWTSecret: "testsecret"}
+			mockService := &mockDriverLocationServiceForHandlerUpstreamError{
+				err: domain.NewUpstreamError(tt.kind, 0, "corr-123", errors.New("upstream failed")),
+			}
+			handler := NewMatchHandler(application.NewMatchingService(mockService))
+
+			e := echo.New()
+			e.Use(middleware.JWTAuthMiddleware(cfg))
+			e.POST("/api/v1/match", handler.Match)
+
+			token := generateJWT(cfg.JWTSecret, jwt.MapClaims{"user_id": "user-1", "authenticated": true})
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/match", strings.NewReader(`{
+				"location": {"type": "Point", "coordinates": [28.9, 41.0]},
+				"radius": 500
+			}`))
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			w := httptest.NewRecorder()
+
+			e.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.statusCode, w.Code)
+			assert.Contains(t, w.Body.String(), string(tt.kind))
+			assert.Contains(t, w.Body.String(), "corr-123")
+		})
+	}
+}
+
+// TestMatchHandler_UnknownField tests a match request with a misspelled field
+// Expected: Should return 400 Bad Request naming the unknown field instead of matching without a radius
+func TestMatchHandler_UnknownField(t *testing.T) {
+	cfg := &config.Config{JWTSecret: "testsecret"}
+	// NewRouter registers the prometheus middleware, which can only happen once per process
+	router := &Router{echo: echo.New(), handler: NewMatchHandler(application.NewMatchingService(&mockDriverLocationServiceForHandler{})), config: cfg}
+	router.echo.JSONSerializer = JSONCodec{}
+	router.setupRoutes(cfg)
+
+	token := generateJWT(cfg.JWTSecret, jwt.MapClaims{"user_id": "user-1", "authenticated": true})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/match", strings.NewReader(`{
+		"location": {"type": "Point", "coordinates": [28.9, 41.0]},
+		"raduis": 500
+	}`))
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	w := httptest.NewRecorder()
+	router.GetEcho().ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `unknown field \"raduis\"`)
+}
+
+// TestRouter_EnforceScopes tests authorizing the match route by the scopes of the token
+// Expected: Should let any token through until scopes are enforced, then answer 403 to tokens without the rider scope
+func TestRouter_EnforceScopes(t *testing.T) {
+	cfg := &config.Config{JWTSecret: "testsecret"}
+	router := &Router{echo: echo.New(), handler: NewMatchHandler(application.NewMatchingService(&mockDriverLocationServiceForHandler{})), config: cfg}
+	router.echo.JSONSerializer = JSONCodec{}
+	router.setupRoutes(cfg)
+
+	match := func(scope string) int {
+		token := generateJWT(cfg.JWTSecret, jwt.MapClaims{"user_id": "user-1", "authenticated": true, "scope": scope})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/match", strings.NewReader(`{
+			"location": {"type": "Point", "coordinates": [28.9, 41.0]},
+			"radius": 500
+		}`))
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		w := httptest.NewRecorder()
+		router.GetEcho().ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, match(""))
+
+	cfg.JWT.EnforceScopes = true
+	assert.Equal(t, http.StatusForbidden, match(""))
+	assert.Equal(t, http.StatusForbidden, match("driver"))
+	assert.Equal(t, http.StatusOK, match("rider"))
+}
+
+// TestMatchHandler_Candidates tests listing the drivers a rider can choose from
+// Expected: HTTP 200 OK with the rider, the count and the candidates with distances and ETAs
+func TestMatchHandler_Candidates(t *testing.T) {
+	cfg := &config.Config{JWTSecret: "testsecret"}
+	handler := NewMatchHandler(application.NewMatchingService(&mockDriverLocationServiceForHandler{}))
+
+	e := echo.New()
+	e.Use(middleware.JWTAuthMiddleware(cfg))
+	e.POST("/api/v1/match/candidates", handler.Candidates)
+
+	claims := jwt.MapClaims{"user_id": "user-1", "authenticated": true}
+	token := generateJWT(cfg.JWTSecret, claims)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/match/candidates", strings.NewReader(`{
+		"location": {"type": "Point", "coordinates": [28.9, 41.0]},
+		"radius": 500,
+		"limit": 3
+	}`))
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	w := httptest.NewRecorder()
+
+	e.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"rider":"user-1"`)
+	assert.Contains(t, w.Body.String(), `"count":1`)
+	assert.Contains(t, w.Body.String(), `{"driver_id":"driver-1","distance":100,"eta_seconds":12}`)
+}
+
+// TestMatchHandler_Candidates_errors tests the error responses of the candidates endpoint
+// Expected: Same status codes as a match: 401 without a token, 400 for an invalid body, 404 without drivers and 422 at (0,0)
+func TestMatchHandler_Candidates_errors(t *testing.T) {
+	cfg := &config.Config{JWTSecret: "testsecret"}
+	token := generateJWT(cfg.JWTSecret, jwt.MapClaims{"user_id": "user-1", "authenticated": true})
+
+	for _, tc := range []struct {
+		name     string
+		token    string
+		body     string
+		expected int
+	}{
+		{name: "unauthenticated", body: `{"location": {"type": "Point", "coordinates": [28.9, 41.0]}, "radius": 500}`, expected: http.StatusUnauthorized},
+		{name: "invalid radius", token: token, body: `{"location": {"type": "Point", "coordinates": [28.9, 41.0]}, "radius": -1}`, expected: http.StatusBadRequest},
+		{name: "no drivers", token: token, body: `{"location": {"type": "Point", "coordinates": [28.9, 41.0]}, "radius": 500}`, expected: http.StatusNotFound},
+		{name: "out of service area", token: token, body: `{"location": {"type": "Point", "coordinates": [0, 0]}, "radius": 500}`, expected: http.StatusUnprocessableEntity},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := NewMatchHandler(application.NewMatchingService(&mockDriverLocationServiceForHandlerNoDrivers{}))
+			e := echo.New()
+			e.Use(middleware.JWTAuthMiddleware(cfg))
+			e.POST("/api/v1/match/candidates", handler.Candidates)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/match/candidates", strings.NewReader(tc.body))
+			if tc.token != "" {
+				req.Header.Set(echo.HeaderAuthorization, "Bearer "+tc.token)
+			}
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			w := httptest.NewRecorder()
+
+			e.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expected, w.Code)
+		})
+	}
+}
+
+// TestMatchHandler_IncludeCandidates tests asking a match for the candidates it chose from
+// Expected: Should add the ranked candidates for tokens with the match:candidates scope, refuse other tokens with 403 and leave them out unless asked for
+func TestMatchHandler_IncludeCandidates(t *testing.T) {
+	cfg := &config.Config{JWTSecret: "testsecret"}
+	handler := NewMatchHandler(application.NewMatchingService(&mockDriverLocationServiceForHandler{}))
+
+	e := echo.New()
+	e.Use(middleware.JWTAuthMiddleware(cfg))
+	e.POST("/api/v1/match", handler.Match)
+
+	withScope := generateJWT(cfg.JWTSecret, jwt.MapClaims{"user_id": "user-1", "authenticated": true, "scope": "match:read match:candidates"})
+	withoutScope := generateJWT(cfg.JWTSecret, jwt.MapClaims{"user_id": "user-1", "authenticated": true})
+	for _, tc := range []struct {
+		name       string
+		token      string
+		body       string
+		expected   int
+		candidates bool
+	}{
+		{name: "with scope", token: withScope, body: `{"location": {"type": "Point", "coordinates": [28.9, 41.0]}, "radius": 500, "include_candidates": true}`, expected: http.StatusOK, candidates: true},
+		{name: "without scope", token: withoutScope, body: `{"location": {"type": "Point", "coordinates": [28.9, 41.0]}, "radius": 500, "include_candidates": true}`, expected: http.StatusForbidden},
+		{name: "not asked for", token: withScope, body: `{"location": {"type": "Point", "coordinates": [28.9, 41.0]}, "radius": 500}`, expected: http.StatusOK},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/match", strings.NewReader(tc.body))
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+tc.token)
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			w := httptest.NewRecorder()
+
+			e.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expected, w.Code)
+			if tc.candidates {
+				assert.Contains(t, w.Body.String(), `"candidates":[{"driver_id":"driver-1","distance":100,"eta_seconds":12}]`)
+			} else {
+				assert.NotContains(t, w.Body.String(), `"candidates"`)
+			}
+		})
+	}
+}
+
+// TestMatchHandler_IdempotencyKey tests retrying a match request with an Idempotency-Key
+// Expected: Should replay the first match for the same request, refuse the key with another request and refuse overlong keys
+func TestMatchHandler_IdempotencyKey(t *testing.T) {
+	cfg := &config.Config{JWTSecret: "testsecret"}
+	service := application.NewMatchingService(&mockDriverLocationServiceForHandler{})
+	service.SetIdempotency(application.Idempotency{Store: idempotency.NewMemoryStore()})
+	e := echo.New()
+	e.Use(middleware.JWTAuthMiddleware(cfg))
+	e.POST("/api/v1/match", NewMatchHandler(service).Match)
+
+	token := generateJWT(cfg.JWTSecret, jwt.MapClaims{"user_id": "user-1", "authenticated": true})
+	match := func(key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/match", strings.NewReader(body))
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set("Idempotency-Key", key)
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+		return w
+	}
+	body := `{"location": {"type": "Point", "coordinates": [28.9, 41.0]}, "radius": 500}`
+
+	first := match("key-1", body)
+	require.Equal(t, http.StatusOK, first.Code)
+	assert.Empty(t, first.Header().Get("Idempotent-Replayed"))
+
+	again := match("key-1", body)
+	require.Equal(t, http.StatusOK, again.Code)
+	assert.Equal(t, "true", again.Header().Get("Idempotent-Replayed"))
+	assert.JSONEq(t, first.Body.String(), again.Body.String())
+
+	w := match("key-1", `{"location": {"type": "Point", "coordinates": [28.9, 41.0]}, "radius": 1000}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), "idempotency_key_reused")
+
+	w = match(strings.Repeat("k", domain.MaxIdempotencyKeyLength+1), body)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
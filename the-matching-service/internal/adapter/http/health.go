@@ -0,0 +1,97 @@
+package httpadapter
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"the-matching-service/internal/domain"
+)
+
+const (
+	UpstreamUp   = "up"
+	UpstreamDown = "down"
+)
+
+// UpstreamHealth is the state of the driver location service reported by the
+// health check
+type UpstreamHealth struct {
+	Status    string            `json:"status"` // up or down
+	Error     string            `json:"error,omitempty"`
+	LatencyMs float64           `json:"latency_ms"`
+	CheckedAt time.Time         `json:"checked_at"`
+	Breakers  map[string]string `json:"circuit_breakers"` // operation -> closed, half-open or open
+}
+
+// Healthy reports whether the upstream answered its probe and no operation has
+// an open breaker
+func (h UpstreamHealth) Healthy() bool {
+	if h.Status != UpstreamUp {
+		return false
+	}
+	for _, state := range h.Breakers {
+		if state != "closed" {
+			return false
+		}
+	}
+	return true
+}
+
+// UpstreamProbe probes the driver location service for the health check. A
+// probe result is reused for ttl so the health checks of every orchestrator and
+// load balancer do not add up to load on the upstream, the breaker states are
+// always current.
+type UpstreamProbe struct {
+	client  *DriverLocationClient
+	timeout time.Duration
+	ttl     time.Duration
+
+	mu   sync.Mutex
+	last *UpstreamHealth
+}
+
+func NewUpstreamProbe(client *DriverLocationClient, timeout, ttl time.Duration) *UpstreamProbe {
+	return &UpstreamProbe{client: client, timeout: timeout, ttl: ttl}
+}
+
+// Check returns the cached probe result or probes the upstream once it
+// expired, concurrent health checks wait for the same probe
+func (p *UpstreamProbe) Check(ctx context.Context) UpstreamHealth {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.last == nil || time.Since(p.last.CheckedAt) >= p.ttl {
+		p.last = p.probe(ctx)
+	}
+	health := *p.last
+	health.Breakers = p.client.BreakerStates()
+	return health
+}
+
+// Ping fails while the driver location service does not answer its probe, it
+// is the readiness check of the upstream
+func (p *UpstreamProbe) Ping(ctx context.Context) error {
+	if health := p.Check(ctx); health.Status != UpstreamUp {
+		return errors.New(health.Error)
+	}
+	return nil
+}
+
+func (p *UpstreamProbe) probe(ctx context.Context) *UpstreamHealth {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := p.client.CheckHealth(ctx)
+	health := &UpstreamHealth{
+		Status:    UpstreamUp,
+		LatencyMs: domain.Milliseconds(time.Since(start)),
+		CheckedAt: start,
+	}
+	if err != nil {
+		health.Status = UpstreamDown
+		health.Error = err.Error()
+	}
+	return health
+}
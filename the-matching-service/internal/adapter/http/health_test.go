@@ -0,0 +1,101 @@
+package httpadapter
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"the-matching-service/internal/application"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestUpstreamProbe_Check tests probing a healthy driver location service
+// Expected: Should report it up with closed breakers and reuse the result within the TTL
+func TestUpstreamProbe_Check(t *testing.T) {
+	var probes atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/health", r.URL.Path)
+		probes.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	probe := NewUpstreamProbe(NewDriverLocationClient(ts.URL, ""), time.Second, time.Minute)
+	health := probe.Check(context.Background())
+	assert.Equal(t, UpstreamUp, health.Status)
+	assert.Equal(t, map[string]string{OperationSearch: "closed", OperationOutcome: "closed"}, health.Breakers)
+	assert.True(t, health.Healthy())
+
+	probe.Check(context.Background())
+	assert.Equal(t, int32(1), probes.Load())
+}
+
+// TestUpstreamProbe_Down tests probing a failing and a hanging driver location service
+// Expected: Should report it down with the error, the hanging one once the probe timeout passed
+func TestUpstreamProbe_Down(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+
+	health := NewUpstreamProbe(NewDriverLocationClient(failing.URL, ""), time.Second, 0).Check(context.Background())
+	assert.Equal(t, UpstreamDown, health.Status)
+	assert.Contains(t, health.Error, "503")
+	assert.False(t, health.Healthy())
+
+	release := make(chan struct{})
+	hanging := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer hanging.Close()
+	defer close(release)
+
+	start := time.Now()
+	health = NewUpstreamProbe(NewDriverLocationClient(hanging.URL, ""), 50*time.Millisecond, 0).Check(context.Background())
+	assert.Equal(t, UpstreamDown, health.Status)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+// TestUpstreamHealth_Healthy tests the upstream health with an open breaker
+// Expected: Should not be healthy while a breaker is not closed even when the probe succeeded
+func TestUpstreamHealth_Healthy(t *testing.T) {
+	health := UpstreamHealth{Status: UpstreamUp, Breakers: map[string]string{OperationSearch: "open", OperationOutcome: "closed"}}
+	assert.False(t, health.Healthy())
+}
+
+// TestMatchHandler_HealthCheck_Upstream tests the health check with the upstream probe
+// Expected: Should stay 200 OK and report the service degraded with the upstream state while it is down
+func TestMatchHandler_HealthCheck_Upstream(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	handler := NewMatchHandler(application.NewMatchingService(&mockDriverLocationServiceForHandler{}))
+	handler.SetUpstreamProbe(NewUpstreamProbe(NewDriverLocationClient(ts.URL, ""), time.Second, time.Minute))
+
+	e := echo.New()
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/health", nil), rec)
+	require.NoError(t, handler.HealthCheck(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var body struct {
+		Status   string         `json:"status"`
+		Upstream UpstreamHealth `json:"upstream"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "degraded", body.Status)
+	assert.Equal(t, UpstreamDown, body.Upstream.Status)
+	assert.Equal(t, "closed", body.Upstream.Breakers[OperationSearch])
+}
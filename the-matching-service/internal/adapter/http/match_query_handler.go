@@ -0,0 +1,140 @@
+package httpadapter
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"the-matching-service/internal/application"
+	"the-matching-service/internal/domain"
+
+	"github.com/labstack/echo/v4"
+)
+
+type MatchQueryHandler struct {
+	matchQueryService *application.MatchQueryService
+}
+
+func NewMatchQueryHandler(matchQueryService *application.MatchQueryService) *MatchQueryHandler {
+	return &MatchQueryHandler{matchQueryService: matchQueryService}
+}
+
+// ListMatches godoc
+// @Summary List the matches of the rider
+// @Description Get the stored matches of the authenticated rider, the most recent first
+// @Tags matching
+// @Produce json
+// @Param limit query int false "Matches per page, at most 100" default(20)
+// @Param offset query int false "Matches to skip" default(0)
+// @Success 200 {object} domain.SuccessResponse "Success: data contains a MatchPage"
+// @Failure 400 {object} domain.ErrorResponse "Bad Request - Invalid limit or offset"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized - User not authenticated"
+// @Failure 403 {object} domain.ErrorResponse "Forbidden - without the rider scope once JWT_ENFORCE_SCOPES is set"
+// @Failure 429 {object} domain.ErrorResponse "Too Many Requests - Rate limit of the user exceeded, retry after Retry-After seconds"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Security BearerAuth
+// @Router /api/v1/matches [get]
+func (h *MatchQueryHandler) ListMatches(c echo.Context) error {
+	riderID, ok := authenticatedUser(c)
+	if !ok {
+		return unauthenticatedResponse(c)
+	}
+
+	limit, err := intQueryParam(c, "limit")
+	if err != nil {
+		return invalidQueryResponse(c, "limit must be a number")
+	}
+	offset, err := intQueryParam(c, "offset")
+	if err != nil || offset < 0 {
+		return invalidQueryResponse(c, "offset must be a non negative number")
+	}
+
+	page, err := h.matchQueryService.List(c.Request().Context(), riderID, limit, offset)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, domain.ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, domain.SuccessResponse{
+		Success: true,
+		Data:    page,
+		Message: "Matches retrieved successfully",
+	})
+}
+
+// GetMatch godoc
+// @Summary Get a match of the rider
+// @Description Get a stored match of the authenticated rider by its ID
+// @Tags matching
+// @Produce json
+// @Param id path string true "Match ID"
+// @Success 200 {object} domain.SuccessResponse "Success: data contains the MatchResult"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized - User not authenticated"
+// @Failure 403 {object} domain.ErrorResponse "Forbidden - without the rider scope once JWT_ENFORCE_SCOPES is set"
+// @Failure 429 {object} domain.ErrorResponse "Too Many Requests - Rate limit of the user exceeded, retry after Retry-After seconds"
+// @Failure 404 {object} domain.ErrorResponse "Not Found - Unknown or expired match"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Security BearerAuth
+// @Router /api/v1/matches/{id} [get]
+func (h *MatchQueryHandler) GetMatch(c echo.Context) error {
+	riderID, ok := authenticatedUser(c)
+	if !ok {
+		return unauthenticatedResponse(c)
+	}
+
+	result, err := h.matchQueryService.Get(c.Request().Context(), riderID, c.Param("id"))
+	if errors.Is(err, domain.ErrMatchNotFound) {
+		return c.JSON(http.StatusNotFound, domain.ErrorResponse{
+			Success: false,
+			Error:   "not_found",
+			Message: "Match not found",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, domain.ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, domain.SuccessResponse{
+		Success: true,
+		Data:    result,
+		Message: "Match retrieved successfully",
+	})
+}
+
+func authenticatedUser(c echo.Context) (string, bool) {
+	isAuth, _ := c.Get("is_authenticated").(bool)
+	userID, _ := c.Get("user_id").(string)
+	return userID, isAuth && userID != ""
+}
+
+func unauthenticatedResponse(c echo.Context) error {
+	return c.JSON(http.StatusUnauthorized, domain.ErrorResponse{
+		Success: false,
+		Error:   "unauthorized",
+		Message: "User not authenticated",
+	})
+}
+
+func invalidQueryResponse(c echo.Context, message string) error {
+	return c.JSON(http.StatusBadRequest, domain.ErrorResponse{
+		Success: false,
+		Error:   "invalid_request",
+		Message: message,
+	})
+}
+
+// intQueryParam returns 0 for a missing parameter
+func intQueryParam(c echo.Context, name string) (int, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(value)
+}
@@ -0,0 +1,164 @@
+package httpadapter
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"the-matching-service/config"
+	"the-matching-service/internal/application"
+	"the-matching-service/internal/domain"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryMatchStore struct {
+	matches []domain.MatchResult // in match order
+}
+
+func (s *memoryMatchStore) Save(ctx context.Context, result domain.MatchResult) error {
+	s.matches = append(s.matches, result)
+	return nil
+}
+
+func (s *memoryMatchStore) Get(ctx context.Context, id string) (*domain.MatchResult, error) {
+	for _, result := range s.matches {
+		if result.ID == id {
+			return &result, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *memoryMatchStore) Update(ctx context.Context, id string, change func(result *domain.MatchResult) error) (*domain.MatchResult, error) {
+	for i, result := range s.matches {
+		if result.ID == id {
+			if err := change(&result); err != nil {
+				return nil, err
+			}
+			s.matches[i] = result
+			return &result, nil
+		}
+	}
+	return nil, domain.ErrMatchNotFound
+}
+
+func (s *memoryMatchStore) ListByRider(ctx context.Context, riderID string, offset, limit int) ([]domain.MatchResult, int64, error) {
+	var results []domain.MatchResult
+	for i := len(s.matches) - 1; i >= 0; i-- {
+		if s.matches[i].RiderID == riderID {
+			results = append(results, s.matches[i])
+		}
+	}
+	total := int64(len(results))
+	if offset >= len(results) {
+		return []domain.MatchResult{}, total, nil
+	}
+	return results[offset:min(offset+limit, len(results))], total, nil
+}
+
+func newMatchQueryTestServer(cfg *config.Config) *echo.Echo {
+	store := &memoryMatchStore{}
+	matchingService := application.NewMatchingService(&mockDriverLocationServiceForHandler{})
+	matchingService.SetMatchStore(store)
+
+	// NewRouter registers the prometheus middleware, which can only happen once per process
+	router := &Router{echo: echo.New(), handler: NewMatchHandler(matchingService), config: cfg}
+	router.setupRoutes(cfg)
+	router.SetupMatchQueryRoutes(NewMatchQueryHandler(application.NewMatchQueryService(store)))
+	return router.GetEcho()
+}
+
+func serveRider(e *echo.Echo, method, path, body, userID string) *httptest.ResponseRecorder {
+	token := generateJWT("testsecret", jwt.MapClaims{"user_id": userID, "authenticated": true})
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	return w
+}
+
+// TestMatchQueryHandler_Lifecycle tests looking up matches after matching
+// Expected: Should list and return the rider's matches and hide them from other riders
+func TestMatchQueryHandler_Lifecycle(t *testing.T) {
+	e := newMatchQueryTestServer(&config.Config{JWTSecret: "testsecret"})
+	matchBody := `{"location": {"type": "Point", "coordinates": [28.9, 41.0]}, "radius": 500}`
+
+	var matchIDs []string
+	for i := 0; i < 3; i++ {
+		w := serveRider(e, http.MethodPost, "/api/v1/match", matchBody, "user-1")
+		require.Equal(t, http.StatusOK, w.Code)
+		var matched struct {
+			Data domain.MatchResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &matched))
+		require.NotEmpty(t, matched.Data.MatchID)
+		matchIDs = append(matchIDs, matched.Data.MatchID)
+	}
+
+	w := serveRider(e, http.MethodGet, "/api/v1/matches?limit=2&offset=1", "", "user-1")
+	require.Equal(t, http.StatusOK, w.Code)
+	var listed struct {
+		Data struct {
+			Matches []map[string]interface{} `json:"matches"`
+			Total   int64                    `json:"total"`
+			Limit   int                      `json:"limit"`
+			Offset  int                      `json:"offset"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listed))
+	assert.Equal(t, int64(3), listed.Data.Total)
+	assert.Equal(t, 2, listed.Data.Limit)
+	assert.Equal(t, 1, listed.Data.Offset)
+	require.Len(t, listed.Data.Matches, 2)
+	assert.Equal(t, matchIDs[1], listed.Data.Matches[0]["id"])
+	assert.Equal(t, "driver-1", listed.Data.Matches[0]["driver_id"])
+	assert.NotEmpty(t, listed.Data.Matches[0]["matched_at"])
+
+	w = serveRider(e, http.MethodGet, "/api/v1/matches/"+matchIDs[0], "", "user-1")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), matchIDs[0])
+
+	w = serveRider(e, http.MethodGet, "/api/v1/matches/"+matchIDs[0], "", "user-2")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = serveRider(e, http.MethodGet, "/api/v1/matches", "", "user-2")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"total":0`)
+}
+
+// TestMatchQueryHandler_InvalidPaging tests malformed paging parameters
+// Expected: Should return 400 Bad Request
+func TestMatchQueryHandler_InvalidPaging(t *testing.T) {
+	e := newMatchQueryTestServer(&config.Config{JWTSecret: "testsecret"})
+
+	for _, query := range []string{"limit=ten", "offset=-1"} {
+		w := serveRider(e, http.MethodGet, "/api/v1/matches?"+query, "", "user-1")
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+// TestMatchQueryHandler_Unauthorized tests listing matches without an authenticated token
+// Expected: Should return 401 Unauthorized
+func TestMatchQueryHandler_Unauthorized(t *testing.T) {
+	e := newMatchQueryTestServer(&config.Config{JWTSecret: "testsecret"})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/matches", nil)
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	token := generateJWT("testsecret", jwt.MapClaims{"user_id": "user-1"})
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/matches", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
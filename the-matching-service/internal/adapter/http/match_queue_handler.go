@@ -0,0 +1,117 @@
+package httpadapter
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"the-matching-service/internal/application"
+	"the-matching-service/internal/domain"
+	"the-matching-service/internal/ports/secondary"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/net/websocket"
+)
+
+// MatchQueueHandler lets riders follow their wait in the match queue
+type MatchQueueHandler struct {
+	queue  *application.MatchQueue
+	logger secondary.Logger
+}
+
+func NewMatchQueueHandler(queue *application.MatchQueue) *MatchQueueHandler {
+	return &MatchQueueHandler{queue: queue, logger: secondary.NopLogger{}}
+}
+
+// SetLogger sets where streams closed by a failure are logged
+func (h *MatchQueueHandler) SetLogger(logger secondary.Logger) {
+	h.logger = logger
+}
+
+// GetQueueEntry godoc
+// @Summary Get a wait in the match queue
+// @Description Get the status of the authenticated rider's queue entry, the position while waiting and the match once matched. Finished entries are kept for the wait window.
+// @Tags matching
+// @Produce json
+// @Param id path string true "Queue entry ID"
+// @Success 200 {object} domain.SuccessResponse "Success: data contains the QueueEntry"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized - User not authenticated"
+// @Failure 403 {object} domain.ErrorResponse "Forbidden - without the rider scope once JWT_ENFORCE_SCOPES is set"
+// @Failure 429 {object} domain.ErrorResponse "Too Many Requests - Rate limit of the user exceeded, retry after Retry-After seconds"
+// @Failure 404 {object} domain.ErrorResponse "Not Found - Unknown queue entry"
+// @Security BearerAuth
+// @Router /api/v1/match/queue/{id} [get]
+func (h *MatchQueueHandler) GetQueueEntry(c echo.Context) error {
+	riderID, ok := authenticatedUser(c)
+	if !ok {
+		return unauthenticatedResponse(c)
+	}
+
+	entry, err := h.queue.Get(riderID, c.Param("id"))
+	if errors.Is(err, domain.ErrQueueEntryNotFound) {
+		return queueEntryNotFoundResponse(c)
+	}
+
+	return c.JSON(http.StatusOK, domain.SuccessResponse{
+		Success: true,
+		Data:    entry,
+		Message: "Queue entry retrieved successfully",
+	})
+}
+
+// StreamQueueEntry godoc
+// @Summary Watch a wait in the match queue
+// @Description Upgrade to a WebSocket that receives the queue entry once right away and once more when the rider is matched or the wait expires, then closes
+// @Tags matching
+// @Param id path string true "Queue entry ID"
+// @Success 101 {object} domain.QueueEntry
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized - User not authenticated"
+// @Failure 403 {object} domain.ErrorResponse "Forbidden - without the rider scope once JWT_ENFORCE_SCOPES is set"
+// @Failure 429 {object} domain.ErrorResponse "Too Many Requests - Rate limit of the user exceeded, retry after Retry-After seconds"
+// @Failure 404 {object} domain.ErrorResponse "Not Found - Unknown queue entry"
+// @Security BearerAuth
+// @Router /api/v1/match/queue/{id}/stream [get]
+func (h *MatchQueueHandler) StreamQueueEntry(c echo.Context) error {
+	riderID, ok := authenticatedUser(c)
+	if !ok {
+		return unauthenticatedResponse(c)
+	}
+
+	entry, updates, stop, err := h.queue.Watch(riderID, c.Param("id"))
+	if errors.Is(err, domain.ErrQueueEntryNotFound) {
+		return queueEntryNotFoundResponse(c)
+	}
+	defer stop()
+
+	server := websocket.Server{
+		// rider apps are authenticated by the JWT middleware instead of the Origin header
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(ws *websocket.Conn) {
+			defer ws.Close()
+			// the server read and write timeouts are still set on the hijacked connection
+			ws.SetWriteDeadline(time.Time{})
+			if err := websocket.JSON.Send(ws, entry); err != nil {
+				return
+			}
+			select {
+			case finished, ok := <-updates:
+				if ok {
+					if err := websocket.JSON.Send(ws, finished); err != nil {
+						h.logger.Info(ws.Request().Context(), "queue stream closed", "entry_id", entry.ID, "error", err)
+					}
+				}
+			case <-ws.Request().Context().Done():
+			}
+		},
+	}
+	server.ServeHTTP(c.Response(), c.Request())
+	return nil
+}
+
+func queueEntryNotFoundResponse(c echo.Context) error {
+	return c.JSON(http.StatusNotFound, domain.ErrorResponse{
+		Success: false,
+		Error:   "not_found",
+		Message: "Queue entry not found",
+	})
+}
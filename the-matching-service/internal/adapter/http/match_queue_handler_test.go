@@ -0,0 +1,72 @@
+package httpadapter
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"the-matching-service/config"
+	"the-matching-service/internal/application"
+	"the-matching-service/internal/domain"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMatchQueueTestServer(cfg *config.Config) *echo.Echo {
+	matchingService := application.NewMatchingService(&mockDriverLocationServiceForHandlerNoDrivers{})
+	queue := application.NewMatchQueue(matchingService, time.Minute)
+	handler := NewMatchHandler(matchingService)
+	handler.SetMatchQueue(queue)
+
+	// NewRouter registers the prometheus middleware, which can only happen once per process
+	router := &Router{echo: echo.New(), handler: handler, config: cfg}
+	router.setupRoutes(cfg)
+	router.SetupMatchQueueRoutes(NewMatchQueueHandler(queue))
+	return router.GetEcho()
+}
+
+// TestMatchQueueHandler_Wait tests matching with no driver nearby for riders that do and do not ask to wait
+// Expected: Should answer 404 without wait, 202 with the queue entry with wait and return the entry to its rider only
+func TestMatchQueueHandler_Wait(t *testing.T) {
+	e := newMatchQueueTestServer(&config.Config{JWTSecret: "testsecret"})
+
+	w := serveRider(e, http.MethodPost, "/api/v1/match", `{"location": {"type": "Point", "coordinates": [28.9, 41.0]}, "radius": 500}`, "user-1")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = serveRider(e, http.MethodPost, "/api/v1/match", `{"location": {"type": "Point", "coordinates": [28.9, 41.0]}, "radius": 500, "wait": true}`, "user-1")
+	require.Equal(t, http.StatusAccepted, w.Code)
+	var queued struct {
+		Data domain.QueueEntry `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &queued))
+	assert.NotEmpty(t, queued.Data.ID)
+	assert.Equal(t, "user-1", queued.Data.RiderID)
+	assert.Equal(t, domain.QueueWaiting, queued.Data.Status)
+	assert.Equal(t, 1, queued.Data.Position)
+
+	w = serveRider(e, http.MethodGet, "/api/v1/match/queue/"+queued.Data.ID, "", "user-1")
+	require.Equal(t, http.StatusOK, w.Code)
+	var got struct {
+		Data domain.QueueEntry `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+	assert.Equal(t, queued.Data.ID, got.Data.ID)
+	assert.Equal(t, domain.QueueWaiting, got.Data.Status)
+
+	w = serveRider(e, http.MethodGet, "/api/v1/match/queue/"+queued.Data.ID, "", "user-2")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+// TestMatchQueueHandler_Disabled tests asking to wait while the match queue is off
+// Expected: Should answer 404 no drivers found as before
+func TestMatchQueueHandler_Disabled(t *testing.T) {
+	cfg := &config.Config{JWTSecret: "testsecret"}
+	router := &Router{echo: echo.New(), handler: NewMatchHandler(application.NewMatchingService(&mockDriverLocationServiceForHandlerNoDrivers{})), config: cfg}
+	router.setupRoutes(cfg)
+
+	w := serveRider(router.GetEcho(), http.MethodPost, "/api/v1/match", `{"location": {"type": "Point", "coordinates": [28.9, 41.0]}, "radius": 500, "wait": true}`, "user-1")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
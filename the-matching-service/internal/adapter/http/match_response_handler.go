@@ -0,0 +1,186 @@
+package httpadapter
+
+import (
+	"errors"
+	"net/http"
+
+	"the-matching-service/internal/application"
+	"the-matching-service/internal/domain"
+
+	"github.com/labstack/echo/v4"
+)
+
+// MatchResponseHandler lets drivers answer the matches proposed to them and end
+// them, drivers authenticate with a JWT whose user_id is their driver ID
+type MatchResponseHandler struct {
+	matchingService *application.MatchingService
+}
+
+func NewMatchResponseHandler(matchingService *application.MatchingService) *MatchResponseHandler {
+	return &MatchResponseHandler{matchingService: matchingService}
+}
+
+// AcceptMatch godoc
+// @Summary Accept a proposed match
+// @Description Confirm a match proposed to the authenticated driver before it times out
+// @Tags matching
+// @Produce json
+// @Param id path string true "Match ID"
+// @Success 200 {object} domain.SuccessResponse "Success: data contains the accepted MatchResult"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized - User not authenticated"
+// @Failure 403 {object} domain.ErrorResponse "Forbidden - without the driver scope once JWT_ENFORCE_SCOPES is set"
+// @Failure 429 {object} domain.ErrorResponse "Too Many Requests - Rate limit of the user exceeded, retry after Retry-After seconds"
+// @Failure 404 {object} domain.ErrorResponse "Not Found - Unknown match or a match of another driver"
+// @Failure 409 {object} domain.ErrorResponse "Conflict - Match accepted, rejected or timed out already"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Security BearerAuth
+// @Router /api/v1/matches/{id}/accept [post]
+func (h *MatchResponseHandler) AcceptMatch(c echo.Context) error {
+	driverID, ok := authenticatedUser(c)
+	if !ok {
+		return unauthenticatedResponse(c)
+	}
+
+	result, err := h.matchingService.AcceptMatch(c.Request().Context(), driverID, c.Param("id"))
+	if err != nil {
+		return answerErrorResponse(c, err)
+	}
+	matchAnswersTotal.WithLabelValues(domain.MatchAccepted).Inc()
+
+	return c.JSON(http.StatusOK, domain.SuccessResponse{
+		Success: true,
+		Data:    result,
+		Message: "Match accepted successfully",
+	})
+}
+
+// RejectMatch godoc
+// @Summary Reject a proposed match
+// @Description Decline a match proposed to the authenticated driver, the rider is proposed to the next driver while re-matches are left. The response holds the match after the re-match.
+// @Tags matching
+// @Produce json
+// @Param id path string true "Match ID"
+// @Success 200 {object} domain.SuccessResponse "Success: data contains the MatchResult"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized - User not authenticated"
+// @Failure 403 {object} domain.ErrorResponse "Forbidden - without the driver scope once JWT_ENFORCE_SCOPES is set"
+// @Failure 429 {object} domain.ErrorResponse "Too Many Requests - Rate limit of the user exceeded, retry after Retry-After seconds"
+// @Failure 404 {object} domain.ErrorResponse "Not Found - Unknown match or a match of another driver"
+// @Failure 409 {object} domain.ErrorResponse "Conflict - Match accepted, rejected or timed out already"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Security BearerAuth
+// @Router /api/v1/matches/{id}/reject [post]
+func (h *MatchResponseHandler) RejectMatch(c echo.Context) error {
+	driverID, ok := authenticatedUser(c)
+	if !ok {
+		return unauthenticatedResponse(c)
+	}
+
+	result, err := h.matchingService.RejectMatch(c.Request().Context(), driverID, c.Param("id"))
+	if err != nil {
+		return answerErrorResponse(c, err)
+	}
+	matchAnswersTotal.WithLabelValues(domain.MatchRejected).Inc()
+
+	return c.JSON(http.StatusOK, domain.SuccessResponse{
+		Success: true,
+		Data:    result,
+		Message: "Match rejected successfully",
+	})
+}
+
+// CompleteMatch godoc
+// @Summary Complete an accepted match
+// @Description End the ride of a match the authenticated driver accepted, the outcome is reported to the driver location service
+// @Tags matching
+// @Produce json
+// @Param id path string true "Match ID"
+// @Success 200 {object} domain.SuccessResponse "Success: data contains the completed MatchResult"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized - User not authenticated"
+// @Failure 403 {object} domain.ErrorResponse "Forbidden - without the driver scope once JWT_ENFORCE_SCOPES is set"
+// @Failure 429 {object} domain.ErrorResponse "Too Many Requests - Rate limit of the user exceeded, retry after Retry-After seconds"
+// @Failure 404 {object} domain.ErrorResponse "Not Found - Unknown match or a match of another driver"
+// @Failure 409 {object} domain.ErrorResponse "Conflict - Match not accepted or ended already"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Security BearerAuth
+// @Router /api/v1/matches/{id}/complete [post]
+func (h *MatchResponseHandler) CompleteMatch(c echo.Context) error {
+	driverID, ok := authenticatedUser(c)
+	if !ok {
+		return unauthenticatedResponse(c)
+	}
+
+	result, err := h.matchingService.CompleteMatch(c.Request().Context(), driverID, c.Param("id"))
+	if err != nil {
+		return answerErrorResponse(c, err)
+	}
+	matchAnswersTotal.WithLabelValues(domain.MatchCompleted).Inc()
+
+	return c.JSON(http.StatusOK, domain.SuccessResponse{
+		Success: true,
+		Data:    result,
+		Message: "Match completed successfully",
+	})
+}
+
+// CancelMatch godoc
+// @Summary Cancel a match
+// @Description Cancel a match of the authenticated rider while it is proposed or accepted, or an accepted match of the authenticated driver. Only driver cancellations are reported to the driver location service.
+// @Tags matching
+// @Produce json
+// @Param id path string true "Match ID"
+// @Success 200 {object} domain.SuccessResponse "Success: data contains the cancelled MatchResult"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized - User not authenticated"
+// @Failure 403 {object} domain.ErrorResponse "Forbidden - without the rider or driver scope once JWT_ENFORCE_SCOPES is set"
+// @Failure 429 {object} domain.ErrorResponse "Too Many Requests - Rate limit of the user exceeded, retry after Retry-After seconds"
+// @Failure 404 {object} domain.ErrorResponse "Not Found - Unknown match or a match of another rider or driver"
+// @Failure 409 {object} domain.ErrorResponse "Conflict - Match cannot be cancelled anymore"
+// @Failure 500 {object} domain.ErrorResponse "Internal Server Error"
+// @Security BearerAuth
+// @Router /api/v1/matches/{id}/cancel [post]
+func (h *MatchResponseHandler) CancelMatch(c echo.Context) error {
+	userID, ok := authenticatedUser(c)
+	if !ok {
+		return unauthenticatedResponse(c)
+	}
+
+	result, err := h.matchingService.CancelMatch(c.Request().Context(), userID, c.Param("id"))
+	if err != nil {
+		return answerErrorResponse(c, err)
+	}
+	matchAnswersTotal.WithLabelValues(domain.MatchCancelled).Inc()
+
+	return c.JSON(http.StatusOK, domain.SuccessResponse{
+		Success: true,
+		Data:    result,
+		Message: "Match cancelled successfully",
+	})
+}
+
+func answerErrorResponse(c echo.Context, err error) error {
+	if errors.Is(err, domain.ErrMatchNotFound) {
+		return c.JSON(http.StatusNotFound, domain.ErrorResponse{
+			Success: false,
+			Error:   "not_found",
+			Message: "Match not found",
+		})
+	}
+	if errors.Is(err, domain.ErrMatchNotProposed) {
+		return c.JSON(http.StatusConflict, domain.ErrorResponse{
+			Success: false,
+			Error:   "match_not_proposed",
+			Message: "Match is not awaiting an answer",
+		})
+	}
+	if errors.Is(err, domain.ErrMatchNotActive) {
+		return c.JSON(http.StatusConflict, domain.ErrorResponse{
+			Success: false,
+			Error:   "match_not_active",
+			Message: "Match is not in progress",
+		})
+	}
+	return c.JSON(http.StatusInternalServerError, domain.ErrorResponse{
+		Success: false,
+		Error:   "internal_error",
+		Message: err.Error(),
+	})
+}
@@ -0,0 +1,160 @@
+package httpadapter
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"the-matching-service/config"
+	"the-matching-service/internal/application"
+	"the-matching-service/internal/domain"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type twoDriversLocationService struct{}
+
+func (twoDriversLocationService) FindNearbyDrivers(ctx context.Context, location domain.Location, radius float64, limit int, preferences domain.RiderPreferences) ([]domain.DriverDistancePair, error) {
+	return []domain.DriverDistancePair{
+		{Driver: domain.Driver{ID: "driver-1"}, Distance: 100},
+		{Driver: domain.Driver{ID: "driver-2"}, Distance: 200},
+	}, nil
+}
+
+func newMatchResponseTestServer(cfg *config.Config, timeout time.Duration) *echo.Echo {
+	store := &memoryMatchStore{}
+	matchingService := application.NewMatchingService(twoDriversLocationService{})
+	matchingService.SetMatchStore(store)
+	matchingService.SetMatchWorkflow(application.MatchWorkflow{ProposalTimeout: timeout, MaxRematches: 1})
+	matchQueryService := application.NewMatchQueryService(store)
+	matchQueryService.SetMatchingService(matchingService)
+
+	// NewRouter registers the prometheus middleware, which can only happen once per process
+	router := &Router{echo: echo.New(), handler: NewMatchHandler(matchingService), config: cfg}
+	router.setupRoutes(cfg)
+	router.SetupMatchQueryRoutes(NewMatchQueryHandler(matchQueryService))
+	router.SetupMatchResponseRoutes(NewMatchResponseHandler(matchingService))
+	return router.GetEcho()
+}
+
+func matchResultOf(t *testing.T, w interface{ Bytes() []byte }) domain.MatchResult {
+	var body struct {
+		Data domain.MatchResult `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Bytes(), &body))
+	return body.Data
+}
+
+// TestMatchResponseHandler_RejectThenAccept tests a match rejected by the first driver and accepted by the next
+// Expected: Should propose the match to the next driver on rejection, let only that driver accept it and refuse further answers
+func TestMatchResponseHandler_RejectThenAccept(t *testing.T) {
+	e := newMatchResponseTestServer(&config.Config{JWTSecret: "testsecret"}, time.Minute)
+
+	w := serveRider(e, http.MethodPost, "/api/v1/match", `{"location": {"type": "Point", "coordinates": [28.9, 41.0]}, "radius": 500}`, "user-1")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"proposed"`)
+	var matched struct {
+		Data domain.MatchResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &matched))
+	id := matched.Data.MatchID
+	assert.Equal(t, "driver-1", matched.Data.Driver)
+
+	w = serveRider(e, http.MethodPost, "/api/v1/matches/"+id+"/accept", "", "driver-2")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = serveRider(e, http.MethodPost, "/api/v1/matches/"+id+"/reject", "", "driver-1")
+	require.Equal(t, http.StatusOK, w.Code)
+	rematched := matchResultOf(t, w.Body)
+	assert.Equal(t, "driver-2", rematched.DriverID)
+	assert.Equal(t, domain.MatchProposed, rematched.Status)
+	assert.Equal(t, []string{"driver-1"}, rematched.DeclinedDrivers)
+	assert.Equal(t, 1, rematched.Rematches)
+
+	w = serveRider(e, http.MethodPost, "/api/v1/matches/"+id+"/accept", "", "driver-2")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, domain.MatchAccepted, matchResultOf(t, w.Body).Status)
+
+	w = serveRider(e, http.MethodPost, "/api/v1/matches/"+id+"/reject", "", "driver-2")
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = serveRider(e, http.MethodGet, "/api/v1/matches/"+id, "", "user-1")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, domain.MatchAccepted, matchResultOf(t, w.Body).Status)
+}
+
+// TestMatchResponseHandler_Timeout tests proposals the drivers do not answer in time
+// Expected: Should re-match an overdue proposal when the rider reads it, refuse late answers and stay expired once out of re-matches
+func TestMatchResponseHandler_Timeout(t *testing.T) {
+	e := newMatchResponseTestServer(&config.Config{JWTSecret: "testsecret"}, time.Nanosecond)
+
+	w := serveRider(e, http.MethodPost, "/api/v1/match", `{"location": {"type": "Point", "coordinates": [28.9, 41.0]}, "radius": 500}`, "user-1")
+	require.Equal(t, http.StatusOK, w.Code)
+	var matched struct {
+		Data domain.MatchResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &matched))
+	id := matched.Data.MatchID
+
+	w = serveRider(e, http.MethodGet, "/api/v1/matches/"+id, "", "user-1")
+	require.Equal(t, http.StatusOK, w.Code)
+	rematched := matchResultOf(t, w.Body)
+	assert.Equal(t, "driver-2", rematched.DriverID)
+	assert.Equal(t, domain.MatchProposed, rematched.Status)
+
+	// the answer of driver-2 comes too late too and the only re-match is used up
+	w = serveRider(e, http.MethodPost, "/api/v1/matches/"+id+"/accept", "", "driver-2")
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = serveRider(e, http.MethodGet, "/api/v1/matches/"+id, "", "user-1")
+	require.Equal(t, http.StatusOK, w.Code)
+	expired := matchResultOf(t, w.Body)
+	assert.Equal(t, domain.MatchExpired, expired.Status)
+	assert.Equal(t, []string{"driver-1", "driver-2"}, expired.DeclinedDrivers)
+}
+
+// TestMatchResponseHandler_CompleteAndCancel tests ending accepted matches
+// Expected: Should let the driver complete an accepted match only, let the rider cancel a proposal and refuse ending a match twice
+func TestMatchResponseHandler_CompleteAndCancel(t *testing.T) {
+	e := newMatchResponseTestServer(&config.Config{JWTSecret: "testsecret"}, time.Minute)
+	matchBody := `{"location": {"type": "Point", "coordinates": [28.9, 41.0]}, "radius": 500}`
+
+	w := serveRider(e, http.MethodPost, "/api/v1/match", matchBody, "user-1")
+	require.Equal(t, http.StatusOK, w.Code)
+	var matched struct {
+		Data domain.MatchResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &matched))
+	id := matched.Data.MatchID
+
+	w = serveRider(e, http.MethodPost, "/api/v1/matches/"+id+"/complete", "", "driver-1")
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "match_not_active")
+
+	w = serveRider(e, http.MethodPost, "/api/v1/matches/"+id+"/accept", "", "driver-1")
+	require.Equal(t, http.StatusOK, w.Code)
+	w = serveRider(e, http.MethodPost, "/api/v1/matches/"+id+"/complete", "", "driver-2")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = serveRider(e, http.MethodPost, "/api/v1/matches/"+id+"/complete", "", "driver-1")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, domain.MatchCompleted, matchResultOf(t, w.Body).Status)
+
+	w = serveRider(e, http.MethodPost, "/api/v1/matches/"+id+"/cancel", "", "user-1")
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = serveRider(e, http.MethodPost, "/api/v1/match", matchBody, "user-2")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &matched))
+
+	w = serveRider(e, http.MethodPost, "/api/v1/matches/"+matched.Data.MatchID+"/cancel", "", "user-1")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = serveRider(e, http.MethodPost, "/api/v1/matches/"+matched.Data.MatchID+"/cancel", "", "user-2")
+	require.Equal(t, http.StatusOK, w.Code)
+	cancelled := matchResultOf(t, w.Body)
+	assert.Equal(t, domain.MatchCancelled, cancelled.Status)
+	assert.Equal(t, domain.CancelledByRider, cancelled.CancelledBy)
+}
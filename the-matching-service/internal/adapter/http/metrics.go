@@ -0,0 +1,41 @@
+package httpadapter
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// matchesTotal counts match requests per strategy variant so the A/B rollout
+// can be compared on success rate next to the request latency metrics
+var matchesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "matching_service",
+	Name:      "matches_total",
+	Help:      "Number of match requests by strategy variant and outcome.",
+}, []string{"strategy", "outcome"})
+
+// upstreamInvalidDriversTotal counts the drivers of driver location searches
+// skipped for missing or malformed fields instead of failing the match
+var upstreamInvalidDriversTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "matching_service",
+	Name:      "upstream_invalid_drivers_total",
+	Help:      "Number of drivers skipped from driver location search responses by reason.",
+}, []string{"reason"})
+
+// matchAnswersTotal counts the matches drivers accepted or rejected and the
+// matches completed or cancelled
+var matchAnswersTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "matching_service",
+	Name:      "match_answers_total",
+	Help:      "Number of matches answered by drivers, completed or cancelled by answer.",
+}, []string{"answer"})
+
+// matchDurationSeconds times the match requests by area, search radius band,
+// strategy and outcome, so latency and failure rates can be compared across
+// areas without logging where riders are. Requests rejected before the search
+// have no area and are left out.
+var matchDurationSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: "matching_service",
+	Name:      "match_duration_seconds",
+	Help:      "Duration of match requests by coarse geohash area, radius band, strategy and outcome.",
+	Buckets:   prometheus.DefBuckets,
+}, []string{"area", "radius_band", "strategy", "outcome"})
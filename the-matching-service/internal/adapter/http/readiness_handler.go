@@ -0,0 +1,122 @@
+package httpadapter
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"the-matching-service/internal/domain"
+
+	"github.com/labstack/echo/v4"
+)
+
+// DependencyCheck is a dependency pinged by the readiness probe. An instance is
+// not ready while a required dependency is down, an optional one that is down
+// only degrades it.
+type DependencyCheck struct {
+	Name     string
+	Required bool
+	Ping     func(ctx context.Context) error
+}
+
+// DependencyHealth is the result of pinging a dependency
+type DependencyHealth struct {
+	Status    string  `json:"status"` // up or down
+	Required  bool    `json:"required"`
+	Error     string  `json:"error,omitempty"`
+	LatencyMs float64 `json:"latency_ms"`
+}
+
+// ReadinessHandler reports whether the instance can take traffic, unlike the
+// liveness probe it pings the Redis stores and the driver location service
+type ReadinessHandler struct {
+	dependencies []DependencyCheck
+	timeout      time.Duration
+}
+
+func NewReadinessHandler() *ReadinessHandler {
+	return &ReadinessHandler{timeout: 2 * time.Second}
+}
+
+// SetDependencies makes the readiness probe ping the dependencies, each within
+// timeout
+func (h *ReadinessHandler) SetDependencies(timeout time.Duration, dependencies ...DependencyCheck) {
+	if timeout > 0 {
+		h.timeout = timeout
+	}
+	h.dependencies = dependencies
+}
+
+// ReadinessCheck godoc
+// @Summary Readiness check endpoint
+// @Description Check if the service is ready. The configured Redis stores (blocklist, idempotency keys, matches) are required and answer 503 while down, the driver location service only degrades the status: its outage is shared by every instance and handled by the circuit breakers.
+// @Tags health
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 503 {object} map[string]interface{}
+// @Router /health/ready [get]
+func (h *ReadinessHandler) ReadinessCheck(c echo.Context) error {
+	dependencies, ready, degraded := h.checkDependencies(c.Request().Context())
+	data := map[string]interface{}{
+		"status":       "ready",
+		"service":      "matching-service",
+		"dependencies": dependencies,
+	}
+	if !ready {
+		data["status"] = "unavailable"
+		return c.JSON(http.StatusServiceUnavailable, data)
+	}
+	if degraded {
+		data["status"] = "degraded"
+	}
+	return c.JSON(http.StatusOK, data)
+}
+
+// checkDependencies pings the dependencies at once, the instance is not ready
+// when a required one is down and degraded when an optional one is
+func (h *ReadinessHandler) checkDependencies(ctx context.Context) (map[string]DependencyHealth, bool, bool) {
+	results := make([]DependencyHealth, len(h.dependencies))
+	var wg sync.WaitGroup
+	for i, dependency := range h.dependencies {
+		wg.Add(1)
+		go func(i int, dependency DependencyCheck) {
+			defer wg.Done()
+			results[i] = h.ping(ctx, dependency)
+		}(i, dependency)
+	}
+	wg.Wait()
+
+	dependencies := make(map[string]DependencyHealth, len(results))
+	ready, degraded := true, false
+	for i, result := range results {
+		dependencies[h.dependencies[i].Name] = result
+		if result.Status == UpstreamUp {
+			continue
+		}
+		if result.Required {
+			ready = false
+		} else {
+			degraded = true
+		}
+	}
+	return dependencies, ready, degraded
+}
+
+func (h *ReadinessHandler) ping(ctx context.Context, dependency DependencyCheck) DependencyHealth {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := dependency.Ping(ctx)
+	health := DependencyHealth{
+		Status:    UpstreamUp,
+		Required:  dependency.Required,
+		LatencyMs: domain.Milliseconds(time.Since(start)),
+	}
+	if err != nil {
+		health.Status = UpstreamDown
+		health.Error = err.Error()
+	}
+	return health
+}
@@ -0,0 +1,103 @@
+package httpadapter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ping(err error) func(ctx context.Context) error {
+	return func(ctx context.Context) error { return err }
+}
+
+func serveReadiness(t *testing.T, handler *ReadinessHandler) (int, string, map[string]DependencyHealth) {
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/health/ready", nil), rec)
+	require.NoError(t, handler.ReadinessCheck(c))
+
+	var body struct {
+		Status       string                      `json:"status"`
+		Dependencies map[string]DependencyHealth `json:"dependencies"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	return rec.Code, body.Status, body.Dependencies
+}
+
+// TestReadinessCheck_Dependencies tests the readiness probe with the Redis stores and the driver location service up or down
+// Expected: Should be ready with all up, degraded but ready without the driver location service and 503 without a Redis store
+func TestReadinessCheck_Dependencies(t *testing.T) {
+	for _, tc := range []struct {
+		name           string
+		upstream       error
+		redis          error
+		expectedCode   int
+		expectedStatus string
+	}{
+		{name: "all up", expectedCode: http.StatusOK, expectedStatus: "ready"},
+		{name: "upstream down", upstream: errors.New("unexpected status 503"), expectedCode: http.StatusOK, expectedStatus: "degraded"},
+		{name: "redis down", redis: errors.New("connection refused"), expectedCode: http.StatusServiceUnavailable, expectedStatus: "unavailable"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := NewReadinessHandler()
+			handler.SetDependencies(time.Second,
+				DependencyCheck{Name: "driver-location-service", Ping: ping(tc.upstream)},
+				DependencyCheck{Name: "redis-matches", Required: true, Ping: ping(tc.redis)},
+			)
+
+			code, status, dependencies := serveReadiness(t, handler)
+			assert.Equal(t, tc.expectedCode, code)
+			assert.Equal(t, tc.expectedStatus, status)
+			require.Len(t, dependencies, 2)
+			assert.False(t, dependencies["driver-location-service"].Required)
+			assert.True(t, dependencies["redis-matches"].Required)
+			if tc.redis != nil {
+				assert.Equal(t, UpstreamDown, dependencies["redis-matches"].Status)
+				assert.Equal(t, tc.redis.Error(), dependencies["redis-matches"].Error)
+			}
+		})
+	}
+}
+
+// TestReadinessCheck_Timeout tests a dependency that does not answer its ping
+// Expected: Should count it down once the timeout passed instead of hanging the probe
+func TestReadinessCheck_Timeout(t *testing.T) {
+	handler := NewReadinessHandler()
+	handler.SetDependencies(20*time.Millisecond, DependencyCheck{Name: "redis-blocklist", Required: true, Ping: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}})
+
+	start := time.Now()
+	code, _, dependencies := serveReadiness(t, handler)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Contains(t, dependencies["redis-blocklist"].Error, "deadline exceeded")
+}
+
+// TestUpstreamProbe_Ping tests the readiness check of the driver location service
+// Expected: Should pass while it is healthy and fail with the probe error once it answers 503
+func TestUpstreamProbe_Ping(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusOK)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer ts.Close()
+
+	probe := NewUpstreamProbe(NewDriverLocationClient(ts.URL, ""), time.Second, 0)
+	assert.NoError(t, probe.Ping(context.Background()))
+
+	status.Store(http.StatusServiceUnavailable)
+	err := probe.Ping(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "503")
+}
@@ -3,6 +3,8 @@ package httpadapter
 import (
 	"the-matching-service/config"
 	"the-matching-service/internal/adapter/middleware"
+	"the-matching-service/internal/domain"
+	"the-matching-service/internal/ports/secondary"
 
 	"github.com/labstack/echo-contrib/echoprometheus"
 	"github.com/labstack/echo/v4"
@@ -11,21 +13,40 @@ import (
 )
 
 type Router struct {
-	echo    *echo.Echo
-	handler *MatchHandler
+	echo      *echo.Echo
+	handler   *MatchHandler
+	config    *config.Config
+	jwtAuth   echo.MiddlewareFunc
+	rateLimit echo.MiddlewareFunc
+	requests  *ErrorRateTracker
 }
 
 func NewRouter(handler *MatchHandler, cfg *config.Config) *Router {
 	e := echo.New()
+	e.JSONSerializer = JSONCodec{}
 
-	e.Use(echoMiddleware.Logger())
+	// the request ID is written to the access log and to the entries logged
+	// while handling the request, callers get it back in X-Request-ID
+	e.Use(middleware.RequestID())
+	e.Use(middleware.RequestLogFields())
+	e.Use(middleware.AccessLog(NewPrivacy(cfg)))
 	e.Use(echoMiddleware.Recover())
 	e.Use(echoMiddleware.CORS())
-	e.Use(echoprometheus.NewMiddleware("matching_service"))
+	e.Use(middleware.ServiceVersion())
+	e.Use(echoprometheus.NewMiddlewareWithConfig(echoprometheus.MiddlewareConfig{
+		Subsystem:         "matching_service",
+		HistogramOptsFunc: middleware.HistogramBuildLabels,
+		CounterOptsFunc:   middleware.CounterBuildLabels,
+	}))
+	requests := NewErrorRateTracker()
+	e.Use(requests.Middleware())
 
 	r := &Router{
-		echo:    e,
-		handler: handler,
+		echo:     e,
+		handler:  handler,
+		config:   cfg,
+		jwtAuth:  middleware.JWTAuthMiddleware(cfg),
+		requests: requests,
 	}
 
 	r.setupRoutes(cfg)
@@ -35,11 +56,137 @@ func NewRouter(handler *MatchHandler, cfg *config.Config) *Router {
 func (r *Router) setupRoutes(cfg *config.Config) {
 	r.echo.GET("/swagger/*", echoSwagger.WrapHandler)
 	r.echo.GET("/health", r.handler.HealthCheck)
+	r.echo.GET("/health/live", r.handler.LivenessCheck)
+	r.echo.GET("/version", r.handler.Version)
 	r.echo.GET("/metrics", echoprometheus.NewHandler())
 
 	// routes with authentication
-	v1 := r.echo.Group("/api/v1", middleware.JWTAuthMiddleware(cfg))
-	v1.POST("/match", r.handler.Match)
+	v1 := r.apiV1()
+	v1.POST("/match", r.handler.Match, r.requireScope(domain.ScopeRider), MatchAuditLog(NewAuditLogger(), NewPrivacy(cfg)), StrictJSON())
+	v1.POST("/match/candidates", r.handler.Candidates, r.requireScope(domain.ScopeRider), StrictJSON())
+}
+
+// apiV1 groups routes under /api/v1, riders and drivers authenticate with a JWT
+// and are rate limited per user once SetRateLimit was called
+func (r *Router) apiV1() *echo.Group {
+	return r.echo.Group("/api/v1", r.authenticate, r.limitRate)
+}
+
+// SetJWTKeySet makes the /api/v1 routes also accept the RSA tokens signed with
+// the keys of the set
+func (r *Router) SetJWTKeySet(keys secondary.JWTKeySet) {
+	r.jwtAuth = middleware.JWTAuthMiddlewareWithKeySet(r.config, keys)
+}
+
+// authenticate looks the JWT middleware up on every request, so the key set
+// also applies to the routes registered before SetJWTKeySet
+func (r *Router) authenticate(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if r.jwtAuth == nil {
+			return middleware.JWTAuthMiddleware(r.config)(next)(c)
+		}
+		return r.jwtAuth(next)(c)
+	}
+}
+
+// requireScope authorizes the requests of a route by the scopes of their JWT
+// once JWT_ENFORCE_SCOPES is set. Until then every authenticated user may call
+// every route, the tokens issued before the scopes were introduced carry none.
+func (r *Router) requireScope(scopes ...string) echo.MiddlewareFunc {
+	requireScope := middleware.RequireScope(scopes...)
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if r.config == nil || !r.config.JWT.EnforceScopes {
+				return next(c)
+			}
+			return requireScope(next)(c)
+		}
+	}
+}
+
+// authorizeAdmin protects the admin endpoints with the admin API key. Once
+// JWT_ENFORCE_SCOPES is set a JWT of a user with the admin role is accepted
+// instead, for the tools that act on behalf of a signed in operator.
+func (r *Router) authorizeAdmin(next echo.HandlerFunc) echo.HandlerFunc {
+	apiKey := middleware.AdminAPIKeyMiddleware(r.config)(next)
+	adminRole := r.authenticate(middleware.RequireRole(domain.RoleAdmin)(next))
+	return func(c echo.Context) error {
+		header := c.Request().Header
+		if r.config.JWT.EnforceScopes && header.Get("X-API-Key") == "" && header.Get(echo.HeaderAuthorization) != "" {
+			return adminRole(c)
+		}
+		return apiKey(c)
+	}
+}
+
+// SetRateLimit limits the requests of every user to the /api/v1 routes
+func (r *Router) SetRateLimit(config middleware.RateLimitConfig) {
+	r.rateLimit = middleware.RateLimit(config)
+}
+
+// limitRate looks the rate limit up on every request, so it also applies to the
+// routes registered before SetRateLimit
+func (r *Router) limitRate(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if r.rateLimit == nil {
+			return next(c)
+		}
+		return r.rateLimit(next)(c)
+	}
+}
+
+// SetJSONCodec replaces the encoding/json codec of the request and response bodies
+func (r *Router) SetJSONCodec(codec JSONCodec) {
+	r.echo.JSONSerializer = codec
+}
+
+// SetupBlocklistRoutes registers the blocklist management endpoints behind the admin API key
+func (r *Router) SetupBlocklistRoutes(handler *BlocklistHandler) {
+	admin := r.echo.Group("/admin", r.authorizeAdmin)
+	admin.GET("/riders/:rider_id/blocked-drivers", handler.ListBlockedDrivers)
+	admin.POST("/riders/:rider_id/blocked-drivers", handler.BlockDriver)
+	admin.DELETE("/riders/:rider_id/blocked-drivers/:driver_id", handler.UnblockDriver)
+}
+
+// SetupMatchQueryRoutes registers the match lookup endpoints, riders authenticate
+// with the same JWT as for matching
+func (r *Router) SetupMatchQueryRoutes(handler *MatchQueryHandler) {
+	v1 := r.apiV1()
+	v1.GET("/matches", handler.ListMatches, r.requireScope(domain.ScopeRider))
+	v1.GET("/matches/:id", handler.GetMatch, r.requireScope(domain.ScopeRider))
+}
+
+// SetupMatchResponseRoutes registers the endpoints drivers answer and end their
+// matches with, they authenticate with the same JWT as riders
+func (r *Router) SetupMatchResponseRoutes(handler *MatchResponseHandler) {
+	v1 := r.apiV1()
+	v1.POST("/matches/:id/accept", handler.AcceptMatch, r.requireScope(domain.ScopeDriver))
+	v1.POST("/matches/:id/reject", handler.RejectMatch, r.requireScope(domain.ScopeDriver))
+	v1.POST("/matches/:id/complete", handler.CompleteMatch, r.requireScope(domain.ScopeDriver))
+	v1.POST("/matches/:id/cancel", handler.CancelMatch, r.requireScope(domain.ScopeRider, domain.ScopeDriver))
+}
+
+// SetupMatchQueueRoutes registers the endpoints riders follow their wait in the
+// match queue with
+func (r *Router) SetupMatchQueueRoutes(handler *MatchQueueHandler) {
+	v1 := r.apiV1()
+	v1.GET("/match/queue/:id", handler.GetQueueEntry, r.requireScope(domain.ScopeRider))
+	v1.GET("/match/queue/:id/stream", handler.StreamQueueEntry, r.requireScope(domain.ScopeRider))
+}
+
+// SetupReadinessRoute registers the readiness probe, it is public like the health check
+func (r *Router) SetupReadinessRoute(handler *ReadinessHandler) {
+	r.echo.GET("/health/ready", handler.ReadinessCheck)
+}
+
+// SetupStatusRoute registers the status page document, it is public like the health check
+func (r *Router) SetupStatusRoute(handler *StatusHandler) {
+	r.echo.GET("/status", handler.Status)
+}
+
+// RequestErrorRates returns the error rates of the API requests served by the router
+func (r *Router) RequestErrorRates() *ErrorRateTracker {
+	return r.requests
 }
 
 func (r *Router) Start(address string) error {
@@ -2,22 +2,28 @@ package httpadapter
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"the-matching-service/config"
+	"the-matching-service/internal/adapter/middleware"
+	"the-matching-service/internal/adapter/ratelimit"
 	"the-matching-service/internal/application"
+	"the-matching-service/internal/buildinfo"
 	"the-matching-service/internal/domain"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
 )
 
 type mockDriverLocationService struct{}
 
-func (m *mockDriverLocationService) FindNearbyDrivers(ctx context.Context, location domain.Location, radius float64) ([]domain.DriverDistancePair, error) {
+func (m *mockDriverLocationService) FindNearbyDrivers(ctx context.Context, location domain.Location, radius float64, limit int, preferences domain.RiderPreferences) ([]domain.DriverDistancePair, error) {
 	return []domain.DriverDistancePair{
 		{
 			Driver:   domain.Driver{ID: "driver-1"},
@@ -26,8 +32,8 @@ func (m *mockDriverLocationService) FindNearbyDrivers(ctx context.Context, locat
 	}, nil
 }
 
-// TestRouter_HealthAndMatchEndpoints tests the /health and /api/v1/match endpoints.
-// Expected: /health returns 200 OK and 'healthy', /api/v1/match without JWT returns 401 Unauthorized.
+// TestRouter_HealthAndMatchEndpoints tests the /health, /health/live, /version and /api/v1/match endpoints.
+// Expected: /health returns 200 OK and 'healthy', /health/live 200 OK and 'alive', /version the build information, /api/v1/match without JWT returns 401 Unauthorized and 429 once the user exceeded the rate limit.
 func TestRouter_HealthAndMatchEndpoints(t *testing.T) {
 	cfg := &config.Config{JWTSecret: "testsecret"}
 	mockService := &mockDriverLocationService{}
@@ -42,9 +48,61 @@ func TestRouter_HealthAndMatchEndpoints(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Contains(t, w.Body.String(), "healthy")
 
+	liveW := httptest.NewRecorder()
+	e.ServeHTTP(liveW, httptest.NewRequest(http.MethodGet, "/health/live", nil))
+	assert.Equal(t, http.StatusOK, liveW.Code)
+	assert.Contains(t, liveW.Body.String(), `"status":"alive"`)
+
+	defer func(version string) { buildinfo.Version = version }(buildinfo.Version)
+	buildinfo.Version = "1.4.0"
+	versionW := httptest.NewRecorder()
+	e.ServeHTTP(versionW, httptest.NewRequest(http.MethodGet, "/version", nil))
+	assert.Equal(t, http.StatusOK, versionW.Code)
+	var info buildinfo.Info
+	assert.NoError(t, json.Unmarshal(versionW.Body.Bytes(), &info))
+	assert.Equal(t, "1.4.0", info.Version)
+	assert.NotEmpty(t, info.GitSHA)
+
 	matchReq := httptest.NewRequest(http.MethodPost, "/api/v1/match", strings.NewReader(`{}`))
 	matchReq.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 	matchW := httptest.NewRecorder()
 	e.ServeHTTP(matchW, matchReq)
 	assert.Equal(t, http.StatusUnauthorized, matchW.Code)
+
+	router.SetRateLimit(middleware.RateLimitConfig{
+		Limiter: ratelimit.NewMemoryLimiter(),
+		Limit:   domain.RateLimit{Rate: 0.1, Burst: 1},
+		Key:     middleware.UserRateLimitKey,
+	})
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"user_id": "rider-1", "authenticated": true}).SignedString([]byte(cfg.JWTSecret))
+	assert.NoError(t, err)
+	codes := make([]int, 2)
+	for i := range codes {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/match", strings.NewReader(`{}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+		codes[i] = w.Code
+		if w.Code == http.StatusTooManyRequests {
+			assert.Equal(t, "10", w.Header().Get("Retry-After"))
+		}
+	}
+	assert.Equal(t, http.StatusBadRequest, codes[0])
+	assert.Equal(t, http.StatusTooManyRequests, codes[1])
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer upstream.Close()
+	probe := NewUpstreamProbe(NewDriverLocationClient(upstream.URL, ""), time.Second, time.Minute)
+	router.SetupStatusRoute(NewStatusHandler(probe, router.RequestErrorRates()))
+	statusW := httptest.NewRecorder()
+	e.ServeHTTP(statusW, httptest.NewRequest(http.MethodGet, "/status", nil))
+	assert.Equal(t, http.StatusOK, statusW.Code)
+	var report StatusReport
+	assert.NoError(t, json.Unmarshal(statusW.Body.Bytes(), &report))
+	assert.Equal(t, StatusOperational, report.Status)
+	assert.Equal(t, "1.4.0", report.Build.Version)
+	assert.Equal(t, UpstreamUp, report.Upstream.Status)
+	assert.Equal(t, ErrorRate{Requests: 3}, report.ErrorRates.Requests["15m"], "the match requests are counted, the health checks are not")
+	assert.Contains(t, report.ErrorRates.Upstream, OperationSearch)
 }
@@ -0,0 +1,72 @@
+package httpadapter
+
+import (
+	"net/http"
+	"time"
+
+	"the-matching-service/internal/buildinfo"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	StatusOperational = "operational"
+	StatusDegraded    = "degraded"
+)
+
+// StatusErrorRates are the error rates per window of the API requests and of
+// the calls of every operation of the driver location service
+type StatusErrorRates struct {
+	Requests map[string]ErrorRate            `json:"requests"`
+	Upstream map[string]map[string]ErrorRate `json:"upstream"` // operation -> window -> rate
+}
+
+// StatusReport is the document of the status page aggregator
+type StatusReport struct {
+	Service       string           `json:"service"`
+	Status        string           `json:"status"` // operational, or degraded while the upstream is unhealthy
+	Build         buildinfo.Info   `json:"build"`
+	StartedAt     time.Time        `json:"started_at"`
+	UptimeSeconds int64            `json:"uptime_seconds"`
+	Upstream      UpstreamHealth   `json:"upstream"`
+	ErrorRates    StatusErrorRates `json:"error_rates"`
+}
+
+// StatusHandler serves everything the status page shows about the instance in
+// one document. The health checks stay small for the orchestrators polling them.
+type StatusHandler struct {
+	upstream  *UpstreamProbe
+	requests  *ErrorRateTracker
+	startedAt time.Time
+}
+
+func NewStatusHandler(upstream *UpstreamProbe, requests *ErrorRateTracker) *StatusHandler {
+	return &StatusHandler{upstream: upstream, requests: requests, startedAt: time.Now()}
+}
+
+// Status godoc
+// @Summary Status page document
+// @Description Build, uptime, health and circuit breakers of the driver location service, and the error rates of the API requests and of the upstream calls over the last 1, 5 and 15 minutes. The upstream probe is cached for HEALTH_PROBE_CACHE_TTL. The status is degraded while the driver location service is down or a breaker is not closed, the response stays 200.
+// @Tags health
+// @Produce json
+// @Success 200 {object} StatusReport
+// @Router /status [get]
+func (h *StatusHandler) Status(c echo.Context) error {
+	upstream := h.upstream.Check(c.Request().Context())
+	report := StatusReport{
+		Service:       "matching-service",
+		Status:        StatusOperational,
+		Build:         buildinfo.Get(),
+		StartedAt:     h.startedAt,
+		UptimeSeconds: int64(time.Since(h.startedAt).Seconds()),
+		Upstream:      upstream,
+		ErrorRates: StatusErrorRates{
+			Requests: h.requests.Rates(),
+			Upstream: h.upstream.client.ErrorRates(),
+		},
+	}
+	if !upstream.Healthy() {
+		report.Status = StatusDegraded
+	}
+	return c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,44 @@
+package httpadapter
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"the-matching-service/internal/domain"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestStatusHandler_Degraded tests the status document while the driver location service fails
+// Expected: Should answer 200 with a degraded status, the upstream down and the failed searches in the upstream error rates
+func TestStatusHandler_Degraded(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	client := NewDriverLocationClient(ts.URL, "")
+	_, err := client.FindNearbyDrivers(context.Background(), domain.Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}}, 500, 0, domain.RiderPreferences{})
+	require.Error(t, err)
+	handler := NewStatusHandler(NewUpstreamProbe(client, time.Second, time.Minute), NewErrorRateTracker())
+
+	rec := httptest.NewRecorder()
+	require.NoError(t, handler.Status(echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/status", nil), rec)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var report StatusReport
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	assert.Equal(t, "matching-service", report.Service)
+	assert.Equal(t, StatusDegraded, report.Status)
+	assert.Equal(t, UpstreamDown, report.Upstream.Status)
+	assert.Equal(t, ErrorRate{Requests: 1, Errors: 1, Rate: 1}, report.ErrorRates.Upstream[OperationSearch]["1m"])
+	assert.Equal(t, ErrorRate{}, report.ErrorRates.Requests["1m"])
+	assert.NotEmpty(t, report.Build.GoVersion)
+	assert.False(t, report.StartedAt.IsZero())
+}
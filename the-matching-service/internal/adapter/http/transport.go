@@ -0,0 +1,57 @@
+package httpadapter
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// TransportOptions controls how outbound requests leave the service. Without a
+// ProxyURL the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables are
+// honored, CABundle adds the certificates of a PEM file to the system roots.
+type TransportOptions struct {
+	ProxyURL string
+	NoProxy  string
+	CABundle string
+}
+
+// NewTransport builds the transport of the driver location client
+func NewTransport(options TransportOptions) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if options.ProxyURL != "" {
+		if _, err := url.Parse(options.ProxyURL); err != nil {
+			return nil, fmt.Errorf("invalid proxy url %q: %w", options.ProxyURL, err)
+		}
+		proxy := (&httpproxy.Config{
+			HTTPProxy:  options.ProxyURL,
+			HTTPSProxy: options.ProxyURL,
+			NoProxy:    options.NoProxy,
+		}).ProxyFunc()
+		transport.Proxy = func(req *http.Request) (*url.URL, error) {
+			return proxy(req.URL)
+		}
+	}
+
+	if options.CABundle != "" {
+		pem, err := os.ReadFile(options.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ca bundle: %w", err)
+		}
+		roots, err := x509.SystemCertPool()
+		if err != nil || roots == nil {
+			roots = x509.NewCertPool()
+		}
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in ca bundle %s", options.CABundle)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}
+	}
+
+	return transport, nil
+}
@@ -0,0 +1,91 @@
+package httpadapter
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNewTransport_Proxy tests a configured proxy and its exceptions
+// Expected: Should send requests through the proxy unless the host is listed in NoProxy
+func TestNewTransport_Proxy(t *testing.T) {
+	transport, err := NewTransport(TransportOptions{
+		ProxyURL: "http://egress.corp:3128",
+		NoProxy:  "internal.corp",
+	})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "http://driver-location.example.com/api/v1/drivers/search", nil)
+	proxyURL, err := transport.Proxy(req)
+	require.NoError(t, err)
+	require.NotNil(t, proxyURL)
+	assert.Equal(t, "egress.corp:3128", proxyURL.Host)
+
+	req = httptest.NewRequest(http.MethodGet, "http://driver-location.internal.corp/api/v1/drivers/search", nil)
+	proxyURL, err = transport.Proxy(req)
+	require.NoError(t, err)
+	assert.Nil(t, proxyURL)
+}
+
+// TestNewTransport_ProxyForwardsRequests tests the driver location client behind a proxy
+// Expected: Should reach the driver location service through the proxy
+func TestNewTransport_ProxyForwardsRequests(t *testing.T) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.String())
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"success":true,"data":[]}`))
+	}))
+	defer proxy.Close()
+
+	transport, err := NewTransport(TransportOptions{ProxyURL: proxy.URL})
+	require.NoError(t, err)
+
+	resp, err := (&http.Client{Transport: transport}).Get("http://driver-location.example.com/health")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, []string{"http://driver-location.example.com/health"}, proxied)
+}
+
+// TestNewTransport_CABundle tests trusting a private certificate authority
+// Expected: Should accept the server certificate only with the bundle configured
+func TestNewTransport_CABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	require.NoError(t, os.WriteFile(bundle, certPEM, 0o600))
+
+	transport, err := NewTransport(TransportOptions{})
+	require.NoError(t, err)
+	_, err = (&http.Client{Transport: transport}).Get(server.URL)
+	assert.Error(t, err)
+
+	transport, err = NewTransport(TransportOptions{CABundle: bundle})
+	require.NoError(t, err)
+	resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+// TestNewTransport_InvalidCABundle tests missing and malformed bundles
+// Expected: Should return an error instead of silently using the system roots
+func TestNewTransport_InvalidCABundle(t *testing.T) {
+	_, err := NewTransport(TransportOptions{CABundle: filepath.Join(t.TempDir(), "missing.pem")})
+	assert.ErrorContains(t, err, "failed to read ca bundle")
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(bundle, []byte("not a certificate"), 0o600))
+	_, err = NewTransport(TransportOptions{CABundle: bundle})
+	assert.ErrorContains(t, err, "no certificates found")
+}
@@ -0,0 +1,107 @@
+package httpadapter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"the-matching-service/internal/domain"
+
+	"github.com/sony/gobreaker"
+)
+
+// Operations of the driver location service, every operation has its own circuit
+// breaker and bulkhead so a failing endpoint does not take the others down
+const (
+	OperationSearch  = "search"
+	OperationOutcome = "outcome"
+)
+
+// DefaultMaxConcurrentCalls is the bulkhead size of an operation without a configured limit
+const DefaultMaxConcurrentCalls = 100
+
+// ErrBulkheadFull is returned when an operation already has its maximum number of calls in flight
+var ErrBulkheadFull = errors.New("too many concurrent calls")
+
+// upstreamOperation guards the calls of one upstream endpoint. The bulkhead rejects
+// calls beyond the concurrency limit right away instead of queueing them, and those
+// rejections are not counted by the breaker because the upstream never saw them.
+type upstreamOperation struct {
+	breaker    *gobreaker.CircuitBreaker
+	bulkhead   chan struct{}
+	errorRates *ErrorRateTracker // calls failed for any reason, rejections included
+}
+
+func newUpstreamOperation(name string, maxConcurrent int) *upstreamOperation {
+	if maxConcurrent <= 0 {
+		maxConcurrent = DefaultMaxConcurrentCalls
+	}
+
+	return &upstreamOperation{
+		breaker:    gobreaker.NewCircuitBreaker(breakerSettings(name)),
+		bulkhead:   make(chan struct{}, maxConcurrent),
+		errorRates: NewErrorRateTracker(),
+	}
+}
+
+// breakerSettings opens the breaker of an operation after more than 5 failures in
+// a row and lets MaxRequests trial calls through 10s later
+func breakerSettings(name string) gobreaker.Settings {
+	return gobreaker.Settings{
+		Name:         "DriverLocationService/" + name,
+		MaxRequests:  3,
+		Interval:     60 * time.Second,
+		Timeout:      10 * time.Second,
+		IsSuccessful: upstreamSuccessful,
+	}
+}
+
+// upstreamSuccessful reports whether a call went through. A request rejected by
+// validation and a call its caller gave up on say nothing about the health of
+// the upstream, so neither counts as a failure.
+func upstreamSuccessful(err error) bool {
+	var upstreamErr *domain.UpstreamError
+	var abandoned *abandonedCallError
+	return err == nil ||
+		errors.Is(err, context.Canceled) ||
+		errors.As(err, &abandoned) ||
+		(errors.As(err, &upstreamErr) && upstreamErr.Kind == domain.UpstreamValidation)
+}
+
+// abandonedCallError wraps the error of a call whose caller's context was done
+// by the time it returned, the deadline of the caller is not the upstream timing out
+type abandonedCallError struct {
+	err error
+}
+
+func (e *abandonedCallError) Error() string { return e.err.Error() }
+
+func (e *abandonedCallError) Unwrap() error { return e.err }
+
+// execute runs call through the bulkhead and the breaker, rejections of either are
+// reported as an unavailable upstream
+func (o *upstreamOperation) execute(ctx context.Context, correlationID *string, call func() (interface{}, error)) (result interface{}, err error) {
+	defer func() { o.errorRates.Record(!upstreamSuccessful(err)) }()
+
+	select {
+	case o.bulkhead <- struct{}{}:
+		defer func() { <-o.bulkhead }()
+	default:
+		return nil, domain.NewUpstreamError(domain.UpstreamUnavailable, 0, *correlationID,
+			fmt.Errorf("%s: %w", o.breaker.Name(), ErrBulkheadFull))
+	}
+
+	result, err = o.breaker.Execute(func() (interface{}, error) {
+		result, err := call()
+		if err != nil && ctx.Err() != nil {
+			return result, &abandonedCallError{err: err}
+		}
+		return result, err
+	})
+	if errors.Is(err, gobreaker.ErrOpenState) || errors.Is(err, gobreaker.ErrTooManyRequests) {
+		return nil, domain.NewUpstreamError(domain.UpstreamUnavailable, 0, *correlationID,
+			fmt.Errorf("%s: %w", o.breaker.Name(), err))
+	}
+	return result, err
+}
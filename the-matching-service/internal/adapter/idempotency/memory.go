@@ -0,0 +1,96 @@
+package idempotency
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"the-matching-service/internal/domain"
+	"the-matching-service/internal/ports/secondary"
+)
+
+// sweepInterval is how often Claim drops the expired keys
+const sweepInterval = time.Minute
+
+type memoryEntry struct {
+	record    domain.IdempotencyRecord
+	expiresAt time.Time
+}
+
+// MemoryStore keeps the idempotency keys of this instance, a retry that reaches
+// another instance is not recognized
+type MemoryStore struct {
+	now func() time.Time
+
+	mu        sync.Mutex
+	entries   map[string]memoryEntry
+	nextSweep time.Time
+}
+
+var _ secondary.IdempotencyStore = (*MemoryStore)(nil)
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		now:     time.Now,
+		entries: make(map[string]memoryEntry),
+	}
+}
+
+func (s *MemoryStore) Claim(ctx context.Context, key string, record domain.IdempotencyRecord, ttl time.Duration) (*domain.IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+	if entry, ok := s.entries[key]; ok && now.Before(entry.expiresAt) {
+		existing := copyRecord(entry.record)
+		return &existing, false, nil
+	}
+	s.entries[key] = memoryEntry{record: record, expiresAt: now.Add(ttl)}
+	return nil, true, nil
+}
+
+func (s *MemoryStore) Complete(ctx context.Context, key string, record domain.IdempotencyRecord, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[key] = memoryEntry{record: copyRecord(record), expiresAt: s.now().Add(ttl)}
+	return nil
+}
+
+func (s *MemoryStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
+
+// copyRecord copies the match of the record, replays of a key run in parallel
+// and must not share it with each other or with the request that completed it
+func copyRecord(record domain.IdempotencyRecord) domain.IdempotencyRecord {
+	if record.Result != nil {
+		result := *record.Result
+		result.DeclinedDrivers = slices.Clone(result.DeclinedDrivers)
+		if result.Search != nil {
+			search := *result.Search
+			result.Search = &search
+		}
+		record.Result = &result
+	}
+	record.Candidates = slices.Clone(record.Candidates)
+	return record
+}
+
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Before(s.nextSweep) {
+		return
+	}
+	for key, entry := range s.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+	s.nextSweep = now.Add(sweepInterval)
+}
@@ -0,0 +1,115 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"the-matching-service/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMemoryStore_Claim tests claiming, completing and releasing a key
+// Expected: Should hand the key to the first claim only, return the stored record to the others and free it on release
+func TestMemoryStore_Claim(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	existing, claimed, err := store.Claim(ctx, "rider-1:key", domain.IdempotencyRecord{Fingerprint: "a"}, time.Hour)
+	require.NoError(t, err)
+	assert.True(t, claimed)
+	assert.Nil(t, existing)
+
+	existing, claimed, err = store.Claim(ctx, "rider-1:key", domain.IdempotencyRecord{Fingerprint: "b"}, time.Hour)
+	require.NoError(t, err)
+	assert.False(t, claimed)
+	assert.Equal(t, "a", existing.Fingerprint)
+	assert.Nil(t, existing.Result)
+
+	result := &domain.MatchResult{ID: "match-1"}
+	require.NoError(t, store.Complete(ctx, "rider-1:key", domain.IdempotencyRecord{Fingerprint: "a", Result: result}, time.Hour))
+	existing, claimed, err = store.Claim(ctx, "rider-1:key", domain.IdempotencyRecord{Fingerprint: "a"}, time.Hour)
+	require.NoError(t, err)
+	assert.False(t, claimed)
+	assert.Equal(t, result, existing.Result)
+
+	require.NoError(t, store.Release(ctx, "rider-1:key"))
+	_, claimed, err = store.Claim(ctx, "rider-1:key", domain.IdempotencyRecord{Fingerprint: "b"}, time.Hour)
+	require.NoError(t, err)
+	assert.True(t, claimed)
+}
+
+// TestMemoryStore_expiry tests keys outliving their TTL
+// Expected: Should let the key be claimed again once expired and sweep expired keys on later claims
+func TestMemoryStore_expiry(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	_, claimed, err := store.Claim(ctx, "rider-1:key", domain.IdempotencyRecord{Fingerprint: "a"}, time.Minute)
+	require.NoError(t, err)
+	require.True(t, claimed)
+	_, claimed, err = store.Claim(ctx, "rider-2:key", domain.IdempotencyRecord{Fingerprint: "a"}, time.Minute)
+	require.NoError(t, err)
+	require.True(t, claimed)
+
+	now = now.Add(time.Minute - time.Second)
+	_, claimed, err = store.Claim(ctx, "rider-1:key", domain.IdempotencyRecord{Fingerprint: "b"}, time.Minute)
+	require.NoError(t, err)
+	assert.False(t, claimed)
+
+	now = now.Add(time.Second)
+	_, claimed, err = store.Claim(ctx, "rider-1:key", domain.IdempotencyRecord{Fingerprint: "b"}, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, claimed)
+	assert.NotContains(t, store.entries, "rider-2:key")
+}
+
+// TestMemoryStore_Claim_parallel tests many requests claiming the same key at once
+// Expected: Should hand the key to exactly one of them
+func TestMemoryStore_Claim_parallel(t *testing.T) {
+	store := NewMemoryStore()
+	const claims = 50
+
+	var wg sync.WaitGroup
+	var claimedCount atomic.Int32
+	for i := 0; i < claims; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, claimed, err := store.Claim(context.Background(), "rider-1:key", domain.IdempotencyRecord{Fingerprint: "a"}, time.Hour)
+			assert.NoError(t, err)
+			if claimed {
+				claimedCount.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), claimedCount.Load())
+}
+
+// TestMemoryStore_Complete_copiesResult tests changing a match after completing its key and between replays
+// Expected: Should replay the match as it was completed, replays do not share it
+func TestMemoryStore_Complete_copiesResult(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	result := &domain.MatchResult{ID: "match-1", DriverID: "driver-1", DeclinedDrivers: []string{"driver-0"}}
+	require.NoError(t, store.Complete(ctx, "rider-1:key", domain.IdempotencyRecord{Fingerprint: "a", Result: result}, time.Hour))
+
+	result.DriverID = "driver-2"
+	result.DeclinedDrivers[0] = "driver-3"
+
+	first, _, err := store.Claim(ctx, "rider-1:key", domain.IdempotencyRecord{Fingerprint: "a"}, time.Hour)
+	require.NoError(t, err)
+	second, _, err := store.Claim(ctx, "rider-1:key", domain.IdempotencyRecord{Fingerprint: "a"}, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, "driver-1", first.Result.DriverID)
+	assert.Equal(t, []string{"driver-0"}, first.Result.DeclinedDrivers)
+	assert.NotSame(t, first.Result, second.Result)
+}
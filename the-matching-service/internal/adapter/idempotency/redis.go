@@ -0,0 +1,79 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"the-matching-service/internal/domain"
+	"the-matching-service/internal/ports/secondary"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const keyPrefix = "idempotency:"
+
+// RedisStore keeps every idempotency key as a JSON string under idempotency:{key}
+// that expires with the key, so every instance recognizes the retries
+type RedisStore struct {
+	client *redis.Client
+}
+
+var _ secondary.IdempotencyStore = (*RedisStore)(nil)
+
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+// Claim sets the key only when it does not exist. A key that expires between the
+// failed SET NX and the read of its record is claimed again.
+func (s *RedisStore) Claim(ctx context.Context, key string, record domain.IdempotencyRecord, ttl time.Duration) (*domain.IdempotencyRecord, bool, error) {
+	value, err := json.Marshal(record)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to encode idempotency record: %w", err)
+	}
+
+	for attempt := 0; attempt < 2; attempt++ {
+		claimed, err := s.client.SetNX(ctx, keyPrefix+key, value, ttl).Result()
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to claim idempotency key: %w", err)
+		}
+		if claimed {
+			return nil, true, nil
+		}
+
+		stored, err := s.client.Get(ctx, keyPrefix+key).Bytes()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to read idempotency key: %w", err)
+		}
+		var existing domain.IdempotencyRecord
+		if err := json.Unmarshal(stored, &existing); err != nil {
+			return nil, false, fmt.Errorf("failed to decode idempotency record: %w", err)
+		}
+		return &existing, false, nil
+	}
+	return nil, false, fmt.Errorf("failed to claim idempotency key: key expired while claiming it twice")
+}
+
+func (s *RedisStore) Complete(ctx context.Context, key string, record domain.IdempotencyRecord, ttl time.Duration) error {
+	value, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode idempotency record: %w", err)
+	}
+	if err := s.client.Set(ctx, keyPrefix+key, value, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to store idempotency record: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisStore) Release(ctx context.Context, key string) error {
+	if err := s.client.Del(ctx, keyPrefix+key).Err(); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package jwks
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"the-matching-service/internal/ports/secondary"
+)
+
+// ErrUnknownKey is returned for a kid the key set does not list, even after a refresh
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// minRefreshInterval bounds how often tokens with an unknown kid refresh the
+// set, forged kids must not turn every request into a call to the auth service
+const minRefreshInterval = time.Minute
+
+// jsonWebKey is the part of a JWK the RSA signature keys need
+// https://datatracker.ietf.org/doc/html/rfc7517#section-4
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// KeySet caches the RSA keys of the JSON Web Key Set of the auth service for
+// the refresh interval. A token signed with a kid the set does not list
+// refreshes it right away, so a rotated key is picked up before the interval
+// runs out. When a refresh fails the cached keys keep being used.
+type KeySet struct {
+	url             string
+	refreshInterval time.Duration
+	httpClient      *http.Client
+
+	mu          sync.Mutex
+	keys        map[string]*rsa.PublicKey
+	fetched     time.Time
+	lastAttempt time.Time
+}
+
+var _ secondary.JWTKeySet = (*KeySet)(nil)
+
+func NewKeySet(url string, refreshInterval time.Duration) *KeySet {
+	return &KeySet{
+		url:             url,
+		refreshInterval: refreshInterval,
+		httpClient:      &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// SetTransport replaces the transport the set is fetched with, e.g. one from
+// httpadapter.NewTransport to go through an egress proxy
+func (s *KeySet) SetTransport(transport http.RoundTripper) {
+	s.httpClient.Transport = transport
+}
+
+// Key returns the key of kid, fetching the set when it expired or does not list kid
+func (s *KeySet) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, known := s.keys[kid]
+	fresh := time.Since(s.fetched) <= s.refreshInterval
+	// whatever failed or went unknown, the set is fetched at most every minRefreshInterval
+	if (known && fresh) || time.Since(s.lastAttempt) < minRefreshInterval {
+		if !known {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownKey, kid)
+		}
+		return key, nil
+	}
+
+	if err := s.refresh(ctx); err != nil {
+		// the auth service being down must not log every rider out
+		if known {
+			return key, nil
+		}
+		return nil, err
+	}
+	if key, ok := s.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownKey, kid)
+}
+
+// Refresh fetches the set, main calls it on startup to fail early on a wrong URL
+func (s *KeySet) Refresh(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.refresh(ctx)
+}
+
+func (s *KeySet) refresh(ctx context.Context) error {
+	s.lastAttempt = time.Now()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch JWKS: unexpected status %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		// encryption keys and the key types the middleware does not verify are skipped
+		if jwk.Kty != "RSA" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+		key, err := rsaPublicKey(jwk)
+		if err != nil {
+			return fmt.Errorf("invalid JWKS key %q: %w", jwk.Kid, err)
+		}
+		keys[jwk.Kid] = key
+	}
+	s.keys = keys
+	s.fetched = s.lastAttempt
+	return nil
+}
+
+func rsaPublicKey(jwk jsonWebKey) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+	if err != nil {
+		return nil, fmt.Errorf("invalid modulus: %w", err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exponent: %w", err)
+	}
+	exponent := new(big.Int).SetBytes(e)
+	if len(n) == 0 || !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+		return nil, errors.New("invalid modulus or exponent")
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+}
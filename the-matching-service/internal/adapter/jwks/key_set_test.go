@@ -0,0 +1,100 @@
+package jwks
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodeJWK(kid string, key *rsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA",
+		"kid": kid,
+		"use": "sig",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+// authService serves the keys of the set and counts the fetches
+type authService struct {
+	keys    atomic.Value // []map[string]string
+	fetches atomic.Int32
+	down    atomic.Bool
+}
+
+func (a *authService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.fetches.Add(1)
+	if a.down.Load() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"keys": a.keys.Load()})
+}
+
+// TestKeySet_Key tests looking keys up by kid
+// Expected: Should fetch the set once, return the RSA key of the kid and skip keys that are not RSA signature keys
+func TestKeySet_Key(t *testing.T) {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	auth := &authService{}
+	auth.keys.Store([]map[string]string{
+		encodeJWK("k1", &private.PublicKey),
+		{"kty": "EC", "kid": "ec", "crv": "P-256"},
+		{"kty": "RSA", "kid": "enc", "use": "enc", "n": "AQAB", "e": "AQAB"},
+	})
+	ts := httptest.NewServer(auth)
+	defer ts.Close()
+
+	keys := NewKeySet(ts.URL, time.Hour)
+	key, err := keys.Key(context.Background(), "k1")
+	require.NoError(t, err)
+	assert.True(t, private.PublicKey.Equal(key))
+
+	_, err = keys.Key(context.Background(), "ec")
+	assert.ErrorIs(t, err, ErrUnknownKey)
+	_, err = keys.Key(context.Background(), "enc")
+	assert.ErrorIs(t, err, ErrUnknownKey)
+	assert.Equal(t, int32(1), auth.fetches.Load(), "unknown kids refresh the set at most once a minute")
+}
+
+// TestKeySet_Rotation tests a key rotated by the auth service and an auth service that is down
+// Expected: Should pick up the new kid with a refresh and keep using the cached key while the set cannot be fetched
+func TestKeySet_Rotation(t *testing.T) {
+	first, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	second, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	auth := &authService{}
+	auth.keys.Store([]map[string]string{encodeJWK("k1", &first.PublicKey)})
+	ts := httptest.NewServer(auth)
+	defer ts.Close()
+
+	keys := NewKeySet(ts.URL, time.Hour)
+	require.NoError(t, keys.Refresh(context.Background()))
+
+	auth.keys.Store([]map[string]string{encodeJWK("k1", &first.PublicKey), encodeJWK("k2", &second.PublicKey)})
+	keys.lastAttempt = time.Now().Add(-2 * minRefreshInterval)
+	key, err := keys.Key(context.Background(), "k2")
+	require.NoError(t, err)
+	assert.True(t, second.PublicKey.Equal(key))
+
+	auth.down.Store(true)
+	keys.fetched = time.Now().Add(-2 * time.Hour)
+	keys.lastAttempt = keys.fetched
+	key, err = keys.Key(context.Background(), "k1")
+	require.NoError(t, err)
+	assert.True(t, first.PublicKey.Equal(key))
+	assert.Equal(t, int32(3), auth.fetches.Load())
+}
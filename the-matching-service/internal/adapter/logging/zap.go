@@ -0,0 +1,116 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+
+	"the-matching-service/config"
+	"the-matching-service/internal/domain"
+	"the-matching-service/internal/ports/secondary"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// ZapLogger writes the log entries with zap, JSON lines on stdout unless the
+// console format is asked for
+type ZapLogger struct {
+	logger  *zap.SugaredLogger
+	privacy *domain.Privacy
+}
+
+var _ secondary.Logger = (*ZapLogger)(nil)
+
+func NewZapLogger(cfg config.LogConfig) (*ZapLogger, error) {
+	level, err := zapcore.ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, fmt.Errorf("invalid log level: %w", err)
+	}
+
+	zapConfig := zap.NewProductionConfig()
+	if cfg.Format == "console" {
+		zapConfig = zap.NewDevelopmentConfig()
+	}
+	zapConfig.Level = zap.NewAtomicLevelAt(level)
+	// the same time field the echo access log writes
+	zapConfig.EncoderConfig.TimeKey = "time"
+	zapConfig.EncoderConfig.EncodeTime = zapcore.RFC3339NanoTimeEncoder
+
+	logger, err := zapConfig.Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build logger: %w", err)
+	}
+	return NewZapLoggerFrom(logger), nil
+}
+
+// NewZapLoggerFrom writes the entries with an existing zap logger
+func NewZapLoggerFrom(logger *zap.Logger) *ZapLogger {
+	// the caller of interest is the one calling the port, not this adapter
+	return &ZapLogger{logger: logger.WithOptions(zap.AddCallerSkip(1)).Sugar()}
+}
+
+// SetPrivacy applies the policy to the fields of every entry, including the
+// ones of the context, so no call site can log a rider ID or location in full
+func (l *ZapLogger) SetPrivacy(privacy domain.Privacy) {
+	l.privacy = &privacy
+}
+
+func (l *ZapLogger) Debug(ctx context.Context, msg string, keysAndValues ...any) {
+	l.logger.Debugw(msg, l.fields(ctx, keysAndValues)...)
+}
+
+func (l *ZapLogger) Info(ctx context.Context, msg string, keysAndValues ...any) {
+	l.logger.Infow(msg, l.fields(ctx, keysAndValues)...)
+}
+
+func (l *ZapLogger) Warn(ctx context.Context, msg string, keysAndValues ...any) {
+	l.logger.Warnw(msg, l.fields(ctx, keysAndValues)...)
+}
+
+func (l *ZapLogger) Error(ctx context.Context, msg string, keysAndValues ...any) {
+	l.logger.Errorw(msg, l.fields(ctx, keysAndValues)...)
+}
+
+// Fatal logs the entry and exits, only main gives up on the whole service
+func (l *ZapLogger) Fatal(ctx context.Context, msg string, keysAndValues ...any) {
+	l.logger.Fatalw(msg, l.fields(ctx, keysAndValues)...)
+}
+
+// Sync flushes buffered entries, it is called before the service exits
+func (l *ZapLogger) Sync() error {
+	return l.logger.Sync()
+}
+
+func (l *ZapLogger) fields(ctx context.Context, keysAndValues []any) []any {
+	fields := withContextFields(ctx, keysAndValues)
+	if l.privacy == nil {
+		return fields
+	}
+	return l.privacy.LogFields(fields)
+}
+
+// withContextFields puts the fields of the context before the ones of the
+// entry, a key the entry sets itself is not repeated from the context
+func withContextFields(ctx context.Context, keysAndValues []any) []any {
+	fields := domain.LogFields(ctx)
+	if len(fields) == 0 {
+		return keysAndValues
+	}
+
+	merged := make([]any, 0, len(fields)+len(keysAndValues))
+	for i := 0; i+1 < len(fields); i += 2 {
+		if !hasKey(keysAndValues, fields[i]) {
+			merged = append(merged, fields[i], fields[i+1])
+		}
+	}
+	return append(merged, keysAndValues...)
+}
+
+func hasKey(keysAndValues []any, key any) bool {
+	for i := 0; i < len(keysAndValues); i += 2 {
+		if keysAndValues[i] == key {
+			return true
+		}
+	}
+	return false
+}
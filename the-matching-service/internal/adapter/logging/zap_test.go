@@ -0,0 +1,72 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"the-matching-service/config"
+	"the-matching-service/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// TestZapLogger_ContextFields tests logging with a context carrying request fields
+// Expected: Should write the fields of the context once, before the fields of the entry, and drop entries below the level
+func TestZapLogger_ContextFields(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := NewZapLoggerFrom(zap.New(core))
+
+	ctx := domain.WithLogFields(context.Background(), "request_id", "req-1")
+	ctx = domain.WithLogFields(ctx, "user_id", "rider-1")
+	logger.Warn(ctx, "failed to store match", "user_id", "rider-1", "error", errors.New("redis down"))
+	logger.Debug(ctx, "search cache hit")
+	logger.Info(context.Background(), "riders queued", "riders", 3)
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 2)
+	assert.Equal(t, zapcore.WarnLevel, entries[0].Level)
+	assert.Equal(t, "failed to store match", entries[0].Message)
+	assert.Len(t, entries[0].Context, 3)
+	assert.Equal(t, map[string]interface{}{
+		"request_id": "req-1",
+		"user_id":    "rider-1",
+		"error":      "redis down",
+	}, entries[0].ContextMap())
+	assert.Equal(t, map[string]interface{}{"riders": int64(3)}, entries[1].ContextMap())
+}
+
+// TestZapLogger_Privacy tests logging with a privacy policy
+// Expected: Should hash the rider IDs of the context and of the entry
+func TestZapLogger_Privacy(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := NewZapLoggerFrom(zap.New(core))
+	logger.SetPrivacy(domain.Privacy{CoordinateDecimals: 2, HashRiderIDs: true, HashKey: "secret"})
+
+	ctx := domain.WithLogFields(context.Background(), "user_id", "rider-1")
+	logger.Info(ctx, "rider queued", "rider_id", "rider-2", "riders", 3)
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 1)
+	assert.Equal(t, map[string]interface{}{
+		"user_id":  domain.HashEndUser("secret", "rider-1"),
+		"rider_id": domain.HashEndUser("secret", "rider-2"),
+		"riders":   int64(3),
+	}, entries[0].ContextMap())
+}
+
+// TestNewZapLogger tests building the logger from the configuration
+// Expected: Should accept the configured levels and formats and reject unknown levels
+func TestNewZapLogger(t *testing.T) {
+	for _, cfg := range []config.LogConfig{{}, {Level: "debug", Format: "console"}, {Level: "error", Format: "json"}} {
+		_, err := NewZapLogger(cfg)
+		assert.NoError(t, err, cfg)
+	}
+
+	_, err := NewZapLogger(config.LogConfig{Level: "verbose"})
+	assert.Error(t, err)
+}
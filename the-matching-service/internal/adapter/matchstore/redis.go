@@ -0,0 +1,158 @@
+package matchstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"the-matching-service/internal/domain"
+	"the-matching-service/internal/ports/secondary"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	matchKeyPrefix = "match:"
+	riderKeyPrefix = "matches:rider:"
+
+	// maxUpdateAttempts bounds the retries of an update racing other updates of the same match
+	maxUpdateAttempts = 5
+)
+
+// RedisMatchStore keeps every match as a JSON string under match:{id} and the IDs
+// of a rider's matches in a sorted set scored by match time, both expire after
+// the retention so the store does not grow with the ride history
+type RedisMatchStore struct {
+	client    *redis.Client
+	retention time.Duration
+}
+
+var _ secondary.MatchStore = (*RedisMatchStore)(nil)
+
+func NewRedisMatchStore(client *redis.Client, retention time.Duration) *RedisMatchStore {
+	if retention <= 0 {
+		retention = 30 * 24 * time.Hour
+	}
+	return &RedisMatchStore{client: client, retention: retention}
+}
+
+func (s *RedisMatchStore) Save(ctx context.Context, result domain.MatchResult) error {
+	value, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to encode match: %w", err)
+	}
+
+	riderKey := riderKeyPrefix + result.RiderID
+	expiredBefore := result.MatchedAt.Add(-s.retention).UnixMilli()
+
+	pipe := s.client.TxPipeline()
+	pipe.Set(ctx, matchKeyPrefix+result.ID, value, s.retention)
+	pipe.ZAdd(ctx, riderKey, redis.Z{Score: float64(result.MatchedAt.UnixMilli()), Member: result.ID})
+	pipe.ZRemRangeByScore(ctx, riderKey, "-inf", "("+strconv.FormatInt(expiredBefore, 10))
+	pipe.Expire(ctx, riderKey, s.retention)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to save match: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisMatchStore) Get(ctx context.Context, id string) (*domain.MatchResult, error) {
+	value, err := s.client.Get(ctx, matchKeyPrefix+id).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read match: %w", err)
+	}
+
+	var result domain.MatchResult
+	if err := json.Unmarshal([]byte(value), &result); err != nil {
+		return nil, fmt.Errorf("failed to decode match %s: %w", id, err)
+	}
+	return &result, nil
+}
+
+// Update watches the match key so a concurrent update makes the transaction fail
+// and the change is applied again to the match that won, the retention is kept
+func (s *RedisMatchStore) Update(ctx context.Context, id string, change func(result *domain.MatchResult) error) (*domain.MatchResult, error) {
+	key := matchKeyPrefix + id
+	for attempt := 0; attempt < maxUpdateAttempts; attempt++ {
+		var updated domain.MatchResult
+		err := s.client.Watch(ctx, func(tx *redis.Tx) error {
+			value, err := tx.Get(ctx, key).Result()
+			if errors.Is(err, redis.Nil) {
+				return domain.ErrMatchNotFound
+			}
+			if err != nil {
+				return fmt.Errorf("failed to read match: %w", err)
+			}
+			if err := json.Unmarshal([]byte(value), &updated); err != nil {
+				return fmt.Errorf("failed to decode match %s: %w", id, err)
+			}
+			if err := change(&updated); err != nil {
+				return err
+			}
+
+			encoded, err := json.Marshal(updated)
+			if err != nil {
+				return fmt.Errorf("failed to encode match: %w", err)
+			}
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				pipe.SetArgs(ctx, key, encoded, redis.SetArgs{KeepTTL: true})
+				return nil
+			})
+			return err
+		}, key)
+		if errors.Is(err, redis.TxFailedErr) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &updated, nil
+	}
+	return nil, fmt.Errorf("failed to update match %s: too many concurrent updates", id)
+}
+
+func (s *RedisMatchStore) ListByRider(ctx context.Context, riderID string, offset, limit int) ([]domain.MatchResult, int64, error) {
+	riderKey := riderKeyPrefix + riderID
+	total, err := s.client.ZCard(ctx, riderKey).Result()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count matches: %w", err)
+	}
+
+	ids, err := s.client.ZRevRange(ctx, riderKey, int64(offset), int64(offset+limit-1)).Result()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list matches: %w", err)
+	}
+	if len(ids) == 0 {
+		return []domain.MatchResult{}, total, nil
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = matchKeyPrefix + id
+	}
+	values, err := s.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read matches: %w", err)
+	}
+
+	results := make([]domain.MatchResult, 0, len(values))
+	for i, value := range values {
+		// the match expired between ZREVRANGE and MGET
+		encoded, ok := value.(string)
+		if !ok {
+			continue
+		}
+		var result domain.MatchResult
+		if err := json.Unmarshal([]byte(encoded), &result); err != nil {
+			return nil, 0, fmt.Errorf("failed to decode match %s: %w", ids[i], err)
+		}
+		results = append(results, result)
+	}
+	return results, total, nil
+}
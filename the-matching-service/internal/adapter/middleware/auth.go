@@ -1,15 +1,55 @@
 package middleware
 
 import (
+	"crypto/subtle"
+	"errors"
 	"net/http"
+	"slices"
+	"strings"
 
 	"the-matching-service/config"
+	"the-matching-service/internal/domain"
+	"the-matching-service/internal/ports/secondary"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
 )
 
+// hmacMethods are verified with JWT_SECRET, rsaMethods with the keys of the JWKS
+var (
+	hmacMethods = []string{"HS256", "HS384", "HS512"}
+	rsaMethods  = []string{"RS256", "RS384", "RS512"}
+)
+
+// JWTAuthMiddleware verifies tokens signed with JWT_SECRET
 func JWTAuthMiddleware(cfg *config.Config) echo.MiddlewareFunc {
+	return JWTAuthMiddlewareWithKeySet(cfg, nil)
+}
+
+// JWTAuthMiddlewareWithKeySet also verifies the RSA tokens of an auth service
+// with the keys of its JWKS. Only the algorithms of the configured keys are
+// accepted, so a token cannot have its RSA public key used as an HMAC secret.
+// The iss, aud and exp claims are checked as set in cfg.JWT.
+func JWTAuthMiddlewareWithKeySet(cfg *config.Config, keys secondary.JWTKeySet) echo.MiddlewareFunc {
+	var methods []string
+	if cfg.JWTSecret != "" {
+		methods = append(methods, hmacMethods...)
+	}
+	if keys != nil {
+		methods = append(methods, rsaMethods...)
+	}
+	options := []jwt.ParserOption{jwt.WithValidMethods(methods), jwt.WithLeeway(cfg.JWT.Leeway)}
+	if cfg.JWT.Issuer != "" {
+		options = append(options, jwt.WithIssuer(cfg.JWT.Issuer))
+	}
+	if cfg.JWT.Audience != "" {
+		options = append(options, jwt.WithAudience(cfg.JWT.Audience))
+	}
+	if cfg.JWT.RequireExpiry {
+		options = append(options, jwt.WithExpirationRequired())
+	}
+	parser := jwt.NewParser(options...)
+
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			tokenString := c.Request().Header.Get("Authorization")
@@ -24,7 +64,14 @@ func JWTAuthMiddleware(cfg *config.Config) echo.MiddlewareFunc {
 				tokenString = tokenString[7:]
 			}
 
-			token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+			token, err := parser.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+				if _, ok := token.Method.(*jwt.SigningMethodRSA); ok {
+					kid, _ := token.Header["kid"].(string)
+					return keys.Key(c.Request().Context(), kid)
+				}
+				if cfg.JWTSecret == "" {
+					return nil, errors.New("JWT secret is not set")
+				}
 				return []byte(cfg.JWTSecret), nil
 			})
 			if err != nil || !token.Valid {
@@ -51,16 +98,118 @@ func JWTAuthMiddleware(cfg *config.Config) echo.MiddlewareFunc {
 			}
 			c.Set("is_authenticated", isAuth)
 
-			if uid, ok := claims["user_id"].(string); ok {
-				c.Set("user_id", uid)
-			} else if sub, ok := claims["sub"].(string); ok {
-				c.Set("user_id", sub)
-			} else {
+			userID, ok := claims["user_id"].(string)
+			if !ok {
+				userID, ok = claims["sub"].(string)
+			}
+			if !ok {
 				return c.JSON(http.StatusUnauthorized, map[string]interface{}{
 					"error":   "unauthorized",
 					"message": "user_id or sub claim is required in JWT",
 				})
 			}
+			c.Set("user_id", userID)
+			c.SetRequest(c.Request().WithContext(domain.WithEndUser(c.Request().Context(), userID)))
+			c.Set("scopes", tokenScopes(claims))
+			c.Set("roles", tokenRoles(claims))
+			addLogFields(c, "user_id", userID)
+
+			return next(c)
+		}
+	}
+}
+
+// tokenScopes reads the scope claim, a space separated string as in OAuth 2.0
+// or a list of strings
+func tokenScopes(claims jwt.MapClaims) []string {
+	return claimStrings(claims["scope"])
+}
+
+// tokenRoles reads the roles claim, or the single role claim of the auth
+// services that only give a user one
+func tokenRoles(claims jwt.MapClaims) []string {
+	if roles, ok := claims["roles"]; ok {
+		return claimStrings(roles)
+	}
+	return claimStrings(claims["role"])
+}
+
+func claimStrings(claim interface{}) []string {
+	switch claim := claim.(type) {
+	case string:
+		return strings.Fields(claim)
+	case []interface{}:
+		values := make([]string, 0, len(claim))
+		for _, v := range claim {
+			if v, ok := v.(string); ok {
+				values = append(values, v)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// HasScope reports whether the JWT of the request was granted the scope
+func HasScope(c echo.Context, scope string) bool {
+	scopes, _ := c.Get("scopes").([]string)
+	return slices.Contains(scopes, scope)
+}
+
+// HasRole reports whether the user of the JWT of the request has the role
+func HasRole(c echo.Context, role string) bool {
+	roles, _ := c.Get("roles").([]string)
+	return slices.Contains(roles, role)
+}
+
+// RequireScope lets a request through when its JWT was granted any of the
+// scopes and answers 403 otherwise. It runs after JWTAuthMiddleware.
+func RequireScope(scopes ...string) echo.MiddlewareFunc {
+	return requireAny(scopes, HasScope, "Insufficient scope")
+}
+
+// RequireRole lets a request through when the user of its JWT has any of the
+// roles and answers 403 otherwise. It runs after JWTAuthMiddleware.
+func RequireRole(roles ...string) echo.MiddlewareFunc {
+	return requireAny(roles, HasRole, "Insufficient role")
+}
+
+func requireAny(grants []string, has func(echo.Context, string) bool, message string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			for _, grant := range grants {
+				if has(c, grant) {
+					return next(c)
+				}
+			}
+			return c.JSON(http.StatusForbidden, map[string]interface{}{
+				"error":   "forbidden",
+				"message": message + ", requires one of: " + strings.Join(grants, ", "),
+			})
+		}
+	}
+}
+
+// AdminAPIKeyMiddleware protects the admin endpoints with the X-API-Key header,
+// they are called by internal tools and not by riders with a JWT
+func AdminAPIKeyMiddleware(cfg *config.Config) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			expectedKey := strings.TrimSpace(cfg.AdminAPIKey)
+			if expectedKey == "" {
+				return c.JSON(http.StatusUnauthorized, map[string]interface{}{
+					"error":   "unauthorized",
+					"message": "Admin API key is not configured",
+				})
+			}
+
+			apiKey := strings.TrimSpace(c.Request().Header.Get("X-API-Key"))
+			if subtle.ConstantTimeCompare([]byte(apiKey), []byte(expectedKey)) != 1 {
+				return c.JSON(http.StatusUnauthorized, map[string]interface{}{
+					"error":   "unauthorized",
+					"message": "Invalid API key",
+				})
+			}
 
 			return next(c)
 		}
@@ -1,15 +1,24 @@
 package middleware
 
 import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"the-matching-service/config"
+	"the-matching-service/internal/domain"
+	"the-matching-service/internal/ports/secondary"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func generateJWT(secret string, claims jwt.MapClaims) string {
@@ -19,7 +28,7 @@ func generateJWT(secret string, claims jwt.MapClaims) string {
 }
 
 // TestJWTAuthMiddleware_validToken tests successful authentication with valid JWT token
-// Expected: Should authenticate successfully and set user_id and is_authenticated in context
+// Expected: Should authenticate successfully, set user_id and is_authenticated in context and the user in the request context
 func TestJWTAuthMiddleware_validToken(t *testing.T) {
 	e := echo.New()
 	cfg := &config.Config{JWTSecret: "testsecret"}
@@ -36,6 +45,7 @@ func TestJWTAuthMiddleware_validToken(t *testing.T) {
 		isAuth := c.Get("is_authenticated")
 		assert.Equal(t, "user-1", userID)
 		assert.Equal(t, true, isAuth)
+		assert.Equal(t, "user-1", domain.EndUserFrom(c.Request().Context()))
 		return c.String(http.StatusOK, "ok")
 	}
 
@@ -148,3 +158,169 @@ func TestJWTAuthMiddleware_TokenWithoutBearerPrefix(t *testing.T) {
 	assert.NoError(t, middleware(c))
 	assert.Equal(t, http.StatusOK, w.Code)
 }
+
+// TestJWTAuthMiddleware_scopes tests reading the scope claim of a token
+// Expected: Should accept a space separated string or a list of strings and grant nothing without the claim
+func TestJWTAuthMiddleware_scopes(t *testing.T) {
+	cfg := &config.Config{JWTSecret: "testsecret"}
+	for _, tc := range []struct {
+		name     string
+		scope    interface{}
+		expected bool
+	}{
+		{name: "string", scope: "match:read match:candidates", expected: true},
+		{name: "list", scope: []string{"match:read", "match:candidates"}, expected: true},
+		{name: "other scopes", scope: "match:read", expected: false},
+		{name: "no claim", expected: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			claims := jwt.MapClaims{"user_id": "user-1", "authenticated": true}
+			if tc.scope != nil {
+				claims["scope"] = tc.scope
+			}
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+generateJWT(cfg.JWTSecret, claims))
+			c := echo.New().NewContext(req, httptest.NewRecorder())
+
+			h := func(c echo.Context) error {
+				assert.Equal(t, tc.expected, HasScope(c, "match:candidates"))
+				return nil
+			}
+			assert.NoError(t, JWTAuthMiddleware(cfg)(h)(c))
+		})
+	}
+}
+
+// TestRequireScope tests authorizing requests by the scopes and roles of their token
+// Expected: Should pass tokens granted any of the scopes or roles, read a single role claim, and answer 403 to the others
+func TestRequireScope(t *testing.T) {
+	cfg := &config.Config{JWTSecret: "testsecret"}
+	serve := func(authorize echo.MiddlewareFunc, claims jwt.MapClaims) *httptest.ResponseRecorder {
+		claims["user_id"] = "user-1"
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+generateJWT(cfg.JWTSecret, claims))
+		w := httptest.NewRecorder()
+		h := JWTAuthMiddleware(cfg)(authorize(func(c echo.Context) error {
+			return c.String(http.StatusOK, "ok")
+		}))
+		_ = h(echo.New().NewContext(req, w))
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, serve(RequireScope("rider"), jwt.MapClaims{"scope": "rider"}).Code)
+	assert.Equal(t, http.StatusOK, serve(RequireScope("rider", "driver"), jwt.MapClaims{"scope": []string{"driver"}}).Code)
+	w := serve(RequireScope("rider"), jwt.MapClaims{"scope": "driver", "roles": []string{"rider"}})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "Insufficient scope")
+
+	assert.Equal(t, http.StatusOK, serve(RequireRole("admin"), jwt.MapClaims{"roles": []string{"support", "admin"}}).Code)
+	assert.Equal(t, http.StatusOK, serve(RequireRole("admin"), jwt.MapClaims{"role": "admin"}).Code)
+	assert.Equal(t, http.StatusForbidden, serve(RequireRole("admin"), jwt.MapClaims{"scope": "admin"}).Code)
+}
+
+func serveJWT(cfg *config.Config, keys secondary.JWTKeySet, token string) int {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	w := httptest.NewRecorder()
+	h := JWTAuthMiddlewareWithKeySet(cfg, keys)(func(c echo.Context) error {
+		return c.String(http.StatusOK, "ok")
+	})
+	_ = h(echo.New().NewContext(req, w))
+	return w.Code
+}
+
+// TestJWTAuthMiddleware_claims tests the issuer, audience and expiry of tokens
+// Expected: Should accept tokens of the configured issuer and audience and refuse others, expired ones and, when required, ones without exp
+func TestJWTAuthMiddleware_claims(t *testing.T) {
+	cfg := &config.Config{JWTSecret: "testsecret", JWT: config.JWTConfig{Issuer: "https://auth.example.com", Audience: "matching", RequireExpiry: true}}
+	valid := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"sub": "rider-1",
+			"iss": "https://auth.example.com",
+			"aud": []string{"matching", "payments"},
+			"exp": time.Now().Add(time.Hour).Unix(),
+		}
+	}
+	assert.Equal(t, http.StatusOK, serveJWT(cfg, nil, generateJWT(cfg.JWTSecret, valid())))
+
+	for name, change := range map[string]func(jwt.MapClaims){
+		"other issuer":   func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" },
+		"other audience": func(c jwt.MapClaims) { c["aud"] = "payments" },
+		"expired":        func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() },
+		"no expiry":      func(c jwt.MapClaims) { delete(c, "exp") },
+	} {
+		claims := valid()
+		change(claims)
+		assert.Equal(t, http.StatusUnauthorized, serveJWT(cfg, nil, generateJWT(cfg.JWTSecret, claims)), name)
+	}
+
+	cfg.JWT.Leeway = time.Minute
+	claims := valid()
+	claims["exp"] = time.Now().Add(-10 * time.Second).Unix()
+	assert.Equal(t, http.StatusOK, serveJWT(cfg, nil, generateJWT(cfg.JWTSecret, claims)), "expired within the leeway")
+}
+
+type staticKeySet map[string]*rsa.PublicKey
+
+func (s staticKeySet) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	if key, ok := s[kid]; ok {
+		return key, nil
+	}
+	return nil, errors.New("unknown key")
+}
+
+// TestJWTAuthMiddleware_keySet tests tokens signed with the RSA keys of a JWKS
+// Expected: Should verify RS256 tokens with the key of their kid and refuse unknown kids and HMAC tokens without a JWT secret
+func TestJWTAuthMiddleware_keySet(t *testing.T) {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keys := staticKeySet{"k1": &private.PublicKey}
+	sign := func(kid string) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"sub": "rider-1", "exp": time.Now().Add(time.Hour).Unix()})
+		token.Header["kid"] = kid
+		signed, err := token.SignedString(private)
+		require.NoError(t, err)
+		return signed
+	}
+
+	cfg := &config.Config{JWTSecret: "testsecret"}
+	assert.Equal(t, http.StatusOK, serveJWT(cfg, keys, sign("k1")))
+	assert.Equal(t, http.StatusUnauthorized, serveJWT(cfg, keys, sign("k2")))
+	assert.Equal(t, http.StatusUnauthorized, serveJWT(cfg, nil, sign("k1")), "RSA tokens need a key set")
+	assert.Equal(t, http.StatusOK, serveJWT(cfg, keys, generateJWT(cfg.JWTSecret, jwt.MapClaims{"sub": "rider-1"})))
+
+	cfg.JWTSecret = ""
+	assert.Equal(t, http.StatusOK, serveJWT(cfg, keys, sign("k1")))
+	assert.Equal(t, http.StatusUnauthorized, serveJWT(cfg, keys, generateJWT("testsecret", jwt.MapClaims{"sub": "rider-1"})))
+}
+
+// TestAdminAPIKeyMiddleware tests the API key check of the admin endpoints
+// Expected: Should pass requests with the configured key and reject wrong keys or a missing configuration
+func TestAdminAPIKeyMiddleware(t *testing.T) {
+	e := echo.New()
+	h := func(c echo.Context) error { return c.String(http.StatusOK, "ok") }
+
+	tests := []struct {
+		name     string
+		adminKey string
+		apiKey   string
+		expected int
+	}{
+		{"valid key", "admin-key", "admin-key", http.StatusOK},
+		{"wrong key", "admin-key", "other", http.StatusUnauthorized},
+		{"missing key", "admin-key", "", http.StatusUnauthorized},
+		{"not configured", "", "", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("X-API-Key", tt.apiKey)
+			w := httptest.NewRecorder()
+
+			middleware := AdminAPIKeyMiddleware(&config.Config{AdminAPIKey: tt.adminKey})(h)
+			assert.NoError(t, middleware(e.NewContext(req, w)))
+			assert.Equal(t, tt.expected, w.Code)
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"the-matching-service/internal/domain"
+
+	"github.com/labstack/echo/v4"
+	echoMiddleware "github.com/labstack/echo/v4/middleware"
+)
+
+// RequestLogFields adds the request ID and the rider and driver IDs of the
+// path, when the route has them, to the context of the request, so every entry
+// logged while handling it can be traced back to the request. The request ID is
+// the X-Request-ID the caller sent or the one the RequestID middleware
+// generated when it runs first. The JWT middleware adds the user ID.
+func RequestLogFields() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			var fields []any
+			requestID := c.Response().Header().Get(echo.HeaderXRequestID)
+			if requestID == "" {
+				requestID = c.Request().Header.Get(echo.HeaderXRequestID)
+			}
+			if requestID != "" {
+				fields = append(fields, "request_id", requestID)
+			}
+			for _, param := range []string{"rider_id", "driver_id"} {
+				if value := c.Param(param); value != "" {
+					fields = append(fields, param, value)
+				}
+			}
+
+			if len(fields) > 0 {
+				addLogFields(c, fields...)
+			}
+			return next(c)
+		}
+	}
+}
+
+func addLogFields(c echo.Context, keysAndValues ...any) {
+	req := c.Request()
+	c.SetRequest(req.WithContext(domain.WithLogFields(req.Context(), keysAndValues...)))
+}
+
+// AccessLog writes the echo access log. When the privacy policy hashes rider
+// IDs, the rider ID of the path is written hashed in the uri as well.
+func AccessLog(privacy domain.Privacy) echo.MiddlewareFunc {
+	if !privacy.HashRiderIDs {
+		return echoMiddleware.Logger()
+	}
+	config := echoMiddleware.DefaultLoggerConfig
+	config.Format = strings.Replace(config.Format, `"uri":"${uri}"`, `"uri":${custom}`, 1)
+	config.CustomTagFunc = func(c echo.Context, buf *bytes.Buffer) (int, error) {
+		encoded, err := json.Marshal(redactedURI(c, privacy))
+		if err != nil {
+			return 0, err
+		}
+		return buf.Write(encoded)
+	}
+	return echoMiddleware.LoggerWithConfig(config)
+}
+
+func redactedURI(c echo.Context, privacy domain.Privacy) string {
+	uri := c.Request().RequestURI
+	riderID := c.Param("rider_id")
+	if riderID == "" {
+		return uri
+	}
+	return strings.Replace(uri, "/"+url.PathEscape(riderID), "/"+privacy.RiderID(riderID), 1)
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"the-matching-service/config"
+	"the-matching-service/internal/domain"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRequestLogFields tests the log fields added to requests of riders and of the admin endpoints
+// Expected: Should add the request ID the caller sent or the generated one, the authenticated user and the IDs of the path
+func TestRequestLogFields(t *testing.T) {
+	cfg := &config.Config{JWTSecret: "testsecret"}
+	e := echo.New()
+	e.Use(RequestID())
+	e.Use(RequestLogFields())
+	var fields []any
+	handler := func(c echo.Context) error {
+		fields = domain.LogFields(c.Request().Context())
+		return c.NoContent(http.StatusOK)
+	}
+	e.GET("/api/v1/matches", handler, JWTAuthMiddleware(cfg))
+	e.DELETE("/admin/riders/:rider_id/blocked-drivers/:driver_id", handler)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/matches", nil)
+	req.Header.Set(echo.HeaderXRequestID, "correlation-1")
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+generateJWT(cfg.JWTSecret, jwt.MapClaims{"sub": "rider-1", "authenticated": true}))
+	e.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, []any{"request_id", "correlation-1", "user_id", "rider-1"}, fields)
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/admin/riders/rider-1/blocked-drivers/driver-1", nil))
+	generated := rec.Header().Get(echo.HeaderXRequestID)
+	require.NotEmpty(t, generated)
+	assert.Equal(t, []any{"request_id", generated, "rider_id", "rider-1", "driver_id", "driver-1"}, fields)
+}
+
+// TestAccessLog_RedactsRiderID tests the uri of the access log of a rider path while rider IDs are hashed
+// Expected: Should write the hash of the rider ID in place of the ID and leave other paths alone
+func TestAccessLog_RedactsRiderID(t *testing.T) {
+	privacy := domain.Privacy{HashRiderIDs: true, HashKey: "secret"}
+	e := echo.New()
+	var uri string
+	handler := func(c echo.Context) error {
+		uri = redactedURI(c, privacy)
+		return c.NoContent(http.StatusOK)
+	}
+	e.DELETE("/admin/riders/:rider_id/blocked-drivers/:driver_id", handler)
+	e.GET("/api/v1/matches", handler)
+
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/admin/riders/rider-1/blocked-drivers/driver-1?x=1", nil))
+	assert.Equal(t, "/admin/riders/"+domain.HashEndUser("secret", "rider-1")+"/blocked-drivers/driver-1?x=1", uri)
+
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/matches?limit=5", nil))
+	assert.Equal(t, "/api/v1/matches?limit=5", uri)
+}
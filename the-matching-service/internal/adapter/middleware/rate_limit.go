@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"the-matching-service/internal/domain"
+	"the-matching-service/internal/ports/secondary"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	RateLimitLimitHeader     = "X-RateLimit-Limit"
+	RateLimitRemainingHeader = "X-RateLimit-Remaining"
+)
+
+// rateLimitedRequestsTotal counts the requests refused with 429 per route
+var rateLimitedRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "matching_service",
+	Name:      "rate_limited_requests_total",
+	Help:      "Number of requests refused by the rate limiter by route.",
+}, []string{"route"})
+
+// RateLimitConfig limits the requests of each caller, Key names the caller of a
+// request and requests it returns "" for are not limited
+type RateLimitConfig struct {
+	Limiter secondary.RateLimiter
+	Limit   domain.RateLimit
+	Key     func(c echo.Context) string
+	Logger  secondary.Logger // where limiter failures are logged, nil discards them
+}
+
+// UserRateLimitKey limits each user of a JWT, it runs after JWTAuthMiddleware
+func UserRateLimitKey(c echo.Context) string {
+	if userID, ok := c.Get("user_id").(string); ok && userID != "" {
+		return "user:" + userID
+	}
+	return ""
+}
+
+// RateLimit answers 429 with Retry-After once a caller used up its limit. The
+// requests pass when the limiter fails, an unavailable Redis must not take the
+// API down with it.
+func RateLimit(config RateLimitConfig) echo.MiddlewareFunc {
+	logger := config.Logger
+	if logger == nil {
+		logger = secondary.NopLogger{}
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			key := config.Key(c)
+			if key == "" {
+				return next(c)
+			}
+
+			decision, err := config.Limiter.Allow(c.Request().Context(), key, config.Limit)
+			if err != nil {
+				logger.Warn(c.Request().Context(), "rate limiter failed, letting the request through", "error", err)
+				return next(c)
+			}
+
+			header := c.Response().Header()
+			header.Set(RateLimitLimitHeader, strconv.Itoa(config.Limit.Burst))
+			header.Set(RateLimitRemainingHeader, strconv.Itoa(decision.Remaining))
+			if decision.Allowed {
+				return next(c)
+			}
+
+			retryAfter := max(1, int(math.Ceil(decision.RetryAfter.Seconds())))
+			header.Set("Retry-After", strconv.Itoa(retryAfter))
+			rateLimitedRequestsTotal.WithLabelValues(c.Path()).Inc()
+			return c.JSON(http.StatusTooManyRequests, map[string]interface{}{
+				"error":   "rate_limited",
+				"message": "Too many requests, retry in " + strconv.Itoa(retryAfter) + "s",
+			})
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"the-matching-service/internal/domain"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type stubRateLimiter struct {
+	decision domain.RateLimitDecision
+	err      error
+	keys     []string
+}
+
+func (l *stubRateLimiter) Allow(ctx context.Context, key string, limit domain.RateLimit) (domain.RateLimitDecision, error) {
+	l.keys = append(l.keys, key)
+	return l.decision, l.err
+}
+
+func serveRateLimited(limiter *stubRateLimiter, userID string) *httptest.ResponseRecorder {
+	e := echo.New()
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodPost, "/api/v1/match", nil), rec)
+	if userID != "" {
+		c.Set("user_id", userID)
+	}
+
+	h := RateLimit(RateLimitConfig{
+		Limiter: limiter,
+		Limit:   domain.RateLimit{Rate: 1, Burst: 5},
+		Key:     UserRateLimitKey,
+	})(func(c echo.Context) error {
+		return c.String(http.StatusOK, "ok")
+	})
+	_ = h(c)
+	return rec
+}
+
+// TestRateLimit_Allowed tests a request within the limit of its user
+// Expected: Should pass the request on with the limit and the remaining requests in the headers
+func TestRateLimit_Allowed(t *testing.T) {
+	limiter := &stubRateLimiter{decision: domain.RateLimitDecision{Allowed: true, Remaining: 4}}
+	rec := serveRateLimited(limiter, "rider-1")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, []string{"user:rider-1"}, limiter.keys)
+	assert.Equal(t, "5", rec.Header().Get(RateLimitLimitHeader))
+	assert.Equal(t, "4", rec.Header().Get(RateLimitRemainingHeader))
+	assert.Empty(t, rec.Header().Get("Retry-After"))
+}
+
+// TestRateLimit_Exceeded tests a request over the limit of its user
+// Expected: Should return 429 with Retry-After rounded up to whole seconds
+func TestRateLimit_Exceeded(t *testing.T) {
+	limiter := &stubRateLimiter{decision: domain.RateLimitDecision{RetryAfter: 1200 * time.Millisecond}}
+	rec := serveRateLimited(limiter, "rider-1")
+
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("Retry-After"))
+	assert.Equal(t, "0", rec.Header().Get(RateLimitRemainingHeader))
+	assert.Contains(t, rec.Body.String(), "rate_limited")
+}
+
+// TestRateLimit_Unlimited tests requests the rate limit does not take from
+// Expected: Should let requests without a user and requests the limiter failed on through
+func TestRateLimit_Unlimited(t *testing.T) {
+	limiter := &stubRateLimiter{}
+	rec := serveRateLimited(limiter, "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, limiter.keys)
+
+	limiter = &stubRateLimiter{err: errors.New("connection refused")}
+	rec = serveRateLimited(limiter, "rider-1")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get(RateLimitLimitHeader))
+}
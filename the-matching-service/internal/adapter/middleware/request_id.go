@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"the-matching-service/internal/domain"
+
+	"github.com/labstack/echo/v4"
+)
+
+// maxRequestIDLength bounds the X-Request-ID accepted from callers, it ends up
+// in every log entry of the request
+const maxRequestIDLength = 128
+
+// RequestID accepts the X-Request-ID the caller sent or generates one when it is
+// missing or not a plain token. The ID is returned in the response and carried
+// by the request context, so the calls to the driver location service forward
+// it and a match can be followed through the logs of both services.
+func RequestID() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			requestID := req.Header.Get(echo.HeaderXRequestID)
+			if !validRequestID(requestID) {
+				requestID = newRequestID()
+				req.Header.Set(echo.HeaderXRequestID, requestID)
+			}
+			c.Response().Header().Set(echo.HeaderXRequestID, requestID)
+			c.SetRequest(req.WithContext(domain.WithRequestID(req.Context(), requestID)))
+			return next(c)
+		}
+	}
+}
+
+// validRequestID accepts letters, digits and the separators UUIDs and trace IDs
+// are written with
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range requestID {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"the-matching-service/internal/domain"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRequestID tests the request ID of requests with and without a usable X-Request-ID
+// Expected: Should keep the caller's ID, replace missing, oversized and malformed ones and return the ID in the response and the request context
+func TestRequestID(t *testing.T) {
+	e := echo.New()
+	e.Use(RequestID())
+	var fromContext string
+	e.GET("/", func(c echo.Context) error {
+		fromContext = domain.RequestIDFrom(c.Request().Context())
+		return c.NoContent(http.StatusOK)
+	})
+
+	tests := []struct {
+		name      string
+		requestID string
+		kept      bool
+	}{
+		{name: "uuid", requestID: "0f8fad5b-d9cb-469f-a165-70867728950e", kept: true},
+		{name: "trace", requestID: "match.rider_1:42", kept: true},
+		{name: "missing", requestID: ""},
+		{name: "oversized", requestID: strings.Repeat("a", maxRequestIDLength+1)},
+		{name: "spaces", requestID: "not a token"},
+		{name: "log injection", requestID: "id\"}\n{\"level\":\"ERROR\""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(echo.HeaderXRequestID, tt.requestID)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			returned := rec.Header().Get(echo.HeaderXRequestID)
+			if tt.kept {
+				assert.Equal(t, tt.requestID, returned)
+			} else {
+				assert.Len(t, returned, 32)
+			}
+			assert.Equal(t, returned, fromContext)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"the-matching-service/internal/buildinfo"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// ServiceVersionHeader carries the version of the instance that answered
+const ServiceVersionHeader = "X-Service-Version"
+
+// buildInfo is always 1, its labels describe the running binary so any series
+// can be joined with the deploy it came from
+var buildInfo = promauto.NewGaugeFunc(prometheus.GaugeOpts{
+	Namespace: "matching_service",
+	Name:      "build_info",
+	Help:      "Build of the running binary, the value is always 1.",
+	ConstLabels: prometheus.Labels{
+		"version":    buildinfo.Get().Version,
+		"git_sha":    buildinfo.Get().GitSHA,
+		"build_time": buildinfo.Get().BuildTime,
+		"go_version": buildinfo.Get().GoVersion,
+	},
+}, func() float64 { return 1 })
+
+// ServiceVersion answers every request with the version of the running binary
+// in X-Service-Version
+func ServiceVersion() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Response().Header().Set(ServiceVersionHeader, buildinfo.Version)
+			return next(c)
+		}
+	}
+}
+
+// buildLabels are the constant labels of the request metrics, a latency
+// regression then shows on the series of the deploy that brought it
+func buildLabels() prometheus.Labels {
+	info := buildinfo.Get()
+	return prometheus.Labels{"version": info.Version, "git_sha": info.GitSHA}
+}
+
+// HistogramBuildLabels adds the version and commit of the binary to the request
+// latency histograms of echoprometheus
+func HistogramBuildLabels(opts prometheus.HistogramOpts) prometheus.HistogramOpts {
+	opts.ConstLabels = buildLabels()
+	return opts
+}
+
+// CounterBuildLabels adds the version and commit of the binary to the request
+// counters of echoprometheus
+func CounterBuildLabels(opts prometheus.CounterOpts) prometheus.CounterOpts {
+	opts.ConstLabels = buildLabels()
+	return opts
+}
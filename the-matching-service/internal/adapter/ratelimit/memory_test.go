@@ -0,0 +1,62 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"the-matching-service/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMemoryLimiter_Allow tests taking tokens from the bucket of a user
+// Expected: Should allow the burst at once, refuse the next request until a token refilled and keep users apart
+func TestMemoryLimiter_Allow(t *testing.T) {
+	now := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+	limiter := NewMemoryLimiter()
+	limiter.now = func() time.Time { return now }
+	limit := domain.RateLimit{Rate: 2, Burst: 3}
+	ctx := context.Background()
+
+	for remaining := 2; remaining >= 0; remaining-- {
+		decision, err := limiter.Allow(ctx, "user:rider-1", limit)
+		require.NoError(t, err)
+		assert.True(t, decision.Allowed)
+		assert.Equal(t, remaining, decision.Remaining)
+	}
+
+	decision, err := limiter.Allow(ctx, "user:rider-1", limit)
+	require.NoError(t, err)
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, 500*time.Millisecond, decision.RetryAfter)
+
+	decision, err = limiter.Allow(ctx, "user:rider-2", limit)
+	require.NoError(t, err)
+	assert.True(t, decision.Allowed)
+
+	now = now.Add(500 * time.Millisecond)
+	decision, err = limiter.Allow(ctx, "user:rider-1", limit)
+	require.NoError(t, err)
+	assert.True(t, decision.Allowed)
+	assert.Zero(t, decision.Remaining)
+}
+
+// TestMemoryLimiter_Sweep tests dropping the buckets of idle users
+// Expected: Should drop a bucket once it refilled and start it again full
+func TestMemoryLimiter_Sweep(t *testing.T) {
+	now := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+	limiter := NewMemoryLimiter()
+	limiter.now = func() time.Time { return now }
+	limit := domain.RateLimit{Rate: 1, Burst: 2}
+
+	_, err := limiter.Allow(context.Background(), "user:rider-1", limit)
+	require.NoError(t, err)
+	now = now.Add(sweepInterval)
+	_, err = limiter.Allow(context.Background(), "user:rider-2", limit)
+	require.NoError(t, err)
+
+	assert.NotContains(t, limiter.buckets, "user:rider-1")
+	assert.Contains(t, limiter.buckets, "user:rider-2")
+}
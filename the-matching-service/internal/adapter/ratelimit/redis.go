@@ -0,0 +1,70 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"the-matching-service/internal/domain"
+	"the-matching-service/internal/ports/secondary"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const keyPrefix = "ratelimit:"
+
+// allowScript refills and takes from the bucket in one step, so concurrent
+// requests of a caller on several instances cannot take the same token. The
+// clock is the one of Redis, the instances may disagree on theirs. It returns
+// whether a token was taken, the tokens left and the milliseconds until the
+// next one.
+var allowScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) + tonumber(time[2]) / 1000000
+
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'updated')
+local tokens = tonumber(bucket[1]) or burst
+local updated = tonumber(bucket[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - updated) * rate)
+
+local allowed, retry = 0, 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+else
+  retry = math.ceil((1 - tokens) / rate * 1000)
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'updated', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil((burst - tokens) / rate * 1000) + 1000)
+return {allowed, math.floor(tokens), retry}
+`)
+
+// RedisLimiter keeps every bucket as a hash under ratelimit:{key} that expires
+// once the bucket refilled, so the limit holds across the instances
+type RedisLimiter struct {
+	client *redis.Client
+}
+
+var _ secondary.RateLimiter = (*RedisLimiter)(nil)
+
+func NewRedisLimiter(client *redis.Client) *RedisLimiter {
+	return &RedisLimiter{client: client}
+}
+
+func (l *RedisLimiter) Allow(ctx context.Context, key string, limit domain.RateLimit) (domain.RateLimitDecision, error) {
+	result, err := allowScript.Run(ctx, l.client, []string{keyPrefix + key}, limit.Rate, limit.Burst).Int64Slice()
+	if err != nil {
+		return domain.RateLimitDecision{}, fmt.Errorf("failed to take a rate limit token: %w", err)
+	}
+	if len(result) != 3 {
+		return domain.RateLimitDecision{}, fmt.Errorf("failed to take a rate limit token: unexpected reply %v", result)
+	}
+	return domain.RateLimitDecision{
+		Allowed:    result[0] == 1,
+		Remaining:  int(result[1]),
+		RetryAfter: time.Duration(result[2]) * time.Millisecond,
+	}, nil
+}
@@ -0,0 +1,92 @@
+package ridestore
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"the-matching-service/internal/domain"
+	"the-matching-service/internal/ports/secondary"
+)
+
+// MemoryRideStore keeps the pooled rides of this instance. Riders leave a ride
+// when their match is declined or cancelled, nothing reports the end of a ride
+// to the matching service otherwise and a ride is forgotten ttl after it started.
+type MemoryRideStore struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu    sync.Mutex
+	rides map[string]domain.PooledRide
+}
+
+var _ secondary.RideStore = (*MemoryRideStore)(nil)
+
+func NewMemoryRideStore(ttl time.Duration) *MemoryRideStore {
+	if ttl <= 0 {
+		ttl = 45 * time.Minute
+	}
+	return &MemoryRideStore{
+		ttl:   ttl,
+		now:   time.Now,
+		rides: make(map[string]domain.PooledRide),
+	}
+}
+
+func (s *MemoryRideStore) ActiveRides(ctx context.Context) ([]domain.PooledRide, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	rides := make([]domain.PooledRide, 0, len(s.rides))
+	for driverID, ride := range s.rides {
+		if !now.Before(ride.ExpiresAt) {
+			delete(s.rides, driverID)
+			continue
+		}
+		ride.RiderIDs = slices.Clone(ride.RiderIDs)
+		rides = append(rides, ride)
+	}
+	return rides, nil
+}
+
+func (s *MemoryRideStore) Start(ctx context.Context, ride domain.PooledRide) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ride.RiderIDs = slices.Clone(ride.RiderIDs)
+	ride.ExpiresAt = s.now().Add(s.ttl)
+	s.rides[ride.DriverID] = ride
+	return nil
+}
+
+func (s *MemoryRideStore) Join(ctx context.Context, driverID, riderID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ride, ok := s.rides[driverID]
+	if !ok || !s.now().Before(ride.ExpiresAt) || ride.SeatsLeft() == 0 {
+		return false, nil
+	}
+	ride.RiderIDs = append(ride.RiderIDs, riderID)
+	s.rides[driverID] = ride
+	return true, nil
+}
+
+func (s *MemoryRideStore) Leave(ctx context.Context, driverID, riderID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ride, ok := s.rides[driverID]
+	if !ok {
+		return nil
+	}
+	ride.RiderIDs = slices.DeleteFunc(ride.RiderIDs, func(id string) bool { return id == riderID })
+	if len(ride.RiderIDs) == 0 {
+		delete(s.rides, driverID)
+		return nil
+	}
+	s.rides[driverID] = ride
+	return nil
+}
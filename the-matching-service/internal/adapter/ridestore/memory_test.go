@@ -0,0 +1,79 @@
+package ridestore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"the-matching-service/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMemoryRideStore_Join tests riders joining a pooled ride
+// Expected: Should add riders while seats are left and refuse unknown drivers and full rides
+func TestMemoryRideStore_Join(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryRideStore(time.Hour)
+	assert.NoError(t, store.Start(ctx, domain.PooledRide{DriverID: "driver-1", Capacity: 2, RiderIDs: []string{"rider-1"}}))
+
+	joined, err := store.Join(ctx, "driver-1", "rider-2")
+	assert.NoError(t, err)
+	assert.True(t, joined)
+
+	joined, err = store.Join(ctx, "driver-1", "rider-3")
+	assert.NoError(t, err)
+	assert.False(t, joined)
+
+	joined, err = store.Join(ctx, "driver-2", "rider-3")
+	assert.NoError(t, err)
+	assert.False(t, joined)
+
+	rides, err := store.ActiveRides(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, rides, 1)
+	assert.Equal(t, []string{"rider-1", "rider-2"}, rides[0].RiderIDs)
+	assert.Equal(t, 0, rides[0].SeatsLeft())
+}
+
+// TestMemoryRideStore_Leave tests riders leaving a pooled ride
+// Expected: Should free the seat of the rider and end the ride with its last rider
+func TestMemoryRideStore_Leave(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryRideStore(time.Hour)
+	assert.NoError(t, store.Start(ctx, domain.PooledRide{DriverID: "driver-1", Capacity: 2, RiderIDs: []string{"rider-1"}}))
+	joined, err := store.Join(ctx, "driver-1", "rider-2")
+	assert.NoError(t, err)
+	assert.True(t, joined)
+
+	assert.NoError(t, store.Leave(ctx, "driver-1", "rider-2"))
+	rides, err := store.ActiveRides(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"rider-1"}, rides[0].RiderIDs)
+	assert.Equal(t, 1, rides[0].SeatsLeft())
+
+	assert.NoError(t, store.Leave(ctx, "driver-1", "rider-1"))
+	assert.NoError(t, store.Leave(ctx, "driver-2", "rider-1"))
+	rides, err = store.ActiveRides(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, rides)
+}
+
+// TestMemoryRideStore_Expiry tests rides older than the TTL
+// Expected: Should forget expired rides and refuse riders joining them
+func TestMemoryRideStore_Expiry(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store := NewMemoryRideStore(time.Minute)
+	store.now = func() time.Time { return now }
+	assert.NoError(t, store.Start(ctx, domain.PooledRide{DriverID: "driver-1", Capacity: 4, RiderIDs: []string{"rider-1"}}))
+
+	now = now.Add(time.Minute)
+	joined, err := store.Join(ctx, "driver-1", "rider-2")
+	assert.NoError(t, err)
+	assert.False(t, joined)
+
+	rides, err := store.ActiveRides(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, rides)
+}
@@ -0,0 +1,290 @@
+package searchcache
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"the-matching-service/internal/domain"
+	"the-matching-service/internal/ports/secondary"
+
+	"golang.org/x/sync/singleflight"
+)
+
+const earthRadiusMeters = 6371000
+
+// metersPerDegree is the length of a degree of latitude, a degree of longitude is never longer
+const metersPerDegree = 111320
+
+type Options struct {
+	TTL         time.Duration
+	CellDegrees float64 // size of the grid cells locations are quantized to
+	MaxEntries  int
+}
+
+type entry struct {
+	drivers   []domain.DriverDistancePair
+	expiresAt time.Time
+	center    domain.Location
+	reach     float64 // radius of the upstream search around center
+	limit     int
+}
+
+// DriverLocationService caches nearby searches of the driver location service for a
+// short time, so a crowd of riders requesting at the same venue costs one upstream
+// search. Rider locations are quantized to grid cells and a miss searches around the
+// cell center with the radius grown by half the cell diagonal, so the cached drivers
+// cover every rider in the cell; distances are then computed from the actual rider.
+// Unless the driver events are handed to HandleDriverEvent, drivers that became
+// busy are still returned until the entry expires, the TTL then has to stay well
+// below the time a reservation takes so stale drivers are rare.
+type DriverLocationService struct {
+	upstream secondary.DriverLocationService
+	options  Options
+	now      func() time.Time
+
+	inflight singleflight.Group
+	mu       sync.Mutex
+	entries  map[string]entry
+	byDriver map[string]map[string]struct{} // driver ID -> keys of the entries holding it
+}
+
+var _ secondary.DriverLocationService = (*DriverLocationService)(nil)
+
+func New(upstream secondary.DriverLocationService, options Options) *DriverLocationService {
+	if options.CellDegrees <= 0 {
+		options.CellDegrees = 0.001
+	}
+	if options.MaxEntries <= 0 {
+		options.MaxEntries = 10000
+	}
+	return &DriverLocationService{
+		upstream: upstream,
+		options:  options,
+		now:      time.Now,
+		entries:  make(map[string]entry),
+		byDriver: make(map[string]map[string]struct{}),
+	}
+}
+
+func (s *DriverLocationService) FindNearbyDrivers(ctx context.Context, location domain.Location, radius float64, limit int, preferences domain.RiderPreferences) ([]domain.DriverDistancePair, error) {
+	center := s.cellCenter(location)
+	key := fmt.Sprintf("%.6f|%.6f|%.1f|%d|%s|%d", center.Coordinates[0], center.Coordinates[1], radius, limit, preferences.VehicleType, preferences.MinCapacity)
+
+	start := time.Now()
+	drivers, ok := s.get(key)
+	if ok {
+		domain.MatchAuditFrom(ctx).RecordUpstream(domain.UpstreamCall{
+			Operation:  "search",
+			Radius:     radius,
+			Cached:     true,
+			DurationMs: domain.Milliseconds(time.Since(start)),
+			Drivers:    len(drivers),
+		})
+	} else {
+		v, err, _ := s.inflight.Do(key, func() (interface{}, error) {
+			if drivers, ok := s.get(key); ok {
+				return drivers, nil
+			}
+			margin := s.options.CellDegrees / 2 * math.Sqrt2 * metersPerDegree
+			drivers, err := s.upstream.FindNearbyDrivers(context.WithoutCancel(ctx), center, radius+margin, limit, preferences)
+			if err != nil {
+				return nil, err
+			}
+			s.put(key, drivers, center, radius+margin, limit)
+			return drivers, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		drivers = v.([]domain.DriverDistancePair)
+	}
+
+	// the upstream picked the nearest drivers to the cell center, the rider gets
+	// the nearest of those to its own location
+	drivers = withinRadius(drivers, location, radius)
+	if limit > 0 && len(drivers) > limit {
+		drivers = drivers[:limit]
+	}
+	return drivers, nil
+}
+
+func (s *DriverLocationService) cellCenter(location domain.Location) domain.Location {
+	cell := s.options.CellDegrees
+	return domain.Location{
+		Type: "Point",
+		Coordinates: [2]float64{
+			(math.Floor(location.Coordinates[0]/cell) + 0.5) * cell,
+			(math.Floor(location.Coordinates[1]/cell) + 0.5) * cell,
+		},
+	}
+}
+
+func (s *DriverLocationService) get(key string) ([]domain.DriverDistancePair, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[key]
+	if !ok || !s.now().Before(e.expiresAt) {
+		return nil, false
+	}
+	return e.drivers, true
+}
+
+func (s *DriverLocationService) put(key string, drivers []domain.DriverDistancePair, center domain.Location, reach float64, limit int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.remove(key)
+	if len(s.entries) >= s.options.MaxEntries {
+		for k, e := range s.entries {
+			if !now.Before(e.expiresAt) {
+				s.remove(k)
+			}
+		}
+		if len(s.entries) >= s.options.MaxEntries {
+			return
+		}
+	}
+	s.entries[key] = entry{drivers: drivers, expiresAt: now.Add(s.options.TTL), center: center, reach: reach, limit: limit}
+	for _, pair := range drivers {
+		s.index(pair.Driver.ID, key)
+	}
+}
+
+// remove drops an entry and its drivers from the index, s.mu must be held
+func (s *DriverLocationService) remove(key string) {
+	e, ok := s.entries[key]
+	if !ok {
+		return
+	}
+	delete(s.entries, key)
+	for _, pair := range e.drivers {
+		s.unindex(pair.Driver.ID, key)
+	}
+}
+
+func (s *DriverLocationService) index(driverID, key string) {
+	keys, ok := s.byDriver[driverID]
+	if !ok {
+		keys = make(map[string]struct{})
+		s.byDriver[driverID] = keys
+	}
+	keys[key] = struct{}{}
+}
+
+func (s *DriverLocationService) unindex(driverID, key string) {
+	delete(s.byDriver[driverID], key)
+	if len(s.byDriver[driverID]) == 0 {
+		delete(s.byDriver, driverID)
+	}
+}
+
+// HandleDriverEvent applies a driver event to the cached searches it affects
+// and leaves every other entry alone, so the cache keeps its hits while drivers
+// move all the time. The entries holding the driver are looked up by its ID:
+// a move within the searched area updates the location in place, and a driver
+// that left the area, went busy or offline, or was deleted is taken out. A
+// driver showing up in the area of an entry that does not hold it drops that
+// entry, the event lacks the vehicle the upstream filters on. So does a change
+// that could let a driver the upstream left out past the limit into an entry
+// that is full: the driver leaving it or moving away from its center.
+func (s *DriverLocationService) HandleDriverEvent(ctx context.Context, event domain.DriverEvent) {
+	available := event.Location != nil && event.Type != domain.DriverDeleted && event.Type != domain.DriverWentOffline &&
+		event.Status != "busy" && event.Status != "offline"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	holding := make([]string, 0, len(s.byDriver[event.DriverID]))
+	for key := range s.byDriver[event.DriverID] {
+		holding = append(holding, key)
+	}
+	for _, key := range holding {
+		if e := s.entries[key]; now.Before(e.expiresAt) {
+			s.applyEvent(key, e, event, available)
+		} else {
+			s.remove(key)
+		}
+	}
+	if !available {
+		return
+	}
+
+	for key, e := range s.entries {
+		if slices.Contains(holding, key) || !now.Before(e.expiresAt) {
+			continue
+		}
+		if covers(e, *event.Location) {
+			s.remove(key)
+		}
+	}
+}
+
+// applyEvent updates the driver of the event in an entry holding it, s.mu must be held
+func (s *DriverLocationService) applyEvent(key string, e entry, event domain.DriverEvent, available bool) {
+	i := slices.IndexFunc(e.drivers, func(pair domain.DriverDistancePair) bool { return pair.Driver.ID == event.DriverID })
+	full := e.limit > 0 && len(e.drivers) >= e.limit
+
+	// the slice may still be read by the riders the entry was returned to
+	drivers := slices.Clone(e.drivers)
+	if available && covers(e, *event.Location) {
+		distance := haversine(e.center, *event.Location)
+		if full && distance > drivers[i].Distance {
+			s.remove(key)
+			return
+		}
+		drivers[i].Driver.Location = *event.Location
+		drivers[i].Distance = distance
+	} else {
+		if full {
+			s.remove(key)
+			return
+		}
+		drivers = slices.Delete(drivers, i, i+1)
+		s.unindex(event.DriverID, key)
+	}
+	e.drivers = drivers
+	s.entries[key] = e
+}
+
+// covers reports whether the upstream search of an entry reached location
+func covers(e entry, location domain.Location) bool {
+	// the distance is never shorter than the one along the meridian, most entries
+	// are ruled out without the haversine
+	if math.Abs(location.Coordinates[1]-e.center.Coordinates[1])*math.Pi/180*earthRadiusMeters > e.reach {
+		return false
+	}
+	return haversine(e.center, location) <= e.reach
+}
+
+// withinRadius returns the drivers within radius of the rider ordered by distance,
+// the upstream distances are measured from the cell center and are replaced
+func withinRadius(drivers []domain.DriverDistancePair, location domain.Location, radius float64) []domain.DriverDistancePair {
+	result := make([]domain.DriverDistancePair, 0, len(drivers))
+	for _, pair := range drivers {
+		distance := haversine(location, pair.Driver.Location)
+		if distance <= radius {
+			result = append(result, domain.DriverDistancePair{Driver: pair.Driver, Distance: distance})
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Distance < result[j].Distance })
+	return result
+}
+
+// haversine returns the great circle distance between two locations in meters
+func haversine(from, to domain.Location) float64 {
+	lat1 := from.Coordinates[1] * math.Pi / 180
+	lat2 := to.Coordinates[1] * math.Pi / 180
+	dLat := lat2 - lat1
+	dLon := (to.Coordinates[0] - from.Coordinates[0]) * math.Pi / 180
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Sqrt(a))
+}
@@ -0,0 +1,213 @@
+package searchcache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"the-matching-service/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type countingUpstream struct {
+	calls       int
+	radius      float64
+	limit       int
+	location    domain.Location
+	preferences domain.RiderPreferences
+	drivers     []domain.DriverDistancePair
+	err         error
+}
+
+func (u *countingUpstream) FindNearbyDrivers(ctx context.Context, location domain.Location, radius float64, limit int, preferences domain.RiderPreferences) ([]domain.DriverDistancePair, error) {
+	u.calls++
+	u.location = location
+	u.radius = radius
+	u.limit = limit
+	u.preferences = preferences
+	return u.drivers, u.err
+}
+
+func point(lon, lat float64) domain.Location {
+	return domain.Location{Type: "Point", Coordinates: [2]float64{lon, lat}}
+}
+
+func driverAt(id string, lon, lat float64) domain.DriverDistancePair {
+	return domain.DriverDistancePair{Driver: domain.Driver{ID: id, Location: point(lon, lat)}}
+}
+
+// TestFindNearbyDrivers_CachesPerCell tests caching searches of riders in the same grid cell
+// Expected: Should search upstream once around the cell center with a grown radius and measure distances from each rider
+func TestFindNearbyDrivers_CachesPerCell(t *testing.T) {
+	upstream := &countingUpstream{drivers: []domain.DriverDistancePair{
+		driverAt("near", 29.0005, 41.0005),
+		driverAt("far", 29.0100, 41.0005),
+	}}
+	cache := New(upstream, Options{TTL: time.Second, CellDegrees: 0.001})
+
+	drivers, err := cache.FindNearbyDrivers(context.Background(), point(29.0001, 41.0001), 500, 0, domain.RiderPreferences{})
+	require.NoError(t, err)
+	require.Len(t, drivers, 1)
+	assert.Equal(t, "near", drivers[0].Driver.ID)
+	assert.InDelta(t, 55, drivers[0].Distance, 5)
+	assert.InDelta(t, 29.0005, upstream.location.Coordinates[0], 1e-9)
+	assert.Greater(t, upstream.radius, 500.0)
+
+	drivers, err = cache.FindNearbyDrivers(context.Background(), point(29.0009, 41.0009), 500, 0, domain.RiderPreferences{})
+	require.NoError(t, err)
+	require.Len(t, drivers, 1)
+	assert.InDelta(t, 55, drivers[0].Distance, 5)
+	assert.Equal(t, 1, upstream.calls)
+
+	// another cell or another radius is a separate entry
+	_, err = cache.FindNearbyDrivers(context.Background(), point(29.0011, 41.0001), 500, 0, domain.RiderPreferences{})
+	require.NoError(t, err)
+	_, err = cache.FindNearbyDrivers(context.Background(), point(29.0001, 41.0001), 1000, 0, domain.RiderPreferences{})
+	require.NoError(t, err)
+	assert.Equal(t, 3, upstream.calls)
+}
+
+// TestFindNearbyDrivers_Limit tests searches asking for a limited number of drivers
+// Expected: Should pass the limit upstream, cache per limit and return at most limit drivers nearest to the rider
+func TestFindNearbyDrivers_Limit(t *testing.T) {
+	upstream := &countingUpstream{drivers: []domain.DriverDistancePair{
+		driverAt("second", 29.0010, 41.0005),
+		driverAt("first", 29.0005, 41.0005),
+		driverAt("third", 29.0020, 41.0005),
+	}}
+	cache := New(upstream, Options{TTL: time.Second, CellDegrees: 0.001})
+
+	drivers, err := cache.FindNearbyDrivers(context.Background(), point(29.0001, 41.0001), 500, 2, domain.RiderPreferences{})
+	require.NoError(t, err)
+	require.Len(t, drivers, 2)
+	assert.Equal(t, "first", drivers[0].Driver.ID)
+	assert.Equal(t, "second", drivers[1].Driver.ID)
+	assert.Equal(t, 2, upstream.limit)
+
+	_, err = cache.FindNearbyDrivers(context.Background(), point(29.0001, 41.0001), 500, 5, domain.RiderPreferences{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, upstream.calls)
+	assert.Equal(t, 5, upstream.limit)
+}
+
+// TestFindNearbyDrivers_Preferences tests searches of riders with different preferences in the same cell
+// Expected: Should pass the preferences upstream and cache per preferences
+func TestFindNearbyDrivers_Preferences(t *testing.T) {
+	upstream := &countingUpstream{drivers: []domain.DriverDistancePair{driverAt("van", 29.0005, 41.0005)}}
+	cache := New(upstream, Options{TTL: time.Second, CellDegrees: 0.001})
+	vans := domain.RiderPreferences{VehicleType: "van", MinCapacity: 6}
+
+	_, err := cache.FindNearbyDrivers(context.Background(), point(29.0001, 41.0001), 500, 0, vans)
+	require.NoError(t, err)
+	assert.Equal(t, vans, upstream.preferences)
+
+	_, err = cache.FindNearbyDrivers(context.Background(), point(29.0002, 41.0002), 500, 0, vans)
+	require.NoError(t, err)
+	assert.Equal(t, 1, upstream.calls)
+
+	_, err = cache.FindNearbyDrivers(context.Background(), point(29.0002, 41.0002), 500, 0, domain.RiderPreferences{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, upstream.calls)
+	assert.Equal(t, domain.RiderPreferences{}, upstream.preferences)
+}
+
+// TestFindNearbyDrivers_Expires tests the TTL of cached searches
+// Expected: Should search upstream again once the entry expired
+func TestFindNearbyDrivers_Expires(t *testing.T) {
+	upstream := &countingUpstream{}
+	cache := New(upstream, Options{TTL: time.Second})
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	cache.FindNearbyDrivers(context.Background(), point(29, 41), 500, 0, domain.RiderPreferences{})
+	cache.FindNearbyDrivers(context.Background(), point(29, 41), 500, 0, domain.RiderPreferences{})
+	assert.Equal(t, 1, upstream.calls)
+
+	now = now.Add(time.Second)
+	cache.FindNearbyDrivers(context.Background(), point(29, 41), 500, 0, domain.RiderPreferences{})
+	assert.Equal(t, 2, upstream.calls)
+}
+
+// TestFindNearbyDrivers_ErrorsNotCached tests failing upstream searches
+// Expected: Should return the error and search upstream again on the next call
+func TestFindNearbyDrivers_ErrorsNotCached(t *testing.T) {
+	upstream := &countingUpstream{err: errors.New("upstream down")}
+	cache := New(upstream, Options{TTL: time.Minute})
+
+	_, err := cache.FindNearbyDrivers(context.Background(), point(29, 41), 500, 0, domain.RiderPreferences{})
+	assert.Error(t, err)
+	_, err = cache.FindNearbyDrivers(context.Background(), point(29, 41), 500, 0, domain.RiderPreferences{})
+	assert.Error(t, err)
+	assert.Equal(t, 2, upstream.calls)
+}
+
+func moved(id string, lon, lat float64, status string) domain.DriverEvent {
+	location := point(lon, lat)
+	return domain.DriverEvent{Type: domain.DriverLocationUpdated, DriverID: id, Location: &location, Status: status}
+}
+
+// TestHandleDriverEvent_UpdatesHoldingEntries tests driver events about drivers the cached searches hold
+// Expected: Should move the driver within the entry without searching upstream, and take out drivers that went busy or were deleted
+func TestHandleDriverEvent_UpdatesHoldingEntries(t *testing.T) {
+	upstream := &countingUpstream{drivers: []domain.DriverDistancePair{
+		driverAt("moving", 29.0005, 41.0005),
+		driverAt("busy", 29.0010, 41.0005),
+		driverAt("deleted", 29.0015, 41.0005),
+	}}
+	cache := New(upstream, Options{TTL: time.Minute, CellDegrees: 0.001})
+	rider := point(29.0005, 41.0005)
+	_, err := cache.FindNearbyDrivers(context.Background(), rider, 500, 0, domain.RiderPreferences{})
+	require.NoError(t, err)
+
+	cache.HandleDriverEvent(context.Background(), moved("moving", 29.0025, 41.0005, "available"))
+	cache.HandleDriverEvent(context.Background(), moved("busy", 29.0010, 41.0005, "busy"))
+	cache.HandleDriverEvent(context.Background(), domain.DriverEvent{Type: domain.DriverDeleted, DriverID: "deleted"})
+
+	drivers, err := cache.FindNearbyDrivers(context.Background(), rider, 500, 0, domain.RiderPreferences{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, upstream.calls)
+	require.Len(t, drivers, 1)
+	assert.Equal(t, "moving", drivers[0].Driver.ID)
+	assert.InDelta(t, 168, drivers[0].Distance, 5)
+
+	// a driver moving out of the searched area leaves it too
+	cache.HandleDriverEvent(context.Background(), moved("moving", 29.1, 41.0005, "available"))
+	drivers, err = cache.FindNearbyDrivers(context.Background(), rider, 500, 0, domain.RiderPreferences{})
+	require.NoError(t, err)
+	assert.Empty(t, drivers)
+	assert.Equal(t, 1, upstream.calls)
+}
+
+// TestHandleDriverEvent_DropsAffectedEntries tests driver events the cached searches cannot apply in place
+// Expected: Should drop the entries whose area a new driver shows up in and the full entries a driver leaves, and keep every other entry
+func TestHandleDriverEvent_DropsAffectedEntries(t *testing.T) {
+	upstream := &countingUpstream{drivers: []domain.DriverDistancePair{driverAt("first", 29.0005, 41.0005)}}
+	cache := New(upstream, Options{TTL: time.Minute, CellDegrees: 0.001})
+	here, elsewhere := point(29.0005, 41.0005), point(29.5005, 41.0005)
+	search := func(location domain.Location, limit int) {
+		_, err := cache.FindNearbyDrivers(context.Background(), location, 500, limit, domain.RiderPreferences{})
+		require.NoError(t, err)
+	}
+	search(here, 0)
+	search(elsewhere, 0)
+	search(here, 1)
+	require.Equal(t, 3, upstream.calls)
+
+	// a driver the searches here do not hold shows up next to them
+	cache.HandleDriverEvent(context.Background(), moved("new", 29.0006, 41.0006, "available"))
+	search(elsewhere, 0)
+	assert.Equal(t, 3, upstream.calls)
+	search(here, 0)
+	search(here, 1)
+	assert.Equal(t, 5, upstream.calls)
+
+	// the search limited to one driver is full, the next nearest driver may belong in it
+	cache.HandleDriverEvent(context.Background(), moved("first", 29.0008, 41.0005, "available"))
+	search(here, 0)
+	assert.Equal(t, 5, upstream.calls)
+	search(here, 1)
+	assert.Equal(t, 6, upstream.calls)
+}
@@ -0,0 +1,59 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"the-matching-service/internal/domain"
+	"the-matching-service/internal/ports/secondary"
+)
+
+// QueuePayload is the body posted when the wait of a rider ended, Type is
+// match.queue_matched or match.queue_expired
+type QueuePayload struct {
+	Type  string            `json:"type"`
+	Entry domain.QueueEntry `json:"entry"`
+}
+
+// QueueNotifier posts finished queue entries to a URL, e.g. the push notification
+// service of the rider app. Delivery is best effort: a failed post is not retried.
+type QueueNotifier struct {
+	url    string
+	client *http.Client
+}
+
+var _ secondary.QueueNotifier = (*QueueNotifier)(nil)
+
+func NewQueueNotifier(url string, timeout time.Duration) *QueueNotifier {
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return &QueueNotifier{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+func (n *QueueNotifier) Notify(ctx context.Context, entry domain.QueueEntry) error {
+	body, err := json.Marshal(QueuePayload{Type: "match.queue_" + entry.Status, Entry: entry})
+	if err != nil {
+		return fmt.Errorf("failed to encode queue notification: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build queue notification: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post queue notification: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("queue notification answered with status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"the-matching-service/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestQueueNotifier_Notify tests posting a finished queue entry
+// Expected: Should post the entry as JSON with the event type of its status
+func TestQueueNotifier_Notify(t *testing.T) {
+	var payload map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	entry := domain.QueueEntry{ID: "q1", RiderID: "rider-1", Status: domain.QueueExpired, EnqueuedAt: time.Now(), ExpiresAt: time.Now()}
+	require.NoError(t, NewQueueNotifier(server.URL, time.Second).Notify(context.Background(), entry))
+
+	assert.Equal(t, "match.queue_expired", payload["type"])
+	body, ok := payload["entry"].(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, "q1", body["id"])
+	assert.Equal(t, "rider-1", body["rider_id"])
+}
+
+// TestQueueNotifier_Notify_Error tests a webhook answering with an error
+// Expected: Should return an error with the status code
+func TestQueueNotifier_Notify_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	err := NewQueueNotifier(server.URL, time.Second).Notify(context.Background(), domain.QueueEntry{ID: "q1", Status: domain.QueueMatched})
+	assert.ErrorContains(t, err, "502")
+}
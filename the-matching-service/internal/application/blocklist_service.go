@@ -0,0 +1,63 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"the-matching-service/internal/domain"
+	"the-matching-service/internal/ports/secondary"
+)
+
+var ErrInvalidBlockedPair = errors.New("rider ID and driver ID are required")
+
+// BlocklistService manages the rider and driver pairs the matching service never matches
+type BlocklistService struct {
+	blocklist secondary.Blocklist
+	now       func() time.Time
+}
+
+func NewBlocklistService(blocklist secondary.Blocklist) *BlocklistService {
+	return &BlocklistService{blocklist: blocklist, now: time.Now}
+}
+
+// BlockedDrivers returns the pairs of the rider, the most recently blocked first
+func (s *BlocklistService) BlockedDrivers(ctx context.Context, riderID string) ([]domain.BlockedPair, error) {
+	if strings.TrimSpace(riderID) == "" {
+		return nil, ErrInvalidBlockedPair
+	}
+
+	pairs, err := s.blocklist.BlockedDrivers(ctx, riderID)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].CreatedAt.After(pairs[j].CreatedAt) })
+	return pairs, nil
+}
+
+// Block stores the pair, blocking a blocked pair again replaces its reason
+func (s *BlocklistService) Block(ctx context.Context, riderID, driverID, reason string) (domain.BlockedPair, error) {
+	if strings.TrimSpace(riderID) == "" || strings.TrimSpace(driverID) == "" {
+		return domain.BlockedPair{}, ErrInvalidBlockedPair
+	}
+
+	pair := domain.BlockedPair{
+		RiderID:   riderID,
+		DriverID:  driverID,
+		Reason:    reason,
+		CreatedAt: s.now().UTC(),
+	}
+	if err := s.blocklist.Block(ctx, pair); err != nil {
+		return domain.BlockedPair{}, err
+	}
+	return pair, nil
+}
+
+func (s *BlocklistService) Unblock(ctx context.Context, riderID, driverID string) error {
+	if strings.TrimSpace(riderID) == "" || strings.TrimSpace(driverID) == "" {
+		return ErrInvalidBlockedPair
+	}
+	return s.blocklist.Unblock(ctx, riderID, driverID)
+}
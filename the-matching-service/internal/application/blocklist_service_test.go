@@ -0,0 +1,151 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"the-matching-service/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryBlocklist struct {
+	pairs map[string]map[string]domain.BlockedPair
+	err   error
+}
+
+func newMemoryBlocklist() *memoryBlocklist {
+	return &memoryBlocklist{pairs: map[string]map[string]domain.BlockedPair{}}
+}
+
+func (b *memoryBlocklist) BlockedDrivers(ctx context.Context, riderID string) ([]domain.BlockedPair, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	var pairs []domain.BlockedPair
+	for _, pair := range b.pairs[riderID] {
+		pairs = append(pairs, pair)
+	}
+	return pairs, nil
+}
+
+func (b *memoryBlocklist) Block(ctx context.Context, pair domain.BlockedPair) error {
+	if b.pairs[pair.RiderID] == nil {
+		b.pairs[pair.RiderID] = map[string]domain.BlockedPair{}
+	}
+	b.pairs[pair.RiderID][pair.DriverID] = pair
+	return nil
+}
+
+func (b *memoryBlocklist) Unblock(ctx context.Context, riderID, driverID string) error {
+	delete(b.pairs[riderID], driverID)
+	return nil
+}
+
+// TestBlocklistService_BlockAndUnblock tests managing the blocked drivers of a rider
+// Expected: Should list the blocked drivers newest first and forget unblocked ones
+func TestBlocklistService_BlockAndUnblock(t *testing.T) {
+	service := NewBlocklistService(newMemoryBlocklist())
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	_, err := service.Block(context.Background(), "rider-1", "driver-1", "complaint")
+	require.NoError(t, err)
+	now = now.Add(time.Hour)
+	pair, err := service.Block(context.Background(), "rider-1", "driver-2", "")
+	require.NoError(t, err)
+	assert.Equal(t, now, pair.CreatedAt)
+
+	pairs, err := service.BlockedDrivers(context.Background(), "rider-1")
+	require.NoError(t, err)
+	require.Len(t, pairs, 2)
+	assert.Equal(t, "driver-2", pairs[0].DriverID)
+	assert.Equal(t, "complaint", pairs[1].Reason)
+
+	require.NoError(t, service.Unblock(context.Background(), "rider-1", "driver-2"))
+	pairs, err = service.BlockedDrivers(context.Background(), "rider-1")
+	require.NoError(t, err)
+	assert.Len(t, pairs, 1)
+}
+
+// TestBlocklistService_InvalidPair tests blocking without rider or driver ID
+// Expected: Should return ErrInvalidBlockedPair
+func TestBlocklistService_InvalidPair(t *testing.T) {
+	service := NewBlocklistService(newMemoryBlocklist())
+
+	_, err := service.Block(context.Background(), "rider-1", " ", "")
+	assert.ErrorIs(t, err, ErrInvalidBlockedPair)
+	assert.ErrorIs(t, service.Unblock(context.Background(), "", "driver-1"), ErrInvalidBlockedPair)
+}
+
+// TestMatchingService_MatchRiderToDriver_skipsBlockedDrivers tests matching a rider with a blocked nearest driver
+// Expected: Should match the nearest driver that is not blocked for the rider
+func TestMatchingService_MatchRiderToDriver_skipsBlockedDrivers(t *testing.T) {
+	mockSvc := &mockDriverLocationService{
+		FindNearbyDriversFunc: func(ctx context.Context, location domain.Location, radius float64) ([]domain.DriverDistancePair, error) {
+			return []domain.DriverDistancePair{
+				{Driver: domain.Driver{ID: "driver-1"}, Distance: 100},
+				{Driver: domain.Driver{ID: "driver-2"}, Distance: 200},
+			}, nil
+		},
+	}
+	blocklist := newMemoryBlocklist()
+	require.NoError(t, blocklist.Block(context.Background(), domain.BlockedPair{RiderID: "rider-1", DriverID: "driver-1"}))
+
+	service := NewMatchingService(mockSvc)
+	service.SetBlocklist(blocklist)
+	rider := domain.Rider{ID: "rider-1", Location: domain.Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}}}
+	result, err := service.MatchRiderToDriver(context.Background(), rider, 500, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "driver-2", result.DriverID)
+
+	other := domain.Rider{ID: "rider-2", Location: rider.Location}
+	result, err = service.MatchRiderToDriver(context.Background(), other, 500, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "driver-1", result.DriverID)
+}
+
+// TestMatchingService_MatchRiderToDriver_onlyBlockedDrivers tests a rider whose only nearby drivers are blocked
+// Expected: Should expand the radius and return no drivers found when nothing else is found
+func TestMatchingService_MatchRiderToDriver_onlyBlockedDrivers(t *testing.T) {
+	calls := 0
+	mockSvc := &mockDriverLocationService{
+		FindNearbyDriversFunc: func(ctx context.Context, location domain.Location, radius float64) ([]domain.DriverDistancePair, error) {
+			calls++
+			return []domain.DriverDistancePair{{Driver: domain.Driver{ID: "driver-1"}, Distance: 100}}, nil
+		},
+	}
+	blocklist := newMemoryBlocklist()
+	require.NoError(t, blocklist.Block(context.Background(), domain.BlockedPair{RiderID: "rider-1", DriverID: "driver-1"}))
+
+	service := NewMatchingService(mockSvc)
+	service.SetBlocklist(blocklist)
+	service.SetRadiusExpansion(RadiusExpansion{Factor: 2, MaxRadius: 1000})
+	rider := domain.Rider{ID: "rider-1", Location: domain.Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}}}
+	_, err := service.MatchRiderToDriver(context.Background(), rider, 500, 0)
+
+	assert.ErrorIs(t, err, domain.ErrNoDriversFound)
+	assert.Equal(t, 2, calls)
+}
+
+// TestMatchingService_MatchRiderToDriver_blocklistError tests a blocklist that cannot be read
+// Expected: Should fail the match instead of risking a blocked pair
+func TestMatchingService_MatchRiderToDriver_blocklistError(t *testing.T) {
+	mockSvc := &mockDriverLocationService{
+		FindNearbyDriversFunc: func(ctx context.Context, location domain.Location, radius float64) ([]domain.DriverDistancePair, error) {
+			return []domain.DriverDistancePair{{Driver: domain.Driver{ID: "driver-1"}, Distance: 100}}, nil
+		},
+	}
+	blocklist := newMemoryBlocklist()
+	blocklist.err = errors.New("redis unavailable")
+
+	service := NewMatchingService(mockSvc)
+	service.SetBlocklist(blocklist)
+	rider := domain.Rider{ID: "rider-1", Location: domain.Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}}}
+	_, err := service.MatchRiderToDriver(context.Background(), rider, 500, 0)
+
+	assert.ErrorContains(t, err, "redis unavailable")
+}
@@ -0,0 +1,181 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"the-matching-service/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The tests in this file run matches in parallel, they are meant to run with
+// -race (make race) to catch unsynchronized state as well as wrong results
+
+// runParallel calls fn n times at once and waits for all calls to return
+func runParallel(n int, fn func(i int)) {
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			fn(i)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+}
+
+// TestMatchingService_parallelMatches_spreadOverDrivers tests riders matched at once by the strategies spreading rides
+// Expected: Should match every driver exactly once instead of handing the driver that waited longest to several riders
+func TestMatchingService_parallelMatches_spreadOverDrivers(t *testing.T) {
+	const riders = 20
+	drivers := make([]domain.DriverDistancePair, riders)
+	for i := range drivers {
+		drivers[i] = domain.DriverDistancePair{Driver: domain.Driver{ID: fmt.Sprintf("driver-%d", i)}, Distance: float64(100 + i)}
+	}
+
+	strategies := map[string]func(history *MatchHistory) MatchStrategy{
+		StrategyLeastRecentlyMatched: func(history *MatchHistory) MatchStrategy {
+			return NewLeastRecentlyMatchedStrategy(history)
+		},
+		StrategyWeighted: func(history *MatchHistory) MatchStrategy {
+			return NewWeightedStrategy(ScoreWeights{Idle: 1}, history)
+		},
+	}
+	for name, newStrategy := range strategies {
+		t.Run(name, func(t *testing.T) {
+			service := NewMatchingService(&mockDriverLocationService{
+				FindNearbyDriversFunc: func(ctx context.Context, location domain.Location, radius float64) ([]domain.DriverDistancePair, error) {
+					return drivers, nil
+				},
+			})
+			history := NewMatchHistory(time.Hour)
+			// a slow clock widens the window between reading and recording the history
+			history.now = func() time.Time {
+				time.Sleep(time.Millisecond)
+				return time.Now()
+			}
+			service.SetStrategyRollout(StrategyRollout{Control: newStrategy(history)})
+
+			matched := make([]string, riders)
+			runParallel(riders, func(i int) {
+				result, err := service.MatchRiderToDriver(context.Background(), queueRider(fmt.Sprintf("rider-%d", i), 28.9, 41.0), 500, 0)
+				if assert.NoError(t, err) {
+					matched[i] = result.DriverID
+				}
+			})
+
+			counts := make(map[string]int)
+			for _, driverID := range matched {
+				counts[driverID]++
+			}
+			assert.Len(t, counts, riders)
+			for driverID, count := range counts {
+				assert.Equal(t, 1, count, "driver %s matched more than once", driverID)
+			}
+		})
+	}
+}
+
+// TestMatchingService_parallelAnswers tests a driver answering the same proposal several times at once
+// Expected: Should let exactly one answer through and refuse the others
+func TestMatchingService_parallelAnswers(t *testing.T) {
+	service, store := newWorkflowService(MatchWorkflow{ProposalTimeout: 30 * time.Second, MaxRematches: 3}, "driver-1", "driver-2")
+	result, err := service.MatchRiderToDriver(context.Background(), queueRider("rider-1", 28.9, 41.0), 500, 0)
+	require.NoError(t, err)
+	require.Equal(t, "driver-1", result.DriverID)
+
+	const answers = 20
+	errs := make([]error, answers)
+	runParallel(answers, func(i int) {
+		if i%2 == 0 {
+			_, errs[i] = service.AcceptMatch(context.Background(), "driver-1", result.ID)
+		} else {
+			_, errs[i] = service.RejectMatch(context.Background(), "driver-1", result.ID)
+		}
+	})
+
+	succeeded := 0
+	for _, err := range errs {
+		if err == nil {
+			succeeded++
+			continue
+		}
+		// a rejection re-matches the rider, driver-1 does not see the match anymore
+		assert.True(t, errors.Is(err, domain.ErrMatchNotProposed) || errors.Is(err, domain.ErrMatchNotFound), "unexpected error %v", err)
+	}
+	assert.Equal(t, 1, succeeded)
+
+	stored, err := store.Get(context.Background(), result.ID)
+	require.NoError(t, err)
+	if stored.Status == domain.MatchAccepted {
+		assert.Equal(t, "driver-1", stored.DriverID)
+	} else {
+		assert.Equal(t, domain.MatchProposed, stored.Status)
+		assert.Equal(t, "driver-2", stored.DriverID)
+		assert.Equal(t, []string{"driver-1"}, stored.DeclinedDrivers)
+	}
+}
+
+// TestMatchingService_MatchOnce_parallel tests retries with the same Idempotency-Key racing the first request
+// Expected: Should search once, refuse the retries while it matches and replay its match afterwards
+func TestMatchingService_MatchOnce_parallel(t *testing.T) {
+	const requests = 10
+	var mu sync.Mutex
+	searches := 0
+	release := make(chan struct{})
+	service := NewMatchingService(&mockDriverLocationService{
+		FindNearbyDriversFunc: func(ctx context.Context, location domain.Location, radius float64) ([]domain.DriverDistancePair, error) {
+			mu.Lock()
+			searches++
+			mu.Unlock()
+			<-release
+			return []domain.DriverDistancePair{{Driver: domain.Driver{ID: "driver-1"}, Distance: 100}}, nil
+		},
+	})
+	service.SetIdempotency(Idempotency{Store: newMemoryIdempotencyStore()})
+	rider := queueRider("rider-1", 28.9, 41.0)
+
+	type outcome struct {
+		result   *domain.MatchResult
+		replayed bool
+		err      error
+	}
+	outcomes := make(chan outcome, requests)
+	for i := 0; i < requests; i++ {
+		go func() {
+			result, replayed, err := service.MatchOnce(context.Background(), "key-1", rider, 500, 0)
+			outcomes <- outcome{result, replayed, err}
+		}()
+	}
+
+	// the request that claimed the key waits for the search, the others are refused right away
+	for i := 0; i < requests-1; i++ {
+		refused := <-outcomes
+		assert.ErrorIs(t, refused.err, domain.ErrIdempotencyKeyInFlight)
+	}
+	close(release)
+	first := <-outcomes
+	require.NoError(t, first.err)
+	assert.False(t, first.replayed)
+
+	replays := make([]outcome, requests)
+	runParallel(requests, func(i int) {
+		result, replayed, err := service.MatchOnce(context.Background(), "key-1", rider, 500, 0)
+		replays[i] = outcome{result, replayed, err}
+	})
+	for _, replay := range replays {
+		require.NoError(t, replay.err)
+		assert.True(t, replay.replayed)
+		assert.Equal(t, first.result.ID, replay.result.ID)
+	}
+	assert.Equal(t, 1, searches)
+}
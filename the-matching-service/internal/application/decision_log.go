@@ -0,0 +1,41 @@
+package application
+
+import (
+	"context"
+	"time"
+
+	"the-matching-service/internal/domain"
+	"the-matching-service/internal/ports/secondary"
+)
+
+// DecisionLog appends every pick of a match strategy, with the candidates it
+// was given, to Sink so new strategies can be replayed against real matches.
+// The rider ID and location are written as Privacy allows.
+type DecisionLog struct {
+	Sink    secondary.DecisionLog
+	Privacy domain.Privacy
+}
+
+// SetDecisionLog turns the decision log on
+func (s *MatchingService) SetDecisionLog(log DecisionLog) {
+	s.decisions = log
+}
+
+// recordDecision appends the decision, a decision that cannot be appended is
+// logged and the match goes on
+func (s *MatchingService) recordDecision(ctx context.Context, event domain.DecisionEvent) {
+	if s.decisions.Sink == nil {
+		return
+	}
+	privacy := s.decisions.Privacy
+	event.Rider.ID = privacy.RiderID(event.Rider.ID)
+	event.Rider.Location = privacy.Location(event.Rider.Location)
+	if event.Rider.Destination != nil {
+		destination := privacy.Location(*event.Rider.Destination)
+		event.Rider.Destination = &destination
+	}
+	event.DecidedAt = event.DecidedAt.UTC().Truncate(time.Millisecond)
+	if err := s.decisions.Sink.Append(ctx, event); err != nil {
+		s.logger.Warn(ctx, "failed to record match decision", "match_id", event.MatchID, "error", err)
+	}
+}
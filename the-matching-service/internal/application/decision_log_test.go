@@ -0,0 +1,59 @@
+package application
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"the-matching-service/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryDecisionLog struct {
+	mu     sync.Mutex
+	events []domain.DecisionEvent
+}
+
+func (l *memoryDecisionLog) Append(ctx context.Context, event domain.DecisionEvent) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, event)
+	return nil
+}
+
+// TestMatchingService_MatchRiderToDriver_recordsDecision tests the decision log of a match
+// Expected: Should append the candidates and the pick of the strategy with the rider as the privacy policy allows
+func TestMatchingService_MatchRiderToDriver_recordsDecision(t *testing.T) {
+	drivers := []domain.DriverDistancePair{
+		{Driver: domain.Driver{ID: "driver-1"}, Distance: 300},
+		{Driver: domain.Driver{ID: "driver-2"}, Distance: 120.456},
+	}
+	service := NewMatchingService(&mockDriverLocationService{
+		FindNearbyDriversFunc: func(ctx context.Context, location domain.Location, radius float64) ([]domain.DriverDistancePair, error) {
+			return drivers, nil
+		},
+	})
+	decisions := &memoryDecisionLog{}
+	service.SetDecisionLog(DecisionLog{Sink: decisions, Privacy: domain.Privacy{CoordinateDecimals: 2, HashRiderIDs: true}})
+
+	rider := domain.Rider{ID: "rider-1", Location: domain.Location{Type: "Point", Coordinates: [2]float64{28.9784, 41.0082}}}
+	result, err := service.MatchRiderToDriver(context.Background(), rider, 500, 5)
+	require.NoError(t, err)
+
+	require.Len(t, decisions.events, 1)
+	event := decisions.events[0]
+	assert.Equal(t, result.ID, event.MatchID)
+	assert.Equal(t, domain.HashEndUser("", "rider-1"), event.Rider.ID)
+	assert.Equal(t, [2]float64{28.97, 41}, event.Rider.Location.Coordinates)
+	assert.Equal(t, 500.0, event.Radius)
+	assert.Equal(t, 5, event.Limit)
+	assert.ElementsMatch(t, drivers, event.Candidates)
+	assert.Equal(t, StrategyNearest, event.Strategy)
+	assert.Equal(t, "driver-2", event.DriverID)
+	assert.Equal(t, 120.46, event.Distance)
+	assert.False(t, event.Rematch)
+	assert.WithinDuration(t, time.Now(), event.DecidedAt, time.Minute)
+}
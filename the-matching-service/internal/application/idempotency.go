@@ -0,0 +1,97 @@
+package application
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"the-matching-service/internal/domain"
+	"the-matching-service/internal/ports/secondary"
+)
+
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+// Idempotency lets riders retry a match request with the same Idempotency-Key
+// and get the match of the first request instead of a new search. Keys are kept
+// per rider for TTL after the match.
+type Idempotency struct {
+	Store secondary.IdempotencyStore
+	TTL   time.Duration
+}
+
+// SetIdempotency turns idempotency keys on, a zero TTL keeps DefaultIdempotencyTTL
+func (s *MatchingService) SetIdempotency(idempotency Idempotency) {
+	if idempotency.TTL <= 0 {
+		idempotency.TTL = DefaultIdempotencyTTL
+	}
+	s.idempotency = idempotency
+}
+
+// MatchOnce matches like MatchRiderToDriver, unless the rider sent the key with
+// the same request before: the match of that request is returned with replayed
+// set. A key sent with another request is refused, and so is a key whose first
+// request is still matching. Failed matches release the key, retrying them
+// searches again.
+func (s *MatchingService) MatchOnce(ctx context.Context, key string, rider domain.Rider, radius float64, limit int) (result *domain.MatchResult, replayed bool, err error) {
+	if key == "" || s.idempotency.Store == nil {
+		result, err = s.MatchRiderToDriver(ctx, rider, radius, limit)
+		return result, false, err
+	}
+
+	storeKey := rider.ID + ":" + key
+	record := domain.IdempotencyRecord{Fingerprint: fingerprint(rider, radius, limit)}
+	existing, claimed, err := s.idempotency.Store.Claim(ctx, storeKey, record, s.idempotency.TTL)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+	if !claimed {
+		switch {
+		case existing.Fingerprint != record.Fingerprint:
+			return nil, false, domain.ErrIdempotencyKeyReused
+		case existing.Result == nil:
+			return nil, false, domain.ErrIdempotencyKeyInFlight
+		}
+		replay := *existing.Result
+		replay.Candidates = existing.Candidates
+		return &replay, true, nil
+	}
+
+	// the key has to be settled even when the client gave up waiting
+	settleCtx := context.WithoutCancel(ctx)
+	result, err = s.MatchRiderToDriver(ctx, rider, radius, limit)
+	if err != nil {
+		if releaseErr := s.idempotency.Store.Release(settleCtx, storeKey); releaseErr != nil {
+			s.logger.Warn(settleCtx, "failed to release idempotency key", "rider_id", rider.ID, "error", releaseErr)
+		}
+		return nil, false, err
+	}
+
+	record.Result = result
+	record.Candidates = result.Candidates
+	if err := s.idempotency.Store.Complete(settleCtx, storeKey, record, s.idempotency.TTL); err != nil {
+		// a claim left pending would refuse the retries until it expires
+		s.logger.Warn(settleCtx, "failed to store the match of idempotency key", "rider_id", rider.ID, "match_id", result.ID, "error", err)
+		if err := s.idempotency.Store.Release(settleCtx, storeKey); err != nil {
+			s.logger.Warn(settleCtx, "failed to release idempotency key", "rider_id", rider.ID, "error", err)
+		}
+	}
+	return result, false, nil
+}
+
+// fingerprint identifies the search of a match request, the rider ID is part of
+// the store key already
+func fingerprint(rider domain.Rider, radius float64, limit int) string {
+	search, _ := json.Marshal(struct {
+		Location    domain.Location
+		Preferences domain.RiderPreferences
+		Pool        bool
+		Destination *domain.Location
+		Radius      float64
+		Limit       int
+	}{rider.Location, rider.Preferences, rider.Pool, rider.Destination, radius, limit})
+	sum := sha256.Sum256(search)
+	return hex.EncodeToString(sum[:])
+}
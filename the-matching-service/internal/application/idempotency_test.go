@@ -0,0 +1,163 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"the-matching-service/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryIdempotencyStore keeps the keys without expiry, JSON encoded like the
+// Redis store keeps them
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string][]byte
+}
+
+func newMemoryIdempotencyStore() *memoryIdempotencyStore {
+	return &memoryIdempotencyStore{records: make(map[string][]byte)}
+}
+
+func (s *memoryIdempotencyStore) Claim(ctx context.Context, key string, record domain.IdempotencyRecord, ttl time.Duration) (*domain.IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if stored, ok := s.records[key]; ok {
+		var existing domain.IdempotencyRecord
+		if err := json.Unmarshal(stored, &existing); err != nil {
+			return nil, false, err
+		}
+		return &existing, false, nil
+	}
+	return nil, true, s.set(key, record)
+}
+
+func (s *memoryIdempotencyStore) Complete(ctx context.Context, key string, record domain.IdempotencyRecord, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.set(key, record)
+}
+
+func (s *memoryIdempotencyStore) set(key string, record domain.IdempotencyRecord) error {
+	value, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	s.records[key] = value
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+	return nil
+}
+
+// newIdempotentTestService returns a service whose driver location service
+// counts the searches and finds driver-1 unless failing is set
+func newIdempotentTestService(store *memoryIdempotencyStore) (*MatchingService, *int, *bool) {
+	searches, failing := 0, false
+	service := NewMatchingService(&mockDriverLocationService{
+		FindNearbyDriversFunc: func(ctx context.Context, location domain.Location, radius float64) ([]domain.DriverDistancePair, error) {
+			searches++
+			if failing {
+				return nil, errors.New("driver location service unavailable")
+			}
+			return []domain.DriverDistancePair{{Driver: domain.Driver{ID: "driver-1"}, Distance: 100}}, nil
+		},
+	})
+	service.SetIdempotency(Idempotency{Store: store})
+	return service, &searches, &failing
+}
+
+// TestMatchingService_MatchOnce_replay tests repeating a match request with the same key
+// Expected: Should search once and return the first match with its candidates as replayed
+func TestMatchingService_MatchOnce_replay(t *testing.T) {
+	service, searches, _ := newIdempotentTestService(newMemoryIdempotencyStore())
+	rider := queueRider("rider-1", 28.9, 41.0)
+
+	first, replayed, err := service.MatchOnce(context.Background(), "key-1", rider, 500, 0)
+	require.NoError(t, err)
+	assert.False(t, replayed)
+
+	again, replayed, err := service.MatchOnce(context.Background(), "key-1", rider, 500, 0)
+	require.NoError(t, err)
+	assert.True(t, replayed)
+	assert.Equal(t, first.ID, again.ID)
+	assert.NotEmpty(t, again.Candidates)
+	assert.Equal(t, first.Candidates, again.Candidates)
+	assert.Equal(t, 1, *searches)
+
+	other, replayed, err := service.MatchOnce(context.Background(), "key-1", queueRider("rider-2", 28.9, 41.0), 500, 0)
+	require.NoError(t, err)
+	assert.False(t, replayed)
+	assert.NotEqual(t, first.ID, other.ID)
+}
+
+// TestMatchingService_MatchOnce_reused tests sending a key again with another request
+// Expected: Should refuse the request with ErrIdempotencyKeyReused
+func TestMatchingService_MatchOnce_reused(t *testing.T) {
+	service, _, _ := newIdempotentTestService(newMemoryIdempotencyStore())
+
+	_, _, err := service.MatchOnce(context.Background(), "key-1", queueRider("rider-1", 28.9, 41.0), 500, 0)
+	require.NoError(t, err)
+
+	_, _, err = service.MatchOnce(context.Background(), "key-1", queueRider("rider-1", 28.9, 41.0), 1000, 0)
+	assert.ErrorIs(t, err, domain.ErrIdempotencyKeyReused)
+}
+
+// TestMatchingService_MatchOnce_inFlight tests sending a key whose first request is still matching
+// Expected: Should refuse the request with ErrIdempotencyKeyInFlight
+func TestMatchingService_MatchOnce_inFlight(t *testing.T) {
+	store := newMemoryIdempotencyStore()
+	service, searches, _ := newIdempotentTestService(store)
+	rider := queueRider("rider-1", 28.9, 41.0)
+	require.NoError(t, store.set("rider-1:key-1", domain.IdempotencyRecord{Fingerprint: fingerprint(rider, 500, 0)}))
+
+	_, _, err := service.MatchOnce(context.Background(), "key-1", rider, 500, 0)
+	assert.ErrorIs(t, err, domain.ErrIdempotencyKeyInFlight)
+	assert.Zero(t, *searches)
+}
+
+// TestMatchingService_MatchOnce_failed tests retrying a failed match with the same key
+// Expected: Should release the key so the retry searches again
+func TestMatchingService_MatchOnce_failed(t *testing.T) {
+	store := newMemoryIdempotencyStore()
+	service, searches, failing := newIdempotentTestService(store)
+	rider := queueRider("rider-1", 28.9, 41.0)
+
+	*failing = true
+	_, _, err := service.MatchOnce(context.Background(), "key-1", rider, 500, 0)
+	require.Error(t, err)
+	assert.Empty(t, store.records)
+
+	*failing = false
+	result, replayed, err := service.MatchOnce(context.Background(), "key-1", rider, 500, 0)
+	require.NoError(t, err)
+	assert.False(t, replayed)
+	assert.Equal(t, "driver-1", result.DriverID)
+	assert.Equal(t, 2, *searches)
+}
+
+// TestMatchingService_MatchOnce_noKey tests match requests without a key
+// Expected: Should search every time and store nothing
+func TestMatchingService_MatchOnce_noKey(t *testing.T) {
+	store := newMemoryIdempotencyStore()
+	service, searches, _ := newIdempotentTestService(store)
+	rider := queueRider("rider-1", 28.9, 41.0)
+
+	for i := 0; i < 2; i++ {
+		_, replayed, err := service.MatchOnce(context.Background(), "", rider, 500, 0)
+		require.NoError(t, err)
+		assert.False(t, replayed)
+	}
+	assert.Equal(t, 2, *searches)
+	assert.Empty(t, store.records)
+}
@@ -0,0 +1,72 @@
+package application
+
+import (
+	"context"
+
+	"the-matching-service/internal/domain"
+	"the-matching-service/internal/ports/secondary"
+)
+
+// MatchQueryService looks up the stored matches of a rider, riders only ever see
+// their own matches
+type MatchQueryService struct {
+	store    secondary.MatchStore
+	matching *MatchingService
+}
+
+func NewMatchQueryService(store secondary.MatchStore) *MatchQueryService {
+	return &MatchQueryService{store: store}
+}
+
+// SetMatchingService re-matches the proposals that timed out when they are read,
+// drivers answering matches need it
+func (s *MatchQueryService) SetMatchingService(matching *MatchingService) {
+	s.matching = matching
+}
+
+// Get returns ErrMatchNotFound for matches of other riders too, so match IDs of
+// other riders cannot be probed
+func (s *MatchQueryService) Get(ctx context.Context, riderID, id string) (*domain.MatchResult, error) {
+	result, err := s.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if result == nil || result.RiderID != riderID {
+		return nil, domain.ErrMatchNotFound
+	}
+	if s.matching != nil {
+		return s.matching.ExpireOverdue(ctx, result)
+	}
+	return result, nil
+}
+
+// List returns a page of the rider's matches, limit is clamped to MaxMatchPageSize
+func (s *MatchQueryService) List(ctx context.Context, riderID string, limit, offset int) (*domain.MatchPage, error) {
+	if limit <= 0 {
+		limit = domain.DefaultMatchPageSize
+	}
+	limit = min(limit, domain.MaxMatchPageSize)
+	offset = max(offset, 0)
+
+	matches, total, err := s.store.ListByRider(ctx, riderID, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	if s.matching != nil {
+		for i := range matches {
+			refreshed, err := s.matching.ExpireOverdue(ctx, &matches[i])
+			if err != nil {
+				return nil, err
+			}
+			if refreshed != nil {
+				matches[i] = *refreshed
+			}
+		}
+	}
+	return &domain.MatchPage{
+		Matches: matches,
+		Total:   total,
+		Limit:   limit,
+		Offset:  offset,
+	}, nil
+}
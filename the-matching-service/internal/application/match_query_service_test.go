@@ -0,0 +1,164 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"the-matching-service/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryMatchStore is safe for concurrent use, Update changes a match atomically
+// like the Redis store does
+type memoryMatchStore struct {
+	mu      sync.Mutex
+	matches map[string]domain.MatchResult
+	err     error
+}
+
+func newMemoryMatchStore() *memoryMatchStore {
+	return &memoryMatchStore{matches: map[string]domain.MatchResult{}}
+}
+
+func (s *memoryMatchStore) Save(ctx context.Context, result domain.MatchResult) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.matches[result.ID] = result
+	return nil
+}
+
+func (s *memoryMatchStore) Get(ctx context.Context, id string) (*domain.MatchResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result, ok := s.matches[id]
+	if !ok {
+		return nil, nil
+	}
+	return &result, nil
+}
+
+func (s *memoryMatchStore) Update(ctx context.Context, id string, change func(result *domain.MatchResult) error) (*domain.MatchResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result, ok := s.matches[id]
+	if !ok {
+		return nil, domain.ErrMatchNotFound
+	}
+	result.DeclinedDrivers = slices.Clone(result.DeclinedDrivers)
+	if err := change(&result); err != nil {
+		return nil, err
+	}
+	s.matches[id] = result
+	return &result, nil
+}
+
+func (s *memoryMatchStore) ListByRider(ctx context.Context, riderID string, offset, limit int) ([]domain.MatchResult, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var results []domain.MatchResult
+	for _, result := range s.matches {
+		if result.RiderID == riderID {
+			results = append(results, result)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].MatchedAt.After(results[j].MatchedAt) })
+
+	total := int64(len(results))
+	if offset >= len(results) {
+		return []domain.MatchResult{}, total, nil
+	}
+	return results[offset:min(offset+limit, len(results))], total, nil
+}
+
+// TestMatchingService_MatchRiderToDriver_storesMatch tests persisting matches
+// Expected: Should store the returned match with its ID and match time
+func TestMatchingService_MatchRiderToDriver_storesMatch(t *testing.T) {
+	store := newMemoryMatchStore()
+	service := NewMatchingService(&mockDriverLocationService{
+		FindNearbyDriversFunc: func(ctx context.Context, location domain.Location, radius float64) ([]domain.DriverDistancePair, error) {
+			return []domain.DriverDistancePair{{Driver: domain.Driver{ID: "driver-1"}, Distance: 120}}, nil
+		},
+	})
+	service.SetMatchStore(store)
+
+	rider := domain.Rider{ID: "rider-1", Location: domain.Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}}}
+	result, err := service.MatchRiderToDriver(context.Background(), rider, 500, 0)
+	require.NoError(t, err)
+
+	require.NotEmpty(t, result.ID)
+	assert.WithinDuration(t, time.Now(), result.MatchedAt, time.Second)
+	assert.Equal(t, *result, store.matches[result.ID])
+}
+
+// TestMatchingService_MatchRiderToDriver_storeFails tests a match store that is unavailable
+// Expected: Should still return the match, the driver is matched already
+func TestMatchingService_MatchRiderToDriver_storeFails(t *testing.T) {
+	store := newMemoryMatchStore()
+	store.err = errors.New("redis unavailable")
+	service := NewMatchingService(&mockDriverLocationService{
+		FindNearbyDriversFunc: func(ctx context.Context, location domain.Location, radius float64) ([]domain.DriverDistancePair, error) {
+			return []domain.DriverDistancePair{{Driver: domain.Driver{ID: "driver-1"}, Distance: 120}}, nil
+		},
+	})
+	service.SetMatchStore(store)
+
+	rider := domain.Rider{ID: "rider-1", Location: domain.Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}}}
+	result, err := service.MatchRiderToDriver(context.Background(), rider, 500, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "driver-1", result.DriverID)
+}
+
+// TestMatchQueryService_Get tests looking a match up by ID
+// Expected: Should return the rider's own match and ErrMatchNotFound for unknown matches and matches of other riders
+func TestMatchQueryService_Get(t *testing.T) {
+	store := newMemoryMatchStore()
+	store.matches["m1"] = domain.MatchResult{ID: "m1", RiderID: "rider-1", DriverID: "driver-1"}
+	service := NewMatchQueryService(store)
+
+	result, err := service.Get(context.Background(), "rider-1", "m1")
+	require.NoError(t, err)
+	assert.Equal(t, "driver-1", result.DriverID)
+
+	_, err = service.Get(context.Background(), "rider-2", "m1")
+	assert.ErrorIs(t, err, domain.ErrMatchNotFound)
+
+	_, err = service.Get(context.Background(), "rider-1", "missing")
+	assert.ErrorIs(t, err, domain.ErrMatchNotFound)
+}
+
+// TestMatchQueryService_List tests paging through the matches of a rider
+// Expected: Should return the most recent matches first, default and clamp the page size
+func TestMatchQueryService_List(t *testing.T) {
+	store := newMemoryMatchStore()
+	start := time.Date(2025, 1, 2, 3, 0, 0, 0, time.UTC)
+	for i := 0; i < 150; i++ {
+		id := fmt.Sprintf("m%03d", i)
+		store.matches[id] = domain.MatchResult{ID: id, RiderID: "rider-1", MatchedAt: start.Add(time.Duration(i) * time.Minute)}
+	}
+	store.matches["other"] = domain.MatchResult{ID: "other", RiderID: "rider-2", MatchedAt: start}
+	service := NewMatchQueryService(store)
+
+	page, err := service.List(context.Background(), "rider-1", 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(150), page.Total)
+	assert.Equal(t, domain.DefaultMatchPageSize, page.Limit)
+	require.Len(t, page.Matches, domain.DefaultMatchPageSize)
+	assert.Equal(t, "m149", page.Matches[0].ID)
+
+	page, err = service.List(context.Background(), "rider-1", 500, 120)
+	require.NoError(t, err)
+	assert.Equal(t, domain.MaxMatchPageSize, page.Limit)
+	require.Len(t, page.Matches, 30)
+	assert.Equal(t, "m029", page.Matches[0].ID)
+}
@@ -0,0 +1,271 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"the-matching-service/internal/domain"
+	"the-matching-service/internal/ports/secondary"
+)
+
+// MatchQueue holds the riders that found no driver and asked to wait. Driver
+// events retry the oldest waiting riders around the driver, a rider not matched
+// within the wait window expires. Riders wait on the instance they matched with,
+// every instance reads all driver events.
+type MatchQueue struct {
+	matching *MatchingService
+	wait     time.Duration
+	notifier secondary.QueueNotifier
+	now      func() time.Time
+
+	mu       sync.Mutex
+	waiting  []*domain.QueueEntry // enqueue order
+	entries  map[string]*domain.QueueEntry
+	claimed  map[string]bool // entries being matched by a driver event
+	watchers map[string][]chan domain.QueueEntry
+}
+
+func NewMatchQueue(matching *MatchingService, wait time.Duration) *MatchQueue {
+	return &MatchQueue{
+		matching: matching,
+		wait:     wait,
+		now:      time.Now,
+		entries:  make(map[string]*domain.QueueEntry),
+		claimed:  make(map[string]bool),
+		watchers: make(map[string][]chan domain.QueueEntry),
+	}
+}
+
+// SetNotifier sends every matched or expired rider to the notifier too
+func (q *MatchQueue) SetNotifier(notifier secondary.QueueNotifier) {
+	q.notifier = notifier
+}
+
+// Enqueue makes the rider wait for a driver, a rider already waiting keeps
+// their place
+func (q *MatchQueue) Enqueue(rider domain.Rider, radius float64, limit int) domain.QueueEntry {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, entry := range q.waiting {
+		if entry.RiderID == rider.ID && rider.ID != "" {
+			return q.snapshot(entry)
+		}
+	}
+
+	now := q.now().UTC()
+	entry := &domain.QueueEntry{
+		ID:         newMatchID(),
+		RiderID:    rider.ID,
+		Status:     domain.QueueWaiting,
+		EnqueuedAt: now,
+		ExpiresAt:  now.Add(q.wait),
+		Rider:      rider,
+		Radius:     radius,
+		Limit:      limit,
+	}
+	q.waiting = append(q.waiting, entry)
+	q.entries[entry.ID] = entry
+	return q.snapshot(entry)
+}
+
+// Get returns the entry with its current position, entries of other riders are not found
+func (q *MatchQueue) Get(riderID, id string) (domain.QueueEntry, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entry, ok := q.entries[id]
+	if !ok || entry.RiderID != riderID {
+		return domain.QueueEntry{}, domain.ErrQueueEntryNotFound
+	}
+	return q.snapshot(entry), nil
+}
+
+// Watch returns the entry and a channel receiving it once it is matched or
+// expired, stop releases the channel of a watcher that leaves early
+func (q *MatchQueue) Watch(riderID, id string) (entry domain.QueueEntry, updates <-chan domain.QueueEntry, stop func(), err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	current, ok := q.entries[id]
+	if !ok || current.RiderID != riderID {
+		return domain.QueueEntry{}, nil, nil, domain.ErrQueueEntryNotFound
+	}
+
+	ch := make(chan domain.QueueEntry, 1)
+	if current.Status != domain.QueueWaiting {
+		ch <- q.snapshot(current)
+		close(ch)
+		return q.snapshot(current), ch, func() {}, nil
+	}
+	q.watchers[id] = append(q.watchers[id], ch)
+	stop = func() {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		for i, watcher := range q.watchers[id] {
+			if watcher == ch {
+				q.watchers[id] = append(q.watchers[id][:i], q.watchers[id][i+1:]...)
+				break
+			}
+		}
+	}
+	return q.snapshot(current), ch, stop, nil
+}
+
+// HandleDriverEvent retries the waiting riders whose search area holds the
+// driver, oldest first, until one of them is matched; one driver can only take
+// one rider. A rider is retried by one event at a time so it is matched once.
+func (q *MatchQueue) HandleDriverEvent(ctx context.Context, event domain.DriverEvent) {
+	if !event.MayFreeDriver() {
+		return
+	}
+
+	tried := make(map[string]bool)
+	for {
+		entry, ok := q.claim(*event.Location, tried)
+		if !ok {
+			return
+		}
+		tried[entry.ID] = true
+
+		result, err := q.matching.MatchRiderToDriver(ctx, entry.Rider, entry.Radius, entry.Limit)
+		if err == nil {
+			q.finish(ctx, entry.ID, domain.QueueMatched, result)
+			q.release(entry.ID)
+			return
+		}
+		if !errors.Is(err, domain.ErrNoDriversFound) {
+			q.matching.logger.Warn(ctx, "failed to match waiting rider", "rider_id", entry.RiderID, "entry_id", entry.ID, "error", err)
+		}
+		q.release(entry.ID)
+	}
+}
+
+// Run expires the riders that waited for the whole window and forgets finished
+// entries one window later, until ctx is done
+func (q *MatchQueue) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			q.expire(ctx)
+		}
+	}
+}
+
+func (q *MatchQueue) expire(ctx context.Context) {
+	now := q.now()
+	var expired []string
+
+	q.mu.Lock()
+	for _, entry := range q.waiting {
+		// a rider being matched right now expires on the next tick if no driver is found
+		if !now.Before(entry.ExpiresAt) && !q.claimed[entry.ID] {
+			expired = append(expired, entry.ID)
+		}
+	}
+	for id, entry := range q.entries {
+		if entry.Status != domain.QueueWaiting && now.Sub(entry.ExpiresAt) >= q.wait {
+			delete(q.entries, id)
+		}
+	}
+	q.mu.Unlock()
+
+	for _, id := range expired {
+		q.finish(ctx, id, domain.QueueExpired, nil)
+	}
+}
+
+// claim returns the oldest waiting rider whose search area holds the location
+// and that is neither tried nor being matched by another event
+func (q *MatchQueue) claim(location domain.Location, tried map[string]bool) (domain.QueueEntry, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, entry := range q.waiting {
+		if tried[entry.ID] || q.claimed[entry.ID] {
+			continue
+		}
+		if haversine(entry.Rider.Location, location) <= entry.Radius {
+			q.claimed[entry.ID] = true
+			return *entry, true
+		}
+	}
+	return domain.QueueEntry{}, false
+}
+
+func (q *MatchQueue) release(id string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.claimed, id)
+}
+
+// finish ends the wait of a rider still waiting and tells the watchers and the
+// notifier, a rider finished concurrently is left alone
+func (q *MatchQueue) finish(ctx context.Context, id, status string, result *domain.MatchResult) {
+	q.mu.Lock()
+	entry, ok := q.entries[id]
+	if !ok || entry.Status != domain.QueueWaiting || (status == domain.QueueExpired && q.claimed[id]) {
+		q.mu.Unlock()
+		return
+	}
+	entry.Status = status
+	entry.Match = result
+	for i, waiting := range q.waiting {
+		if waiting.ID == id {
+			q.waiting = append(q.waiting[:i], q.waiting[i+1:]...)
+			break
+		}
+	}
+	finished := q.snapshot(entry)
+	for _, watcher := range q.watchers[id] {
+		watcher <- finished
+		close(watcher)
+	}
+	delete(q.watchers, id)
+	q.mu.Unlock()
+
+	if q.notifier != nil {
+		if err := q.notifier.Notify(context.WithoutCancel(ctx), finished); err != nil {
+			q.matching.logger.Warn(ctx, "failed to notify rider of queue entry", "rider_id", finished.RiderID, "entry_id", id, "error", err)
+		}
+	}
+}
+
+// snapshot copies the entry with its position, the riders waiting longer in an
+// overlapping area are matched first
+func (q *MatchQueue) snapshot(entry *domain.QueueEntry) domain.QueueEntry {
+	copied := *entry
+	copied.Position = 0
+	if entry.Status != domain.QueueWaiting {
+		return copied
+	}
+
+	copied.Position = 1
+	for _, other := range q.waiting {
+		if other.ID == entry.ID {
+			break
+		}
+		if haversine(other.Rider.Location, entry.Rider.Location) <= other.Radius+entry.Radius {
+			copied.Position++
+		}
+	}
+	return copied
+}
+
+// haversine returns the great circle distance between two locations in meters
+func haversine(a, b domain.Location) float64 {
+	lat1 := a.Coordinates[1] * math.Pi / 180
+	lat2 := b.Coordinates[1] * math.Pi / 180
+	dLat := lat2 - lat1
+	dLon := (b.Coordinates[0] - a.Coordinates[0]) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Sqrt(h))
+}
@@ -0,0 +1,192 @@
+package application
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"the-matching-service/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingQueueNotifier struct {
+	mu      sync.Mutex
+	entries []domain.QueueEntry
+}
+
+func (n *recordingQueueNotifier) Notify(ctx context.Context, entry domain.QueueEntry) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.entries = append(n.entries, entry)
+	return nil
+}
+
+// newTestQueue returns a queue over a driver location service that finds the
+// drivers currently in the slice
+func newTestQueue(wait time.Duration) (*MatchQueue, *[]string) {
+	drivers := &[]string{}
+	service := NewMatchingService(&mockDriverLocationService{
+		FindNearbyDriversFunc: func(ctx context.Context, location domain.Location, radius float64) ([]domain.DriverDistancePair, error) {
+			pairs := make([]domain.DriverDistancePair, 0, len(*drivers))
+			for _, id := range *drivers {
+				pairs = append(pairs, domain.DriverDistancePair{Driver: domain.Driver{ID: id}, Distance: 100})
+			}
+			return pairs, nil
+		},
+	})
+	return NewMatchQueue(service, wait), drivers
+}
+
+func queueRider(id string, lon, lat float64) domain.Rider {
+	return domain.Rider{ID: id, Location: domain.Location{Type: "Point", Coordinates: [2]float64{lon, lat}}}
+}
+
+func driverEvent(eventType, status string, lon, lat float64) domain.DriverEvent {
+	return domain.DriverEvent{
+		Type:     eventType,
+		DriverID: "driver-1",
+		Location: &domain.Location{Type: "Point", Coordinates: [2]float64{lon, lat}},
+		Status:   status,
+	}
+}
+
+// TestMatchQueue_Enqueue tests riders joining the queue
+// Expected: Should count riders waiting longer in an overlapping area only and keep the place of a rider enqueued twice
+func TestMatchQueue_Enqueue(t *testing.T) {
+	queue, _ := newTestQueue(time.Minute)
+
+	first := queue.Enqueue(queueRider("rider-1", 28.9, 41.0), 500, 0)
+	second := queue.Enqueue(queueRider("rider-2", 28.9, 41.001), 500, 0)
+	faraway := queue.Enqueue(queueRider("rider-3", 29.5, 41.0), 500, 0)
+
+	assert.Equal(t, domain.QueueWaiting, first.Status)
+	assert.Equal(t, first.EnqueuedAt.Add(time.Minute), first.ExpiresAt)
+	assert.Equal(t, 1, first.Position)
+	assert.Equal(t, 2, second.Position)
+	assert.Equal(t, 1, faraway.Position)
+
+	again := queue.Enqueue(queueRider("rider-2", 28.9, 41.001), 500, 0)
+	assert.Equal(t, second.ID, again.ID)
+	assert.Equal(t, 2, again.Position)
+}
+
+// TestMatchQueue_Get tests reading queue entries
+// Expected: Should return the entry of the rider and hide it from other riders
+func TestMatchQueue_Get(t *testing.T) {
+	queue, _ := newTestQueue(time.Minute)
+	entry := queue.Enqueue(queueRider("rider-1", 28.9, 41.0), 500, 0)
+
+	got, err := queue.Get("rider-1", entry.ID)
+	require.NoError(t, err)
+	assert.Equal(t, entry, got)
+
+	_, err = queue.Get("rider-2", entry.ID)
+	assert.ErrorIs(t, err, domain.ErrQueueEntryNotFound)
+	_, err = queue.Get("rider-1", "missing")
+	assert.ErrorIs(t, err, domain.ErrQueueEntryNotFound)
+}
+
+// TestMatchQueue_HandleDriverEvent tests driver events reaching waiting riders
+// Expected: Should match the oldest rider around an available driver only and leave the others waiting
+func TestMatchQueue_HandleDriverEvent(t *testing.T) {
+	queue, drivers := newTestQueue(time.Minute)
+	notifier := &recordingQueueNotifier{}
+	queue.SetNotifier(notifier)
+
+	first := queue.Enqueue(queueRider("rider-1", 28.9, 41.0), 500, 0)
+	second := queue.Enqueue(queueRider("rider-2", 28.9, 41.001), 500, 0)
+	*drivers = []string{"driver-1"}
+
+	queue.HandleDriverEvent(context.Background(), driverEvent(domain.DriverStatusChanged, "busy", 28.9, 41.0))
+	queue.HandleDriverEvent(context.Background(), driverEvent(domain.DriverLocationUpdated, "available", 29.5, 41.0))
+	got, err := queue.Get("rider-1", first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.QueueWaiting, got.Status)
+
+	queue.HandleDriverEvent(context.Background(), driverEvent(domain.DriverStatusChanged, "available", 28.9, 41.0))
+
+	got, err = queue.Get("rider-1", first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.QueueMatched, got.Status)
+	assert.Zero(t, got.Position)
+	require.NotNil(t, got.Match)
+	assert.Equal(t, "driver-1", got.Match.DriverID)
+
+	got, err = queue.Get("rider-2", second.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.QueueWaiting, got.Status)
+	assert.Equal(t, 1, got.Position)
+
+	require.Len(t, notifier.entries, 1)
+	assert.Equal(t, first.ID, notifier.entries[0].ID)
+}
+
+// TestMatchQueue_HandleDriverEvent_noDriver tests driver events that still find no driver for the waiting riders
+// Expected: Should try every rider around the driver and leave them waiting
+func TestMatchQueue_HandleDriverEvent_noDriver(t *testing.T) {
+	queue, _ := newTestQueue(time.Minute)
+	first := queue.Enqueue(queueRider("rider-1", 28.9, 41.0), 500, 0)
+	second := queue.Enqueue(queueRider("rider-2", 28.9, 41.001), 500, 0)
+
+	queue.HandleDriverEvent(context.Background(), driverEvent(domain.DriverCreated, "available", 28.9, 41.0))
+
+	got, err := queue.Get("rider-1", first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.QueueWaiting, got.Status)
+	got, err = queue.Get("rider-2", second.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.QueueWaiting, got.Status)
+	assert.Empty(t, queue.claimed)
+}
+
+// TestMatchQueue_expire tests riders waiting past the wait window
+// Expected: Should expire them, tell the watchers and forget the entry one window later
+func TestMatchQueue_expire(t *testing.T) {
+	queue, _ := newTestQueue(time.Minute)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	queue.now = func() time.Time { return now }
+
+	entry := queue.Enqueue(queueRider("rider-1", 28.9, 41.0), 500, 0)
+	current, updates, stop, err := queue.Watch("rider-1", entry.ID)
+	require.NoError(t, err)
+	defer stop()
+	assert.Equal(t, domain.QueueWaiting, current.Status)
+
+	now = now.Add(59 * time.Second)
+	queue.expire(context.Background())
+	assert.Empty(t, updates)
+
+	now = now.Add(time.Second)
+	queue.expire(context.Background())
+	finished := <-updates
+	assert.Equal(t, domain.QueueExpired, finished.Status)
+	assert.Nil(t, finished.Match)
+
+	now = now.Add(time.Minute)
+	queue.expire(context.Background())
+	_, err = queue.Get("rider-1", entry.ID)
+	assert.ErrorIs(t, err, domain.ErrQueueEntryNotFound)
+}
+
+// TestMatchQueue_Watch_finished tests watching an entry that is no longer waiting
+// Expected: Should hand the final entry right away and close the channel
+func TestMatchQueue_Watch_finished(t *testing.T) {
+	queue, drivers := newTestQueue(time.Minute)
+	entry := queue.Enqueue(queueRider("rider-1", 28.9, 41.0), 500, 0)
+	*drivers = []string{"driver-1"}
+	queue.HandleDriverEvent(context.Background(), driverEvent(domain.DriverCreated, "available", 28.9, 41.0))
+
+	current, updates, stop, err := queue.Watch("rider-1", entry.ID)
+	require.NoError(t, err)
+	defer stop()
+	assert.Equal(t, domain.QueueMatched, current.Status)
+
+	finished, ok := <-updates
+	assert.True(t, ok)
+	assert.Equal(t, domain.QueueMatched, finished.Status)
+	_, ok = <-updates
+	assert.False(t, ok)
+}
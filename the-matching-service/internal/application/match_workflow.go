@@ -0,0 +1,290 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"math"
+	"slices"
+	"time"
+
+	"the-matching-service/internal/domain"
+	"the-matching-service/internal/ports/secondary"
+)
+
+const DefaultMaxRematches = 3
+
+// MatchWorkflow makes the matched driver answer a match within ProposalTimeout.
+// A rejected or timed out match is proposed to the next driver, up to MaxRematches
+// times; a zero timeout keeps matches final as soon as they are made.
+type MatchWorkflow struct {
+	ProposalTimeout time.Duration
+	MaxRematches    int
+}
+
+func (w MatchWorkflow) enabled() bool {
+	return w.ProposalTimeout > 0
+}
+
+// SetMatchWorkflow lets drivers accept or reject their matches, it needs the match store
+func (s *MatchingService) SetMatchWorkflow(workflow MatchWorkflow) {
+	if workflow.MaxRematches < 0 {
+		workflow.MaxRematches = 0
+	}
+	s.workflow = workflow
+}
+
+// SetOutcomeReporter reports the accepted, completed and driver cancelled matches
+// so the supply side can score drivers by them
+func (s *MatchingService) SetOutcomeReporter(reporter secondary.OutcomeReporter) {
+	s.outcomes = reporter
+}
+
+// propose makes the match wait for the driver's answer, the search is kept to
+// re-match the rider
+func (s *MatchingService) propose(result *domain.MatchResult, rider domain.Rider, radius float64, limit int) {
+	if !s.workflow.enabled() || s.matchStore == nil {
+		return
+	}
+	result.Status = domain.MatchProposed
+	result.RespondBy = result.MatchedAt.Add(s.workflow.ProposalTimeout)
+	result.Search = &domain.MatchSearch{
+		Location:    rider.Location,
+		Radius:      radius,
+		Limit:       limit,
+		Preferences: rider.Preferences,
+	}
+}
+
+// AcceptMatch confirms a proposed match of the driver
+func (s *MatchingService) AcceptMatch(ctx context.Context, driverID, id string) (*domain.MatchResult, error) {
+	result, err := s.answer(ctx, driverID, id, domain.MatchAccepted)
+	if err != nil {
+		return nil, err
+	}
+	s.reportOutcome(ctx, result, domain.MatchAccepted)
+	return result, nil
+}
+
+// CompleteMatch ends an accepted match of the driver once the ride is over
+func (s *MatchingService) CompleteMatch(ctx context.Context, driverID, id string) (*domain.MatchResult, error) {
+	result, err := s.end(ctx, id, func(stored *domain.MatchResult) error {
+		if stored.DriverID != driverID {
+			return domain.ErrMatchNotFound
+		}
+		if stored.Status != domain.MatchAccepted {
+			return domain.ErrMatchNotActive
+		}
+		stored.Status = domain.MatchCompleted
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.reportOutcome(ctx, result, domain.MatchCompleted)
+	return result, nil
+}
+
+// CancelMatch cancels a match for its rider or its driver. The rider can cancel
+// while the match is proposed or accepted, the driver rejects proposals instead
+// and can only cancel an accepted match; only those count against the driver.
+func (s *MatchingService) CancelMatch(ctx context.Context, userID, id string) (*domain.MatchResult, error) {
+	result, err := s.end(ctx, id, func(stored *domain.MatchResult) error {
+		switch {
+		case stored.RiderID == userID && (stored.Status == domain.MatchProposed || stored.Status == domain.MatchAccepted):
+			stored.CancelledBy = domain.CancelledByRider
+		case stored.RiderID == userID:
+			return domain.ErrMatchNotActive
+		case stored.DriverID == userID && stored.Status == domain.MatchAccepted:
+			stored.CancelledBy = domain.CancelledByDriver
+		case stored.DriverID == userID:
+			return domain.ErrMatchNotActive
+		default:
+			return domain.ErrMatchNotFound
+		}
+		stored.Status = domain.MatchCancelled
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.leavePooledRide(ctx, *result)
+	if result.CancelledBy == domain.CancelledByDriver {
+		s.reportOutcome(ctx, result, domain.MatchCancelled)
+	}
+	return result, nil
+}
+
+// end moves a match of the workflow to its final status, matches made without
+// the workflow are final already and not found
+func (s *MatchingService) end(ctx context.Context, id string, change func(stored *domain.MatchResult) error) (*domain.MatchResult, error) {
+	if !s.workflow.enabled() || s.matchStore == nil {
+		return nil, domain.ErrMatchNotFound
+	}
+	return s.matchStore.Update(ctx, id, func(stored *domain.MatchResult) error {
+		if stored.Status == "" {
+			return domain.ErrMatchNotFound
+		}
+		return change(stored)
+	})
+}
+
+// reportOutcome is best effort, a failed report only costs the driver's score
+// one outcome
+func (s *MatchingService) reportOutcome(ctx context.Context, result *domain.MatchResult, outcome string) {
+	if s.outcomes == nil {
+		return
+	}
+	report := domain.MatchOutcome{MatchID: result.ID, DriverID: result.DriverID, Outcome: outcome}
+	if err := s.outcomes.ReportOutcome(context.WithoutCancel(ctx), report); err != nil {
+		s.logger.Warn(ctx, "failed to report match outcome", "match_id", result.ID, "driver_id", result.DriverID, "outcome", outcome, "error", err)
+	}
+}
+
+// RejectMatch declines a proposed match of the driver, the rider is proposed to
+// the next driver
+func (s *MatchingService) RejectMatch(ctx context.Context, driverID, id string) (*domain.MatchResult, error) {
+	result, err := s.answer(ctx, driverID, id, domain.MatchRejected)
+	if err != nil {
+		return nil, err
+	}
+	return s.rematch(ctx, *result)
+}
+
+// ExpireOverdue re-matches a proposal its driver did not answer in time, other
+// matches are returned as they are. Proposals expire when they are read, the
+// rider polls the match until it is accepted anyway.
+func (s *MatchingService) ExpireOverdue(ctx context.Context, result *domain.MatchResult) (*domain.MatchResult, error) {
+	if !result.Overdue(time.Now()) {
+		return result, nil
+	}
+
+	expired, err := s.matchStore.Update(ctx, result.ID, func(stored *domain.MatchResult) error {
+		if !stored.Overdue(time.Now()) {
+			return errAnswered
+		}
+		decline(stored, domain.MatchExpired)
+		return nil
+	})
+	if errors.Is(err, errAnswered) {
+		// answered or expired concurrently, the stored match is more recent
+		return s.matchStore.Get(ctx, result.ID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.rematch(ctx, *expired)
+}
+
+// errAnswered aborts an expiry that lost the race against the driver's answer
+var errAnswered = errors.New("match answered")
+
+// answer moves a proposed match of the driver to accepted or rejected. Another
+// driver's match is not found, so match IDs cannot be probed.
+func (s *MatchingService) answer(ctx context.Context, driverID, id, status string) (*domain.MatchResult, error) {
+	if !s.workflow.enabled() || s.matchStore == nil {
+		return nil, domain.ErrMatchNotFound
+	}
+
+	overdue := false
+	result, err := s.matchStore.Update(ctx, id, func(stored *domain.MatchResult) error {
+		if stored.DriverID != driverID {
+			return domain.ErrMatchNotFound
+		}
+		if stored.Status != domain.MatchProposed {
+			return domain.ErrMatchNotProposed
+		}
+		if overdue = stored.Overdue(time.Now()); overdue {
+			decline(stored, domain.MatchExpired)
+			return nil
+		}
+		if status == domain.MatchAccepted {
+			stored.Status = domain.MatchAccepted
+			return nil
+		}
+		decline(stored, domain.MatchRejected)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if overdue {
+		// the answer came too late, the rider goes to the next driver all the same
+		if _, err := s.rematch(ctx, *result); err != nil {
+			s.logger.Warn(ctx, "failed to re-match expired match", "match_id", id, "error", err)
+		}
+		return nil, domain.ErrMatchNotProposed
+	}
+	return result, nil
+}
+
+func decline(result *domain.MatchResult, status string) {
+	result.Status = status
+	result.DeclinedDrivers = append(result.DeclinedDrivers, result.DriverID)
+}
+
+// rematch proposes a rejected or expired match to the nearest driver that did not
+// decline it yet. A match without drivers left or out of re-matches stays as it is.
+// The rider leaves the pooled ride of the driver that declined either way.
+func (s *MatchingService) rematch(ctx context.Context, declined domain.MatchResult) (*domain.MatchResult, error) {
+	s.leavePooledRide(ctx, declined)
+	if declined.Search == nil || declined.Rematches >= s.workflow.MaxRematches {
+		return &declined, nil
+	}
+
+	rider := domain.Rider{ID: declined.RiderID, Location: declined.Search.Location, Preferences: declined.Search.Preferences}
+	blocked, err := s.blockedDrivers(ctx, rider.ID)
+	if err != nil {
+		return nil, err
+	}
+	excluded := make(map[string]bool, len(blocked)+len(declined.DeclinedDrivers))
+	for driverID := range withRidingDrivers(blocked, s.activeRides(ctx)) {
+		excluded[driverID] = true
+	}
+	for _, driverID := range declined.DeclinedDrivers {
+		excluded[driverID] = true
+	}
+
+	drivers, err := s.searchExpanding(ctx, rider, declined.Search.Radius, declined.Search.Limit, excluded)
+	if errors.Is(err, domain.ErrNoDriversFound) {
+		return &declined, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	strategy := s.rollout.Assign(rider.ID)
+	selected := strategy.Select(rider, drivers)
+	now := time.Now().UTC()
+	rematched := false
+	result, err := s.matchStore.Update(ctx, declined.ID, func(stored *domain.MatchResult) error {
+		// a retried update decides again
+		rematched = stored.Status == declined.Status && slices.Equal(stored.DeclinedDrivers, declined.DeclinedDrivers)
+		if !rematched {
+			// re-matched concurrently
+			return nil
+		}
+		stored.DriverID = selected.Driver.ID
+		stored.Distance = math.Round(selected.Distance*100) / 100
+		stored.Strategy = strategy.Name()
+		stored.Pooled = false
+		stored.Status = domain.MatchProposed
+		stored.RespondBy = now.Add(s.workflow.ProposalTimeout)
+		stored.Rematches++
+		return nil
+	})
+	if err == nil && rematched {
+		s.recordDecision(ctx, domain.DecisionEvent{
+			MatchID:    declined.ID,
+			Rider:      rider,
+			Radius:     declined.Search.Radius,
+			Limit:      declined.Search.Limit,
+			Candidates: drivers,
+			Strategy:   strategy.Name(),
+			DriverID:   selected.Driver.ID,
+			Distance:   result.Distance,
+			Rematch:    true,
+			DecidedAt:  now,
+		})
+	}
+	return result, err
+}
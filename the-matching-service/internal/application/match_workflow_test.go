@@ -0,0 +1,149 @@
+package application
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"the-matching-service/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newWorkflowService(workflow MatchWorkflow, drivers ...string) (*MatchingService, *memoryMatchStore) {
+	store := newMemoryMatchStore()
+	service := NewMatchingService(&mockDriverLocationService{
+		FindNearbyDriversFunc: func(ctx context.Context, location domain.Location, radius float64) ([]domain.DriverDistancePair, error) {
+			pairs := make([]domain.DriverDistancePair, 0, len(drivers))
+			for i, id := range drivers {
+				pairs = append(pairs, domain.DriverDistancePair{Driver: domain.Driver{ID: id}, Distance: float64(100 * (i + 1))})
+			}
+			return pairs, nil
+		},
+	})
+	service.SetMatchStore(store)
+	service.SetMatchWorkflow(workflow)
+	return service, store
+}
+
+// TestMatchingService_MatchWorkflow_proposes tests matches made while drivers answer matches
+// Expected: Should store the match as proposed with its deadline and the search to re-match with
+func TestMatchingService_MatchWorkflow_proposes(t *testing.T) {
+	service, store := newWorkflowService(MatchWorkflow{ProposalTimeout: 30 * time.Second, MaxRematches: 3}, "driver-1")
+	rider := domain.Rider{ID: "rider-1", Location: domain.Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}}}
+
+	result, err := service.MatchRiderToDriver(context.Background(), rider, 500, 5)
+	require.NoError(t, err)
+
+	stored := store.matches[result.ID]
+	assert.Equal(t, domain.MatchProposed, stored.Status)
+	assert.Equal(t, result.MatchedAt.Add(30*time.Second), stored.RespondBy)
+	assert.Equal(t, &domain.MatchSearch{Location: rider.Location, Radius: 500, Limit: 5, Preferences: rider.Preferences}, stored.Search)
+}
+
+// TestMatchingService_MatchWorkflow_disabled tests answering matches without a proposal timeout
+// Expected: Should keep matches without a status and refuse answers as unknown matches
+func TestMatchingService_MatchWorkflow_disabled(t *testing.T) {
+	service, store := newWorkflowService(MatchWorkflow{}, "driver-1")
+	rider := domain.Rider{ID: "rider-1", Location: domain.Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}}}
+
+	result, err := service.MatchRiderToDriver(context.Background(), rider, 500, 0)
+	require.NoError(t, err)
+	assert.Empty(t, store.matches[result.ID].Status)
+
+	_, err = service.AcceptMatch(context.Background(), "driver-1", result.ID)
+	assert.ErrorIs(t, err, domain.ErrMatchNotFound)
+}
+
+// TestMatchingService_RejectMatch_noDriversLeft tests rejecting a match when every nearby driver declined it
+// Expected: Should keep the match rejected instead of proposing it to a driver that declined it already
+func TestMatchingService_RejectMatch_noDriversLeft(t *testing.T) {
+	service, _ := newWorkflowService(MatchWorkflow{ProposalTimeout: time.Minute, MaxRematches: 3}, "driver-1")
+	rider := domain.Rider{ID: "rider-1", Location: domain.Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}}}
+
+	result, err := service.MatchRiderToDriver(context.Background(), rider, 500, 0)
+	require.NoError(t, err)
+
+	rejected, err := service.RejectMatch(context.Background(), "driver-1", result.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.MatchRejected, rejected.Status)
+	assert.Equal(t, "driver-1", rejected.DriverID)
+	assert.Zero(t, rejected.Rematches)
+
+	_, err = service.AcceptMatch(context.Background(), "driver-1", result.ID)
+	assert.ErrorIs(t, err, domain.ErrMatchNotProposed)
+}
+
+// TestMatchingService_ExpireOverdue tests reading proposals before and after their deadline
+// Expected: Should return answered and pending proposals as they are and re-match overdue ones
+func TestMatchingService_ExpireOverdue(t *testing.T) {
+	service, store := newWorkflowService(MatchWorkflow{ProposalTimeout: time.Minute, MaxRematches: 3}, "driver-1", "driver-2")
+	rider := domain.Rider{ID: "rider-1", Location: domain.Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}}}
+
+	result, err := service.MatchRiderToDriver(context.Background(), rider, 500, 0)
+	require.NoError(t, err)
+
+	pending := store.matches[result.ID]
+	refreshed, err := service.ExpireOverdue(context.Background(), &pending)
+	require.NoError(t, err)
+	assert.Equal(t, "driver-1", refreshed.DriverID)
+
+	pending.RespondBy = time.Now().Add(-time.Second)
+	store.matches[result.ID] = pending
+	refreshed, err = service.ExpireOverdue(context.Background(), &pending)
+	require.NoError(t, err)
+	assert.Equal(t, "driver-2", refreshed.DriverID)
+	assert.Equal(t, domain.MatchProposed, refreshed.Status)
+	assert.Equal(t, []string{"driver-1"}, refreshed.DeclinedDrivers)
+	assert.True(t, refreshed.RespondBy.After(time.Now()))
+}
+
+type recordingOutcomeReporter struct {
+	outcomes []domain.MatchOutcome
+}
+
+func (r *recordingOutcomeReporter) ReportOutcome(ctx context.Context, outcome domain.MatchOutcome) error {
+	r.outcomes = append(r.outcomes, outcome)
+	return nil
+}
+
+// TestMatchingService_MatchOutcomes tests the outcomes reported while matches are answered and ended
+// Expected: Should report accepted, completed and driver cancelled matches and leave rider cancellations out
+func TestMatchingService_MatchOutcomes(t *testing.T) {
+	service, _ := newWorkflowService(MatchWorkflow{ProposalTimeout: time.Minute, MaxRematches: 3}, "driver-1")
+	reporter := &recordingOutcomeReporter{}
+	service.SetOutcomeReporter(reporter)
+	rider := domain.Rider{ID: "rider-1", Location: domain.Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}}}
+
+	completed, err := service.MatchRiderToDriver(context.Background(), rider, 500, 0)
+	require.NoError(t, err)
+	_, err = service.AcceptMatch(context.Background(), "driver-1", completed.ID)
+	require.NoError(t, err)
+	_, err = service.CompleteMatch(context.Background(), "driver-1", completed.ID)
+	require.NoError(t, err)
+
+	cancelled, err := service.MatchRiderToDriver(context.Background(), rider, 500, 0)
+	require.NoError(t, err)
+	_, err = service.CancelMatch(context.Background(), "driver-1", cancelled.ID)
+	assert.ErrorIs(t, err, domain.ErrMatchNotActive)
+	_, err = service.AcceptMatch(context.Background(), "driver-1", cancelled.ID)
+	require.NoError(t, err)
+	result, err := service.CancelMatch(context.Background(), "driver-1", cancelled.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.CancelledByDriver, result.CancelledBy)
+
+	riderCancelled, err := service.MatchRiderToDriver(context.Background(), rider, 500, 0)
+	require.NoError(t, err)
+	_, err = service.CancelMatch(context.Background(), "rider-1", riderCancelled.ID)
+	require.NoError(t, err)
+	_, err = service.AcceptMatch(context.Background(), "driver-1", riderCancelled.ID)
+	assert.ErrorIs(t, err, domain.ErrMatchNotProposed)
+
+	assert.Equal(t, []domain.MatchOutcome{
+		{MatchID: completed.ID, DriverID: "driver-1", Outcome: domain.MatchAccepted},
+		{MatchID: completed.ID, DriverID: "driver-1", Outcome: domain.MatchCompleted},
+		{MatchID: cancelled.ID, DriverID: "driver-1", Outcome: domain.MatchAccepted},
+		{MatchID: cancelled.ID, DriverID: "driver-1", Outcome: domain.MatchCancelled},
+	}, reporter.outcomes)
+}
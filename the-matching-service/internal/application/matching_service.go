@@ -1,36 +1,424 @@
 package application
 
 import (
+	"cmp"
 	"context"
-	"errors"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
 	"math"
+	"slices"
+	"strings"
+	"time"
 
 	"the-matching-service/internal/domain"
 	"the-matching-service/internal/ports/secondary"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// coalescePrecision rounds rider coordinates to 4 decimals (~11m) so a double tap
+// from a slightly drifting GPS still hits the same in-flight match
+const coalescePrecision = 1e4
+
+// RadiusExpansion retries a search that found no drivers with the radius multiplied
+// by Factor each time, until a search finds drivers or the radius reached MaxRadius
+type RadiusExpansion struct {
+	Factor    float64
+	MaxRadius float64 // meters, expansion is off when it is not above the requested radius
+}
+
+// next returns the radius of the next attempt, false when the search should give up
+func (e RadiusExpansion) next(radius float64) (float64, bool) {
+	if e.Factor <= 1 || radius >= e.MaxRadius {
+		return radius, false
+	}
+	return math.Min(radius*e.Factor, e.MaxRadius), true
+}
+
+const (
+	DefaultSearchLimit    = 10
+	DefaultMaxSearchLimit = 50
 )
 
+// SearchLimits caps how many candidates are requested from the driver location
+// service, a match request without a limit gets Default and larger ones Max
+type SearchLimits struct {
+	Default int
+	Max     int
+}
+
+func (l SearchLimits) clamp(limit int) int {
+	if limit <= 0 {
+		limit = l.Default
+	}
+	return min(limit, l.Max)
+}
+
 type MatchingService struct {
 	DriverLocationService secondary.DriverLocationService
+	inflight              singleflight.Group
+	rollout               StrategyRollout
+	expansion             RadiusExpansion
+	blocklist             secondary.Blocklist
+	matchStore            secondary.MatchStore
+	geofence              secondary.Geofence
+	limits                SearchLimits
+	eta                   *ETAStrategy
+	pooling               Pooling
+	workflow              MatchWorkflow
+	outcomes              secondary.OutcomeReporter
+	idempotency           Idempotency
+	decisions             DecisionLog
+	logger                secondary.Logger
 }
 
 func NewMatchingService(driverLocationService secondary.DriverLocationService) *MatchingService {
 	return &MatchingService{
 		DriverLocationService: driverLocationService,
+		rollout:               StrategyRollout{Control: NearestStrategy{}},
+		limits:                SearchLimits{Default: DefaultSearchLimit, Max: DefaultMaxSearchLimit},
+		eta:                   NewETAStrategy(DefaultAverageSpeedKmh),
+		logger:                secondary.NopLogger{},
 	}
 }
 
-func (s *MatchingService) MatchRiderToDriver(ctx context.Context, rider domain.Rider, radius float64) (*domain.MatchResult, error) {
-	drivers, err := s.DriverLocationService.FindNearbyDrivers(ctx, rider.Location, radius)
+// SetLogger sets where the failures a match tolerates are logged, the match
+// queue logs there too
+func (s *MatchingService) SetLogger(logger secondary.Logger) {
+	s.logger = logger
+}
+
+// SetSearchLimits replaces the default and maximum number of candidates of a match
+func (s *MatchingService) SetSearchLimits(limits SearchLimits) {
+	if limits.Max <= 0 {
+		limits.Max = DefaultMaxSearchLimit
+	}
+	if limits.Default <= 0 {
+		limits.Default = DefaultSearchLimit
+	}
+	limits.Default = min(limits.Default, limits.Max)
+	s.limits = limits
+}
+
+// SetStrategyRollout replaces the default nearest driver strategy with an A/B rollout
+func (s *MatchingService) SetStrategyRollout(rollout StrategyRollout) {
+	if rollout.Control == nil {
+		rollout.Control = NearestStrategy{}
+	}
+	s.rollout = rollout
+}
+
+// SetRadiusExpansion makes matches in sparse areas retry with larger radii before
+// returning no drivers found, radii never grow beyond domain.MaxRadius
+func (s *MatchingService) SetRadiusExpansion(expansion RadiusExpansion) {
+	expansion.MaxRadius = math.Min(expansion.MaxRadius, domain.MaxRadius)
+	s.expansion = expansion
+}
+
+// SetBlocklist drops the drivers a rider is blocked from before a driver is picked
+func (s *MatchingService) SetBlocklist(blocklist secondary.Blocklist) {
+	s.blocklist = blocklist
+}
+
+// SetMatchStore persists every match so riders can look their matches up later
+func (s *MatchingService) SetMatchStore(store secondary.MatchStore) {
+	s.matchStore = store
+}
+
+// SetGeofence rejects riders outside the service area before any search
+func (s *MatchingService) SetGeofence(geofence secondary.Geofence) {
+	s.geofence = geofence
+}
+
+// SetAverageSpeed replaces the speed the ETAs of match candidates are estimated with
+func (s *MatchingService) SetAverageSpeed(averageSpeedKmh float64) {
+	if averageSpeedKmh <= 0 {
+		averageSpeedKmh = DefaultAverageSpeedKmh
+	}
+	s.eta = NewETAStrategy(averageSpeedKmh)
+}
+
+// SetPooling lets riders that opt in join a driver already carrying a pooling
+// rider whose route passes near them
+func (s *MatchingService) SetPooling(pooling Pooling) {
+	if pooling.MaxDetour <= 0 {
+		pooling.MaxDetour = DefaultPoolMaxDetour
+	}
+	s.pooling = pooling
+}
+
+// StrategyFor returns the strategy variant the given user is assigned to
+func (s *MatchingService) StrategyFor(userID string) string {
+	return s.rollout.Assign(userID).Name()
+}
+
+// MatchRiderToDriver coalesces identical concurrent requests of the same rider,
+// only the first one searches the driver location service and the others share its result.
+// limit is the number of candidates to consider, zero uses the configured default.
+func (s *MatchingService) MatchRiderToDriver(ctx context.Context, rider domain.Rider, radius float64, limit int) (*domain.MatchResult, error) {
+	limit = s.limits.clamp(limit)
+	audit := domain.MatchAuditFrom(ctx)
+	audit.SetRequest(rider, radius, limit, s.StrategyFor(rider.ID))
+	if err := s.checkServiceArea(ctx, rider.Location); err != nil {
+		return nil, err
+	}
+	if rider.ID == "" {
+		result, err := s.match(ctx, rider, radius, limit)
+		if err == nil {
+			audit.SetDecision(result, false)
+		}
+		return result, err
+	}
+
+	// only the leader searches, the audit of a follower records the shared decision
+	leader := false
+	v, err, _ := s.inflight.Do(coalesceKey(rider, radius, limit), func() (interface{}, error) {
+		leader = true
+		// the leader must not abort the followers when its own client disconnects
+		return s.match(context.WithoutCancel(ctx), rider, radius, limit)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := *v.(*domain.MatchResult)
+	audit.SetDecision(&result, !leader)
+	return &result, nil
+}
+
+func (s *MatchingService) match(ctx context.Context, rider domain.Rider, radius float64, limit int) (*domain.MatchResult, error) {
+	blocked, err := s.blockedDrivers(ctx, rider.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	rides := s.activeRides(ctx)
+	if rider.Pool {
+		if result := s.joinPooledRide(ctx, rider, radius, limit, blocked, rides); result != nil {
+			s.propose(result, rider, radius, limit)
+			s.saveMatch(ctx, result)
+			return result, nil
+		}
+	}
+
+	drivers, err := s.searchExpanding(ctx, rider, radius, limit, withRidingDrivers(blocked, rides))
+	if err != nil {
+		return nil, err
+	}
+
+	strategy := s.rollout.Assign(rider.ID)
+	selected := strategy.Select(rider, drivers)
+	result := &domain.MatchResult{
+		ID:         newMatchID(),
+		RiderID:    rider.ID,
+		DriverID:   selected.Driver.ID,
+		Distance:   math.Round(selected.Distance*100) / 100,
+		Strategy:   strategy.Name(),
+		MatchedAt:  time.Now().UTC(),
+		Candidates: s.rankByETA(rider, drivers),
+	}
+	s.recordDecision(ctx, domain.DecisionEvent{
+		MatchID:    result.ID,
+		Rider:      rider,
+		Radius:     radius,
+		Limit:      limit,
+		Candidates: drivers,
+		Strategy:   result.Strategy,
+		DriverID:   result.DriverID,
+		Distance:   result.Distance,
+		DecidedAt:  result.MatchedAt,
+	})
+	s.startPooledRide(ctx, rider, selected.Driver)
+	s.propose(result, rider, radius, limit)
+	s.saveMatch(ctx, result)
+	return result, nil
+}
+
+// saveMatch stores the match, the driver is matched already and a match that
+// cannot be stored is still returned
+func (s *MatchingService) saveMatch(ctx context.Context, result *domain.MatchResult) {
+	if s.matchStore == nil {
+		return
+	}
+	if err := s.matchStore.Save(ctx, *result); err != nil {
+		s.logger.Warn(ctx, "failed to store match", "match_id", result.ID, "error", err)
+	}
+}
+
+// FindCandidates returns up to limit drivers the rider may choose from, the
+// fastest to arrive first. Nothing is matched: the strategies and the match
+// store are left alone so a rider browsing options does not count as a match.
+func (s *MatchingService) FindCandidates(ctx context.Context, rider domain.Rider, radius float64, limit int) ([]domain.MatchCandidate, error) {
+	limit = s.limits.clamp(limit)
+	if err := s.checkServiceArea(ctx, rider.Location); err != nil {
+		return nil, err
+	}
+	blocked, err := s.blockedDrivers(ctx, rider.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	drivers, err := s.searchExpanding(ctx, rider, radius, limit, withRidingDrivers(blocked, s.activeRides(ctx)))
+	if err != nil {
+		return nil, err
+	}
+	return s.rankByETA(rider, drivers), nil
+}
+
+// rankByETA returns the drivers as candidates, the fastest to arrive first. The
+// drivers are left in their order.
+func (s *MatchingService) rankByETA(rider domain.Rider, drivers []domain.DriverDistancePair) []domain.MatchCandidate {
+	etas := make(map[string]time.Duration, len(drivers))
+	for _, driver := range drivers {
+		etas[driver.Driver.ID] = s.eta.estimate(rider, driver)
+	}
+	ranked := slices.Clone(drivers)
+	// stable, drivers arriving at the same time stay nearest first
+	slices.SortStableFunc(ranked, func(a, b domain.DriverDistancePair) int {
+		return cmp.Compare(etas[a.Driver.ID], etas[b.Driver.ID])
+	})
+
+	candidates := make([]domain.MatchCandidate, 0, len(ranked))
+	for _, driver := range ranked {
+		candidates = append(candidates, domain.MatchCandidate{
+			DriverID:   driver.Driver.ID,
+			Distance:   math.Round(driver.Distance*100) / 100,
+			ETASeconds: int(math.Ceil(etas[driver.Driver.ID].Seconds())),
+		})
+	}
+	return candidates
+}
+
+// searchExpanding finds the drivers around the rider, retrying with larger radii
+// while the search comes back empty
+func (s *MatchingService) searchExpanding(ctx context.Context, rider domain.Rider, radius float64, limit int, blocked map[string]bool) ([]domain.DriverDistancePair, error) {
+	drivers, err := s.findDrivers(ctx, rider, radius, limit, blocked)
+	for err == nil && len(drivers) == 0 {
+		var expand bool
+		if radius, expand = s.expansion.next(radius); !expand {
+			break
+		}
+		drivers, err = s.findDrivers(ctx, rider, radius, limit, blocked)
+	}
 	if err != nil {
 		return nil, err
 	}
 	if len(drivers) == 0 {
-		return nil, errors.New("no drivers found")
-	}
-	nearestDriver := drivers[0]
-	return &domain.MatchResult{
-		RiderID:  rider.ID,
-		DriverID: nearestDriver.Driver.ID,
-		Distance: math.Round(nearestDriver.Distance*100) / 100,
-	}, nil
+		return nil, domain.ErrNoDriversFound
+	}
+	return drivers, nil
+}
+
+// findDrivers returns the drivers that may be matched with the rider, nearest
+// first. The upstream orders its results too, but strategies must not depend on
+// that order surviving serialization and caching.
+func (s *MatchingService) findDrivers(ctx context.Context, rider domain.Rider, radius float64, limit int, blocked map[string]bool) ([]domain.DriverDistancePair, error) {
+	drivers, err := s.DriverLocationService.FindNearbyDrivers(ctx, rider.Location, radius, limit, rider.Preferences)
+	if err != nil {
+		return nil, err
+	}
+
+	// copied, the search cache shares the returned slice between riders
+	candidates := make([]domain.DriverDistancePair, 0, len(drivers))
+	blockedCount := 0
+	for _, driver := range drivers {
+		switch {
+		case blocked[driver.Driver.ID]:
+			blockedCount++
+		case rider.Preferences.Accepts(driver.Driver):
+			candidates = append(candidates, driver)
+		}
+	}
+	sortByDistance(candidates)
+	domain.MatchAuditFrom(ctx).RecordSearch(radius, len(candidates), blockedCount)
+	return candidates, nil
+}
+
+// sortByDistance orders drivers nearest first, drivers at the same distance by ID
+// so equal searches always produce the same match
+func sortByDistance(drivers []domain.DriverDistancePair) {
+	slices.SortFunc(drivers, func(a, b domain.DriverDistancePair) int {
+		if c := cmp.Compare(a.Distance, b.Distance); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Driver.ID, b.Driver.ID)
+	})
+}
+
+// checkServiceArea rejects (0,0) always and locations the geofence does not
+// serve. A geofence that cannot answer lets the match through, a search outside
+// the area costs less than refusing riders inside it.
+func (s *MatchingService) checkServiceArea(ctx context.Context, location domain.Location) error {
+	if location.Coordinates == [2]float64{0, 0} {
+		return domain.ErrOutOfServiceArea
+	}
+	if s.geofence == nil {
+		return nil
+	}
+
+	serves, err := s.geofence.Serves(ctx, location)
+	if err != nil {
+		s.logger.Warn(ctx, "failed to check the service area, matching anyway", "error", err)
+		return nil
+	}
+	if !serves {
+		return domain.ErrOutOfServiceArea
+	}
+	return nil
+}
+
+// withRidingDrivers adds the drivers on a pooled ride to the blocked drivers, they
+// only take riders joining their route and are never matched alone. The audit
+// counts them as blocked.
+func withRidingDrivers(blocked map[string]bool, rides map[string]domain.PooledRide) map[string]bool {
+	if len(rides) == 0 {
+		return blocked
+	}
+
+	excluded := make(map[string]bool, len(blocked)+len(rides))
+	for driverID := range blocked {
+		excluded[driverID] = true
+	}
+	for driverID := range rides {
+		excluded[driverID] = true
+	}
+	return excluded
+}
+
+// blockedDrivers fails the match when the blocklist cannot be read, a blocked
+// pair must never be matched
+func (s *MatchingService) blockedDrivers(ctx context.Context, riderID string) (map[string]bool, error) {
+	if s.blocklist == nil || riderID == "" {
+		return nil, nil
+	}
+
+	pairs, err := s.blocklist.BlockedDrivers(ctx, riderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check blocked drivers: %w", err)
+	}
+
+	blocked := make(map[string]bool, len(pairs))
+	for _, pair := range pairs {
+		blocked[pair.DriverID] = true
+	}
+	return blocked, nil
+}
+
+func newMatchID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+func coalesceKey(rider domain.Rider, radius float64, limit int) string {
+	lon := math.Round(rider.Location.Coordinates[0]*coalescePrecision) / coalescePrecision
+	lat := math.Round(rider.Location.Coordinates[1]*coalescePrecision) / coalescePrecision
+	key := fmt.Sprintf("%s|%.4f|%.4f|%.1f|%d|%s|%d", rider.ID, lon, lat, radius, limit, rider.Preferences.VehicleType, rider.Preferences.MinCapacity)
+	if rider.Pool && rider.Destination != nil {
+		key += fmt.Sprintf("|pool|%.4f|%.4f", rider.Destination.Coordinates[0], rider.Destination.Coordinates[1])
+	}
+	return key
 }
@@ -3,7 +3,10 @@ package application
 import (
 	"context"
 	"errors"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"the-matching-service/internal/domain"
 
@@ -12,9 +15,17 @@ import (
 
 type mockDriverLocationService struct {
 	FindNearbyDriversFunc func(ctx context.Context, location domain.Location, radius float64) ([]domain.DriverDistancePair, error)
+
+	mu          sync.Mutex
+	limit       int
+	preferences domain.RiderPreferences
 }
 
-func (m *mockDriverLocationService) FindNearbyDrivers(ctx context.Context, location domain.Location, radius float64) ([]domain.DriverDistancePair, error) {
+func (m *mockDriverLocationService) FindNearbyDrivers(ctx context.Context, location domain.Location, radius float64, limit int, preferences domain.RiderPreferences) ([]domain.DriverDistancePair, error) {
+	m.mu.Lock()
+	m.limit = limit
+	m.preferences = preferences
+	m.mu.Unlock()
 	return m.FindNearbyDriversFunc(ctx, location, radius)
 }
 
@@ -34,7 +45,7 @@ func TestMatchingService_MatchRiderToDriver_success(t *testing.T) {
 
 	service := NewMatchingService(mockSvc)
 	rider := domain.Rider{ID: "rider-1", Location: domain.Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}}}
-	result, err := service.MatchRiderToDriver(context.Background(), rider, 500)
+	result, err := service.MatchRiderToDriver(context.Background(), rider, 500, 0)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, result)
@@ -54,11 +65,12 @@ func TestMatchingService_MatchRiderToDriver_noDrivers(t *testing.T) {
 
 	service := NewMatchingService(mockSvc)
 	rider := domain.Rider{ID: "rider-2", Location: domain.Location{Type: "Point", Coordinates: [2]float64{29.0, 41.1}}}
-	result, err := service.MatchRiderToDriver(context.Background(), rider, 500)
+	result, err := service.MatchRiderToDriver(context.Background(), rider, 500, 0)
 
 	assert.Error(t, err)
 	assert.Nil(t, result)
 	assert.Equal(t, "no drivers found", err.Error())
+	assert.ErrorIs(t, err, domain.ErrNoDriversFound)
 }
 
 // TestMatchingService_MatchRiderToDriver_serviceError tests error handling when external driver location service fails
@@ -72,9 +84,475 @@ func TestMatchingService_MatchRiderToDriver_serviceError(t *testing.T) {
 
 	service := NewMatchingService(mockSvc)
 	rider := domain.Rider{ID: "rider-3", Location: domain.Location{Type: "Point", Coordinates: [2]float64{29.1, 41.2}}}
-	result, err := service.MatchRiderToDriver(context.Background(), rider, 500)
+	result, err := service.MatchRiderToDriver(context.Background(), rider, 500, 0)
 
 	assert.Error(t, err)
 	assert.Nil(t, result)
 	assert.Equal(t, "external service error", err.Error())
 }
+
+// TestMatchingService_MatchRiderToDriver_coalescesIdenticalRequests tests coalescing of concurrent identical requests
+// Expected: Should call the driver location service once and return the same match to every caller
+func TestMatchingService_MatchRiderToDriver_coalescesIdenticalRequests(t *testing.T) {
+	var calls int32
+	results := make([]*domain.MatchResult, 4)
+	// every caller checks the service area right before coalescing, the search
+	// of the first one blocks until all of them got there
+	geofence := &enteringGeofence{callers: int32(len(results)), entered: make(chan struct{})}
+	mockSvc := &mockDriverLocationService{
+		FindNearbyDriversFunc: func(ctx context.Context, location domain.Location, radius float64) ([]domain.DriverDistancePair, error) {
+			atomic.AddInt32(&calls, 1)
+			<-geofence.entered
+			return []domain.DriverDistancePair{{Driver: domain.Driver{ID: "driver-1"}, Distance: 100}}, nil
+		},
+	}
+
+	service := NewMatchingService(mockSvc)
+	service.SetGeofence(geofence)
+	rider := domain.Rider{ID: "rider-1", Location: domain.Location{Type: "Point", Coordinates: [2]float64{28.90001, 41.00001}}}
+	drifted := domain.Rider{ID: "rider-1", Location: domain.Location{Type: "Point", Coordinates: [2]float64{28.90002, 41.00002}}}
+
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			r := rider
+			if i%2 == 1 {
+				r = drifted
+			}
+			results[i], _ = service.MatchRiderToDriver(context.Background(), r, 500, 0)
+		}(i)
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	for _, result := range results {
+		assert.NotNil(t, result)
+		assert.Equal(t, "driver-1", result.DriverID)
+	}
+}
+
+// TestMatchingService_MatchRiderToDriver_doesNotCoalesceDifferentRiders tests that different riders are never coalesced
+// Expected: Should call the driver location service once per rider
+func TestMatchingService_MatchRiderToDriver_doesNotCoalesceDifferentRiders(t *testing.T) {
+	var calls int32
+	mockSvc := &mockDriverLocationService{
+		FindNearbyDriversFunc: func(ctx context.Context, location domain.Location, radius float64) ([]domain.DriverDistancePair, error) {
+			atomic.AddInt32(&calls, 1)
+			return []domain.DriverDistancePair{{Driver: domain.Driver{ID: "driver-1"}, Distance: 100}}, nil
+		},
+	}
+
+	service := NewMatchingService(mockSvc)
+	location := domain.Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}}
+	_, err := service.MatchRiderToDriver(context.Background(), domain.Rider{ID: "rider-1", Location: location}, 500, 0)
+	assert.NoError(t, err)
+	_, err = service.MatchRiderToDriver(context.Background(), domain.Rider{ID: "rider-2", Location: location}, 500, 0)
+	assert.NoError(t, err)
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+// TestMatchingService_MatchRiderToDriver_strategyRollout tests matching with the candidate strategy rolled out
+// Expected: Should select the driver with the candidate strategy and annotate the result with its variant
+func TestMatchingService_MatchRiderToDriver_strategyRollout(t *testing.T) {
+	mockSvc := &mockDriverLocationService{
+		FindNearbyDriversFunc: func(ctx context.Context, location domain.Location, radius float64) ([]domain.DriverDistancePair, error) {
+			return []domain.DriverDistancePair{
+				{Driver: domain.Driver{ID: "driver-1", UpdatedAt: time.Now().Add(-10 * time.Minute)}, Distance: 100},
+				{Driver: domain.Driver{ID: "driver-2", UpdatedAt: time.Now()}, Distance: 200},
+			}, nil
+		},
+	}
+
+	service := NewMatchingService(mockSvc)
+	rider := domain.Rider{ID: "rider-1", Location: domain.Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}}}
+
+	result, err := service.MatchRiderToDriver(context.Background(), rider, 500, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "driver-1", result.DriverID)
+	assert.Equal(t, StrategyNearest, result.Strategy)
+
+	service.SetStrategyRollout(StrategyRollout{Control: NearestStrategy{}, Candidate: NewETAStrategy(30), Percentage: 100})
+	result, err = service.MatchRiderToDriver(context.Background(), rider, 500, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "driver-2", result.DriverID)
+	assert.Equal(t, StrategyETA, result.Strategy)
+	assert.Equal(t, StrategyETA, service.StrategyFor("rider-1"))
+}
+
+// firstDriverStrategy picks the first candidate, like the matching did before it sorted
+type firstDriverStrategy struct{}
+
+func (firstDriverStrategy) Name() string { return "first" }
+
+func (firstDriverStrategy) Select(rider domain.Rider, drivers []domain.DriverDistancePair) domain.DriverDistancePair {
+	return drivers[0]
+}
+
+// TestMatchingService_MatchRiderToDriver_sortsCandidates tests drivers the upstream returned out of order
+// Expected: Should hand the strategy the drivers nearest first, ties by driver ID, without reordering the upstream slice
+func TestMatchingService_MatchRiderToDriver_sortsCandidates(t *testing.T) {
+	upstream := []domain.DriverDistancePair{
+		{Driver: domain.Driver{ID: "driver-far"}, Distance: 900},
+		{Driver: domain.Driver{ID: "driver-c"}, Distance: 120},
+		{Driver: domain.Driver{ID: "driver-b"}, Distance: 120},
+		{Driver: domain.Driver{ID: "driver-mid"}, Distance: 400},
+	}
+	mockSvc := &mockDriverLocationService{
+		FindNearbyDriversFunc: func(ctx context.Context, location domain.Location, radius float64) ([]domain.DriverDistancePair, error) {
+			return upstream, nil
+		},
+	}
+
+	service := NewMatchingService(mockSvc)
+	service.SetStrategyRollout(StrategyRollout{Control: firstDriverStrategy{}})
+	rider := domain.Rider{ID: "rider-1", Location: domain.Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}}}
+	result, err := service.MatchRiderToDriver(context.Background(), rider, 1000, 0)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "driver-b", result.DriverID)
+	assert.Equal(t, 120.0, result.Distance)
+	assert.Equal(t, "driver-far", upstream[0].Driver.ID)
+}
+
+// TestMatchingService_MatchRiderToDriver_searchLimit tests the number of candidates requested from the driver location service
+// Expected: Should use the default limit when none is given and cap larger ones at the maximum
+func TestMatchingService_MatchRiderToDriver_searchLimit(t *testing.T) {
+	mockSvc := &mockDriverLocationService{
+		FindNearbyDriversFunc: func(ctx context.Context, location domain.Location, radius float64) ([]domain.DriverDistancePair, error) {
+			return []domain.DriverDistancePair{{Driver: domain.Driver{ID: "driver-1"}, Distance: 100}}, nil
+		},
+	}
+
+	service := NewMatchingService(mockSvc)
+	service.SetSearchLimits(SearchLimits{Default: 5, Max: 20})
+	rider := domain.Rider{ID: "rider-1", Location: domain.Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}}}
+
+	for _, tc := range []struct {
+		requested int
+		expected  int
+	}{
+		{requested: 0, expected: 5},
+		{requested: 15, expected: 15},
+		{requested: 100, expected: 20},
+	} {
+		_, err := service.MatchRiderToDriver(context.Background(), rider, 500, tc.requested)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.expected, mockSvc.limit, "requested %d", tc.requested)
+	}
+}
+
+// TestMatchingService_MatchRiderToDriver_preferences tests a rider asking for a vehicle type and capacity
+// Expected: Should pass the rider preferences to the driver location search and only match drivers that fit them
+func TestMatchingService_MatchRiderToDriver_preferences(t *testing.T) {
+	mockSvc := &mockDriverLocationService{
+		FindNearbyDriversFunc: func(ctx context.Context, location domain.Location, radius float64) ([]domain.DriverDistancePair, error) {
+			// an upstream that ignores the filter returns nearer drivers of other classes
+			return []domain.DriverDistancePair{
+				{Driver: domain.Driver{ID: "sedan-1", VehicleType: "sedan", Capacity: 4}, Distance: 50},
+				{Driver: domain.Driver{ID: "van-small", VehicleType: "van", Capacity: 4}, Distance: 80},
+				{Driver: domain.Driver{ID: "driver-1", VehicleType: "van", Capacity: 6}, Distance: 100},
+			}, nil
+		},
+	}
+
+	service := NewMatchingService(mockSvc)
+	rider := domain.Rider{
+		ID:          "rider-1",
+		Location:    domain.Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}},
+		Preferences: domain.RiderPreferences{VehicleType: "van", MinCapacity: 5},
+	}
+	result, err := service.MatchRiderToDriver(context.Background(), rider, 500, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "driver-1", result.DriverID)
+	assert.Equal(t, rider.Preferences, mockSvc.preferences)
+
+	rider.Preferences = domain.RiderPreferences{VehicleType: "motorcycle"}
+	_, err = service.MatchRiderToDriver(context.Background(), rider, 500, 0)
+	assert.ErrorIs(t, err, domain.ErrNoDriversFound)
+}
+
+// TestMatchingService_MatchRiderToDriver_expandsRadius tests a rider without drivers in the requested radius
+// Expected: Should retry with doubled radii up to the maximum and match the first driver found
+func TestMatchingService_MatchRiderToDriver_expandsRadius(t *testing.T) {
+	var radii []float64
+	mockSvc := &mockDriverLocationService{
+		FindNearbyDriversFunc: func(ctx context.Context, location domain.Location, radius float64) ([]domain.DriverDistancePair, error) {
+			radii = append(radii, radius)
+			if radius < 2000 {
+				return nil, nil
+			}
+			return []domain.DriverDistancePair{{Driver: domain.Driver{ID: "driver-far"}, Distance: 1800}}, nil
+		},
+	}
+
+	service := NewMatchingService(mockSvc)
+	service.SetRadiusExpansion(RadiusExpansion{Factor: 2, MaxRadius: 2000})
+	rider := domain.Rider{ID: "rider-1", Location: domain.Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}}}
+	result, err := service.MatchRiderToDriver(context.Background(), rider, 500, 0)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "driver-far", result.DriverID)
+	assert.Equal(t, []float64{500, 1000, 2000}, radii)
+}
+
+// TestMatchingService_MatchRiderToDriver_expansionCapped tests a rider without drivers up to the maximum radius
+// Expected: Should stop at the capped maximum radius and return no drivers found
+func TestMatchingService_MatchRiderToDriver_expansionCapped(t *testing.T) {
+	var radii []float64
+	mockSvc := &mockDriverLocationService{
+		FindNearbyDriversFunc: func(ctx context.Context, location domain.Location, radius float64) ([]domain.DriverDistancePair, error) {
+			radii = append(radii, radius)
+			return nil, nil
+		},
+	}
+
+	service := NewMatchingService(mockSvc)
+	service.SetRadiusExpansion(RadiusExpansion{Factor: 2, MaxRadius: 1500})
+	rider := domain.Rider{ID: "rider-1", Location: domain.Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}}}
+	_, err := service.MatchRiderToDriver(context.Background(), rider, 500, 0)
+
+	assert.ErrorIs(t, err, domain.ErrNoDriversFound)
+	assert.Equal(t, []float64{500, 1000, 1500}, radii)
+
+	radii = nil
+	_, err = service.MatchRiderToDriver(context.Background(), rider, 3000, 0)
+	assert.ErrorIs(t, err, domain.ErrNoDriversFound)
+	assert.Equal(t, []float64{3000}, radii)
+}
+
+// TestMatchingService_MatchRiderToDriver_expansionStopsOnError tests a failing search while expanding the radius
+// Expected: Should return the search error without trying larger radii
+func TestMatchingService_MatchRiderToDriver_expansionStopsOnError(t *testing.T) {
+	calls := 0
+	mockSvc := &mockDriverLocationService{
+		FindNearbyDriversFunc: func(ctx context.Context, location domain.Location, radius float64) ([]domain.DriverDistancePair, error) {
+			calls++
+			if radius > 500 {
+				return nil, errors.New("external service error")
+			}
+			return nil, nil
+		},
+	}
+
+	service := NewMatchingService(mockSvc)
+	service.SetRadiusExpansion(RadiusExpansion{Factor: 2, MaxRadius: 4000})
+	rider := domain.Rider{ID: "rider-1", Location: domain.Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}}}
+	_, err := service.MatchRiderToDriver(context.Background(), rider, 500, 0)
+
+	assert.EqualError(t, err, "external service error")
+	assert.Equal(t, 2, calls)
+}
+
+// TestMatchingService_MatchRiderToDriver_audit tests the audit of a match that expanded its radius
+// Expected: Should record the request, every search attempt and the decision in the audit of the context
+func TestMatchingService_MatchRiderToDriver_audit(t *testing.T) {
+	mockSvc := &mockDriverLocationService{
+		FindNearbyDriversFunc: func(ctx context.Context, location domain.Location, radius float64) ([]domain.DriverDistancePair, error) {
+			if radius < 1000 {
+				return nil, nil
+			}
+			return []domain.DriverDistancePair{
+				{Driver: domain.Driver{ID: "driver-1"}, Distance: 900},
+				{Driver: domain.Driver{ID: "driver-blocked"}, Distance: 100},
+			}, nil
+		},
+	}
+
+	blocklist := newMemoryBlocklist()
+	assert.NoError(t, blocklist.Block(context.Background(), domain.BlockedPair{RiderID: "rider-1", DriverID: "driver-blocked"}))
+
+	service := NewMatchingService(mockSvc)
+	service.SetRadiusExpansion(RadiusExpansion{Factor: 2, MaxRadius: 1000})
+	service.SetBlocklist(blocklist)
+	audit := domain.NewMatchAudit(time.Now())
+	ctx := domain.WithMatchAudit(context.Background(), audit)
+	rider := domain.Rider{ID: "rider-1", Location: domain.Location{Type: "Point", Coordinates: [2]float64{28.9784, 41.0082}}}
+	result, err := service.MatchRiderToDriver(ctx, rider, 500, 0)
+	assert.NoError(t, err)
+
+	event := audit.Finish(200, time.Now())
+	assert.Equal(t, "rider-1", event.UserID)
+	assert.Equal(t, "41.00,28.97", event.Cell)
+	assert.Equal(t, 500.0, event.Radius)
+	assert.Equal(t, DefaultSearchLimit, event.Limit)
+	assert.Equal(t, StrategyNearest, event.Strategy)
+	assert.Equal(t, 2, event.Attempts)
+	assert.Equal(t, 1000.0, event.FinalRadius)
+	assert.Equal(t, 1, event.Candidates)
+	assert.Equal(t, 1, event.Blocked)
+	assert.False(t, event.Coalesced)
+	assert.Equal(t, &domain.MatchDecision{MatchID: result.ID, DriverID: "driver-1", Distance: 900}, event.Decision)
+}
+
+// enteringGeofence serves every location and closes entered once the given
+// number of callers asked
+type enteringGeofence struct {
+	callers int32
+	asked   int32
+	entered chan struct{}
+}
+
+func (g *enteringGeofence) Serves(ctx context.Context, location domain.Location) (bool, error) {
+	if atomic.AddInt32(&g.asked, 1) == g.callers {
+		close(g.entered)
+	}
+	return true, nil
+}
+
+type stubGeofence struct {
+	serves bool
+	err    error
+}
+
+func (g *stubGeofence) Serves(ctx context.Context, location domain.Location) (bool, error) {
+	return g.serves, g.err
+}
+
+// TestMatchingService_MatchRiderToDriver_serviceArea tests riders at (0,0) and outside the geofence
+// Expected: Should reject them without searching and match anyway when the geofence cannot answer
+func TestMatchingService_MatchRiderToDriver_serviceArea(t *testing.T) {
+	searches := 0
+	mockSvc := &mockDriverLocationService{
+		FindNearbyDriversFunc: func(ctx context.Context, location domain.Location, radius float64) ([]domain.DriverDistancePair, error) {
+			searches++
+			return []domain.DriverDistancePair{{Driver: domain.Driver{ID: "driver-1"}, Distance: 100}}, nil
+		},
+	}
+	service := NewMatchingService(mockSvc)
+	rider := domain.Rider{ID: "rider-1", Location: domain.Location{Type: "Point", Coordinates: [2]float64{0, 0}}}
+
+	_, err := service.MatchRiderToDriver(context.Background(), rider, 500, 0)
+	assert.ErrorIs(t, err, domain.ErrOutOfServiceArea)
+
+	rider.Location.Coordinates = [2]float64{28.9, 41.0}
+	service.SetGeofence(&stubGeofence{serves: false})
+	_, err = service.MatchRiderToDriver(context.Background(), rider, 500, 0)
+	assert.ErrorIs(t, err, domain.ErrOutOfServiceArea)
+	assert.Zero(t, searches)
+
+	service.SetGeofence(&stubGeofence{err: errors.New("geofence unavailable")})
+	result, err := service.MatchRiderToDriver(context.Background(), rider, 500, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "driver-1", result.DriverID)
+
+	service.SetGeofence(&stubGeofence{serves: true})
+	_, err = service.MatchRiderToDriver(context.Background(), rider, 500, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, searches)
+}
+
+// TestMatchingService_SetRadiusExpansion_capped tests an expansion configured beyond the largest radius
+// Expected: Should never search the driver location service with a radius above domain.MaxRadius
+func TestMatchingService_SetRadiusExpansion_capped(t *testing.T) {
+	var radii []float64
+	mockSvc := &mockDriverLocationService{
+		FindNearbyDriversFunc: func(ctx context.Context, location domain.Location, radius float64) ([]domain.DriverDistancePair, error) {
+			radii = append(radii, radius)
+			return nil, nil
+		},
+	}
+
+	service := NewMatchingService(mockSvc)
+	service.SetRadiusExpansion(RadiusExpansion{Factor: 4, MaxRadius: 100000})
+	rider := domain.Rider{ID: "rider-1", Location: domain.Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}}}
+	_, err := service.MatchRiderToDriver(context.Background(), rider, 10000, 0)
+
+	assert.ErrorIs(t, err, domain.ErrNoDriversFound)
+	assert.Equal(t, []float64{10000, 40000, domain.MaxRadius}, radii)
+}
+
+// TestMatchingService_FindCandidates_rankedByETA tests listing the drivers a rider can choose from
+// Expected: Should order the candidates by ETA, a near driver with an old location behind fresher ones further away
+func TestMatchingService_FindCandidates_rankedByETA(t *testing.T) {
+	mockSvc := &mockDriverLocationService{
+		FindNearbyDriversFunc: func(ctx context.Context, location domain.Location, radius float64) ([]domain.DriverDistancePair, error) {
+			return []domain.DriverDistancePair{
+				{Driver: domain.Driver{ID: "driver-far"}, Distance: 600},
+				{Driver: domain.Driver{ID: "driver-stale", UpdatedAt: time.Now().Add(-time.Minute)}, Distance: 100},
+				{Driver: domain.Driver{ID: "driver-mid"}, Distance: 300.456},
+			}, nil
+		},
+	}
+
+	service := NewMatchingService(mockSvc)
+	service.SetAverageSpeed(36)
+	rider := domain.Rider{ID: "rider-1", Location: domain.Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}}}
+	candidates, err := service.FindCandidates(context.Background(), rider, 1000, 0)
+
+	assert.NoError(t, err)
+	assert.Len(t, candidates, 3)
+	assert.Equal(t, domain.MatchCandidate{DriverID: "driver-mid", Distance: 300.46, ETASeconds: 31}, candidates[0])
+	assert.Equal(t, domain.MatchCandidate{DriverID: "driver-far", Distance: 600, ETASeconds: 60}, candidates[1])
+	assert.Equal(t, "driver-stale", candidates[2].DriverID)
+	assert.GreaterOrEqual(t, candidates[2].ETASeconds, 70)
+	assert.Equal(t, DefaultSearchLimit, mockSvc.limit)
+}
+
+// TestMatchingService_MatchRiderToDriver_candidates tests the candidates a match was chosen from
+// Expected: Should carry the drivers ranked by ETA next to the chosen driver
+func TestMatchingService_MatchRiderToDriver_candidates(t *testing.T) {
+	mockSvc := &mockDriverLocationService{
+		FindNearbyDriversFunc: func(ctx context.Context, location domain.Location, radius float64) ([]domain.DriverDistancePair, error) {
+			return []domain.DriverDistancePair{
+				{Driver: domain.Driver{ID: "driver-far"}, Distance: 600},
+				{Driver: domain.Driver{ID: "driver-near"}, Distance: 100},
+			}, nil
+		},
+	}
+
+	service := NewMatchingService(mockSvc)
+	service.SetAverageSpeed(36)
+	rider := domain.Rider{ID: "rider-1", Location: domain.Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}}}
+	result, err := service.MatchRiderToDriver(context.Background(), rider, 1000, 0)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "driver-near", result.DriverID)
+	assert.Equal(t, []domain.MatchCandidate{
+		{DriverID: "driver-near", Distance: 100, ETASeconds: 10},
+		{DriverID: "driver-far", Distance: 600, ETASeconds: 60},
+	}, result.Candidates)
+}
+
+// TestMatchingService_FindCandidates_filtersDrivers tests candidates for a rider with blocked drivers and preferences
+// Expected: Should leave out blocked drivers and drivers that do not fit the preferences without storing a match
+func TestMatchingService_FindCandidates_filtersDrivers(t *testing.T) {
+	mockSvc := &mockDriverLocationService{
+		FindNearbyDriversFunc: func(ctx context.Context, location domain.Location, radius float64) ([]domain.DriverDistancePair, error) {
+			return []domain.DriverDistancePair{
+				{Driver: domain.Driver{ID: "driver-blocked", VehicleType: "van"}, Distance: 50},
+				{Driver: domain.Driver{ID: "driver-sedan", VehicleType: "sedan"}, Distance: 80},
+				{Driver: domain.Driver{ID: "driver-van", VehicleType: "van"}, Distance: 100},
+			}, nil
+		},
+	}
+	blocklist := newMemoryBlocklist()
+	assert.NoError(t, blocklist.Block(context.Background(), domain.BlockedPair{RiderID: "rider-1", DriverID: "driver-blocked"}))
+	store := newMemoryMatchStore()
+
+	service := NewMatchingService(mockSvc)
+	service.SetBlocklist(blocklist)
+	service.SetMatchStore(store)
+	rider := domain.Rider{
+		ID:          "rider-1",
+		Location:    domain.Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}},
+		Preferences: domain.RiderPreferences{VehicleType: "van"},
+	}
+	candidates, err := service.FindCandidates(context.Background(), rider, 500, 0)
+
+	assert.NoError(t, err)
+	assert.Len(t, candidates, 1)
+	assert.Equal(t, "driver-van", candidates[0].DriverID)
+	assert.Empty(t, store.matches)
+
+	rider.Preferences = domain.RiderPreferences{VehicleType: "motorcycle"}
+	_, err = service.FindCandidates(context.Background(), rider, 500, 0)
+	assert.ErrorIs(t, err, domain.ErrNoDriversFound)
+
+	rider.Location.Coordinates = [2]float64{0, 0}
+	_, err = service.FindCandidates(context.Background(), rider, 500, 0)
+	assert.ErrorIs(t, err, domain.ErrOutOfServiceArea)
+}
@@ -0,0 +1,198 @@
+package application
+
+import (
+	"cmp"
+	"context"
+	"math"
+	"slices"
+	"time"
+
+	"the-matching-service/internal/domain"
+	"the-matching-service/internal/ports/secondary"
+)
+
+const (
+	StrategyPool = "pool"
+
+	// DefaultPoolMaxDetour is how far in meters the pickup and the destination of
+	// a joining rider may lie from the route of a pooled ride
+	DefaultPoolMaxDetour = 500.0
+
+	earthRadiusMeters = 6371000
+)
+
+// Pooling lets riders that opt in share a driver already carrying a pooling rider.
+// Rides holds the rides in progress and Routes plans the route of a new ride.
+type Pooling struct {
+	Rides     secondary.RideStore
+	Routes    secondary.RouteService
+	MaxDetour float64 // meters
+}
+
+func (p Pooling) enabled() bool {
+	return p.Rides != nil && p.Routes != nil
+}
+
+// poolFit is a pooled ride a rider can join, detour is the distance in meters
+// of the pickup and the destination from the route
+type poolFit struct {
+	driver domain.DriverDistancePair
+	detour float64
+}
+
+// activeRides returns the pooled rides by driver ID. Pooling is a bonus on top of
+// matching: a store that cannot be read is logged and matches go on without it.
+func (s *MatchingService) activeRides(ctx context.Context) map[string]domain.PooledRide {
+	if !s.pooling.enabled() {
+		return nil
+	}
+
+	rides, err := s.pooling.Rides.ActiveRides(ctx)
+	if err != nil {
+		s.logger.Warn(ctx, "failed to read pooled rides, matching without pooling", "error", err)
+		return nil
+	}
+
+	byDriver := make(map[string]domain.PooledRide, len(rides))
+	for _, ride := range rides {
+		byDriver[ride.DriverID] = ride
+	}
+	return byDriver
+}
+
+// joinPooledRide matches the rider with a nearby driver whose ride passes the
+// pickup before the destination, the smallest detour first. It returns nil when
+// no ride fits, the rider is then matched with a driver of their own.
+func (s *MatchingService) joinPooledRide(ctx context.Context, rider domain.Rider, radius float64, limit int, blocked map[string]bool, rides map[string]domain.PooledRide) *domain.MatchResult {
+	if len(rides) == 0 || rider.Destination == nil {
+		return nil
+	}
+
+	drivers, err := s.findDrivers(ctx, rider, radius, limit, blocked)
+	if err != nil {
+		s.logger.Warn(ctx, "failed to search pooled rides", "rider_id", rider.ID, "error", err)
+		return nil
+	}
+
+	var fits []poolFit
+	for _, driver := range drivers {
+		ride, ok := rides[driver.Driver.ID]
+		if !ok || ride.SeatsLeft() == 0 {
+			continue
+		}
+		if detour, ok := s.routeFits(ride.Route, driver.Driver.Location, rider.Location, *rider.Destination); ok {
+			fits = append(fits, poolFit{driver: driver, detour: detour})
+		}
+	}
+	// drivers are sorted by distance already, a stable sort keeps the nearest among equal detours
+	slices.SortStableFunc(fits, func(a, b poolFit) int { return cmp.Compare(a.detour, b.detour) })
+
+	for _, fit := range fits {
+		joined, err := s.pooling.Rides.Join(ctx, fit.driver.Driver.ID, rider.ID)
+		if err != nil {
+			s.logger.Warn(ctx, "failed to join the pooled ride", "rider_id", rider.ID, "driver_id", fit.driver.Driver.ID, "error", err)
+			continue
+		}
+		if joined {
+			return &domain.MatchResult{
+				ID:        newMatchID(),
+				RiderID:   rider.ID,
+				DriverID:  fit.driver.Driver.ID,
+				Distance:  math.Round(fit.driver.Distance*100) / 100,
+				Strategy:  StrategyPool,
+				Pooled:    true,
+				MatchedAt: time.Now().UTC(),
+			}
+		}
+	}
+	return nil
+}
+
+// startPooledRide opens a pooled ride along the trip of a pooling rider matched
+// with a driver of their own, drivers without a free seat are left alone
+func (s *MatchingService) startPooledRide(ctx context.Context, rider domain.Rider, driver domain.Driver) {
+	if !s.pooling.enabled() || !rider.Pool || rider.Destination == nil || driver.Capacity < 2 {
+		return
+	}
+
+	route, err := s.pooling.Routes.Route(ctx, rider.Location, *rider.Destination)
+	if err != nil {
+		s.logger.Warn(ctx, "failed to route the pooled ride", "rider_id", rider.ID, "driver_id", driver.ID, "error", err)
+		return
+	}
+	ride := domain.PooledRide{
+		DriverID:    driver.ID,
+		VehicleType: driver.VehicleType,
+		Capacity:    driver.Capacity,
+		Route:       route,
+		RiderIDs:    []string{rider.ID},
+	}
+	if err := s.pooling.Rides.Start(ctx, ride); err != nil {
+		s.logger.Warn(ctx, "failed to start the pooled ride", "rider_id", rider.ID, "driver_id", driver.ID, "error", err)
+	}
+}
+
+// leavePooledRide frees the seat of the rider on the pooled ride of the driver
+// once their match is declined or cancelled, the driver of a ride without riders
+// is matched alone again. A seat that cannot be freed is kept until the ride expires.
+func (s *MatchingService) leavePooledRide(ctx context.Context, result domain.MatchResult) {
+	if !s.pooling.enabled() {
+		return
+	}
+	if err := s.pooling.Rides.Leave(context.WithoutCancel(ctx), result.DriverID, result.RiderID); err != nil {
+		s.logger.Warn(ctx, "failed to leave the pooled ride", "rider_id", result.RiderID, "driver_id", result.DriverID, "error", err)
+	}
+}
+
+// routeFits reports whether the pickup and the destination both lie within the
+// maximum detour of the route, in the order the driver reaches them
+func (s *MatchingService) routeFits(route []domain.Location, driver, pickup, destination domain.Location) (float64, bool) {
+	pickupOffset, pickupAlong := routeOffset(route, pickup)
+	destinationOffset, destinationAlong := routeOffset(route, destination)
+	_, driverAlong := routeOffset(route, driver)
+
+	if pickupOffset > s.pooling.MaxDetour || destinationOffset > s.pooling.MaxDetour {
+		return 0, false
+	}
+	if driverAlong > pickupAlong || pickupAlong > destinationAlong {
+		return 0, false
+	}
+	return pickupOffset + destinationOffset, true
+}
+
+// routeOffset returns the distance in meters from the location to the nearest
+// point of the route and how far along the route that point is. Segments are
+// short enough to treat as flat around the location.
+func routeOffset(route []domain.Location, location domain.Location) (offset, along float64) {
+	if len(route) == 0 {
+		return math.Inf(1), 0
+	}
+
+	lat := location.Coordinates[1] * math.Pi / 180
+	project := func(l domain.Location) (float64, float64) {
+		x := (l.Coordinates[0] - location.Coordinates[0]) * math.Pi / 180 * math.Cos(lat) * earthRadiusMeters
+		y := (l.Coordinates[1] - location.Coordinates[1]) * math.Pi / 180 * earthRadiusMeters
+		return x, y
+	}
+
+	ax, ay := project(route[0])
+	offset = math.Hypot(ax, ay)
+	walked := 0.0
+	for _, point := range route[1:] {
+		bx, by := project(point)
+		dx, dy := bx-ax, by-ay
+		length := math.Hypot(dx, dy)
+
+		t := 0.0
+		if length > 0 {
+			// the location is the origin, so the nearest point is at -a·d/|d|²
+			t = math.Max(0, math.Min(1, -(ax*dx+ay*dy)/(length*length)))
+		}
+		if d := math.Hypot(ax+t*dx, ay+t*dy); d < offset {
+			offset, along = d, walked+t*length
+		}
+		walked += length
+		ax, ay = bx, by
+	}
+	return offset, along
+}
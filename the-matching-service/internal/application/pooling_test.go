@@ -0,0 +1,164 @@
+package application
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"the-matching-service/internal/adapter/ridestore"
+	"the-matching-service/internal/adapter/routing"
+	"the-matching-service/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func point(lon, lat float64) domain.Location {
+	return domain.Location{Type: "Point", Coordinates: [2]float64{lon, lat}}
+}
+
+func poolingRider(id string, pickup, destination domain.Location) domain.Rider {
+	return domain.Rider{ID: id, Location: pickup, Pool: true, Destination: &destination}
+}
+
+func newPoolingService(drivers *[]domain.DriverDistancePair) *MatchingService {
+	service := NewMatchingService(&mockDriverLocationService{
+		FindNearbyDriversFunc: func(ctx context.Context, location domain.Location, radius float64) ([]domain.DriverDistancePair, error) {
+			return *drivers, nil
+		},
+	})
+	service.SetPooling(Pooling{
+		Rides:  ridestore.NewMemoryRideStore(time.Hour),
+		Routes: routing.StraightLine{},
+	})
+	return service
+}
+
+// TestMatchingService_Pooling_joinsRide tests a pooling rider along the route of a pooled ride
+// Expected: Should match the rider with the driver of the ride instead of a nearer free driver
+func TestMatchingService_Pooling_joinsRide(t *testing.T) {
+	drivers := []domain.DriverDistancePair{
+		{Driver: domain.Driver{ID: "driver-1", Location: point(28.90, 41.0), Capacity: 4}, Distance: 100},
+	}
+	service := newPoolingService(&drivers)
+
+	first, err := service.MatchRiderToDriver(context.Background(), poolingRider("rider-1", point(28.90, 41.0), point(28.95, 41.0)), 500, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "driver-1", first.DriverID)
+	assert.False(t, first.Pooled)
+
+	// the driver picked rider-1 up and drives east, rider-2 waits about 110m off the route
+	drivers = []domain.DriverDistancePair{
+		{Driver: domain.Driver{ID: "driver-2", Location: point(28.921, 41.001), Capacity: 4}, Distance: 50},
+		{Driver: domain.Driver{ID: "driver-1", Location: point(28.905, 41.0), Capacity: 4}, Distance: 1300},
+	}
+	second, err := service.MatchRiderToDriver(context.Background(), poolingRider("rider-2", point(28.92, 41.001), point(28.94, 41.0)), 2000, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "driver-1", second.DriverID)
+	assert.True(t, second.Pooled)
+	assert.Equal(t, StrategyPool, second.Strategy)
+	assert.Equal(t, 1300.0, second.Distance)
+}
+
+// TestMatchingService_Pooling_noFit tests pooling riders the ride cannot take
+// Expected: Should match a free driver when the trip runs against the route, the driver passed the pickup or the detour is too long
+func TestMatchingService_Pooling_noFit(t *testing.T) {
+	for _, tc := range []struct {
+		name           string
+		driverLocation domain.Location
+		pickup         domain.Location
+		destination    domain.Location
+	}{
+		{name: "against the route", driverLocation: point(28.905, 41.0), pickup: point(28.93, 41.0), destination: point(28.91, 41.0)},
+		{name: "pickup passed", driverLocation: point(28.935, 41.0), pickup: point(28.92, 41.0), destination: point(28.94, 41.0)},
+		{name: "detour too long", driverLocation: point(28.905, 41.0), pickup: point(28.92, 41.01), destination: point(28.94, 41.0)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			drivers := []domain.DriverDistancePair{
+				{Driver: domain.Driver{ID: "driver-1", Location: point(28.90, 41.0), Capacity: 4}, Distance: 100},
+			}
+			service := newPoolingService(&drivers)
+			_, err := service.MatchRiderToDriver(context.Background(), poolingRider("rider-1", point(28.90, 41.0), point(28.95, 41.0)), 500, 0)
+			assert.NoError(t, err)
+
+			drivers = []domain.DriverDistancePair{
+				{Driver: domain.Driver{ID: "driver-2", Location: tc.pickup, Capacity: 4}, Distance: 50},
+				{Driver: domain.Driver{ID: "driver-1", Location: tc.driverLocation, Capacity: 4}, Distance: 300},
+			}
+			result, err := service.MatchRiderToDriver(context.Background(), poolingRider("rider-2", tc.pickup, tc.destination), 2000, 0)
+			assert.NoError(t, err)
+			assert.Equal(t, "driver-2", result.DriverID)
+			assert.False(t, result.Pooled)
+		})
+	}
+}
+
+// TestMatchingService_Pooling_seatsAndSoloRiders tests the drivers of pooled rides for other riders
+// Expected: Should never match a driver on a pooled ride alone and stop pooling once the seats are taken
+func TestMatchingService_Pooling_seatsAndSoloRiders(t *testing.T) {
+	drivers := []domain.DriverDistancePair{
+		{Driver: domain.Driver{ID: "driver-1", Location: point(28.90, 41.0), Capacity: 2}, Distance: 100},
+	}
+	service := newPoolingService(&drivers)
+	_, err := service.MatchRiderToDriver(context.Background(), poolingRider("rider-1", point(28.90, 41.0), point(28.95, 41.0)), 500, 0)
+	assert.NoError(t, err)
+
+	drivers = []domain.DriverDistancePair{
+		{Driver: domain.Driver{ID: "driver-1", Location: point(28.905, 41.0), Capacity: 2}, Distance: 40},
+		{Driver: domain.Driver{ID: "driver-2", Location: point(28.92, 41.0), Capacity: 4}, Distance: 60},
+	}
+	solo, err := service.MatchRiderToDriver(context.Background(), domain.Rider{ID: "rider-solo", Location: point(28.92, 41.0)}, 2000, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "driver-2", solo.DriverID)
+
+	pooled, err := service.MatchRiderToDriver(context.Background(), poolingRider("rider-2", point(28.92, 41.0), point(28.94, 41.0)), 2000, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "driver-1", pooled.DriverID)
+	assert.True(t, pooled.Pooled)
+
+	// both seats are taken, the next pooling rider gets a driver of their own
+	full, err := service.MatchRiderToDriver(context.Background(), poolingRider("rider-3", point(28.92, 41.0), point(28.94, 41.0)), 2000, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "driver-2", full.DriverID)
+	assert.False(t, full.Pooled)
+}
+
+// TestMatchingService_Pooling_rejectedRide tests a driver rejecting the match that started a pooled ride
+// Expected: Should end the ride so the driver can be matched with a solo rider again
+func TestMatchingService_Pooling_rejectedRide(t *testing.T) {
+	drivers := []domain.DriverDistancePair{
+		{Driver: domain.Driver{ID: "driver-1", Location: point(28.90, 41.0), Capacity: 4}, Distance: 100},
+	}
+	service := newPoolingService(&drivers)
+	service.SetMatchStore(newMemoryMatchStore())
+	service.SetMatchWorkflow(MatchWorkflow{ProposalTimeout: time.Minute, MaxRematches: 3})
+
+	pooled, err := service.MatchRiderToDriver(context.Background(), poolingRider("rider-1", point(28.90, 41.0), point(28.95, 41.0)), 500, 0)
+	require.NoError(t, err)
+	assert.Len(t, service.activeRides(context.Background()), 1)
+
+	_, err = service.RejectMatch(context.Background(), "driver-1", pooled.ID)
+	require.NoError(t, err)
+	assert.Empty(t, service.activeRides(context.Background()))
+
+	solo, err := service.MatchRiderToDriver(context.Background(), domain.Rider{ID: "rider-solo", Location: point(28.90, 41.0)}, 500, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "driver-1", solo.DriverID)
+}
+
+// TestRouteOffset tests the distance of locations from a route and their position along it
+// Expected: Should measure the distance to the nearest segment and the meters along the route up to it
+func TestRouteOffset(t *testing.T) {
+	route := []domain.Location{point(28.90, 41.0), point(28.92, 41.0), point(28.92, 41.02)}
+
+	offset, along := routeOffset(route, point(28.91, 41.001))
+	assert.InDelta(t, 111, offset, 1)
+	assert.InDelta(t, 839, along, 5)
+
+	offset, along = routeOffset(route, point(28.921, 41.01))
+	assert.InDelta(t, 84, offset, 1)
+	assert.InDelta(t, 1678+1112, along, 10)
+
+	offset, _ = routeOffset(nil, point(28.91, 41.0))
+	assert.True(t, offset > 1e9)
+}
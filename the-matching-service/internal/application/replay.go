@@ -0,0 +1,115 @@
+package application
+
+import (
+	"math"
+	"time"
+
+	"the-matching-service/internal/domain"
+)
+
+// ReplayCount is how many recorded decisions a replay went through and how
+// many of them picked another driver
+type ReplayCount struct {
+	Decisions int `json:"decisions"`
+	Changed   int `json:"changed"`
+}
+
+// ReplayChange is a recorded decision the replayed strategy decided otherwise
+type ReplayChange struct {
+	MatchID          string  `json:"match_id"`
+	RecordedStrategy string  `json:"recorded_strategy"`
+	RecordedDriverID string  `json:"recorded_driver_id"`
+	RecordedDistance float64 `json:"recorded_distance"`
+	ReplayedDriverID string  `json:"replayed_driver_id"`
+	ReplayedDistance float64 `json:"replayed_distance"`
+}
+
+// ReplayReport compares the recorded decisions with the ones the replayed
+// strategy makes on the same candidates. The distances are the means of the
+// picked drivers, in meters.
+type ReplayReport struct {
+	Strategy             string                 `json:"strategy"`
+	Decisions            int                    `json:"decisions"`
+	Changed              int                    `json:"changed"`
+	ChangedRate          float64                `json:"changed_rate"`
+	Skipped              int                    `json:"skipped"` // decisions without candidates
+	RecordedMeanDistance float64                `json:"recorded_mean_distance"`
+	ReplayedMeanDistance float64                `json:"replayed_mean_distance"`
+	ByRecordedStrategy   map[string]ReplayCount `json:"by_recorded_strategy"`
+}
+
+// Replay re-runs recorded decisions against a strategy, in the order they were
+// made and at the time they were made, so the history of least_recently_matched
+// and weighted builds up as it would have in production
+type Replay struct {
+	strategy MatchStrategy
+	now      time.Time
+	report   ReplayReport
+
+	recordedDistance float64
+	replayedDistance float64
+}
+
+// NewReplay builds the strategy to replay by its name, as NewStrategy does
+func NewReplay(name string, options StrategyOptions) (*Replay, error) {
+	replay := &Replay{}
+	options.Now = func() time.Time { return replay.now }
+	strategy, err := NewStrategy(name, options)
+	if err != nil {
+		return nil, err
+	}
+	replay.strategy = strategy
+	replay.report = ReplayReport{Strategy: strategy.Name(), ByRecordedStrategy: make(map[string]ReplayCount)}
+	return replay, nil
+}
+
+// Add replays one decision, it returns the change when the strategy picked
+// another driver than the recorded one
+func (r *Replay) Add(event domain.DecisionEvent) (ReplayChange, bool) {
+	if len(event.Candidates) == 0 {
+		r.report.Skipped++
+		return ReplayChange{}, false
+	}
+	r.now = event.DecidedAt
+	selected := r.strategy.Select(event.Rider, event.Candidates)
+	distance := math.Round(selected.Distance*100) / 100
+
+	r.report.Decisions++
+	r.recordedDistance += event.Distance
+	r.replayedDistance += distance
+	count := r.report.ByRecordedStrategy[event.Strategy]
+	count.Decisions++
+	changed := selected.Driver.ID != event.DriverID
+	if changed {
+		r.report.Changed++
+		count.Changed++
+	}
+	r.report.ByRecordedStrategy[event.Strategy] = count
+	if !changed {
+		return ReplayChange{}, false
+	}
+	return ReplayChange{
+		MatchID:          event.MatchID,
+		RecordedStrategy: event.Strategy,
+		RecordedDriverID: event.DriverID,
+		RecordedDistance: event.Distance,
+		ReplayedDriverID: selected.Driver.ID,
+		ReplayedDistance: distance,
+	}, true
+}
+
+// Report returns the comparison of the decisions replayed so far
+func (r *Replay) Report() ReplayReport {
+	report := r.report
+	report.ByRecordedStrategy = make(map[string]ReplayCount, len(r.report.ByRecordedStrategy))
+	for strategy, count := range r.report.ByRecordedStrategy {
+		report.ByRecordedStrategy[strategy] = count
+	}
+	if report.Decisions > 0 {
+		decisions := float64(report.Decisions)
+		report.ChangedRate = float64(report.Changed) / decisions
+		report.RecordedMeanDistance = math.Round(r.recordedDistance/decisions*100) / 100
+		report.ReplayedMeanDistance = math.Round(r.replayedDistance/decisions*100) / 100
+	}
+	return report
+}
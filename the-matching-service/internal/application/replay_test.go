@@ -0,0 +1,52 @@
+package application
+
+import (
+	"testing"
+	"time"
+
+	"the-matching-service/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestReplay tests replaying recorded decisions against another strategy
+// Expected: Should report the decisions the strategy picks another driver for and the mean distances of both
+func TestReplay(t *testing.T) {
+	decidedAt := time.Date(2026, 10, 15, 9, 30, 0, 0, time.UTC)
+	near := domain.DriverDistancePair{Driver: domain.Driver{ID: "near", UpdatedAt: decidedAt.Add(-10 * time.Minute)}, Distance: 100}
+	far := domain.DriverDistancePair{Driver: domain.Driver{ID: "far", UpdatedAt: decidedAt}, Distance: 400}
+
+	replay, err := NewReplay(StrategyETA, StrategyOptions{AverageSpeedKmh: 36})
+	require.NoError(t, err)
+
+	// 100m take 10s but the location of near is 10 minutes old, far arrives in 40s
+	change, changed := replay.Add(domain.DecisionEvent{MatchID: "m1", Candidates: []domain.DriverDistancePair{near, far}, Strategy: StrategyNearest, DriverID: "near", Distance: 100, DecidedAt: decidedAt})
+	assert.True(t, changed)
+	assert.Equal(t, ReplayChange{MatchID: "m1", RecordedStrategy: StrategyNearest, RecordedDriverID: "near", RecordedDistance: 100, ReplayedDriverID: "far", ReplayedDistance: 400}, change)
+
+	// a decision made when near had just reported is kept
+	_, changed = replay.Add(domain.DecisionEvent{MatchID: "m2", Candidates: []domain.DriverDistancePair{near, far}, Strategy: StrategyNearest, DriverID: "near", Distance: 100, DecidedAt: near.Driver.UpdatedAt})
+	assert.False(t, changed)
+
+	_, changed = replay.Add(domain.DecisionEvent{MatchID: "m3", Strategy: StrategyNearest})
+	assert.False(t, changed)
+
+	assert.Equal(t, ReplayReport{
+		Strategy:             StrategyETA,
+		Decisions:            2,
+		Changed:              1,
+		ChangedRate:          0.5,
+		Skipped:              1,
+		RecordedMeanDistance: 100,
+		ReplayedMeanDistance: 250,
+		ByRecordedStrategy:   map[string]ReplayCount{StrategyNearest: {Decisions: 2, Changed: 1}},
+	}, replay.Report())
+}
+
+// TestNewReplay_unknownStrategy tests replaying against a strategy that does not exist
+// Expected: Should fail as NewStrategy does
+func TestNewReplay_unknownStrategy(t *testing.T) {
+	_, err := NewReplay("fastest", StrategyOptions{})
+	assert.ErrorContains(t, err, `unknown match strategy "fastest"`)
+}
@@ -0,0 +1,341 @@
+package application
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"the-matching-service/internal/domain"
+)
+
+const (
+	StrategyNearest              = "nearest"
+	StrategyETA                  = "eta"
+	StrategyLeastRecentlyMatched = "least_recently_matched"
+	StrategyWeighted             = "weighted"
+)
+
+// DefaultAverageSpeedKmh is the city driving speed ETAs are estimated with
+const DefaultAverageSpeedKmh = 30.0
+
+// MatchStrategy picks one driver out of the nearby drivers, the list is never
+// empty but its order is not guaranteed (e.g. drivers served from the search cache)
+type MatchStrategy interface {
+	Name() string
+	Select(rider domain.Rider, drivers []domain.DriverDistancePair) domain.DriverDistancePair
+}
+
+// NearestStrategy picks the closest driver
+type NearestStrategy struct{}
+
+func (NearestStrategy) Name() string {
+	return StrategyNearest
+}
+
+func (NearestStrategy) Select(rider domain.Rider, drivers []domain.DriverDistancePair) domain.DriverDistancePair {
+	best := drivers[0]
+	for _, driver := range drivers[1:] {
+		if driver.Distance < best.Distance {
+			best = driver
+		}
+	}
+	return best
+}
+
+// MatchHistory remembers when drivers were last matched by this instance, entries
+// older than the window are forgotten so the map does not grow with the fleet
+type MatchHistory struct {
+	window time.Duration
+	now    func() time.Time
+
+	mu       sync.Mutex
+	matched  map[string]time.Time
+	prunedAt time.Time
+}
+
+func NewMatchHistory(window time.Duration) *MatchHistory {
+	if window <= 0 {
+		window = time.Hour
+	}
+	return &MatchHistory{
+		window:  window,
+		now:     time.Now,
+		matched: make(map[string]time.Time),
+	}
+}
+
+// Idle returns how long ago the driver was last matched, capped at the window
+// for drivers that were not matched within it
+func (h *MatchHistory) Idle(driverID string) time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.idle(driverID)
+}
+
+func (h *MatchHistory) Record(driverID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.record(driverID)
+}
+
+// Choose records the driver choose picks from the idle times it looks up. The
+// history stays locked in between, so parallel matches cannot both pick the
+// driver that waited longest.
+func (h *MatchHistory) Choose(choose func(idle func(driverID string) time.Duration) domain.DriverDistancePair) domain.DriverDistancePair {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	chosen := choose(h.idle)
+	h.record(chosen.Driver.ID)
+	return chosen
+}
+
+func (h *MatchHistory) idle(driverID string) time.Duration {
+	matchedAt, ok := h.matched[driverID]
+	if !ok {
+		return h.window
+	}
+	return min(h.now().Sub(matchedAt), h.window)
+}
+
+func (h *MatchHistory) record(driverID string) {
+	now := h.now()
+	h.matched[driverID] = now
+	if now.Sub(h.prunedAt) < h.window {
+		return
+	}
+	for id, matchedAt := range h.matched {
+		if now.Sub(matchedAt) >= h.window {
+			delete(h.matched, id)
+		}
+	}
+	h.prunedAt = now
+}
+
+// LeastRecentlyMatchedStrategy spreads rides over the drivers: it picks the driver
+// that waited longest since its last match, the nearest one among equals
+type LeastRecentlyMatchedStrategy struct {
+	history *MatchHistory
+}
+
+func NewLeastRecentlyMatchedStrategy(history *MatchHistory) *LeastRecentlyMatchedStrategy {
+	return &LeastRecentlyMatchedStrategy{history: history}
+}
+
+func (s *LeastRecentlyMatchedStrategy) Name() string {
+	return StrategyLeastRecentlyMatched
+}
+
+func (s *LeastRecentlyMatchedStrategy) Select(rider domain.Rider, drivers []domain.DriverDistancePair) domain.DriverDistancePair {
+	return s.history.Choose(func(idleFor func(driverID string) time.Duration) domain.DriverDistancePair {
+		best := drivers[0]
+		bestIdle := idleFor(best.Driver.ID)
+		for _, driver := range drivers[1:] {
+			idle := idleFor(driver.Driver.ID)
+			if idle > bestIdle || (idle == bestIdle && driver.Distance < best.Distance) {
+				best, bestIdle = driver, idle
+			}
+		}
+		return best
+	})
+}
+
+// ScoreWeights are the weights of the weighted strategy, a zero weight ignores the factor
+type ScoreWeights struct {
+	Distance  float64
+	Idle      float64
+	Freshness float64
+}
+
+// WeightedStrategy picks the driver with the lowest score. Every factor is scaled
+// to 0..1 before it is weighted: the distance relative to the farthest candidate,
+// the time since the last match relative to the history window (a long wait lowers
+// the score) and the location age relative to StaleAfter.
+type WeightedStrategy struct {
+	Weights    ScoreWeights
+	StaleAfter time.Duration
+	history    *MatchHistory
+	now        func() time.Time
+}
+
+func NewWeightedStrategy(weights ScoreWeights, history *MatchHistory) *WeightedStrategy {
+	return &WeightedStrategy{
+		Weights:    weights,
+		StaleAfter: 2 * time.Minute,
+		history:    history,
+		now:        time.Now,
+	}
+}
+
+func (s *WeightedStrategy) Name() string {
+	return StrategyWeighted
+}
+
+func (s *WeightedStrategy) Select(rider domain.Rider, drivers []domain.DriverDistancePair) domain.DriverDistancePair {
+	farthest := 0.0
+	for _, driver := range drivers {
+		farthest = math.Max(farthest, driver.Distance)
+	}
+
+	return s.history.Choose(func(idleFor func(driverID string) time.Duration) domain.DriverDistancePair {
+		best := drivers[0]
+		bestScore := math.Inf(1)
+		for _, driver := range drivers {
+			if score := s.score(driver, idleFor(driver.Driver.ID), farthest); score < bestScore || (score == bestScore && driver.Distance < best.Distance) {
+				best, bestScore = driver, score
+			}
+		}
+		return best
+	})
+}
+
+func (s *WeightedStrategy) score(driver domain.DriverDistancePair, idle time.Duration, farthest float64) float64 {
+	score := 0.0
+	if farthest > 0 {
+		score += s.Weights.Distance * driver.Distance / farthest
+	}
+
+	score += s.Weights.Idle * (1 - float64(idle)/float64(s.history.window))
+
+	if !driver.Driver.UpdatedAt.IsZero() {
+		age := s.now().Sub(driver.Driver.UpdatedAt)
+		score += s.Weights.Freshness * math.Min(math.Max(float64(age)/float64(s.StaleAfter), 0), 1)
+	}
+	return score
+}
+
+// StrategyOptions configures the strategies NewStrategy can build
+type StrategyOptions struct {
+	AverageSpeedKmh float64
+	History         *MatchHistory
+	Weights         ScoreWeights
+	// Now is the clock of the strategy and its history, time.Now when nil.
+	// Replays run the strategies at the time of the recorded decisions.
+	Now func() time.Time
+}
+
+// NewStrategy builds a strategy by its name
+func NewStrategy(name string, options StrategyOptions) (MatchStrategy, error) {
+	if options.History == nil {
+		options.History = NewMatchHistory(time.Hour)
+	}
+	if options.Now != nil {
+		options.History.now = options.Now
+	}
+
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", StrategyNearest:
+		return NearestStrategy{}, nil
+	case StrategyETA:
+		strategy := NewETAStrategy(options.AverageSpeedKmh)
+		if options.Now != nil {
+			strategy.now = options.Now
+		}
+		return strategy, nil
+	case StrategyLeastRecentlyMatched:
+		return NewLeastRecentlyMatchedStrategy(options.History), nil
+	case StrategyWeighted:
+		strategy := NewWeightedStrategy(options.Weights, options.History)
+		if options.Now != nil {
+			strategy.now = options.Now
+		}
+		return strategy, nil
+	default:
+		return nil, fmt.Errorf("unknown match strategy %q", name)
+	}
+}
+
+// ETAStrategy picks the driver with the lowest estimated time of arrival.
+// Without live traffic data the ETA is the travel time at an average speed plus
+// the age of the driver's last location, a driver that has not reported for a
+// while is probably no longer where we think it is. Drivers driving away from
+// the rider at high speed get a turnaround penalty.
+type ETAStrategy struct {
+	AverageSpeed      float64       // meters per second
+	HighSpeed         float64       // meters per second, moving away faster than this is penalized
+	TurnaroundPenalty time.Duration // added in full for a driver heading straight away
+	now               func() time.Time
+}
+
+func NewETAStrategy(averageSpeedKmh float64) *ETAStrategy {
+	return &ETAStrategy{
+		AverageSpeed:      averageSpeedKmh * 1000 / 3600,
+		HighSpeed:         30 * 1000 / 3600.0,
+		TurnaroundPenalty: 3 * time.Minute,
+		now:               time.Now,
+	}
+}
+
+func (s *ETAStrategy) Name() string {
+	return StrategyETA
+}
+
+func (s *ETAStrategy) Select(rider domain.Rider, drivers []domain.DriverDistancePair) domain.DriverDistancePair {
+	best := drivers[0]
+	bestETA := s.estimate(rider, best)
+	for _, driver := range drivers[1:] {
+		if eta := s.estimate(rider, driver); eta < bestETA {
+			best, bestETA = driver, eta
+		}
+	}
+	return best
+}
+
+func (s *ETAStrategy) estimate(rider domain.Rider, driver domain.DriverDistancePair) time.Duration {
+	eta := time.Duration(driver.Distance / s.AverageSpeed * float64(time.Second))
+	if age := s.now().Sub(driver.Driver.UpdatedAt); !driver.Driver.UpdatedAt.IsZero() && age > 0 {
+		eta += age
+	}
+
+	speed, heading := driver.Driver.Speed, driver.Driver.Heading
+	if speed != nil && heading != nil && *speed >= s.HighSpeed {
+		toRider := bearing(driver.Driver.Location, rider.Location)
+		// 1 when heading straight away from the rider, 0 or less when not moving away
+		away := -math.Cos((*heading - toRider) * math.Pi / 180)
+		if away > 0 {
+			eta += time.Duration(away * float64(s.TurnaroundPenalty))
+		}
+	}
+	return eta
+}
+
+// bearing returns the initial bearing from one location to another in degrees clockwise from north
+func bearing(from, to domain.Location) float64 {
+	lat1 := from.Coordinates[1] * math.Pi / 180
+	lat2 := to.Coordinates[1] * math.Pi / 180
+	dLon := (to.Coordinates[0] - from.Coordinates[0]) * math.Pi / 180
+
+	y := math.Sin(dLon) * math.Cos(lat2)
+	x := math.Cos(lat1)*math.Sin(lat2) - math.Sin(lat1)*math.Cos(lat2)*math.Cos(dLon)
+	return math.Mod(math.Atan2(y, x)*180/math.Pi+360, 360)
+}
+
+// StrategyRollout sends a stable percentage of users to the candidate strategy
+// and everyone else to the control strategy
+type StrategyRollout struct {
+	Control    MatchStrategy
+	Candidate  MatchStrategy
+	Percentage int
+}
+
+// Assign hashes the user ID so a rider always gets the same variant
+func (r StrategyRollout) Assign(userID string) MatchStrategy {
+	if r.Candidate == nil || r.Percentage <= 0 {
+		return r.Control
+	}
+	if r.Percentage >= 100 {
+		return r.Candidate
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(userID))
+	if int(h.Sum32()%100) < r.Percentage {
+		return r.Candidate
+	}
+	return r.Control
+}
@@ -0,0 +1,175 @@
+package application
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"the-matching-service/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestETAStrategy_Select tests picking the driver with the lowest estimated arrival time
+// Expected: Should prefer a slightly farther driver over a closer one with a stale location
+func TestETAStrategy_Select(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	strategy := NewETAStrategy(36) // 10 m/s
+	strategy.now = func() time.Time { return now }
+
+	drivers := []domain.DriverDistancePair{
+		{Driver: domain.Driver{ID: "stale", UpdatedAt: now.Add(-2 * time.Minute)}, Distance: 100},
+		{Driver: domain.Driver{ID: "fresh", UpdatedAt: now.Add(-5 * time.Second)}, Distance: 300},
+	}
+
+	selected := strategy.Select(domain.Rider{ID: "rider-1"}, drivers)
+	assert.Equal(t, "fresh", selected.Driver.ID)
+	assert.Equal(t, StrategyETA, strategy.Name())
+}
+
+// TestETAStrategy_Select_movingAway tests deprioritizing drivers moving away from the rider
+// Expected: Should prefer a farther driver over a closer one driving away at high speed
+func TestETAStrategy_Select_movingAway(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	strategy := NewETAStrategy(36) // 10 m/s
+	strategy.now = func() time.Time { return now }
+
+	rider := domain.Rider{ID: "rider-1", Location: domain.Location{Type: "Point", Coordinates: [2]float64{29.0, 41.0}}}
+	fast, north, south := 20.0, 0.0, 180.0
+	drivers := []domain.DriverDistancePair{
+		// north of the rider, driving further north
+		{Driver: domain.Driver{ID: "leaving", Location: domain.Location{Coordinates: [2]float64{29.0, 41.009}}, UpdatedAt: now, Speed: &fast, Heading: &north}, Distance: 1000},
+		// north of the rider, driving towards it
+		{Driver: domain.Driver{ID: "coming", Location: domain.Location{Coordinates: [2]float64{29.0, 41.013}}, UpdatedAt: now, Speed: &fast, Heading: &south}, Distance: 1450},
+	}
+
+	selected := strategy.Select(rider, drivers)
+	assert.Equal(t, "coming", selected.Driver.ID)
+
+	// a slow driver moving away is not penalized
+	slow := 2.0
+	drivers[0].Driver.Speed = &slow
+	selected = strategy.Select(rider, drivers)
+	assert.Equal(t, "leaving", selected.Driver.ID)
+}
+
+// TestStrategyRollout_Assign tests the percentage based strategy assignment
+// Expected: Should keep a user on the same variant and split users close to the percentage
+func TestStrategyRollout_Assign(t *testing.T) {
+	rollout := StrategyRollout{Control: NearestStrategy{}, Candidate: NewETAStrategy(30), Percentage: 20}
+
+	candidates := 0
+	for i := 0; i < 1000; i++ {
+		userID := fmt.Sprintf("user-%d", i)
+		name := rollout.Assign(userID).Name()
+		assert.Equal(t, name, rollout.Assign(userID).Name())
+		if name == StrategyETA {
+			candidates++
+		}
+	}
+	assert.InDelta(t, 200, candidates, 60)
+
+	assert.Equal(t, StrategyNearest, StrategyRollout{Control: NearestStrategy{}, Candidate: NewETAStrategy(30)}.Assign("user-1").Name())
+	assert.Equal(t, StrategyETA, StrategyRollout{Control: NearestStrategy{}, Candidate: NewETAStrategy(30), Percentage: 100}.Assign("user-1").Name())
+}
+
+// TestNearestStrategy_Select tests picking the closest driver from an unsorted list
+// Expected: Should return the driver with the smallest distance wherever it is in the list
+func TestNearestStrategy_Select(t *testing.T) {
+	drivers := []domain.DriverDistancePair{
+		{Driver: domain.Driver{ID: "far"}, Distance: 900},
+		{Driver: domain.Driver{ID: "near"}, Distance: 100},
+		{Driver: domain.Driver{ID: "middle"}, Distance: 400},
+	}
+
+	selected := NearestStrategy{}.Select(domain.Rider{ID: "rider-1"}, drivers)
+	assert.Equal(t, "near", selected.Driver.ID)
+}
+
+// TestLeastRecentlyMatchedStrategy_Select tests spreading matches over the nearby drivers
+// Expected: Should prefer drivers that were not matched recently and fall back to the nearest among equals
+func TestLeastRecentlyMatchedStrategy_Select(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	history := NewMatchHistory(time.Hour)
+	history.now = func() time.Time { return now }
+	strategy := NewLeastRecentlyMatchedStrategy(history)
+
+	drivers := []domain.DriverDistancePair{
+		{Driver: domain.Driver{ID: "middle"}, Distance: 400},
+		{Driver: domain.Driver{ID: "near"}, Distance: 100},
+		{Driver: domain.Driver{ID: "far"}, Distance: 900},
+	}
+
+	var picked []string
+	for i := 0; i < 4; i++ {
+		picked = append(picked, strategy.Select(domain.Rider{ID: "rider-1"}, drivers).Driver.ID)
+		now = now.Add(time.Minute)
+	}
+	assert.Equal(t, []string{"near", "middle", "far", "near"}, picked)
+	assert.Equal(t, StrategyLeastRecentlyMatched, strategy.Name())
+}
+
+// TestMatchHistory_Prune tests forgetting matches older than the window
+// Expected: Should report old matches as idle for the whole window and drop them from memory
+func TestMatchHistory_Prune(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	history := NewMatchHistory(time.Hour)
+	history.now = func() time.Time { return now }
+
+	history.Record("driver-1")
+	now = now.Add(10 * time.Minute)
+	assert.Equal(t, 10*time.Minute, history.Idle("driver-1"))
+
+	now = now.Add(2 * time.Hour)
+	assert.Equal(t, time.Hour, history.Idle("driver-1"))
+	history.Record("driver-2")
+	assert.Len(t, history.matched, 1)
+}
+
+// TestWeightedStrategy_Select tests scoring drivers by distance, idle time and location age
+// Expected: Should trade a longer distance for a long idle driver and skip drivers with stale locations
+func TestWeightedStrategy_Select(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	history := NewMatchHistory(time.Hour)
+	history.now = func() time.Time { return now }
+	strategy := NewWeightedStrategy(ScoreWeights{Distance: 0.6, Idle: 0.3, Freshness: 0.1}, history)
+	strategy.now = func() time.Time { return now }
+
+	drivers := []domain.DriverDistancePair{
+		{Driver: domain.Driver{ID: "near", UpdatedAt: now}, Distance: 400},
+		{Driver: domain.Driver{ID: "idle", UpdatedAt: now}, Distance: 500},
+	}
+	history.Record("near")
+
+	selected := strategy.Select(domain.Rider{ID: "rider-1"}, drivers)
+	assert.Equal(t, "idle", selected.Driver.ID)
+
+	// distance only scoring keeps the nearest driver
+	strategy.Weights = ScoreWeights{Distance: 1}
+	selected = strategy.Select(domain.Rider{ID: "rider-1"}, drivers)
+	assert.Equal(t, "near", selected.Driver.ID)
+
+	// a stale location outweighs a small distance advantage
+	strategy.Weights = ScoreWeights{Distance: 0.5, Freshness: 0.5}
+	drivers[0].Driver.UpdatedAt = now.Add(-5 * time.Minute)
+	selected = strategy.Select(domain.Rider{ID: "rider-1"}, drivers)
+	assert.Equal(t, "idle", selected.Driver.ID)
+	assert.Equal(t, StrategyWeighted, strategy.Name())
+}
+
+// TestNewStrategy tests building strategies from their configured names
+// Expected: Should build every known strategy, default to nearest and reject unknown names
+func TestNewStrategy(t *testing.T) {
+	for _, name := range []string{StrategyNearest, StrategyETA, StrategyLeastRecentlyMatched, StrategyWeighted} {
+		strategy, err := NewStrategy(name, StrategyOptions{AverageSpeedKmh: 30})
+		assert.NoError(t, err)
+		assert.Equal(t, name, strategy.Name())
+	}
+
+	strategy, err := NewStrategy("", StrategyOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, StrategyNearest, strategy.Name())
+
+	_, err = NewStrategy("random", StrategyOptions{})
+	assert.EqualError(t, err, `unknown match strategy "random"`)
+}
@@ -0,0 +1,50 @@
+// Package buildinfo describes the build of the running binary. The values are
+// set at build time:
+//
+//	go build -ldflags "-X the-matching-service/internal/buildinfo.Version=1.4.0 \
+//	  -X the-matching-service/internal/buildinfo.GitSHA=$(git rev-parse HEAD) \
+//	  -X the-matching-service/internal/buildinfo.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+var (
+	Version   = "dev"
+	GitSHA    = ""
+	BuildTime = ""
+)
+
+// Info is the build of the running binary
+type Info struct {
+	Version   string `json:"version"`
+	GitSHA    string `json:"git_sha"`
+	BuildTime string `json:"build_time"`
+	GoVersion string `json:"go_version"`
+}
+
+// Get returns the build of the running binary. The commit and its time
+// recorded by the go command stand in for a GitSHA and BuildTime not set with
+// -ldflags, "unknown" when neither is known.
+func Get() Info {
+	info := Info{Version: Version, GitSHA: GitSHA, BuildTime: BuildTime, GoVersion: runtime.Version()}
+	if build, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range build.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && info.GitSHA == "":
+				info.GitSHA = setting.Value
+			case setting.Key == "vcs.time" && info.BuildTime == "":
+				info.BuildTime = setting.Value
+			}
+		}
+	}
+	if info.GitSHA == "" {
+		info.GitSHA = "unknown"
+	}
+	if info.BuildTime == "" {
+		info.BuildTime = "unknown"
+	}
+	return info
+}
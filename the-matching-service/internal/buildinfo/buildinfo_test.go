@@ -0,0 +1,28 @@
+package buildinfo
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestGet tests the build information set with -ldflags
+// Expected: Should report the set values and the Go version of the binary
+func TestGet(t *testing.T) {
+	defer func(version, sha, buildTime string) {
+		Version, GitSHA, BuildTime = version, sha, buildTime
+	}(Version, GitSHA, BuildTime)
+	Version, GitSHA, BuildTime = "1.4.0", "0123abc", "2026-10-15T09:30:00Z"
+
+	assert.Equal(t, Info{Version: "1.4.0", GitSHA: "0123abc", BuildTime: "2026-10-15T09:30:00Z", GoVersion: runtime.Version()}, Get())
+}
+
+// TestGet_unset tests the build information of a binary built without -ldflags
+// Expected: Should report the dev version and fall back to the recorded commit or unknown
+func TestGet_unset(t *testing.T) {
+	info := Get()
+	assert.Equal(t, "dev", info.Version)
+	assert.NotEmpty(t, info.GitSHA)
+	assert.NotEmpty(t, info.BuildTime)
+}
@@ -0,0 +1,169 @@
+package domain
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// UpstreamCall is one search of the driver location service made for a match,
+// a cached search never left the service
+type UpstreamCall struct {
+	Operation     string  `json:"operation"`
+	Radius        float64 `json:"radius"`
+	Cached        bool    `json:"cached,omitempty"`
+	DurationMs    float64 `json:"duration_ms"`
+	Status        int     `json:"status,omitempty"`
+	CorrelationID string  `json:"correlation_id,omitempty"`
+	Drivers       int     `json:"drivers"`
+	Skipped       int     `json:"skipped,omitempty"` // invalid drivers left out of the response
+	Error         string  `json:"error,omitempty"`
+}
+
+// MatchDecision is the driver the strategy picked
+type MatchDecision struct {
+	MatchID  string  `json:"match_id"`
+	DriverID string  `json:"driver_id"`
+	Distance float64 `json:"distance"`
+}
+
+// AuditEvent is the structured log event of one match request
+type AuditEvent struct {
+	UserID      string         `json:"user_id,omitempty"`
+	Cell        string         `json:"cell,omitempty"`
+	Area        string         `json:"area,omitempty"` // coarse cell the metrics are labelled with, see MatchArea
+	Radius      float64        `json:"radius"`
+	RadiusBand  string         `json:"radius_band,omitempty"`
+	Limit       int            `json:"limit"`
+	Strategy    string         `json:"strategy,omitempty"`
+	Attempts    int            `json:"attempts"`
+	FinalRadius float64        `json:"final_radius,omitempty"`
+	Candidates  int            `json:"candidates"`
+	Blocked     int            `json:"blocked"`
+	Coalesced   bool           `json:"coalesced,omitempty"`
+	Upstream    []UpstreamCall `json:"upstream"`
+	Decision    *MatchDecision `json:"decision,omitempty"`
+	Outcome     string         `json:"outcome,omitempty"`
+	Error       string         `json:"error,omitempty"`
+	Status      int            `json:"status"`
+	DurationMs  float64        `json:"duration_ms"`
+	StartedAt   time.Time      `json:"started_at"`
+
+	// location is the rider location, Privacy.Audit turns it into the cell
+	// the policy asks for and drops it
+	location *Location
+}
+
+// MatchAudit collects what happened while a match request went through the
+// pipeline, every stage adds what it knows and the HTTP layer logs it once the
+// response is written. A nil audit ignores every call so the stages do not have
+// to check whether the request is audited.
+type MatchAudit struct {
+	mu    sync.Mutex
+	event AuditEvent
+}
+
+func NewMatchAudit(startedAt time.Time) *MatchAudit {
+	return &MatchAudit{event: AuditEvent{StartedAt: startedAt, Upstream: []UpstreamCall{}}}
+}
+
+type matchAuditKey struct{}
+
+// WithMatchAudit returns a context carrying the audit of the request
+func WithMatchAudit(ctx context.Context, audit *MatchAudit) context.Context {
+	return context.WithValue(ctx, matchAuditKey{}, audit)
+}
+
+// MatchAuditFrom returns the audit of the request, nil when it is not audited
+func MatchAuditFrom(ctx context.Context) *MatchAudit {
+	audit, _ := ctx.Value(matchAuditKey{}).(*MatchAudit)
+	return audit
+}
+
+// SetRequest records who asked for a match and where
+func (a *MatchAudit) SetRequest(rider Rider, radius float64, limit int, strategy string) {
+	a.update(func(e *AuditEvent) {
+		e.UserID = rider.ID
+		e.Cell = AuditCell(rider.Location)
+		e.location = &rider.Location
+		e.Area = MatchArea(rider.Location)
+		e.Radius = radius
+		e.RadiusBand = RadiusBand(radius)
+		e.Limit = limit
+		e.Strategy = strategy
+	})
+}
+
+// RecordSearch records a search attempt, the last one is the radius the
+// candidates were found in
+func (a *MatchAudit) RecordSearch(radius float64, candidates, blocked int) {
+	a.update(func(e *AuditEvent) {
+		e.Attempts++
+		e.FinalRadius = radius
+		e.Candidates = candidates
+		e.Blocked = blocked
+	})
+}
+
+func (a *MatchAudit) RecordUpstream(call UpstreamCall) {
+	a.update(func(e *AuditEvent) {
+		e.Upstream = append(e.Upstream, call)
+	})
+}
+
+// SetDecision records the match, coalesced when the request shared the search
+// of an identical request in flight
+func (a *MatchAudit) SetDecision(result *MatchResult, coalesced bool) {
+	a.update(func(e *AuditEvent) {
+		e.Strategy = result.Strategy
+		e.Coalesced = coalesced
+		e.Decision = &MatchDecision{MatchID: result.ID, DriverID: result.DriverID, Distance: result.Distance}
+	})
+}
+
+// SetOutcome records how the request ended, err is nil for a match
+func (a *MatchAudit) SetOutcome(outcome string, err error) {
+	a.update(func(e *AuditEvent) {
+		e.Outcome = outcome
+		if err != nil {
+			e.Error = err.Error()
+		}
+	})
+}
+
+// Finish completes the event with the response status and returns it
+func (a *MatchAudit) Finish(status int, finishedAt time.Time) AuditEvent {
+	if a == nil {
+		return AuditEvent{}
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.event.Status = status
+	a.event.DurationMs = Milliseconds(finishedAt.Sub(a.event.StartedAt))
+	event := a.event
+	event.Upstream = slices.Clone(a.event.Upstream)
+	return event
+}
+
+func (a *MatchAudit) update(apply func(e *AuditEvent)) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	apply(&a.event)
+}
+
+// AuditCell returns the grid cell of a location as "lat,lon" of its south west
+// corner, about 1km: the area is enough to reproduce a search without logging
+// where a rider stands. Privacy.Audit redraws it at the configured precision.
+func AuditCell(location Location) string {
+	return DefaultPrivacy().Cell(location)
+}
+
+// Milliseconds returns d in milliseconds with microsecond precision
+func Milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMatchAudit_Nil tests recording into a context without an audit
+// Expected: Should ignore every call
+func TestMatchAudit_Nil(t *testing.T) {
+	audit := MatchAuditFrom(context.Background())
+	assert.Nil(t, audit)
+
+	audit.SetRequest(Rider{ID: "rider-1"}, 500, 10, "nearest")
+	audit.RecordSearch(500, 1, 0)
+	audit.RecordUpstream(UpstreamCall{Operation: "search"})
+	audit.SetDecision(&MatchResult{ID: "match-1"}, false)
+	audit.SetOutcome("matched", nil)
+	assert.Equal(t, AuditEvent{}, audit.Finish(200, time.Now()))
+}
+
+// TestMatchAudit_Finish tests finishing an audit
+// Expected: Should return the recorded event with the status, the duration and the error
+func TestMatchAudit_Finish(t *testing.T) {
+	start := time.Now()
+	audit := NewMatchAudit(start)
+	ctx := WithMatchAudit(context.Background(), audit)
+
+	MatchAuditFrom(ctx).RecordUpstream(UpstreamCall{Operation: "search", Cached: true})
+	MatchAuditFrom(ctx).SetOutcome("no_drivers", errors.New("no drivers found"))
+	event := audit.Finish(404, start.Add(1500*time.Microsecond))
+
+	assert.Equal(t, 404, event.Status)
+	assert.Equal(t, 1.5, event.DurationMs)
+	assert.Equal(t, "no_drivers", event.Outcome)
+	assert.Equal(t, "no drivers found", event.Error)
+	assert.Len(t, event.Upstream, 1)
+}
+
+// TestAuditCell tests the grid cell logged for a rider location
+// Expected: Should return the south west corner of the 0.01 degree cell
+func TestAuditCell(t *testing.T) {
+	assert.Equal(t, "41.00,28.97", AuditCell(Location{Type: "Point", Coordinates: [2]float64{28.9784, 41.0082}}))
+	assert.Equal(t, "-33.87,151.20", AuditCell(Location{Type: "Point", Coordinates: [2]float64{151.2093, -33.8688}}))
+}
+
+// TestMatchArea tests the coarse area matches are grouped by in the metrics
+// Expected: Should return the geohash of precision 3 of the location
+func TestMatchArea(t *testing.T) {
+	assert.Equal(t, "sxk", MatchArea(Location{Type: "Point", Coordinates: [2]float64{28.9784, 41.0082}}))
+	assert.Equal(t, "r3g", MatchArea(Location{Type: "Point", Coordinates: [2]float64{151.2093, -33.8688}}))
+	assert.Equal(t, "dr5", MatchArea(Location{Type: "Point", Coordinates: [2]float64{-73.9857, 40.7484}}))
+}
+
+// TestRadiusBand tests the band of the search radius in the metrics
+// Expected: Should return the upper bound of the smallest band the radius fits in
+func TestRadiusBand(t *testing.T) {
+	assert.Equal(t, "500", RadiusBand(0.1))
+	assert.Equal(t, "500", RadiusBand(500))
+	assert.Equal(t, "3000", RadiusBand(2500))
+	assert.Equal(t, "50000", RadiusBand(50000))
+	assert.Equal(t, "+Inf", RadiusBand(60000))
+}
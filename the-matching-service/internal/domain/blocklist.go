@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// BlockedPair is a rider and driver that must never be matched, e.g. after a complaint
+type BlockedPair struct {
+	RiderID   string    `json:"rider_id"`
+	DriverID  string    `json:"driver_id"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (p BlockedPair) MarshalJSON() ([]byte, error) {
+	type pair BlockedPair
+	return json.Marshal(struct {
+		pair
+		CreatedAt string `json:"created_at"`
+	}{
+		pair:      pair(p),
+		CreatedAt: FormatTimestamp(p.CreatedAt),
+	})
+}
+
+// BlockDriverRequest blocks a driver for the rider in the path
+// @Description Request to never match a driver with a rider
+type BlockDriverRequest struct {
+	DriverID string `json:"driver_id" validate:"required" example:"driver-123" description:"Driver that must not be matched with the rider"`
+	Reason   string `json:"reason" validate:"max=500" example:"complaint" description:"Why the pair is blocked"`
+}
@@ -0,0 +1,18 @@
+package domain
+
+import "time"
+
+// DecisionEvent is one pick of a match strategy with everything the strategy
+// was given, so the decision can be replayed against another strategy
+type DecisionEvent struct {
+	MatchID    string               `json:"match_id"`
+	Rider      Rider                `json:"rider"`
+	Radius     float64              `json:"radius"`
+	Limit      int                  `json:"limit"`
+	Candidates []DriverDistancePair `json:"candidates"` // the drivers left after the blocklist, as the strategy got them
+	Strategy   string               `json:"strategy"`
+	DriverID   string               `json:"driver_id"`
+	Distance   float64              `json:"distance"`
+	Rematch    bool                 `json:"rematch,omitempty"` // picked after the driver of the match declined
+	DecidedAt  time.Time            `json:"decided_at"`
+}
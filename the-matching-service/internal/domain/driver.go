@@ -1,13 +1,42 @@
 package domain
 
+import (
+	"encoding/json"
+	"time"
+)
+
 type DriverWithDistance struct {
 	Driver   Driver  `json:"driver"`
 	Distance float64 `json:"distance"`
 }
 
 type Driver struct {
-	ID        string   `json:"id"`
-	Location  Location `json:"location"`
-	CreatedAt string   `json:"created_at"`
-	UpdatedAt string   `json:"updated_at"`
+	ID          string            `json:"id" validate:"required"`
+	Location    Location          `json:"location"`
+	CreatedAt   time.Time         `json:"created_at" validate:"required"`
+	UpdatedAt   time.Time         `json:"updated_at" validate:"required,gtefield=CreatedAt"`
+	Speed       *float64          `json:"speed,omitempty"`   // meters per second
+	Heading     *float64          `json:"heading,omitempty"` // degrees clockwise from north
+	VehicleType string            `json:"vehicle_type,omitempty"`
+	Capacity    int               `json:"capacity,omitempty"` // passenger seats, 0 when unknown
+	Attributes  map[string]string `json:"attributes,omitempty"`
+}
+
+// FormatTimestamp is the single timestamp format of the API: RFC3339 in UTC,
+// the same format the driver location service uses
+func FormatTimestamp(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+func (d Driver) MarshalJSON() ([]byte, error) {
+	type driver Driver
+	return json.Marshal(struct {
+		driver
+		CreatedAt string `json:"created_at"`
+		UpdatedAt string `json:"updated_at"`
+	}{
+		driver:    driver(d),
+		CreatedAt: FormatTimestamp(d.CreatedAt),
+		UpdatedAt: FormatTimestamp(d.UpdatedAt),
+	})
 }
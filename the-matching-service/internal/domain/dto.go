@@ -1,32 +1,78 @@
 package domain
 
+import "strings"
+
 // MatchRequest represents a request to match a rider with a nearby driver
 // @Description Request to find a nearby driver for a rider
 type MatchRequest struct {
-	Location Location `json:"location" validate:"required" description:"Rider's current location in GeoJSON format"`
-	Radius   float64  `json:"radius" validate:"required,radius" example:"500" description:"Search radius in meters"`
+	Location    Location  `json:"location" validate:"required" description:"Rider's current location in GeoJSON format"`
+	Radius      float64   `json:"radius" validate:"required,radius" example:"500" minimum:"0.1" maximum:"50000" description:"Search radius in meters, between 0.1 and 50000"`
+	Limit       int       `json:"limit,omitempty" validate:"gte=0" example:"10" description:"Number of nearby drivers to choose from, capped by MATCH_SEARCH_MAX_LIMIT"`
+	VehicleType string    `json:"vehicle_type,omitempty" validate:"omitempty,max=32" example:"sedan" description:"Only match drivers of this vehicle type"`
+	MinCapacity int       `json:"min_capacity,omitempty" validate:"gte=0,lte=100" example:"4" description:"Only match drivers with at least this many seats"`
+	Pool        bool      `json:"pool,omitempty" example:"true" description:"Accept a driver already carrying a pooling rider along the way, requires destination"`
+	Destination *Location `json:"destination,omitempty" validate:"required_if=Pool true" description:"Rider's destination in GeoJSON format, required for pooling"`
+	Wait        bool      `json:"wait,omitempty" example:"true" description:"Wait in the match queue instead of answering 404 when no driver is nearby"`
+
+	IncludeCandidates bool `json:"include_candidates,omitempty" example:"false" description:"Return the ranked candidates the driver was picked from, requires the match:candidates scope"`
 }
 
+// ScopeMatchCandidates lets dispatcher tooling see the candidates of a match to
+// override the automatic pick, riders never get it
+const ScopeMatchCandidates = "match:candidates"
+
+// The scopes and roles the /api/v1 routes are authorized with once
+// JWT_ENFORCE_SCOPES is set: riders request and look up matches, drivers answer
+// them, and a token with the admin role can call the /admin endpoints
+const (
+	ScopeRider  = "rider"
+	ScopeDriver = "driver"
+	RoleAdmin   = "admin"
+)
+
 func (r *MatchRequest) CreateRider(userID string) *Rider {
-	return NewRider(userID, r.Location)
+	rider := NewRider(userID, r.Location)
+	rider.Preferences = RiderPreferences{
+		VehicleType: strings.ToLower(strings.TrimSpace(r.VehicleType)),
+		MinCapacity: r.MinCapacity,
+	}
+	rider.Pool = r.Pool
+	rider.Destination = r.Destination
+	return rider
 }
 
 // MatchResponse represents the response when a driver is successfully matched
 // @Description Response containing matched driver information
 type MatchResponse struct {
+	MatchID  string  `json:"match_id,omitempty" example:"5f1c0f7e9a3b4c2d8e6f7a8b9c0d1e2f" description:"ID to look the match up with GET /api/v1/matches/{id}"`
 	Driver   string  `json:"driver" example:"driver-123" description:"Matched driver ID"`
 	Rider    string  `json:"rider" example:"rider-456" description:"Rider ID"`
 	Distance float64 `json:"distance" example:"250.5" description:"Distance between rider and driver in meters"`
+	Pooled   bool    `json:"pooled,omitempty" example:"false" description:"The driver already carries a pooling rider"`
+	Status   string  `json:"status,omitempty" example:"proposed" description:"proposed until the driver accepts, when drivers answer matches"`
+
+	Candidates []MatchCandidate `json:"candidates,omitempty" description:"With include_candidates, the drivers the match was picked from ordered by ETA, nearest first among equal ETAs"`
 }
 
 func NewMatchResponse(result *MatchResult) *MatchResponse {
 	return &MatchResponse{
+		MatchID:  result.ID,
 		Driver:   result.DriverID,
 		Rider:    result.RiderID,
 		Distance: result.Distance,
+		Pooled:   result.Pooled,
+		Status:   result.Status,
 	}
 }
 
+// CandidatesResponse lists the drivers a rider can choose from
+// @Description Drivers near the rider, the fastest to arrive first
+type CandidatesResponse struct {
+	Rider      string           `json:"rider" example:"rider-456" description:"Rider ID"`
+	Count      int              `json:"count" example:"3" description:"Number of candidates"`
+	Candidates []MatchCandidate `json:"candidates" description:"Candidates ordered by ETA, nearest first among equal ETAs"`
+}
+
 type DriverDistancePair struct {
 	Driver   Driver  `json:"driver"`
 	Distance float64 `json:"distance"`
@@ -22,6 +22,21 @@ func TestMatchRequest_CreateRider(t *testing.T) {
 	assert.Equal(t, req.Location, rider.Location)
 }
 
+// TestMatchRequest_CreateRider_Preferences tests the rider preferences of a match request.
+// Expected: Should copy the vehicle type lower case and the minimum capacity to the rider.
+func TestMatchRequest_CreateRider_Preferences(t *testing.T) {
+	req := &MatchRequest{
+		Location:    Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}},
+		Radius:      500,
+		VehicleType: " SUV",
+		MinCapacity: 6,
+	}
+
+	rider := req.CreateRider("user-123")
+
+	assert.Equal(t, RiderPreferences{VehicleType: "suv", MinCapacity: 6}, rider.Preferences)
+}
+
 // TestNewMatchResponse tests the NewMatchResponse function.
 // Expected: Should create a MatchResponse with correct driver, rider, and distance.
 func TestNewMatchResponse(t *testing.T) {
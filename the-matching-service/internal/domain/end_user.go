@@ -0,0 +1,17 @@
+package domain
+
+import "context"
+
+type endUserKey struct{}
+
+// WithEndUser returns a context carrying the subject of the JWT the request was
+// made with, calls to the driver location service made with it send its hash
+func WithEndUser(ctx context.Context, subject string) context.Context {
+	return context.WithValue(ctx, endUserKey{}, subject)
+}
+
+// EndUserFrom returns the subject the context carries, empty outside of an authenticated request
+func EndUserFrom(ctx context.Context) string {
+	subject, _ := ctx.Value(endUserKey{}).(string)
+	return subject
+}
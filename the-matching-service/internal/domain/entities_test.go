@@ -3,6 +3,7 @@ package domain
 import (
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -67,14 +68,38 @@ func TestDriver_JSONTags(t *testing.T) {
 	driver := &Driver{
 		ID:        "driver-123",
 		Location:  Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}},
-		CreatedAt: "2023-01-01T00:00:00Z",
-		UpdatedAt: "2023-01-01T00:00:00Z",
+		CreatedAt: time.Date(2023, 1, 1, 3, 0, 0, 0, time.FixedZone("TRT", 3*60*60)),
+		UpdatedAt: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
 	}
 
 	data, err := json.Marshal(driver)
 	assert.NoError(t, err)
 	assert.Contains(t, string(data), "driver-123")
-	assert.Contains(t, string(data), "2023-01-01T00:00:00Z")
+	assert.Contains(t, string(data), `"created_at":"2023-01-01T00:00:00Z"`)
+	assert.Contains(t, string(data), `"updated_at":"2023-01-01T00:00:00Z"`)
+}
+
+// TestDriver_UnmarshalTimestamps tests JSON unmarshaling of Driver timestamps.
+// Expected: Should parse RFC3339 timestamps and reject ad-hoc formats.
+func TestDriver_UnmarshalTimestamps(t *testing.T) {
+	var driver Driver
+	err := json.Unmarshal([]byte(`{"id":"driver-1","created_at":"2023-01-01T00:00:00Z","updated_at":"2023-01-01T03:30:00+03:00"}`), &driver)
+	assert.NoError(t, err)
+	assert.True(t, driver.UpdatedAt.Equal(time.Date(2023, 1, 1, 0, 30, 0, 0, time.UTC)))
+
+	err = json.Unmarshal([]byte(`{"id":"driver-1","created_at":"01/01/2023 10:00"}`), &driver)
+	assert.Error(t, err)
+}
+
+// TestDriver_ValidateTimestamps tests validation of Driver timestamps.
+// Expected: Should reject missing timestamps and an update before the creation.
+func TestDriver_ValidateTimestamps(t *testing.T) {
+	now := time.Now()
+	location := Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}}
+
+	assert.NoError(t, ValidateStruct(&Driver{ID: "driver-1", Location: location, CreatedAt: now, UpdatedAt: now}))
+	assert.Error(t, ValidateStruct(&Driver{ID: "driver-1", Location: location}))
+	assert.Error(t, ValidateStruct(&Driver{ID: "driver-1", Location: location, CreatedAt: now, UpdatedAt: now.Add(-time.Minute)}))
 }
 
 // TestDriverWithDistance_JSONTags tests JSON marshaling of DriverWithDistance.
@@ -189,3 +214,16 @@ func TestRider_Equality(t *testing.T) {
 
 	assert.NotEqual(t, rider1.ID, rider3.ID)
 }
+
+// TestRiderPreferences_Accepts tests which drivers fit the preferences of a rider
+// Expected: Should accept every driver without preferences and compare vehicle types without case
+func TestRiderPreferences_Accepts(t *testing.T) {
+	van := Driver{ID: "d1", VehicleType: "van", Capacity: 6}
+
+	assert.True(t, RiderPreferences{}.Accepts(van))
+	assert.True(t, RiderPreferences{}.Accepts(Driver{ID: "d2"}))
+	assert.True(t, RiderPreferences{VehicleType: "VAN", MinCapacity: 6}.Accepts(van))
+	assert.False(t, RiderPreferences{VehicleType: "sedan"}.Accepts(van))
+	assert.False(t, RiderPreferences{MinCapacity: 7}.Accepts(van))
+	assert.False(t, RiderPreferences{VehicleType: "van"}.Accepts(Driver{ID: "d2"}))
+}
@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNoDriversFound is returned when the search around the rider is empty
+var ErrNoDriversFound = errors.New("no drivers found")
+
+// ErrOutOfServiceArea is returned for riders at (0,0), the position of clients
+// without a fix, or outside the configured service area; searching there could
+// only end in no drivers found
+var ErrOutOfServiceArea = errors.New("location is outside the service area")
+
+// ErrMatchNotFound is returned for match IDs that are unknown, expired or belong to another rider
+var ErrMatchNotFound = errors.New("match not found")
+
+// ErrMatchNotProposed is returned when a driver answers a match that was accepted,
+// rejected or timed out already
+var ErrMatchNotProposed = errors.New("match is not awaiting an answer")
+
+// ErrMatchNotActive is returned when a match is completed or cancelled before it
+// was accepted, or after it ended
+var ErrMatchNotActive = errors.New("match is not in progress")
+
+// UpstreamErrorKind classifies failures of the driver location service
+type UpstreamErrorKind string
+
+const (
+	// UpstreamUnavailable means the service could not be reached, answered with a 5xx
+	// or a 429, or the circuit breaker is open
+	UpstreamUnavailable UpstreamErrorKind = "upstream_unavailable"
+	// UpstreamTimeout means the call exceeded its deadline
+	UpstreamTimeout UpstreamErrorKind = "upstream_timeout"
+	// UpstreamValidation means the service rejected the request we built
+	UpstreamValidation UpstreamErrorKind = "upstream_validation"
+	// UpstreamAuth means the service refused the API key or the signature of the
+	// matching service, every call fails the same way until it is fixed
+	UpstreamAuth UpstreamErrorKind = "upstream_auth"
+)
+
+// UpstreamError is returned by the driver location client so the handler can
+// pick a status code without parsing error strings
+type UpstreamError struct {
+	Kind          UpstreamErrorKind
+	StatusCode    int    // upstream status code, 0 when no response was received
+	CorrelationID string // request ID shared with the driver location service
+	Err           error
+}
+
+func NewUpstreamError(kind UpstreamErrorKind, statusCode int, correlationID string, err error) *UpstreamError {
+	return &UpstreamError{
+		Kind:          kind,
+		StatusCode:    statusCode,
+		CorrelationID: correlationID,
+		Err:           err,
+	}
+}
+
+func (e *UpstreamError) Error() string {
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("%s (status %d): %v", e.Kind, e.StatusCode, e.Err)
+	}
+	return fmt.Sprintf("%s: %v", e.Kind, e.Err)
+}
+
+func (e *UpstreamError) Unwrap() error {
+	return e.Err
+}
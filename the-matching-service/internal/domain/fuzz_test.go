@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+// FuzzMatchRequest_UnmarshalJSON feeds arbitrary locations to the match request of the riders
+// Expected: Should never panic and only accept the coordinates the rider sent, finite and in range
+func FuzzMatchRequest_UnmarshalJSON(f *testing.F) {
+	for _, seed := range []string{
+		`{"type": "Point", "coordinates": [28.9, 41.0]}`,
+		`{"type": "Point", "coordinates": [-180, -90]}`,
+		`{"type": "Point", "coordinates": [180.5, 41.0]}`,
+		`{"type": "Point", "coordinates": [28.9]}`,
+		`{"type": "Point", "coordinates": []}`,
+		`{"type": "Point", "coordinates": [28.9, 41.0, 100]}`,
+		`{"type": "Point"}`,
+		`{"type": "Point", "coordinates": null}`,
+		`{"type": "LineString", "coordinates": [28.9, 41.0]}`,
+		`null`,
+	} {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, location []byte) {
+		body := append(append([]byte(`{"radius": 500, "location": `), location...), '}')
+		var req MatchRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			return
+		}
+		if err := ValidateStruct(&req); err != nil {
+			return
+		}
+
+		longitude, latitude := req.Location.Coordinates[0], req.Location.Coordinates[1]
+		if !(longitude >= -180 && longitude <= 180 && latitude >= -90 && latitude <= 90) {
+			t.Fatalf("accepted location out of range: %v", req.Location.Coordinates)
+		}
+
+		var sent struct {
+			Location struct {
+				Coordinates []float64 `json:"coordinates"`
+			} `json:"location"`
+		}
+		if err := json.Unmarshal(body, &sent); err == nil && sent.Location.Coordinates != nil && len(sent.Location.Coordinates) != 2 {
+			t.Fatalf("accepted %d coordinates as %v", len(sent.Location.Coordinates), req.Location.Coordinates)
+		}
+	})
+}
+
+// FuzzValidateCoordinates checks the coordinates validation with arbitrary positions
+// Expected: Should accept exactly the finite positions in range
+func FuzzValidateCoordinates(f *testing.F) {
+	f.Add(28.9, 41.0)
+	f.Add(-180.0, 90.0)
+	f.Add(180.0001, 0.0)
+	f.Add(math.NaN(), 41.0)
+	f.Add(28.9, math.Inf(1))
+
+	f.Fuzz(func(t *testing.T, longitude, latitude float64) {
+		location := Location{Type: "Point", Coordinates: [2]float64{longitude, latitude}}
+		inRange := longitude >= -180 && longitude <= 180 && latitude >= -90 && latitude <= 90
+		if valid := ValidateStruct(&location) == nil; valid != inRange {
+			t.Fatalf("validation of %v is %t", location.Coordinates, valid)
+		}
+	})
+}
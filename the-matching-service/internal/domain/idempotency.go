@@ -0,0 +1,24 @@
+package domain
+
+import "errors"
+
+// MaxIdempotencyKeyLength bounds the Idempotency-Key header, clients send UUIDs
+const MaxIdempotencyKeyLength = 255
+
+// ErrIdempotencyKeyReused is returned when a rider sends an idempotency key again
+// with a different match request
+var ErrIdempotencyKeyReused = errors.New("idempotency key was used with another request")
+
+// ErrIdempotencyKeyInFlight is returned while the first request with the key is
+// still matching
+var ErrIdempotencyKeyInFlight = errors.New("a request with the idempotency key is in progress")
+
+// IdempotencyRecord is what is kept for an idempotency key: the fingerprint of
+// the request that claimed it and its match once that request succeeded. The
+// candidates of the match are kept next to it since MatchResult does not
+// encode them, a replay returns the same body as the first response.
+type IdempotencyRecord struct {
+	Fingerprint string           `json:"fingerprint"`
+	Result      *MatchResult     `json:"result,omitempty"`
+	Candidates  []MatchCandidate `json:"candidates,omitempty"`
+}
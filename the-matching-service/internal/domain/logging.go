@@ -0,0 +1,20 @@
+package domain
+
+import "context"
+
+type logFieldsKey struct{}
+
+// WithLogFields returns a context carrying the key value pairs in addition to
+// the ones ctx already carries, every entry logged with it is written with them
+func WithLogFields(ctx context.Context, keysAndValues ...any) context.Context {
+	fields := LogFields(ctx)
+	merged := make([]any, 0, len(fields)+len(keysAndValues))
+	merged = append(append(merged, fields...), keysAndValues...)
+	return context.WithValue(ctx, logFieldsKey{}, merged)
+}
+
+// LogFields returns the key value pairs the context carries for log entries
+func LogFields(ctx context.Context) []any {
+	fields, _ := ctx.Value(logFieldsKey{}).([]any)
+	return fields
+}
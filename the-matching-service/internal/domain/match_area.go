@@ -0,0 +1,58 @@
+package domain
+
+import "strconv"
+
+// matchAreaPrecision is the geohash precision matches are grouped by in the
+// metrics, cells of about 156km x 156km: a service area spans a few of them,
+// few enough to label a metric with and too coarse to tell where a rider is
+const matchAreaPrecision = 3
+
+const geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// radiusBands are the upper bounds in meters of the search radius bands,
+// the last one is the largest radius a match accepts
+var radiusBands = []float64{500, 1000, 3000, 5000, 10000, 50000}
+
+// MatchArea returns the coarse geohash cell of a location, e.g. "sxk" for Istanbul
+func MatchArea(location Location) string {
+	return geohash(location.Coordinates[1], location.Coordinates[0], matchAreaPrecision)
+}
+
+// RadiusBand returns the smallest band the radius fits in as its upper
+// bound in meters, e.g. "3000" for 2500
+func RadiusBand(radius float64) string {
+	for _, bound := range radiusBands {
+		if radius <= bound {
+			return strconv.FormatFloat(bound, 'f', -1, 64)
+		}
+	}
+	return "+Inf"
+}
+
+// geohash encodes a location, https://en.wikipedia.org/wiki/Geohash
+func geohash(lat, lon float64, precision int) string {
+	latRange, lonRange := [2]float64{-90, 90}, [2]float64{-180, 180}
+	hash := make([]byte, 0, precision)
+	var bits, ch int
+	even := true // even bits split the longitude
+	for len(hash) < precision {
+		value, span := lat, &latRange
+		if even {
+			value, span = lon, &lonRange
+		}
+		mid := (span[0] + span[1]) / 2
+		ch <<= 1
+		if value >= mid {
+			ch |= 1
+			span[0] = mid
+		} else {
+			span[1] = mid
+		}
+		even = !even
+		if bits++; bits == 5 {
+			hash = append(hash, geohashAlphabet[ch])
+			bits, ch = 0, 0
+		}
+	}
+	return string(hash)
+}
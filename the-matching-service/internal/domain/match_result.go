@@ -1,7 +1,106 @@
 package domain
 
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	DefaultMatchPageSize = 20
+	MaxMatchPageSize     = 100
+)
+
+// Statuses of a match the driver has to answer: a proposal is accepted by the
+// driver, or rejected or expired and then proposed to the next driver while
+// re-matches are left. An accepted match ends completed or cancelled.
+const (
+	MatchProposed  = "proposed"
+	MatchAccepted  = "accepted"
+	MatchRejected  = "rejected"
+	MatchExpired   = "expired"
+	MatchCompleted = "completed"
+	MatchCancelled = "cancelled"
+)
+
+// Who cancelled a match
+const (
+	CancelledByRider  = "rider"
+	CancelledByDriver = "driver"
+)
+
+// MatchOutcome is what became of a match of the driver, reported to the supply
+// side to compute the driver's quality score: accepted, completed or cancelled
+// by the driver
+type MatchOutcome struct {
+	MatchID  string `json:"match_id"`
+	DriverID string `json:"-"`
+	Outcome  string `json:"outcome"`
+}
+
 type MatchResult struct {
-	RiderID  string  `json:"rider_id"`
-	DriverID string  `json:"driver_id"`
-	Distance float64 `json:"distance"` //meters
+	ID        string    `json:"id"`
+	RiderID   string    `json:"rider_id"`
+	DriverID  string    `json:"driver_id"`
+	Distance  float64   `json:"distance"` //meters
+	Strategy  string    `json:"strategy"` // matching strategy variant that picked the driver
+	Pooled    bool      `json:"pooled,omitempty"`
+	MatchedAt time.Time `json:"matched_at"`
+
+	// set when drivers answer matches, empty otherwise
+	Status          string       `json:"status,omitempty"`
+	RespondBy       time.Time    `json:"respond_by,omitempty"`
+	DeclinedDrivers []string     `json:"declined_drivers,omitempty"`
+	Rematches       int          `json:"rematches,omitempty"`
+	CancelledBy     string       `json:"cancelled_by,omitempty"` // rider or driver
+	Search          *MatchSearch `json:"search,omitempty"`
+
+	// the drivers the match was picked from, ranked like FindCandidates ranks
+	// them; not stored, only the response of the match request shows them
+	Candidates []MatchCandidate `json:"-"`
+}
+
+// MatchSearch is the search a match came from, kept to re-match the rider when
+// the driver does not accept
+type MatchSearch struct {
+	Location    Location         `json:"location"`
+	Radius      float64          `json:"radius"`
+	Limit       int              `json:"limit"`
+	Preferences RiderPreferences `json:"preferences"`
+}
+
+// Overdue reports whether the driver let the proposal time out
+func (r MatchResult) Overdue(now time.Time) bool {
+	return r.Status == MatchProposed && !now.Before(r.RespondBy)
+}
+
+func (r MatchResult) MarshalJSON() ([]byte, error) {
+	type result MatchResult
+	var respondBy string
+	if !r.RespondBy.IsZero() {
+		respondBy = FormatTimestamp(r.RespondBy)
+	}
+	return json.Marshal(struct {
+		result
+		MatchedAt string `json:"matched_at"`
+		RespondBy string `json:"respond_by,omitempty"`
+	}{
+		result:    result(r),
+		MatchedAt: FormatTimestamp(r.MatchedAt),
+		RespondBy: respondBy,
+	})
+}
+
+// MatchCandidate is a driver offered to a rider choosing between options
+type MatchCandidate struct {
+	DriverID   string  `json:"driver_id" example:"driver-123"`
+	Distance   float64 `json:"distance" example:"250.5" description:"Distance between rider and driver in meters"`
+	ETASeconds int     `json:"eta_seconds" example:"45" description:"Estimated seconds until the driver arrives"`
+}
+
+// MatchPage is a page of the matches of a rider, the most recent first
+type MatchPage struct {
+	Matches []MatchResult `json:"matches"`
+	Total   int64         `json:"total" example:"42" description:"Stored matches of the rider"`
+	Limit   int           `json:"limit" example:"20"`
+	Offset  int           `json:"offset" example:"0"`
 }
@@ -0,0 +1,20 @@
+package domain
+
+import "time"
+
+// PooledRide is a driver carrying riders that opted into pooling along the route
+// of the first rider's trip, a pooling rider whose pickup and destination lie
+// near the route can join while seats are left
+type PooledRide struct {
+	DriverID    string     `json:"driver_id"`
+	VehicleType string     `json:"vehicle_type,omitempty"`
+	Capacity    int        `json:"capacity"` // passenger seats of the driver
+	Route       []Location `json:"route"`
+	RiderIDs    []string   `json:"rider_ids"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+}
+
+// SeatsLeft returns the seats not taken by a rider of the ride
+func (r PooledRide) SeatsLeft() int {
+	return max(r.Capacity-len(r.RiderIDs), 0)
+}
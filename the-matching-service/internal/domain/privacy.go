@@ -0,0 +1,118 @@
+package domain
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+)
+
+// riderIDLogKeys are the log fields that carry the ID of a rider
+var riderIDLogKeys = map[string]bool{"user_id": true, "rider_id": true}
+
+// Privacy is how much of a rider the service writes down. Rider coordinates in
+// the logs and the match audits are truncated to CoordinateDecimals, 2 is about
+// 1km, and with HashRiderIDs the rider IDs are replaced with the same hash the
+// driver location service gets in X-End-User-Hash, so the entries of a rider
+// can still be followed across both services without naming them.
+type Privacy struct {
+	CoordinateDecimals int
+	HashRiderIDs       bool
+	HashKey            string // keys the hash, plain SHA-256 when empty
+}
+
+// DefaultPrivacy truncates coordinates to the cells the match audit always used
+// and keeps rider IDs as they are
+func DefaultPrivacy() Privacy {
+	return Privacy{CoordinateDecimals: 2}
+}
+
+// Coordinate truncates a latitude or longitude down to CoordinateDecimals
+func (p Privacy) Coordinate(degrees float64) float64 {
+	scale := math.Pow10(p.CoordinateDecimals)
+	return math.Floor(degrees*scale) / scale
+}
+
+// Location returns the location truncated to CoordinateDecimals
+func (p Privacy) Location(location Location) Location {
+	return Location{
+		Type:        location.Type,
+		Coordinates: [2]float64{p.Coordinate(location.Coordinates[0]), p.Coordinate(location.Coordinates[1])},
+	}
+}
+
+// Cell returns the cell of a location as "lat,lon" of its south west corner
+func (p Privacy) Cell(location Location) string {
+	return fmt.Sprintf("%.*f,%.*f",
+		p.CoordinateDecimals, p.Coordinate(location.Coordinates[1]),
+		p.CoordinateDecimals, p.Coordinate(location.Coordinates[0]))
+}
+
+// RiderID returns the ID to write down for a rider, its hash with HashRiderIDs
+func (p Privacy) RiderID(id string) string {
+	if !p.HashRiderIDs || id == "" {
+		return id
+	}
+	return HashEndUser(p.HashKey, id)
+}
+
+// Audit applies the policy to a match audit event
+func (p Privacy) Audit(event AuditEvent) AuditEvent {
+	event.UserID = p.RiderID(event.UserID)
+	if event.location != nil {
+		event.Cell = p.Cell(*event.location)
+	}
+	event.location = nil
+	return event
+}
+
+// LogFields applies the policy to the fields of a log entry: the user_id and
+// rider_id values are hashed and locations are truncated, whichever call site
+// wrote them. keysAndValues is not modified.
+func (p Privacy) LogFields(keysAndValues []any) []any {
+	var redacted []any
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		value, changed := p.logValue(keysAndValues[i], keysAndValues[i+1])
+		if !changed {
+			continue
+		}
+		if redacted == nil {
+			redacted = append([]any(nil), keysAndValues...)
+		}
+		redacted[i+1] = value
+	}
+	if redacted == nil {
+		return keysAndValues
+	}
+	return redacted
+}
+
+func (p Privacy) logValue(key, value any) (any, bool) {
+	switch v := value.(type) {
+	case string:
+		if name, ok := key.(string); ok && riderIDLogKeys[name] && p.HashRiderIDs {
+			return p.RiderID(v), true
+		}
+	case Location:
+		return p.Location(v), true
+	case *Location:
+		if v != nil {
+			location := p.Location(*v)
+			return &location, true
+		}
+	}
+	return value, false
+}
+
+// HashEndUser returns the hex HMAC-SHA256 of a rider subject with key, or its
+// SHA-256 when key is empty
+func HashEndUser(key, subject string) string {
+	if key == "" {
+		sum := sha256.Sum256([]byte(subject))
+		return hex.EncodeToString(sum[:])
+	}
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(subject))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPrivacy_Audit tests applying the privacy policy to a match audit event
+// Expected: Should redraw the cell at the configured decimals and hash the rider ID with the key
+func TestPrivacy_Audit(t *testing.T) {
+	audit := NewMatchAudit(time.Now())
+	audit.SetRequest(Rider{ID: "rider-1", Location: Location{Type: "Point", Coordinates: [2]float64{28.9784, 41.0082}}}, 500, 10, "nearest")
+	event := audit.Finish(200, time.Now())
+
+	assert.Equal(t, "41.00,28.97", DefaultPrivacy().Audit(event).Cell)
+	assert.Equal(t, "rider-1", DefaultPrivacy().Audit(event).UserID)
+
+	redacted := Privacy{CoordinateDecimals: 1, HashRiderIDs: true, HashKey: "secret"}.Audit(event)
+	assert.Equal(t, "41.0,28.9", redacted.Cell)
+	assert.Equal(t, HashEndUser("secret", "rider-1"), redacted.UserID)
+	assert.Len(t, redacted.UserID, 64)
+	assert.Equal(t, "sxk", redacted.Area)
+}
+
+// TestPrivacy_LogFields tests applying the privacy policy to the fields of a log entry
+// Expected: Should hash the rider ID fields, truncate locations and leave the other fields and the input alone
+func TestPrivacy_LogFields(t *testing.T) {
+	location := Location{Type: "Point", Coordinates: [2]float64{28.9784, 41.0082}}
+	fields := []any{"user_id", "rider-1", "rider_id", "rider-2", "match_id", "match-1", "location", location, "pickup", &location, "drivers", []string{"d1"}}
+	privacy := Privacy{CoordinateDecimals: 2, HashRiderIDs: true}
+
+	redacted := privacy.LogFields(fields)
+	assert.Equal(t, HashEndUser("", "rider-1"), redacted[1])
+	assert.Equal(t, HashEndUser("", "rider-2"), redacted[3])
+	assert.Equal(t, "match-1", redacted[5])
+	assert.Equal(t, Location{Type: "Point", Coordinates: [2]float64{28.97, 41}}, redacted[7])
+	assert.Equal(t, &Location{Type: "Point", Coordinates: [2]float64{28.97, 41}}, redacted[9])
+	assert.Equal(t, []string{"d1"}, redacted[11])
+	assert.Equal(t, "rider-1", fields[1])
+	assert.Equal(t, location, fields[7])
+
+	assert.Equal(t, "rider-1", DefaultPrivacy().LogFields(fields)[1])
+}
@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Statuses of a rider waiting for a driver
+const (
+	QueueWaiting = "waiting"
+	QueueMatched = "matched"
+	QueueExpired = "expired"
+)
+
+// ErrQueueEntryNotFound is returned for queue IDs that are unknown, finished long
+// ago or belong to another rider
+var ErrQueueEntryNotFound = errors.New("queue entry not found")
+
+// QueueEntry is a rider waiting for a driver to become available nearby. Position
+// counts the riders waiting in an overlapping area that are matched first, 1 for
+// the next one.
+type QueueEntry struct {
+	ID         string       `json:"id" example:"9b2d4c6e8f0a1b3c5d7e9f1a2b3c4d5e"`
+	RiderID    string       `json:"rider_id" example:"rider-456"`
+	Status     string       `json:"status" example:"waiting"`
+	Position   int          `json:"position,omitempty" example:"2"`
+	Match      *MatchResult `json:"match,omitempty"`
+	EnqueuedAt time.Time    `json:"enqueued_at"`
+	ExpiresAt  time.Time    `json:"expires_at"`
+
+	Rider  Rider   `json:"-"`
+	Radius float64 `json:"-"`
+	Limit  int     `json:"-"`
+}
+
+func (e QueueEntry) MarshalJSON() ([]byte, error) {
+	type entry QueueEntry
+	return json.Marshal(struct {
+		entry
+		EnqueuedAt string `json:"enqueued_at"`
+		ExpiresAt  string `json:"expires_at"`
+	}{
+		entry:      entry(e),
+		EnqueuedAt: FormatTimestamp(e.EnqueuedAt),
+		ExpiresAt:  FormatTimestamp(e.ExpiresAt),
+	})
+}
+
+// Driver event types published by the driver location service
+const (
+	DriverCreated         = "driver.created"
+	DriverLocationUpdated = "driver.location_updated"
+	DriverDeleted         = "driver.deleted"
+	DriverWentOffline     = "driver.went_offline"
+	DriverStatusChanged   = "driver.status_changed"
+)
+
+// DriverEvent is a driver change published by the driver location service,
+// Location and Status are the state after the change
+type DriverEvent struct {
+	Type     string    `json:"type"`
+	DriverID string    `json:"driver_id"`
+	Location *Location `json:"location,omitempty"`
+	Status   string    `json:"status,omitempty"`
+}
+
+// MayFreeDriver reports whether the event may bring an available driver near
+// waiting riders, a driver going busy or offline never does
+func (e DriverEvent) MayFreeDriver() bool {
+	if e.Location == nil {
+		return false
+	}
+	switch e.Type {
+	case DriverCreated, DriverLocationUpdated:
+		return e.Status != "busy" && e.Status != "offline"
+	case DriverStatusChanged:
+		return e.Status == "available"
+	}
+	return false
+}
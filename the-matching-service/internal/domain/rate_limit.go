@@ -0,0 +1,18 @@
+package domain
+
+import "time"
+
+// RateLimit is a token bucket: a caller makes Rate requests per second on
+// average and up to Burst at once after being idle
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// RateLimitDecision tells whether a request fits the limit of its caller. A
+// refused caller gets a token back after RetryAfter.
+type RateLimitDecision struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+}
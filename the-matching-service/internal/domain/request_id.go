@@ -0,0 +1,17 @@
+package domain
+
+import "context"
+
+type requestIDKey struct{}
+
+// WithRequestID returns a context carrying the X-Request-ID of the request being
+// handled, calls to the driver location service made with it forward the ID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFrom returns the request ID the context carries, empty outside of a request
+func RequestIDFrom(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
@@ -1,15 +1,62 @@
 package domain
 
-// Location represents a GeoJSON Point location
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Location represents a GeoJSON Point location, coordinates are not required
+// because (0,0) is what clients without a fix send and the matching service
+// answers it with out_of_service_area
 // @Description GeoJSON Point location with longitude and latitude coordinates
 type Location struct {
 	Type        string     `json:"type" validate:"required,eq=Point" example:"Point" description:"GeoJSON type, must be 'Point'"`
-	Coordinates [2]float64 `json:"coordinates" validate:"required,len=2,coordinates" example:"28.9784,41.0082" description:"Array of [longitude, latitude] coordinates"`
+	Coordinates [2]float64 `json:"coordinates" validate:"len=2,coordinates" example:"28.9784,41.0082" description:"Array of [longitude, latitude] coordinates"`
+}
+
+// UnmarshalJSON rejects coordinates that are not a [longitude, latitude] pair,
+// encoding/json would fill the fixed array with zeros or drop the extra values
+func (l *Location) UnmarshalJSON(data []byte) error {
+	var location struct {
+		Type        string    `json:"type"`
+		Coordinates []float64 `json:"coordinates"`
+	}
+	if err := json.Unmarshal(data, &location); err != nil {
+		return err
+	}
+	if location.Coordinates != nil && len(location.Coordinates) != 2 {
+		return fmt.Errorf("location coordinates must be [longitude, latitude], got %d values", len(location.Coordinates))
+	}
+
+	l.Type = location.Type
+	copy(l.Coordinates[:], location.Coordinates)
+	return nil
 }
 
 type Rider struct {
-	ID       string   `json:"id"`
-	Location Location `json:"location" validate:"required"`
+	ID          string           `json:"id"`
+	Location    Location         `json:"location" validate:"required"`
+	Preferences RiderPreferences `json:"preferences"`
+	Pool        bool             `json:"pool,omitempty"`        // the rider accepts sharing the driver
+	Destination *Location        `json:"destination,omitempty"` // where a pooling rider goes
+}
+
+// RiderPreferences narrows the drivers a rider can be matched with, they are
+// passed to the driver location search and zero fields match every driver
+type RiderPreferences struct {
+	VehicleType string `json:"vehicle_type,omitempty"`
+	MinCapacity int    `json:"min_capacity,omitempty"`
+}
+
+// Accepts reports whether the driver fits the preferences, the driver location
+// service filters its search already and this guards against instances that
+// do not know the filter yet
+func (p RiderPreferences) Accepts(driver Driver) bool {
+	if p.VehicleType != "" && !strings.EqualFold(driver.VehicleType, p.VehicleType) {
+		return false
+	}
+	return driver.Capacity >= p.MinCapacity
 }
 
 // NewRider creates a new Rider with the given ID, name and location
@@ -36,25 +36,22 @@ func validateCoordinates(fl validator.FieldLevel) bool {
 	longitude := coordinates[0]
 	latitude := coordinates[1]
 
-	// Check longitude range (-180 to 180)
-	if longitude < -180 || longitude > 180 {
-		return false
-	}
-
-	// Check latitude range (-90 to 90)
-	if latitude < -90 || latitude > 90 {
-		return false
-	}
-
-	return true
+	// NaN fails every comparison, so the ranges are checked for what is valid
+	return longitude >= -180 && longitude <= 180 && latitude >= -90 && latitude <= 90
 }
 
+// MinRadius and MaxRadius bound the radius of a match in meters, MaxRadius is
+// the default SEARCH_MAX_RADIUS of the driver location service so every radius
+// accepted here, expanded ones included, is searched upstream
+const (
+	MinRadius = 0.1
+	MaxRadius = 50000
+)
+
 // validateRadius validates that radius is within reasonable bounds
 func validateRadius(fl validator.FieldLevel) bool {
 	radius := fl.Field().Float()
-
-	// Radius should be positive and reasonable (0.1 to 50000 meters)
-	return radius >= 0.1 && radius <= 50000
+	return radius >= MinRadius && radius <= MaxRadius
 }
 
 // ValidationError represents a validation error with field and message
@@ -68,11 +65,13 @@ type ValidationErrors struct {
 	Errors []ValidationError `json:"errors"`
 }
 
+// structValidator is shared, a validator caches the rules of every struct it
+// has seen and building one per call dominated the CPU time of large searches
+var structValidator = NewCustomValidator()
+
 // ValidateStruct validates a struct and returns formatted validation errors
 func ValidateStruct(s interface{}) error {
-	v := NewCustomValidator()
-
-	err := v.Validate(s)
+	err := structValidator.Validate(s)
 	if err != nil {
 		var validationErrors ValidationErrors
 
@@ -106,7 +105,7 @@ func getValidationMessage(err validator.FieldError) string {
 	case "coordinates":
 		return fmt.Sprintf("%s coordinates are invalid (longitude: -180 to 180, latitude: -90 to 90)", err.Field())
 	case "radius":
-		return fmt.Sprintf("%s must be between 0.1 and 50000 meters", err.Field())
+		return fmt.Sprintf("%s must be between %g and %g meters", err.Field(), MinRadius, float64(MaxRadius))
 	default:
 		return fmt.Sprintf("%s failed validation: %s", err.Field(), err.Tag())
 	}
@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -383,3 +384,42 @@ func TestLocation_GeoJSONCompliance(t *testing.T) {
 	assert.Equal(t, -73.856077, location.Coordinates[0])
 	assert.Equal(t, 40.848447, location.Coordinates[1])
 }
+
+// TestValidateStruct_Preferences tests validation of the vehicle type and minimum capacity of a match request
+// Expected: Should accept short vehicle types and capacities up to 100 and reject longer or negative ones
+func TestValidateStruct_Preferences(t *testing.T) {
+	req := &MatchRequest{
+		Location:    Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}},
+		Radius:      500.0,
+		VehicleType: "van",
+		MinCapacity: 6,
+	}
+	assert.NoError(t, ValidateStruct(req))
+
+	req.VehicleType = strings.Repeat("x", 33)
+	req.MinCapacity = -1
+	err := ValidateStruct(req)
+	validationErrors, ok := err.(*ValidationErrors)
+	assert.True(t, ok)
+	assert.Len(t, validationErrors.Errors, 2)
+}
+
+// TestValidateStruct_Pool tests match requests opting into pooling
+// Expected: Should require a valid destination when pool is set and accept requests without pooling as before
+func TestValidateStruct_Pool(t *testing.T) {
+	req := &MatchRequest{
+		Location: Location{Type: "Point", Coordinates: [2]float64{28.9, 41.0}},
+		Radius:   500.0,
+		Pool:     true,
+	}
+	assert.Error(t, ValidateStruct(req))
+
+	req.Destination = &Location{Type: "Point", Coordinates: [2]float64{29.0, 41.1}}
+	assert.NoError(t, ValidateStruct(req))
+
+	req.Destination = &Location{Type: "Point", Coordinates: [2]float64{200, 41.1}}
+	assert.Error(t, ValidateStruct(req))
+
+	req.Pool, req.Destination = false, nil
+	assert.NoError(t, ValidateStruct(req))
+}
@@ -0,0 +1,14 @@
+package secondary
+
+import (
+	"context"
+
+	"the-matching-service/internal/domain"
+)
+
+// Blocklist stores the rider and driver pairs that must never be matched
+type Blocklist interface {
+	BlockedDrivers(ctx context.Context, riderID string) ([]domain.BlockedPair, error)
+	Block(ctx context.Context, pair domain.BlockedPair) error
+	Unblock(ctx context.Context, riderID, driverID string) error
+}
@@ -0,0 +1,13 @@
+package secondary
+
+import (
+	"context"
+
+	"the-matching-service/internal/domain"
+)
+
+// DecisionLog appends the decisions of the match strategies to an append-only
+// stream, a new strategy is evaluated by replaying it
+type DecisionLog interface {
+	Append(ctx context.Context, event domain.DecisionEvent) error
+}
@@ -6,5 +6,5 @@ import (
 )
 
 type DriverLocationService interface {
-	FindNearbyDrivers(ctx context.Context, location domain.Location, radius float64) ([]domain.DriverDistancePair, error)
+	FindNearbyDrivers(ctx context.Context, location domain.Location, radius float64, limit int, preferences domain.RiderPreferences) ([]domain.DriverDistancePair, error)
 }
@@ -0,0 +1,12 @@
+package secondary
+
+import (
+	"context"
+
+	"the-matching-service/internal/domain"
+)
+
+// Geofence knows where the service operates, riders outside of it are not matched
+type Geofence interface {
+	Serves(ctx context.Context, location domain.Location) (bool, error)
+}
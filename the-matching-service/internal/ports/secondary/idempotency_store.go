@@ -0,0 +1,18 @@
+package secondary
+
+import (
+	"context"
+	"time"
+
+	"the-matching-service/internal/domain"
+)
+
+// IdempotencyStore keeps the records of idempotency keys for ttl. Claim stores
+// the record unless the key has one and returns the existing record otherwise,
+// so only one request runs per key. Complete replaces the record of a claimed key
+// and Release drops it so the request can be retried.
+type IdempotencyStore interface {
+	Claim(ctx context.Context, key string, record domain.IdempotencyRecord, ttl time.Duration) (existing *domain.IdempotencyRecord, claimed bool, err error)
+	Complete(ctx context.Context, key string, record domain.IdempotencyRecord, ttl time.Duration) error
+	Release(ctx context.Context, key string) error
+}
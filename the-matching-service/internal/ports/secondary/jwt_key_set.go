@@ -0,0 +1,11 @@
+package secondary
+
+import (
+	"context"
+	"crypto"
+)
+
+// JWTKeySet looks up the public key a JWT was signed with by the kid of its header
+type JWTKeySet interface {
+	Key(ctx context.Context, kid string) (crypto.PublicKey, error)
+}
@@ -0,0 +1,21 @@
+package secondary
+
+import (
+	"context"
+
+	"the-matching-service/internal/domain"
+)
+
+// MatchStore keeps the matches made by the service so riders can look them up later
+type MatchStore interface {
+	Save(ctx context.Context, result domain.MatchResult) error
+	// Get returns nil without an error when the match is unknown or expired
+	Get(ctx context.Context, id string) (*domain.MatchResult, error)
+	// ListByRider returns a page of the matches of the rider, the most recent
+	// first, and the number of stored matches of the rider
+	ListByRider(ctx context.Context, riderID string, offset, limit int) ([]domain.MatchResult, int64, error)
+	// Update applies change to the stored match and stores the result unless change
+	// fails, concurrent updates are retried so none of them is lost. Unknown or
+	// expired matches fail with domain.ErrMatchNotFound.
+	Update(ctx context.Context, id string, change func(result *domain.MatchResult) error) (*domain.MatchResult, error)
+}
@@ -0,0 +1,13 @@
+package secondary
+
+import (
+	"context"
+
+	"the-matching-service/internal/domain"
+)
+
+// OutcomeReporter reports what became of a match to the supply side, the driver
+// location service counts the outcomes of every driver
+type OutcomeReporter interface {
+	ReportOutcome(ctx context.Context, outcome domain.MatchOutcome) error
+}
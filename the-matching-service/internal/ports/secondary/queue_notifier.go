@@ -0,0 +1,12 @@
+package secondary
+
+import (
+	"context"
+
+	"the-matching-service/internal/domain"
+)
+
+// QueueNotifier tells riders that stopped watching that their wait ended
+type QueueNotifier interface {
+	Notify(ctx context.Context, entry domain.QueueEntry) error
+}
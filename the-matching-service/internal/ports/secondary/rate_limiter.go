@@ -0,0 +1,13 @@
+package secondary
+
+import (
+	"context"
+
+	"the-matching-service/internal/domain"
+)
+
+// RateLimiter takes a token from the bucket of key, refilled at limit.Rate up to
+// limit.Burst tokens
+type RateLimiter interface {
+	Allow(ctx context.Context, key string, limit domain.RateLimit) (domain.RateLimitDecision, error)
+}
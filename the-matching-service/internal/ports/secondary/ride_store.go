@@ -0,0 +1,21 @@
+package secondary
+
+import (
+	"context"
+
+	"the-matching-service/internal/domain"
+)
+
+// RideStore keeps the pooled rides in progress, one per driver
+type RideStore interface {
+	// ActiveRides returns the rides that did not expire yet
+	ActiveRides(ctx context.Context) ([]domain.PooledRide, error)
+	// Start replaces the ride of the driver
+	Start(ctx context.Context, ride domain.PooledRide) error
+	// Join adds the rider to the ride of the driver, false when the ride ended
+	// or another rider took the last seat first
+	Join(ctx context.Context, driverID, riderID string) (bool, error)
+	// Leave drops the rider from the ride of the driver, the ride ends with its
+	// last rider
+	Leave(ctx context.Context, driverID, riderID string) error
+}
@@ -0,0 +1,13 @@
+package secondary
+
+import (
+	"context"
+
+	"the-matching-service/internal/domain"
+)
+
+// RouteService plans the route a driver takes between two locations
+type RouteService interface {
+	// Route returns the route as a line from one location to the other, both included
+	Route(ctx context.Context, from, to domain.Location) ([]domain.Location, error)
+}
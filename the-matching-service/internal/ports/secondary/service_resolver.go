@@ -0,0 +1,10 @@
+package secondary
+
+import "context"
+
+// ServiceResolver resolves the base URL of an upstream service.
+// Invalidate is called after a failed call so the next Resolve looks the address up again.
+type ServiceResolver interface {
+	Resolve(ctx context.Context) (string, error)
+	Invalidate()
+}